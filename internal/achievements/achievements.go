@@ -0,0 +1,176 @@
+// Package achievements awards declarative achievements and XP. Plugins
+// describe their achievements as Definitions and emit named events with a
+// running value (an entry count, a streak length); the engine unlocks every
+// definition whose threshold the value reaches, exactly once per user.
+package achievements
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Definition describes one achievement. It unlocks the first time an Event
+// is emitted with a value of at least Threshold.
+type Definition struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Emoji       string `json:"emoji,omitempty"`
+	XP          int    `json:"xp"`
+	Event       string `json:"-"`
+	Threshold   int    `json:"threshold"`
+}
+
+// Unlock is published to OnUnlock subscribers when a user earns an
+// achievement.
+type Unlock struct {
+	AppID      string
+	UserID     uuid.UUID
+	Definition Definition
+	UnlockedAt time.Time
+}
+
+var (
+	hooksMu sync.RWMutex
+	hooks   []func(Unlock)
+)
+
+// OnUnlock registers fn to be called after each new unlock, e.g. to send a
+// push notification. Callbacks run synchronously on the emitting goroutine.
+func OnUnlock(fn func(Unlock)) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, fn)
+}
+
+func publish(u Unlock) {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	for _, fn := range hooks {
+		fn(u)
+	}
+}
+
+// Engine evaluates one plugin's definitions.
+type Engine struct {
+	db   *gorm.DB
+	defs []Definition
+}
+
+func New(db *gorm.DB, defs []Definition) *Engine {
+	return &Engine{db: db, defs: defs}
+}
+
+// Definitions returns the engine's achievement definitions.
+func (e *Engine) Definitions() []Definition { return e.defs }
+
+// Emit evaluates event with value for the user and returns the achievements
+// unlocked by it. Already-unlocked achievements are skipped, so emitting the
+// same event twice is harmless.
+func (e *Engine) Emit(appID string, userID uuid.UUID, event string, value int) ([]Definition, error) {
+	var unlocked []Definition
+	for _, def := range e.defs {
+		if def.Event != event || value < def.Threshold {
+			continue
+		}
+		ua := models.UserAchievement{
+			AppID:      appID,
+			UserID:     userID,
+			Key:        def.Key,
+			XP:         def.XP,
+			UnlockedAt: time.Now(),
+		}
+		result := e.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&ua)
+		if result.Error != nil {
+			return unlocked, fmt.Errorf("unlock %s: %w", def.Key, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		unlocked = append(unlocked, def)
+		slog.Info("achievement unlocked", "app_id", appID, "user_id", userID, "key", def.Key, "xp", def.XP)
+		publish(Unlock{AppID: appID, UserID: userID, Definition: def, UnlockedAt: ua.UnlockedAt})
+	}
+	return unlocked, nil
+}
+
+// EmitStreak emits "<kind>.count" with the streak's total and
+// "<kind>.streak" with its current length. Errors are logged, since
+// achievements never fail the activity that triggered them.
+func (e *Engine) EmitStreak(appID string, userID uuid.UUID, kind string, st *models.Streak) {
+	if _, err := e.Emit(appID, userID, kind+".count", st.TotalCount); err != nil {
+		slog.Warn("achievement evaluation failed", "app_id", appID, "user_id", userID, "event", kind+".count", "error", err)
+	}
+	if _, err := e.Emit(appID, userID, kind+".streak", st.CurrentStreak); err != nil {
+		slog.Warn("achievement evaluation failed", "app_id", appID, "user_id", userID, "event", kind+".streak", "error", err)
+	}
+}
+
+// Status is a definition with the user's progress on it.
+type Status struct {
+	Definition
+	Unlocked   bool       `json:"unlocked"`
+	UnlockedAt *time.Time `json:"unlocked_at,omitempty"`
+}
+
+// Summary is a user's XP, level and achievements in one app.
+type Summary struct {
+	XP           int      `json:"xp"`
+	Level        int      `json:"level"`
+	LevelXP      int      `json:"level_xp"`
+	NextLevelXP  int      `json:"next_level_xp"`
+	Achievements []Status `json:"achievements"`
+}
+
+// Summary returns the user's progress. XP counts every unlock on record,
+// including achievements whose definitions have since been removed.
+func (e *Engine) Summary(appID string, userID uuid.UUID) (*Summary, error) {
+	var rows []models.UserAchievement
+	if err := e.db.Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ?", userID).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	xp := 0
+	byKey := make(map[string]models.UserAchievement, len(rows))
+	for _, r := range rows {
+		xp += r.XP
+		byKey[r.Key] = r
+	}
+
+	sum := &Summary{XP: xp, Achievements: make([]Status, len(e.defs))}
+	sum.Level, sum.LevelXP, sum.NextLevelXP = Level(xp)
+	for i, def := range e.defs {
+		st := Status{Definition: def}
+		if r, ok := byKey[def.Key]; ok {
+			at := r.UnlockedAt
+			st.Unlocked = true
+			st.UnlockedAt = &at
+		}
+		sum.Achievements[i] = st
+	}
+	return sum, nil
+}
+
+// levelStep is the XP needed to go from level 1 to 2; each later level
+// needs levelStep more than the one before.
+const levelStep = 100
+
+// Level returns the level for xp, along with the XP at which that level
+// starts and the next one begins. Level n starts at levelStep*n*(n-1)/2.
+func Level(xp int) (level, from, next int) {
+	level = 1
+	for levelStep*(level+1)*level/2 <= xp {
+		level++
+	}
+	return level, levelStep * level * (level - 1) / 2, levelStep * (level + 1) * level / 2
+}
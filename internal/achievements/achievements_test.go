@@ -0,0 +1,40 @@
+package achievements
+
+import "testing"
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		xp, level, from, next int
+	}{
+		{-10, 1, 0, 100},
+		{0, 1, 0, 100},
+		{99, 1, 0, 100},
+		{100, 2, 100, 300},
+		{299, 2, 100, 300},
+		{300, 3, 300, 600},
+		{599, 3, 300, 600},
+		{600, 4, 600, 1000},
+		{4500, 10, 4500, 5500},
+	}
+	for _, tt := range tests {
+		level, from, next := Level(tt.xp)
+		if level != tt.level || from != tt.from || next != tt.next {
+			t.Errorf("Level(%d) = %d, %d, %d; want %d, %d, %d", tt.xp, level, from, next, tt.level, tt.from, tt.next)
+		}
+	}
+}
+
+func TestLevelThresholdsAreContiguous(t *testing.T) {
+	// Every level starts where the previous one ends.
+	prevLevel, _, prevNext := Level(0)
+	for xp := 1; xp <= 20000; xp++ {
+		level, from, next := Level(xp)
+		if level == prevLevel {
+			continue
+		}
+		if level != prevLevel+1 || from != prevNext || xp != from || next-from != levelStep*level {
+			t.Fatalf("Level(%d) = %d, %d, %d after level %d ending at %d", xp, level, from, next, prevLevel, prevNext)
+		}
+		prevLevel, prevNext = level, next
+	}
+}
@@ -0,0 +1,144 @@
+// Package apierr is the API's error type. Handlers and middleware return an
+// *Error and the app's error handler renders it as an ErrorResponse, so
+// every error body has the same shape and a stable code clients can switch
+// on.
+package apierr
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Error codes. Clients should branch on these, not on messages.
+const (
+	CodeBadRequest      = "bad_request"
+	CodeInvalidBody     = "invalid_body"
+	CodeValidation      = "validation_failed"
+	CodeUnauthorized    = "unauthorized"
+	CodePaymentRequired = "payment_required"
+	CodeForbidden       = "forbidden"
+	CodeNotFound        = "not_found"
+	CodeConflict        = "conflict"
+	CodeTooLarge        = "payload_too_large"
+	CodeUnprocessable   = "unprocessable"
+	CodeRateLimited     = "rate_limited"
+	CodeInternal        = "internal"
+	CodeUnavailable     = "unavailable"
+)
+
+// ErrorResponse is the body of every error response. Code is a stable,
+// machine-readable identifier such as "validation_failed"; Fields lists the
+// invalid request fields.
+type ErrorResponse struct {
+	Error   bool             `json:"error"`
+	Code    string           `json:"code,omitempty"`
+	Message string           `json:"message"`
+	Fields  []dto.FieldError `json:"fields,omitempty"`
+}
+
+// Error is an API error response.
+type Error struct {
+	Status int
+	Code   string
+	// Message is shown to the user as-is. When empty, the code's catalog
+	// message is used, localised to the request's language.
+	Message string
+	Fields  []dto.FieldError
+}
+
+// New returns an error with the code for status.
+func New(status int, message string) *Error {
+	return &Error{Status: status, Code: CodeFor(status), Message: message}
+}
+
+// Validation returns the error for a request with invalid fields.
+func Validation(fields []dto.FieldError) *Error {
+	return &Error{Status: fiber.StatusBadRequest, Code: CodeValidation, Fields: fields}
+}
+
+// WithCode sets a more specific code than the status's.
+func (e *Error) WithCode(code string) *Error {
+	e.Code = code
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return e.Code
+}
+
+// CodeFor returns the generic code for an HTTP status.
+func CodeFor(status int) string {
+	switch status {
+	case fiber.StatusBadRequest:
+		return CodeBadRequest
+	case fiber.StatusUnauthorized:
+		return CodeUnauthorized
+	case fiber.StatusPaymentRequired:
+		return CodePaymentRequired
+	case fiber.StatusForbidden:
+		return CodeForbidden
+	case fiber.StatusNotFound:
+		return CodeNotFound
+	case fiber.StatusConflict:
+		return CodeConflict
+	case fiber.StatusRequestEntityTooLarge:
+		return CodeTooLarge
+	case fiber.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case fiber.StatusTooManyRequests:
+		return CodeRateLimited
+	case fiber.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// From converts any handler error to an *Error: *Error is returned as is,
+// *fiber.Error keeps its status, and anything else is a 500. Only messages
+// written for users are kept: those of *Error, and of client-side
+// *fiber.Errors.
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		if fe.Code >= 500 {
+			return New(fe.Code, "")
+		}
+		return New(fe.Code, fe.Message)
+	}
+	return New(fiber.StatusInternalServerError, "")
+}
+
+// Response renders e in lang, a tag from Language.
+func (e *Error) Response(lang string) ErrorResponse {
+	message := e.Message
+	if message == "" {
+		message = Message(lang, e.Code, "", "")
+		if message == "" {
+			message = http.StatusText(e.Status)
+		}
+	}
+	var fields []dto.FieldError
+	if len(e.Fields) > 0 {
+		fields = make([]dto.FieldError, len(e.Fields))
+		for i, f := range e.Fields {
+			if f.Message == "" {
+				f.Message = Message(lang, f.Code, f.Field, f.Param)
+			}
+			fields[i] = f
+		}
+	}
+	return ErrorResponse{Error: true, Code: e.Code, Message: message, Fields: fields}
+}
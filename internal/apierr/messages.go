@@ -0,0 +1,279 @@
+package apierr
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Field error codes, set by the validation package.
+const (
+	FieldRequired         = "required"
+	FieldTooShort         = "too_short"
+	FieldTooLong          = "too_long"
+	FieldTooSmall         = "too_small"
+	FieldTooLarge         = "too_large"
+	FieldTooFew           = "too_few"
+	FieldTooMany          = "too_many"
+	FieldWrongLength      = "wrong_length"
+	FieldNotAllowed       = "not_allowed"
+	FieldInvalidEmail     = "invalid_email"
+	FieldInvalidURL       = "invalid_url"
+	FieldInsecureURL      = "insecure_url"
+	FieldInvalidUUID      = "invalid_uuid"
+	FieldInvalidLatitude  = "invalid_latitude"
+	FieldInvalidLongitude = "invalid_longitude"
+	FieldInvalidTimezone  = "invalid_timezone"
+	FieldInvalidLocale    = "invalid_locale"
+	FieldWeakPassword     = "weak_password"
+	FieldInvalid          = "invalid"
+)
+
+var supported = []language.Tag{
+	language.English, // first: the fallback
+	language.Spanish,
+	language.German,
+	language.French,
+	language.Portuguese,
+	language.Turkish,
+}
+
+var matcher = language.NewMatcher(supported)
+
+// Language picks the catalog language for an Accept-Language header.
+func Language(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return "en"
+	}
+	_, i, _ := matcher.Match(tags...)
+	base, _ := supported[i].Base()
+	return base.String()
+}
+
+// Message returns the catalog message for code in lang, with {field} and
+// {param} filled in, or "" for an unknown code. Missing translations fall
+// back to English.
+func Message(lang, code, field, param string) string {
+	msg, ok := catalog[lang][code]
+	if !ok {
+		msg, ok = catalog["en"][code]
+	}
+	if !ok {
+		return ""
+	}
+	return strings.NewReplacer("{field}", field, "{param}", param).Replace(msg)
+}
+
+var catalog = map[string]map[string]string{
+	"en": {
+		CodeBadRequest:      "The request is invalid.",
+		CodeInvalidBody:     "The request body could not be parsed.",
+		CodeValidation:      "Some fields are invalid.",
+		CodeUnauthorized:    "Authentication required.",
+		CodePaymentRequired: "A subscription is required.",
+		CodeForbidden:       "You don't have access to this.",
+		CodeNotFound:        "Not found.",
+		CodeConflict:        "The request conflicts with the current state.",
+		CodeTooLarge:        "The request is too large.",
+		CodeUnprocessable:   "The request could not be processed.",
+		CodeRateLimited:     "Too many requests. Please try again later.",
+		CodeInternal:        "Internal server error.",
+		CodeUnavailable:     "The service is temporarily unavailable.",
+
+		FieldRequired:         "{field} is required.",
+		FieldTooShort:         "{field} must be at least {param} characters.",
+		FieldTooLong:          "{field} must be at most {param} characters.",
+		FieldTooSmall:         "{field} must be at least {param}.",
+		FieldTooLarge:         "{field} must be at most {param}.",
+		FieldTooFew:           "{field} must have at least {param} items.",
+		FieldTooMany:          "{field} must have at most {param} items.",
+		FieldWrongLength:      "{field} must be exactly {param} long.",
+		FieldNotAllowed:       "{field} must be one of: {param}.",
+		FieldInvalidEmail:     "{field} must be a valid email address.",
+		FieldInvalidURL:       "{field} must be a valid URL.",
+		FieldInsecureURL:      "{field} must be an https:// URL.",
+		FieldInvalidUUID:      "{field} must be a valid ID.",
+		FieldInvalidLatitude:  "{field} must be between -90 and 90.",
+		FieldInvalidLongitude: "{field} must be between -180 and 180.",
+		FieldInvalidTimezone:  "{field} must be an IANA time zone such as Europe/Istanbul.",
+		FieldInvalidLocale:    "{field} must be a language tag such as en or pt-BR.",
+		FieldWeakPassword:     "{field} must be 8 to 72 characters with at least one uppercase letter and one digit.",
+		FieldInvalid:          "{field} is invalid.",
+	},
+	"es": {
+		CodeBadRequest:      "La solicitud no es válida.",
+		CodeInvalidBody:     "No se pudo leer el cuerpo de la solicitud.",
+		CodeValidation:      "Algunos campos no son válidos.",
+		CodeUnauthorized:    "Se requiere autenticación.",
+		CodePaymentRequired: "Se requiere una suscripción.",
+		CodeForbidden:       "No tienes acceso a esto.",
+		CodeNotFound:        "No encontrado.",
+		CodeConflict:        "La solicitud entra en conflicto con el estado actual.",
+		CodeTooLarge:        "La solicitud es demasiado grande.",
+		CodeUnprocessable:   "No se pudo procesar la solicitud.",
+		CodeRateLimited:     "Demasiadas solicitudes. Inténtalo de nuevo más tarde.",
+		CodeInternal:        "Error interno del servidor.",
+		CodeUnavailable:     "El servicio no está disponible temporalmente.",
+
+		FieldRequired:         "{field} es obligatorio.",
+		FieldTooShort:         "{field} debe tener al menos {param} caracteres.",
+		FieldTooLong:          "{field} debe tener como máximo {param} caracteres.",
+		FieldTooSmall:         "{field} debe ser al menos {param}.",
+		FieldTooLarge:         "{field} debe ser como máximo {param}.",
+		FieldTooFew:           "{field} debe tener al menos {param} elementos.",
+		FieldTooMany:          "{field} debe tener como máximo {param} elementos.",
+		FieldWrongLength:      "{field} debe tener exactamente {param} de longitud.",
+		FieldNotAllowed:       "{field} debe ser uno de: {param}.",
+		FieldInvalidEmail:     "{field} debe ser un correo electrónico válido.",
+		FieldInvalidURL:       "{field} debe ser una URL válida.",
+		FieldInsecureURL:      "{field} debe ser una URL https://.",
+		FieldInvalidUUID:      "{field} debe ser un ID válido.",
+		FieldInvalidLatitude:  "{field} debe estar entre -90 y 90.",
+		FieldInvalidLongitude: "{field} debe estar entre -180 y 180.",
+		FieldInvalidTimezone:  "{field} debe ser una zona horaria IANA, como Europe/Madrid.",
+		FieldInvalidLocale:    "{field} debe ser una etiqueta de idioma, como es o pt-BR.",
+		FieldWeakPassword:     "{field} debe tener entre 8 y 72 caracteres, con al menos una mayúscula y un dígito.",
+		FieldInvalid:          "{field} no es válido.",
+	},
+	"de": {
+		CodeBadRequest:      "Die Anfrage ist ungültig.",
+		CodeInvalidBody:     "Der Anfragetext konnte nicht gelesen werden.",
+		CodeValidation:      "Einige Felder sind ungültig.",
+		CodeUnauthorized:    "Anmeldung erforderlich.",
+		CodePaymentRequired: "Ein Abonnement ist erforderlich.",
+		CodeForbidden:       "Du hast keinen Zugriff darauf.",
+		CodeNotFound:        "Nicht gefunden.",
+		CodeConflict:        "Die Anfrage steht im Konflikt mit dem aktuellen Zustand.",
+		CodeTooLarge:        "Die Anfrage ist zu groß.",
+		CodeUnprocessable:   "Die Anfrage konnte nicht verarbeitet werden.",
+		CodeRateLimited:     "Zu viele Anfragen. Bitte versuche es später erneut.",
+		CodeInternal:        "Interner Serverfehler.",
+		CodeUnavailable:     "Der Dienst ist vorübergehend nicht verfügbar.",
+
+		FieldRequired:         "{field} ist erforderlich.",
+		FieldTooShort:         "{field} muss mindestens {param} Zeichen lang sein.",
+		FieldTooLong:          "{field} darf höchstens {param} Zeichen lang sein.",
+		FieldTooSmall:         "{field} muss mindestens {param} sein.",
+		FieldTooLarge:         "{field} darf höchstens {param} sein.",
+		FieldTooFew:           "{field} muss mindestens {param} Einträge haben.",
+		FieldTooMany:          "{field} darf höchstens {param} Einträge haben.",
+		FieldWrongLength:      "{field} muss genau {param} lang sein.",
+		FieldNotAllowed:       "{field} muss einer der folgenden Werte sein: {param}.",
+		FieldInvalidEmail:     "{field} muss eine gültige E-Mail-Adresse sein.",
+		FieldInvalidURL:       "{field} muss eine gültige URL sein.",
+		FieldInsecureURL:      "{field} muss eine https://-URL sein.",
+		FieldInvalidUUID:      "{field} muss eine gültige ID sein.",
+		FieldInvalidLatitude:  "{field} muss zwischen -90 und 90 liegen.",
+		FieldInvalidLongitude: "{field} muss zwischen -180 und 180 liegen.",
+		FieldInvalidTimezone:  "{field} muss eine IANA-Zeitzone wie Europe/Berlin sein.",
+		FieldInvalidLocale:    "{field} muss ein Sprach-Tag wie de oder pt-BR sein.",
+		FieldWeakPassword:     "{field} muss 8 bis 72 Zeichen lang sein und mindestens einen Großbuchstaben und eine Ziffer enthalten.",
+		FieldInvalid:          "{field} ist ungültig.",
+	},
+	"fr": {
+		CodeBadRequest:      "La requête est invalide.",
+		CodeInvalidBody:     "Le corps de la requête n'a pas pu être lu.",
+		CodeValidation:      "Certains champs sont invalides.",
+		CodeUnauthorized:    "Authentification requise.",
+		CodePaymentRequired: "Un abonnement est requis.",
+		CodeForbidden:       "Vous n'avez pas accès à cette ressource.",
+		CodeNotFound:        "Introuvable.",
+		CodeConflict:        "La requête est en conflit avec l'état actuel.",
+		CodeTooLarge:        "La requête est trop volumineuse.",
+		CodeUnprocessable:   "La requête n'a pas pu être traitée.",
+		CodeRateLimited:     "Trop de requêtes. Veuillez réessayer plus tard.",
+		CodeInternal:        "Erreur interne du serveur.",
+		CodeUnavailable:     "Le service est temporairement indisponible.",
+
+		FieldRequired:         "{field} est obligatoire.",
+		FieldTooShort:         "{field} doit contenir au moins {param} caractères.",
+		FieldTooLong:          "{field} doit contenir au plus {param} caractères.",
+		FieldTooSmall:         "{field} doit être au moins {param}.",
+		FieldTooLarge:         "{field} doit être au plus {param}.",
+		FieldTooFew:           "{field} doit contenir au moins {param} éléments.",
+		FieldTooMany:          "{field} doit contenir au plus {param} éléments.",
+		FieldWrongLength:      "{field} doit avoir une longueur de {param}.",
+		FieldNotAllowed:       "{field} doit être l'une des valeurs : {param}.",
+		FieldInvalidEmail:     "{field} doit être une adresse e-mail valide.",
+		FieldInvalidURL:       "{field} doit être une URL valide.",
+		FieldInsecureURL:      "{field} doit être une URL https://.",
+		FieldInvalidUUID:      "{field} doit être un identifiant valide.",
+		FieldInvalidLatitude:  "{field} doit être compris entre -90 et 90.",
+		FieldInvalidLongitude: "{field} doit être compris entre -180 et 180.",
+		FieldInvalidTimezone:  "{field} doit être un fuseau horaire IANA comme Europe/Paris.",
+		FieldInvalidLocale:    "{field} doit être une balise de langue comme fr ou pt-BR.",
+		FieldWeakPassword:     "{field} doit contenir de 8 à 72 caractères, dont au moins une majuscule et un chiffre.",
+		FieldInvalid:          "{field} est invalide.",
+	},
+	"pt": {
+		CodeBadRequest:      "A solicitação é inválida.",
+		CodeInvalidBody:     "Não foi possível ler o corpo da solicitação.",
+		CodeValidation:      "Alguns campos são inválidos.",
+		CodeUnauthorized:    "Autenticação necessária.",
+		CodePaymentRequired: "É necessária uma assinatura.",
+		CodeForbidden:       "Você não tem acesso a isso.",
+		CodeNotFound:        "Não encontrado.",
+		CodeConflict:        "A solicitação conflita com o estado atual.",
+		CodeTooLarge:        "A solicitação é grande demais.",
+		CodeUnprocessable:   "Não foi possível processar a solicitação.",
+		CodeRateLimited:     "Muitas solicitações. Tente novamente mais tarde.",
+		CodeInternal:        "Erro interno do servidor.",
+		CodeUnavailable:     "O serviço está temporariamente indisponível.",
+
+		FieldRequired:         "{field} é obrigatório.",
+		FieldTooShort:         "{field} deve ter pelo menos {param} caracteres.",
+		FieldTooLong:          "{field} deve ter no máximo {param} caracteres.",
+		FieldTooSmall:         "{field} deve ser pelo menos {param}.",
+		FieldTooLarge:         "{field} deve ser no máximo {param}.",
+		FieldTooFew:           "{field} deve ter pelo menos {param} itens.",
+		FieldTooMany:          "{field} deve ter no máximo {param} itens.",
+		FieldWrongLength:      "{field} deve ter exatamente {param} de comprimento.",
+		FieldNotAllowed:       "{field} deve ser um de: {param}.",
+		FieldInvalidEmail:     "{field} deve ser um e-mail válido.",
+		FieldInvalidURL:       "{field} deve ser uma URL válida.",
+		FieldInsecureURL:      "{field} deve ser uma URL https://.",
+		FieldInvalidUUID:      "{field} deve ser um ID válido.",
+		FieldInvalidLatitude:  "{field} deve estar entre -90 e 90.",
+		FieldInvalidLongitude: "{field} deve estar entre -180 e 180.",
+		FieldInvalidTimezone:  "{field} deve ser um fuso horário IANA, como America/Sao_Paulo.",
+		FieldInvalidLocale:    "{field} deve ser uma tag de idioma, como pt ou pt-BR.",
+		FieldWeakPassword:     "{field} deve ter de 8 a 72 caracteres, com pelo menos uma letra maiúscula e um dígito.",
+		FieldInvalid:          "{field} é inválido.",
+	},
+	"tr": {
+		CodeBadRequest:      "İstek geçersiz.",
+		CodeInvalidBody:     "İstek gövdesi okunamadı.",
+		CodeValidation:      "Bazı alanlar geçersiz.",
+		CodeUnauthorized:    "Kimlik doğrulaması gerekli.",
+		CodePaymentRequired: "Abonelik gerekli.",
+		CodeForbidden:       "Buna erişim izniniz yok.",
+		CodeNotFound:        "Bulunamadı.",
+		CodeConflict:        "İstek mevcut durumla çakışıyor.",
+		CodeTooLarge:        "İstek çok büyük.",
+		CodeUnprocessable:   "İstek işlenemedi.",
+		CodeRateLimited:     "Çok fazla istek. Lütfen daha sonra tekrar deneyin.",
+		CodeInternal:        "Sunucu hatası.",
+		CodeUnavailable:     "Hizmet geçici olarak kullanılamıyor.",
+
+		FieldRequired:         "{field} zorunludur.",
+		FieldTooShort:         "{field} en az {param} karakter olmalıdır.",
+		FieldTooLong:          "{field} en fazla {param} karakter olmalıdır.",
+		FieldTooSmall:         "{field} en az {param} olmalıdır.",
+		FieldTooLarge:         "{field} en fazla {param} olmalıdır.",
+		FieldTooFew:           "{field} en az {param} öğe içermelidir.",
+		FieldTooMany:          "{field} en fazla {param} öğe içermelidir.",
+		FieldWrongLength:      "{field} tam olarak {param} uzunluğunda olmalıdır.",
+		FieldNotAllowed:       "{field} şunlardan biri olmalıdır: {param}.",
+		FieldInvalidEmail:     "{field} geçerli bir e-posta adresi olmalıdır.",
+		FieldInvalidURL:       "{field} geçerli bir URL olmalıdır.",
+		FieldInsecureURL:      "{field} https:// ile başlayan bir URL olmalıdır.",
+		FieldInvalidUUID:      "{field} geçerli bir kimlik olmalıdır.",
+		FieldInvalidLatitude:  "{field} -90 ile 90 arasında olmalıdır.",
+		FieldInvalidLongitude: "{field} -180 ile 180 arasında olmalıdır.",
+		FieldInvalidTimezone:  "{field} Europe/Istanbul gibi bir IANA saat dilimi olmalıdır.",
+		FieldInvalidLocale:    "{field} tr veya pt-BR gibi bir dil etiketi olmalıdır.",
+		FieldWeakPassword:     "{field} 8 ile 72 karakter arasında olmalı, en az bir büyük harf ve bir rakam içermelidir.",
+		FieldInvalid:          "{field} geçersiz.",
+	},
+}
@@ -6,7 +6,10 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -28,13 +31,13 @@ func (h *AuraHandler) CheckScanEligibility(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid user ID"})
+		return apierr.New(fiber.StatusBadRequest, "Invalid user ID")
 	}
 
 	isSubscribed := h.auraService.IsSubscribed(appID, userID)
 	allowed, remaining, err := h.auraService.CanScan(appID, userID, isSubscribed)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to check eligibility"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to check eligibility")
 	}
 
 	return c.JSON(ScanEligibilityResponse{CanScan: allowed, Remaining: remaining, IsSubscribed: isSubscribed})
@@ -44,33 +47,33 @@ func (h *AuraHandler) Scan(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid user ID"})
+		return apierr.New(fiber.StatusBadRequest, "Invalid user ID")
 	}
 
 	isSubscribed := h.auraService.IsSubscribed(appID, userID)
 	allowed, _, err := h.auraService.CanScan(appID, userID, isSubscribed)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to verify scan eligibility"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to verify scan eligibility")
 	}
 	if !allowed {
-		return c.Status(429).JSON(fiber.Map{"error": true, "message": "Daily scan limit reached. Upgrade to Premium for unlimited scans."})
+		return apierr.New(429, "Daily scan limit reached. Upgrade to Premium for unlimited scans.")
 	}
 
 	var req CreateAuraRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid request body"})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	if req.ImageData != "" && len(req.ImageData) > 3*1024*1024 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Image data too large. Maximum 3MB base64."})
+		return apierr.New(fiber.StatusBadRequest, "Image data too large. Maximum 3MB base64.")
 	}
 	if req.ImageData == "" && req.ImageURL == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Either image_data or image_url is required"})
+		return apierr.New(fiber.StatusBadRequest, "Either image_data or image_url is required")
 	}
 
 	reading, err := h.auraService.Create(appID, userID, req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": err.Error()})
+		return apierr.New(fiber.StatusInternalServerError, err.Error())
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(reading)
@@ -80,41 +83,41 @@ func (h *AuraHandler) ScanWithUpload(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid user ID"})
+		return apierr.New(fiber.StatusBadRequest, "Invalid user ID")
 	}
 
 	isSubscribed := h.auraService.IsSubscribed(appID, userID)
 	allowed, _, err := h.auraService.CanScan(appID, userID, isSubscribed)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to verify scan eligibility"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to verify scan eligibility")
 	}
 	if !allowed {
-		return c.Status(429).JSON(fiber.Map{"error": true, "message": "Daily scan limit reached. Upgrade to Premium for unlimited scans."})
+		return apierr.New(429, "Daily scan limit reached. Upgrade to Premium for unlimited scans.")
 	}
 
 	file, err := c.FormFile("image")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Image file is required"})
+		return apierr.New(fiber.StatusBadRequest, "Image file is required")
 	}
 
 	contentType := file.Header.Get("Content-Type")
 	if !strings.HasPrefix(contentType, "image/jpeg") && !strings.HasPrefix(contentType, "image/png") {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Only JPEG and PNG images are supported"})
+		return apierr.New(fiber.StatusBadRequest, "Only JPEG and PNG images are supported")
 	}
 
 	if file.Size > 4*1024*1024 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Image too large. Maximum 4MB."})
+		return apierr.New(fiber.StatusBadRequest, "Image too large. Maximum 4MB.")
 	}
 
 	f, err := file.Open()
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to read image"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to read image")
 	}
 	defer f.Close()
 
 	fileBytes, err := io.ReadAll(f)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to read image data"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to read image data")
 	}
 
 	b64Data := base64.StdEncoding.EncodeToString(fileBytes)
@@ -122,7 +125,7 @@ func (h *AuraHandler) ScanWithUpload(c *fiber.Ctx) error {
 
 	reading, err := h.auraService.Create(appID, userID, req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": err.Error()})
+		return apierr.New(fiber.StatusInternalServerError, err.Error())
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(reading)
@@ -132,17 +135,17 @@ func (h *AuraHandler) GetByID(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid user ID"})
+		return apierr.New(fiber.StatusBadRequest, "Invalid user ID")
 	}
 
 	readingID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid reading ID"})
+		return apierr.New(fiber.StatusBadRequest, "Invalid reading ID")
 	}
 
 	reading, err := h.auraService.GetByID(appID, userID, readingID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": true, "message": "Reading not found"})
+		return apierr.New(fiber.StatusNotFound, "Reading not found")
 	}
 
 	return c.JSON(reading)
@@ -152,7 +155,7 @@ func (h *AuraHandler) List(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid user ID"})
+		return apierr.New(fiber.StatusBadRequest, "Invalid user ID")
 	}
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
@@ -166,7 +169,7 @@ func (h *AuraHandler) List(c *fiber.Ctx) error {
 
 	readings, total, err := h.auraService.List(appID, userID, page, pageSize)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to fetch readings"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch readings")
 	}
 
 	items := make([]AuraReadingResponse, 0, len(readings))
@@ -188,12 +191,12 @@ func (h *AuraHandler) Stats(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid user ID"})
+		return apierr.New(fiber.StatusBadRequest, "Invalid user ID")
 	}
 
 	stats, err := h.auraService.GetStats(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to fetch stats"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch stats")
 	}
 
 	return c.JSON(stats)
@@ -215,17 +218,21 @@ func (h *AuraMatchHandler) CreateMatch(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": true, "message": "Invalid user ID"})
+		return apierr.New(fiber.StatusUnauthorized, "Invalid user ID")
 	}
 
 	var req CreateMatchRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid request body"})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
-	match, err := h.matchService.Create(appID, userID, req)
+	blocks, err := services.RequestBlockSet(c, h.matchService.db, appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": err.Error()})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load block list")
+	}
+	match, err := h.matchService.Create(appID, userID, blocks, req)
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(match)
@@ -235,12 +242,16 @@ func (h *AuraMatchHandler) GetMatches(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": true, "message": "Invalid user ID"})
+		return apierr.New(fiber.StatusUnauthorized, "Invalid user ID")
 	}
 
-	matches, err := h.matchService.List(appID, userID)
+	blocks, err := services.RequestBlockSet(c, h.matchService.db, appID, userID)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load block list")
+	}
+	matches, err := h.matchService.List(appID, userID, blocks)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to fetch matches"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch matches")
 	}
 
 	return c.JSON(fiber.Map{"data": matches})
@@ -250,20 +261,24 @@ func (h *AuraMatchHandler) GetMatchByFriend(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": true, "message": "Invalid user ID"})
+		return apierr.New(fiber.StatusUnauthorized, "Invalid user ID")
 	}
 
 	friendID, err := uuid.Parse(c.Params("friend_id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid friend ID"})
+		return apierr.New(fiber.StatusBadRequest, "Invalid friend ID")
 	}
 
-	match, err := h.matchService.GetByFriend(appID, userID, friendID)
+	blocks, err := services.RequestBlockSet(c, h.matchService.db, appID, userID)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load block list")
+	}
+	match, err := h.matchService.GetByFriend(appID, userID, blocks, friendID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": true, "message": "No match found with this friend"})
+			return apierr.New(fiber.StatusNotFound, "No match found with this friend")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to fetch match"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch match")
 	}
 
 	return c.JSON(match)
@@ -285,12 +300,12 @@ func (h *StreakHandler) GetStreak(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": true, "message": "Invalid user ID"})
+		return apierr.New(fiber.StatusUnauthorized, "Invalid user ID")
 	}
 
 	streak, err := h.streakService.Get(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to fetch streak"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch streak")
 	}
 
 	return c.JSON(streak)
@@ -300,12 +315,12 @@ func (h *StreakHandler) UpdateStreak(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": true, "message": "Invalid user ID"})
+		return apierr.New(fiber.StatusUnauthorized, "Invalid user ID")
 	}
 
 	result, err := h.streakService.Update(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to update streak"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to update streak")
 	}
 
 	return c.JSON(result)
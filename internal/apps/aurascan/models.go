@@ -51,24 +51,6 @@ func (AuraMatch) TableName() string {
 	return "aura_matches"
 }
 
-// AuraStreak tracks daily scanning streaks and unlocked colors.
-type AuraStreak struct {
-	ID             uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	AppID          string    `gorm:"size:50;not null;uniqueIndex:idx_aura_streak_app_user" json:"app_id"`
-	UserID         uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_aura_streak_app_user" json:"user_id"`
-	CurrentStreak  int       `gorm:"type:integer;default:0" json:"current_streak"`
-	LongestStreak  int       `gorm:"type:integer;default:0" json:"longest_streak"`
-	TotalScans     int       `gorm:"type:integer;default:0" json:"total_scans"`
-	LastScanDate   time.Time `gorm:"type:date" json:"last_scan_date"`
-	UnlockedColors []string  `gorm:"type:jsonb;serializer:json;default:'[]'" json:"unlocked_colors"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
-}
-
-func (AuraStreak) TableName() string {
-	return "aura_streaks"
-}
-
 // --- DTOs ---
 
 type CreateAuraRequest struct {
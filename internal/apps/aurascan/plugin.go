@@ -18,7 +18,6 @@ func (p *AuraScanPlugin) Models() []interface{} {
 	return []interface{}{
 		&AuraReading{},
 		&AuraMatch{},
-		&AuraStreak{},
 	}
 }
 
@@ -15,7 +15,10 @@ import (
 	"time"
 
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/localtime"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -490,7 +493,7 @@ var adviceMessages = map[string]string{
 	"challenging":   "Practice patience and active listening.",
 }
 
-func (s *AuraMatchService) Create(appID string, userID uuid.UUID, req CreateMatchRequest) (*AuraMatchResponse, error) {
+func (s *AuraMatchService) Create(appID string, userID uuid.UUID, blocks services.BlockSet, req CreateMatchRequest) (*AuraMatchResponse, error) {
 	friendID, err := uuid.Parse(req.FriendID)
 	if err != nil {
 		return nil, errors.New("invalid friend ID")
@@ -498,6 +501,9 @@ func (s *AuraMatchService) Create(appID string, userID uuid.UUID, req CreateMatc
 	if userID == friendID {
 		return nil, errors.New("cannot match with yourself")
 	}
+	if blocks.Contains(friendID) {
+		return nil, services.ErrUserBlocked
+	}
 
 	var userAura AuraReading
 	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).Order("created_at DESC").First(&userAura).Error; err != nil {
@@ -568,9 +574,9 @@ func (s *AuraMatchService) calculateCompatibility(userColor, friendColor string)
 	return score, synergy, tension, advice
 }
 
-func (s *AuraMatchService) List(appID string, userID uuid.UUID) ([]AuraMatchResponse, error) {
+func (s *AuraMatchService) List(appID string, userID uuid.UUID, blocks services.BlockSet) ([]AuraMatchResponse, error) {
 	var matches []AuraMatch
-	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).Order("created_at DESC").Find(&matches).Error; err != nil {
+	if err := s.db.Scopes(tenant.ForTenant(appID), blocks.Exclude("friend_id")).Where("user_id = ?", userID).Order("created_at DESC").Find(&matches).Error; err != nil {
 		return nil, err
 	}
 
@@ -593,7 +599,11 @@ func (s *AuraMatchService) List(appID string, userID uuid.UUID) ([]AuraMatchResp
 	return responses, nil
 }
 
-func (s *AuraMatchService) GetByFriend(appID string, userID, friendID uuid.UUID) (*AuraMatchResponse, error) {
+func (s *AuraMatchService) GetByFriend(appID string, userID uuid.UUID, blocks services.BlockSet, friendID uuid.UUID) (*AuraMatchResponse, error) {
+	if blocks.Contains(friendID) {
+		return nil, gorm.ErrRecordNotFound
+	}
+
 	var match AuraMatch
 	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ? AND friend_id = ?", userID, friendID).
 		Order("created_at DESC").First(&match).Error; err != nil {
@@ -619,156 +629,79 @@ func (s *AuraMatchService) GetByFriend(appID string, userID, friendID uuid.UUID)
 // =============================================================================
 
 type StreakService struct {
-	db *gorm.DB
+	db      *gorm.DB
+	streaks *streaks.Engine
 }
 
 func NewStreakService(db *gorm.DB) *StreakService {
-	return &StreakService{db: db}
-}
-
-var streakUnlocks = map[int]string{
-	3: "silver", 7: "gold", 14: "white", 21: "rainbow", 30: "cosmic", 50: "celestial",
-}
-
-func (s *StreakService) GetOrCreate(appID string, userID uuid.UUID) (*AuraStreak, error) {
-	var streak AuraStreak
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error
-	if err == gorm.ErrRecordNotFound {
-		streak = AuraStreak{
-			AppID:          appID,
-			UserID:         userID,
-			UnlockedColors: []string{},
-		}
-		if err := s.db.Create(&streak).Error; err != nil {
-			return nil, err
-		}
-		return &streak, nil
-	}
-	if err != nil {
-		return nil, err
-	}
-	return &streak, nil
+	return &StreakService{db: db, streaks: newAuraStreaks(db)}
 }
 
 func (s *StreakService) Get(appID string, userID uuid.UUID) (*StreakResponse, error) {
-	streak, err := s.GetOrCreate(appID, userID)
+	streak, err := s.streaks.Get(appID, userID)
 	if err != nil {
 		return nil, err
 	}
-
-	resp := &StreakResponse{
-		ID: streak.ID, UserID: streak.UserID,
-		CurrentStreak: streak.CurrentStreak, LongestStreak: streak.LongestStreak,
-		TotalScans: streak.TotalScans, LastScanDate: streak.LastScanDate,
-		UnlockedColors: streak.UnlockedColors,
-	}
-
-	for days, color := range streakUnlocks {
-		if streak.CurrentStreak < days {
-			resp.NextUnlock = color
-			resp.DaysUntilUnlock = days - streak.CurrentStreak
-			break
-		}
-	}
-
-	return resp, nil
+	return s.response(streak), nil
 }
 
 func (s *StreakService) Update(appID string, userID uuid.UUID) (*StreakUpdateResponse, error) {
-	streak, err := s.GetOrCreate(appID, userID)
+	loc := localtime.ForUser(s.db, appID, userID)
+	streak, err := s.streaks.Get(appID, userID)
 	if err != nil {
 		return nil, err
 	}
-
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	lastScan := time.Date(streak.LastScanDate.Year(), streak.LastScanDate.Month(), streak.LastScanDate.Day(), 0, 0, 0, 0, streak.LastScanDate.Location())
-
-	streakBroken := false
-	message := ""
-	var newUnlock string
-
-	if !streak.LastScanDate.IsZero() && lastScan.Equal(today) {
-		message = "You've already scanned today! Come back tomorrow."
+	if streak.LastActiveDay != nil && streak.LastActiveDay.Equal(localtime.Today(loc)) {
 		return &StreakUpdateResponse{
-			Streak: StreakResponse{
-				ID: streak.ID, UserID: streak.UserID,
-				CurrentStreak: streak.CurrentStreak, LongestStreak: streak.LongestStreak,
-				TotalScans: streak.TotalScans, LastScanDate: streak.LastScanDate,
-				UnlockedColors: streak.UnlockedColors,
-			},
-			Message: message,
+			Streak:  *s.response(streak),
+			Message: "You've already scanned today! Come back tomorrow.",
 		}, nil
 	}
 
-	yesterday := today.AddDate(0, 0, -1)
-
-	if streak.LastScanDate.IsZero() {
-		streak.CurrentStreak = 1
-		message = "Your aura journey begins! Day 1 streak started."
-	} else if lastScan.Equal(yesterday) {
-		streak.CurrentStreak++
-		message = fmt.Sprintf("%d day streak! Keep going!", streak.CurrentStreak)
-	} else {
-		if streak.CurrentStreak > 0 {
-			streakBroken = true
-			message = fmt.Sprintf("New beginning! Your previous streak was %d days. Let's start fresh!", streak.CurrentStreak)
-		} else {
-			message = "Your aura journey begins!"
-		}
-		streak.CurrentStreak = 1
-	}
-
-	if streak.CurrentStreak > streak.LongestStreak {
-		streak.LongestStreak = streak.CurrentStreak
+	res, err := s.streaks.RecordIn(appID, userID, time.Now(), loc)
+	if err != nil {
+		return nil, err
 	}
 
-	streak.TotalScans++
-	streak.LastScanDate = now
-
-	for days, color := range streakUnlocks {
-		if streak.CurrentStreak == days {
-			newUnlock = color
-			if !containsStr(streak.UnlockedColors, color) {
-				streak.UnlockedColors = append(streak.UnlockedColors, color)
-				message = message + " You unlocked the " + color + " aura!"
-			}
-			break
-		}
+	var message string
+	switch {
+	case res.Broken && res.Previous > 0:
+		message = fmt.Sprintf("New beginning! Your previous streak was %d days. Let's start fresh!", res.Previous)
+	case res.Previous == 0 && res.Streak.TotalCount == 1:
+		message = "Your aura journey begins! Day 1 streak started."
+	case res.Previous == 0:
+		message = "Your aura journey begins!"
+	default:
+		message = fmt.Sprintf("%d day streak! Keep going!", res.Streak.CurrentStreak)
 	}
 
-	if err := s.db.Save(streak).Error; err != nil {
-		return nil, err
+	var newUnlock string
+	for _, m := range res.Unlocked {
+		newUnlock = m.Key
+		message = message + " You unlocked the " + m.Key + " aura!"
 	}
 
-	resp := &StreakUpdateResponse{
-		Streak: StreakResponse{
-			ID: streak.ID, UserID: streak.UserID,
-			CurrentStreak: streak.CurrentStreak, LongestStreak: streak.LongestStreak,
-			TotalScans: streak.TotalScans, LastScanDate: streak.LastScanDate,
-			UnlockedColors: streak.UnlockedColors,
-		},
+	return &StreakUpdateResponse{
+		Streak:       *s.response(res.Streak),
 		NewUnlock:    newUnlock,
-		StreakBroken: streakBroken,
+		StreakBroken: res.Broken,
 		Message:      message,
-	}
-
-	for days, color := range streakUnlocks {
-		if streak.CurrentStreak < days {
-			resp.Streak.NextUnlock = color
-			resp.Streak.DaysUntilUnlock = days - streak.CurrentStreak
-			break
-		}
-	}
-
-	return resp, nil
+	}, nil
 }
 
-func containsStr(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
-		}
+func (s *StreakService) response(streak *models.Streak) *StreakResponse {
+	resp := &StreakResponse{
+		ID: streak.ID, UserID: streak.UserID,
+		CurrentStreak: streak.CurrentStreak, LongestStreak: streak.LongestStreak,
+		TotalScans:     streak.TotalCount,
+		UnlockedColors: streak.UnlockedBadges,
+	}
+	if streak.LastActivityAt != nil {
+		resp.LastScanDate = *streak.LastActivityAt
+	}
+	if next := s.streaks.NextMilestone(streak); next != nil {
+		resp.NextUnlock = next.Key
+		resp.DaysUntilUnlock = next.Required - streak.CurrentStreak
 	}
-	return false
+	return resp
 }
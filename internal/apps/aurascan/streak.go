@@ -0,0 +1,53 @@
+package aurascan
+
+import (
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// auraStreakPolicy counts consecutive scanning days; each milestone unlocks
+// an aura color, keyed by the color name.
+var auraStreakPolicy = streaks.Policy{
+	MaxGap: 1,
+	Milestones: []streaks.Milestone{
+		{Key: "silver", Name: "Silver Aura", Required: 3},
+		{Key: "gold", Name: "Gold Aura", Required: 7},
+		{Key: "white", Name: "White Aura", Required: 14},
+		{Key: "rainbow", Name: "Rainbow Aura", Required: 21},
+		{Key: "cosmic", Name: "Cosmic Aura", Required: 30},
+		{Key: "celestial", Name: "Celestial Aura", Required: 50},
+	},
+}
+
+func newAuraStreaks(db *gorm.DB) *streaks.Engine {
+	return streaks.New(db, "scan", auraStreakPolicy)
+}
+
+// Migrate implements apps.MigrationPlugin, carrying aura_streaks rows over to
+// the shared streaks table.
+func (p *AuraScanPlugin) Migrate(db *gorm.DB) error {
+	_, err := newAuraStreaks(db).ImportLegacy(streaks.Legacy{
+		Table:      "aura_streaks",
+		Total:      "total_scans",
+		LastActive: "NULLIF(last_scan_date, '0001-01-01')",
+		Badges:     "unlocked_colors",
+	})
+	return err
+}
+
+// RecomputeStreaks implements services.StreakRecomputer, rebuilding the scan
+// streak from reading times bucketed by local day in loc.
+func (p *AuraScanPlugin) RecomputeStreaks(db *gorm.DB, appID string, userID uuid.UUID, loc *time.Location) error {
+	var times []time.Time
+	if err := db.Model(&AuraReading{}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ?", userID).
+		Pluck("created_at", &times).Error; err != nil {
+		return err
+	}
+	return newAuraStreaks(db).Recompute(appID, userID, loc, times)
+}
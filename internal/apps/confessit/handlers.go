@@ -1,7 +1,10 @@
 package confessit
 
 import (
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -30,23 +33,34 @@ type ReactRequest struct {
 	Emoji string `json:"emoji"`
 }
 
+// viewer returns the signed-in user, or uuid.Nil for anonymous readers, and
+// the users hidden from them by blocks.
+func (h *ConfessionHandler) viewer(c *fiber.Ctx) (uuid.UUID, services.BlockSet, error) {
+	id, err := tenant.GetUserID(c)
+	if err != nil {
+		id = uuid.Nil
+	}
+	blocks, err := services.RequestBlockSet(c, h.service.db, tenant.GetAppID(c), id)
+	return id, blocks, err
+}
+
 // --- Protected handlers (require JWT) ---
 
 func (h *ConfessionHandler) Create(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": true, "message": "Unauthorized"})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	var req CreateConfessionRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid request"})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	confession, err := h.service.CreateConfession(appID, userID, req.Content, req.Category, req.Mood)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": err.Error()})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(confession)
@@ -64,9 +78,13 @@ func (h *ConfessionHandler) GetFeed(c *fiber.Ctx) error {
 		limit = 20
 	}
 
-	confessions, total, err := h.service.GetFeed(appID, page, limit)
+	viewer, blocks, err := h.viewer(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to fetch confessions"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load block list")
+	}
+	confessions, total, err := h.service.GetFeed(appID, viewer, blocks, page, limit)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch confessions")
 	}
 
 	return c.JSON(fiber.Map{
@@ -95,9 +113,13 @@ func (h *ConfessionHandler) GetByCategory(c *fiber.Ctx) error {
 		limit = 20
 	}
 
-	confessions, total, err := h.service.GetByCategory(appID, category, page, limit)
+	viewer, blocks, err := h.viewer(c)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load block list")
+	}
+	confessions, total, err := h.service.GetByCategory(appID, viewer, blocks, category, page, limit)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to fetch confessions"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch confessions")
 	}
 
 	return c.JSON(fiber.Map{
@@ -117,16 +139,16 @@ func (h *ConfessionHandler) Like(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": true, "message": "Unauthorized"})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	confessionID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid confession ID"})
+		return apierr.New(fiber.StatusBadRequest, "Invalid confession ID")
 	}
 
 	if err := h.service.LikeConfession(appID, userID, confessionID); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to like confession"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to like confession")
 	}
 
 	return c.JSON(fiber.Map{"success": true})
@@ -136,22 +158,26 @@ func (h *ConfessionHandler) AddComment(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": true, "message": "Unauthorized"})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	confessionID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid confession ID"})
+		return apierr.New(fiber.StatusBadRequest, "Invalid confession ID")
 	}
 
 	var req AddCommentRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid request"})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
-	comment, err := h.service.AddComment(appID, userID, confessionID, req.Content)
+	blocks, err := services.RequestBlockSet(c, h.service.db, appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": err.Error()})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load block list")
+	}
+	comment, err := h.service.AddComment(appID, userID, blocks, confessionID, req.Content)
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(comment)
@@ -161,15 +187,19 @@ func (h *ConfessionHandler) GetComments(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	confessionID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid confession ID"})
+		return apierr.New(fiber.StatusBadRequest, "Invalid confession ID")
 	}
 
 	page := c.QueryInt("page", 1)
 	limit := c.QueryInt("limit", 20)
 
-	comments, err := h.service.GetComments(appID, confessionID, page, limit)
+	viewer, blocks, err := h.viewer(c)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load block list")
+	}
+	comments, err := h.service.GetComments(appID, viewer, blocks, confessionID, page, limit)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to fetch comments"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch comments")
 	}
 
 	return c.JSON(fiber.Map{
@@ -187,11 +217,11 @@ func (h *ConfessionHandler) Share(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	confessionID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid confession ID"})
+		return apierr.New(fiber.StatusBadRequest, "Invalid confession ID")
 	}
 
 	if err := h.service.IncrementShare(appID, confessionID); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to update share count"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to update share count")
 	}
 
 	return c.JSON(fiber.Map{"success": true})
@@ -201,12 +231,12 @@ func (h *ConfessionHandler) GetStats(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": true, "message": "Unauthorized"})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	stats, err := h.service.GetStats(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to fetch stats"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch stats")
 	}
 
 	return c.JSON(stats)
@@ -216,12 +246,16 @@ func (h *ConfessionHandler) GetByID(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	confessionID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid confession ID"})
+		return apierr.New(fiber.StatusBadRequest, "Invalid confession ID")
 	}
 
-	confession, err := h.service.GetConfession(appID, confessionID)
+	viewer, blocks, err := h.viewer(c)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": true, "message": "Confession not found"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load block list")
+	}
+	confession, err := h.service.GetConfession(appID, viewer, blocks, confessionID)
+	if err != nil {
+		return apierr.New(fiber.StatusNotFound, "Confession not found")
 	}
 
 	return c.JSON(fiber.Map{"data": confession})
@@ -231,16 +265,16 @@ func (h *ConfessionHandler) Delete(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": true, "message": "Unauthorized"})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	confessionID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid confession ID"})
+		return apierr.New(fiber.StatusBadRequest, "Invalid confession ID")
 	}
 
 	if err := h.service.DeleteConfession(appID, userID, confessionID); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": err.Error()})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.JSON(fiber.Map{"success": true, "message": "Confession deleted"})
@@ -250,21 +284,21 @@ func (h *ConfessionHandler) React(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": true, "message": "Unauthorized"})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	confessionID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid confession ID"})
+		return apierr.New(fiber.StatusBadRequest, "Invalid confession ID")
 	}
 
 	var req ReactRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid request"})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	if err := h.service.ReactToConfession(appID, userID, confessionID, req.Emoji); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": err.Error()})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.JSON(fiber.Map{"success": true})
@@ -274,12 +308,12 @@ func (h *ConfessionHandler) GetReactions(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	confessionID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": true, "message": "Invalid confession ID"})
+		return apierr.New(fiber.StatusBadRequest, "Invalid confession ID")
 	}
 
 	reactions, err := h.service.GetReactions(appID, confessionID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to fetch reactions"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch reactions")
 	}
 
 	return c.JSON(fiber.Map{"data": reactions})
@@ -289,7 +323,7 @@ func (h *ConfessionHandler) GetMyConfessions(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": true, "message": "Unauthorized"})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	page := c.QueryInt("page", 1)
@@ -297,7 +331,7 @@ func (h *ConfessionHandler) GetMyConfessions(c *fiber.Ctx) error {
 
 	confessions, total, err := h.service.GetMyConfessions(appID, userID, page, limit)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to fetch confessions"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch confessions")
 	}
 
 	return c.JSON(fiber.Map{
@@ -319,9 +353,13 @@ func (h *ConfessionHandler) GetTrending(c *fiber.Ctx) error {
 		limit = 20
 	}
 
-	confessions, total, err := h.service.GetTrendingFeed(appID, page, limit)
+	viewer, blocks, err := h.viewer(c)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load block list")
+	}
+	confessions, total, err := h.service.GetTrendingFeed(appID, viewer, blocks, page, limit)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": true, "message": "Failed to fetch trending confessions"})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch trending confessions")
 	}
 
 	return c.JSON(fiber.Map{
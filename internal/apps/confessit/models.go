@@ -21,6 +21,7 @@ type Confession struct {
 	ShareCount    int            `gorm:"default:0" json:"share_count"`
 	ViewCount     int            `gorm:"default:0" json:"view_count"`
 	ReactionCount int            `gorm:"default:0" json:"reaction_count"`
+	Hidden        bool           `gorm:"not null;default:false" json:"-"` // set by moderation
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
@@ -44,19 +45,21 @@ type ConfessionComment struct {
 	Content      string         `gorm:"type:text;not null" json:"content"`
 	IsAnonymous  bool           `gorm:"default:true" json:"is_anonymous"`
 	LikeCount    int            `gorm:"default:0" json:"like_count"`
+	Hidden       bool           `gorm:"not null;default:false" json:"-"` // set by moderation
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
-// ConfessionStreak tracks user's daily confession streak.
+// ConfessionStreak is the user's daily confession streak as returned by the
+// stats endpoint, backed by the shared "confession" streak.
 type ConfessionStreak struct {
-	ID            uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	AppID         string    `gorm:"size:50;not null;uniqueIndex:idx_confessit_streak_app_user" json:"app_id"`
-	UserID        uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_confessit_streak_app_user" json:"user_id"`
-	CurrentStreak int       `gorm:"default:0" json:"current_streak"`
-	LongestStreak int       `gorm:"default:0" json:"longest_streak"`
-	TotalPosts    int       `gorm:"default:0" json:"total_posts"`
+	ID            uuid.UUID `json:"id"`
+	AppID         string    `json:"app_id"`
+	UserID        uuid.UUID `json:"user_id"`
+	CurrentStreak int       `json:"current_streak"`
+	LongestStreak int       `json:"longest_streak"`
+	TotalPosts    int       `json:"total_posts"`
 	LastPostDate  time.Time `json:"last_post_date"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
@@ -0,0 +1,89 @@
+package confessit
+
+import (
+	"errors"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ResolveContent implements apps.ModerationPlugin. Reports on "post" refer
+// to confessions and "comment" to confession comments. Soft-deleted rows are
+// still resolved so a report filed just after deletion keeps its snapshot.
+func (p *ConfessitPlugin) ResolveContent(db *gorm.DB, appID, contentType, contentID string) (*services.ContentSnapshot, error) {
+	id, err := uuid.Parse(contentID)
+	if err != nil {
+		return nil, services.ErrContentNotFound
+	}
+
+	switch contentType {
+	case "post":
+		var c Confession
+		if err := db.Unscoped().Scopes(tenant.ForTenant(appID)).First(&c, "id = ?", id).Error; err != nil {
+			return nil, notFound(err)
+		}
+		return &services.ContentSnapshot{
+			AuthorID:  &c.UserID,
+			Text:      c.Content,
+			CreatedAt: &c.CreatedAt,
+			Extra:     map[string]interface{}{"category": c.Category, "mood": c.Mood},
+		}, nil
+	case "comment":
+		var c ConfessionComment
+		if err := db.Unscoped().Scopes(tenant.ForTenant(appID)).First(&c, "id = ?", id).Error; err != nil {
+			return nil, notFound(err)
+		}
+		return &services.ContentSnapshot{
+			AuthorID:  &c.UserID,
+			Text:      c.Content,
+			CreatedAt: &c.CreatedAt,
+			Extra:     map[string]interface{}{"confession_id": c.ConfessionID},
+		}, nil
+	}
+	return nil, services.ErrContentUnsupported
+}
+
+// SetContentHidden implements apps.ModerationPlugin.
+func (p *ConfessitPlugin) SetContentHidden(db *gorm.DB, appID, contentType, contentID string, hidden bool) error {
+	id, err := uuid.Parse(contentID)
+	if err != nil {
+		return services.ErrContentNotFound
+	}
+
+	var model interface{}
+	switch contentType {
+	case "post":
+		model = &Confession{}
+	case "comment":
+		model = &ConfessionComment{}
+	default:
+		return services.ErrContentUnsupported
+	}
+	result := db.Model(model).Unscoped().Scopes(tenant.ForTenant(appID)).Where("id = ?", id).Update("hidden", hidden)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrContentNotFound
+	}
+	return nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return services.ErrContentNotFound
+	}
+	return err
+}
+
+// visible filters out hidden content, content by users on either side of a
+// block with the viewer and, except for the viewer's own rows, content by
+// shadow-banned users.
+func visible(appID string, viewerID uuid.UUID, blocks services.BlockSet) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("hidden = ?", false).
+			Scopes(services.ExcludeShadowBanned(appID, viewerID, "user_id"), blocks.Exclude("user_id"))
+	}
+}
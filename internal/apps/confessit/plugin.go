@@ -2,14 +2,17 @@ package confessit
 
 import (
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
-type ConfessitPlugin struct{}
+type ConfessitPlugin struct {
+	moderationService *services.ModerationService
+}
 
-func New() *ConfessitPlugin {
-	return &ConfessitPlugin{}
+func New(moderationService *services.ModerationService) *ConfessitPlugin {
+	return &ConfessitPlugin{moderationService: moderationService}
 }
 
 func (p *ConfessitPlugin) ID() string { return "confessit" }
@@ -19,13 +22,12 @@ func (p *ConfessitPlugin) Models() []interface{} {
 		&Confession{},
 		&ConfessionLike{},
 		&ConfessionComment{},
-		&ConfessionStreak{},
 		&ConfessionReaction{},
 	}
 }
 
 func (p *ConfessitPlugin) RegisterRoutes(router fiber.Router, db *gorm.DB, _ *config.Config) {
-	svc := NewConfessionService(db)
+	svc := NewConfessionService(db, p.moderationService)
 	h := NewConfessionHandler(svc)
 
 	// Public feed routes (no JWT required, but app_id comes from tenant middleware)
@@ -1,9 +1,12 @@
 package confessit
 
 import (
+	"context"
 	"errors"
-	"time"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/locale"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -11,11 +14,25 @@ import (
 
 // ConfessionService handles confession CRUD and engagement.
 type ConfessionService struct {
-	db *gorm.DB
+	db                *gorm.DB
+	streaks           *streaks.Engine
+	moderationService *services.ModerationService
 }
 
-func NewConfessionService(db *gorm.DB) *ConfessionService {
-	return &ConfessionService{db: db}
+func NewConfessionService(db *gorm.DB, moderationService *services.ModerationService) *ConfessionService {
+	return &ConfessionService{db: db, streaks: newConfessionStreaks(db), moderationService: moderationService}
+}
+
+// checkContent screens text shown to other users in the author's language.
+func (s *ConfessionService) checkContent(appID string, userID uuid.UUID, text string) error {
+	if s.moderationService == nil {
+		return nil
+	}
+	res := s.moderationService.CheckContent(context.Background(), appID, locale.ForUser(s.db, appID, userID), text)
+	if !res.Allowed {
+		return errors.New(s.moderationService.GetRejectionMessage(res.Reason()))
+	}
+	return nil
 }
 
 func (s *ConfessionService) CreateConfession(appID string, userID uuid.UUID, content, category, mood string) (*Confession, error) {
@@ -25,6 +42,9 @@ func (s *ConfessionService) CreateConfession(appID string, userID uuid.UUID, con
 	if len(content) > 1000 {
 		return nil, errors.New("confession must be under 1000 characters")
 	}
+	if err := s.checkContent(appID, userID, content); err != nil {
+		return nil, err
+	}
 
 	confession := &Confession{
 		AppID:       appID,
@@ -45,15 +65,15 @@ func (s *ConfessionService) CreateConfession(appID string, userID uuid.UUID, con
 	return confession, nil
 }
 
-func (s *ConfessionService) GetFeed(appID string, page, limit int) ([]Confession, int64, error) {
+func (s *ConfessionService) GetFeed(appID string, viewerID uuid.UUID, blocks services.BlockSet, page, limit int) ([]Confession, int64, error) {
 	var confessions []Confession
 	var total int64
 
 	offset := (page - 1) * limit
 
-	s.db.Model(&Confession{}).Scopes(tenant.ForTenant(appID)).Count(&total)
+	s.db.Model(&Confession{}).Scopes(tenant.ForTenant(appID), visible(appID, viewerID, blocks)).Count(&total)
 
-	err := s.db.Scopes(tenant.ForTenant(appID)).
+	err := s.db.Scopes(tenant.ForTenant(appID), visible(appID, viewerID, blocks)).
 		Order("created_at DESC").
 		Offset(offset).
 		Limit(limit).
@@ -71,13 +91,13 @@ func (s *ConfessionService) GetFeed(appID string, page, limit int) ([]Confession
 	return confessions, total, nil
 }
 
-func (s *ConfessionService) GetByCategory(appID, category string, page, limit int) ([]Confession, int64, error) {
+func (s *ConfessionService) GetByCategory(appID string, viewerID uuid.UUID, blocks services.BlockSet, category string, page, limit int) ([]Confession, int64, error) {
 	var confessions []Confession
 	var total int64
 
 	offset := (page - 1) * limit
 
-	query := s.db.Model(&Confession{}).Scopes(tenant.ForTenant(appID)).Where("category = ?", category)
+	query := s.db.Model(&Confession{}).Scopes(tenant.ForTenant(appID), visible(appID, viewerID, blocks)).Where("category = ?", category)
 	query.Count(&total)
 
 	err := query.Order("created_at DESC").
@@ -121,10 +141,21 @@ func (s *ConfessionService) LikeConfession(appID string, userID, confessionID uu
 	return nil
 }
 
-func (s *ConfessionService) AddComment(appID string, userID, confessionID uuid.UUID, content string) (*ConfessionComment, error) {
+func (s *ConfessionService) AddComment(appID string, userID uuid.UUID, blocks services.BlockSet, confessionID uuid.UUID, content string) (*ConfessionComment, error) {
 	if len(content) < 1 || len(content) > 500 {
 		return nil, errors.New("comment must be 1-500 characters")
 	}
+	if err := s.checkContent(appID, userID, content); err != nil {
+		return nil, err
+	}
+
+	var confession Confession
+	if err := s.db.Scopes(tenant.ForTenant(appID), visible(appID, userID, blocks)).Select("id").Where("id = ?", confessionID).First(&confession).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("confession not found")
+		}
+		return nil, err
+	}
 
 	comment := &ConfessionComment{
 		AppID:        appID,
@@ -144,11 +175,11 @@ func (s *ConfessionService) AddComment(appID string, userID, confessionID uuid.U
 	return comment, nil
 }
 
-func (s *ConfessionService) GetComments(appID string, confessionID uuid.UUID, page, limit int) ([]ConfessionComment, error) {
+func (s *ConfessionService) GetComments(appID string, viewerID uuid.UUID, blocks services.BlockSet, confessionID uuid.UUID, page, limit int) ([]ConfessionComment, error) {
 	var comments []ConfessionComment
 	offset := (page - 1) * limit
 
-	err := s.db.Scopes(tenant.ForTenant(appID)).
+	err := s.db.Scopes(tenant.ForTenant(appID), visible(appID, viewerID, blocks)).
 		Where("confession_id = ?", confessionID).
 		Order("created_at DESC").
 		Offset(offset).
@@ -166,16 +197,11 @@ func (s *ConfessionService) IncrementShare(appID string, confessionID uuid.UUID)
 }
 
 func (s *ConfessionService) GetStats(appID string, userID uuid.UUID) (*ConfessionStreak, error) {
-	var streak ConfessionStreak
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		streak = ConfessionStreak{
-			AppID:  appID,
-			UserID: userID,
-		}
-		s.db.Create(&streak)
+	streak, err := s.streaks.Get(appID, userID)
+	if err != nil {
+		return nil, err
 	}
-	return &streak, nil
+	return confessionStreakFrom(streak), nil
 }
 
 func (s *ConfessionService) GetMyConfessions(appID string, userID uuid.UUID, page, limit int) ([]Confession, int64, error) {
@@ -207,9 +233,9 @@ func (s *ConfessionService) DeleteConfession(appID string, userID, confessionID
 	return s.db.Delete(&confession).Error
 }
 
-func (s *ConfessionService) GetConfession(appID string, confessionID uuid.UUID) (*Confession, error) {
+func (s *ConfessionService) GetConfession(appID string, viewerID uuid.UUID, blocks services.BlockSet, confessionID uuid.UUID) (*Confession, error) {
 	var confession Confession
-	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("id = ?", confessionID).First(&confession).Error; err != nil {
+	if err := s.db.Scopes(tenant.ForTenant(appID), visible(appID, viewerID, blocks)).Where("id = ?", confessionID).First(&confession).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("confession not found")
 		}
@@ -291,66 +317,25 @@ func (s *ConfessionService) GetReactions(appID string, confessionID uuid.UUID) (
 }
 
 // GetTrendingFeed retrieves confessions ordered by trending score.
-func (s *ConfessionService) GetTrendingFeed(appID string, page, limit int) ([]Confession, int64, error) {
+func (s *ConfessionService) GetTrendingFeed(appID string, viewerID uuid.UUID, blocks services.BlockSet, page, limit int) ([]Confession, int64, error) {
 	var confessions []Confession
 	var total int64
 
 	offset := (page - 1) * limit
 
-	if err := s.db.Model(&Confession{}).Scopes(tenant.ForTenant(appID)).Where("deleted_at IS NULL").Count(&total).Error; err != nil {
+	if err := s.db.Model(&Confession{}).Scopes(tenant.ForTenant(appID), visible(appID, viewerID, blocks)).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	query := `
-		SELECT id, app_id, user_id, content, category, mood, is_anonymous, like_count, comment_count, share_count, view_count, reaction_count, created_at, updated_at, deleted_at,
-		((like_count * 3) + (reaction_count * 2) + (comment_count * 2) + (view_count * 0.1) - (EXTRACT(EPOCH FROM (NOW() - created_at)) / 3600 * 1.5)) as score
-		FROM confessions
-		WHERE deleted_at IS NULL AND app_id = ?
-		ORDER BY score DESC
-		OFFSET ? LIMIT ?
-	`
-
-	if err := s.db.Raw(query, appID, offset, limit).Scan(&confessions).Error; err != nil {
+	err := s.db.Scopes(tenant.ForTenant(appID), visible(appID, viewerID, blocks)).
+		Select("*, ((like_count * 3) + (reaction_count * 2) + (comment_count * 2) + (view_count * 0.1) - (EXTRACT(EPOCH FROM (NOW() - created_at)) / 3600 * 1.5)) AS score").
+		Order("score DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&confessions).Error
+	if err != nil {
 		return nil, 0, err
 	}
 
 	return confessions, total, nil
 }
-
-func (s *ConfessionService) updateStreak(appID string, userID uuid.UUID) {
-	var streak ConfessionStreak
-	today := time.Now().Truncate(24 * time.Hour)
-
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		streak = ConfessionStreak{
-			AppID:         appID,
-			UserID:        userID,
-			CurrentStreak: 1,
-			LongestStreak: 1,
-			TotalPosts:    1,
-			LastPostDate:  today,
-		}
-		s.db.Create(&streak)
-		return
-	}
-
-	lastPost := streak.LastPostDate.Truncate(24 * time.Hour)
-	yesterday := today.Add(-24 * time.Hour)
-
-	if lastPost.Equal(today) {
-		streak.TotalPosts++
-	} else if lastPost.Equal(yesterday) {
-		streak.CurrentStreak++
-		streak.TotalPosts++
-		if streak.CurrentStreak > streak.LongestStreak {
-			streak.LongestStreak = streak.CurrentStreak
-		}
-	} else {
-		streak.CurrentStreak = 1
-		streak.TotalPosts++
-	}
-
-	streak.LastPostDate = today
-	s.db.Save(&streak)
-}
@@ -0,0 +1,67 @@
+package confessit
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// confessionStreakPolicy counts consecutive days with a confession.
+var confessionStreakPolicy = streaks.Policy{MaxGap: 1}
+
+func newConfessionStreaks(db *gorm.DB) *streaks.Engine {
+	return streaks.New(db, "confession", confessionStreakPolicy)
+}
+
+func confessionStreakFrom(st *models.Streak) *ConfessionStreak {
+	cs := &ConfessionStreak{
+		ID:            st.ID,
+		AppID:         st.AppID,
+		UserID:        st.UserID,
+		CurrentStreak: st.CurrentStreak,
+		LongestStreak: st.LongestStreak,
+		TotalPosts:    st.TotalCount,
+		CreatedAt:     st.CreatedAt,
+		UpdatedAt:     st.UpdatedAt,
+	}
+	if st.LastActivityAt != nil {
+		cs.LastPostDate = *st.LastActivityAt
+	}
+	return cs
+}
+
+// updateStreak records a confession day for the user.
+func (s *ConfessionService) updateStreak(appID string, userID uuid.UUID) {
+	if _, err := s.streaks.Record(appID, userID, time.Now()); err != nil {
+		slog.Warn("failed to update confession streak", "app_id", appID, "user_id", userID, "error", err)
+	}
+}
+
+// Migrate implements apps.MigrationPlugin, carrying confession_streaks rows
+// over to the shared streaks table.
+func (p *ConfessitPlugin) Migrate(db *gorm.DB) error {
+	_, err := newConfessionStreaks(db).ImportLegacy(streaks.Legacy{
+		Table:      "confession_streaks",
+		Total:      "total_posts",
+		LastActive: "last_post_date",
+	})
+	return err
+}
+
+// RecomputeStreaks implements services.StreakRecomputer, rebuilding the
+// confession streak from post times bucketed by local day in loc.
+func (p *ConfessitPlugin) RecomputeStreaks(db *gorm.DB, appID string, userID uuid.UUID, loc *time.Location) error {
+	var times []time.Time
+	if err := db.Model(&Confession{}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ?", userID).
+		Pluck("created_at", &times).Error; err != nil {
+		return err
+	}
+	return newConfessionStreaks(db).Recompute(appID, userID, loc, times)
+}
@@ -0,0 +1,19 @@
+package daiyly
+
+import "github.com/ahmetcoskunkizilkaya/unified-backend/internal/achievements"
+
+// journalAchievements unlock from the journal streak: "journal.count" is the
+// total number of entries, "journal.streak" the current streak length.
+var journalAchievements = []achievements.Definition{
+	{Key: "journal_first_entry", Name: "Dear Diary", Description: "Write your first journal entry", Emoji: "📓", XP: 10, Event: "journal.count", Threshold: 1},
+	{Key: "journal_10_entries", Name: "Finding Your Voice", Description: "Write 10 journal entries", Emoji: "✍️", XP: 50, Event: "journal.count", Threshold: 10},
+	{Key: "journal_30_entries", Name: "Storyteller", Description: "Write 30 journal entries", Emoji: "📚", XP: 150, Event: "journal.count", Threshold: 30},
+	{Key: "journal_100_entries", Name: "Memoirist", Description: "Write 100 journal entries", Emoji: "🏛️", XP: 500, Event: "journal.count", Threshold: 100},
+	{Key: "journal_streak_7", Name: "Week of Reflection", Description: "Journal 7 days in a row", Emoji: "🔥", XP: 100, Event: "journal.streak", Threshold: 7},
+	{Key: "journal_streak_30", Name: "Month of Reflection", Description: "Journal 30 days in a row", Emoji: "🌟", XP: 400, Event: "journal.streak", Threshold: 30},
+}
+
+// Achievements implements apps.AchievementPlugin.
+func (p *DaiylyPlugin) Achievements() []achievements.Definition {
+	return journalAchievements
+}
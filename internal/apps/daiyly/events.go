@@ -0,0 +1,85 @@
+package daiyly
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"gorm.io/gorm"
+)
+
+func (p *DaiylyPlugin) newService(db *gorm.DB, cfg *config.Config) *JournalService {
+	return NewJournalService(db, p.bus, cfg.GLMAPIKey, cfg.GLMAPIURL, cfg.GLMModel, cfg.AITimeout, cfg.OpenAIAPIKey, cfg.OpenAIModel, cfg.OpenAIBaseURL, cfg.EmotionSenseMLURL)
+}
+
+// RegisterEvents implements apps.EventPlugin.
+func (p *DaiylyPlugin) RegisterEvents(bus *events.Bus, db *gorm.DB, cfg *config.Config) {
+	p.bus = bus
+	svc := p.newService(db, cfg)
+
+	bus.Subscribe(events.UserDeleted, "daiyly.delete_user_data", p.deleteUserData)
+	bus.Subscribe(events.GuestClaimed, "daiyly.claim_guest_data", p.claimGuestData)
+	bus.SubscribeAsync(events.JournalEntryCreated, "daiyly.embed_entry", svc.embedEntry)
+}
+
+// deleteUserData removes the user's journal data in the account deletion
+// transaction.
+func (p *DaiylyPlugin) deleteUserData(tx *gorm.DB, ev events.Event) error {
+	for _, m := range p.Models() {
+		if err := tx.Unscoped().Where("app_id = ? AND user_id = ?", ev.AppID, ev.UserID).Delete(m).Error; err != nil {
+			return fmt.Errorf("delete %T: %w", m, err)
+		}
+	}
+	return nil
+}
+
+// embedEntry stores the semantic-search embedding for a new entry.
+func (s *JournalService) embedEntry(db *gorm.DB, ev events.Event) error {
+	var payload events.JournalEntryCreatedPayload
+	if err := ev.Decode(&payload); err != nil {
+		return err
+	}
+	var entry JournalEntry
+	err := db.Scopes(tenant.ForTenant(ev.AppID)).
+		Where("id = ? AND user_id = ?", payload.EntryID, ev.UserID).
+		First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // deleted before we got to it
+	}
+	if err != nil {
+		return err
+	}
+	return s.storeEmbedding(entry.AppID, entry.UserID, entry.ID, entry.Content)
+}
+
+// claimGuestData moves a claimed guest's journal to the registered account.
+// Per-day and per-week caches keep the registered account's copy.
+func (p *DaiylyPlugin) claimGuestData(tx *gorm.DB, ev events.Event) error {
+	var payload events.GuestClaimedPayload
+	if err := ev.Decode(&payload); err != nil {
+		return err
+	}
+	for _, m := range []interface{}{&JournalEntry{}, &EntryAnalysis{}, &JournalEmbedding{}} {
+		if err := services.MoveUserRows(tx, m, ev.AppID, payload.GuestID, ev.UserID); err != nil {
+			return fmt.Errorf("move %T: %w", m, err)
+		}
+	}
+	unique := []struct {
+		model interface{}
+		keys  []string
+	}{
+		{&WeeklyReport{}, []string{"week_start"}},
+		{&DailyPromptCache{}, []string{"prompt_date"}},
+		{&NotificationConfigCache{}, []string{"config_date"}},
+		{&TherapistExportCache{}, nil},
+	}
+	for _, u := range unique {
+		if err := services.MoveUniqueUserRows(tx, u.model, ev.AppID, payload.GuestID, ev.UserID, u.keys...); err != nil {
+			return fmt.Errorf("move %T: %w", u.model, err)
+		}
+	}
+	return nil
+}
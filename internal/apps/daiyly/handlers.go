@@ -4,15 +4,23 @@ import (
 	"bytes"
 	"encoding/csv"
 	"errors"
-	"log/slog"
-	"strconv"
 
-	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
-	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/sdk"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
+func init() {
+	sdk.RegisterErrors(map[error]int{
+		ErrJournalNotFound:  fiber.StatusNotFound,
+		ErrNotOwner:         fiber.StatusForbidden,
+		ErrAnalysisNotFound: fiber.StatusNotFound,
+		ErrInvalidEntryType: fiber.StatusBadRequest,
+		ErrNoItems:          fiber.StatusBadRequest,
+		ErrTooManyItems:     fiber.StatusBadRequest,
+	})
+}
+
 type JournalHandler struct {
 	service *JournalService
 }
@@ -21,566 +29,187 @@ func NewJournalHandler(service *JournalService) *JournalHandler {
 	return &JournalHandler{service: service}
 }
 
-func (h *JournalHandler) Search(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
+func (h *JournalHandler) Search(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*SearchJournalResponse, error) {
 	query := c.Query("q")
 	if len(query) < 2 {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "search query must be at least 2 characters",
-		})
-	}
-
-	limit, _ := strconv.Atoi(c.Query("limit", "20"))
-	if limit <= 0 || limit > 100 {
-		limit = 20
-	}
-
-	offset, _ := strconv.Atoi(c.Query("offset", "0"))
-	if offset < 0 {
-		offset = 0
-	}
-	if offset > 10000 {
-		offset = 0
+		return nil, apierr.New(fiber.StatusBadRequest, "search query must be at least 2 characters")
 	}
 
-	response, err := h.service.SearchEntries(appID, userID, query, limit, offset)
+	page, err := sdk.PageFrom(c, sdk.DefaultLimit)
 	if err != nil {
-		slog.Error("search entries failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "search failed",
-		})
+		return nil, err
 	}
-
-	return c.JSON(response)
+	return h.service.SearchEntries(u.AppID, u.ID, query, page.Limit, page.Offset)
 }
 
-func (h *JournalHandler) Create(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	var req CreateJournalRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid request body",
-		})
-	}
-
-	entry, err := h.service.CreateEntry(appID, userID, req)
+func (h *JournalHandler) Create(c *fiber.Ctx, u sdk.User, req CreateJournalRequest) (*JournalEntry, error) {
+	entry, err := h.service.CreateEntry(u.AppID, u.ID, req)
 	if err != nil {
-		if errors.Is(err, ErrInvalidMoodEmoji) ||
-			errors.Is(err, ErrInvalidMoodScore) ||
-			errors.Is(err, ErrInvalidCardColor) ||
-			errors.Is(err, ErrInvalidPhotoURL) ||
-			errors.Is(err, ErrInvalidAudioURL) ||
-			errors.Is(err, ErrContentInappropriate) {
-			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-				Error: true, Message: err.Error(),
-			})
-		}
-		slog.Error("create journal entry failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to create journal entry",
-		})
+		return nil, err
 	}
-
-	return c.Status(fiber.StatusCreated).JSON(entry)
+	c.Status(fiber.StatusCreated)
+	return entry, nil
 }
 
-func (h *JournalHandler) List(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
+func (h *JournalHandler) List(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*JournalListResponse, error) {
+	page, err := sdk.PageFrom(c, sdk.DefaultLimit)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	limit, _ := strconv.Atoi(c.Query("limit", "20"))
-	offset, _ := strconv.Atoi(c.Query("offset", "0"))
-	if limit < 1 {
-		limit = 1
-	}
-	if limit > 100 {
-		limit = 100
-	}
-	if offset < 0 {
-		offset = 0
-	}
-	if offset > 10000 {
-		offset = 0
+		return nil, err
 	}
 
-	entries, total, err := h.service.GetEntries(appID, userID, limit, offset)
+	entries, total, err := h.service.GetEntries(u.AppID, u.ID, page)
 	if err != nil {
-		slog.Error("list journal entries failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to fetch journal entries",
-		})
+		return nil, err
 	}
 
-	return c.JSON(JournalListResponse{
+	resp := &JournalListResponse{
 		Entries: entries,
 		Total:   total,
-		Limit:   limit,
-		Offset:  offset,
-	})
-}
-
-func (h *JournalHandler) Get(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		Limit:   page.Limit,
+		Offset:  page.Offset,
 	}
-
-	entryID, err := uuid.Parse(c.Params("id"))
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid entry ID",
-		})
+	if n := len(entries); n > 0 {
+		resp.NextCursor = page.Next(n, sdk.Cursor{Time: entries[n-1].EntryDate, ID: entries[n-1].ID})
 	}
-
-	entry, err := h.service.GetEntry(appID, userID, entryID)
-	if err != nil {
-		if errors.Is(err, ErrJournalNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{
-				Error: true, Message: err.Error(),
-			})
-		}
-		if errors.Is(err, ErrNotOwner) {
-			return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{
-				Error: true, Message: err.Error(),
-			})
-		}
-		slog.Error("get journal entry failed", "app", appID, "user", userID, "entry", entryID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to fetch journal entry",
-		})
-	}
-
-	return c.JSON(entry)
+	return resp, nil
 }
 
-func (h *JournalHandler) Update(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	entryID, err := uuid.Parse(c.Params("id"))
+func (h *JournalHandler) Get(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*JournalEntry, error) {
+	entryID, err := sdk.ParamUUID(c, "id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid entry ID",
-		})
+		return nil, err
 	}
-
-	var req UpdateJournalRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid request body",
-		})
-	}
-
-	entry, err := h.service.UpdateEntry(appID, userID, entryID, req)
-	if err != nil {
-		if errors.Is(err, ErrJournalNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{
-				Error: true, Message: err.Error(),
-			})
-		}
-		if errors.Is(err, ErrNotOwner) {
-			return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{
-				Error: true, Message: err.Error(),
-			})
-		}
-		if errors.Is(err, ErrInvalidMoodEmoji) ||
-			errors.Is(err, ErrInvalidMoodScore) ||
-			errors.Is(err, ErrInvalidCardColor) ||
-			errors.Is(err, ErrInvalidPhotoURL) ||
-			errors.Is(err, ErrInvalidAudioURL) ||
-			errors.Is(err, ErrContentInappropriate) {
-			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-				Error: true, Message: err.Error(),
-			})
-		}
-		slog.Error("update journal entry failed", "app", appID, "user", userID, "entry", entryID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to update journal entry",
-		})
-	}
-
-	return c.JSON(entry)
+	return h.service.GetEntry(u.AppID, u.ID, entryID)
 }
 
-func (h *JournalHandler) Delete(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
+func (h *JournalHandler) Update(c *fiber.Ctx, u sdk.User, req UpdateJournalRequest) (*JournalEntry, error) {
+	entryID, err := sdk.ParamUUID(c, "id")
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return nil, err
 	}
+	return h.service.UpdateEntry(u.AppID, u.ID, entryID, req)
+}
 
-	entryID, err := uuid.Parse(c.Params("id"))
+func (h *JournalHandler) Delete(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*DeleteJournalResponse, error) {
+	entryID, err := sdk.ParamUUID(c, "id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid entry ID",
-		})
+		return nil, err
 	}
 
-	err = h.service.DeleteEntry(appID, userID, entryID)
-	if err != nil {
-		if errors.Is(err, ErrJournalNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{
-				Error: true, Message: err.Error(),
-			})
-		}
-		if errors.Is(err, ErrNotOwner) {
-			return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{
-				Error: true, Message: err.Error(),
-			})
-		}
-		slog.Error("delete journal entry failed", "app", appID, "user", userID, "entry", entryID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to delete journal entry",
-		})
+	if err := h.service.DeleteEntry(u.AppID, u.ID, entryID); err != nil {
+		return nil, err
 	}
 
-	return c.JSON(DeleteJournalResponse{
+	return &DeleteJournalResponse{
 		Message: "Entry deleted successfully",
-	})
+	}, nil
 }
 
-func (h *JournalHandler) GetStreak(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	streak, err := h.service.GetStreak(appID, userID)
-	if err != nil {
-		slog.Error("get streak failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to fetch streak",
-		})
-	}
-
-	return c.JSON(streak)
+func (h *JournalHandler) GetStreak(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*JournalStreak, error) {
+	return h.service.GetStreak(u.AppID, u.ID)
 }
 
-func (h *JournalHandler) GetWeeklyInsights(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	insights, err := h.service.GetWeeklyInsights(appID, userID)
-	if err != nil {
-		slog.Error("get weekly insights failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to fetch weekly insights",
-		})
-	}
-
-	return c.JSON(insights)
+func (h *JournalHandler) GetWeeklyInsights(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*WeeklyInsights, error) {
+	return h.service.GetWeeklyInsights(u.AppID, u.ID)
 }
 
-func (h *JournalHandler) GetPrompts(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	prompts, err := h.service.GetPersonalizedPrompts(appID, userID)
-	if err != nil {
-		slog.Error("get prompts failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to generate prompts",
-		})
-	}
-
-	return c.JSON(prompts)
+func (h *JournalHandler) GetPrompts(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*PromptsResponse, error) {
+	return h.service.GetPersonalizedPrompts(u.AppID, u.ID)
 }
 
-func (h *JournalHandler) GetWeeklyReport(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
+func (h *JournalHandler) GetWeeklyReport(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*WeeklyReportResponse, error) {
 	forceRefresh := c.Query("refresh") == "true"
-	report, err := h.service.GetWeeklyReport(appID, userID, forceRefresh)
-	if err != nil {
-		slog.Error("get weekly report failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to generate weekly report",
-		})
-	}
-
-	return c.JSON(report)
+	return h.service.GetWeeklyReport(u.AppID, u.ID, forceRefresh)
 }
 
-func (h *JournalHandler) GetFlashbacks(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	flashbacks, err := h.service.GetFlashbacks(appID, userID)
-	if err != nil {
-		slog.Error("get flashbacks failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to fetch flashbacks",
-		})
-	}
-
-	return c.JSON(flashbacks)
+func (h *JournalHandler) GetFlashbacks(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*FlashbacksResponse, error) {
+	return h.service.GetFlashbacks(u.AppID, u.ID)
 }
 
-func (h *JournalHandler) GetNotificationConfig(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	config, err := h.service.GetNotificationConfig(appID, userID)
-	if err != nil {
-		slog.Error("get notification config failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to generate notification config",
-		})
-	}
-
-	return c.JSON(config)
+func (h *JournalHandler) GetNotificationConfig(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*NotificationConfigResponse, error) {
+	return h.service.GetNotificationConfig(u.AppID, u.ID)
 }
 
-func (h *JournalHandler) AnalyzeEntry(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	entryID, err := uuid.Parse(c.Params("id"))
+func (h *JournalHandler) AnalyzeEntry(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (fiber.Map, error) {
+	entryID, err := sdk.ParamUUID(c, "id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid entry ID",
-		})
+		return nil, err
 	}
 
 	// Verify ownership
-	if _, err := h.service.GetEntry(appID, userID, entryID); err != nil {
-		if errors.Is(err, ErrJournalNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{
-				Error: true, Message: err.Error(),
-			})
-		}
-		if errors.Is(err, ErrNotOwner) {
-			return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{
-				Error: true, Message: err.Error(),
-			})
-		}
-		slog.Error("verify entry for analysis failed", "app", appID, "user", userID, "entry", entryID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to verify entry",
-		})
+	if _, err := h.service.GetEntry(u.AppID, u.ID, entryID); err != nil {
+		return nil, err
 	}
 
-	if err := h.service.TriggerAnalysis(appID, userID, entryID); err != nil {
-		slog.Error("trigger analysis failed", "app", appID, "user", userID, "entry", entryID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to trigger analysis",
-		})
+	if err := h.service.TriggerAnalysis(u.AppID, u.ID, entryID); err != nil {
+		return nil, err
 	}
 
-	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+	c.Status(fiber.StatusAccepted)
+	return fiber.Map{
 		"message": "Analysis started",
-	})
+	}, nil
 }
 
-func (h *JournalHandler) GetEntryAnalysis(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
+func (h *JournalHandler) GetEntryAnalysis(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*EntryAnalysisResponse, error) {
+	entryID, err := sdk.ParamUUID(c, "id")
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return nil, err
 	}
 
-	entryID, err := uuid.Parse(c.Params("id"))
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid entry ID",
-		})
+	analysis, err := h.service.GetEntryAnalysis(u.AppID, u.ID, entryID)
+	if errors.Is(err, ErrAnalysisNotFound) {
+		return nil, apierr.New(fiber.StatusNotFound, "Analysis not available yet")
 	}
-
-	analysis, err := h.service.GetEntryAnalysis(appID, userID, entryID)
-	if err != nil {
-		if errors.Is(err, ErrAnalysisNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{
-				Error: true, Message: "Analysis not available yet",
-			})
-		}
-		slog.Error("get entry analysis failed", "app", appID, "user", userID, "entry", entryID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to fetch analysis",
-		})
-	}
-
-	return c.JSON(analysis)
+	return analysis, err
 }
 
 // TherapistExport returns an AI-generated therapist-ready summary of the last 30 days.
 // PREMIUM feature: gating is noted with a TODO below pending subscription check wiring.
-func (h *JournalHandler) TherapistExport(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
+func (h *JournalHandler) TherapistExport(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*TherapistExportResponse, error) {
 	// TODO: Check subscription entitlement here once RevenueCat webhook is wired up.
-	// if !isPremium(c) { return c.Status(fiber.StatusPaymentRequired).JSON(...) }
+	// if !isPremium(c) { return nil, apierr.New(fiber.StatusPaymentRequired, ...) }
 
-	report, err := h.service.TherapistExport(appID, userID)
-	if err != nil {
-		slog.Error("therapist export failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to generate therapist export",
-		})
-	}
-
-	return c.JSON(report)
+	return h.service.TherapistExport(u.AppID, u.ID)
 }
 
 // TherapistReport returns the spec-compatible therapist report envelope for GET /journals/therapist-report.
 // It wraps TherapistExport output into a simpler {report, generated_at, entry_count, date_range} shape.
 // PREMIUM feature: gating is noted with a TODO below pending subscription check wiring.
-func (h *JournalHandler) TherapistReport(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
+func (h *JournalHandler) TherapistReport(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*TherapistReportResponse, error) {
 	// TODO: Check subscription entitlement here once RevenueCat webhook is wired up.
-	// if !isPremium(c) { return c.Status(fiber.StatusPaymentRequired).JSON(...) }
+	// if !isPremium(c) { return nil, apierr.New(fiber.StatusPaymentRequired, ...) }
 
-	report, err := h.service.TherapistReport(appID, userID)
-	if err != nil {
-		slog.Error("therapist report failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to generate therapist report",
-		})
-	}
-
-	return c.JSON(report)
+	return h.service.TherapistReport(u.AppID, u.ID)
 }
 
 // GetNotificationTiming returns the user's optimal journaling hour based on the last 30 days.
-func (h *JournalHandler) GetNotificationTiming(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	timing, err := h.service.GetNotificationTiming(appID, userID)
-	if err != nil {
-		slog.Error("get notification timing failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to compute notification timing",
-		})
-	}
-
-	return c.JSON(timing)
+func (h *JournalHandler) GetNotificationTiming(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*NotificationTimingResponse, error) {
+	return h.service.GetNotificationTiming(u.AppID, u.ID)
 }
 
 // AISearch performs semantic journal search using GPT-4o-mini.
 // GET /journals/ai-search?q=...&limit=10&days=90
-func (h *JournalHandler) AISearch(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
+func (h *JournalHandler) AISearch(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*AISearchResponse, error) {
 	query := c.Query("q")
 	if len(query) == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "query parameter 'q' is required",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "query parameter 'q' is required")
 	}
 	if len(query) > 500 {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "query must be at most 500 characters",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "query must be at most 500 characters")
 	}
 
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	limit := c.QueryInt("limit", 10)
 	if limit <= 0 || limit > 50 {
 		limit = 10
 	}
 
-	days, _ := strconv.Atoi(c.Query("days", "90"))
+	days := c.QueryInt("days", 90)
 	if days <= 0 || days > 365 {
 		days = 90
 	}
 
-	result, err := h.service.AISearchEntries(appID, userID, query, limit, days)
-	if err != nil {
-		slog.Error("ai search failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "AI search failed",
-		})
-	}
-
-	return c.JSON(result)
+	return h.service.AISearchEntries(u.AppID, u.ID, query, limit, days)
 }
 
 // AskJournal handles POST /journals/ask.
@@ -590,28 +219,11 @@ func (h *JournalHandler) AISearch(c *fiber.Ctx) error {
 //
 // When "query" is present the new SemanticAsk path is used (embeddings + AI answer).
 // When only "question" is present, the legacy keyword-based AskJournal path is used.
-func (h *JournalHandler) AskJournal(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	var req AskJournalRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid request body",
-		})
-	}
-
+func (h *JournalHandler) AskJournal(c *fiber.Ctx, u sdk.User, req AskJournalRequest) (interface{}, error) {
 	// New semantic shape: "query" field present.
 	if len(req.Query) > 0 {
 		if len(req.Query) > 500 {
-			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-				Error: true, Message: "query must be at most 500 characters",
-			})
+			return nil, apierr.New(fiber.StatusBadRequest, "query must be at most 500 characters")
 		}
 		days := req.Days
 		if days <= 0 || days > 365 {
@@ -621,87 +233,44 @@ func (h *JournalHandler) AskJournal(c *fiber.Ctx) error {
 		if limit <= 0 || limit > 20 {
 			limit = 5
 		}
-		result, err := h.service.SemanticAsk(appID, userID, req.Query, days, limit)
-		if err != nil {
-			slog.Error("semantic ask failed", "app", appID, "user", userID, "error", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-				Error: true, Message: "Failed to answer question",
-			})
-		}
-		return c.JSON(result)
+		return h.service.SemanticAsk(u.AppID, u.ID, req.Query, days, limit)
 	}
 
 	// Legacy shape: "question" field.
 	if len(req.Question) == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "query or question is required",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "query or question is required")
 	}
 	if len(req.Question) > 1000 {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "question must be at most 1000 characters",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "question must be at most 1000 characters")
 	}
 
-	result, err := h.service.AskJournal(appID, userID, req.Question)
-	if err != nil {
-		slog.Error("ask journal failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to answer question",
-		})
-	}
-
-	return c.JSON(result)
+	return h.service.AskJournal(u.AppID, u.ID, req.Question)
 }
 
 // CreateQuickEntry handles POST /journals/quick.
 // Accepts { type, items, mood, mood_score, card_color, entry_date } and saves a formatted JournalEntry.
-func (h *JournalHandler) CreateQuickEntry(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	var req QuickEntryRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid request body",
-		})
-	}
-
-	entry, err := h.service.CreateQuickEntry(appID, userID, req)
+func (h *JournalHandler) CreateQuickEntry(c *fiber.Ctx, u sdk.User, req QuickEntryRequest) (*JournalEntry, error) {
+	entry, err := h.service.CreateQuickEntry(u.AppID, u.ID, req)
 	if err != nil {
-		slog.Error("create quick entry failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: err.Error(),
-		})
+		return nil, err
 	}
-
-	return c.Status(fiber.StatusCreated).JSON(entry)
+	c.Status(fiber.StatusCreated)
+	return entry, nil
 }
 
 // Export handles GET /journals/export?format=csv|json.
 // Returns all journal entries for the authenticated user.
 func (h *JournalHandler) Export(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
+	u, err := sdk.Caller(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return err
 	}
 
 	format := c.Query("format", "json")
 
-	entries, err := h.service.ExportJournals(appID, userID, format)
+	entries, err := h.service.ExportJournals(u.AppID, u.ID, format)
 	if err != nil {
-		slog.Error("export failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Export failed",
-		})
+		return err
 	}
 
 	if format == "csv" {
@@ -737,11 +306,8 @@ func (h *JournalHandler) Export(c *fiber.Ctx) error {
 			})
 		}
 		w.Flush()
-		if flushErr := w.Error(); flushErr != nil {
-			slog.Error("csv flush failed", "app", appID, "user", userID, "error", flushErr)
-			return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-				Error: true, Message: "CSV generation failed",
-			})
+		if err := w.Error(); err != nil {
+			return err
 		}
 
 		c.Set("Content-Type", "text/csv")
@@ -758,45 +324,13 @@ func (h *JournalHandler) Export(c *fiber.Ctx) error {
 
 // OnThisDay handles GET /journals/on-this-day.
 // Returns journal entries from the same calendar day (±2 days) in previous years.
-func (h *JournalHandler) OnThisDay(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	result, err := h.service.GetOnThisDay(appID, userID)
-	if err != nil {
-		slog.Error("on-this-day failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to fetch on-this-day entries",
-		})
-	}
-
-	return c.JSON(result)
+func (h *JournalHandler) OnThisDay(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*OnThisDayResponse, error) {
+	return h.service.GetOnThisDay(u.AppID, u.ID)
 }
 
 // GetWritingPrompts handles GET /journals/writing-prompts.
 // Returns 4 daily writing prompts personalised by the user's recent mood.
 // No AI call — prompts are static and selected deterministically per day.
-func (h *JournalHandler) GetWritingPrompts(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	resp, err := h.service.GetWritingPrompts(appID, userID)
-	if err != nil {
-		slog.Error("writing prompts failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to fetch writing prompts",
-		})
-	}
-
-	return c.JSON(resp)
+func (h *JournalHandler) GetWritingPrompts(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*WritingPromptsResponse, error) {
+	return h.service.GetWritingPrompts(u.AppID, u.ID)
 }
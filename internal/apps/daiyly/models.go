@@ -3,6 +3,7 @@ package daiyly
 import (
 	"time"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -52,16 +53,19 @@ type JournalEmbedding struct {
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// JournalStreak is the GET /journals/streak response. The streak itself is
+// the shared "journal" streak (see streak.go); the journal_streaks table is
+// only read once to import legacy rows.
 type JournalStreak struct {
-	ID                uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	AppID             string     `gorm:"size:50;not null;index;uniqueIndex:idx_journal_streak_app_user" json:"app_id"`
-	UserID            uuid.UUID  `gorm:"type:uuid;uniqueIndex:idx_journal_streak_app_user" json:"user_id"`
-	CurrentStreak     int        `gorm:"default:0" json:"current_streak"`
-	LongestStreak     int        `gorm:"default:0" json:"longest_streak"`
-	TotalEntries      int        `gorm:"default:0" json:"total_entries"`
+	ID                uuid.UUID  `json:"id"`
+	AppID             string     `json:"app_id"`
+	UserID            uuid.UUID  `json:"user_id"`
+	CurrentStreak     int        `json:"current_streak"`
+	LongestStreak     int        `json:"longest_streak"`
+	TotalEntries      int        `json:"total_entries"`
 	LastEntryDate     time.Time  `json:"last_entry_date"`
-	GracePeriodActive bool       `gorm:"default:false" json:"grace_period_active"`
-	GracePeriodUsedAt *time.Time `gorm:"default:null" json:"grace_period_used_at"`
+	GracePeriodActive bool       `json:"grace_period_active"`
+	GracePeriodUsedAt *time.Time `json:"grace_period_used_at"`
 	CreatedAt         time.Time  `json:"created_at"`
 	UpdatedAt         time.Time  `json:"updated_at"`
 }
@@ -69,36 +73,45 @@ type JournalStreak struct {
 var MoodEmojis = []string{"😊", "😢", "😡", "😰", "😴", "🥳", "😌", "🤔", "😍", "😤", "😔", "😐"}
 var CardColors = []string{"#fef3c7", "#dbeafe", "#dcfce7", "#fce7f3", "#ede9fe", "#fef2f2"}
 
+func init() {
+	validation.RegisterEnum("daiyly_mood", MoodEmojis...)
+	validation.RegisterEnum("daiyly_card_color", CardColors...)
+}
+
 // --- DTOs ---
 
+// Photo and audio URLs must be https://, so clients never load mixed content.
 type CreateJournalRequest struct {
-	MoodEmoji  string `json:"mood_emoji"`
-	MoodScore  int    `json:"mood_score"`
-	Content    string `json:"content"`
-	PhotoURL   string `json:"photo_url"`
-	AudioURL   string `json:"audio_url"`
-	Transcript string `json:"transcript"`
-	CardColor  string `json:"card_color"`
+	MoodEmoji  string `json:"mood_emoji" validate:"required,daiyly_mood"`
+	MoodScore  int    `json:"mood_score" validate:"gte=1,lte=100"`
+	Content    string `json:"content" validate:"max=50000"`
+	PhotoURL   string `json:"photo_url" validate:"omitempty,max=2048,https_url"`
+	AudioURL   string `json:"audio_url" validate:"omitempty,max=2048,https_url"`
+	Transcript string `json:"transcript" validate:"max=50000"`
+	CardColor  string `json:"card_color" validate:"omitempty,daiyly_card_color"` // defaults to #dbeafe
 	IsPrivate  bool   `json:"is_private"`
 	EntryDate  string `json:"entry_date"` // "YYYY-MM-DD" from client's local timezone; optional
 }
 
+// UpdateJournalRequest changes the fields present; an empty photo_url or
+// audio_url removes the attachment.
 type UpdateJournalRequest struct {
-	MoodEmoji  *string `json:"mood_emoji"`
-	MoodScore  *int    `json:"mood_score"`
-	Content    *string `json:"content"`
-	PhotoURL   *string `json:"photo_url"`
-	AudioURL   *string `json:"audio_url"`
-	Transcript *string `json:"transcript"`
-	CardColor  *string `json:"card_color"`
+	MoodEmoji  *string `json:"mood_emoji" validate:"daiyly_mood"`
+	MoodScore  *int    `json:"mood_score" validate:"gte=1,lte=100"`
+	Content    *string `json:"content" validate:"max=50000"`
+	PhotoURL   *string `json:"photo_url" validate:"omitempty,max=2048,https_url"`
+	AudioURL   *string `json:"audio_url" validate:"omitempty,max=2048,https_url"`
+	Transcript *string `json:"transcript" validate:"max=50000"`
+	CardColor  *string `json:"card_color" validate:"daiyly_card_color"`
 	IsPrivate  *bool   `json:"is_private"`
 }
 
 type JournalListResponse struct {
-	Entries []JournalEntry `json:"entries"`
-	Total   int64          `json:"total"`
-	Limit   int            `json:"limit"`
-	Offset  int            `json:"offset"`
+	Entries    []JournalEntry `json:"entries"`
+	Total      int64          `json:"total"`
+	Limit      int            `json:"limit"`
+	Offset     int            `json:"offset"`
+	NextCursor string         `json:"next_cursor,omitempty"`
 }
 
 type WeeklyInsights struct {
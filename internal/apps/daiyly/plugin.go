@@ -1,15 +1,16 @@
 package daiyly
 
 import (
-	"time"
-
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/sdk"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"gorm.io/gorm"
 )
 
-type DaiylyPlugin struct{}
+type DaiylyPlugin struct {
+	bus *events.Bus
+}
 
 func New() *DaiylyPlugin {
 	return &DaiylyPlugin{}
@@ -20,7 +21,6 @@ func (p *DaiylyPlugin) ID() string { return "daiyly" }
 func (p *DaiylyPlugin) Models() []interface{} {
 	return []interface{}{
 		&JournalEntry{},
-		&JournalStreak{},
 		&EntryAnalysis{},
 		&WeeklyReport{},
 		&DailyPromptCache{},
@@ -31,156 +31,83 @@ func (p *DaiylyPlugin) Models() []interface{} {
 }
 
 func (p *DaiylyPlugin) RegisterRoutes(router fiber.Router, db *gorm.DB, cfg *config.Config) {
-	svc := NewJournalService(db, cfg.GLMAPIKey, cfg.GLMAPIURL, cfg.GLMModel, cfg.AITimeout, cfg.OpenAIAPIKey, cfg.OpenAIModel, cfg.EmotionSenseMLURL)
+	svc := p.newService(db, cfg)
 	handler := NewJournalHandler(svc)
 
-	// Per-user rate limiter for AI-backed endpoints. Keyed on JWT token prefix so each
-	// authenticated user gets their own bucket — prevents a single user from exhausting
-	// AI compute quota or running up unbounded GLM costs.
+	// Per-user rate limiters for AI-backed endpoints. Each user gets their own
+	// bucket — prevents a single user from exhausting AI compute quota or
+	// running up unbounded GLM costs.
 	// weekly-report and notification-config: 5 per hour (heavy AI, some have ?refresh bypass)
 	// prompts and per-entry analyze: 10 per hour (lighter, but still AI-backed)
-	aiHeavyLimiter := limiter.New(limiter.Config{
-		Max:               5,
-		Expiration:        1 * time.Hour,
-		LimiterMiddleware: limiter.SlidingWindow{},
-		KeyGenerator: func(c *fiber.Ctx) string {
-			auth := c.Get("Authorization")
-			if len(auth) > 39 { // "Bearer " (7) + 32 chars minimum
-				return "ai_heavy:" + auth[7:39]
-			}
-			return "ai_heavy:ip:" + c.IP()
-		},
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   true,
-				"message": "AI rate limit exceeded. Please try again in an hour.",
-			})
-		},
-	})
-	aiLightLimiter := limiter.New(limiter.Config{
-		Max:               10,
-		Expiration:        1 * time.Hour,
-		LimiterMiddleware: limiter.SlidingWindow{},
-		KeyGenerator: func(c *fiber.Ctx) string {
-			auth := c.Get("Authorization")
-			if len(auth) > 39 {
-				return "ai_light:" + auth[7:39]
-			}
-			return "ai_light:ip:" + c.IP()
-		},
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   true,
-				"message": "AI rate limit exceeded. Please try again in an hour.",
-			})
-		},
-	})
+	aiHeavyLimiter := sdk.AILimiter(p.ID(), "ai_heavy", 5)
+	aiLightLimiter := sdk.AILimiter(p.ID(), "ai_light", 10)
 
 	// Journal CRUD routes
-	router.Post("/journals", handler.Create)
-	router.Get("/journals", handler.List)
-	router.Get("/journals/search", handler.Search)
-	router.Get("/journals/streak", handler.GetStreak)
-	router.Get("/journals/insights", handler.GetWeeklyInsights)
+	router.Post("/journals", sdk.Handle(handler.Create))
+	router.Get("/journals", sdk.Handle(handler.List))
+	router.Get("/journals/search", sdk.Handle(handler.Search))
+	router.Get("/journals/streak", sdk.Handle(handler.GetStreak))
+	router.Get("/journals/insights", sdk.Handle(handler.GetWeeklyInsights))
 
 	// AI routes (MUST come before :id catch-all)
-	router.Get("/journals/prompts", aiLightLimiter, handler.GetPrompts)
-	router.Get("/journals/weekly-report", aiHeavyLimiter, handler.GetWeeklyReport)
-	router.Get("/journals/flashbacks", handler.GetFlashbacks)
-	router.Get("/journals/notification-config", aiHeavyLimiter, handler.GetNotificationConfig)
-	router.Get("/journals/therapist-export", aiHeavyLimiter, handler.TherapistExport)
+	router.Get("/journals/prompts", aiLightLimiter, sdk.Handle(handler.GetPrompts))
+	router.Get("/journals/weekly-report", aiHeavyLimiter, sdk.Handle(handler.GetWeeklyReport))
+	router.Get("/journals/flashbacks", sdk.Handle(handler.GetFlashbacks))
+	router.Get("/journals/notification-config", aiHeavyLimiter, sdk.Handle(handler.GetNotificationConfig))
+	router.Get("/journals/therapist-export", aiHeavyLimiter, sdk.Handle(handler.TherapistExport))
 	// /journals/therapist-report is the spec-required alias for the same feature.
-	router.Get("/journals/therapist-report", aiHeavyLimiter, handler.TherapistReport)
-	router.Get("/journals/notification-timing", handler.GetNotificationTiming)
+	router.Get("/journals/therapist-report", aiHeavyLimiter, sdk.Handle(handler.TherapistReport))
+	router.Get("/journals/notification-timing", sdk.Handle(handler.GetNotificationTiming))
 
 	// AI semantic search and ask-your-journal (MUST come before :id catch-all)
-	router.Get("/journals/ai-search", aiLightLimiter, handler.AISearch)
+	router.Get("/journals/ai-search", aiLightLimiter, sdk.Handle(handler.AISearch))
 
 	// askLimiter: 5 req/hr — semantic ask uses embedding + GPT-4o-mini (expensive).
-	askLimiter := limiter.New(limiter.Config{
-		Max:               5,
-		Expiration:        1 * time.Hour,
-		LimiterMiddleware: limiter.SlidingWindow{},
-		KeyGenerator: func(c *fiber.Ctx) string {
-			auth := c.Get("Authorization")
-			if len(auth) > 39 {
-				return "ask:" + auth[7:39]
-			}
-			return "ask:ip:" + c.IP()
-		},
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   true,
-				"message": "Ask rate limit exceeded. Please try again in an hour.",
-			})
-		},
+	askLimiter := sdk.Limiter(p.ID(), sdk.Limit{
+		Name:    "ask",
+		Max:     5,
+		Message: "Ask rate limit exceeded. Please try again in an hour.",
 	})
-	router.Post("/journals/ask", askLimiter, handler.AskJournal)
+	router.Post("/journals/ask", askLimiter, sdk.Handle(handler.AskJournal))
 
 	// Quick entry — minimal structured entries (gratitude / bullet / word).
-	router.Post("/journals/quick", handler.CreateQuickEntry)
+	router.Post("/journals/quick", sdk.Handle(handler.CreateQuickEntry))
 
 	// Export — full journal export (JSON default, CSV with ?format=csv).
 	router.Get("/journals/export", handler.Export)
 
 	// On This Day — historical entries from the same calendar day in prior years.
-	router.Get("/journals/on-this-day", handler.OnThisDay)
+	router.Get("/journals/on-this-day", sdk.Handle(handler.OnThisDay))
 
 	// Writing prompts — DB-only, no AI, no rate limit (MUST be before :id catch-all).
-	router.Get("/journals/writing-prompts", handler.GetWritingPrompts)
+	router.Get("/journals/writing-prompts", sdk.Handle(handler.GetWritingPrompts))
 
 	// Per-user rate limiters for upload endpoints.
 	// Photo: 20 uploads/hour — prevents disk exhaustion from a single authenticated user.
 	// Transcribe: 10/hour — each call proxies to paid Whisper API (cost control + disk).
-	uploadPhotoLimiter := limiter.New(limiter.Config{
-		Max:               20,
-		Expiration:        1 * time.Hour,
-		LimiterMiddleware: limiter.SlidingWindow{},
-		KeyGenerator: func(c *fiber.Ctx) string {
-			auth := c.Get("Authorization")
-			if len(auth) > 39 {
-				return "upload_photo:" + auth[7:39]
-			}
-			return "upload_photo:ip:" + c.IP()
-		},
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   true,
-				"message": "Upload rate limit exceeded. Please try again in an hour.",
-			})
-		},
+	uploadPhotoLimiter := sdk.Limiter(p.ID(), sdk.Limit{
+		Name:    "upload_photo",
+		Max:     20,
+		Message: "Upload rate limit exceeded. Please try again in an hour.",
 	})
-	transcribeLimiter := limiter.New(limiter.Config{
-		Max:               10,
-		Expiration:        1 * time.Hour,
-		LimiterMiddleware: limiter.SlidingWindow{},
-		KeyGenerator: func(c *fiber.Ctx) string {
-			auth := c.Get("Authorization")
-			if len(auth) > 39 {
-				return "transcribe:" + auth[7:39]
-			}
-			return "transcribe:ip:" + c.IP()
-		},
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   true,
-				"message": "Transcription rate limit exceeded. Please try again in an hour.",
-			})
-		},
+	transcribeLimiter := sdk.Limiter(p.ID(), sdk.Limit{
+		Name:    "transcribe",
+		Max:     10,
+		Message: "Transcription rate limit exceeded. Please try again in an hour.",
 	})
 
 	// Upload routes — photo storage and audio transcription (MUST come before :id catch-all).
 	// Protected by JWT (upstream middleware) + per-user rate limiters above.
 	// Use cfg.UploadsRoot (set via UPLOADS_ROOT env var) so the path is correct
 	// regardless of the process working directory. Defaults to "./uploads".
-	uploadHandler := NewUploadHandler(cfg.OpenAIAPIKey, cfg.FalAPIKey, cfg.AITimeout, cfg.UploadsRoot)
-	router.Post("/journals/upload-photo", uploadPhotoLimiter, uploadHandler.UploadPhoto)
-	router.Post("/journals/transcribe", transcribeLimiter, uploadHandler.Transcribe)
+	uploadHandler := NewUploadHandler(cfg.OpenAIAPIKey, cfg.OpenAIBaseURL, cfg.FalAPIKey, cfg.AITimeout, cfg.UploadsRoot)
+	router.Post("/journals/upload-photo", uploadPhotoLimiter, sdk.Handle(uploadHandler.UploadPhoto))
+	router.Post("/journals/transcribe", transcribeLimiter, sdk.Handle(uploadHandler.Transcribe))
 
 	// Parameterized routes (MUST be last)
-	router.Get("/journals/:id", handler.Get)
-	router.Put("/journals/:id", handler.Update)
-	router.Delete("/journals/:id", handler.Delete)
-	router.Post("/journals/:id/analyze", aiLightLimiter, handler.AnalyzeEntry)
-	router.Get("/journals/:id/analysis", handler.GetEntryAnalysis)
+	router.Get("/journals/:id", sdk.Handle(handler.Get))
+	router.Put("/journals/:id", sdk.Handle(handler.Update))
+	router.Delete("/journals/:id", sdk.Handle(handler.Delete))
+	router.Post("/journals/:id/analyze", aiLightLimiter, sdk.Handle(handler.AnalyzeEntry))
+	router.Get("/journals/:id/analysis", sdk.Handle(handler.GetEntryAnalysis))
 }
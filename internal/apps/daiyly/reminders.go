@@ -0,0 +1,167 @@
+package daiyly
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/localtime"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var defaultDailyMessages = []NotificationMessage{
+	{Title: "Time to Journal", Body: "Take a moment to reflect on your day."},
+	{Title: "How was your day?", Body: "A few words can make a big difference."},
+	{Title: "Your journal awaits", Body: "What made you smile today?"},
+	{Title: "Pause and reflect", Body: "Even one sentence counts."},
+	{Title: "Evening check-in", Body: "How are you really feeling right now?"},
+	{Title: "Capture this moment", Body: "Future you will thank you for writing today."},
+	{Title: "Daily reflection time", Body: "What's on your mind tonight?"},
+}
+
+var defaultStreakMessages = []NotificationMessage{
+	{Title: "Keep your streak alive!", Body: "A quick entry is all it takes."},
+	{Title: "Don't break the chain!", Body: "Your consistency is building something great."},
+	{Title: "Streak check!", Body: "You haven't written today yet — still time!"},
+	{Title: "Almost missed today!", Body: "Just one sentence keeps your streak going."},
+	{Title: "Your streak matters", Body: "Small habits lead to big changes."},
+	{Title: "Last chance today!", Body: "A quick note keeps your journey alive."},
+	{Title: "Streak reminder", Body: "Don't let today slip by without a word."},
+}
+
+// suggestedReminderHour returns the local hour before the user's peak
+// journaling hour over the given entries, or 20:00 with fewer than 3 entries.
+// When several hours tie for the peak the earliest one wins.
+func suggestedReminderHour(entries []JournalEntry, loc *time.Location) int {
+	if len(entries) < 3 {
+		return 20 // default 8 PM
+	}
+
+	// Count entries per hour
+	var hourCounts [24]int
+	for _, e := range entries {
+		hourCounts[e.EntryDate.In(loc).Hour()]++
+	}
+	// Find peak hour; ties go to the earliest hour
+	peakHour := 20
+	peakCount := 0
+	for h, c := range hourCounts {
+		if c > peakCount {
+			peakCount = c
+			peakHour = h
+		}
+	}
+	// Suggest 1 hour before peak (remind before they usually write)
+	suggestedHour := peakHour - 1
+	if suggestedHour < 0 {
+		suggestedHour = 23
+	}
+	return suggestedHour
+}
+
+// DueReminders implements services.ReminderSource. Users whose suggested
+// local hour is now and who haven't written today (their local day) get a
+// streak reminder if they have a streak to lose, a journaling reminder
+// otherwise. Copy comes from today's AI-personalised messages when
+// GET /notification-config has cached them.
+func (p *DaiylyPlugin) DueReminders(db *gorm.DB, appID string, userIDs []uuid.UUID, now time.Time) ([]services.Reminder, error) {
+	var users []models.User
+	if err := db.Scopes(tenant.ForTenant(appID)).
+		Select("id", "timezone").
+		Where("id IN ?", userIDs).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	locByUser := make(map[uuid.UUID]*time.Location, len(users))
+	for _, u := range users {
+		locByUser[u.ID] = localtime.Location(u.Timezone)
+	}
+
+	// Look back 31 days so the 30-day window covers every zone's local day.
+	var entries []JournalEntry
+	if err := db.Scopes(tenant.ForTenant(appID)).
+		Select("user_id", "entry_date").
+		Where("user_id IN ? AND entry_date >= ?", userIDs, now.AddDate(0, 0, -31)).
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	byUser := make(map[uuid.UUID][]JournalEntry)
+	for _, e := range entries {
+		byUser[e.UserID] = append(byUser[e.UserID], e)
+	}
+
+	var due []uuid.UUID
+	for _, userID := range userIDs {
+		loc, ok := locByUser[userID]
+		if !ok {
+			continue // deleted user with leftover tokens
+		}
+		today := localtime.Day(now, loc)
+		wroteToday := false
+		for _, e := range byUser[userID] {
+			if localtime.Day(e.EntryDate, loc).Equal(today) {
+				wroteToday = true
+				break
+			}
+		}
+		if !wroteToday && suggestedReminderHour(byUser[userID], loc) == now.In(loc).Hour() {
+			due = append(due, userID)
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	// The notification-config cache is keyed by UTC date, matching its writer.
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	streakByUser, err := newJournalStreaks(db).ForUsers(appID, due)
+	if err != nil {
+		return nil, err
+	}
+
+	var cached []NotificationConfigCache
+	if err := db.Where("app_id = ? AND user_id IN ? AND config_date = ?", appID, due, today).Find(&cached).Error; err != nil {
+		return nil, err
+	}
+	cachedByUser := make(map[uuid.UUID]string, len(cached))
+	for _, c := range cached {
+		cachedByUser[c.UserID] = c.MessagesJSON
+	}
+
+	reminders := make([]services.Reminder, 0, len(due))
+	for _, userID := range due {
+		daily, streak := defaultDailyMessages, defaultStreakMessages
+		if raw := cachedByUser[userID]; raw != "" {
+			var msgs struct {
+				Daily  []NotificationMessage `json:"daily"`
+				Streak []NotificationMessage `json:"streak"`
+			}
+			if err := json.Unmarshal([]byte(raw), &msgs); err == nil {
+				if len(msgs.Daily) > 0 {
+					daily = msgs.Daily
+				}
+				if len(msgs.Streak) > 0 {
+					streak = msgs.Streak
+				}
+			}
+		}
+
+		kind, pool := "journal_reminder", daily
+		if streakByUser[userID].CurrentStreak > 0 {
+			kind, pool = "streak_reminder", streak
+		}
+		// Seven messages per pool: one per local weekday.
+		msg := pool[int(now.In(locByUser[userID]).Weekday())%len(pool)]
+		reminders = append(reminders, services.Reminder{
+			UserID: userID,
+			Kind:   kind,
+			Title:  msg.Title,
+			Body:   msg.Body,
+		})
+	}
+	return reminders, nil
+}
@@ -0,0 +1,39 @@
+package daiyly
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSuggestedReminderHour(t *testing.T) {
+	at := func(hours ...int) []JournalEntry {
+		entries := make([]JournalEntry, len(hours))
+		for i, h := range hours {
+			entries[i].EntryDate = time.Date(2026, 3, 1+i, h, 30, 0, 0, time.UTC)
+		}
+		return entries
+	}
+	istanbul := time.FixedZone("TRT", 3*3600)
+
+	tests := []struct {
+		name    string
+		entries []JournalEntry
+		loc     *time.Location
+		want    int
+	}{
+		{"too few entries", at(7, 7), time.UTC, 20},
+		{"single peak", at(21, 21, 9), time.UTC, 20},
+		{"peak at midnight wraps", at(0, 0, 0), time.UTC, 23},
+		{"local hour", at(18, 18, 18), istanbul, 20},
+		{"tie goes to the earliest hour", at(22, 8, 22, 8, 15), time.UTC, 7},
+		{"three-way tie", at(23, 6, 12), time.UTC, 5},
+	}
+	for _, tt := range tests {
+		// Run repeatedly: a tie broken by map order would flake.
+		for i := 0; i < 20; i++ {
+			if got := suggestedReminderHour(tt.entries, tt.loc); got != tt.want {
+				t.Fatalf("%s: hour = %d, want %d", tt.name, got, tt.want)
+			}
+		}
+	}
+}
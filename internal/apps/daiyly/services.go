@@ -14,58 +14,42 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/achievements"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/sdk"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/locale"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/localtime"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 var (
-	ErrInvalidMoodEmoji     = errors.New("invalid mood emoji")
-	ErrInvalidMoodScore     = errors.New("mood score must be between 1 and 100")
-	ErrInvalidCardColor     = errors.New("invalid card color")
-	ErrInvalidPhotoURL      = errors.New("photo_url must be an https:// URL of at most 2048 characters")
-	ErrInvalidAudioURL      = errors.New("audio_url must be an https:// URL of at most 2048 characters")
-	ErrJournalNotFound      = errors.New("journal entry not found")
-	ErrNotOwner             = errors.New("you do not own this journal entry")
-	ErrContentInappropriate = errors.New("content contains inappropriate language")
-	ErrAnalysisNotFound     = errors.New("analysis not found")
+	ErrJournalNotFound  = errors.New("journal entry not found")
+	ErrNotOwner         = errors.New("you do not own this journal entry")
+	ErrAnalysisNotFound = errors.New("analysis not found")
+	ErrInvalidEntryType = errors.New("invalid entry type: must be gratitude, bullet, or word")
+	ErrNoItems          = errors.New("items must not be empty")
+	ErrTooManyItems     = errors.New("items must not exceed 50 entries")
 )
 
-// ContentFilterService provides content moderation functionality.
-type ContentFilterService struct {
-	blockedWords []string
-}
-
-func NewContentFilterService(blockedWords []string) *ContentFilterService {
-	return &ContentFilterService{blockedWords: blockedWords}
-}
-
-func (f *ContentFilterService) FilterContent(content string) (bool, string) {
-	if f == nil {
-		return false, ""
-	}
-	contentLower := strings.ToLower(content)
-	for _, word := range f.blockedWords {
-		if strings.Contains(contentLower, strings.ToLower(word)) {
-			return true, "contains blocked word"
-		}
-	}
-	return false, ""
-}
-
 type JournalService struct {
 	db                *gorm.DB
-	contentFilter     *ContentFilterService
+	events            *events.Bus
+	streaks           *streaks.Engine
+	achievements      *achievements.Engine
 	aiAPIKey          string
 	aiAPIURL          string
 	aiModel           string
 	aiTimeout         time.Duration
 	openAIAPIKey      string
 	openAIModel       string
+	openAIBaseURL     string
 	emotionSenseMLURL string
 }
 
-func NewJournalService(db *gorm.DB, aiAPIKey, aiAPIURL, aiModel string, aiTimeout time.Duration, openAIAPIKey, openAIModel, emotionSenseMLURL string) *JournalService {
+func NewJournalService(db *gorm.DB, bus *events.Bus, aiAPIKey, aiAPIURL, aiModel string, aiTimeout time.Duration, openAIAPIKey, openAIModel, openAIBaseURL, emotionSenseMLURL string) *JournalService {
 	if aiAPIURL == "" {
 		aiAPIURL = "https://api.z.ai/api/paas/v4/chat/completions"
 	}
@@ -78,45 +62,30 @@ func NewJournalService(db *gorm.DB, aiAPIKey, aiAPIURL, aiModel string, aiTimeou
 	if openAIModel == "" {
 		openAIModel = "gpt-4o-mini"
 	}
+	if openAIBaseURL == "" {
+		openAIBaseURL = "https://api.openai.com/v1"
+	}
 	return &JournalService{
 		db:                db,
+		events:            bus,
+		streaks:           newJournalStreaks(db),
+		achievements:      achievements.New(db, journalAchievements),
 		aiAPIKey:          aiAPIKey,
 		aiAPIURL:          aiAPIURL,
 		aiModel:           aiModel,
 		aiTimeout:         aiTimeout,
 		openAIAPIKey:      openAIAPIKey,
 		openAIModel:       openAIModel,
+		openAIBaseURL:     openAIBaseURL,
 		emotionSenseMLURL: emotionSenseMLURL,
 	}
 }
 
+// CreateEntry saves a journal entry. req has been validated by the handler.
 func (s *JournalService) CreateEntry(appID string, userID uuid.UUID, req CreateJournalRequest) (*JournalEntry, error) {
-	if !isValidMoodEmoji(req.MoodEmoji) {
-		return nil, ErrInvalidMoodEmoji
-	}
-
-	if req.MoodScore < 1 || req.MoodScore > 100 {
-		return nil, ErrInvalidMoodScore
-	}
-
-	if len(req.Content) > 50000 {
-		return nil, errors.New("content too long (max 50000 characters)")
-	}
-
-	// Content filtering check
-	if s.contentFilter != nil && req.Content != "" {
-		flagged, _ := s.contentFilter.FilterContent(req.Content)
-		if flagged {
-			return nil, ErrContentInappropriate
-		}
-	}
-
 	if req.CardColor == "" {
 		req.CardColor = "#dbeafe"
 	}
-	if !isValidCardColor(req.CardColor) {
-		return nil, ErrInvalidCardColor
-	}
 
 	// Use client's local date if provided and valid (prevents timezone drift for late-night entries).
 	// Format: "YYYY-MM-DD". Falls back to server UTC if missing or invalid.
@@ -131,18 +100,6 @@ func (s *JournalService) CreateEntry(appID string, userID uuid.UUID, req CreateJ
 		}
 	}
 
-	if !isValidStorageURL(req.PhotoURL) {
-		return nil, ErrInvalidPhotoURL
-	}
-
-	if !isValidStorageURL(req.AudioURL) {
-		return nil, ErrInvalidAudioURL
-	}
-
-	if len(req.Transcript) > 50000 {
-		return nil, errors.New("transcript too long (max 50000 characters)")
-	}
-
 	entry := JournalEntry{
 		ID:         uuid.New(),
 		AppID:      appID,
@@ -158,7 +115,7 @@ func (s *JournalService) CreateEntry(appID string, userID uuid.UUID, req CreateJ
 		IsPrivate:  req.IsPrivate,
 	}
 
-	if err := s.db.Create(&entry).Error; err != nil {
+	if err := s.saveEntry(&entry); err != nil {
 		return nil, err
 	}
 
@@ -176,13 +133,21 @@ func (s *JournalService) CreateEntry(appID string, userID uuid.UUID, req CreateJ
 	// Fire async emotion analysis (non-blocking)
 	s.analyzeEmotionAsync(appID, entry.ID.String(), entry.Content)
 
-	// Fire async embedding generation for semantic search (non-blocking)
-	s.storeEmbeddingAsync(appID, userID, entry.ID, entry.Content)
-
 	return &entry, nil
 }
 
-func (s *JournalService) GetEntries(appID string, userID uuid.UUID, limit, offset int) ([]JournalEntry, int64, error) {
+// saveEntry inserts the entry and publishes journal.entry_created in the same
+// transaction, so subscribers (e.g. embeddings) see every committed entry.
+func (s *JournalService) saveEntry(entry *JournalEntry) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return s.events.Publish(tx, entry.AppID, entry.UserID, events.JournalEntryCreated, events.JournalEntryCreatedPayload{EntryID: entry.ID})
+	})
+}
+
+func (s *JournalService) GetEntries(appID string, userID uuid.UUID, page sdk.Page) ([]JournalEntry, int64, error) {
 	var entries []JournalEntry
 	var total int64
 
@@ -190,10 +155,7 @@ func (s *JournalService) GetEntries(appID string, userID uuid.UUID, limit, offse
 		return nil, 0, err
 	}
 
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).
-		Order("entry_date DESC").
-		Limit(limit).
-		Offset(offset).
+	err := page.Apply(s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID), "entry_date").
 		Find(&entries).Error
 
 	return entries, total, err
@@ -274,28 +236,11 @@ func (s *JournalService) UpdateEntry(appID string, userID uuid.UUID, entryID uui
 		return nil, err
 	}
 
-	if req.Content != nil && len(*req.Content) > 50000 {
-		return nil, errors.New("content too long (max 50000 characters)")
-	}
-
-	if req.Content != nil && *req.Content != "" && s.contentFilter != nil {
-		flagged, _ := s.contentFilter.FilterContent(*req.Content)
-		if flagged {
-			return nil, ErrContentInappropriate
-		}
-	}
-
 	if req.MoodEmoji != nil {
-		if !isValidMoodEmoji(*req.MoodEmoji) {
-			return nil, ErrInvalidMoodEmoji
-		}
 		entry.MoodEmoji = *req.MoodEmoji
 	}
 
 	if req.MoodScore != nil {
-		if *req.MoodScore < 1 || *req.MoodScore > 100 {
-			return nil, ErrInvalidMoodScore
-		}
 		entry.MoodScore = *req.MoodScore
 	}
 
@@ -304,30 +249,18 @@ func (s *JournalService) UpdateEntry(appID string, userID uuid.UUID, entryID uui
 	}
 
 	if req.PhotoURL != nil {
-		if !isValidStorageURL(*req.PhotoURL) {
-			return nil, ErrInvalidPhotoURL
-		}
 		entry.PhotoURL = *req.PhotoURL
 	}
 
 	if req.AudioURL != nil {
-		if !isValidStorageURL(*req.AudioURL) {
-			return nil, ErrInvalidAudioURL
-		}
 		entry.AudioURL = *req.AudioURL
 	}
 
 	if req.Transcript != nil {
-		if len(*req.Transcript) > 50000 {
-			return nil, errors.New("transcript too long (max 50000 characters)")
-		}
 		entry.Transcript = *req.Transcript
 	}
 
 	if req.CardColor != nil {
-		if !isValidCardColor(*req.CardColor) {
-			return nil, ErrInvalidCardColor
-		}
 		entry.CardColor = *req.CardColor
 	}
 
@@ -357,82 +290,21 @@ func (s *JournalService) DeleteEntry(appID string, userID uuid.UUID, entryID uui
 }
 
 func (s *JournalService) GetStreak(appID string, userID uuid.UUID) (*JournalStreak, error) {
-	var streak JournalStreak
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		streak = JournalStreak{
-			ID:            uuid.New(),
-			AppID:         appID,
-			UserID:        userID,
-			CurrentStreak: 0,
-			LongestStreak: 0,
-			TotalEntries:  0,
-		}
-		if createErr := s.db.Create(&streak).Error; createErr != nil {
-			return nil, createErr
-		}
-		return &streak, nil
-	}
+	st, err := s.streaks.Get(appID, userID)
 	if err != nil {
 		return nil, err
 	}
-	return &streak, nil
+	return journalStreakFrom(st), nil
 }
 
+// UpdateStreak records a journaling day in the user's local timezone.
 func (s *JournalService) UpdateStreak(appID string, userID uuid.UUID) error {
-	streak, err := s.GetStreak(appID, userID)
+	res, err := s.streaks.Record(appID, userID, time.Now())
 	if err != nil {
 		return err
 	}
-
-	today := time.Now().UTC().Truncate(24 * time.Hour)
-	lastEntry := streak.LastEntryDate.UTC().Truncate(24 * time.Hour)
-
-	// Already journaled today — nothing to do.
-	if today.Equal(lastEntry) {
-		return nil
-	}
-
-	yesterday := today.AddDate(0, 0, -1)
-	twoDaysAgo := today.AddDate(0, 0, -2)
-
-	if lastEntry.Equal(yesterday) {
-		// Consecutive day — extend streak normally.
-		// If grace was active it is now consumed; clear it.
-		streak.CurrentStreak++
-		streak.GracePeriodActive = false
-	} else if lastEntry.Equal(twoDaysAgo) && streak.CurrentStreak >= 7 {
-		// User missed exactly one day and has a streak of 7+.
-		// Grant grace period if they haven't used one in the last 7 days.
-		graceAllowed := streak.GracePeriodUsedAt == nil ||
-			time.Since(*streak.GracePeriodUsedAt) >= 7*24*time.Hour
-
-		if graceAllowed {
-			// Keep streak alive; mark grace active so the client can show a warning.
-			streak.GracePeriodActive = true
-			now := time.Now().UTC()
-			streak.GracePeriodUsedAt = &now
-			// Do NOT increment CurrentStreak — the user hasn't journaled yet today.
-			// Increment TotalEntries below still happens because they ARE journaling now.
-		} else {
-			// Grace already used within 7 days — reset streak.
-			streak.CurrentStreak = 1
-			streak.GracePeriodActive = false
-		}
-	} else {
-		// Gap is 3+ days or streak < 3: reset.
-		streak.CurrentStreak = 1
-		streak.GracePeriodActive = false
-	}
-
-	if streak.CurrentStreak > streak.LongestStreak {
-		streak.LongestStreak = streak.CurrentStreak
-	}
-
-	streak.TotalEntries++
-	streak.LastEntryDate = time.Now().UTC()
-
-	return s.db.Save(streak).Error
+	s.achievements.EmitStreak(appID, userID, "journal", res.Streak)
+	return nil
 }
 
 func (s *JournalService) GetWeeklyInsights(appID string, userID uuid.UUID) (*WeeklyInsights, error) {
@@ -529,13 +401,11 @@ func (s *JournalService) GetWeeklyInsights(appID string, userID uuid.UUID) (*Wee
 	}
 
 	// Fetch streak data
-	var streak JournalStreak
-	streakResult := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak)
 	streakData := StreakData{Current: 0, Longest: 0, Total: 0}
-	if streakResult.Error == nil {
+	if streak, err := s.streaks.Get(appID, userID); err == nil {
 		streakData.Current = streak.CurrentStreak
 		streakData.Longest = streak.LongestStreak
-		streakData.Total = streak.TotalEntries
+		streakData.Total = streak.TotalCount
 	}
 
 	return &WeeklyInsights{
@@ -596,24 +466,6 @@ func isValidCardColor(color string) bool {
 	return false
 }
 
-// isValidStorageURL accepts empty values (field is optional) and requires https:// scheme
-// and a maximum length of 2048 chars. This prevents: (1) excessively long URLs being
-// stored in the DB, (2) http:// URLs that would cause mixed-content on the client.
-// Used for both photo_url and audio_url fields.
-func isValidStorageURL(url string) bool {
-	if url == "" {
-		return true
-	}
-	if len(url) > 2048 {
-		return false
-	}
-	return strings.HasPrefix(url, "https://")
-}
-
-// isValidPhotoURL is an alias kept for backward compatibility.
-// New callers should use isValidStorageURL.
-func isValidPhotoURL(url string) bool { return isValidStorageURL(url) }
-
 // --- OpenAI Integration ---
 
 type openAIChatRequest struct {
@@ -634,6 +486,12 @@ type openAIChatResponse struct {
 	} `json:"choices"`
 }
 
+// localized appends the instruction to answer in the user's language to an
+// AI system prompt.
+func (s *JournalService) localized(appID string, userID uuid.UUID, systemPrompt string) string {
+	return systemPrompt + locale.PromptInstruction(locale.ForUser(s.db, appID, userID))
+}
+
 func (s *JournalService) callOpenAI(systemPrompt, userPrompt string) (string, error) {
 	reqBody := openAIChatRequest{
 		Model: s.aiModel,
@@ -707,7 +565,7 @@ func (s *JournalService) callOpenAIChat(systemPrompt, userPrompt string) (string
 		return "", fmt.Errorf("marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", s.openAIBaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("create request: %w", err)
 	}
@@ -860,7 +718,7 @@ Rules:
 
 	userPrompt := fmt.Sprintf("Mood: %s (score: %d/100)\n\n%s", entry.MoodEmoji, entry.MoodScore, entry.Content)
 
-	content, err := s.callOpenAI(systemPrompt, userPrompt)
+	content, err := s.callOpenAI(s.localized(appID, userID, systemPrompt), userPrompt)
 	if err != nil {
 		s.db.Model(&analysis).Update("status", "failed")
 		return
@@ -983,7 +841,7 @@ Rules:
 - Keep each prompt under 100 characters
 - Be warm, encouraging, and non-judgmental`
 
-	content, err := s.callOpenAI(systemPrompt, summary.String())
+	content, err := s.callOpenAI(s.localized(appID, userID, systemPrompt), summary.String())
 	if err != nil {
 		return &PromptsResponse{Prompts: genericPrompts}, nil
 	}
@@ -1099,7 +957,7 @@ Rules:
 	statsContext := fmt.Sprintf("Stats: %d entries, avg mood %d/100, trend: %s, top mood: %s\n\nEntries:\n%s",
 		stats.TotalEntries, stats.AverageMoodScore, stats.MoodTrend, stats.TopMood, summary.String())
 
-	content, err := s.callOpenAI(systemPrompt, statsContext)
+	content, err := s.callOpenAI(s.localized(appID, userID, systemPrompt), statsContext)
 	if err != nil {
 		return &WeeklyReportResponse{
 			Narrative:       fmt.Sprintf("This week you wrote %d entries with an average mood score of %d.", stats.TotalEntries, stats.AverageMoodScore),
@@ -1195,33 +1053,9 @@ func (s *JournalService) GetNotificationConfig(appID string, userID uuid.UUID) (
 		Where("user_id = ? AND entry_date >= ?", userID, thirtyDaysAgo).
 		Find(&entries)
 
-	suggestedHour := 20 // default 8 PM
+	suggestedHour := suggestedReminderHour(entries, localtime.ForUser(s.db, appID, userID))
 	suggestedMinute := 0
 
-	if len(entries) >= 3 {
-		// Count entries per hour
-		hourCounts := make(map[int]int)
-		for _, e := range entries {
-			h := e.EntryDate.Hour()
-			hourCounts[h]++
-		}
-		// Find peak hour
-		peakHour := 20
-		peakCount := 0
-		for h, c := range hourCounts {
-			if c > peakCount {
-				peakCount = c
-				peakHour = h
-			}
-		}
-		// Suggest 1 hour before peak (remind before they usually write)
-		suggestedHour = peakHour - 1
-		if suggestedHour < 0 {
-			suggestedHour = 23
-		}
-		suggestedMinute = 0
-	}
-
 	// --- 2. Check daily cache for messages ---
 	today := time.Now().UTC().Truncate(24 * time.Hour)
 	var cached NotificationConfigCache
@@ -1242,24 +1076,8 @@ func (s *JournalService) GetNotificationConfig(appID string, userID uuid.UUID) (
 	}
 
 	// --- 3. Generate personalized messages via AI ---
-	defaultDaily := []NotificationMessage{
-		{Title: "Time to Journal", Body: "Take a moment to reflect on your day."},
-		{Title: "How was your day?", Body: "A few words can make a big difference."},
-		{Title: "Your journal awaits", Body: "What made you smile today?"},
-		{Title: "Pause and reflect", Body: "Even one sentence counts."},
-		{Title: "Evening check-in", Body: "How are you really feeling right now?"},
-		{Title: "Capture this moment", Body: "Future you will thank you for writing today."},
-		{Title: "Daily reflection time", Body: "What's on your mind tonight?"},
-	}
-	defaultStreak := []NotificationMessage{
-		{Title: "Keep your streak alive!", Body: "A quick entry is all it takes."},
-		{Title: "Don't break the chain!", Body: "Your consistency is building something great."},
-		{Title: "Streak check!", Body: "You haven't written today yet — still time!"},
-		{Title: "Almost missed today!", Body: "Just one sentence keeps your streak going."},
-		{Title: "Your streak matters", Body: "Small habits lead to big changes."},
-		{Title: "Last chance today!", Body: "A quick note keeps your journey alive."},
-		{Title: "Streak reminder", Body: "Don't let today slip by without a word."},
-	}
+	defaultDaily := defaultDailyMessages
+	defaultStreak := defaultStreakMessages
 
 	if s.aiAPIKey == "" || len(entries) == 0 {
 		return &NotificationConfigResponse{
@@ -1271,9 +1089,8 @@ func (s *JournalService) GetNotificationConfig(appID string, userID uuid.UUID) (
 	}
 
 	// Build context for AI
-	var streak JournalStreak
 	streakCount := 0
-	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error; err == nil {
+	if streak, err := s.streaks.Get(appID, userID); err == nil {
 		streakCount = streak.CurrentStreak
 	}
 
@@ -1320,7 +1137,7 @@ Rules:
 	userContext := fmt.Sprintf("User context: %d-day streak, avg mood %d/100, top mood %s, %d entries in last 30 days",
 		streakCount, avgScore, topMood, len(entries))
 
-	content, err := s.callOpenAI(systemPrompt, userContext)
+	content, err := s.callOpenAI(s.localized(appID, userID, systemPrompt), userContext)
 	if err != nil {
 		return &NotificationConfigResponse{
 			SuggestedHour:   suggestedHour,
@@ -1494,7 +1311,7 @@ Rules:
 		periodLabel, entryCount, avgScore, moodTrend, summary.String(),
 	)
 
-	aiContent, err := s.callOpenAIChat(systemPrompt, statsContext)
+	aiContent, err := s.callOpenAIChat(s.localized(appID, userID, systemPrompt), statsContext)
 	if err != nil {
 		slog.Warn("[daiyly] therapist export AI call failed", "user", userID, "error", err)
 		return &TherapistExportResponse{
@@ -1623,12 +1440,13 @@ func (s *JournalService) GetNotificationTiming(appID string, userID uuid.UUID) (
 		}, nil
 	}
 
-	// Count entries per hour and track unique journaling days.
+	// Count entries per local hour and track unique local journaling days.
+	loc := localtime.ForUser(s.db, appID, userID)
 	hourCounts := make(map[int]int)
 	uniqueDays := map[string]bool{}
 	for _, e := range entries {
-		hourCounts[e.CreatedAt.UTC().Hour()]++
-		uniqueDays[e.CreatedAt.UTC().Format("2006-01-02")] = true
+		hourCounts[e.CreatedAt.In(loc).Hour()]++
+		uniqueDays[localtime.DateString(e.CreatedAt, loc)] = true
 	}
 
 	// Find peak hour.
@@ -1737,7 +1555,7 @@ func (s *JournalService) callOpenAIDirect(systemPrompt, userPrompt string) (stri
 		return "", fmt.Errorf("marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", s.openAIBaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("create request: %w", err)
 	}
@@ -2051,7 +1869,7 @@ func (s *JournalService) AskJournal(appID string, userID uuid.UUID, question str
 
 	userPrompt := fmt.Sprintf("Journal entries (last 90 days):\n%s\n\nQuestion: %s", sb.String(), question)
 
-	rawContent, err := s.callOpenAIDirect(systemPrompt, userPrompt)
+	rawContent, err := s.callOpenAIDirect(s.localized(appID, userID, systemPrompt), userPrompt)
 	if err != nil {
 		return nil, fmt.Errorf("openai ask: %w", err)
 	}
@@ -2113,7 +1931,7 @@ func (s *JournalService) generateEmbedding(text string) ([]float64, error) {
 	if err != nil {
 		return nil, fmt.Errorf("marshal embedding request: %w", err)
 	}
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/embeddings", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", s.openAIBaseURL+"/embeddings", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("create embedding request: %w", err)
 	}
@@ -2172,36 +1990,33 @@ func mathSqrt(x float64) float64 {
 	return z
 }
 
-// storeEmbeddingAsync stores a pre-generated embedding for the given entry in the background.
-func (s *JournalService) storeEmbeddingAsync(appID string, userID, entryID uuid.UUID, content string) {
+// storeEmbedding generates and stores the embedding for an entry. Errors are
+// returned so the event dispatcher retries the entry later.
+func (s *JournalService) storeEmbedding(appID string, userID, entryID uuid.UUID, content string) error {
 	if s.openAIAPIKey == "" || len(strings.Fields(content)) < 10 {
-		return
+		return nil
 	}
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				slog.Warn("[daiyly] storeEmbeddingAsync panic", "panic", r)
-			}
-		}()
-		embedding, err := s.generateEmbedding(content)
-		if err != nil {
-			slog.Warn("[daiyly] generate embedding failed", "entry", entryID, "error", err)
-			return
-		}
-		embJSON, err := json.Marshal(embedding)
-		if err != nil {
-			return
+	embedding, err := s.generateEmbedding(content)
+	if err != nil {
+		return fmt.Errorf("generate embedding: %w", err)
+	}
+	embJSON, err := json.Marshal(embedding)
+	if err != nil {
+		return err
+	}
+	// Upsert: delete existing then insert.
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("entry_id = ? AND app_id = ?", entryID, appID).Delete(&JournalEmbedding{}).Error; err != nil {
+			return err
 		}
-		// Upsert: delete existing then insert.
-		s.db.Where("entry_id = ? AND app_id = ?", entryID, appID).Delete(&JournalEmbedding{})
-		s.db.Create(&JournalEmbedding{
+		return tx.Create(&JournalEmbedding{
 			ID:            uuid.New(),
 			AppID:         appID,
 			UserID:        userID,
 			EntryID:       entryID,
 			EmbeddingJSON: string(embJSON),
-		})
-	}()
+		}).Error
+	})
 }
 
 // backfillEmbeddingsAsync embeds the last 100 entries for a user that don't yet have embeddings.
@@ -2428,13 +2243,13 @@ var validQuickTypes = map[string]bool{
 func (s *JournalService) CreateQuickEntry(appID string, userID uuid.UUID, req QuickEntryRequest) (*JournalEntry, error) {
 	entryType := req.Type
 	if !validQuickTypes[entryType] {
-		return nil, fmt.Errorf("invalid entry type: must be gratitude, bullet, or word")
+		return nil, ErrInvalidEntryType
 	}
 	if len(req.Items) == 0 {
-		return nil, fmt.Errorf("items must not be empty")
+		return nil, ErrNoItems
 	}
 	if len(req.Items) > 50 {
-		return nil, fmt.Errorf("items must not exceed 50 entries")
+		return nil, ErrTooManyItems
 	}
 
 	// Validate each item.
@@ -2504,7 +2319,7 @@ func (s *JournalService) CreateQuickEntry(appID string, userID uuid.UUID, req Qu
 		EntryType: entryType,
 	}
 
-	if err := s.db.Create(&entry).Error; err != nil {
+	if err := s.saveEntry(&entry); err != nil {
 		return nil, err
 	}
 
@@ -2514,9 +2329,6 @@ func (s *JournalService) CreateQuickEntry(appID string, userID uuid.UUID, req Qu
 	// Fire async emotion analysis.
 	s.analyzeEmotionAsync(appID, entry.ID.String(), entry.Content)
 
-	// Fire async embedding generation.
-	s.storeEmbeddingAsync(appID, userID, entry.ID, entry.Content)
-
 	return &entry, nil
 }
 
@@ -2543,7 +2355,8 @@ func (s *JournalService) ExportJournals(appID string, userID uuid.UUID, format s
 
 // GetOnThisDay returns entries from the same calendar day (±2 days window) in prior years.
 func (s *JournalService) GetOnThisDay(appID string, userID uuid.UUID) (*OnThisDayResponse, error) {
-	now := time.Now().UTC()
+	loc := localtime.ForUser(s.db, appID, userID)
+	now := time.Now().In(loc)
 	month := int(now.Month())
 	day := now.Day()
 
@@ -2561,8 +2374,8 @@ func (s *JournalService) GetOnThisDay(appID string, userID uuid.UUID) (*OnThisDa
 	// Exclude entries from current year — we want historical lookbacks only.
 	currentYear := now.Year()
 	if err := s.db.Scopes(tenant.ForTenant(appID)).
-		Where("user_id = ? AND EXTRACT(YEAR FROM entry_date) < ? AND EXTRACT(MONTH FROM entry_date) = ? AND EXTRACT(DAY FROM entry_date) BETWEEN ? AND ?",
-			userID, currentYear, month, dayMin, dayMax).
+		Where("user_id = ? AND EXTRACT(YEAR FROM entry_date AT TIME ZONE ?) < ? AND EXTRACT(MONTH FROM entry_date AT TIME ZONE ?) = ? AND EXTRACT(DAY FROM entry_date AT TIME ZONE ?) BETWEEN ? AND ?",
+			userID, loc.String(), currentYear, loc.String(), month, loc.String(), dayMin, dayMax).
 		Order("entry_date DESC").
 		Limit(20).
 		Find(&entries).Error; err != nil {
@@ -2571,7 +2384,7 @@ func (s *JournalService) GetOnThisDay(appID string, userID uuid.UUID) (*OnThisDa
 
 	result := make([]OnThisDayEntry, 0, len(entries))
 	for _, e := range entries {
-		yearsAgo := currentYear - e.EntryDate.Year()
+		yearsAgo := currentYear - e.EntryDate.In(loc).Year()
 		label := "1 year ago"
 		if yearsAgo > 1 {
 			label = fmt.Sprintf("%d years ago", yearsAgo)
@@ -0,0 +1,70 @@
+package daiyly
+
+import (
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// journalStreakPolicy counts consecutive journaling days. Once a streak
+// reaches 7 days a single missed day is forgiven, at most once a week.
+var journalStreakPolicy = streaks.Policy{
+	MaxGap:         1,
+	GraceMinStreak: 7,
+	GraceCooldown:  7 * 24 * time.Hour,
+}
+
+func newJournalStreaks(db *gorm.DB) *streaks.Engine {
+	return streaks.New(db, "journal", journalStreakPolicy)
+}
+
+// journalStreakFrom maps the shared streak onto the GET /journals/streak
+// response shape.
+func journalStreakFrom(st *models.Streak) *JournalStreak {
+	js := &JournalStreak{
+		ID:                st.ID,
+		AppID:             st.AppID,
+		UserID:            st.UserID,
+		CurrentStreak:     st.CurrentStreak,
+		LongestStreak:     st.LongestStreak,
+		TotalEntries:      st.TotalCount,
+		GracePeriodActive: st.GraceActive,
+		GracePeriodUsedAt: st.GraceUsedAt,
+		CreatedAt:         st.CreatedAt,
+		UpdatedAt:         st.UpdatedAt,
+	}
+	if st.LastActivityAt != nil {
+		js.LastEntryDate = *st.LastActivityAt
+	}
+	return js
+}
+
+// Migrate implements apps.MigrationPlugin, carrying journal_streaks rows over
+// to the shared streaks table.
+func (p *DaiylyPlugin) Migrate(db *gorm.DB) error {
+	_, err := newJournalStreaks(db).ImportLegacy(streaks.Legacy{
+		Table:       "journal_streaks",
+		Total:       "total_entries",
+		LastActive:  "last_entry_date",
+		GraceActive: "grace_period_active",
+		GraceUsedAt: "grace_period_used_at",
+	})
+	return err
+}
+
+// RecomputeStreaks implements services.StreakRecomputer, rebuilding the
+// journal streak from entry dates in loc.
+func (p *DaiylyPlugin) RecomputeStreaks(db *gorm.DB, appID string, userID uuid.UUID, loc *time.Location) error {
+	var dates []time.Time
+	if err := db.Model(&JournalEntry{}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ?", userID).
+		Pluck("entry_date", &dates).Error; err != nil {
+		return err
+	}
+	return newJournalStreaks(db).Recompute(appID, userID, loc, dates)
+}
@@ -14,8 +14,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
-	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/sdk"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -24,9 +24,8 @@ const (
 	photoMaxBytes = 10 * 1024 * 1024 // 10 MB
 	audioMaxBytes = 25 * 1024 * 1024 // 25 MB
 
-	openAIWhisperURL = "https://api.openai.com/v1/audio/transcriptions"
-	falWhisperURL    = "https://fal.run/fal-ai/whisper"
-	baseUploadURL    = "https://api.vexellabspro.com"
+	falWhisperURL = "https://fal.run/fal-ai/whisper"
+	baseUploadURL = "https://api.vexellabspro.com"
 )
 
 var allowedPhotoMIME = map[string]string{
@@ -47,51 +46,40 @@ var allowedAudioMIME = map[string]string{
 
 // UploadHandler handles file upload and transcription endpoints.
 type UploadHandler struct {
-	openAIAPIKey string
-	falAPIKey    string
-	aiTimeout    time.Duration
-	uploadsRoot  string // absolute path to uploads directory on disk
+	openAIAPIKey  string
+	openAIBaseURL string
+	falAPIKey     string
+	aiTimeout     time.Duration
+	uploadsRoot   string // absolute path to uploads directory on disk
 }
 
-func NewUploadHandler(openAIAPIKey, falAPIKey string, aiTimeout time.Duration, uploadsRoot string) *UploadHandler {
+func NewUploadHandler(openAIAPIKey, openAIBaseURL, falAPIKey string, aiTimeout time.Duration, uploadsRoot string) *UploadHandler {
 	return &UploadHandler{
-		openAIAPIKey: openAIAPIKey,
-		falAPIKey:    falAPIKey,
-		aiTimeout:    aiTimeout,
-		uploadsRoot:  uploadsRoot,
+		openAIAPIKey:  openAIAPIKey,
+		openAIBaseURL: openAIBaseURL,
+		falAPIKey:     falAPIKey,
+		aiTimeout:     aiTimeout,
+		uploadsRoot:   uploadsRoot,
 	}
 }
 
 // UploadPhoto handles POST /journals/upload-photo
 // Accepts multipart/form-data with a "photo" field.
 // Validates size (max 10MB) and MIME type, saves to disk, returns URL.
-func (h *UploadHandler) UploadPhoto(c *fiber.Ctx) error {
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
+func (h *UploadHandler) UploadPhoto(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (fiber.Map, error) {
 	fileHeader, err := c.FormFile("photo")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "photo field is required",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "photo field is required")
 	}
 
 	if fileHeader.Size > photoMaxBytes {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "photo exceeds maximum size of 10MB",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "photo exceeds maximum size of 10MB")
 	}
 
 	f, err := fileHeader.Open()
 	if err != nil {
 		slog.Error("photo upload: open file failed", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "failed to read uploaded file",
-		})
+		return nil, apierr.New(fiber.StatusInternalServerError, "failed to read uploaded file")
 	}
 	defer f.Close()
 
@@ -99,14 +87,10 @@ func (h *UploadHandler) UploadPhoto(c *fiber.Ctx) error {
 	data, err := io.ReadAll(io.LimitReader(f, photoMaxBytes+1))
 	if err != nil {
 		slog.Error("photo upload: read failed", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "failed to read uploaded file",
-		})
+		return nil, apierr.New(fiber.StatusInternalServerError, "failed to read uploaded file")
 	}
 	if int64(len(data)) > photoMaxBytes {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "photo exceeds maximum size of 10MB",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "photo exceeds maximum size of 10MB")
 	}
 
 	// Validate MIME type via Content-Type header AND magic bytes.
@@ -117,15 +101,11 @@ func (h *UploadHandler) UploadPhoto(c *fiber.Ctx) error {
 	}
 	ext, allowed := allowedPhotoMIME[contentType]
 	if !allowed {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "unsupported image type; allowed: jpeg, png, webp, heic",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "unsupported image type; allowed: jpeg, png, webp, heic")
 	}
 
 	if !validatePhotoMagic(data, contentType) {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "file content does not match declared image type",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "file content does not match declared image type")
 	}
 
 	// Generate UUID filename — never use user-supplied filename.
@@ -134,70 +114,50 @@ func (h *UploadHandler) UploadPhoto(c *fiber.Ctx) error {
 	filename = filepath.Base(filename)
 
 	// Store under daiyly/photos/{user_id}/ to namespace by user.
-	userIDStr := userID.String()
+	userIDStr := u.ID.String()
 	savePath := filepath.Join(h.uploadsRoot, "daiyly", "photos", userIDStr, filename)
 	if err := saveFile(savePath, data); err != nil {
 		slog.Error("photo upload: save failed", "path", savePath, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "failed to save photo",
-		})
+		return nil, apierr.New(fiber.StatusInternalServerError, "failed to save photo")
 	}
 
 	url := fmt.Sprintf("%s/uploads/daiyly/photos/%s/%s", baseUploadURL, userIDStr, filename)
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"url": url})
+	c.Status(fiber.StatusCreated)
+	return fiber.Map{"url": url}, nil
 }
 
 // Transcribe handles POST /journals/transcribe
 // Accepts multipart/form-data with an "audio" field.
 // Validates size (max 25MB) and MIME type, calls OpenAI Whisper, returns transcript.
-func (h *UploadHandler) Transcribe(c *fiber.Ctx) error {
-	_, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
+func (h *UploadHandler) Transcribe(c *fiber.Ctx, _ sdk.User, _ sdk.Empty) (fiber.Map, error) {
 	if h.openAIAPIKey == "" && h.falAPIKey == "" {
 		slog.Error("transcribe: no transcription provider configured (OPENAI_API_KEY or FAL_API_KEY required)")
-		return c.Status(fiber.StatusServiceUnavailable).JSON(dto.ErrorResponse{
-			Error: true, Message: "transcription service not available",
-		})
+		return nil, apierr.New(fiber.StatusServiceUnavailable, "transcription service not available")
 	}
 
 	fileHeader, err := c.FormFile("audio")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "audio field is required",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "audio field is required")
 	}
 
 	if fileHeader.Size > audioMaxBytes {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "audio exceeds maximum size of 25MB",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "audio exceeds maximum size of 25MB")
 	}
 
 	f, err := fileHeader.Open()
 	if err != nil {
 		slog.Error("transcribe: open file failed", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "failed to read uploaded file",
-		})
+		return nil, apierr.New(fiber.StatusInternalServerError, "failed to read uploaded file")
 	}
 	defer f.Close()
 
 	data, err := io.ReadAll(io.LimitReader(f, audioMaxBytes+1))
 	if err != nil {
 		slog.Error("transcribe: read failed", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "failed to read uploaded file",
-		})
+		return nil, apierr.New(fiber.StatusInternalServerError, "failed to read uploaded file")
 	}
 	if int64(len(data)) > audioMaxBytes {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "audio exceeds maximum size of 25MB",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "audio exceeds maximum size of 25MB")
 	}
 
 	// Validate MIME type via Content-Type header.
@@ -207,9 +167,7 @@ func (h *UploadHandler) Transcribe(c *fiber.Ctx) error {
 	}
 	ext, allowed := allowedAudioMIME[contentType]
 	if !allowed {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "unsupported audio type; allowed: m4a, mp3, wav, aac, mp4, webm",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "unsupported audio type; allowed: m4a, mp3, wav, aac, mp4, webm")
 	}
 
 	var transcript string
@@ -221,18 +179,16 @@ func (h *UploadHandler) Transcribe(c *fiber.Ctx) error {
 	}
 	if transcriptErr != nil {
 		slog.Error("transcribe: failed", "provider", map[bool]string{true: "openai", false: "fal"}[h.openAIAPIKey != ""], "error", transcriptErr)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "transcription failed",
-		})
+		return nil, apierr.New(fiber.StatusInternalServerError, "transcription failed")
 	}
 
-	return c.JSON(fiber.Map{
+	return fiber.Map{
 		"transcript": transcript,
-	})
+	}, nil
 }
 
 // callWhisper sends the audio bytes to OpenAI Whisper and returns the transcript.
-// Uses POST https://api.openai.com/v1/audio/transcriptions with model=whisper-1.
+// Uses POST {OpenAIBaseURL}/audio/transcriptions with model=whisper-1.
 func (h *UploadHandler) callWhisper(audioData []byte, ext string) (string, error) {
 	// Build a safe filename — never send the user-supplied filename.
 	safeFilename := "audio." + ext
@@ -259,7 +215,7 @@ func (h *UploadHandler) callWhisper(audioData []byte, ext string) (string, error
 	}
 
 	httpClient := &http.Client{Timeout: timeout}
-	req, err := http.NewRequest(http.MethodPost, openAIWhisperURL, &buf)
+	req, err := http.NewRequest(http.MethodPost, h.openAIBaseURL+"/audio/transcriptions", &buf)
 	if err != nil {
 		return "", fmt.Errorf("create request: %w", err)
 	}
@@ -0,0 +1,21 @@
+package driftoff
+
+import "github.com/ahmetcoskunkizilkaya/unified-backend/internal/achievements"
+
+// sleepAchievements unlock from the sleep streak ("sleep.count" sessions
+// logged, "sleep.streak" current streak) and from CBT-I progress
+// ("cbti.weeks" completed program weeks).
+var sleepAchievements = []achievements.Definition{
+	{Key: "sleep_first_session", Name: "First Night", Description: "Log your first sleep session", Emoji: "🌙", XP: 10, Event: "sleep.count", Threshold: 1},
+	{Key: "sleep_30_sessions", Name: "Sleep Scholar", Description: "Log 30 sleep sessions", Emoji: "🛌", XP: 150, Event: "sleep.count", Threshold: 30},
+	{Key: "sleep_100_sessions", Name: "Dream Archivist", Description: "Log 100 sleep sessions", Emoji: "💤", XP: 500, Event: "sleep.count", Threshold: 100},
+	{Key: "sleep_streak_7", Name: "Seven Nights", Description: "Reach a 7-night sleep streak", Emoji: "🔥", XP: 100, Event: "sleep.streak", Threshold: 7},
+	{Key: "sleep_streak_30", Name: "Sleep Routine", Description: "Reach a 30-night sleep streak", Emoji: "🌟", XP: 400, Event: "sleep.streak", Threshold: 30},
+	{Key: "cbti_first_week", Name: "CBT-I Week One", Description: "Complete your first CBT-I week", Emoji: "🧠", XP: 100, Event: "cbti.weeks", Threshold: 1},
+	{Key: "cbti_graduate", Name: "CBT-I Graduate", Description: "Complete all 6 weeks of the CBT-I program", Emoji: "🎓", XP: 600, Event: "cbti.weeks", Threshold: 6},
+}
+
+// Achievements implements apps.AchievementPlugin.
+func (p *DriftoffPlugin) Achievements() []achievements.Definition {
+	return sleepAchievements
+}
@@ -0,0 +1,64 @@
+package driftoff
+
+import (
+	"fmt"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"gorm.io/gorm"
+)
+
+// RegisterEvents implements apps.EventPlugin.
+func (p *DriftoffPlugin) RegisterEvents(bus *events.Bus, db *gorm.DB, cfg *config.Config) {
+	p.bus = bus
+	svc := NewSleepService(db, cfg, bus)
+
+	bus.Subscribe(events.UserDeleted, "driftoff.delete_user_data", p.deleteUserData)
+	bus.Subscribe(events.GuestClaimed, "driftoff.claim_guest_data", p.claimGuestData)
+	bus.SubscribeAsync(events.SleepSessionLogged, "driftoff.sleep_streak", svc.recordSessionStreak)
+}
+
+// deleteUserData removes the user's sleep data in the account deletion
+// transaction.
+func (p *DriftoffPlugin) deleteUserData(tx *gorm.DB, ev events.Event) error {
+	for _, m := range p.Models() {
+		if err := tx.Unscoped().Where("app_id = ? AND user_id = ?", ev.AppID, ev.UserID).Delete(m).Error; err != nil {
+			return fmt.Errorf("delete %T: %w", m, err)
+		}
+	}
+	return nil
+}
+
+// recordSessionStreak counts a logged session towards the sleep streak, on
+// the day it was logged rather than the day the message is delivered.
+func (s *SleepService) recordSessionStreak(db *gorm.DB, ev events.Event) error {
+	res, err := s.streaks.Record(ev.AppID, ev.UserID, ev.OccurredAt)
+	if err != nil {
+		return err
+	}
+	s.achievements.EmitStreak(ev.AppID, ev.UserID, "sleep", res.Streak)
+	return nil
+}
+
+// claimGuestData moves a claimed guest's sleep data to the registered
+// account. Rituals on the same date and the CBT-I programme keep the
+// registered account's copy.
+func (p *DriftoffPlugin) claimGuestData(tx *gorm.DB, ev events.Event) error {
+	var payload events.GuestClaimedPayload
+	if err := ev.Decode(&payload); err != nil {
+		return err
+	}
+	for _, m := range []interface{}{&SleepSession{}, &DailyCaffeineLog{}, &AlertnessLog{}, &CBTIDayCheckIn{}} {
+		if err := services.MoveUserRows(tx, m, ev.AppID, payload.GuestID, ev.UserID); err != nil {
+			return fmt.Errorf("move %T: %w", m, err)
+		}
+	}
+	if err := services.MoveUniqueUserRows(tx, &SleepRitual{}, ev.AppID, payload.GuestID, ev.UserID, "date"); err != nil {
+		return fmt.Errorf("move rituals: %w", err)
+	}
+	if err := services.MoveUniqueUserRows(tx, &CBTIProgress{}, ev.AppID, payload.GuestID, ev.UserID); err != nil {
+		return fmt.Errorf("move CBT-I progress: %w", err)
+	}
+	return nil
+}
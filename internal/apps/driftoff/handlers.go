@@ -4,11 +4,38 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/sdk"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
+func init() {
+	// Sessions of other users are reported as missing, not forbidden, so
+	// IDs can't be probed.
+	sdk.RegisterErrors(map[error]int{
+		ErrInvalidScore:    fiber.StatusBadRequest,
+		ErrInvalidDuration: fiber.StatusBadRequest,
+		ErrMissingTimes:    fiber.StatusBadRequest,
+		ErrNotFound:        fiber.StatusNotFound,
+		ErrNotOwner:        fiber.StatusNotFound,
+		ErrNotEnoughData:   fiber.StatusUnprocessableEntity,
+
+		ErrInvalidBedtime:     fiber.StatusBadRequest,
+		ErrInvalidWakeTime:    fiber.StatusBadRequest,
+		ErrTooManyPhases:      fiber.StatusBadRequest,
+		ErrTooManySounds:      fiber.StatusBadRequest,
+		ErrInvalidSoundscape:  fiber.StatusBadRequest,
+		ErrInvalidRoomTemp:    fiber.StatusBadRequest,
+		ErrInvalidLevel:       fiber.StatusBadRequest,
+		ErrMissingDate:        fiber.StatusBadRequest,
+		ErrInvalidDate:        fiber.StatusBadRequest,
+		ErrNotesTooLong:       fiber.StatusBadRequest,
+		ErrMissingSleepWindow: fiber.StatusBadRequest,
+		ErrInvalidClockTime:   fiber.StatusBadRequest,
+		ErrNoActiveCBTI:       fiber.StatusBadRequest,
+	})
+}
+
 type SleepHandler struct {
 	svc *SleepService
 }
@@ -17,242 +44,124 @@ func NewSleepHandler(svc *SleepService) *SleepHandler {
 	return &SleepHandler{svc: svc}
 }
 
-func (h *SleepHandler) Create(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	var req CreateSleepRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+// days reads ?days, clamped to [1, 90].
+func days(c *fiber.Ctx, def int) int {
+	d := c.QueryInt("days", def)
+	if d < 1 {
+		d = 1
 	}
-
-	session, err := h.svc.Create(appID, userID, req)
-	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	if d > 90 {
+		d = 90
 	}
-
-	return c.Status(fiber.StatusCreated).JSON(session)
+	return d
 }
 
-func (h *SleepHandler) List(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
+func (h *SleepHandler) Create(c *fiber.Ctx, u sdk.User, req CreateSleepRequest) (*SleepResponse, error) {
+	session, err := h.svc.Create(u.AppID, u.ID, req)
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	limit, _ := strconv.Atoi(c.Query("limit", "20"))
-	offset, _ := strconv.Atoi(c.Query("offset", "0"))
-	if limit < 1 {
-		limit = 1
-	}
-	if limit > 100 {
-		limit = 100
-	}
-	if offset < 0 {
-		offset = 0
+		return nil, err
 	}
-	if offset > 10000 {
-		offset = 0
-	}
-
-	resp, err := h.svc.List(appID, userID, limit, offset)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "list failed")
-	}
-
-	return c.JSON(resp)
+	c.Status(fiber.StatusCreated)
+	return session, nil
 }
 
-func (h *SleepHandler) Get(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	id, err := uuid.Parse(c.Params("id"))
-	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
-	}
-
-	session, err := h.svc.Get(appID, userID, id)
+func (h *SleepHandler) List(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*SleepListResponse, error) {
+	page, err := sdk.PageFrom(c, sdk.DefaultLimit)
 	if err != nil {
-		return fiber.NewError(fiber.StatusNotFound, err.Error())
+		return nil, err
 	}
-
-	return c.JSON(session)
+	return h.svc.List(u.AppID, u.ID, page)
 }
 
-func (h *SleepHandler) Update(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
+func (h *SleepHandler) Get(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*SleepResponse, error) {
+	id, err := sdk.ParamUUID(c, "id")
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
+		return nil, err
 	}
+	return h.svc.Get(u.AppID, u.ID, id)
+}
 
-	id, err := uuid.Parse(c.Params("id"))
+func (h *SleepHandler) Update(c *fiber.Ctx, u sdk.User, req UpdateSleepRequest) (*SleepResponse, error) {
+	id, err := sdk.ParamUUID(c, "id")
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
-	}
-
-	var req UpdateSleepRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+		return nil, err
 	}
 
-	session, err := h.svc.Update(appID, userID, id, req)
+	session, err := h.svc.Update(u.AppID, u.ID, id, req)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return nil, err
 	}
-
-	return c.JSON(session)
+	return session, nil
 }
 
-func (h *SleepHandler) Delete(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
+func (h *SleepHandler) Delete(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (fiber.Map, error) {
+	id, err := sdk.ParamUUID(c, "id")
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
+		return nil, err
 	}
 
-	id, err := uuid.Parse(c.Params("id"))
-	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	if err := h.svc.Delete(u.AppID, u.ID, id); err != nil {
+		return nil, err
 	}
-
-	if err := h.svc.Delete(appID, userID, id); err != nil {
-		return fiber.NewError(fiber.StatusNotFound, err.Error())
-	}
-
-	return c.JSON(fiber.Map{"message": "deleted"})
+	return fiber.Map{"message": "deleted"}, nil
 }
 
-func (h *SleepHandler) Search(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
+func (h *SleepHandler) Search(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*SearchSleepResponse, error) {
 	q := c.Query("q")
 	if len(q) < 1 {
-		return fiber.NewError(fiber.StatusBadRequest, "query required")
-	}
-
-	resp, err := h.svc.Search(appID, userID, q)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "search failed")
+		return nil, apierr.New(fiber.StatusBadRequest, "query required")
 	}
-
-	return c.JSON(resp)
+	return h.svc.Search(u.AppID, u.ID, q)
 }
 
-func (h *SleepHandler) GetStreak(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	resp, err := h.svc.GetStreak(appID, userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "streak fetch failed")
-	}
-
-	return c.JSON(resp)
+func (h *SleepHandler) GetStreak(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*StreakResponse, error) {
+	return h.svc.GetStreak(u.AppID, u.ID)
 }
 
-func (h *SleepHandler) GetStats(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	days, _ := strconv.Atoi(c.Query("days", "7"))
-	if days < 1 {
-		days = 1
-	}
-	if days > 90 {
-		days = 90
-	}
-
-	resp, err := h.svc.GetStats(appID, userID, days)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "stats fetch failed")
-	}
-
-	return c.JSON(resp)
+func (h *SleepHandler) GetStats(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*StatsResponse, error) {
+	return h.svc.GetStats(u.AppID, u.ID, days(c, 7))
 }
 
-func (h *SleepHandler) BatchImport(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	var req BatchImportRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
-	}
-
+func (h *SleepHandler) BatchImport(c *fiber.Ctx, u sdk.User, req BatchImportRequest) (*BatchImportResponse, error) {
 	if len(req.Sessions) == 0 {
-		return fiber.NewError(fiber.StatusBadRequest, "no sessions provided")
+		return nil, apierr.New(fiber.StatusBadRequest, "no sessions provided")
 	}
 	if len(req.Sessions) > 100 {
-		return fiber.NewError(fiber.StatusBadRequest, "max 100 sessions per batch")
+		return nil, apierr.New(fiber.StatusBadRequest, "max 100 sessions per batch")
 	}
 
-	resp, err := h.svc.BatchImport(appID, userID, req)
+	resp, err := h.svc.BatchImport(u.AppID, u.ID, req)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "batch import failed")
+		return nil, err
 	}
-
-	return c.Status(fiber.StatusCreated).JSON(resp)
+	c.Status(fiber.StatusCreated)
+	return resp, nil
 }
 
-func (h *SleepHandler) GetSleepDebt(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	goalStr := c.Query("goal", "8")
-	goal := 8.0
-	if g, err := strconv.ParseFloat(goalStr, 64); err == nil && g > 0 && g <= 12 {
+func (h *SleepHandler) GetSleepDebt(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*SleepDebtResponse, error) {
+	// ?goal= overrides the user's saved goal for this request.
+	goal := h.svc.GoalHours(u.AppID, u.ID)
+	if g, err := strconv.ParseFloat(c.Query("goal"), 64); err == nil && g > 0 && g <= 12 {
 		goal = g
 	}
-
-	resp, err := h.svc.GetSleepDebt(appID, userID, goal)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "sleep debt fetch failed")
-	}
-
-	return c.JSON(resp)
+	return h.svc.GetSleepDebt(u.AppID, u.ID, goal)
 }
 
 // ExportSleepData returns all sleep sessions as CSV or JSON download.
 func (h *SleepHandler) ExportSleepData(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
+	u, err := sdk.Caller(c)
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
+		return err
 	}
 
 	format := c.Query("format", "csv")
 	if format != "csv" && format != "json" {
-		return fiber.NewError(fiber.StatusBadRequest, "format must be csv or json")
+		return apierr.New(fiber.StatusBadRequest, "format must be csv or json")
 	}
 
-	data, mimeType, err := h.svc.ExportSleepData(appID, userID, format)
+	data, mimeType, err := h.svc.ExportSleepData(u.AppID, u.ID, format)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "export failed")
+		return err
 	}
 
 	ext := format
@@ -263,398 +172,188 @@ func (h *SleepHandler) ExportSleepData(c *fiber.Ctx) error {
 }
 
 // GetSleepCoach returns AI-generated personalised sleep coaching. Cached 6h per user.
-func (h *SleepHandler) GetSleepCoach(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
+func (h *SleepHandler) GetSleepCoach(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (fiber.Map, error) {
+	coaching, err := h.svc.GetSleepCoach(u.AppID, u.ID)
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
+		return nil, err
 	}
-
-	coaching, err := h.svc.GetSleepCoach(appID, userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "coaching unavailable")
-	}
-
-	return c.JSON(fiber.Map{"message": coaching})
+	return fiber.Map{"message": coaching}, nil
 }
 
 // GetDoctorReport returns a clinical sleep summary. PREMIUM feature.
-func (h *SleepHandler) GetDoctorReport(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
+func (h *SleepHandler) GetDoctorReport(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (fiber.Map, error) {
 	// PREMIUM feature: gating is noted with a TODO below pending subscription check wiring.
 	// TODO: Check subscription entitlement here once RevenueCat webhook is wired up.
-	// if !isPremium(c) { return c.Status(fiber.StatusPaymentRequired).JSON(...) }
+	// if !isPremium(c) { return nil, apierr.New(fiber.StatusPaymentRequired, ...) }
 
-	report, err := h.svc.GetDoctorReport(appID, userID)
+	report, err := h.svc.GetDoctorReport(u.AppID, u.ID)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "report unavailable")
+		return nil, err
 	}
-
-	return c.JSON(fiber.Map{"report": report})
+	return fiber.Map{"report": report}, nil
 }
 
 // GetHygieneScore returns the sleep hygiene score breakdown. Free feature.
-func (h *SleepHandler) GetHygieneScore(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	score, err := h.svc.GetHygieneScore(appID, userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "hygiene score unavailable")
-	}
-
-	return c.JSON(score)
+func (h *SleepHandler) GetHygieneScore(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*HygieneScoreResponse, error) {
+	return h.svc.GetHygieneScore(u.AppID, u.ID)
 }
 
 // LogCaffeine upserts today's caffeine and exercise log.
-func (h *SleepHandler) LogCaffeine(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	var req LogCaffeineRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
-	}
-
+func (h *SleepHandler) LogCaffeine(c *fiber.Ctx, u sdk.User, req LogCaffeineRequest) (*DailyCaffeineLog, error) {
 	if req.CaffeineML < 0 || req.CaffeineML > 10000 {
-		return fiber.NewError(fiber.StatusBadRequest, "caffeine_ml must be between 0 and 10000")
+		return nil, apierr.New(fiber.StatusBadRequest, "caffeine_ml must be between 0 and 10000")
 	}
 	if req.ExerciseMin < 0 || req.ExerciseMin > 1440 {
-		return fiber.NewError(fiber.StatusBadRequest, "exercise_min must be between 0 and 1440")
+		return nil, apierr.New(fiber.StatusBadRequest, "exercise_min must be between 0 and 1440")
 	}
 
 	var lastCupAt *time.Time
 	if req.LastCupAt != nil && *req.LastCupAt != "" {
 		t, err := time.Parse(time.RFC3339, *req.LastCupAt)
 		if err != nil {
-			return fiber.NewError(fiber.StatusBadRequest, "invalid last_cup_at format (use RFC3339)")
+			return nil, apierr.New(fiber.StatusBadRequest, "invalid last_cup_at format (use RFC3339)")
 		}
 		lastCupAt = &t
 	}
 
-	log, err := h.svc.LogCaffeine(appID, userID, req.CaffeineML, req.ExerciseMin, lastCupAt)
+	log, err := h.svc.LogCaffeine(u.AppID, u.ID, req.CaffeineML, req.ExerciseMin, lastCupAt)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "caffeine log failed")
+		return nil, err
 	}
-
-	return c.Status(fiber.StatusCreated).JSON(log)
+	c.Status(fiber.StatusCreated)
+	return log, nil
 }
 
 // GetCaffeineLogs returns caffeine logs for the last N days.
-func (h *SleepHandler) GetCaffeineLogs(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	days, _ := strconv.Atoi(c.Query("days", "30"))
-	if days < 1 {
-		days = 1
-	}
-	if days > 90 {
-		days = 90
-	}
-
-	logs, err := h.svc.GetCaffeineLog(appID, userID, days)
+func (h *SleepHandler) GetCaffeineLogs(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (fiber.Map, error) {
+	n := days(c, 30)
+	logs, err := h.svc.GetCaffeineLog(u.AppID, u.ID, n)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "fetch caffeine logs failed")
+		return nil, err
 	}
-
-	return c.JSON(fiber.Map{"logs": logs, "days": days})
+	return fiber.Map{"logs": logs, "days": n}, nil
 }
 
 // GetSoundCorrelation handles GET /sleeps/sound-correlation
 // Returns average sleep efficiency per soundscape (3+ sessions required per sound).
-func (h *SleepHandler) GetSoundCorrelation(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	resp, err := h.svc.GetSoundCorrelation(appID, userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "sound correlation unavailable")
-	}
-
-	return c.JSON(resp)
+func (h *SleepHandler) GetSoundCorrelation(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*SoundCorrelationResponse, error) {
+	return h.svc.GetSoundCorrelation(u.AppID, u.ID)
 }
 
 // GetTempCorrelation handles GET /sleeps/temp-correlation
 // Returns average sleep score per room temperature (3+ sessions required per temp).
-func (h *SleepHandler) GetTempCorrelation(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	resp, err := h.svc.GetTempCorrelation(appID, userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "temp correlation unavailable")
-	}
-
-	return c.JSON(resp)
+func (h *SleepHandler) GetTempCorrelation(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*TempCorrelationResponse, error) {
+	return h.svc.GetTempCorrelation(u.AppID, u.ID)
 }
 
 // GetCBTIInsights handles GET /sleeps/cbti-insights
 // Returns clinically-validated CBT-I recommendations based on the user's sleep pattern.
-func (h *SleepHandler) GetCBTIInsights(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	resp, err := h.svc.GetCBTIInsights(appID, userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "cbti insights unavailable")
-	}
-
-	return c.JSON(resp)
+func (h *SleepHandler) GetCBTIInsights(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*CBTIInsightsResponse, error) {
+	return h.svc.GetCBTIInsights(u.AppID, u.ID)
 }
 
 // GetSleepRegularityIndex handles GET /sleeps/sri
 // Returns a Sleep Regularity Index score (World Sleep Society 2025).
-func (h *SleepHandler) GetSleepRegularityIndex(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	resp, err := h.svc.GetSleepRegularityIndex(appID, userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "sri unavailable")
-	}
-
-	return c.JSON(resp)
+func (h *SleepHandler) GetSleepRegularityIndex(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*SRIResponse, error) {
+	return h.svc.GetSleepRegularityIndex(u.AppID, u.ID)
 }
 
 // GetLifestyleCorrelation handles GET /sleeps/lifestyle-correlation
 // Returns caffeine timing and exercise correlations with sleep metrics.
-func (h *SleepHandler) GetLifestyleCorrelation(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	resp, err := h.svc.GetLifestyleCorrelation(appID, userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "lifestyle correlation unavailable")
-	}
-
-	return c.JSON(resp)
+func (h *SleepHandler) GetLifestyleCorrelation(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*LifestyleCorrelationResponse, error) {
+	return h.svc.GetLifestyleCorrelation(u.AppID, u.ID)
 }
 
 // LogAlertness handles POST /sleeps/alertness
 // Records a single daytime alertness/energy check-in (level 1-5).
-func (h *SleepHandler) LogAlertness(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	var req LogAlertnessRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
-	}
+func (h *SleepHandler) LogAlertness(c *fiber.Ctx, u sdk.User, req LogAlertnessRequest) (*AlertnessLogResponse, error) {
 	if req.Level < 1 || req.Level > 5 {
-		return fiber.NewError(fiber.StatusBadRequest, "level must be between 1 and 5")
+		return nil, apierr.New(fiber.StatusBadRequest, "level must be between 1 and 5")
 	}
 
 	loggedAt := time.Now().UTC()
 	if req.LoggedAt != "" {
-		if t, err := time.Parse(time.RFC3339, req.LoggedAt); err == nil {
-			loggedAt = t
-		} else {
-			return fiber.NewError(fiber.StatusBadRequest, "invalid logged_at format (use RFC3339)")
+		t, err := time.Parse(time.RFC3339, req.LoggedAt)
+		if err != nil {
+			return nil, apierr.New(fiber.StatusBadRequest, "invalid logged_at format (use RFC3339)")
 		}
+		loggedAt = t
 	}
 
-	resp, err := h.svc.LogAlertness(appID, userID, req.Level, loggedAt)
+	resp, err := h.svc.LogAlertness(u.AppID, u.ID, req.Level, loggedAt)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return nil, err
 	}
-
-	return c.Status(fiber.StatusCreated).JSON(resp)
+	c.Status(fiber.StatusCreated)
+	return resp, nil
 }
 
 // GetAlertnessLogs handles GET /sleeps/alertness
 // Returns daytime alertness logs for the last N days with daily average and peak/trough hours.
-func (h *SleepHandler) GetAlertnessLogs(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	days, _ := strconv.Atoi(c.Query("days", "7"))
-	if days < 1 {
-		days = 1
-	}
-	if days > 90 {
-		days = 90
-	}
-
-	resp, err := h.svc.GetAlertnessLogs(appID, userID, days)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "alertness logs unavailable")
-	}
-
-	return c.JSON(resp)
+func (h *SleepHandler) GetAlertnessLogs(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*AlertnessListResponse, error) {
+	return h.svc.GetAlertnessLogs(u.AppID, u.ID, days(c, 7))
 }
 
 // GetSnoringAnalysis handles GET /sleeps/snoring-analysis
 // Returns snoring correlation with sleep score across the last 30 sessions (min 3 required).
-func (h *SleepHandler) GetSnoringAnalysis(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	resp, err := h.svc.GetSnoringAnalysis(appID, userID)
-	if err != nil {
-		if err.Error() == "not enough data" {
-			return fiber.NewError(fiber.StatusUnprocessableEntity, "not enough data: need 3+ sessions")
-		}
-		return fiber.NewError(fiber.StatusInternalServerError, "snoring analysis unavailable")
-	}
-
-	return c.JSON(resp)
+func (h *SleepHandler) GetSnoringAnalysis(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*SnoringAnalysisResponse, error) {
+	return h.svc.GetSnoringAnalysis(u.AppID, u.ID)
 }
 
 // --- Pre-Sleep Ritual handlers ---
 
 // CreateRitual handles POST /sleeps/ritual
 // Creates or updates a pre-sleep ritual record for the given date.
-func (h *SleepHandler) CreateRitual(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
+func (h *SleepHandler) CreateRitual(c *fiber.Ctx, u sdk.User, req CreateRitualRequest) (*SleepRitual, error) {
+	ritual, err := h.svc.CreateOrUpdateRitual(u.AppID, u.ID, req)
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
+		return nil, err
 	}
-
-	var req CreateRitualRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
-	}
-
-	ritual, err := h.svc.CreateOrUpdateRitual(appID, userID, req)
-	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
-	}
-
-	return c.Status(fiber.StatusCreated).JSON(ritual)
+	c.Status(fiber.StatusCreated)
+	return ritual, nil
 }
 
 // GetRitualCorrelation handles GET /sleeps/ritual-correlation
 // Returns correlation of pre-sleep behaviors with sleep score across all paired nights.
-func (h *SleepHandler) GetRitualCorrelation(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	resp, err := h.svc.GetRitualCorrelation(appID, userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "ritual correlation unavailable")
-	}
-
-	return c.JSON(resp)
+func (h *SleepHandler) GetRitualCorrelation(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*RitualCorrelationResponse, error) {
+	return h.svc.GetRitualCorrelation(u.AppID, u.ID)
 }
 
 // --- CBT-I Program handlers ---
 
 // StartCBTIProgram handles POST /sleeps/cbti/start
 // Enrolls a user in the 6-week CBT-I program. Returns existing enrollment if already active.
-func (h *SleepHandler) StartCBTIProgram(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
+func (h *SleepHandler) StartCBTIProgram(c *fiber.Ctx, u sdk.User, req StartCBTIRequest) (*CBTIStatusResponse, error) {
+	resp, err := h.svc.StartCBTIProgram(u.AppID, u.ID, req)
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
+		return nil, err
 	}
-
-	var req StartCBTIRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
-	}
-
-	resp, err := h.svc.StartCBTIProgram(appID, userID, req)
-	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
-	}
-
-	return c.Status(fiber.StatusCreated).JSON(resp)
+	c.Status(fiber.StatusCreated)
+	return resp, nil
 }
 
 // GetCBTIStatus handles GET /sleeps/cbti/status
 // Returns the current CBT-I program status for the authenticated user.
-func (h *SleepHandler) GetCBTIStatus(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	resp, err := h.svc.GetCBTIStatus(appID, userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "cbti status unavailable")
-	}
-
-	return c.JSON(resp)
+func (h *SleepHandler) GetCBTIStatus(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*CBTIStatusResponse, error) {
+	return h.svc.GetCBTIStatus(u.AppID, u.ID)
 }
 
 // SubmitCBTICheckIn handles POST /sleeps/cbti/checkin
 // Records a daily check-in and advances the user's week/day counter.
-func (h *SleepHandler) SubmitCBTICheckIn(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	var req CBTICheckInRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
-	}
-
-	resp, err := h.svc.SubmitCBTICheckIn(appID, userID, req)
+func (h *SleepHandler) SubmitCBTICheckIn(c *fiber.Ctx, u sdk.User, req CBTICheckInRequest) (*CBTIStatusResponse, error) {
+	resp, err := h.svc.SubmitCBTICheckIn(u.AppID, u.ID, req)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return nil, err
 	}
-
-	return c.Status(fiber.StatusCreated).JSON(resp)
+	c.Status(fiber.StatusCreated)
+	return resp, nil
 }
 
 // PauseCBTIProgram handles POST /sleeps/cbti/pause
 // Sets is_active=false on the user's active CBT-I program.
-func (h *SleepHandler) PauseCBTIProgram(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
+func (h *SleepHandler) PauseCBTIProgram(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (fiber.Map, error) {
+	if err := h.svc.PauseCBTIProgram(u.AppID, u.ID); err != nil {
+		return nil, err
 	}
-
-	if err := h.svc.PauseCBTIProgram(appID, userID); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
-	}
-
-	return c.JSON(fiber.Map{"message": "cbti program paused"})
+	return fiber.Map{"message": "cbti program paused"}, nil
 }
@@ -44,19 +44,6 @@ type DailyCaffeineLog struct {
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
-// SleepStreak tracks consecutive nights of sleep logging.
-type SleepStreak struct {
-	ID              uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	AppID           string    `gorm:"size:50;not null;index;uniqueIndex:idx_sleep_streak_app_user" json:"app_id"`
-	UserID          uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_sleep_streak_app_user" json:"user_id"`
-	CurrentStreak   int       `gorm:"default:0" json:"current_streak"`
-	LongestStreak   int       `gorm:"default:0" json:"longest_streak"`
-	TotalSessions   int       `gorm:"default:0" json:"total_sessions"`
-	LastSessionDate time.Time `json:"last_session_date"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
-}
-
 // --- DTOs ---
 
 type PhaseDTO struct {
@@ -118,10 +105,11 @@ type SleepResponse struct {
 }
 
 type SleepListResponse struct {
-	Sessions []SleepResponse `json:"sessions"`
-	Total    int64           `json:"total"`
-	Limit    int             `json:"limit"`
-	Offset   int             `json:"offset"`
+	Sessions   []SleepResponse `json:"sessions"`
+	Total      int64           `json:"total"`
+	Limit      int             `json:"limit"`
+	Offset     int             `json:"offset"`
+	NextCursor string          `json:"next_cursor,omitempty"`
 }
 
 type SearchSleepResponse struct {
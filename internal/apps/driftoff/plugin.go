@@ -1,15 +1,16 @@
 package driftoff
 
 import (
-	"time"
-
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/sdk"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"gorm.io/gorm"
 )
 
-type DriftoffPlugin struct{}
+type DriftoffPlugin struct {
+	bus *events.Bus
+}
 
 func New() *DriftoffPlugin {
 	return &DriftoffPlugin{}
@@ -20,7 +21,6 @@ func (p *DriftoffPlugin) ID() string { return "driftoff" }
 func (p *DriftoffPlugin) Models() []interface{} {
 	return []interface{}{
 		&SleepSession{},
-		&SleepStreak{},
 		&DailyCaffeineLog{},
 		&AlertnessLog{},
 		&SleepRitual{},
@@ -30,95 +30,60 @@ func (p *DriftoffPlugin) Models() []interface{} {
 }
 
 func (p *DriftoffPlugin) RegisterRoutes(router fiber.Router, db *gorm.DB, cfg *config.Config) {
-	svc := NewSleepService(db, cfg)
+	svc := NewSleepService(db, cfg, p.bus)
 	handler := NewSleepHandler(svc)
 
-	// Per-user rate limiter for AI-backed endpoints. Keyed on JWT token prefix so each
-	// authenticated user gets their own bucket — prevents AI cost abuse.
+	// Per-user rate limits for AI-backed endpoints, to bound AI costs.
 	// Heavy AI (coach, doctor-report): 5 per hour
 	// Light AI (cbti-insights): 10 per hour
-	aiHeavyLimiter := limiter.New(limiter.Config{
-		Max:               5,
-		Expiration:        1 * time.Hour,
-		LimiterMiddleware: limiter.SlidingWindow{},
-		KeyGenerator: func(c *fiber.Ctx) string {
-			auth := c.Get("Authorization")
-			if len(auth) > 39 { // "Bearer " (7) + 32 chars minimum
-				return "sleep_ai_heavy:" + auth[7:39]
-			}
-			return "sleep_ai_heavy:ip:" + c.IP()
-		},
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   true,
-				"message": "AI rate limit exceeded. Please try again in an hour.",
-			})
-		},
-	})
-	aiLightLimiter := limiter.New(limiter.Config{
-		Max:               10,
-		Expiration:        1 * time.Hour,
-		LimiterMiddleware: limiter.SlidingWindow{},
-		KeyGenerator: func(c *fiber.Ctx) string {
-			auth := c.Get("Authorization")
-			if len(auth) > 39 {
-				return "sleep_ai_light:" + auth[7:39]
-			}
-			return "sleep_ai_light:ip:" + c.IP()
-		},
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   true,
-				"message": "AI rate limit exceeded. Please try again in an hour.",
-			})
-		},
-	})
+	aiHeavyLimiter := sdk.AILimiter(p.ID(), "ai_heavy", 5)
+	aiLightLimiter := sdk.AILimiter(p.ID(), "ai_light", 10)
 
 	// Sleep CRUD routes
-	router.Post("/sleeps", handler.Create)
-	router.Get("/sleeps", handler.List)
-	router.Get("/sleeps/search", handler.Search)
-	router.Get("/sleeps/streak", handler.GetStreak)
-	router.Get("/sleeps/stats", handler.GetStats)
-	router.Get("/sleeps/debt", handler.GetSleepDebt)
-	router.Post("/sleeps/batch", handler.BatchImport)
+	router.Post("/sleeps", sdk.Handle(handler.Create))
+	router.Get("/sleeps", sdk.Handle(handler.List))
+	router.Get("/sleeps/search", sdk.Handle(handler.Search))
+	router.Get("/sleeps/streak", sdk.Handle(handler.GetStreak))
+	router.Get("/sleeps/stats", sdk.Handle(handler.GetStats))
+	router.Get("/sleeps/debt", sdk.Handle(handler.GetSleepDebt))
+	router.Post("/sleeps/batch", sdk.Handle(handler.BatchImport))
 
 	// Export
 	router.Get("/sleeps/export", handler.ExportSleepData)
 
 	// AI-powered routes (rate-limited; MUST be before parameterized routes)
-	router.Get("/sleeps/coach", aiHeavyLimiter, handler.GetSleepCoach)
-	router.Get("/sleeps/doctor-report", aiHeavyLimiter, handler.GetDoctorReport)
-	router.Get("/sleeps/hygiene", handler.GetHygieneScore)
-	router.Post("/sleeps/caffeine", handler.LogCaffeine)
-	router.Get("/sleeps/caffeine", handler.GetCaffeineLogs)
+	router.Get("/sleeps/coach", aiHeavyLimiter, sdk.Handle(handler.GetSleepCoach))
+	router.Get("/sleeps/doctor-report", aiHeavyLimiter, sdk.Handle(handler.GetDoctorReport))
+	router.Get("/sleeps/hygiene", sdk.Handle(handler.GetHygieneScore))
+	router.Post("/sleeps/caffeine", sdk.Handle(handler.LogCaffeine))
+	router.Get("/sleeps/caffeine", sdk.Handle(handler.GetCaffeineLogs))
 
 	// Correlation + CBT-I + SRI insights (MUST be before parameterized routes)
-	router.Get("/sleeps/sound-correlation", handler.GetSoundCorrelation)
-	router.Get("/sleeps/temp-correlation", handler.GetTempCorrelation)
-	router.Get("/sleeps/cbti-insights", aiLightLimiter, handler.GetCBTIInsights)
-	router.Get("/sleeps/lifestyle-correlation", handler.GetLifestyleCorrelation)
-	router.Get("/sleeps/sri", handler.GetSleepRegularityIndex)
+	router.Get("/sleeps/sound-correlation", sdk.Handle(handler.GetSoundCorrelation))
+	router.Get("/sleeps/temp-correlation", sdk.Handle(handler.GetTempCorrelation))
+	router.Get("/sleeps/cbti-insights", aiLightLimiter, sdk.Handle(handler.GetCBTIInsights))
+	router.Get("/sleeps/lifestyle-correlation", sdk.Handle(handler.GetLifestyleCorrelation))
+	router.Get("/sleeps/sri", sdk.Handle(handler.GetSleepRegularityIndex))
 
 	// Daytime alertness check-ins (UMD 2026 clinical trial pattern)
-	router.Post("/sleeps/alertness", handler.LogAlertness)
-	router.Get("/sleeps/alertness", handler.GetAlertnessLogs)
+	router.Post("/sleeps/alertness", sdk.Handle(handler.LogAlertness))
+	router.Get("/sleeps/alertness", sdk.Handle(handler.GetAlertnessLogs))
 
 	// Snoring analysis
-	router.Get("/sleeps/snoring-analysis", handler.GetSnoringAnalysis)
+	router.Get("/sleeps/snoring-analysis", sdk.Handle(handler.GetSnoringAnalysis))
 
 	// Pre-sleep ritual (MUST be before parameterized routes)
-	router.Post("/sleeps/ritual", handler.CreateRitual)
-	router.Get("/sleeps/ritual-correlation", handler.GetRitualCorrelation)
+	router.Post("/sleeps/ritual", sdk.Handle(handler.CreateRitual))
+	router.Get("/sleeps/ritual-correlation", sdk.Handle(handler.GetRitualCorrelation))
 
 	// CBT-I program (MUST be before parameterized routes)
-	router.Post("/sleeps/cbti/start", handler.StartCBTIProgram)
-	router.Get("/sleeps/cbti/status", handler.GetCBTIStatus)
-	router.Post("/sleeps/cbti/checkin", handler.SubmitCBTICheckIn)
-	router.Post("/sleeps/cbti/pause", handler.PauseCBTIProgram)
+	router.Post("/sleeps/cbti/start", sdk.Handle(handler.StartCBTIProgram))
+	router.Get("/sleeps/cbti/status", sdk.Handle(handler.GetCBTIStatus))
+	router.Post("/sleeps/cbti/checkin", sdk.Handle(handler.SubmitCBTICheckIn))
+	router.Post("/sleeps/cbti/pause", sdk.Handle(handler.PauseCBTIProgram))
 
 	// Parameterized routes (MUST be last)
-	router.Get("/sleeps/:id", handler.Get)
-	router.Put("/sleeps/:id", handler.Update)
-	router.Delete("/sleeps/:id", handler.Delete)
+	router.Get("/sleeps/:id", sdk.Handle(handler.Get))
+	router.Put("/sleeps/:id", sdk.Handle(handler.Update))
+	router.Delete("/sleeps/:id", sdk.Handle(handler.Delete))
 }
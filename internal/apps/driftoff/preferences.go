@@ -0,0 +1,44 @@
+package driftoff
+
+import (
+	"errors"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/google/uuid"
+)
+
+const preferencesNamespace = "driftoff"
+
+// SleepPreferences are the user's sleep settings, served at
+// /api/me/preferences/driftoff.
+type SleepPreferences struct {
+	// GoalHours is the nightly sleep goal that sleep debt is measured against.
+	GoalHours float64 `json:"goal_hours"`
+}
+
+func (p *SleepPreferences) Validate() error {
+	if p.GoalHours <= 0 || p.GoalHours > 12 {
+		return errors.New("goal_hours must be greater than 0 and at most 12")
+	}
+	return nil
+}
+
+func defaultSleepPreferences() interface{} {
+	return &SleepPreferences{GoalHours: 8}
+}
+
+func (p *DriftoffPlugin) Preferences() []services.PreferenceSchema {
+	return []services.PreferenceSchema{
+		{Namespace: preferencesNamespace, New: defaultSleepPreferences},
+	}
+}
+
+// GoalHours returns the user's nightly sleep goal, or the default if it
+// can't be read.
+func (s *SleepService) GoalHours(appID string, userID uuid.UUID) float64 {
+	prefs := defaultSleepPreferences().(*SleepPreferences)
+	if err := services.LoadPreferences(s.db, appID, userID, preferencesNamespace, prefs); err != nil || prefs.Validate() != nil {
+		return defaultSleepPreferences().(*SleepPreferences).GoalHours
+	}
+	return prefs.GoalHours
+}
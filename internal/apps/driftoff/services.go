@@ -7,13 +7,19 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"math"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/achievements"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/sdk"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/localtime"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -26,6 +32,21 @@ var (
 	ErrMissingTimes    = errors.New("bedtime and wake_time are required")
 	ErrNotFound        = errors.New("sleep session not found")
 	ErrNotOwner        = errors.New("not the owner of this session")
+	ErrNotEnoughData   = errors.New("not enough data: need 3+ sessions")
+
+	ErrInvalidBedtime     = errors.New("invalid bedtime format (use RFC3339)")
+	ErrInvalidWakeTime    = errors.New("invalid wake_time format (use RFC3339)")
+	ErrTooManyPhases      = errors.New("too many phases: max 50")
+	ErrTooManySounds      = errors.New("too many sounds: max 500")
+	ErrInvalidSoundscape  = errors.New("invalid soundscape_played value")
+	ErrInvalidRoomTemp    = errors.New("invalid room_temp value: must be cool, comfortable, or warm")
+	ErrInvalidLevel       = errors.New("level must be between 1 and 5")
+	ErrMissingDate        = errors.New("date is required")
+	ErrInvalidDate        = errors.New("date must be YYYY-MM-DD")
+	ErrNotesTooLong       = errors.New("notes must be at most 500 characters")
+	ErrMissingSleepWindow = errors.New("sleep_window_start and sleep_window_end are required")
+	ErrInvalidClockTime   = errors.New("sleep window times must be HH:MM")
+	ErrNoActiveCBTI       = errors.New("no active cbti program found")
 )
 
 // coachCacheEntry is a simple in-memory cache entry for sleep coach responses.
@@ -36,6 +57,9 @@ type coachCacheEntry struct {
 
 type SleepService struct {
 	db           *gorm.DB
+	events       *events.Bus
+	streaks      *streaks.Engine
+	achievements *achievements.Engine
 	aiAPIKey     string
 	aiAPIURL     string
 	aiModel      string
@@ -44,10 +68,10 @@ type SleepService struct {
 	coachCacheMu sync.Mutex
 }
 
-func NewSleepService(db *gorm.DB, cfg *config.Config) *SleepService {
+func NewSleepService(db *gorm.DB, cfg *config.Config, bus *events.Bus) *SleepService {
 	// Use OpenAI when key is configured; fall back to GLM (always configured) otherwise.
 	apiKey := cfg.OpenAIAPIKey
-	apiURL := "https://api.openai.com/v1/chat/completions"
+	apiURL := cfg.OpenAIBaseURL + "/chat/completions"
 	model := cfg.OpenAIModel
 	if model == "" {
 		model = "gpt-4o-mini"
@@ -65,12 +89,15 @@ func NewSleepService(db *gorm.DB, cfg *config.Config) *SleepService {
 		timeout = 60 * time.Second
 	}
 	return &SleepService{
-		db:         db,
-		aiAPIKey:   apiKey,
-		aiAPIURL:   apiURL,
-		aiModel:    model,
-		aiTimeout:  timeout,
-		coachCache: make(map[string]coachCacheEntry),
+		db:           db,
+		events:       bus,
+		streaks:      newSleepStreaks(db),
+		achievements: achievements.New(db, sleepAchievements),
+		aiAPIKey:     apiKey,
+		aiAPIURL:     apiURL,
+		aiModel:      model,
+		aiTimeout:    timeout,
+		coachCache:   make(map[string]coachCacheEntry),
 	}
 }
 
@@ -163,18 +190,18 @@ func (s *SleepService) Create(appID string, userID uuid.UUID, req CreateSleepReq
 
 	bedtime, err := time.Parse(time.RFC3339, req.Bedtime)
 	if err != nil {
-		return nil, fmt.Errorf("invalid bedtime format: %w", err)
+		return nil, ErrInvalidBedtime
 	}
 	wakeTime, err := time.Parse(time.RFC3339, req.WakeTime)
 	if err != nil {
-		return nil, fmt.Errorf("invalid wake_time format: %w", err)
+		return nil, ErrInvalidWakeTime
 	}
 
 	if len(req.Phases) > 50 {
-		return nil, errors.New("too many phases: max 50")
+		return nil, ErrTooManyPhases
 	}
 	if len(req.Sounds) > 500 {
-		return nil, errors.New("too many sounds: max 500")
+		return nil, ErrTooManySounds
 	}
 
 	// Validate soundscape_played against allowed values.
@@ -185,7 +212,7 @@ func (s *SleepService) Create(appID string, userID uuid.UUID, req CreateSleepReq
 	}
 	if req.SoundscapePlayed != nil && *req.SoundscapePlayed != "" {
 		if !validSoundscapes[*req.SoundscapePlayed] {
-			return nil, errors.New("invalid soundscape_played value")
+			return nil, ErrInvalidSoundscape
 		}
 	}
 
@@ -193,7 +220,7 @@ func (s *SleepService) Create(appID string, userID uuid.UUID, req CreateSleepReq
 	validRoomTemps := map[string]bool{"cool": true, "comfortable": true, "warm": true}
 	if req.RoomTemp != nil && *req.RoomTemp != "" {
 		if !validRoomTemps[*req.RoomTemp] {
-			return nil, errors.New("invalid room_temp value: must be cool, comfortable, or warm")
+			return nil, ErrInvalidRoomTemp
 		}
 	}
 
@@ -223,35 +250,42 @@ func (s *SleepService) Create(appID string, userID uuid.UUID, req CreateSleepReq
 		}
 	}
 
-	if err := s.db.Create(&session).Error; err != nil {
+	// The streak is updated by the sleep.session_logged subscriber.
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		return s.events.Publish(tx, appID, userID, events.SleepSessionLogged, events.SleepSessionLoggedPayload{SessionID: session.ID})
+	}); err != nil {
 		return nil, fmt.Errorf("create failed: %w", err)
 	}
 
-	go s.updateStreak(appID, userID)
-
 	return s.toResponse(session), nil
 }
 
-func (s *SleepService) List(appID string, userID uuid.UUID, limit, offset int) (*SleepListResponse, error) {
+func (s *SleepService) List(appID string, userID uuid.UUID, page sdk.Page) (*SleepListResponse, error) {
 	var sessions []SleepSession
 	var total int64
 
 	base := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID)
 	base.Model(&SleepSession{}).Count(&total)
 
-	if err := base.Order("created_at DESC").Limit(limit).Offset(offset).Find(&sessions).Error; err != nil {
+	if err := page.Apply(base, "created_at").Find(&sessions).Error; err != nil {
 		return nil, err
 	}
 
 	resp := &SleepListResponse{
 		Sessions: make([]SleepResponse, len(sessions)),
 		Total:    total,
-		Limit:    limit,
-		Offset:   offset,
+		Limit:    page.Limit,
+		Offset:   page.Offset,
 	}
 	for i, sess := range sessions {
 		resp.Sessions[i] = *s.toResponse(sess)
 	}
+	if n := len(sessions); n > 0 {
+		resp.NextCursor = page.Next(n, sdk.Cursor{Time: sessions[n-1].CreatedAt, ID: sessions[n-1].ID})
+	}
 	return resp, nil
 }
 
@@ -292,14 +326,14 @@ func (s *SleepService) Update(appID string, userID uuid.UUID, id uuid.UUID, req
 	}
 	if req.Phases != nil {
 		if len(*req.Phases) > 50 {
-			return nil, errors.New("too many phases: max 50")
+			return nil, ErrTooManyPhases
 		}
 		j, _ := json.Marshal(*req.Phases)
 		session.PhasesJSON = string(j)
 	}
 	if req.Sounds != nil {
 		if len(*req.Sounds) > 500 {
-			return nil, errors.New("too many sounds: max 500")
+			return nil, ErrTooManySounds
 		}
 		j, _ := json.Marshal(*req.Sounds)
 		session.SoundsJSON = string(j)
@@ -311,14 +345,14 @@ func (s *SleepService) Update(appID string, userID uuid.UUID, id uuid.UUID, req
 			"none": true,
 		}
 		if *req.SoundscapePlayed != "" && !validSoundscapesUpd[*req.SoundscapePlayed] {
-			return nil, errors.New("invalid soundscape_played value")
+			return nil, ErrInvalidSoundscape
 		}
 		session.SoundscapePlayed = req.SoundscapePlayed
 	}
 	if req.RoomTemp != nil {
 		validRoomTempsUpd := map[string]bool{"cool": true, "comfortable": true, "warm": true}
 		if *req.RoomTemp != "" && !validRoomTempsUpd[*req.RoomTemp] {
-			return nil, errors.New("invalid room_temp value: must be cool, comfortable, or warm")
+			return nil, ErrInvalidRoomTemp
 		}
 		session.RoomTemp = req.RoomTemp
 	}
@@ -375,24 +409,20 @@ func (s *SleepService) Search(appID string, userID uuid.UUID, q string) (*Search
 }
 
 func (s *SleepService) GetStreak(appID string, userID uuid.UUID) (*StreakResponse, error) {
-	var streak SleepStreak
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return &StreakResponse{}, nil
-	}
+	streak, err := s.streaks.Get(appID, userID)
 	if err != nil {
 		return nil, err
 	}
 
 	lastDate := ""
-	if !streak.LastSessionDate.IsZero() {
-		lastDate = streak.LastSessionDate.Format(time.RFC3339)
+	if streak.LastActivityAt != nil {
+		lastDate = streak.LastActivityAt.Format(time.RFC3339)
 	}
 
 	return &StreakResponse{
 		CurrentStreak:   streak.CurrentStreak,
 		LongestStreak:   streak.LongestStreak,
-		TotalSessions:   streak.TotalSessions,
+		TotalSessions:   streak.TotalCount,
 		LastSessionDate: lastDate,
 	}, nil
 }
@@ -643,46 +673,6 @@ func (s *SleepService) ExportSleepData(appID string, userID uuid.UUID, format st
 	return data, "application/json", nil
 }
 
-// updateStreak recalculates the user's streak after a new session.
-func (s *SleepService) updateStreak(appID string, userID uuid.UUID) {
-	var streak SleepStreak
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error
-
-	now := time.Now().Truncate(24 * time.Hour)
-
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		streak = SleepStreak{
-			AppID:           appID,
-			UserID:          userID,
-			CurrentStreak:   1,
-			LongestStreak:   1,
-			TotalSessions:   1,
-			LastSessionDate: now,
-		}
-		s.db.Create(&streak)
-		return
-	}
-
-	streak.TotalSessions++
-	lastDate := streak.LastSessionDate.Truncate(24 * time.Hour)
-
-	switch {
-	case now.Equal(lastDate):
-		// Same day
-	case now.Sub(lastDate) <= 48*time.Hour && now.Sub(lastDate) > 0:
-		streak.CurrentStreak++
-	default:
-		streak.CurrentStreak = 1
-	}
-
-	if streak.CurrentStreak > streak.LongestStreak {
-		streak.LongestStreak = streak.CurrentStreak
-	}
-	streak.LastSessionDate = now
-
-	s.db.Save(&streak)
-}
-
 func (s *SleepService) BatchImport(appID string, userID uuid.UUID, req BatchImportRequest) (*BatchImportResponse, error) {
 	resp := &BatchImportResponse{Results: []BatchImportResult{}}
 
@@ -744,7 +734,13 @@ func (s *SleepService) BatchImport(appID string, userID uuid.UUID, req BatchImpo
 			}
 		}
 
-		if err := s.db.Create(&session).Error; err != nil {
+		// Each imported session counts towards the streak like a logged one.
+		if err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&session).Error; err != nil {
+				return err
+			}
+			return s.events.Publish(tx, appID, userID, events.SleepSessionLogged, events.SleepSessionLoggedPayload{SessionID: session.ID})
+		}); err != nil {
 			result.Status = "error"
 			result.Error = "storage error"
 			resp.Skipped++
@@ -758,11 +754,6 @@ func (s *SleepService) BatchImport(appID string, userID uuid.UUID, req BatchImpo
 		resp.Results = append(resp.Results, result)
 	}
 
-	// Update streak once after all imports
-	if resp.Imported > 0 {
-		go s.updateStreak(appID, userID)
-	}
-
 	return resp, nil
 }
 
@@ -1092,10 +1083,9 @@ func (s *SleepService) GetHygieneScore(appID string, userID uuid.UUID) (*Hygiene
 		efficiencyScore = 12
 	}
 
-	// 4. Streak: fetch from streak table.
-	var streak SleepStreak
+	// 4. Streak: fetch from the shared streak.
 	streakDays := 0
-	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error; err == nil {
+	if streak, err := s.streaks.Get(appID, userID); err == nil {
 		streakDays = streak.CurrentStreak
 	}
 
@@ -1173,9 +1163,10 @@ func (s *SleepService) GetHygieneScore(appID string, userID uuid.UUID) (*Hygiene
 	}, nil
 }
 
-// LogCaffeine upserts today's caffeine log for the user (one record per user per day).
+// LogCaffeine upserts today's caffeine log for the user (one record per user
+// per local day).
 func (s *SleepService) LogCaffeine(appID string, userID uuid.UUID, caffeineML, exerciseMin int, lastCupAt *time.Time) (*DailyCaffeineLog, error) {
-	today := time.Now().UTC().Truncate(24 * time.Hour)
+	today := localtime.Today(localtime.ForUser(s.db, appID, userID))
 
 	log := DailyCaffeineLog{
 		ID:          uuid.New(),
@@ -1657,7 +1648,7 @@ func (s *SleepService) GetLifestyleCorrelation(appID string, userID uuid.UUID) (
 // LogAlertness records a single daytime alertness check-in.
 func (s *SleepService) LogAlertness(appID string, userID uuid.UUID, level int, loggedAt time.Time) (*AlertnessLogResponse, error) {
 	if level < 1 || level > 5 {
-		return nil, errors.New("level must be between 1 and 5")
+		return nil, ErrInvalidLevel
 	}
 	log := AlertnessLog{
 		AppID:    appID,
@@ -1666,7 +1657,7 @@ func (s *SleepService) LogAlertness(appID string, userID uuid.UUID, level int, l
 		LoggedAt: loggedAt,
 	}
 	if err := s.db.Scopes(tenant.ForTenant(appID)).Create(&log).Error; err != nil {
-		return nil, errors.New("storage error")
+		return nil, fmt.Errorf("create alertness log: %w", err)
 	}
 	return &AlertnessLogResponse{
 		ID:       log.ID,
@@ -1684,7 +1675,7 @@ func (s *SleepService) GetAlertnessLogs(appID string, userID uuid.UUID, days int
 		Where("user_id = ? AND logged_at >= ?", userID, since).
 		Order("logged_at ASC").
 		Find(&logs).Error; err != nil {
-		return nil, errors.New("storage error")
+		return nil, fmt.Errorf("fetch alertness logs: %w", err)
 	}
 
 	resp := &AlertnessListResponse{Days: days}
@@ -1731,7 +1722,7 @@ func (s *SleepService) GetAlertnessLogs(appID string, userID uuid.UUID, days int
 }
 
 // GetSnoringAnalysis analyses snoring events stored in the sounds_json JSONB field
-// across the last 30 sleep sessions. Returns 422 if fewer than 3 sessions exist.
+// across the last 30 sleep sessions. Returns ErrNotEnoughData if fewer than 3 sessions exist.
 func (s *SleepService) GetSnoringAnalysis(appID string, userID uuid.UUID) (*SnoringAnalysisResponse, error) {
 	type rawRow struct {
 		ID         string  `gorm:"column:id"`
@@ -1753,7 +1744,7 @@ func (s *SleepService) GetSnoringAnalysis(appID string, userID uuid.UUID) (*Snor
 
 	total := len(rows)
 	if total < 3 {
-		return nil, fmt.Errorf("not enough data")
+		return nil, ErrNotEnoughData
 	}
 
 	type sessionData struct {
@@ -1873,13 +1864,13 @@ func (s *SleepService) GetSnoringAnalysis(appID string, userID uuid.UUID) (*Snor
 // If a record already exists for that date it is overwritten.
 func (s *SleepService) CreateOrUpdateRitual(appID string, userID uuid.UUID, req CreateRitualRequest) (*SleepRitual, error) {
 	if req.Date == "" {
-		return nil, errors.New("date is required")
+		return nil, ErrMissingDate
 	}
 	if _, err := time.Parse("2006-01-02", req.Date); err != nil {
-		return nil, errors.New("date must be YYYY-MM-DD")
+		return nil, ErrInvalidDate
 	}
 	if len(req.Notes) > 500 {
-		return nil, errors.New("notes must be at most 500 characters")
+		return nil, ErrNotesTooLong
 	}
 	if req.ScreenTimeMin < 0 {
 		req.ScreenTimeMin = 0
@@ -2059,12 +2050,12 @@ func (s *SleepService) GetRitualCorrelation(appID string, userID uuid.UUID) (*Ri
 // If a program is already active, it returns the existing record without resetting it.
 func (s *SleepService) StartCBTIProgram(appID string, userID uuid.UUID, req StartCBTIRequest) (*CBTIStatusResponse, error) {
 	if req.SleepWindowStart == "" || req.SleepWindowEnd == "" {
-		return nil, errors.New("sleep_window_start and sleep_window_end are required")
+		return nil, ErrMissingSleepWindow
 	}
 	// Validate HH:MM format.
 	validateTime := func(t string) error {
 		if len(t) != 5 || t[2] != ':' {
-			return fmt.Errorf("invalid time format %q: must be HH:MM", t)
+			return fmt.Errorf("%w, got %q", ErrInvalidClockTime, t)
 		}
 		return nil
 	}
@@ -2091,7 +2082,7 @@ func (s *SleepService) StartCBTIProgram(appID string, userID uuid.UUID, req Star
 	prog := CBTIProgress{
 		AppID:            appID,
 		UserID:           userID,
-		StartDate:        time.Now().UTC().Format("2006-01-02"),
+		StartDate:        localtime.DateString(time.Now(), localtime.ForUser(s.db, appID, userID)),
 		CurrentWeek:      1,
 		CurrentDay:       1,
 		SleepWindowStart: req.SleepWindowStart,
@@ -2190,19 +2181,20 @@ func (s *SleepService) SubmitCBTICheckIn(appID string, userID uuid.UUID, req CBT
 		Where("app_id = ? AND user_id = ? AND is_active = true", appID, userID).
 		First(&prog).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, errors.New("no active cbti program found")
+		return nil, ErrNoActiveCBTI
 	}
 	if err != nil {
 		return nil, fmt.Errorf("fetch cbti progress: %w", err)
 	}
 	if len(req.Notes) > 500 {
-		return nil, errors.New("notes must be at most 500 characters")
+		return nil, ErrNotesTooLong
 	}
 
+	loc := localtime.ForUser(s.db, appID, userID)
 	checkIn := CBTIDayCheckIn{
 		AppID:     appID,
 		UserID:    userID,
-		Date:      time.Now().UTC().Format("2006-01-02"),
+		Date:      localtime.DateString(time.Now(), loc),
 		Week:      prog.CurrentWeek,
 		DidFollow: req.DidFollow,
 		Notes:     req.Notes,
@@ -2214,14 +2206,16 @@ func (s *SleepService) SubmitCBTICheckIn(appID string, userID uuid.UUID, req CBT
 	// Advance counters.
 	prog.CompletedDays++
 	prog.CurrentDay++
+	weekCompleted := false
 	if prog.CurrentDay > 7 {
 		prog.CurrentDay = 1
 		prog.CurrentWeek++
+		weekCompleted = true
 	}
 
 	// Mark complete if finished week 6.
 	if prog.CurrentWeek > 6 {
-		now := time.Now().UTC().Format("2006-01-02")
+		now := localtime.DateString(time.Now(), loc)
 		prog.CompletedAt = &now
 		prog.IsActive = false
 	}
@@ -2230,6 +2224,12 @@ func (s *SleepService) SubmitCBTICheckIn(appID string, userID uuid.UUID, req CBT
 		return nil, fmt.Errorf("update cbti progress: %w", err)
 	}
 
+	if weekCompleted {
+		if _, err := s.achievements.Emit(appID, userID, "cbti.weeks", prog.CurrentWeek-1); err != nil {
+			slog.Warn("achievement evaluation failed", "app_id", appID, "user_id", userID, "event", "cbti.weeks", "error", err)
+		}
+	}
+
 	return s.buildCBTIStatusResponse(appID, userID, &prog)
 }
 
@@ -2243,8 +2243,7 @@ func (s *SleepService) PauseCBTIProgram(appID string, userID uuid.UUID) error {
 		return fmt.Errorf("pause cbti: %w", result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return errors.New("no active cbti program found")
+		return ErrNoActiveCBTI
 	}
 	return nil
 }
-
@@ -0,0 +1,42 @@
+package driftoff
+
+import (
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// sleepStreakPolicy is lenient: a session logged within two days of the last
+// one keeps the streak going, since nights are often logged the next day.
+var sleepStreakPolicy = streaks.Policy{MaxGap: 2}
+
+func newSleepStreaks(db *gorm.DB) *streaks.Engine {
+	return streaks.New(db, "sleep", sleepStreakPolicy)
+}
+
+// Migrate implements apps.MigrationPlugin, carrying sleep_streaks rows over
+// to the shared streaks table.
+func (p *DriftoffPlugin) Migrate(db *gorm.DB) error {
+	_, err := newSleepStreaks(db).ImportLegacy(streaks.Legacy{
+		Table:      "sleep_streaks",
+		Total:      "total_sessions",
+		LastActive: "last_session_date",
+	})
+	return err
+}
+
+// RecomputeStreaks implements services.StreakRecomputer, rebuilding the sleep
+// streak from session log times bucketed by local day in loc.
+func (p *DriftoffPlugin) RecomputeStreaks(db *gorm.DB, appID string, userID uuid.UUID, loc *time.Location) error {
+	var times []time.Time
+	if err := db.Model(&SleepSession{}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ?", userID).
+		Pluck("created_at", &times).Error; err != nil {
+		return err
+	}
+	return newSleepStreaks(db).Recompute(appID, userID, loc, times)
+}
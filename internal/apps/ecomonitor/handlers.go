@@ -6,8 +6,10 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -33,17 +35,12 @@ func (h *CoordinateHandler) CreateCoordinate(c *fiber.Ctx) error {
 	}
 
 	var req CreateCoordinateRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: true, Message: "Invalid request body"})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	coord, err := h.coordService.Create(appID, userID, &req)
 	if err != nil {
-		if errors.Is(err, ErrInvalidLatitude) || errors.Is(err, ErrInvalidLongitude) ||
-			errors.Is(err, ErrLabelRequired) || errors.Is(err, ErrLabelTooLong) ||
-			errors.Is(err, ErrDescriptionTooLong) {
-			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: true, Message: err.Error()})
-		}
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: true, Message: "Failed to create coordinate"})
 	}
 
@@ -111,8 +108,8 @@ func (h *CoordinateHandler) UpdateCoordinate(c *fiber.Ctx) error {
 	}
 
 	var req UpdateCoordinateRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: true, Message: "Invalid request body"})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	coord, err := h.coordService.Update(appID, id, userID, &req)
@@ -120,9 +117,7 @@ func (h *CoordinateHandler) UpdateCoordinate(c *fiber.Ctx) error {
 		if errors.Is(err, ErrCoordinateNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{Error: true, Message: err.Error()})
 		}
-		if errors.Is(err, ErrInvalidLatitude) || errors.Is(err, ErrInvalidLongitude) ||
-			errors.Is(err, ErrLabelRequired) || errors.Is(err, ErrLabelTooLong) ||
-			errors.Is(err, ErrDescriptionTooLong) {
+		if errors.Is(err, ErrLabelRequired) {
 			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: true, Message: err.Error()})
 		}
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: true, Message: "Failed to update coordinate"})
@@ -307,10 +302,8 @@ func (h *ExportHandler) ExportCSV(c *fiber.Ctx) error {
 	err = h.db.Where("user_id = ? AND app_id = ? AND status = ?", userID, appID, "active").First(&subscription).Error
 	if err != nil || subscription.CurrentPeriodEnd.Before(time.Now()) {
 		if errors.Is(err, gorm.ErrRecordNotFound) || err == nil {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "CSV export is a premium feature. Please upgrade to export your data.",
-				"code":  "PREMIUM_REQUIRED",
-			})
+			return apierr.New(fiber.StatusForbidden, "CSV export is a premium feature. Please upgrade to export your data.").
+				WithCode("PREMIUM_REQUIRED")
 		}
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: true, Message: "Failed to check subscription status"})
 	}
@@ -62,17 +62,17 @@ func (AnalysisHistory) TableName() string {
 // --- DTOs ---
 
 type CreateCoordinateRequest struct {
-	Latitude    float64 `json:"latitude"`
-	Longitude   float64 `json:"longitude"`
-	Label       string  `json:"label"`
-	Description string  `json:"description"`
+	Latitude    float64 `json:"latitude" validate:"latitude"`
+	Longitude   float64 `json:"longitude" validate:"longitude"`
+	Label       string  `json:"label" validate:"required,max=255"`
+	Description string  `json:"description" validate:"max=500"`
 }
 
 type UpdateCoordinateRequest struct {
-	Latitude    *float64 `json:"latitude"`
-	Longitude   *float64 `json:"longitude"`
-	Label       *string  `json:"label"`
-	Description *string  `json:"description"`
+	Latitude    *float64 `json:"latitude" validate:"latitude"`
+	Longitude   *float64 `json:"longitude" validate:"longitude"`
+	Label       *string  `json:"label" validate:"max=255"`
+	Description *string  `json:"description" validate:"max=500"`
 }
 
 type CoordinateResponse struct {
@@ -22,11 +22,7 @@ import (
 
 var (
 	ErrCoordinateNotFound = errors.New("coordinate not found")
-	ErrInvalidLatitude    = errors.New("latitude must be between -90 and 90")
-	ErrInvalidLongitude   = errors.New("longitude must be between -180 and 180")
 	ErrLabelRequired      = errors.New("label is required")
-	ErrLabelTooLong       = errors.New("label must be at most 255 characters")
-	ErrDescriptionTooLong = errors.New("description must be at most 500 characters")
 	ErrAINotConfigured    = errors.New("AI analysis service not configured")
 )
 
@@ -74,10 +70,6 @@ func NewCoordinateService(db *gorm.DB) *CoordinateService {
 }
 
 func (s *CoordinateService) Create(appID string, userID uuid.UUID, req *CreateCoordinateRequest) (*CoordinateResponse, error) {
-	if err := validateCoordinateInput(req.Latitude, req.Longitude, req.Label, req.Description); err != nil {
-		return nil, err
-	}
-
 	coord := Coordinate{
 		ID:          uuid.New(),
 		AppID:       appID,
@@ -149,31 +141,20 @@ func (s *CoordinateService) Update(appID string, id, userID uuid.UUID, req *Upda
 	updates := map[string]interface{}{}
 
 	if req.Latitude != nil {
-		if *req.Latitude < -90 || *req.Latitude > 90 {
-			return nil, ErrInvalidLatitude
-		}
 		updates["latitude"] = *req.Latitude
 	}
 	if req.Longitude != nil {
-		if *req.Longitude < -180 || *req.Longitude > 180 {
-			return nil, ErrInvalidLongitude
-		}
 		updates["longitude"] = *req.Longitude
 	}
 	if req.Label != nil {
+		// A label can't be cleared, only replaced.
 		trimmed := strings.TrimSpace(*req.Label)
 		if trimmed == "" {
 			return nil, ErrLabelRequired
 		}
-		if len(trimmed) > 255 {
-			return nil, ErrLabelTooLong
-		}
 		updates["label"] = trimmed
 	}
 	if req.Description != nil {
-		if len(*req.Description) > 500 {
-			return nil, ErrDescriptionTooLong
-		}
 		updates["description"] = *req.Description
 	}
 
@@ -203,25 +184,6 @@ func (s *CoordinateService) Delete(appID string, id, userID uuid.UUID) error {
 	return nil
 }
 
-func validateCoordinateInput(lat, lng float64, label, description string) error {
-	if lat < -90 || lat > 90 {
-		return ErrInvalidLatitude
-	}
-	if lng < -180 || lng > 180 {
-		return ErrInvalidLongitude
-	}
-	if strings.TrimSpace(label) == "" {
-		return ErrLabelRequired
-	}
-	if len(label) > 255 {
-		return ErrLabelTooLong
-	}
-	if len(description) > 500 {
-		return ErrDescriptionTooLong
-	}
-	return nil
-}
-
 func mapCoordinateToResponse(c *Coordinate) *CoordinateResponse {
 	return &CoordinateResponse{
 		ID:          c.ID,
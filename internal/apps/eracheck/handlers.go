@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -24,9 +26,7 @@ func (h *EraHandler) GetQuestions(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	questions, err := h.eraService.GetQuizQuestions(appID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to retrieve questions",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to retrieve questions")
 	}
 	return c.JSON(fiber.Map{"error": false, "questions": questions})
 }
@@ -35,25 +35,19 @@ func (h *EraHandler) SubmitQuiz(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	var req struct {
-		Answers map[string]int `json:"answers"`
+		Answers map[string]int `json:"answers" validate:"required"`
 	}
-	if err := c.BodyParser(&req); err != nil || len(req.Answers) == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Answers are required",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	result, err := h.eraService.SubmitQuizAnswers(appID, userID, req.Answers)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to submit quiz",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to submit quiz")
 	}
 
 	response := fiber.Map{"result": result}
@@ -71,16 +65,12 @@ func (h *EraHandler) GetResults(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	results, err := h.eraService.GetUserResults(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to retrieve results",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to retrieve results")
 	}
 	return c.JSON(fiber.Map{"error": false, "results": results})
 }
@@ -88,21 +78,15 @@ func (h *EraHandler) GetResults(c *fiber.Ctx) error {
 func (h *EraHandler) GetResult(c *fiber.Ctx) error {
 	id, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid result ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid result ID")
 	}
 
 	result, err := h.eraService.GetResultByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) || err.Error() == "record not found" {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": true, "message": "Result not found",
-			})
+			return apierr.New(fiber.StatusNotFound, "Result not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to retrieve result",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to retrieve result")
 	}
 
 	response := fiber.Map{"result": result}
@@ -115,15 +99,11 @@ func (h *EraHandler) GetResult(c *fiber.Ctx) error {
 func (h *EraHandler) ShareResult(c *fiber.Ctx) error {
 	id, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid result ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid result ID")
 	}
 
 	if err := h.eraService.IncrementShareCount(id); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to update share count",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to update share count")
 	}
 	return c.JSON(fiber.Map{"error": false, "message": "Share count incremented"})
 }
@@ -132,16 +112,12 @@ func (h *EraHandler) GetStats(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	stats, err := h.eraService.GetEraStats(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to retrieve stats",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to retrieve stats")
 	}
 	return c.JSON(fiber.Map{"error": false, "stats": stats})
 }
@@ -161,16 +137,12 @@ func (h *ChallengeHandler) GetDailyChallenge(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	challenge, err := h.challengeService.GetDailyChallenge(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to retrieve challenge",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to retrieve challenge")
 	}
 	return c.JSON(fiber.Map{"error": false, "challenge": challenge.ToPublicView()})
 }
@@ -179,25 +151,19 @@ func (h *ChallengeHandler) SubmitChallenge(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	var req struct {
-		Answer string `json:"answer"`
+		Answer string `json:"answer" validate:"required"`
 	}
-	if err := c.BodyParser(&req); err != nil || req.Answer == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Answer is required",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	challenge, err := h.challengeService.SubmitChallengeAnswer(appID, userID, req.Answer)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": err.Error(),
-		})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	if err := h.streakService.UpdateStreak(appID, userID); err != nil {
@@ -215,16 +181,12 @@ func (h *ChallengeHandler) GetHistory(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	challenges, err := h.challengeService.GetChallengeHistory(appID, userID, c.QueryInt("limit", 30))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to retrieve history",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to retrieve history")
 	}
 
 	views := make([]ChallengePublicView, len(challenges))
@@ -238,24 +200,14 @@ func (h *ChallengeHandler) GetStreak(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
-	streak, err := h.streakService.GetOrCreateStreak(appID, userID)
+	streak, err := h.streakService.GetStreak(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to retrieve streak",
-		})
-	}
-
-	badges, err := h.streakService.GetStreakBadges(appID, userID)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to retrieve badges",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to retrieve streak")
 	}
+	badges := h.streakService.GetStreakBadges(streak)
 
 	return c.JSON(fiber.Map{"error": false, "streak": streak, "badges": badges})
 }
@@ -264,16 +216,12 @@ func (h *ChallengeHandler) UseStreakFreeze(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	streak, err := h.streakService.UseStreakFreeze(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": err.Error(),
-		})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.JSON(fiber.Map{"error": false, "streak": streak, "message": "Streak freeze used successfully"})
@@ -301,25 +249,19 @@ func (h *PhotoHandler) AnalyzePhoto(c *fiber.Ctx) error {
 	// Parse multipart photo
 	file, err := c.FormFile("photo")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Photo is required",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Photo is required")
 	}
 
 	// Read the file
 	f, err := file.Open()
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to read photo",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to read photo")
 	}
 	defer f.Close()
 
 	data, err := io.ReadAll(f)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to read photo data",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to read photo data")
 	}
 
 	// Determine MIME type from content type or filename
@@ -334,9 +276,7 @@ func (h *PhotoHandler) AnalyzePhoto(c *fiber.Ctx) error {
 
 	analysis, err := h.photoService.AnalyzePhoto(appID, userID, imageURL)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to analyze photo: " + err.Error(),
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to analyze photo: "+err.Error())
 	}
 
 	return c.JSON(fiber.Map{
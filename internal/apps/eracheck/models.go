@@ -101,17 +101,3 @@ type PhotoAnalysis struct {
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 }
 
-type EraStreak struct {
-	ID              uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	AppID           string         `gorm:"size:50;not null;uniqueIndex:idx_era_streak_app_user" json:"app_id"`
-	UserID          uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_era_streak_app_user" json:"user_id"`
-	CurrentStreak   int            `gorm:"default:0" json:"current_streak"`
-	LongestStreak   int            `gorm:"default:0" json:"longest_streak"`
-	LastActiveDate  time.Time      `gorm:"type:date" json:"last_active_date"`
-	StreakFreezes   int            `gorm:"default:1" json:"streak_freezes"`
-	TotalQuizzes    int            `gorm:"default:0" json:"total_quizzes"`
-	TotalChallenges int            `gorm:"default:0" json:"total_challenges"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
-}
@@ -24,7 +24,6 @@ func (p *Plugin) Models() []interface{} {
 		&EraQuiz{},
 		&EraResult{},
 		&EraChallenge{},
-		&EraStreak{},
 		&PhotoAnalysis{},
 	}
 }
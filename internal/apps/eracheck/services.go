@@ -12,7 +12,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -217,8 +219,7 @@ func (s *EraService) GetEraStats(appID string, userID uuid.UUID) (map[string]int
 		Select("COALESCE(SUM(share_count),0)").Scan(&totalShares)
 	stats["total_shares"] = totalShares
 
-	var streak EraStreak
-	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error; err != nil {
+	if streak, err := newEraStreaks(s.db).Get(appID, userID); err != nil {
 		stats["current_streak"] = 0
 		stats["longest_streak"] = 0
 	} else {
@@ -246,109 +247,63 @@ func (s *EraService) GetEraStats(appID string, userID uuid.UUID) (map[string]int
 
 // --- Streak Service ---
 
+// eraStreakPolicy counts consecutive days with a completed challenge. Users
+// start with one freeze, spent on request to cover a missed day.
+var eraStreakPolicy = streaks.Policy{
+	MaxGap:         1,
+	InitialFreezes: 1,
+	Milestones: []streaks.Milestone{
+		{Key: "starter", Name: "Starter", Description: "Complete 1 day", Emoji: "⭐", Required: 1},
+		{Key: "committed", Name: "Committed", Description: "3-day streak", Emoji: "🔥", Required: 3},
+		{Key: "dedicated", Name: "Dedicated", Description: "7-day streak", Emoji: "💎", Required: 7},
+		{Key: "obsessed", Name: "Obsessed", Description: "14-day streak", Emoji: "👑", Required: 14},
+		{Key: "legend", Name: "Legend", Description: "30-day streak", Emoji: "🏆", Required: 30},
+		{Key: "icon", Name: "Icon", Description: "50-day streak", Emoji: "💫", Required: 50},
+	},
+}
+
+func newEraStreaks(db *gorm.DB) *streaks.Engine {
+	return streaks.New(db, "challenge", eraStreakPolicy)
+}
+
+// Migrate implements apps.MigrationPlugin, carrying era_streaks rows over to
+// the shared streaks table.
+func (p *Plugin) Migrate(db *gorm.DB) error {
+	_, err := newEraStreaks(db).ImportLegacy(streaks.Legacy{
+		Table:            "era_streaks",
+		Where:            "deleted_at IS NULL",
+		Total:            "total_challenges",
+		LastActive:       "last_active_date",
+		FreezesAvailable: "streak_freezes",
+	})
+	return err
+}
+
 type StreakService struct {
-	db *gorm.DB
+	streaks *streaks.Engine
 }
 
 func NewStreakService(db *gorm.DB) *StreakService {
-	return &StreakService{db: db}
+	return &StreakService{streaks: newEraStreaks(db)}
 }
 
-func (s *StreakService) GetOrCreateStreak(appID string, userID uuid.UUID) (*EraStreak, error) {
-	var streak EraStreak
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error
-	if err == nil {
-		return &streak, nil
-	}
-	if !errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, err
-	}
-
-	streak = EraStreak{
-		AppID:          appID,
-		UserID:         userID,
-		LastActiveDate: time.Now().Truncate(24 * time.Hour),
-	}
-	if err := s.db.Create(&streak).Error; err != nil {
-		return nil, err
-	}
-	return &streak, nil
+func (s *StreakService) GetStreak(appID string, userID uuid.UUID) (*models.Streak, error) {
+	return s.streaks.Get(appID, userID)
 }
 
 func (s *StreakService) UpdateStreak(appID string, userID uuid.UUID) error {
-	streak, err := s.GetOrCreateStreak(appID, userID)
-	if err != nil {
-		return err
-	}
-
-	today := time.Now().Truncate(24 * time.Hour)
-	lastActive := streak.LastActiveDate.Truncate(24 * time.Hour)
-
-	if today.Equal(lastActive) {
-		return nil
-	}
-
-	if lastActive.Equal(today.Add(-24 * time.Hour)) {
-		streak.CurrentStreak++
-	} else {
-		streak.CurrentStreak = 1
-	}
-
-	if streak.CurrentStreak > streak.LongestStreak {
-		streak.LongestStreak = streak.CurrentStreak
-	}
-	streak.LastActiveDate = today
-	return s.db.Save(&streak).Error
+	_, err := s.streaks.Record(appID, userID, time.Now())
+	return err
 }
 
 // UseStreakFreeze allows user to preserve their streak when they miss a day.
 // Returns the updated streak or error if no freezes available.
-func (s *StreakService) UseStreakFreeze(appID string, userID uuid.UUID) (*EraStreak, error) {
-	streak, err := s.GetOrCreateStreak(appID, userID)
-	if err != nil {
-		return nil, err
-	}
-	if streak.StreakFreezes <= 0 {
-		return nil, errors.New("no streak freezes available")
-	}
-
-	today := time.Now().Truncate(24 * time.Hour)
-	lastActive := streak.LastActiveDate.Truncate(24 * time.Hour)
-
-	// Only allow freeze if they missed exactly yesterday (streak would break today)
-	if today.Sub(lastActive) < 24*time.Hour || today.Sub(lastActive) > 72*time.Hour {
-		return nil, errors.New("streak freeze can only be used within 2 days of last activity")
-	}
-
-	streak.StreakFreezes--
-	streak.LastActiveDate = today // Pretend they were active
-	if err := s.db.Save(&streak).Error; err != nil {
-		return nil, err
-	}
-	return streak, nil
+func (s *StreakService) UseStreakFreeze(appID string, userID uuid.UUID) (*models.Streak, error) {
+	return s.streaks.UseFreeze(appID, userID)
 }
 
-type StreakBadge struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Emoji       string `json:"emoji"`
-	Required    int    `json:"required"`
-	Unlocked    bool   `json:"unlocked"`
-}
-
-func (s *StreakService) GetStreakBadges(appID string, userID uuid.UUID) ([]StreakBadge, error) {
-	streak, err := s.GetOrCreateStreak(appID, userID)
-	if err != nil {
-		return nil, err
-	}
-	return []StreakBadge{
-		{Name: "Starter", Description: "Complete 1 day", Emoji: "⭐", Required: 1, Unlocked: streak.CurrentStreak >= 1},
-		{Name: "Committed", Description: "3-day streak", Emoji: "🔥", Required: 3, Unlocked: streak.CurrentStreak >= 3},
-		{Name: "Dedicated", Description: "7-day streak", Emoji: "💎", Required: 7, Unlocked: streak.CurrentStreak >= 7},
-		{Name: "Obsessed", Description: "14-day streak", Emoji: "👑", Required: 14, Unlocked: streak.CurrentStreak >= 14},
-		{Name: "Legend", Description: "30-day streak", Emoji: "🏆", Required: 30, Unlocked: streak.CurrentStreak >= 30},
-		{Name: "Icon", Description: "50-day streak", Emoji: "💫", Required: 50, Unlocked: streak.CurrentStreak >= 50},
-	}, nil
+func (s *StreakService) GetStreakBadges(streak *models.Streak) []streaks.Badge {
+	return s.streaks.Badges(streak)
 }
 
 // --- Challenge Service ---
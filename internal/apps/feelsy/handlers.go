@@ -3,7 +3,10 @@ package feelsy
 import (
 	"strconv"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -23,9 +26,7 @@ func (h *FeelHandler) CreateFeelCheck(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	var req struct {
@@ -35,17 +36,13 @@ func (h *FeelHandler) CreateFeelCheck(c *fiber.Ctx) error {
 		Note         string `json:"note"`
 		JournalEntry string `json:"journal_entry"`
 	}
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	check, err := h.service.CreateFeelCheck(appID, userID, req.MoodScore, req.EnergyScore, req.MoodEmoji, req.Note, req.JournalEntry)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": err.Error(),
-		})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(check)
@@ -56,16 +53,12 @@ func (h *FeelHandler) GetTodayCheck(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	check, err := h.service.GetTodayCheck(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": true, "message": "No check-in today",
-		})
+		return apierr.New(fiber.StatusNotFound, "No check-in today")
 	}
 
 	return c.JSON(check)
@@ -76,9 +69,7 @@ func (h *FeelHandler) GetFeelHistory(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
@@ -95,9 +86,7 @@ func (h *FeelHandler) GetFeelHistory(c *fiber.Ctx) error {
 
 	checks, total, err := h.service.GetFeelHistory(appID, userID, limit, offset)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to fetch history",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch history")
 	}
 
 	return c.JSON(fiber.Map{
@@ -111,16 +100,12 @@ func (h *FeelHandler) GetFeelStats(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	stats, err := h.service.GetFeelStats(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to fetch stats",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch stats")
 	}
 
 	return c.JSON(stats)
@@ -131,9 +116,7 @@ func (h *FeelHandler) SendGoodVibe(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	var req struct {
@@ -141,24 +124,22 @@ func (h *FeelHandler) SendGoodVibe(c *fiber.Ctx) error {
 		Message    string `json:"message"`
 		VibeType   string `json:"vibe_type"`
 	}
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	receiverID, err := uuid.Parse(req.ReceiverID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid receiver ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid receiver ID")
 	}
 
-	vibe, err := h.service.SendGoodVibe(appID, userID, receiverID, req.Message, req.VibeType)
+	blocks, err := services.RequestBlockSet(c, h.service.db, appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": err.Error(),
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load block list")
+	}
+	vibe, err := h.service.SendGoodVibe(appID, userID, blocks, receiverID, req.Message, req.VibeType)
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(vibe)
@@ -169,9 +150,7 @@ func (h *FeelHandler) GetReceivedVibes(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
@@ -179,11 +158,13 @@ func (h *FeelHandler) GetReceivedVibes(c *fiber.Ctx) error {
 		limit = 50
 	}
 
-	vibes, err := h.service.GetReceivedVibes(appID, userID, limit)
+	blocks, err := services.RequestBlockSet(c, h.service.db, appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to fetch vibes",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load block list")
+	}
+	vibes, err := h.service.GetReceivedVibes(appID, userID, blocks, limit)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch vibes")
 	}
 
 	return c.JSON(fiber.Map{"data": vibes})
@@ -194,16 +175,16 @@ func (h *FeelHandler) GetFriendFeels(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
-	feels, err := h.service.GetFriendFeels(appID, userID)
+	blocks, err := services.RequestBlockSet(c, h.service.db, appID, userID)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load block list")
+	}
+	feels, err := h.service.GetFriendFeels(appID, userID, blocks)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to fetch friend feels",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch friend feels")
 	}
 
 	return c.JSON(fiber.Map{"data": feels})
@@ -214,31 +195,27 @@ func (h *FeelHandler) SendFriendRequest(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	var req struct {
 		FriendEmail string `json:"friend_email"`
 	}
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	if req.FriendEmail == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "friend_email is required",
-		})
+		return apierr.New(fiber.StatusBadRequest, "friend_email is required")
 	}
 
-	request, err := h.service.SendFriendRequest(appID, userID, req.FriendEmail)
+	blocks, err := services.RequestBlockSet(c, h.service.db, appID, userID)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load block list")
+	}
+	request, err := h.service.SendFriendRequest(appID, userID, blocks, req.FriendEmail)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": err.Error(),
-		})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": request})
@@ -249,22 +226,16 @@ func (h *FeelHandler) AcceptFriendRequest(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	requestID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid request ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid request ID")
 	}
 
 	if err := h.service.AcceptFriendRequest(appID, userID, requestID); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": err.Error(),
-		})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.JSON(fiber.Map{"message": "Friend request accepted"})
@@ -275,22 +246,16 @@ func (h *FeelHandler) RejectFriendRequest(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	requestID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid request ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid request ID")
 	}
 
 	if err := h.service.RejectFriendRequest(appID, userID, requestID); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": err.Error(),
-		})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.JSON(fiber.Map{"message": "Friend request rejected"})
@@ -301,16 +266,12 @@ func (h *FeelHandler) ListFriendRequests(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	requests, err := h.service.ListFriendRequests(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to fetch friend requests",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch friend requests")
 	}
 
 	return c.JSON(fiber.Map{"data": requests})
@@ -321,16 +282,16 @@ func (h *FeelHandler) ListFriends(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
-	friends, err := h.service.ListFriends(appID, userID)
+	blocks, err := services.RequestBlockSet(c, h.service.db, appID, userID)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load block list")
+	}
+	friends, err := h.service.ListFriends(appID, userID, blocks)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to fetch friends",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch friends")
 	}
 
 	return c.JSON(fiber.Map{"data": friends})
@@ -341,22 +302,16 @@ func (h *FeelHandler) RemoveFriend(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	friendshipID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid friendship ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid friendship ID")
 	}
 
 	if err := h.service.RemoveFriend(appID, userID, friendshipID); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": err.Error(),
-		})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.JSON(fiber.Map{"message": "Friend removed"})
@@ -367,16 +322,12 @@ func (h *FeelHandler) GetWeeklyInsights(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	insights, err := h.service.GetWeeklyInsights(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to fetch weekly insights",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch weekly insights")
 	}
 
 	return c.JSON(insights)
@@ -387,32 +338,24 @@ func (h *FeelHandler) UpdateJournal(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	checkID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid check-in ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid check-in ID")
 	}
 
 	var req struct {
 		JournalEntry string `json:"journal_entry"`
 	}
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	check, err := h.service.UpdateJournalEntry(appID, userID, checkID, req.JournalEntry)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": err.Error(),
-		})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.JSON(check)
@@ -423,16 +366,12 @@ func (h *FeelHandler) GetWeeklyRecap(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	recap, err := h.service.GetWeeklyRecap(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": true, "message": "No data for recap",
-		})
+		return apierr.New(fiber.StatusNotFound, "No data for recap")
 	}
 
 	return c.JSON(recap)
@@ -48,21 +48,16 @@ func (f *FeelCheck) GetColorHex() string {
 	}
 }
 
-// FeelStreak tracks daily check-in streaks.
-type FeelStreak struct {
-	ID             uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	AppID          string         `gorm:"size:50;not null;index;uniqueIndex:idx_feel_streak_app_user" json:"app_id"`
-	UserID         uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_feel_streak_app_user" json:"user_id"`
-	CurrentStreak  int            `gorm:"default:0" json:"current_streak"`
-	LongestStreak  int            `gorm:"default:0" json:"longest_streak"`
-	TotalCheckIns  int            `gorm:"default:0" json:"total_check_ins"`
-	LastCheckDate  *time.Time     `gorm:"type:date" json:"last_check_date"`
-	AverageScore   float64        `gorm:"default:0" json:"average_score"`
-	UnlockedBadges string         `gorm:"type:text;default:''" json:"unlocked_badges"`
-	LastMessageIdx int            `gorm:"default:0" json:"last_message_idx"`
-	CreatedAt      time.Time      `json:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at"`
-	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+// FeelInsightState remembers which weekly insight message a user saw last
+// so consecutive insights don't repeat. Streaks live in the shared streaks
+// table.
+type FeelInsightState struct {
+	ID             uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID          string    `gorm:"size:50;not null;index;uniqueIndex:idx_feel_insight_app_user" json:"app_id"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_feel_insight_app_user" json:"user_id"`
+	LastMessageIdx int       `gorm:"default:0" json:"last_message_idx"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 // FeelFriend represents friend connections for comparing feels.
@@ -22,7 +22,7 @@ func (p *Plugin) ID() string { return "feelsy" }
 func (p *Plugin) Models() []interface{} {
 	return []interface{}{
 		&FeelCheck{},
-		&FeelStreak{},
+		&FeelInsightState{},
 		&FeelFriend{},
 		&GoodVibe{},
 	}
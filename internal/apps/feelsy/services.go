@@ -1,14 +1,16 @@
 package feelsy
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
-	"strings"
 	"time"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/locale"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -18,11 +20,24 @@ import (
 type FeelService struct {
 	db                *gorm.DB
 	moderationService *services.ModerationService
+	streaks           *streaks.Engine
 }
 
 // NewFeelService creates a new FeelService.
 func NewFeelService(db *gorm.DB, moderationService *services.ModerationService) *FeelService {
-	return &FeelService{db: db, moderationService: moderationService}
+	return &FeelService{db: db, moderationService: moderationService, streaks: newFeelStreaks(db)}
+}
+
+// filterContent replaces text that fails moderation, checked in the
+// author's language, with a placeholder.
+func (s *FeelService) filterContent(appID string, userID uuid.UUID, text string) string {
+	if s.moderationService == nil || text == "" {
+		return text
+	}
+	if !s.moderationService.CheckContent(context.Background(), appID, locale.ForUser(s.db, appID, userID), text).Allowed {
+		return "[content filtered]"
+	}
+	return text
 }
 
 // CreateFeelCheck creates a new daily mood check-in.
@@ -38,19 +53,9 @@ func (s *FeelService) CreateFeelCheck(appID string, userID uuid.UUID, moodScore,
 		return nil, errors.New("already checked in today")
 	}
 
-	// Filter note for prohibited content
-	if s.moderationService != nil && note != "" {
-		if isClean, _ := s.moderationService.FilterContent(note); !isClean {
-			note = "[content filtered]"
-		}
-	}
-
-	// Filter journal entry for prohibited content
-	if s.moderationService != nil && journalEntry != "" {
-		if isClean, _ := s.moderationService.FilterContent(journalEntry); !isClean {
-			journalEntry = "[content filtered]"
-		}
-	}
+	// Filter note and journal entry for prohibited content
+	note = s.filterContent(appID, userID, note)
+	journalEntry = s.filterContent(appID, userID, journalEntry)
 
 	check := &FeelCheck{
 		AppID:        appID,
@@ -84,13 +89,7 @@ func (s *FeelService) UpdateJournalEntry(appID string, userID, checkID uuid.UUID
 		return nil, err
 	}
 
-	if s.moderationService != nil && journalEntry != "" {
-		if isClean, _ := s.moderationService.FilterContent(journalEntry); !isClean {
-			journalEntry = "[content filtered]"
-		}
-	}
-
-	check.JournalEntry = journalEntry
+	check.JournalEntry = s.filterContent(appID, userID, journalEntry)
 	if err := s.db.Save(&check).Error; err != nil {
 		return nil, err
 	}
@@ -126,128 +125,38 @@ func (s *FeelService) GetFeelHistory(appID string, userID uuid.UUID, limit, offs
 
 // GetFeelStats returns statistics for a user.
 func (s *FeelService) GetFeelStats(appID string, userID uuid.UUID) (map[string]interface{}, error) {
-	var streak FeelStreak
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error
+	streak, err := s.streaks.Get(appID, userID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return map[string]interface{}{
-				"current_streak":  0,
-				"longest_streak":  0,
-				"total_check_ins": 0,
-				"average_score":   0,
-				"unlocked_badges": []string{},
-			}, nil
-		}
 		return nil, err
 	}
 
-	var badgeList []string
-	if streak.UnlockedBadges != "" {
-		badgeList = strings.Split(streak.UnlockedBadges, ",")
-	} else {
-		badgeList = []string{}
+	var avgScore float64
+	if streak.TotalCount > 0 {
+		if err := s.db.Model(&FeelCheck{}).Scopes(tenant.ForTenant(appID)).
+			Where("user_id = ?", userID).
+			Select("COALESCE(AVG(feel_score), 0)").
+			Scan(&avgScore).Error; err != nil {
+			return nil, err
+		}
 	}
 
 	return map[string]interface{}{
 		"current_streak":  streak.CurrentStreak,
 		"longest_streak":  streak.LongestStreak,
-		"total_check_ins": streak.TotalCheckIns,
-		"average_score":   streak.AverageScore,
-		"unlocked_badges": badgeList,
+		"total_check_ins": streak.TotalCount,
+		"average_score":   avgScore,
+		"unlocked_badges": streak.UnlockedBadges,
 	}, nil
 }
 
-// UpdateStreak updates the user's streak after a check-in.
-func (s *FeelService) UpdateStreak(appID string, userID uuid.UUID) error {
-	today := time.Now().Truncate(24 * time.Hour)
-
-	var streak FeelStreak
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		streak = FeelStreak{
-			AppID:          appID,
-			UserID:         userID,
-			CurrentStreak:  1,
-			LongestStreak:  1,
-			TotalCheckIns:  1,
-			LastCheckDate:  &today,
-			UnlockedBadges: "",
-		}
-		return s.db.Create(&streak).Error
-	}
-
-	if streak.LastCheckDate != nil {
-		daysSince := int(today.Sub(*streak.LastCheckDate).Hours() / 24)
-		if daysSince == 1 {
-			streak.CurrentStreak++
-		} else if daysSince > 1 {
-			streak.CurrentStreak = 1
-		}
-	} else {
-		streak.CurrentStreak = 1
-	}
-
-	streak.TotalCheckIns++
-	streak.LastCheckDate = &today
-
-	if streak.CurrentStreak > streak.LongestStreak {
-		streak.LongestStreak = streak.CurrentStreak
-	}
-
-	var avgScore float64
-	s.db.Model(&FeelCheck{}).Scopes(tenant.ForTenant(appID)).
-		Where("user_id = ?", userID).
-		Select("AVG(feel_score)").
-		Scan(&avgScore)
-	streak.AverageScore = avgScore
-
-	streak.UnlockedBadges = checkBadgeUnlocks(streak.CurrentStreak, streak.TotalCheckIns, streak.UnlockedBadges)
-
-	return s.db.Save(&streak).Error
-}
-
-func checkBadgeUnlocks(streak, total int, current string) string {
-	badges := make(map[string]bool)
-	if current != "" {
-		for _, b := range strings.Split(current, ",") {
-			badges[b] = true
-		}
-	}
-
-	if streak >= 3 {
-		badges["streak_3"] = true
-	}
-	if streak >= 7 {
-		badges["streak_7"] = true
-	}
-	if streak >= 14 {
-		badges["streak_14"] = true
-	}
-	if streak >= 30 {
-		badges["streak_30"] = true
-	}
-	if total >= 10 {
-		badges["total_10"] = true
-	}
-	if total >= 50 {
-		badges["total_50"] = true
-	}
-	if total >= 100 {
-		badges["total_100"] = true
-	}
-
-	result := make([]string, 0, len(badges))
-	for badge := range badges {
-		result = append(result, badge)
-	}
-	return strings.Join(result, ",")
-}
-
 // SendGoodVibe sends positive energy to a friend.
-func (s *FeelService) SendGoodVibe(appID string, senderID, receiverID uuid.UUID, message, vibeType string) (*GoodVibe, error) {
+func (s *FeelService) SendGoodVibe(appID string, senderID uuid.UUID, blocks services.BlockSet, receiverID uuid.UUID, message, vibeType string) (*GoodVibe, error) {
 	if senderID == receiverID {
 		return nil, errors.New("cannot send vibe to yourself")
 	}
+	if blocks.Contains(receiverID) {
+		return nil, services.ErrUserBlocked
+	}
 
 	vibe := &GoodVibe{
 		AppID:      appID,
@@ -265,9 +174,9 @@ func (s *FeelService) SendGoodVibe(appID string, senderID, receiverID uuid.UUID,
 }
 
 // GetReceivedVibes returns vibes received by a user.
-func (s *FeelService) GetReceivedVibes(appID string, userID uuid.UUID, limit int) ([]GoodVibe, error) {
+func (s *FeelService) GetReceivedVibes(appID string, userID uuid.UUID, blocks services.BlockSet, limit int) ([]GoodVibe, error) {
 	var vibes []GoodVibe
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("receiver_id = ?", userID).
+	err := s.db.Scopes(tenant.ForTenant(appID), blocks.Exclude("sender_id")).Where("receiver_id = ?", userID).
 		Order("created_at DESC").
 		Limit(limit).
 		Find(&vibes).Error
@@ -275,7 +184,7 @@ func (s *FeelService) GetReceivedVibes(appID string, userID uuid.UUID, limit int
 }
 
 // GetFriendFeels returns today's feels for user's friends.
-func (s *FeelService) GetFriendFeels(appID string, userID uuid.UUID) ([]map[string]interface{}, error) {
+func (s *FeelService) GetFriendFeels(appID string, userID uuid.UUID, blocks services.BlockSet) ([]map[string]interface{}, error) {
 	today := time.Now().Truncate(24 * time.Hour)
 
 	var friends []FeelFriend
@@ -294,6 +203,7 @@ func (s *FeelService) GetFriendFeels(appID string, userID uuid.UUID) ([]map[stri
 			friendIDs = append(friendIDs, f.UserID)
 		}
 	}
+	friendIDs = blocks.Filter(friendIDs)
 
 	if len(friendIDs) == 0 {
 		return []map[string]interface{}{}, nil
@@ -330,7 +240,7 @@ func (s *FeelService) GetFriendFeels(appID string, userID uuid.UUID) ([]map[stri
 }
 
 // SendFriendRequest sends a friend request to a user by email.
-func (s *FeelService) SendFriendRequest(appID string, userID uuid.UUID, friendEmail string) (*FeelFriend, error) {
+func (s *FeelService) SendFriendRequest(appID string, userID uuid.UUID, blocks services.BlockSet, friendEmail string) (*FeelFriend, error) {
 	var friend models.User
 	if err := s.db.Where("email = ?", friendEmail).First(&friend).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -342,6 +252,9 @@ func (s *FeelService) SendFriendRequest(appID string, userID uuid.UUID, friendEm
 	if friend.ID == userID {
 		return nil, errors.New("cannot send friend request to yourself")
 	}
+	if blocks.Contains(friend.ID) {
+		return nil, services.ErrUserBlocked
+	}
 
 	var existing FeelFriend
 	err := s.db.Scopes(tenant.ForTenant(appID)).Where(
@@ -424,7 +337,7 @@ func (s *FeelService) ListFriendRequests(appID string, userID uuid.UUID) ([]Feel
 }
 
 // ListFriends returns all accepted friends for a user.
-func (s *FeelService) ListFriends(appID string, userID uuid.UUID) ([]map[string]interface{}, error) {
+func (s *FeelService) ListFriends(appID string, userID uuid.UUID, blocks services.BlockSet) ([]map[string]interface{}, error) {
 	var friendships []FeelFriend
 	err := s.db.Scopes(tenant.ForTenant(appID)).
 		Where("(user_id = ? OR friend_id = ?) AND status = ?", userID, userID, "accepted").
@@ -448,6 +361,7 @@ func (s *FeelService) ListFriends(appID string, userID uuid.UUID) ([]map[string]
 			friendshipMap[f.UserID] = f.ID
 		}
 	}
+	friendIDs = blocks.Filter(friendIDs)
 
 	var users []models.User
 	s.db.Where("id IN ?", friendIDs).Find(&users)
@@ -516,10 +430,11 @@ func (s *FeelService) GetWeeklyInsights(appID string, userID uuid.UUID) (*Insigh
 	currentInsight := buildWeeklyInsight(currentChecks, currentWeekStart, currentWeekEnd)
 	previousInsight := buildWeeklyInsight(previousChecks, previousWeekStart, previousWeekEnd)
 
-	var streak FeelStreak
-	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error; err == nil {
-		currentInsight.StreakAtEnd = streak.CurrentStreak
+	streak, err := s.streaks.Get(appID, userID)
+	if err != nil {
+		return nil, err
 	}
+	currentInsight.StreakAtEnd = streak.CurrentStreak
 
 	var improvement float64
 	if previousInsight.AverageFeel > 0 {
@@ -540,7 +455,7 @@ func (s *FeelService) GetWeeklyInsights(appID string, userID uuid.UUID) (*Insigh
 		currentInsight.MoodTrend = "stable"
 	}
 
-	message := s.generatePersonalizedMessage(appID, currentInsight, previousInsight, improvement, &streak)
+	message := s.generatePersonalizedMessage(appID, userID, currentInsight, previousInsight, improvement, streak.CurrentStreak)
 
 	return &InsightsResponse{
 		CurrentWeek:  currentInsight,
@@ -550,7 +465,7 @@ func (s *FeelService) GetWeeklyInsights(appID string, userID uuid.UUID) (*Insigh
 	}, nil
 }
 
-func (s *FeelService) generatePersonalizedMessage(appID string, current, previous WeeklyInsight, improvement float64, streak *FeelStreak) string {
+func (s *FeelService) generatePersonalizedMessage(appID string, userID uuid.UUID, current, previous WeeklyInsight, improvement float64, currentStreak int) string {
 	type messageTemplate struct {
 		condition func() bool
 		message   string
@@ -571,8 +486,8 @@ func (s *FeelService) generatePersonalizedMessage(appID string, current, previou
 		}, fmt.Sprintf("You're bouncing back! Your scores improved by %.1f%% this week. That takes real resilience.", math.Abs(improvement)), 8},
 		{func() bool { return current.AverageFeel < 40 && improvement < -10 }, "Tough week. Remember: tracking even the hard days builds awareness. Consider reaching out to someone you trust.", 9},
 		{func() bool { return current.AverageFeel < 40 }, "Hang in there. Low periods are part of the journey. Each day you check in is a step toward understanding yourself better.", 10},
-		{func() bool { return streak != nil && streak.CurrentStreak >= 14 }, fmt.Sprintf("Two weeks strong! Your %d-day streak shows incredible dedication to self-awareness.", streak.CurrentStreak), 11},
-		{func() bool { return streak != nil && streak.CurrentStreak >= 7 }, "One week streak! You're building a powerful habit of self-reflection.", 12},
+		{func() bool { return currentStreak >= 14 }, fmt.Sprintf("Two weeks strong! Your %d-day streak shows incredible dedication to self-awareness.", currentStreak), 11},
+		{func() bool { return currentStreak >= 7 }, "One week streak! You're building a powerful habit of self-reflection.", 12},
 		{func() bool { return current.AverageFeel >= 60 }, fmt.Sprintf("Solid week! Your average feel score is %.0f. Keep the positive energy flowing.", current.AverageFeel), 13},
 		{func() bool { return improvement > 0 }, fmt.Sprintf("Progress! Your mood improved by %.1f%% compared to last week.", math.Abs(improvement)), 14},
 		{func() bool { return improvement < 0 }, fmt.Sprintf("Your mood dipped %.1f%% from last week. Try to identify what changed and adjust.", math.Abs(improvement)), 15},
@@ -580,17 +495,13 @@ func (s *FeelService) generatePersonalizedMessage(appID string, current, previou
 		{func() bool { return true }, "Start checking in to see your weekly mood insights!", 17},
 	}
 
-	lastIdx := 0
-	if streak != nil {
-		lastIdx = streak.LastMessageIdx
-	}
+	state := FeelInsightState{AppID: appID, UserID: userID}
+	s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).FirstOrInit(&state)
 
 	for _, t := range templates {
-		if t.condition() && t.idx != lastIdx {
-			if streak != nil && streak.ID != uuid.Nil {
-				streak.LastMessageIdx = t.idx
-				s.db.Model(streak).Update("last_message_idx", t.idx)
-			}
+		if t.condition() && t.idx != state.LastMessageIdx {
+			state.LastMessageIdx = t.idx
+			s.db.Save(&state)
 			return t.message
 		}
 	}
@@ -659,8 +570,7 @@ func (s *FeelService) GetWeeklyRecap(appID string, userID uuid.UUID) (*WeeklyRec
 	}
 
 	currentStreak := 0
-	var streak FeelStreak
-	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error; err == nil {
+	if streak, err := s.streaks.Get(appID, userID); err == nil {
 		currentStreak = streak.CurrentStreak
 	}
 
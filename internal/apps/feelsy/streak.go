@@ -0,0 +1,63 @@
+package feelsy
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// feelStreakPolicy counts consecutive check-in days. Badge keys match the
+// ones clients already render.
+var feelStreakPolicy = streaks.Policy{
+	MaxGap: 1,
+	Milestones: []streaks.Milestone{
+		{Key: "streak_3", Name: "3 Day Streak", Required: 3},
+		{Key: "streak_7", Name: "Week Streak", Required: 7},
+		{Key: "streak_14", Name: "Two Week Streak", Required: 14},
+		{Key: "streak_30", Name: "Month Streak", Required: 30},
+		{Key: "total_10", Name: "10 Check-ins", Required: 10, Total: true},
+		{Key: "total_50", Name: "50 Check-ins", Required: 50, Total: true},
+		{Key: "total_100", Name: "100 Check-ins", Required: 100, Total: true},
+	},
+}
+
+func newFeelStreaks(db *gorm.DB) *streaks.Engine {
+	return streaks.New(db, "feel", feelStreakPolicy)
+}
+
+// UpdateStreak records a check-in day for the user.
+func (s *FeelService) UpdateStreak(appID string, userID uuid.UUID) {
+	if _, err := s.streaks.Record(appID, userID, time.Now()); err != nil {
+		slog.Warn("failed to update feel streak", "app_id", appID, "user_id", userID, "error", err)
+	}
+}
+
+// Migrate implements apps.MigrationPlugin, carrying feel_streaks rows over to
+// the shared streaks table.
+func (p *Plugin) Migrate(db *gorm.DB) error {
+	_, err := newFeelStreaks(db).ImportLegacy(streaks.Legacy{
+		Table:      "feel_streaks",
+		Where:      "deleted_at IS NULL",
+		Total:      "total_check_ins",
+		LastActive: "last_check_date",
+		Badges:     "to_jsonb(string_to_array(NULLIF(unlocked_badges, ''), ','))",
+	})
+	return err
+}
+
+// RecomputeStreaks implements services.StreakRecomputer, rebuilding the feel
+// streak from check-in times bucketed by local day in loc.
+func (p *Plugin) RecomputeStreaks(db *gorm.DB, appID string, userID uuid.UUID, loc *time.Location) error {
+	var times []time.Time
+	if err := db.Model(&FeelCheck{}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ?", userID).
+		Pluck("created_at", &times).Error; err != nil {
+		return err
+	}
+	return newFeelStreaks(db).Recompute(appID, userID, loc, times)
+}
@@ -0,0 +1,17 @@
+package lucky_draw
+
+import "github.com/ahmetcoskunkizilkaya/unified-backend/internal/achievements"
+
+// drawAchievements unlock from the draw streak: "draw.count" is the total
+// number of draws, "draw.streak" the current streak length.
+var drawAchievements = []achievements.Definition{
+	{Key: "draw_first", Name: "Feeling Lucky", Description: "Make your first draw", Emoji: "🍀", XP: 10, Event: "draw.count", Threshold: 1},
+	{Key: "draw_50", Name: "High Roller", Description: "Make 50 draws", Emoji: "🎲", XP: 150, Event: "draw.count", Threshold: 50},
+	{Key: "draw_streak_3", Name: "On a Roll", Description: "Draw 3 days in a row", Emoji: "🔥", XP: 30, Event: "draw.streak", Threshold: 3},
+	{Key: "draw_streak_7", Name: "Lucky Week", Description: "Draw 7 days in a row", Emoji: "🌟", XP: 100, Event: "draw.streak", Threshold: 7},
+}
+
+// Achievements implements apps.AchievementPlugin.
+func (p *LuckyDrawPlugin) Achievements() []achievements.Definition {
+	return drawAchievements
+}
@@ -0,0 +1,45 @@
+package lucky_draw
+
+import (
+	"fmt"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"gorm.io/gorm"
+)
+
+// RegisterEvents implements apps.EventPlugin.
+func (p *LuckyDrawPlugin) RegisterEvents(bus *events.Bus, db *gorm.DB, cfg *config.Config) {
+	bus.Subscribe(events.UserDeleted, "lucky_draw.delete_user_data", p.deleteUserData)
+	bus.Subscribe(events.GuestClaimed, "lucky_draw.claim_guest_data", p.claimGuestData)
+}
+
+// deleteUserData removes the user's draw data in the account deletion
+// transaction.
+func (p *LuckyDrawPlugin) deleteUserData(tx *gorm.DB, ev events.Event) error {
+	for _, m := range p.Models() {
+		if err := tx.Unscoped().Where("app_id = ? AND user_id = ?", ev.AppID, ev.UserID).Delete(m).Error; err != nil {
+			return fmt.Errorf("delete %T: %w", m, err)
+		}
+	}
+	return nil
+}
+
+// claimGuestData moves a claimed guest's draws and history to the registered
+// account. The draws stop counting as guest draws.
+func (p *LuckyDrawPlugin) claimGuestData(tx *gorm.DB, ev events.Event) error {
+	var payload events.GuestClaimedPayload
+	if err := ev.Decode(&payload); err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&LuckyDraw{}).
+		Where("app_id = ? AND user_id = ?", ev.AppID, payload.GuestID).
+		Updates(map[string]interface{}{"user_id": ev.UserID, "is_guest": false}).Error; err != nil {
+		return fmt.Errorf("move draws: %w", err)
+	}
+	if err := services.MoveUniqueUserRows(tx, &UserHistory{}, ev.AppID, payload.GuestID, ev.UserID, "date"); err != nil {
+		return fmt.Errorf("move history: %w", err)
+	}
+	return nil
+}
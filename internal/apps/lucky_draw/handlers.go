@@ -1,14 +1,21 @@
 package lucky_draw
 
 import (
-	"errors"
-	"strconv"
-
-	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/sdk"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
+func init() {
+	sdk.RegisterErrors(map[error]int{
+		ErrInvalidInput:   fiber.StatusBadRequest,
+		ErrInvalidGuestID: fiber.StatusBadRequest,
+		ErrNotFound:       fiber.StatusNotFound,
+		ErrNotOwner:       fiber.StatusForbidden,
+	})
+}
+
 type LuckyDrawHandler struct {
 	svc *LuckyDrawService
 }
@@ -17,172 +24,87 @@ func NewLuckyDrawHandler(svc *LuckyDrawService) *LuckyDrawHandler {
 	return &LuckyDrawHandler{svc: svc}
 }
 
-func (h *LuckyDrawHandler) Create(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	var userID *uuid.UUID
-
-	// Get user ID if authenticated
-	uid, err := tenant.GetUserID(c)
-	if err == nil {
-		userID = &uid
-	}
-
-	var req CreateDrawRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
-	}
-
-	// Validate input length
-	if len(req.Input) > 5000 {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-			"error":   true,
-			"message": "input_too_long",
-		})
+// drawer is the user a draw belongs to, or nil for anonymous callers.
+func drawer(u sdk.User) *uuid.UUID {
+	if u.Anonymous() {
+		return nil
 	}
+	return &u.ID
+}
 
-	// Set IsGuest based on authentication
-	if userID == nil {
-		req.IsGuest = true
-	}
+func (h *LuckyDrawHandler) Create(c *fiber.Ctx, u sdk.User, req CreateDrawRequest) (*LuckyDraw, error) {
+	// Anonymous callers and guest accounts make guest draws; a guest
+	// account's draws are claimed when it registers.
+	req.IsGuest = u.Anonymous() || u.Guest
 
-	result, err := h.svc.Create(appID, userID, req)
+	draw, err := h.svc.Create(u.AppID, drawer(u), req)
 	if err != nil {
-		if errors.Is(err, ErrInvalidInput) || errors.Is(err, ErrInvalidGuestID) {
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
-		}
-		return fiber.NewError(fiber.StatusInternalServerError, "create failed")
+		return nil, err
 	}
-
-	return c.Status(fiber.StatusCreated).JSON(result)
+	c.Status(fiber.StatusCreated)
+	return draw, nil
 }
 
-func (h *LuckyDrawHandler) Get(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	var userID *uuid.UUID
-
-	// Get user ID if authenticated
-	uid, err := tenant.GetUserID(c)
-	if err == nil {
-		userID = &uid
-	}
-
-	id, err := uuid.Parse(c.Params("id"))
+func (h *LuckyDrawHandler) Get(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*LuckyDraw, error) {
+	id, err := sdk.ParamUUID(c, "id")
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+		return nil, err
 	}
-
-	result, err := h.svc.Get(appID, userID, id)
+	blocks, err := services.RequestBlockSet(c, h.svc.db, u.AppID, u.ID)
 	if err != nil {
-		if errors.Is(err, ErrNotFound) {
-			return fiber.NewError(fiber.StatusNotFound, err.Error())
-		}
-		return fiber.NewError(fiber.StatusInternalServerError, "get failed")
+		return nil, err
 	}
-
-	return c.JSON(result)
+	return h.svc.Get(u.AppID, drawer(u), blocks, id)
 }
 
-func (h *LuckyDrawHandler) List(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	var userID *uuid.UUID
-
-	// Get user ID if authenticated
-	uid, err := tenant.GetUserID(c)
-	if err == nil {
-		userID = &uid
-	}
-
-	// Validate and clamp query parameters
-	limit, _ := strconv.Atoi(c.Query("limit", "20"))
-	offset, _ := strconv.Atoi(c.Query("offset", "0"))
-
-	if limit < 1 {
-		limit = 1
-	}
-	if limit > 100 {
-		limit = 100
-	}
-	if offset < 0 {
-		offset = 0
-	}
-	if offset > 10000 {
-		offset = 0
+func (h *LuckyDrawHandler) List(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*ListDrawsResponse, error) {
+	page, err := sdk.PageFrom(c, sdk.DefaultLimit)
+	if err != nil {
+		return nil, err
 	}
 
-	results, total, err := h.svc.List(appID, userID, limit, offset)
+	results, total, err := h.svc.List(u.AppID, drawer(u), page)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "list failed")
+		return nil, err
 	}
 
-	return c.JSON(ListDrawsResponse{
+	resp := &ListDrawsResponse{
 		Results: results,
 		Total:   total,
-		Limit:   limit,
-		Offset:  offset,
-	})
-}
-
-func (h *LuckyDrawHandler) Delete(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	var userID *uuid.UUID
-
-	// Get user ID if authenticated
-	uid, err := tenant.GetUserID(c)
-	if err == nil {
-		userID = &uid
-	} else {
-		// Guests cannot delete specific results
-		return fiber.NewError(fiber.StatusUnauthorized, "authentication required")
+		Limit:   page.Limit,
+		Offset:  page.Offset,
 	}
-
-	id, err := uuid.Parse(c.Params("id"))
-	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
-	}
-
-	if err := h.svc.Delete(appID, userID, id); err != nil {
-		if errors.Is(err, ErrNotFound) {
-			return fiber.NewError(fiber.StatusNotFound, err.Error())
-		}
-		return fiber.NewError(fiber.StatusInternalServerError, "delete failed")
+	if n := len(results); n > 0 {
+		resp.NextCursor = page.Next(n, sdk.Cursor{Time: results[n-1].CreatedAt, ID: results[n-1].ID})
 	}
-
-	return c.JSON(fiber.Map{
-		"message": "draw result deleted successfully",
-	})
+	return resp, nil
 }
 
-func (h *LuckyDrawHandler) GetStats(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
+// Delete is for signed-in users only: anonymous draws have no owner to
+// check against.
+func (h *LuckyDrawHandler) Delete(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (fiber.Map, error) {
+	id, err := sdk.ParamUUID(c, "id")
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "authentication required")
+		return nil, err
 	}
 
-	stats, err := h.svc.GetStats(appID, userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "stats failed")
+	if err := h.svc.Delete(u.AppID, &u.ID, id); err != nil {
+		return nil, err
 	}
 
-	return c.JSON(stats)
+	return fiber.Map{
+		"message": "draw result deleted successfully",
+	}, nil
 }
 
-func (h *LuckyDrawHandler) GetHistory(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "authentication required")
-	}
+func (h *LuckyDrawHandler) GetStats(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*UserStatsResponse, error) {
+	return h.svc.GetStats(u.AppID, u.ID)
+}
 
-	days, _ := strconv.Atoi(c.Query("days", "30"))
+func (h *LuckyDrawHandler) GetHistory(c *fiber.Ctx, u sdk.User, _ sdk.Empty) ([]HistoryResponse, error) {
+	days := c.QueryInt("days", 30)
 	if days < 1 || days > 90 {
 		days = 30
 	}
-
-	history, err := h.svc.GetHistory(appID, userID, days)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "history failed")
-	}
-
-	return c.JSON(history)
-}
\ No newline at end of file
+	return h.svc.GetHistory(u.AppID, u.ID, days)
+}
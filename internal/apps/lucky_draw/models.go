@@ -12,6 +12,7 @@ import (
 // Supports both authenticated users and guests via nullable UserID
 type LuckyDraw struct {
 	ID        uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID     string         `gorm:"size:50;not null;default:'lucky_draw';index" json:"app_id"`
 	UserID    *uuid.UUID     `gorm:"type:uuid;index" json:"user_id"`
 	Input     string         `gorm:"type:text" json:"input"`
 	Result    string         `gorm:"type:text" json:"result"`
@@ -19,6 +20,7 @@ type LuckyDraw struct {
 	Category  string         `gorm:"type:varchar(100)" json:"category"`
 	Metadata  datatypes.JSON `gorm:"type:jsonb" json:"metadata"`
 	IsGuest   bool           `gorm:"default:false" json:"is_guest"`
+	Hidden    bool           `gorm:"not null;default:false" json:"-"` // set by moderation
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -32,6 +34,7 @@ func (LuckyDraw) TableName() string {
 // UserHistory tracks daily usage count and streak for each user
 type UserHistory struct {
 	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID     string    `gorm:"size:50;not null;default:'lucky_draw';index" json:"app_id"`
 	UserID    uuid.UUID `gorm:"type:uuid;index;not null" json:"user_id"`
 	Date      string    `gorm:"type:date;not null" json:"date"` // Format: YYYY-MM-DD
 	Count     int       `gorm:"default:0" json:"count"`
@@ -65,10 +68,11 @@ type DrawResultResponse struct {
 }
 
 type ListDrawsResponse struct {
-	Results []LuckyDraw `json:"results"`
-	Total   int64       `json:"total"`
-	Limit   int         `json:"limit"`
-	Offset  int         `json:"offset"`
+	Results    []LuckyDraw `json:"results"`
+	Total      int64       `json:"total"`
+	Limit      int         `json:"limit"`
+	Offset     int         `json:"offset"`
+	NextCursor string      `json:"next_cursor,omitempty"`
 }
 
 type HistoryResponse struct {
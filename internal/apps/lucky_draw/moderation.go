@@ -0,0 +1,58 @@
+package lucky_draw
+
+import (
+	"errors"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ResolveContent implements apps.ModerationPlugin. Guest draws are the only
+// lucky_draw content other users can see, so reports on "post" refer to a
+// draw and nothing else is reportable. Soft-deleted draws are still resolved
+// so a report filed just after deletion keeps its snapshot.
+func (p *LuckyDrawPlugin) ResolveContent(db *gorm.DB, appID, contentType, contentID string) (*services.ContentSnapshot, error) {
+	if contentType != "post" {
+		return nil, services.ErrContentUnsupported
+	}
+	id, err := uuid.Parse(contentID)
+	if err != nil {
+		return nil, services.ErrContentNotFound
+	}
+
+	var d LuckyDraw
+	if err := db.Unscoped().Scopes(tenant.ForTenant(appID)).First(&d, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrContentNotFound
+		}
+		return nil, err
+	}
+	return &services.ContentSnapshot{
+		AuthorID:  d.UserID,
+		Text:      d.Input,
+		CreatedAt: &d.CreatedAt,
+		Extra:     map[string]interface{}{"category": d.Category, "is_guest": d.IsGuest},
+	}, nil
+}
+
+// SetContentHidden implements apps.ModerationPlugin.
+func (p *LuckyDrawPlugin) SetContentHidden(db *gorm.DB, appID, contentType, contentID string, hidden bool) error {
+	if contentType != "post" {
+		return services.ErrContentUnsupported
+	}
+	id, err := uuid.Parse(contentID)
+	if err != nil {
+		return services.ErrContentNotFound
+	}
+
+	result := db.Model(&LuckyDraw{}).Unscoped().Scopes(tenant.ForTenant(appID)).Where("id = ?", id).Update("hidden", hidden)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrContentNotFound
+	}
+	return nil
+}
@@ -1,11 +1,9 @@
 package lucky_draw
 
 import (
-	"time"
-
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/sdk"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"gorm.io/gorm"
 )
 
@@ -17,6 +15,10 @@ func New() *LuckyDrawPlugin {
 
 func (p *LuckyDrawPlugin) ID() string { return "lucky_draw" }
 
+// AllowsAnonymous implements apps.AnonymousPlugin: draws can be made and
+// viewed without an account.
+func (p *LuckyDrawPlugin) AllowsAnonymous() bool { return true }
+
 func (p *LuckyDrawPlugin) Models() []interface{} {
 	return []interface{}{
 		&LuckyDraw{},
@@ -28,33 +30,20 @@ func (p *LuckyDrawPlugin) RegisterRoutes(router fiber.Router, db *gorm.DB, cfg *
 	svc := NewLuckyDrawService(db, cfg)
 	handler := NewLuckyDrawHandler(svc)
 
-	// Rate limiter for draw creation (20/hour per user)
-	drawLimiter := limiter.New(limiter.Config{
-		Max:               20,
-		Expiration:        1 * time.Hour,
-		LimiterMiddleware: limiter.SlidingWindow{},
-		KeyGenerator: func(c *fiber.Ctx) string {
-			auth := c.Get("Authorization")
-			if len(auth) > 39 {
-				return "lucky_draw:" + auth[7:39]
-			}
-			return "lucky_draw:ip:" + c.IP()
-		},
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   true,
-				"message": "Rate limit exceeded. Please try again in an hour.",
-			})
-		},
+	// Draw creation: 20/hour per user, or per IP for anonymous callers
+	drawLimiter := sdk.Limiter(p.ID(), sdk.Limit{
+		Name:    "draw",
+		Max:     20,
+		Message: "Rate limit exceeded. Please try again in an hour.",
 	})
 
 	// Draw endpoints
-	router.Post("/draw", drawLimiter, handler.Create)
-	router.Get("/draw", handler.List)
-	router.Get("/draw/:id", handler.Get)
-	router.Delete("/draw/:id", handler.Delete)
+	router.Post("/draw", drawLimiter, sdk.Optional(handler.Create))
+	router.Get("/draw", sdk.Optional(handler.List))
+	router.Get("/draw/:id", sdk.Optional(handler.Get))
+	router.Delete("/draw/:id", sdk.Handle(handler.Delete))
 
 	// Stats endpoints
-	router.Get("/stats", handler.GetStats)
-	router.Get("/history", handler.GetHistory)
+	router.Get("/stats", sdk.Handle(handler.GetStats))
+	router.Get("/history", sdk.Handle(handler.GetHistory))
 }
\ No newline at end of file
@@ -3,9 +3,15 @@ package lucky_draw
 import (
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"time"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/achievements"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/sdk"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/localtime"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
@@ -16,15 +22,17 @@ var (
 	ErrInvalidInput   = errors.New("input is required and must be at most 5000 characters")
 	ErrNotFound       = errors.New("draw result not found")
 	ErrNotOwner       = errors.New("not the owner of this result")
-	ErrInvalidGuestID = errors.New("guest results cannot be associated with a user")
+	ErrInvalidGuestID = errors.New("non-guest results must be associated with a user")
 )
 
 type LuckyDrawService struct {
-	db *gorm.DB
+	db           *gorm.DB
+	streaks      *streaks.Engine
+	achievements *achievements.Engine
 }
 
 func NewLuckyDrawService(db *gorm.DB, cfg *config.Config) *LuckyDrawService {
-	return &LuckyDrawService{db: db}
+	return &LuckyDrawService{db: db, streaks: newDrawStreaks(db), achievements: achievements.New(db, drawAchievements)}
 }
 
 func (s *LuckyDrawService) Create(appID string, userID *uuid.UUID, req CreateDrawRequest) (*LuckyDraw, error) {
@@ -33,8 +41,9 @@ func (s *LuckyDrawService) Create(appID string, userID *uuid.UUID, req CreateDra
 		return nil, ErrInvalidInput
 	}
 
-	// Validate guest/user logic
-	if req.IsGuest && userID != nil {
+	// Validate guest/user logic: only anonymous draws may lack a user, and
+	// those are always guest draws.
+	if !req.IsGuest && userID == nil {
 		return nil, ErrInvalidGuestID
 	}
 
@@ -53,6 +62,7 @@ func (s *LuckyDrawService) Create(appID string, userID *uuid.UUID, req CreateDra
 	}
 
 	draw := &LuckyDraw{
+		AppID:    appID,
 		UserID:   userID,
 		Input:    req.Input,
 		Result:   result,
@@ -66,60 +76,55 @@ func (s *LuckyDrawService) Create(appID string, userID *uuid.UUID, req CreateDra
 		return nil, err
 	}
 
-	// Update user history if not guest
-	if !req.IsGuest && userID != nil {
+	// Update user history for signed-in users, guest accounts included
+	if userID != nil {
 		s.updateUserHistory(appID, *userID)
 	}
 
 	return draw, nil
 }
 
+// updateUserHistory records the draw on the user's streak and bumps the
+// count on today's history row, which also snapshots the streak length.
 func (s *LuckyDrawService) updateUserHistory(appID string, userID uuid.UUID) {
-	today := time.Now().UTC().Truncate(24 * time.Hour)
-	todayStr := today.Format("2006-01-02")
-
-	var history UserHistory
-	err := s.db.Scopes(tenant.ForTenant(appID)).
-		Where("user_id = ? AND date = ?", userID, todayStr).
-		First(&history).Error
-
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		// Check yesterday for streak calculation
-		yesterday := today.Add(-24 * time.Hour)
-		yesterdayStr := yesterday.Format("2006-01-02")
-		var yesterdayHistory UserHistory
-		yesterdayErr := s.db.Scopes(tenant.ForTenant(appID)).
-			Where("user_id = ? AND date = ?", userID, yesterdayStr).
-			First(&yesterdayHistory).Error
-
-		streak := 1
-		if yesterdayErr == nil {
-			streak = yesterdayHistory.Streak + 1
-		}
+	loc := localtime.ForUser(s.db, appID, userID)
+	now := time.Now()
+	res, err := s.streaks.RecordIn(appID, userID, now, loc)
+	if err != nil {
+		slog.Warn("failed to update draw streak", "app_id", appID, "user_id", userID, "error", err)
+		return
+	}
+	s.achievements.EmitStreak(appID, userID, "draw", res.Streak)
 
-		// Create new history entry
-		history = UserHistory{
-			UserID: userID,
-			Date:   todayStr,
-			Count:  1,
-			Streak: streak,
-		}
-		s.db.Scopes(tenant.ForTenant(appID)).Create(&history)
-	} else if err == nil {
-		// Update existing entry
-		history.Count++
-		s.db.Scopes(tenant.ForTenant(appID)).Save(&history)
+	todayStr := localtime.DateString(now, loc)
+	history := UserHistory{
+		AppID:  appID,
+		UserID: userID,
+		Date:   todayStr,
 	}
+	s.db.Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ? AND date = ?", userID, todayStr).
+		FirstOrInit(&history)
+	history.Count++
+	history.Streak = res.Streak.CurrentStreak
+	s.db.Save(&history)
 }
 
-func (s *LuckyDrawService) Get(appID string, userID *uuid.UUID, id uuid.UUID) (*LuckyDraw, error) {
+// Get returns a draw the caller may see. Signed-in callers also see other
+// users' shared guest results, except those of users on either side of a
+// block with them.
+func (s *LuckyDrawService) Get(appID string, userID *uuid.UUID, blocks services.BlockSet, id uuid.UUID) (*LuckyDraw, error) {
 	var draw LuckyDraw
 
 	query := s.db.Scopes(tenant.ForTenant(appID)).Where("id = ?", id)
 
-	// If userID is provided, ensure they own the result (unless it's a guest result they created)
+	// If userID is provided, ensure they own the result (unless it's a guest
+	// result). Guest results hidden by moderation are only visible to their owner.
 	if userID != nil {
-		query = query.Where("(user_id = ? OR is_guest = true)", userID)
+		query = query.Where("(user_id = ? OR (is_guest = true AND hidden = false))", userID).
+			Scopes(blocks.Exclude("user_id"))
+	} else {
+		query = query.Where("hidden = false")
 	}
 
 	if err := query.First(&draw).Error; err != nil {
@@ -132,7 +137,7 @@ func (s *LuckyDrawService) Get(appID string, userID *uuid.UUID, id uuid.UUID) (*
 	return &draw, nil
 }
 
-func (s *LuckyDrawService) List(appID string, userID *uuid.UUID, limit, offset int) ([]LuckyDraw, int64, error) {
+func (s *LuckyDrawService) List(appID string, userID *uuid.UUID, page sdk.Page) ([]LuckyDraw, int64, error) {
 	var draws []LuckyDraw
 	var total int64
 
@@ -142,8 +147,8 @@ func (s *LuckyDrawService) List(appID string, userID *uuid.UUID, limit, offset i
 	if userID != nil {
 		query = query.Where("user_id = ?", userID)
 	} else {
-		// Only show guest results when no userID
-		query = query.Where("is_guest = true")
+		// Only show guest results when no userID, minus those hidden by moderation
+		query = query.Where("is_guest = true AND hidden = false")
 	}
 
 	// Count total
@@ -152,10 +157,7 @@ func (s *LuckyDrawService) List(appID string, userID *uuid.UUID, limit, offset i
 	}
 
 	// Get paginated results
-	if err := query.Order("created_at DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&draws).Error; err != nil {
+	if err := page.Apply(query, "created_at").Find(&draws).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -191,39 +193,16 @@ func (s *LuckyDrawService) GetStats(appID string, userID uuid.UUID) (*UserStatsR
 		return nil, err
 	}
 
-	// Get current streak from today's history
-	today := time.Now().UTC().Truncate(24 * time.Hour)
-	todayStr := today.Format("2006-01-02")
-	var todayHistory UserHistory
-	currentStreak := 0
-	longestStreak := 0
-
-	err := s.db.Scopes(tenant.ForTenant(appID)).
-		Where("user_id = ? AND date = ?", userID, todayStr).
-		First(&todayHistory).Error
-
-	if err == nil {
-		currentStreak = todayHistory.Streak
-	}
-
-	// Get longest streak from all history
-	var maxStreak struct {
-		MaxStreak int
-	}
-	s.db.Scopes(tenant.ForTenant(appID)).
-		Model(&UserHistory{}).
-		Select("MAX(streak) as max_streak").
-		Where("user_id = ?", userID).
-		Scan(&maxStreak)
-
-	if maxStreak.MaxStreak > 0 {
-		longestStreak = maxStreak.MaxStreak
+	streak, err := s.streaks.Get(appID, userID)
+	if err != nil {
+		return nil, err
 	}
+	today := localtime.Today(localtime.ForUser(s.db, appID, userID))
 
 	// Get last 30 days history
 	var histories []UserHistory
 	s.db.Scopes(tenant.ForTenant(appID)).
-		Where("user_id = ? AND date >= ?", userID, today.AddDate(0, 0, -30).Format("2006-01-02")).
+		Where("user_id = ? AND date >= ?", userID, today.AddDate(0, 0, -30).Format(localtime.DateFormat)).
 		Order("date DESC").
 		Find(&histories)
 
@@ -238,8 +217,8 @@ func (s *LuckyDrawService) GetStats(appID string, userID uuid.UUID) (*UserStatsR
 
 	return &UserStatsResponse{
 		TotalDraws:    totalDraws,
-		CurrentStreak: currentStreak,
-		LongestStreak: longestStreak,
+		CurrentStreak: streak.CurrentStreak,
+		LongestStreak: streak.LongestStreak,
 		DailyHistory:  dailyHistory,
 	}, nil
 }
@@ -249,8 +228,8 @@ func (s *LuckyDrawService) GetHistory(appID string, userID uuid.UUID, days int)
 		days = 30
 	}
 
-	startDate := time.Now().UTC().AddDate(0, 0, -days).Truncate(24 * time.Hour)
-	startDateStr := startDate.Format("2006-01-02")
+	startDate := localtime.Today(localtime.ForUser(s.db, appID, userID)).AddDate(0, 0, -days)
+	startDateStr := startDate.Format(localtime.DateFormat)
 
 	var histories []UserHistory
 	if err := s.db.Scopes(tenant.ForTenant(appID)).
@@ -0,0 +1,91 @@
+package lucky_draw
+
+import (
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/localtime"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// drawStreakPolicy counts consecutive days with at least one draw.
+var drawStreakPolicy = streaks.Policy{MaxGap: 1}
+
+func newDrawStreaks(db *gorm.DB) *streaks.Engine {
+	return streaks.New(db, "draw", drawStreakPolicy)
+}
+
+// Migrate implements apps.MigrationPlugin, deriving shared streaks from the
+// per-day history rows: the streak on the latest row is the current one.
+func (p *LuckyDrawPlugin) Migrate(db *gorm.DB) error {
+	_, err := newDrawStreaks(db).ImportLegacy(streaks.Legacy{
+		Table: "lucky_draw_user_histories",
+		Live:  true,
+		From: `(SELECT DISTINCT ON (app_id, user_id)
+				app_id, user_id, streak AS current_streak,
+				MAX(streak) OVER w AS longest_streak,
+				SUM(count) OVER w AS total_count,
+				date AS last_date
+			FROM lucky_draw_user_histories
+			WINDOW w AS (PARTITION BY app_id, user_id)
+			ORDER BY app_id, user_id, date DESC) AS h`,
+		Total:      "total_count",
+		LastActive: "last_date",
+	})
+	return err
+}
+
+// RecomputeStreaks implements services.StreakRecomputer. History rows are
+// keyed by local date, so the user's rows and streak are rebuilt from their
+// draws bucketed by day in loc.
+func (p *LuckyDrawPlugin) RecomputeStreaks(db *gorm.DB, appID string, userID uuid.UUID, loc *time.Location) error {
+	var times []time.Time
+	if err := db.Model(&LuckyDraw{}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ? AND is_guest = false", userID).
+		Order("created_at ASC").
+		Pluck("created_at", &times).Error; err != nil {
+		return err
+	}
+
+	counts := make(map[time.Time]int)
+	for _, t := range times {
+		counts[localtime.Day(t, loc)]++
+	}
+	days := localtime.UniqueDays(times, loc)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(tenant.ForTenant(appID)).
+			Where("user_id = ?", userID).
+			Delete(&UserHistory{}).Error; err != nil {
+			return err
+		}
+		if len(days) == 0 {
+			return nil
+		}
+
+		rows := make([]UserHistory, len(days))
+		streak := 0
+		for i, day := range days {
+			if i > 0 && localtime.DaysBetween(days[i-1], day) <= drawStreakPolicy.MaxGap {
+				streak++
+			} else {
+				streak = 1
+			}
+			rows[i] = UserHistory{
+				AppID:  appID,
+				UserID: userID,
+				Date:   day.Format(localtime.DateFormat),
+				Count:  counts[day],
+				Streak: streak,
+			}
+		}
+		return tx.CreateInBatches(rows, 200).Error
+	})
+	if err != nil {
+		return err
+	}
+	return newDrawStreaks(db).Recompute(appID, userID, loc, times)
+}
@@ -0,0 +1,18 @@
+package moodpulse
+
+import "github.com/ahmetcoskunkizilkaya/unified-backend/internal/achievements"
+
+// moodAchievements unlock from the mood streak: "mood.count" is the total
+// number of check-ins, "mood.streak" the current streak length.
+var moodAchievements = []achievements.Definition{
+	{Key: "mood_first_checkin", Name: "Checking In", Description: "Log your first mood", Emoji: "🙂", XP: 10, Event: "mood.count", Threshold: 1},
+	{Key: "mood_30_checkins", Name: "Mood Tracker", Description: "Log 30 moods", Emoji: "📈", XP: 150, Event: "mood.count", Threshold: 30},
+	{Key: "mood_100_checkins", Name: "Emotional Cartographer", Description: "Log 100 moods", Emoji: "🗺️", XP: 500, Event: "mood.count", Threshold: 100},
+	{Key: "mood_streak_7", Name: "Week of Awareness", Description: "Reach a 7-day mood streak", Emoji: "🔥", XP: 100, Event: "mood.streak", Threshold: 7},
+	{Key: "mood_streak_30", Name: "Month of Awareness", Description: "Reach a 30-day mood streak", Emoji: "🌟", XP: 400, Event: "mood.streak", Threshold: 30},
+}
+
+// Achievements implements apps.AchievementPlugin.
+func (p *MoodPulsePlugin) Achievements() []achievements.Definition {
+	return moodAchievements
+}
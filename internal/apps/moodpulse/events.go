@@ -0,0 +1,69 @@
+package moodpulse
+
+import (
+	"fmt"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"gorm.io/gorm"
+)
+
+// RegisterEvents implements apps.EventPlugin.
+func (p *MoodPulsePlugin) RegisterEvents(bus *events.Bus, db *gorm.DB, cfg *config.Config) {
+	p.bus = bus
+	svc := NewMoodService(db, cfg, bus)
+
+	bus.Subscribe(events.UserDeleted, "moodpulse.delete_user_data", p.deleteUserData)
+	bus.Subscribe(events.GuestClaimed, "moodpulse.claim_guest_data", p.claimGuestData)
+	bus.SubscribeAsync(events.MoodCheckInLogged, "moodpulse.mood_streak", svc.recordCheckInStreak)
+}
+
+// deleteUserData removes the user's mood data in the account deletion
+// transaction.
+func (p *MoodPulsePlugin) deleteUserData(tx *gorm.DB, ev events.Event) error {
+	for _, m := range p.Models() {
+		if err := tx.Unscoped().Where("app_id = ? AND user_id = ?", ev.AppID, ev.UserID).Delete(m).Error; err != nil {
+			return fmt.Errorf("delete %T: %w", m, err)
+		}
+	}
+	return nil
+}
+
+// recordCheckInStreak counts a check-in towards the mood streak, on the day
+// it was logged rather than the day the message is delivered.
+func (s *MoodService) recordCheckInStreak(db *gorm.DB, ev events.Event) error {
+	res, err := s.streaks.Record(ev.AppID, ev.UserID, ev.OccurredAt)
+	if err != nil {
+		return err
+	}
+	s.achievements.EmitStreak(ev.AppID, ev.UserID, "mood", res.Streak)
+	return nil
+}
+
+// claimGuestData moves a claimed guest's check-ins and custom vocabulary to
+// the registered account. Vocabulary with the same name is merged.
+func (p *MoodPulsePlugin) claimGuestData(tx *gorm.DB, ev events.Event) error {
+	var payload events.GuestClaimedPayload
+	if err := ev.Decode(&payload); err != nil {
+		return err
+	}
+	if err := services.MoveUserRows(tx, &MoodCheckIn{}, ev.AppID, payload.GuestID, ev.UserID); err != nil {
+		return fmt.Errorf("move check-ins: %w", err)
+	}
+	unique := []struct {
+		model interface{}
+		key   string
+	}{
+		{&CustomEmotion{}, "name"},
+		{&CustomTrigger{}, "name"},
+		{&CustomActivity{}, "name"},
+		{&CrisisFlag{}, "day"},
+	}
+	for _, u := range unique {
+		if err := services.MoveUniqueUserRows(tx, u.model, ev.AppID, payload.GuestID, ev.UserID, u.key); err != nil {
+			return fmt.Errorf("move %T: %w", u.model, err)
+		}
+	}
+	return nil
+}
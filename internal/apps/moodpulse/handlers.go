@@ -1,15 +1,44 @@
 package moodpulse
 
 import (
-	"log/slog"
-	"strconv"
+	"errors"
 
-	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
-	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/sdk"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
+func init() {
+	// Entries of other users are reported as missing, not forbidden, so IDs
+	// can't be probed.
+	sdk.RegisterErrors(map[error]int{
+		ErrInvalidIntensity: fiber.StatusBadRequest,
+		ErrMissingEmotion:   fiber.StatusBadRequest,
+		ErrNotFound:         fiber.StatusNotFound,
+		ErrNotOwner:         fiber.StatusNotFound,
+		ErrInvalidPhotoURL:  fiber.StatusBadRequest,
+		ErrInvalidAudioURL:  fiber.StatusBadRequest,
+
+		ErrNoteTooLong:            fiber.StatusBadRequest,
+		ErrTranscriptTooLong:      fiber.StatusBadRequest,
+		ErrInvalidWhereContext:    fiber.StatusBadRequest,
+		ErrInvalidWithContext:     fiber.StatusBadRequest,
+		ErrInvalidActivityContext: fiber.StatusBadRequest,
+		ErrSubEmotionTooLong:      fiber.StatusBadRequest,
+		ErrMedNameTooLong:         fiber.StatusBadRequest,
+		ErrBatchTooLarge:          fiber.StatusBadRequest,
+		ErrBatchDeleteTooLarge:    fiber.StatusBadRequest,
+
+		ErrMissingEmotionFields: fiber.StatusBadRequest,
+		ErrMissingName:          fiber.StatusBadRequest,
+		ErrNameTooLong:          fiber.StatusBadRequest,
+		ErrEmojiTooLong:         fiber.StatusBadRequest,
+		ErrColorTooLong:         fiber.StatusBadRequest,
+		ErrIconTooLong:          fiber.StatusBadRequest,
+		ErrBulkSyncTooLarge:     fiber.StatusBadRequest,
+	})
+}
+
 type MoodHandler struct {
 	svc            *MoodService
 	uploadHandler  *UploadHandler
@@ -19,249 +48,114 @@ func NewMoodHandler(svc *MoodService, uploadHandler *UploadHandler) *MoodHandler
 	return &MoodHandler{svc: svc, uploadHandler: uploadHandler}
 }
 
-func (h *MoodHandler) Create(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
+// days reads ?days, clamped to [1, 90].
+func days(c *fiber.Ctx, def int) int {
+	d := c.QueryInt("days", def)
+	if d < 1 {
+		d = 1
 	}
-
-	var req CreateMoodRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	if d > 90 {
+		d = 90
 	}
-
-	entry, err := h.svc.Create(appID, userID, req)
-	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
-	}
-
-	return c.Status(fiber.StatusCreated).JSON(entry)
+	return d
 }
 
-func (h *MoodHandler) List(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	limit, _ := strconv.Atoi(c.Query("limit", "20"))
-	offset, _ := strconv.Atoi(c.Query("offset", "0"))
-	month, _ := strconv.Atoi(c.Query("month", "0"))
-	year, _ := strconv.Atoi(c.Query("year", "0"))
-	if limit < 1 {
-		limit = 1
-	}
-	if limit > 100 {
-		limit = 100
-	}
-	if offset < 0 {
-		offset = 0
-	}
-	if offset > 10000 {
-		offset = 0
-	}
-
-	resp, err := h.svc.List(appID, userID, limit, offset, month, year)
+func (h *MoodHandler) Create(c *fiber.Ctx, u sdk.User, req CreateMoodRequest) (*MoodEntryResponse, error) {
+	entry, err := h.svc.Create(u.AppID, u.ID, req)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "list failed")
+		return nil, err
 	}
-
-	return c.JSON(resp)
+	c.Status(fiber.StatusCreated)
+	return entry, nil
 }
 
-func (h *MoodHandler) Get(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	id, err := uuid.Parse(c.Params("id"))
-	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
-	}
-
-	entry, err := h.svc.Get(appID, userID, id)
+func (h *MoodHandler) List(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*MoodListResponse, error) {
+	page, err := sdk.PageFrom(c, sdk.DefaultLimit)
 	if err != nil {
-		return fiber.NewError(fiber.StatusNotFound, err.Error())
+		return nil, err
 	}
-
-	return c.JSON(entry)
+	return h.svc.List(u.AppID, u.ID, page, c.QueryInt("month"), c.QueryInt("year"))
 }
 
-func (h *MoodHandler) Update(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	id, err := uuid.Parse(c.Params("id"))
+func (h *MoodHandler) Get(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*MoodEntryResponse, error) {
+	id, err := sdk.ParamUUID(c, "id")
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+		return nil, err
 	}
-
-	var req UpdateMoodRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
-	}
-
-	entry, err := h.svc.Update(appID, userID, id, req)
-	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
-	}
-
-	return c.JSON(entry)
+	return h.svc.Get(u.AppID, u.ID, id)
 }
 
-func (h *MoodHandler) Delete(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
+func (h *MoodHandler) Update(c *fiber.Ctx, u sdk.User, req UpdateMoodRequest) (*MoodEntryResponse, error) {
+	id, err := sdk.ParamUUID(c, "id")
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
+		return nil, err
 	}
 
-	id, err := uuid.Parse(c.Params("id"))
+	entry, err := h.svc.Update(u.AppID, u.ID, id, req)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+		return nil, err
 	}
-
-	if err := h.svc.Delete(appID, userID, id); err != nil {
-		return fiber.NewError(fiber.StatusNotFound, err.Error())
-	}
-
-	return c.JSON(fiber.Map{"message": "deleted"})
+	return entry, nil
 }
 
-func (h *MoodHandler) BatchCreate(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
+func (h *MoodHandler) Delete(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (fiber.Map, error) {
+	id, err := sdk.ParamUUID(c, "id")
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
+		return nil, err
 	}
 
-	var req BatchCreateMoodRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	if err := h.svc.Delete(u.AppID, u.ID, id); err != nil {
+		return nil, err
 	}
+	return fiber.Map{"message": "deleted"}, nil
+}
 
+func (h *MoodHandler) BatchCreate(c *fiber.Ctx, u sdk.User, req BatchCreateMoodRequest) (*BatchCreateMoodResponse, error) {
 	if len(req.Entries) == 0 {
-		return fiber.NewError(fiber.StatusBadRequest, "no entries provided")
+		return nil, apierr.New(fiber.StatusBadRequest, "no entries provided")
 	}
 	if len(req.Entries) > 100 {
-		return fiber.NewError(fiber.StatusBadRequest, "max 100 entries per batch")
+		return nil, apierr.New(fiber.StatusBadRequest, "max 100 entries per batch")
 	}
 
-	resp, err := h.svc.BatchCreate(appID, userID, req)
+	resp, err := h.svc.BatchCreate(u.AppID, u.ID, req)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return nil, err
 	}
-
-	return c.Status(fiber.StatusCreated).JSON(resp)
+	c.Status(fiber.StatusCreated)
+	return resp, nil
 }
 
-func (h *MoodHandler) BatchDelete(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	var req BatchDeleteMoodRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
-	}
-
-	resp, err := h.svc.BatchDelete(appID, userID, req)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "batch delete failed")
-	}
-
-	return c.JSON(resp)
+func (h *MoodHandler) BatchDelete(c *fiber.Ctx, u sdk.User, req BatchDeleteMoodRequest) (*BatchDeleteMoodResponse, error) {
+	return h.svc.BatchDelete(u.AppID, u.ID, req)
 }
 
-func (h *MoodHandler) Calendar(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	month, _ := strconv.Atoi(c.Query("month"))
-	year, _ := strconv.Atoi(c.Query("year"))
+func (h *MoodHandler) Calendar(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*CalendarResponse, error) {
+	month := c.QueryInt("month")
+	year := c.QueryInt("year")
 	if month < 1 || month > 12 {
-		return fiber.NewError(fiber.StatusBadRequest, "month must be 1-12")
+		return nil, apierr.New(fiber.StatusBadRequest, "month must be 1-12")
 	}
 	if year < 2000 || year > 2100 {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid year")
+		return nil, apierr.New(fiber.StatusBadRequest, "invalid year")
 	}
-
-	resp, err := h.svc.Calendar(appID, userID, month, year)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "calendar fetch failed")
-	}
-
-	return c.JSON(resp)
+	return h.svc.Calendar(u.AppID, u.ID, month, year)
 }
 
-func (h *MoodHandler) Search(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
+func (h *MoodHandler) Search(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*SearchMoodResponse, error) {
 	q := c.Query("q")
 	if len(q) < 2 {
-		return fiber.NewError(fiber.StatusBadRequest, "query too short")
-	}
-
-	resp, err := h.svc.Search(appID, userID, q)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "search failed")
+		return nil, apierr.New(fiber.StatusBadRequest, "query too short")
 	}
-
-	return c.JSON(resp)
+	return h.svc.Search(u.AppID, u.ID, q)
 }
 
-func (h *MoodHandler) GetStreak(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	resp, err := h.svc.GetStreak(appID, userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "streak fetch failed")
-	}
-
-	return c.JSON(resp)
+func (h *MoodHandler) GetStreak(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*StreakResponse, error) {
+	return h.svc.GetStreak(u.AppID, u.ID)
 }
 
-func (h *MoodHandler) GetStats(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-
-	days, _ := strconv.Atoi(c.Query("days", "7"))
-	if days < 1 {
-		days = 1
-	}
-	if days > 90 {
-		days = 90
-	}
-
-	resp, err := h.svc.GetStats(appID, userID, days)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "stats fetch failed")
-	}
-
-	return c.JSON(resp)
+func (h *MoodHandler) GetStats(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*StatsResponse, error) {
+	return h.svc.GetStats(u.AppID, u.ID, days(c, 7))
 }
 
 // VocabularyHandler handles custom vocabulary endpoints.
@@ -273,469 +167,193 @@ func NewVocabularyHandler(svc *VocabularyService) *VocabularyHandler {
 	return &VocabularyHandler{svc: svc}
 }
 
-func (h *VocabularyHandler) ListEmotions(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-	items, err := h.svc.ListEmotions(appID, userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
-	}
-	return c.JSON(items)
+func (h *VocabularyHandler) ListEmotions(c *fiber.Ctx, u sdk.User, _ sdk.Empty) ([]CustomEmotion, error) {
+	return h.svc.ListEmotions(u.AppID, u.ID)
 }
 
-func (h *VocabularyHandler) CreateEmotion(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-	var req CreateCustomEmotionRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
-	}
-	item, err := h.svc.UpsertEmotion(appID, userID, req)
+func (h *VocabularyHandler) CreateEmotion(c *fiber.Ctx, u sdk.User, req CreateCustomEmotionRequest) (*CustomEmotion, error) {
+	item, err := h.svc.UpsertEmotion(u.AppID, u.ID, req)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return nil, err
 	}
-	return c.Status(fiber.StatusCreated).JSON(item)
+	c.Status(fiber.StatusCreated)
+	return item, nil
 }
 
-func (h *VocabularyHandler) DeleteEmotion(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
+func (h *VocabularyHandler) DeleteEmotion(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (sdk.Empty, error) {
+	id, err := sdk.ParamUUID(c, "id")
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
+		return sdk.Empty{}, err
 	}
-	id, err := uuid.Parse(c.Params("id"))
-	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
-	}
-	if err := h.svc.DeleteEmotion(appID, userID, id); err != nil {
-		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	if err := h.svc.DeleteEmotion(u.AppID, u.ID, id); err != nil {
+		return sdk.Empty{}, err
 	}
-	return c.SendStatus(fiber.StatusNoContent)
+	c.Status(fiber.StatusNoContent)
+	return sdk.Empty{}, nil
 }
 
-func (h *VocabularyHandler) ListTriggers(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-	items, err := h.svc.ListTriggers(appID, userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
-	}
-	return c.JSON(items)
+func (h *VocabularyHandler) ListTriggers(c *fiber.Ctx, u sdk.User, _ sdk.Empty) ([]CustomTrigger, error) {
+	return h.svc.ListTriggers(u.AppID, u.ID)
 }
 
-func (h *VocabularyHandler) CreateTrigger(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-	var req CreateCustomTriggerRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
-	}
-	item, err := h.svc.UpsertTrigger(appID, userID, req)
+func (h *VocabularyHandler) CreateTrigger(c *fiber.Ctx, u sdk.User, req CreateCustomTriggerRequest) (*CustomTrigger, error) {
+	item, err := h.svc.UpsertTrigger(u.AppID, u.ID, req)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return nil, err
 	}
-	return c.Status(fiber.StatusCreated).JSON(item)
+	c.Status(fiber.StatusCreated)
+	return item, nil
 }
 
-func (h *VocabularyHandler) DeleteTrigger(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-	id, err := uuid.Parse(c.Params("id"))
+func (h *VocabularyHandler) DeleteTrigger(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (sdk.Empty, error) {
+	id, err := sdk.ParamUUID(c, "id")
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+		return sdk.Empty{}, err
 	}
-	if err := h.svc.DeleteTrigger(appID, userID, id); err != nil {
-		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	if err := h.svc.DeleteTrigger(u.AppID, u.ID, id); err != nil {
+		return sdk.Empty{}, err
 	}
-	return c.SendStatus(fiber.StatusNoContent)
+	c.Status(fiber.StatusNoContent)
+	return sdk.Empty{}, nil
 }
 
-func (h *VocabularyHandler) ListActivities(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-	items, err := h.svc.ListActivities(appID, userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
-	}
-	return c.JSON(items)
+func (h *VocabularyHandler) ListActivities(c *fiber.Ctx, u sdk.User, _ sdk.Empty) ([]CustomActivity, error) {
+	return h.svc.ListActivities(u.AppID, u.ID)
 }
 
-func (h *VocabularyHandler) CreateActivity(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-	var req CreateCustomActivityRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
-	}
-	item, err := h.svc.UpsertActivity(appID, userID, req)
+func (h *VocabularyHandler) CreateActivity(c *fiber.Ctx, u sdk.User, req CreateCustomActivityRequest) (*CustomActivity, error) {
+	item, err := h.svc.UpsertActivity(u.AppID, u.ID, req)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return nil, err
 	}
-	return c.Status(fiber.StatusCreated).JSON(item)
+	c.Status(fiber.StatusCreated)
+	return item, nil
 }
 
-func (h *VocabularyHandler) DeleteActivity(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-	id, err := uuid.Parse(c.Params("id"))
+func (h *VocabularyHandler) DeleteActivity(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (sdk.Empty, error) {
+	id, err := sdk.ParamUUID(c, "id")
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+		return sdk.Empty{}, err
 	}
-	if err := h.svc.DeleteActivity(appID, userID, id); err != nil {
-		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	if err := h.svc.DeleteActivity(u.AppID, u.ID, id); err != nil {
+		return sdk.Empty{}, err
 	}
-	return c.SendStatus(fiber.StatusNoContent)
+	c.Status(fiber.StatusNoContent)
+	return sdk.Empty{}, nil
 }
 
-func (h *VocabularyHandler) BulkSync(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth")
-	}
-	var req BulkSyncVocabularyRequest
-	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
-	}
-	resp, err := h.svc.BulkSync(appID, userID, req)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
-	}
-	return c.JSON(resp)
+func (h *VocabularyHandler) BulkSync(c *fiber.Ctx, u sdk.User, req BulkSyncVocabularyRequest) (*BulkSyncVocabularyResponse, error) {
+	return h.svc.BulkSync(u.AppID, u.ID, req)
 }
 
 // AIInsights handles GET /moods/ai-insights?days=30
 // Returns longitudinal mood analysis from GPT-4o-mini. Pro-gated via JWT auth.
-func (h *MoodHandler) AIInsights(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	days, _ := strconv.Atoi(c.Query("days", "30"))
-	if days < 1 {
-		days = 1
-	}
-	if days > 90 {
-		days = 90
-	}
-
-	insights, err := h.svc.AIInsights(appID, userID, days)
+func (h *MoodHandler) AIInsights(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*AIInsightsResponse, error) {
+	insights, err := h.svc.AIInsights(u.AppID, u.ID, days(c, 30))
 	if err != nil {
-		slog.Error("[moodpulse] ai insights failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "AI insights unavailable",
-		})
+		return nil, err
 	}
-
-	return c.JSON(AIInsightsResponse{Insights: insights})
+	return &AIInsightsResponse{Insights: insights}, nil
 }
 
 // Ask handles POST /moods/ask with body {"question": "..."}
 // Answers a natural-language question about the user's mood history. Pro-gated via JWT auth.
-func (h *MoodHandler) Ask(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	var req AskMoodRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid request body",
-		})
-	}
-
+func (h *MoodHandler) Ask(c *fiber.Ctx, u sdk.User, req AskMoodRequest) (*AskMoodResponse, error) {
 	if len(req.Question) == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "question is required",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "question is required")
 	}
 	if len(req.Question) > 500 {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "question must be at most 500 characters",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "question must be at most 500 characters")
 	}
 
-	answer, err := h.svc.AskMood(appID, userID, req.Question)
+	answer, err := h.svc.AskMood(u.AppID, u.ID, req.Question)
 	if err != nil {
-		slog.Error("[moodpulse] ask mood failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to answer question",
-		})
+		return nil, err
 	}
-
-	return c.JSON(AskMoodResponse{Answer: answer})
+	return &AskMoodResponse{Answer: answer}, nil
 }
 
 // UploadPhoto delegates to the UploadHandler (POST /moods/upload-photo).
-func (h *MoodHandler) UploadPhoto(c *fiber.Ctx) error {
-	return h.uploadHandler.UploadPhoto(c)
+func (h *MoodHandler) UploadPhoto(c *fiber.Ctx, u sdk.User, req sdk.Empty) (fiber.Map, error) {
+	return h.uploadHandler.UploadPhoto(c, u, req)
 }
 
 // Transcribe delegates to the UploadHandler (POST /moods/transcribe).
-func (h *MoodHandler) Transcribe(c *fiber.Ctx) error {
-	return h.uploadHandler.Transcribe(c)
+func (h *MoodHandler) Transcribe(c *fiber.Ctx, u sdk.User, req sdk.Empty) (fiber.Map, error) {
+	return h.uploadHandler.Transcribe(c, u, req)
 }
 
 // GetCBTExercise handles POST /moods/cbt
 // Accepts {"emotion": "Anxiety", "intensity": 8} and returns a tailored CBT exercise.
-func (h *MoodHandler) GetCBTExercise(c *fiber.Ctx) error {
-	_, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	var body struct {
-		Emotion   string `json:"emotion"`
-		Intensity int    `json:"intensity"`
+func (h *MoodHandler) GetCBTExercise(c *fiber.Ctx, u sdk.User, req CBTExerciseRequest) (map[string]interface{}, error) {
+	if req.Emotion == "" {
+		return nil, apierr.New(fiber.StatusBadRequest, "emotion is required")
 	}
-	if err := c.BodyParser(&body); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid request body",
-		})
+	if req.Intensity < 1 || req.Intensity > 10 {
+		return nil, apierr.New(fiber.StatusBadRequest, "intensity must be between 1 and 10")
 	}
-	if body.Emotion == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "emotion is required",
-		})
-	}
-	if body.Intensity < 1 || body.Intensity > 10 {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "intensity must be between 1 and 10",
-		})
-	}
-
-	result, err := h.svc.GetCBTExercise(body.Emotion, body.Intensity)
-	if err != nil {
-		slog.Error("[moodpulse] cbt exercise failed", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "CBT exercise unavailable",
-		})
-	}
-
-	return c.JSON(result)
+	return h.svc.GetCBTExercise(u.AppID, u.ID, req.Emotion, req.Intensity)
 }
 
 // GetMoodDrivers handles GET /moods/drivers?days=90
 // Returns trigger and activity correlations with mood intensity over the given period.
-func (h *MoodHandler) GetMoodDrivers(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	days, _ := strconv.Atoi(c.Query("days", "90"))
-	if days < 1 {
-		days = 1
-	}
-	if days > 90 {
-		days = 90
-	}
-
-	drivers, err := h.svc.GetMoodDrivers(appID, userID, days)
-	if err != nil {
-		slog.Error("[moodpulse] mood drivers failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Mood drivers unavailable",
-		})
-	}
-
-	return c.JSON(drivers)
+func (h *MoodHandler) GetMoodDrivers(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (map[string]interface{}, error) {
+	return h.svc.GetMoodDrivers(u.AppID, u.ID, days(c, 90))
 }
 
 // GetMoodForecast handles GET /moods/forecast
 // Returns a simple mood forecast for the next 3 days based on historical patterns.
-func (h *MoodHandler) GetMoodForecast(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	forecast, err := h.svc.GetMoodForecast(appID, userID)
-	if err != nil {
-		slog.Error("[moodpulse] mood forecast failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Mood forecast unavailable",
-		})
-	}
-
-	return c.JSON(forecast)
+func (h *MoodHandler) GetMoodForecast(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (map[string]interface{}, error) {
+	return h.svc.GetMoodForecast(u.AppID, u.ID)
 }
 
 // GetContextInsights handles GET /moods/context-insights?days=30
 // Returns average mood intensity per context category (where/with/activity).
-func (h *MoodHandler) GetContextInsights(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	days, _ := strconv.Atoi(c.Query("days", "30"))
-	if days < 1 {
-		days = 1
-	}
-	if days > 90 {
-		days = 90
-	}
-
-	resp, err := h.svc.GetContextInsights(appID, userID, days)
-	if err != nil {
-		slog.Error("[moodpulse] context insights failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Context insights unavailable",
-		})
-	}
-
-	return c.JSON(resp)
+func (h *MoodHandler) GetContextInsights(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*ContextInsightsResponse, error) {
+	return h.svc.GetContextInsights(u.AppID, u.ID, days(c, 30))
 }
 
 // GetMedCorrelation handles GET /moods/med-correlation?med_name=X&days=30
 // Returns average mood intensity on medication-taken days vs not-taken days.
-func (h *MoodHandler) GetMedCorrelation(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
+func (h *MoodHandler) GetMedCorrelation(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*MedCorrelationResponse, error) {
 	medName := c.Query("med_name")
 	if medName == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "med_name is required",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "med_name is required")
 	}
 	if len(medName) > 100 {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "med_name must be at most 100 characters",
-		})
-	}
-
-	days, _ := strconv.Atoi(c.Query("days", "30"))
-	if days < 1 {
-		days = 1
-	}
-	if days > 90 {
-		days = 90
+		return nil, apierr.New(fiber.StatusBadRequest, "med_name must be at most 100 characters")
 	}
-
-	resp, err := h.svc.GetMedCorrelation(appID, userID, medName, days)
-	if err != nil {
-		slog.Error("[moodpulse] med correlation failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Medication correlation unavailable",
-		})
-	}
-
-	return c.JSON(resp)
+	return h.svc.GetMedCorrelation(u.AppID, u.ID, medName, days(c, 30))
 }
 
 // GetSubEmotions handles GET /moods/sub-emotions
 // Returns the static sub-emotion vocabulary map. No DB access, no auth needed
 // beyond the existing JWT middleware applied at router level.
-func (h *MoodHandler) GetSubEmotions(c *fiber.Ctx) error {
-	_, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-	return c.JSON(h.svc.GetSubEmotions())
+func (h *MoodHandler) GetSubEmotions(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*SubEmotionsResponse, error) {
+	return h.svc.GetSubEmotions(), nil
 }
 
 // CrisisCheck handles GET /moods/crisis-check.
 // Returns whether the user is in a crisis pattern (5+ consecutive low-mood days).
 // Light DB-only endpoint — no AI call, no extra rate limit needed.
-func (h *MoodHandler) CrisisCheck(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	resp, err := h.svc.GetCrisisCheck(appID, userID)
-	if err != nil {
-		slog.Error("[moodpulse] crisis check failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Crisis check unavailable",
-		})
-	}
-
-	return c.JSON(resp)
+func (h *MoodHandler) CrisisCheck(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*CrisisCheckResponse, error) {
+	return h.svc.GetCrisisCheck(u.AppID, u.ID)
 }
 
 // GetActionableInsight handles GET /moods/actionable-insight
 // Uses GPT-4o-mini to generate one specific weekly experiment suggestion based on
 // the last 14 days of mood data. Requires at least 7 days of data.
 // Client is expected to cache the response for 24 hours (X-Cache-Ttl header).
-func (h *MoodHandler) GetActionableInsight(c *fiber.Ctx) error {
-	appID := tenant.GetAppID(c)
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
-	insight, err := h.svc.GetActionableInsight(c.Context(), appID, userID)
+func (h *MoodHandler) GetActionableInsight(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*ActionableInsightResponse, error) {
+	insight, err := h.svc.GetActionableInsight(c.Context(), u.AppID, u.ID)
 	if err != nil {
-		if err == ErrNotEnoughData {
-			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-				"error":        "not_enough_data",
-				"minimum_days": 7,
-			})
+		if errors.Is(err, ErrNotEnoughData) {
+			return nil, apierr.New(fiber.StatusUnprocessableEntity, "At least 7 days of mood data are needed for an insight").
+				WithCode("not_enough_data")
 		}
-		slog.Error("[moodpulse] actionable insight failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Actionable insight unavailable",
-		})
+		return nil, err
 	}
 
 	c.Set("X-Cache-Ttl", "86400")
-	return c.JSON(insight)
+	return &insight, nil
 }
@@ -40,19 +40,6 @@ type MoodCheckIn struct {
 	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
-// MoodStreak tracks consecutive days of logging.
-type MoodStreak struct {
-	ID            uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	AppID         string    `gorm:"size:50;not null;index;uniqueIndex:idx_mood_streak_app_user" json:"app_id"`
-	UserID        uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_mood_streak_app_user" json:"user_id"`
-	CurrentStreak int       `gorm:"default:0" json:"current_streak"`
-	LongestStreak int       `gorm:"default:0" json:"longest_streak"`
-	TotalEntries  int       `gorm:"default:0" json:"total_entries"`
-	LastEntryDate time.Time `json:"last_entry_date"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
-}
-
 // --- DTOs ---
 
 type TagItem struct {
@@ -116,6 +103,11 @@ type AskMoodResponse struct {
 	Answer string `json:"answer"`
 }
 
+type CBTExerciseRequest struct {
+	Emotion   string `json:"emotion"`
+	Intensity int    `json:"intensity"`
+}
+
 type MoodEntryResponse struct {
 	ID         uuid.UUID  `json:"id"`
 	Emotion    EmotionDTO `json:"emotion"`
@@ -127,10 +119,11 @@ type MoodEntryResponse struct {
 }
 
 type MoodListResponse struct {
-	Entries []MoodEntryResponse `json:"entries"`
-	Total   int64               `json:"total"`
-	Limit   int                 `json:"limit"`
-	Offset  int                 `json:"offset"`
+	Entries    []MoodEntryResponse `json:"entries"`
+	Total      int64               `json:"total"`
+	Limit      int                 `json:"limit"`
+	Offset     int                 `json:"offset"`
+	NextCursor string              `json:"next_cursor,omitempty"`
 }
 
 type SearchMoodResponse struct {
@@ -199,6 +192,20 @@ type CustomActivity struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// CrisisFlag records that a user's crisis check came back positive on a local
+// day, so mood.crisis_flagged is published at most once per day.
+type CrisisFlag struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID     string    `gorm:"size:50;not null;uniqueIndex:idx_crisis_flag_app_user_day" json:"app_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_crisis_flag_app_user_day" json:"user_id"`
+	Day       string    `gorm:"size:10;not null;uniqueIndex:idx_crisis_flag_app_user_day" json:"day"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (CrisisFlag) TableName() string {
+	return "mood_crisis_flags"
+}
+
 // --- Custom Vocabulary DTOs ---
 
 type CreateCustomEmotionRequest struct {
@@ -1,15 +1,16 @@
 package moodpulse
 
 import (
-	"time"
-
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/sdk"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"gorm.io/gorm"
 )
 
-type MoodPulsePlugin struct{}
+type MoodPulsePlugin struct {
+	bus *events.Bus
+}
 
 func New() *MoodPulsePlugin {
 	return &MoodPulsePlugin{}
@@ -20,130 +21,86 @@ func (p *MoodPulsePlugin) ID() string { return "moodpulse" }
 func (p *MoodPulsePlugin) Models() []interface{} {
 	return []interface{}{
 		&MoodCheckIn{},
-		&MoodStreak{},
 		&CustomEmotion{},
 		&CustomTrigger{},
 		&CustomActivity{},
+		&CrisisFlag{},
 	}
 }
 
 func (p *MoodPulsePlugin) RegisterRoutes(router fiber.Router, db *gorm.DB, cfg *config.Config) {
-	svc := NewMoodService(db, cfg)
-	uploadHandler := NewUploadHandler(cfg.OpenAIAPIKey, cfg.AITimeout, cfg.UploadsRoot)
+	svc := NewMoodService(db, cfg, p.bus)
+	uploadHandler := NewUploadHandler(cfg.OpenAIAPIKey, cfg.OpenAIBaseURL, cfg.AITimeout, cfg.UploadsRoot)
 	handler := NewMoodHandler(svc, uploadHandler)
 
 	// Per-user rate limiter for AI-backed endpoints.
-	// Keyed on JWT token prefix so each authenticated user has their own bucket.
-	aiLimiter := limiter.New(limiter.Config{
-		Max:               10,
-		Expiration:        1 * time.Hour,
-		LimiterMiddleware: limiter.SlidingWindow{},
-		KeyGenerator: func(c *fiber.Ctx) string {
-			auth := c.Get("Authorization")
-			if len(auth) > 39 { // "Bearer " (7) + 32 chars minimum
-				return "mood_ai:" + auth[7:39]
-			}
-			return "mood_ai:ip:" + c.IP()
-		},
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   true,
-				"message": "AI rate limit exceeded. Please try again in an hour.",
-			})
-		},
-	})
+	aiLimiter := sdk.AILimiter(p.ID(), "ai", 10)
 
 	// Per-user rate limiters for upload endpoints.
 	// Photo: 20 uploads/hour — prevents disk exhaustion from a single authenticated user.
 	// Transcribe: 10/hour — each call proxies to paid Whisper API (cost control + disk).
-	uploadPhotoLimiter := limiter.New(limiter.Config{
-		Max:               20,
-		Expiration:        1 * time.Hour,
-		LimiterMiddleware: limiter.SlidingWindow{},
-		KeyGenerator: func(c *fiber.Ctx) string {
-			auth := c.Get("Authorization")
-			if len(auth) > 39 {
-				return "mood_upload_photo:" + auth[7:39]
-			}
-			return "mood_upload_photo:ip:" + c.IP()
-		},
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   true,
-				"message": "Upload rate limit exceeded. Please try again in an hour.",
-			})
-		},
+	uploadPhotoLimiter := sdk.Limiter(p.ID(), sdk.Limit{
+		Name:    "upload_photo",
+		Max:     20,
+		Message: "Upload rate limit exceeded. Please try again in an hour.",
 	})
-	transcribeLimiter := limiter.New(limiter.Config{
-		Max:               10,
-		Expiration:        1 * time.Hour,
-		LimiterMiddleware: limiter.SlidingWindow{},
-		KeyGenerator: func(c *fiber.Ctx) string {
-			auth := c.Get("Authorization")
-			if len(auth) > 39 {
-				return "mood_transcribe:" + auth[7:39]
-			}
-			return "mood_transcribe:ip:" + c.IP()
-		},
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   true,
-				"message": "Transcription rate limit exceeded. Please try again in an hour.",
-			})
-		},
+	transcribeLimiter := sdk.Limiter(p.ID(), sdk.Limit{
+		Name:    "transcribe",
+		Max:     10,
+		Message: "Transcription rate limit exceeded. Please try again in an hour.",
 	})
 
 	// Core mood CRUD routes
-	router.Post("/moods", handler.Create)
-	router.Get("/moods", handler.List)
-	router.Get("/moods/search", handler.Search)
-	router.Get("/moods/calendar", handler.Calendar)
-	router.Get("/moods/streak", handler.GetStreak)
-	router.Get("/moods/stats", handler.GetStats)
-	router.Post("/moods/batch", handler.BatchCreate)
-	router.Post("/moods/batch-delete", handler.BatchDelete)
+	router.Post("/moods", sdk.Handle(handler.Create))
+	router.Get("/moods", sdk.Handle(handler.List))
+	router.Get("/moods/search", sdk.Handle(handler.Search))
+	router.Get("/moods/calendar", sdk.Handle(handler.Calendar))
+	router.Get("/moods/streak", sdk.Handle(handler.GetStreak))
+	router.Get("/moods/stats", sdk.Handle(handler.GetStats))
+	router.Post("/moods/batch", sdk.Handle(handler.BatchCreate))
+	router.Post("/moods/batch-delete", sdk.Handle(handler.BatchDelete))
 
 	// AI routes (MUST come before :id catch-all)
-	router.Get("/moods/ai-insights", aiLimiter, handler.AIInsights)
-	router.Post("/moods/ask", aiLimiter, handler.Ask)
+	router.Get("/moods/ai-insights", aiLimiter, sdk.Handle(handler.AIInsights))
+	router.Post("/moods/ask", aiLimiter, sdk.Handle(handler.Ask))
 
 	// Upload routes — photo storage and audio transcription (MUST come before :id catch-all).
-	router.Post("/moods/upload-photo", uploadPhotoLimiter, handler.UploadPhoto)
-	router.Post("/moods/transcribe", transcribeLimiter, handler.Transcribe)
+	router.Post("/moods/upload-photo", uploadPhotoLimiter, sdk.Handle(handler.UploadPhoto))
+	router.Post("/moods/transcribe", transcribeLimiter, sdk.Handle(handler.Transcribe))
 
 	// Feature endpoints — CBT exercises, mood drivers, mood forecast
-	router.Post("/moods/cbt", aiLimiter, handler.GetCBTExercise)
-	router.Get("/moods/drivers", handler.GetMoodDrivers)
-	router.Get("/moods/forecast", handler.GetMoodForecast)
+	router.Post("/moods/cbt", aiLimiter, sdk.Handle(handler.GetCBTExercise))
+	router.Get("/moods/drivers", sdk.Handle(handler.GetMoodDrivers))
+	router.Get("/moods/forecast", sdk.Handle(handler.GetMoodForecast))
 
 	// Context tagging + medication tracking insights (MUST be before :id catch-all)
-	router.Get("/moods/context-insights", handler.GetContextInsights)
-	router.Get("/moods/med-correlation", handler.GetMedCorrelation)
-	router.Get("/moods/sub-emotions", handler.GetSubEmotions)
+	router.Get("/moods/context-insights", sdk.Handle(handler.GetContextInsights))
+	router.Get("/moods/med-correlation", sdk.Handle(handler.GetMedCorrelation))
+	router.Get("/moods/sub-emotions", sdk.Handle(handler.GetSubEmotions))
 
 	// Crisis check — DB-only, no AI, no extra rate limit (MUST be before :id catch-all)
-	router.Get("/moods/crisis-check", handler.CrisisCheck)
+	router.Get("/moods/crisis-check", sdk.Handle(handler.CrisisCheck))
 
 	// Actionable insight — AI-backed, rate-limited (MUST be before :id catch-all)
-	router.Get("/moods/actionable-insight", aiLimiter, handler.GetActionableInsight)
+	router.Get("/moods/actionable-insight", aiLimiter, sdk.Handle(handler.GetActionableInsight))
 
 	// Parameterized routes last
-	router.Get("/moods/:id", handler.Get)
-	router.Put("/moods/:id", handler.Update)
-	router.Delete("/moods/:id", handler.Delete)
+	router.Get("/moods/:id", sdk.Handle(handler.Get))
+	router.Put("/moods/:id", sdk.Handle(handler.Update))
+	router.Delete("/moods/:id", sdk.Handle(handler.Delete))
 
 	// Custom vocabulary
 	vocSvc := NewVocabularyService(db)
 	vocHandler := NewVocabularyHandler(vocSvc)
 
-	router.Get("/vocabulary/emotions", vocHandler.ListEmotions)
-	router.Post("/vocabulary/emotions", vocHandler.CreateEmotion)
-	router.Delete("/vocabulary/emotions/:id", vocHandler.DeleteEmotion)
-	router.Get("/vocabulary/triggers", vocHandler.ListTriggers)
-	router.Post("/vocabulary/triggers", vocHandler.CreateTrigger)
-	router.Delete("/vocabulary/triggers/:id", vocHandler.DeleteTrigger)
-	router.Get("/vocabulary/activities", vocHandler.ListActivities)
-	router.Post("/vocabulary/activities", vocHandler.CreateActivity)
-	router.Delete("/vocabulary/activities/:id", vocHandler.DeleteActivity)
-	router.Post("/vocabulary/sync", vocHandler.BulkSync)
+	router.Get("/vocabulary/emotions", sdk.Handle(vocHandler.ListEmotions))
+	router.Post("/vocabulary/emotions", sdk.Handle(vocHandler.CreateEmotion))
+	router.Delete("/vocabulary/emotions/:id", sdk.Handle(vocHandler.DeleteEmotion))
+	router.Get("/vocabulary/triggers", sdk.Handle(vocHandler.ListTriggers))
+	router.Post("/vocabulary/triggers", sdk.Handle(vocHandler.CreateTrigger))
+	router.Delete("/vocabulary/triggers/:id", sdk.Handle(vocHandler.DeleteTrigger))
+	router.Get("/vocabulary/activities", sdk.Handle(vocHandler.ListActivities))
+	router.Post("/vocabulary/activities", sdk.Handle(vocHandler.CreateActivity))
+	router.Delete("/vocabulary/activities/:id", sdk.Handle(vocHandler.DeleteActivity))
+	router.Post("/vocabulary/sync", sdk.Handle(vocHandler.BulkSync))
 }
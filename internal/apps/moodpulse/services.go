@@ -12,10 +12,17 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/achievements"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/sdk"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/locale"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/localtime"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
@@ -25,19 +32,41 @@ var (
 	ErrNotOwner         = errors.New("not the owner of this entry")
 	ErrInvalidPhotoURL  = errors.New("photo_url must be an https:// URL of at most 2048 characters")
 	ErrInvalidAudioURL  = errors.New("audio_url must be an https:// URL of at most 2048 characters")
+
+	ErrNoteTooLong            = errors.New("note exceeds 2000 characters")
+	ErrTranscriptTooLong      = errors.New("transcript too long (max 50000 characters)")
+	ErrInvalidWhereContext    = errors.New("invalid where_context value")
+	ErrInvalidWithContext     = errors.New("invalid with_context value")
+	ErrInvalidActivityContext = errors.New("invalid activity_context value")
+	ErrSubEmotionTooLong      = errors.New("sub_emotion too long (max 50 characters)")
+	ErrMedNameTooLong         = errors.New("med_name too long (max 100 characters)")
+	ErrBatchTooLarge          = errors.New("batch limit is 100 entries")
+	ErrBatchDeleteTooLarge    = errors.New("batch delete limit is 100 entries")
+
+	ErrMissingEmotionFields = errors.New("name, emoji, and color are required")
+	ErrMissingName          = errors.New("name is required")
+	ErrNameTooLong          = errors.New("name must be at most 50 characters")
+	ErrEmojiTooLong         = errors.New("emoji must be at most 10 characters")
+	ErrColorTooLong         = errors.New("color must be at most 20 characters")
+	ErrIconTooLong          = errors.New("icon must be at most 50 characters")
+	ErrBulkSyncTooLarge     = errors.New("bulk sync limit is 300 items total")
 )
 
 type MoodService struct {
 	db                *gorm.DB
+	streaks           *streaks.Engine
+	achievements      *achievements.Engine
+	bus               *events.Bus
 	emotionSenseMLURL string
 	aiAPIKey          string
 	aiAPIURL          string
 	openAIKey         string
 	openAIModel       string
+	openAIBaseURL     string
 	aiTimeout         time.Duration
 }
 
-func NewMoodService(db *gorm.DB, cfg *config.Config) *MoodService {
+func NewMoodService(db *gorm.DB, cfg *config.Config, bus *events.Bus) *MoodService {
 	openAIModel := cfg.OpenAIModel
 	if openAIModel == "" {
 		openAIModel = "gpt-4o-mini"
@@ -48,11 +77,15 @@ func NewMoodService(db *gorm.DB, cfg *config.Config) *MoodService {
 	}
 	return &MoodService{
 		db:                db,
+		streaks:           newMoodStreaks(db),
+		achievements:      achievements.New(db, moodAchievements),
+		bus:               bus,
 		emotionSenseMLURL: cfg.EmotionSenseMLURL,
 		aiAPIKey:          cfg.GLMAPIKey,
 		aiAPIURL:          cfg.GLMAPIURL,
 		openAIKey:         cfg.OpenAIAPIKey,
 		openAIModel:       openAIModel,
+		openAIBaseURL:     cfg.OpenAIBaseURL,
 		aiTimeout:         aiTimeout,
 	}
 }
@@ -90,6 +123,12 @@ type moodOpenAIChatResponse struct {
 	} `json:"choices"`
 }
 
+// localized appends the instruction to answer in the user's language to an
+// AI system prompt.
+func (s *MoodService) localized(appID string, userID uuid.UUID, systemPrompt string) string {
+	return systemPrompt + locale.PromptInstruction(locale.ForUser(s.db, appID, userID))
+}
+
 // callOpenAIDirect calls the OpenAI chat completions endpoint using s.openAIKey.
 // Returns an error if openAIKey is not configured.
 func (s *MoodService) callOpenAIDirect(systemPrompt, userPrompt string) (string, error) {
@@ -110,7 +149,7 @@ func (s *MoodService) callOpenAIDirect(systemPrompt, userPrompt string) (string,
 		return "", fmt.Errorf("marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", s.openAIBaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("create request: %w", err)
 	}
@@ -288,7 +327,7 @@ func (s *MoodService) AIInsights(appID string, userID uuid.UUID, days int) (stri
 			"Give evidence-based recommendations. Be warm but factual. "+
 			"Focus on actionable insights the user can apply today.", days)
 
-	rawContent, err := s.callOpenAIDirect(systemPrompt, sb.String())
+	rawContent, err := s.callOpenAIDirect(s.localized(appID, userID, systemPrompt), sb.String())
 	if err != nil {
 		return "", fmt.Errorf("ai insights: %w", err)
 	}
@@ -341,7 +380,7 @@ func (s *MoodService) AskMood(appID string, userID uuid.UUID, question string) (
 	systemPrompt := `You are an AI that has access to all of a user's mood tracking entries. Answer their question about their own mood data truthfully and concisely. Base your answer only on the mood entries provided.`
 	userPrompt := fmt.Sprintf("Mood entries (last 90 days):\n%s\n\nQuestion: %s", sb.String(), question)
 
-	rawContent, err := s.callOpenAIDirect(systemPrompt, userPrompt)
+	rawContent, err := s.callOpenAIDirect(s.localized(appID, userID, systemPrompt), userPrompt)
 	if err != nil {
 		return "", fmt.Errorf("ask mood: %w", err)
 	}
@@ -415,7 +454,7 @@ func (s *MoodService) GetActionableInsight(ctx context.Context, appID string, us
 		`"evidence": ["<date + score>", "<date + score>", "<date + score>"]}. ` +
 		`Be specific. Reference their actual data. No markdown, no extra text.`
 
-	rawContent, err := s.callOpenAIDirect(systemPrompt, dataSection)
+	rawContent, err := s.callOpenAIDirect(s.localized(appID, userID, systemPrompt), dataSection)
 	if err != nil {
 		return ActionableInsightResponse{}, fmt.Errorf("actionable insight ai: %w", err)
 	}
@@ -460,7 +499,7 @@ func (s *MoodService) Create(appID string, userID uuid.UUID, req CreateMoodReque
 		return nil, ErrInvalidIntensity
 	}
 	if len(req.Note) > 2000 {
-		return nil, errors.New("note exceeds 2000 characters")
+		return nil, ErrNoteTooLong
 	}
 
 	if !isValidStorageURL(req.PhotoURL) {
@@ -470,7 +509,7 @@ func (s *MoodService) Create(appID string, userID uuid.UUID, req CreateMoodReque
 		return nil, ErrInvalidAudioURL
 	}
 	if req.Transcript != nil && len(*req.Transcript) > 50000 {
-		return nil, errors.New("transcript too long (max 50000 characters)")
+		return nil, ErrTranscriptTooLong
 	}
 
 	// Validate and cap new context/medication fields.
@@ -478,19 +517,19 @@ func (s *MoodService) Create(appID string, userID uuid.UUID, req CreateMoodReque
 	validWith := map[string]bool{"alone": true, "partner": true, "friends": true, "family": true, "colleagues": true, "strangers": true}
 	validActivity := map[string]bool{"working": true, "relaxing": true, "exercising": true, "eating": true, "socializing": true, "commuting": true}
 	if req.WhereContext != nil && *req.WhereContext != "" && !validWhere[*req.WhereContext] {
-		return nil, errors.New("invalid where_context value")
+		return nil, ErrInvalidWhereContext
 	}
 	if req.WithContext != nil && *req.WithContext != "" && !validWith[*req.WithContext] {
-		return nil, errors.New("invalid with_context value")
+		return nil, ErrInvalidWithContext
 	}
 	if req.ActivityContext != nil && *req.ActivityContext != "" && !validActivity[*req.ActivityContext] {
-		return nil, errors.New("invalid activity_context value")
+		return nil, ErrInvalidActivityContext
 	}
 	if req.SubEmotion != nil && len(*req.SubEmotion) > 50 {
-		return nil, errors.New("sub_emotion too long (max 50 characters)")
+		return nil, ErrSubEmotionTooLong
 	}
 	if req.MedName != nil && len(*req.MedName) > 100 {
-		return nil, errors.New("med_name too long (max 100 characters)")
+		return nil, ErrMedNameTooLong
 	}
 
 	triggersJSON, _ := json.Marshal(req.Triggers)
@@ -519,20 +558,23 @@ func (s *MoodService) Create(appID string, userID uuid.UUID, req CreateMoodReque
 		MedName:         req.MedName,
 	}
 
-	if err := s.db.Create(&entry).Error; err != nil {
+	// The streak is updated by the mood.check_in_logged subscriber.
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+		return s.bus.Publish(tx, appID, userID, events.MoodCheckInLogged, events.MoodCheckInLoggedPayload{CheckInID: entry.ID})
+	}); err != nil {
 		return nil, fmt.Errorf("create failed: %w", err)
 	}
 
-	// Update streak
-	go s.updateStreak(appID, userID)
-
 	// Fire async emotion analysis if a note is present (non-blocking).
 	s.analyzeEmotionAsync(appID, entry.ID.String(), entry.Note)
 
 	return s.toResponse(entry), nil
 }
 
-func (s *MoodService) List(appID string, userID uuid.UUID, limit, offset, month, year int) (*MoodListResponse, error) {
+func (s *MoodService) List(appID string, userID uuid.UUID, page sdk.Page, month, year int) (*MoodListResponse, error) {
 	var entries []MoodCheckIn
 	var total int64
 
@@ -547,25 +589,30 @@ func (s *MoodService) List(appID string, userID uuid.UUID, limit, offset, month,
 
 	base.Model(&MoodCheckIn{}).Count(&total)
 
-	if err := base.Order("created_at DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+	if err := page.Apply(base, "created_at").Find(&entries).Error; err != nil {
 		return nil, err
 	}
 
 	resp := &MoodListResponse{
 		Entries: make([]MoodEntryResponse, len(entries)),
 		Total:   total,
-		Limit:   limit,
-		Offset:  offset,
+		Limit:   page.Limit,
+		Offset:  page.Offset,
 	}
 	for i, e := range entries {
 		resp.Entries[i] = *s.toResponse(e)
 	}
+	if n := len(entries); n > 0 {
+		resp.NextCursor = page.Next(n, sdk.Cursor{Time: entries[n-1].CreatedAt, ID: entries[n-1].ID})
+	}
 	return resp, nil
 }
 
 func (s *MoodService) Calendar(appID string, userID uuid.UUID, month, year int) (*CalendarResponse, error) {
-	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-	end := start.AddDate(0, 1, 0)
+	loc := localtime.ForUser(s.db, appID, userID)
+	first := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	start := localtime.StartOfDay(first, loc)
+	end := localtime.StartOfDay(first.AddDate(0, 1, 0), loc)
 
 	var entries []MoodCheckIn
 	if err := s.db.Scopes(tenant.ForTenant(appID)).
@@ -584,7 +631,7 @@ func (s *MoodService) Calendar(appID string, userID uuid.UUID, month, year int)
 	for i, e := range entries {
 		resp.Entries[i] = CalendarEntry{
 			ID:    e.ID,
-			Date:  e.CreatedAt.Format("2006-01-02"),
+			Date:  localtime.DateString(e.CreatedAt, loc),
 			Color: e.EmotionColor,
 			Emoji: e.EmotionEmoji,
 		}
@@ -630,7 +677,7 @@ func (s *MoodService) Update(appID string, userID uuid.UUID, id uuid.UUID, req U
 	}
 	if req.Note != nil {
 		if len(*req.Note) > 2000 {
-			return nil, errors.New("note exceeds 2000 characters")
+			return nil, ErrNoteTooLong
 		}
 		entry.Note = *req.Note
 	}
@@ -657,34 +704,34 @@ func (s *MoodService) Update(appID string, userID uuid.UUID, id uuid.UUID, req U
 	}
 	if req.Transcript != nil {
 		if len(*req.Transcript) > 50000 {
-			return nil, errors.New("transcript too long (max 50000 characters)")
+			return nil, ErrTranscriptTooLong
 		}
 		entry.Transcript = req.Transcript
 	}
 	if req.WhereContext != nil {
 		validWhereUpd := map[string]bool{"home": true, "work": true, "outside": true, "commuting": true, "social": true, "gym": true}
 		if *req.WhereContext != "" && !validWhereUpd[*req.WhereContext] {
-			return nil, errors.New("invalid where_context value")
+			return nil, ErrInvalidWhereContext
 		}
 		entry.WhereContext = req.WhereContext
 	}
 	if req.WithContext != nil {
 		validWithUpd := map[string]bool{"alone": true, "partner": true, "friends": true, "family": true, "colleagues": true, "strangers": true}
 		if *req.WithContext != "" && !validWithUpd[*req.WithContext] {
-			return nil, errors.New("invalid with_context value")
+			return nil, ErrInvalidWithContext
 		}
 		entry.WithContext = req.WithContext
 	}
 	if req.ActivityContext != nil {
 		validActivityUpd := map[string]bool{"working": true, "relaxing": true, "exercising": true, "eating": true, "socializing": true, "commuting": true}
 		if *req.ActivityContext != "" && !validActivityUpd[*req.ActivityContext] {
-			return nil, errors.New("invalid activity_context value")
+			return nil, ErrInvalidActivityContext
 		}
 		entry.ActivityContext = req.ActivityContext
 	}
 	if req.SubEmotion != nil {
 		if len(*req.SubEmotion) > 50 {
-			return nil, errors.New("sub_emotion too long (max 50 characters)")
+			return nil, ErrSubEmotionTooLong
 		}
 		entry.SubEmotion = req.SubEmotion
 	}
@@ -693,7 +740,7 @@ func (s *MoodService) Update(appID string, userID uuid.UUID, id uuid.UUID, req U
 	}
 	if req.MedName != nil {
 		if len(*req.MedName) > 100 {
-			return nil, errors.New("med_name too long (max 100 characters)")
+			return nil, ErrMedNameTooLong
 		}
 		entry.MedName = req.MedName
 	}
@@ -755,24 +802,20 @@ func (s *MoodService) Search(appID string, userID uuid.UUID, q string) (*SearchM
 }
 
 func (s *MoodService) GetStreak(appID string, userID uuid.UUID) (*StreakResponse, error) {
-	var streak MoodStreak
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return &StreakResponse{}, nil
-	}
+	streak, err := s.streaks.Get(appID, userID)
 	if err != nil {
 		return nil, err
 	}
 
 	lastDate := ""
-	if !streak.LastEntryDate.IsZero() {
-		lastDate = streak.LastEntryDate.Format(time.RFC3339)
+	if streak.LastActivityAt != nil {
+		lastDate = streak.LastActivityAt.Format(time.RFC3339)
 	}
 
 	return &StreakResponse{
 		CurrentStreak: streak.CurrentStreak,
 		LongestStreak: streak.LongestStreak,
-		TotalEntries:  streak.TotalEntries,
+		TotalEntries:  streak.TotalCount,
 		LastEntryDate: lastDate,
 	}, nil
 }
@@ -889,54 +932,12 @@ func (s *MoodService) GetStats(appID string, userID uuid.UUID, days int) (*Stats
 	return resp, nil
 }
 
-// updateStreak recalculates the user's streak after a new entry.
-func (s *MoodService) updateStreak(appID string, userID uuid.UUID) {
-	var streak MoodStreak
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error
-
-	now := time.Now().Truncate(24 * time.Hour)
-
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		streak = MoodStreak{
-			AppID:         appID,
-			UserID:        userID,
-			CurrentStreak: 1,
-			LongestStreak: 1,
-			TotalEntries:  1,
-			LastEntryDate: now,
-		}
-		s.db.Create(&streak)
-		return
-	}
-
-	streak.TotalEntries++
-	lastDate := streak.LastEntryDate.Truncate(24 * time.Hour)
-
-	switch {
-	case now.Equal(lastDate):
-		// Same day, just increment total
-	case now.Sub(lastDate) <= 48*time.Hour && now.Sub(lastDate) > 0:
-		// Next day (or within 48h window to be safe)
-		streak.CurrentStreak++
-	default:
-		// Streak broken
-		streak.CurrentStreak = 1
-	}
-
-	if streak.CurrentStreak > streak.LongestStreak {
-		streak.LongestStreak = streak.CurrentStreak
-	}
-	streak.LastEntryDate = now
-
-	s.db.Save(&streak)
-}
-
 func (s *MoodService) BatchCreate(appID string, userID uuid.UUID, req BatchCreateMoodRequest) (*BatchCreateMoodResponse, error) {
 	if len(req.Entries) == 0 {
 		return &BatchCreateMoodResponse{Results: []BatchMoodResult{}}, nil
 	}
 	if len(req.Entries) > 100 {
-		return nil, errors.New("batch limit is 100 entries")
+		return nil, ErrBatchTooLarge
 	}
 
 	resp := &BatchCreateMoodResponse{
@@ -1005,7 +1006,13 @@ func (s *MoodService) BatchCreate(appID string, userID uuid.UUID, req BatchCreat
 			CreatedAt:      createdAt,
 		}
 
-		if err := s.db.Create(&entry).Error; err != nil {
+		// Each imported check-in counts towards the streak like a logged one.
+		if err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+			return s.bus.Publish(tx, appID, userID, events.MoodCheckInLogged, events.MoodCheckInLoggedPayload{CheckInID: entry.ID})
+		}); err != nil {
 			result.Status = "error"
 			result.Error = "db create failed"
 			resp.Skipped++
@@ -1019,11 +1026,6 @@ func (s *MoodService) BatchCreate(appID string, userID uuid.UUID, req BatchCreat
 		resp.Results = append(resp.Results, result)
 	}
 
-	// Update streak once at end (not per entry)
-	if resp.Imported > 0 {
-		go s.updateStreak(appID, userID)
-	}
-
 	return resp, nil
 }
 
@@ -1032,7 +1034,7 @@ func (s *MoodService) BatchDelete(appID string, userID uuid.UUID, req BatchDelet
 		return &BatchDeleteMoodResponse{}, nil
 	}
 	if len(req.IDs) > 100 {
-		return nil, errors.New("batch delete limit is 100 entries")
+		return nil, ErrBatchDeleteTooLarge
 	}
 
 	// Parse valid UUIDs
@@ -1062,12 +1064,12 @@ func (s *MoodService) BatchDelete(appID string, userID uuid.UUID, req BatchDelet
 
 // GetCBTExercise uses GPT-4o-mini to select a single evidence-based CBT/DBT technique
 // for a given emotion and intensity. Returns structured JSON as a map.
-func (s *MoodService) GetCBTExercise(emotion string, intensity int) (map[string]interface{}, error) {
+func (s *MoodService) GetCBTExercise(appID string, userID uuid.UUID, emotion string, intensity int) (map[string]interface{}, error) {
 	if emotion == "" {
-		return nil, fmt.Errorf("emotion is required")
+		return nil, ErrMissingEmotion
 	}
 	if intensity < 1 || intensity > 10 {
-		return nil, fmt.Errorf("intensity must be between 1 and 10")
+		return nil, ErrInvalidIntensity
 	}
 
 	systemPrompt := `You are a clinical psychologist specializing in CBT and DBT.
@@ -1083,7 +1085,7 @@ Return ONLY valid JSON with no additional text or markdown.`
 		emotion, intensity,
 	)
 
-	raw, err := s.callOpenAIDirect(systemPrompt, userPrompt)
+	raw, err := s.callOpenAIDirect(s.localized(appID, userID, systemPrompt), userPrompt)
 	if err != nil {
 		return nil, fmt.Errorf("cbt exercise: %w", err)
 	}
@@ -1500,16 +1502,16 @@ func (s *VocabularyService) ListEmotions(appID string, userID uuid.UUID) ([]Cust
 
 func (s *VocabularyService) UpsertEmotion(appID string, userID uuid.UUID, req CreateCustomEmotionRequest) (*CustomEmotion, error) {
 	if req.Name == "" || req.Emoji == "" || req.Color == "" {
-		return nil, errors.New("name, emoji, and color are required")
+		return nil, ErrMissingEmotionFields
 	}
 	if len(req.Name) > 50 {
-		return nil, errors.New("name must be at most 50 characters")
+		return nil, ErrNameTooLong
 	}
 	if len(req.Emoji) > 10 {
-		return nil, errors.New("emoji must be at most 10 characters")
+		return nil, ErrEmojiTooLong
 	}
 	if len(req.Color) > 20 {
-		return nil, errors.New("color must be at most 20 characters")
+		return nil, ErrColorTooLong
 	}
 	item := CustomEmotion{
 		AppID:  appID,
@@ -1556,16 +1558,16 @@ func (s *VocabularyService) ListTriggers(appID string, userID uuid.UUID) ([]Cust
 
 func (s *VocabularyService) UpsertTrigger(appID string, userID uuid.UUID, req CreateCustomTriggerRequest) (*CustomTrigger, error) {
 	if req.Name == "" {
-		return nil, errors.New("name is required")
+		return nil, ErrMissingName
 	}
 	if len(req.Name) > 50 {
-		return nil, errors.New("name must be at most 50 characters")
+		return nil, ErrNameTooLong
 	}
 	if req.Icon == "" {
 		req.Icon = "flash-outline"
 	}
 	if len(req.Icon) > 50 {
-		return nil, errors.New("icon must be at most 50 characters")
+		return nil, ErrIconTooLong
 	}
 	item := CustomTrigger{
 		AppID:  appID,
@@ -1610,16 +1612,16 @@ func (s *VocabularyService) ListActivities(appID string, userID uuid.UUID) ([]Cu
 
 func (s *VocabularyService) UpsertActivity(appID string, userID uuid.UUID, req CreateCustomActivityRequest) (*CustomActivity, error) {
 	if req.Name == "" {
-		return nil, errors.New("name is required")
+		return nil, ErrMissingName
 	}
 	if len(req.Name) > 50 {
-		return nil, errors.New("name must be at most 50 characters")
+		return nil, ErrNameTooLong
 	}
 	if req.Icon == "" {
 		req.Icon = "ellipse-outline"
 	}
 	if len(req.Icon) > 50 {
-		return nil, errors.New("icon must be at most 50 characters")
+		return nil, ErrIconTooLong
 	}
 	item := CustomActivity{
 		AppID:  appID,
@@ -1658,7 +1660,7 @@ func (s *VocabularyService) BulkSync(appID string, userID uuid.UUID, req BulkSyn
 	// Limit total items to prevent storage abuse
 	total := len(req.Emotions) + len(req.Triggers) + len(req.Activities)
 	if total > 300 {
-		return nil, errors.New("bulk sync limit is 300 items total")
+		return nil, ErrBulkSyncTooLarge
 	}
 
 	tx := s.db.Begin()
@@ -1879,6 +1881,7 @@ func (s *MoodService) GetSubEmotions() *SubEmotionsResponse {
 // whether they are in a crisis pattern (5+ consecutive low-mood days).
 // "Low" is defined as a daily average intensity <= 3.0 on the 1-10 scale.
 func (s *MoodService) GetCrisisCheck(appID string, userID uuid.UUID) (*CrisisCheckResponse, error) {
+	loc := localtime.ForUser(s.db, appID, userID)
 	cutoff := time.Now().UTC().AddDate(0, 0, -7)
 
 	type DayRow struct {
@@ -1890,9 +1893,9 @@ func (s *MoodService) GetCrisisCheck(appID string, userID uuid.UUID) (*CrisisChe
 	var rows []DayRow
 	err := s.db.Scopes(tenant.ForTenant(appID)).
 		Model(&MoodCheckIn{}).
-		Select("DATE(created_at AT TIME ZONE 'UTC') AS day, AVG(intensity) AS avg_int, COUNT(*) AS entry_count").
+		Select("TO_CHAR(created_at AT TIME ZONE ?, 'YYYY-MM-DD') AS day, AVG(intensity) AS avg_int, COUNT(*) AS entry_count", loc.String()).
 		Where("user_id = ? AND created_at >= ? AND deleted_at IS NULL", userID, cutoff).
-		Group("DATE(created_at AT TIME ZONE 'UTC')").
+		Group("day").
 		Order("day DESC").
 		Scan(&rows).Error
 	if err != nil {
@@ -1914,8 +1917,8 @@ func (s *MoodService) GetCrisisCheck(appID string, userID uuid.UUID) (*CrisisChe
 		avgLast7 = sumIntensity / float64(totalEntries)
 	}
 
-	// Count consecutive low days ending at today or yesterday (UTC).
-	today := time.Now().UTC().Truncate(24 * time.Hour)
+	// Count consecutive low days ending at the user's local today.
+	today := localtime.Today(loc)
 	consecutiveLow := 0
 	for i := 0; i < 7; i++ {
 		day := today.AddDate(0, 0, -i).Format("2006-01-02")
@@ -1938,11 +1941,39 @@ func (s *MoodService) GetCrisisCheck(appID string, userID uuid.UUID) (*CrisisChe
 		recommendation = "Consider reaching out to a trusted person today."
 	}
 
+	inCrisis := consecutiveLow >= 5
+	if inCrisis {
+		s.flagCrisis(appID, userID, today.Format("2006-01-02"), consecutiveLow, avgLast7)
+	}
+
 	return &CrisisCheckResponse{
-		InCrisis:           consecutiveLow >= 5,
+		InCrisis:           inCrisis,
 		ConsecutiveLowDays: consecutiveLow,
 		AvgIntensityLast7:  avgLast7,
 		TotalEntriesLast7:  totalEntries,
 		Recommendation:     recommendation,
 	}, nil
 }
+
+// flagCrisis publishes mood.crisis_flagged the first time a user is in crisis
+// on a local day. Failures are logged; the check itself still succeeds.
+func (s *MoodService) flagCrisis(appID string, userID uuid.UUID, day string, consecutiveLow int, avgLast7 float64) {
+	if s.bus == nil {
+		return
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		flag := CrisisFlag{AppID: appID, UserID: userID, Day: day}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&flag)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return s.bus.Publish(tx, appID, userID, events.MoodCrisisFlagged, events.MoodCrisisFlaggedPayload{
+			Day:                day,
+			ConsecutiveLowDays: consecutiveLow,
+			AvgIntensityLast7:  avgLast7,
+		})
+	})
+	if err != nil {
+		slog.Error("crisis flag failed", "app_id", appID, "user_id", userID, "error", err)
+	}
+}
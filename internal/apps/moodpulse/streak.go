@@ -0,0 +1,42 @@
+package moodpulse
+
+import (
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// moodStreakPolicy is lenient: a check-in within two days of the last one
+// keeps the streak going.
+var moodStreakPolicy = streaks.Policy{MaxGap: 2}
+
+func newMoodStreaks(db *gorm.DB) *streaks.Engine {
+	return streaks.New(db, "mood", moodStreakPolicy)
+}
+
+// Migrate implements apps.MigrationPlugin, carrying mood_streaks rows over
+// to the shared streaks table.
+func (p *MoodPulsePlugin) Migrate(db *gorm.DB) error {
+	_, err := newMoodStreaks(db).ImportLegacy(streaks.Legacy{
+		Table:      "mood_streaks",
+		Total:      "total_entries",
+		LastActive: "last_entry_date",
+	})
+	return err
+}
+
+// RecomputeStreaks implements services.StreakRecomputer, rebuilding the mood
+// streak from check-in times bucketed by local day in loc.
+func (p *MoodPulsePlugin) RecomputeStreaks(db *gorm.DB, appID string, userID uuid.UUID, loc *time.Location) error {
+	var times []time.Time
+	if err := db.Model(&MoodCheckIn{}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ?", userID).
+		Pluck("created_at", &times).Error; err != nil {
+		return err
+	}
+	return newMoodStreaks(db).Recompute(appID, userID, loc, times)
+}
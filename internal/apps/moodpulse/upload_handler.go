@@ -13,8 +13,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
-	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/sdk"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -23,8 +23,7 @@ const (
 	moodPhotoMaxBytes = 10 * 1024 * 1024 // 10 MB
 	moodAudioMaxBytes = 25 * 1024 * 1024 // 25 MB
 
-	moodOpenAIWhisperURL = "https://api.openai.com/v1/audio/transcriptions"
-	moodBaseUploadURL    = "https://api.vexellabspro.com"
+	moodBaseUploadURL = "https://api.vexellabspro.com"
 )
 
 var moodAllowedPhotoMIME = map[string]string{
@@ -45,49 +44,38 @@ var moodAllowedAudioMIME = map[string]string{
 
 // UploadHandler handles file upload and transcription endpoints for moodpulse.
 type UploadHandler struct {
-	openAIAPIKey string
-	aiTimeout    time.Duration
-	uploadsRoot  string // absolute path to uploads directory on disk
+	openAIAPIKey  string
+	openAIBaseURL string
+	aiTimeout     time.Duration
+	uploadsRoot   string // absolute path to uploads directory on disk
 }
 
-func NewUploadHandler(openAIAPIKey string, aiTimeout time.Duration, uploadsRoot string) *UploadHandler {
+func NewUploadHandler(openAIAPIKey, openAIBaseURL string, aiTimeout time.Duration, uploadsRoot string) *UploadHandler {
 	return &UploadHandler{
-		openAIAPIKey: openAIAPIKey,
-		aiTimeout:    aiTimeout,
-		uploadsRoot:  uploadsRoot,
+		openAIAPIKey:  openAIAPIKey,
+		openAIBaseURL: openAIBaseURL,
+		aiTimeout:     aiTimeout,
+		uploadsRoot:   uploadsRoot,
 	}
 }
 
 // UploadPhoto handles POST /moods/upload-photo
 // Accepts multipart/form-data with a "photo" field.
 // Validates size (max 10MB) and MIME type, saves to disk, returns URL.
-func (h *UploadHandler) UploadPhoto(c *fiber.Ctx) error {
-	userID, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
+func (h *UploadHandler) UploadPhoto(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (fiber.Map, error) {
 	fileHeader, err := c.FormFile("photo")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "photo field is required",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "photo field is required")
 	}
 
 	if fileHeader.Size > moodPhotoMaxBytes {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "photo exceeds maximum size of 10MB",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "photo exceeds maximum size of 10MB")
 	}
 
 	f, err := fileHeader.Open()
 	if err != nil {
 		slog.Error("[moodpulse] photo upload: open file failed", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "failed to read uploaded file",
-		})
+		return nil, apierr.New(fiber.StatusInternalServerError, "failed to read uploaded file")
 	}
 	defer f.Close()
 
@@ -95,14 +83,10 @@ func (h *UploadHandler) UploadPhoto(c *fiber.Ctx) error {
 	data, err := io.ReadAll(io.LimitReader(f, moodPhotoMaxBytes+1))
 	if err != nil {
 		slog.Error("[moodpulse] photo upload: read failed", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "failed to read uploaded file",
-		})
+		return nil, apierr.New(fiber.StatusInternalServerError, "failed to read uploaded file")
 	}
 	if int64(len(data)) > moodPhotoMaxBytes {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "photo exceeds maximum size of 10MB",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "photo exceeds maximum size of 10MB")
 	}
 
 	// Validate MIME type via Content-Type header AND magic bytes.
@@ -113,15 +97,11 @@ func (h *UploadHandler) UploadPhoto(c *fiber.Ctx) error {
 	}
 	ext, allowed := moodAllowedPhotoMIME[contentType]
 	if !allowed {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "unsupported image type; allowed: jpeg, png, webp, heic",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "unsupported image type; allowed: jpeg, png, webp, heic")
 	}
 
 	if !validateMoodPhotoMagic(data, contentType) {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "file content does not match declared image type",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "file content does not match declared image type")
 	}
 
 	// Generate UUID filename — never use user-supplied filename.
@@ -130,70 +110,50 @@ func (h *UploadHandler) UploadPhoto(c *fiber.Ctx) error {
 	filename = filepath.Base(filename)
 
 	// Store under moodpulse/photos/{user_id}/ to namespace by user.
-	userIDStr := userID.String()
+	userIDStr := u.ID.String()
 	savePath := filepath.Join(h.uploadsRoot, "moodpulse", "photos", userIDStr, filename)
 	if err := moodSaveFile(savePath, data); err != nil {
 		slog.Error("[moodpulse] photo upload: save failed", "path", savePath, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "failed to save photo",
-		})
+		return nil, apierr.New(fiber.StatusInternalServerError, "failed to save photo")
 	}
 
 	url := fmt.Sprintf("%s/uploads/moodpulse/photos/%s/%s", moodBaseUploadURL, userIDStr, filename)
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"url": url})
+	c.Status(fiber.StatusCreated)
+	return fiber.Map{"url": url}, nil
 }
 
 // Transcribe handles POST /moods/transcribe
 // Accepts multipart/form-data with an "audio" field.
 // Validates size (max 25MB) and MIME type, calls OpenAI Whisper, returns transcript.
-func (h *UploadHandler) Transcribe(c *fiber.Ctx) error {
-	_, err := tenant.GetUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
-	}
-
+func (h *UploadHandler) Transcribe(c *fiber.Ctx, _ sdk.User, _ sdk.Empty) (fiber.Map, error) {
 	if h.openAIAPIKey == "" {
 		slog.Error("[moodpulse] transcribe: OPENAI_API_KEY not configured")
-		return c.Status(fiber.StatusServiceUnavailable).JSON(dto.ErrorResponse{
-			Error: true, Message: "transcription service not available",
-		})
+		return nil, apierr.New(fiber.StatusServiceUnavailable, "transcription service not available")
 	}
 
 	fileHeader, err := c.FormFile("audio")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "audio field is required",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "audio field is required")
 	}
 
 	if fileHeader.Size > moodAudioMaxBytes {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "audio exceeds maximum size of 25MB",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "audio exceeds maximum size of 25MB")
 	}
 
 	f, err := fileHeader.Open()
 	if err != nil {
 		slog.Error("[moodpulse] transcribe: open file failed", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "failed to read uploaded file",
-		})
+		return nil, apierr.New(fiber.StatusInternalServerError, "failed to read uploaded file")
 	}
 	defer f.Close()
 
 	data, err := io.ReadAll(io.LimitReader(f, moodAudioMaxBytes+1))
 	if err != nil {
 		slog.Error("[moodpulse] transcribe: read failed", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "failed to read uploaded file",
-		})
+		return nil, apierr.New(fiber.StatusInternalServerError, "failed to read uploaded file")
 	}
 	if int64(len(data)) > moodAudioMaxBytes {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "audio exceeds maximum size of 25MB",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "audio exceeds maximum size of 25MB")
 	}
 
 	// Validate MIME type via Content-Type header.
@@ -203,26 +163,22 @@ func (h *UploadHandler) Transcribe(c *fiber.Ctx) error {
 	}
 	ext, allowed := moodAllowedAudioMIME[contentType]
 	if !allowed {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "unsupported audio type; allowed: m4a, mp3, wav, aac, mp4, webm",
-		})
+		return nil, apierr.New(fiber.StatusBadRequest, "unsupported audio type; allowed: m4a, mp3, wav, aac, mp4, webm")
 	}
 
 	transcript, err := h.callWhisper(data, ext)
 	if err != nil {
 		slog.Error("[moodpulse] transcribe: OpenAI Whisper call failed", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "transcription failed",
-		})
+		return nil, apierr.New(fiber.StatusInternalServerError, "transcription failed")
 	}
 
-	return c.JSON(fiber.Map{
+	return fiber.Map{
 		"transcript": transcript,
-	})
+	}, nil
 }
 
 // callWhisper sends the audio bytes to OpenAI Whisper and returns the transcript.
-// Uses POST https://api.openai.com/v1/audio/transcriptions with model=whisper-1.
+// Uses POST {OpenAIBaseURL}/audio/transcriptions with model=whisper-1.
 func (h *UploadHandler) callWhisper(audioData []byte, ext string) (string, error) {
 	// Build a safe filename — never send the user-supplied filename.
 	safeFilename := "audio." + ext
@@ -249,7 +205,7 @@ func (h *UploadHandler) callWhisper(audioData []byte, ext string) (string, error
 	}
 
 	httpClient := &http.Client{Timeout: timeout}
-	req, err := http.NewRequest(http.MethodPost, moodOpenAIWhisperURL, &buf)
+	req, err := http.NewRequest(http.MethodPost, h.openAIBaseURL+"/audio/transcriptions", &buf)
 	if err != nil {
 		return "", fmt.Errorf("create request: %w", err)
 	}
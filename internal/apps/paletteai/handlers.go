@@ -8,8 +8,9 @@ import (
 	"strings"
 	"time"
 
-	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -50,23 +51,17 @@ func (h *PaletteHandler) CreatePalette(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	var req CreatePaletteRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	palette, err := h.paletteService.CreatePalette(appID, userID, &req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to create palette",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to create palette")
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(toPaletteResponse(palette))
@@ -77,9 +72,7 @@ func (h *PaletteHandler) ListPalettes(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
@@ -96,9 +89,7 @@ func (h *PaletteHandler) ListPalettes(c *fiber.Ctx) error {
 
 	palettes, total, err := h.paletteService.GetUserPalettes(appID, userID, limit, offset)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to fetch palettes",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch palettes")
 	}
 
 	responses := make([]PaletteResponse, len(palettes))
@@ -117,28 +108,20 @@ func (h *PaletteHandler) GetPalette(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	id, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid palette ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid palette ID")
 	}
 
 	palette, err := h.paletteService.GetPaletteByID(appID, id, userID)
 	if err != nil {
 		if errors.Is(err, ErrPaletteNotFound) || errors.Is(err, ErrNotOwner) {
-			return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{
-				Error: true, Message: "Palette not found",
-			})
+			return apierr.New(fiber.StatusNotFound, "Palette not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to fetch palette",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch palette")
 	}
 
 	return c.JSON(toPaletteResponse(palette))
@@ -149,32 +132,22 @@ func (h *PaletteHandler) DeletePalette(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	paletteID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid palette ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid palette ID")
 	}
 
 	if err := h.paletteService.DeletePalette(appID, userID, paletteID); err != nil {
 		if errors.Is(err, ErrPaletteNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{
-				Error: true, Message: "Palette not found",
-			})
+			return apierr.New(fiber.StatusNotFound, "Palette not found")
 		}
 		if errors.Is(err, ErrNotOwner) {
-			return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{
-				Error: true, Message: "You do not own this palette",
-			})
+			return apierr.New(fiber.StatusForbidden, "You do not own this palette")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to delete palette",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to delete palette")
 	}
 
 	return c.JSON(fiber.Map{"message": "Palette deleted successfully"})
@@ -185,20 +158,14 @@ func (h *PaletteHandler) SharePalette(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	paletteID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid palette ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid palette ID")
 	}
 
 	if err := h.paletteService.IncrementShareCount(appID, paletteID); err != nil {
 		if errors.Is(err, ErrPaletteNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{
-				Error: true, Message: "Palette not found",
-			})
+			return apierr.New(fiber.StatusNotFound, "Palette not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to share palette",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to share palette")
 	}
 
 	return c.JSON(fiber.Map{"message": "Share count incremented"})
@@ -209,16 +176,12 @@ func (h *PaletteHandler) GetStats(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	stats, err := h.paletteService.GetUserStats(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to fetch stats",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch stats")
 	}
 
 	return c.JSON(StatsResponse{
@@ -233,33 +196,23 @@ func (h *PaletteHandler) ToggleFavorite(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	paletteID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid palette ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid palette ID")
 	}
 
 	newStatus, err := h.paletteService.ToggleFavorite(appID, userID, paletteID)
 	if err != nil {
 		if errors.Is(err, ErrPaletteNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{
-				Error: true, Message: "Palette not found",
-			})
+			return apierr.New(fiber.StatusNotFound, "Palette not found")
 		}
 		if errors.Is(err, ErrNotOwner) {
-			return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{
-				Error: true, Message: "You do not own this palette",
-			})
+			return apierr.New(fiber.StatusForbidden, "You do not own this palette")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to toggle favorite",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to toggle favorite")
 	}
 
 	return c.JSON(ToggleFavoriteResponse{
@@ -272,9 +225,7 @@ func (h *PaletteHandler) ListFavorites(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
@@ -291,9 +242,7 @@ func (h *PaletteHandler) ListFavorites(c *fiber.Ctx) error {
 
 	palettes, total, err := h.paletteService.GetFavorites(appID, userID, limit, offset)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to fetch favorites",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch favorites")
 	}
 
 	responses := make([]PaletteResponse, len(palettes))
@@ -311,16 +260,12 @@ func (h *PaletteHandler) ListFavorites(c *fiber.Ctx) error {
 func (h *PaletteHandler) AnalyzeColor(c *fiber.Ctx) error {
 	hex := c.Query("hex", "")
 	if hex == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "hex query parameter is required",
-		})
+		return apierr.New(fiber.StatusBadRequest, "hex query parameter is required")
 	}
 
 	analysis, err := h.paletteService.AnalyzeColor(hex)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: err.Error(),
-		})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.JSON(analysis)
@@ -332,37 +277,27 @@ func (h *PaletteHandler) CheckContrast(c *fiber.Ctx) error {
 	hex2 := c.Query("hex2")
 
 	if hex1 == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "hex1 query parameter is required",
-		})
+		return apierr.New(fiber.StatusBadRequest, "hex1 query parameter is required")
 	}
 
 	if hex2 == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "hex2 query parameter is required",
-		})
+		return apierr.New(fiber.StatusBadRequest, "hex2 query parameter is required")
 	}
 
 	hex1 = strings.TrimPrefix(hex1, "#")
 	hex2 = strings.TrimPrefix(hex2, "#")
 
 	if len(hex1) != 6 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "hex1 must be exactly 6 characters (without #)",
-		})
+		return apierr.New(fiber.StatusBadRequest, "hex1 must be exactly 6 characters (without #)")
 	}
 
 	if len(hex2) != 6 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "hex2 must be exactly 6 characters (without #)",
-		})
+		return apierr.New(fiber.StatusBadRequest, "hex2 must be exactly 6 characters (without #)")
 	}
 
 	result, err := h.paletteService.CheckContrast(hex1, hex2)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -374,26 +309,12 @@ func (h *PaletteHandler) CheckContrast(c *fiber.Ctx) error {
 // ExportPalette handles POST /colors/export
 func (h *PaletteHandler) ExportPalette(c *fiber.Ctx) error {
 	var req struct {
-		Colors []string `json:"colors"`
+		Colors []string `json:"colors" validate:"required,max=10"`
 		Name   string   `json:"name"`
 	}
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid request body",
-		})
-	}
-
-	if len(req.Colors) == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "colors array is required and must not be empty",
-		})
-	}
-
-	if len(req.Colors) > 10 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "maximum 10 colors allowed",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	cleanColors := make([]string, len(req.Colors))
@@ -403,9 +324,7 @@ func (h *PaletteHandler) ExportPalette(c *fiber.Ctx) error {
 
 	result, err := h.paletteService.ExportPalette(cleanColors, req.Name)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
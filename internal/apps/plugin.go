@@ -1,7 +1,10 @@
 package apps
 
 import (
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/achievements"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -15,7 +18,9 @@ type Plugin interface {
 	Models() []interface{}
 
 	// RegisterRoutes mounts app-specific routes on the given Fiber group.
-	// The group is already prefixed with /api and has JWT middleware applied.
+	// The group is already prefixed with /api/p and requires a valid Bearer
+	// token, unless the plugin is an AnonymousPlugin. Handlers are usually
+	// sdk.Handle or sdk.Optional adapters, which resolve the caller.
 	RegisterRoutes(router fiber.Router, db *gorm.DB, cfg *config.Config)
 }
 
@@ -24,3 +29,58 @@ type Plugin interface {
 type AdminPlugin interface {
 	RegisterAdminRoutes(admin fiber.Router, db *gorm.DB, cfg *config.Config)
 }
+
+// AnonymousPlugin is an optional interface for plugins with routes that serve
+// callers without a token. When AllowsAnonymous is true the plugin's routes
+// verify a token only when one is sent, so every handler that needs a user
+// must use sdk.Handle, which rejects anonymous calls.
+type AnonymousPlugin interface {
+	AllowsAnonymous() bool
+}
+
+// EventPlugin is an optional interface for plugins that publish or subscribe
+// to domain events. RegisterEvents runs before RegisterRoutes, so plugins can
+// keep the bus to hand it to the services they build there.
+type EventPlugin interface {
+	RegisterEvents(bus *events.Bus, db *gorm.DB, cfg *config.Config)
+}
+
+// MigrationPlugin is an optional interface for plugins that need data
+// migrations beyond AutoMigrate. Migrate runs on every startup after the
+// plugin's models are migrated, so it must be idempotent.
+type MigrationPlugin interface {
+	Migrate(db *gorm.DB) error
+}
+
+// ReminderPlugin is an optional interface for plugins that send scheduled push
+// reminders (see services.ReminderSource).
+type ReminderPlugin interface {
+	services.ReminderSource
+}
+
+// StreakPlugin is an optional interface for plugins with per-user streak rows
+// that can be rebuilt in a user's timezone (see services.StreakRecomputer).
+type StreakPlugin interface {
+	services.StreakRecomputer
+}
+
+// AchievementPlugin is an optional interface for plugins that award
+// achievements. The definitions are listed by GET /api/achievements; the
+// plugin emits the events that unlock them.
+type AchievementPlugin interface {
+	Achievements() []achievements.Definition
+}
+
+// ModerationPlugin is an optional interface for plugins with reportable
+// user-generated content, so reports can snapshot it and moderation can hide
+// it (see services.ContentResolver).
+type ModerationPlugin interface {
+	services.ContentResolver
+}
+
+// PreferencesPlugin is an optional interface for plugins with per-user
+// settings. Each namespace is served at /api/me/preferences/:namespace for
+// the plugin's app; the plugin reads it with services.LoadPreferences.
+type PreferencesPlugin interface {
+	Preferences() []services.PreferenceSchema
+}
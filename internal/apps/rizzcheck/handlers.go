@@ -4,7 +4,9 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -24,9 +26,7 @@ func (h *RizzHandler) Generate(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Authentication required",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Authentication required")
 	}
 
 	var req struct {
@@ -34,28 +34,20 @@ func (h *RizzHandler) Generate(c *fiber.Ctx) error {
 		Tone      string `json:"tone"`
 		Category  string `json:"category"`
 	}
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	response, err := h.service.GenerateResponses(userID, appID, req.InputText, req.Tone, req.Category)
 	if err != nil {
 		msg := err.Error()
 		if strings.Contains(msg, "daily free limit") {
-			return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{
-				"error": true, "message": msg, "code": "LIMIT_REACHED",
-			})
+			return apierr.New(fiber.StatusPaymentRequired, msg).WithCode("LIMIT_REACHED")
 		}
 		if strings.Contains(msg, "invalid tone") || strings.Contains(msg, "invalid category") || strings.Contains(msg, "required") || strings.Contains(msg, "too long") {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": true, "message": msg,
-			})
+			return apierr.New(fiber.StatusBadRequest, msg)
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to generate responses",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to generate responses")
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -68,16 +60,12 @@ func (h *RizzHandler) GetStats(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Authentication required",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Authentication required")
 	}
 
 	streak, err := h.service.GetStreak(userID, appID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to get stats",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to get stats")
 	}
 
 	// Rizz Score = total * 10 + longest_streak * 5
@@ -98,9 +86,7 @@ func (h *RizzHandler) GetHistory(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Authentication required",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Authentication required")
 	}
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
@@ -114,9 +100,7 @@ func (h *RizzHandler) GetHistory(c *fiber.Ctx) error {
 
 	responses, total, err := h.service.GetHistory(userID, appID, page, limit)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to get history",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to get history")
 	}
 
 	return c.JSON(fiber.Map{
@@ -132,32 +116,24 @@ func (h *RizzHandler) SelectResponse(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Authentication required",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Authentication required")
 	}
 
 	var req struct {
 		ResponseID string `json:"response_id"`
 		SelectedIdx int   `json:"selected_idx"`
 	}
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	responseID, err := uuid.Parse(req.ResponseID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid response ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid response ID")
 	}
 
 	if err := h.service.SelectResponse(userID, appID, responseID, req.SelectedIdx); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": err.Error(),
-		})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.JSON(fiber.Map{"message": "Response selected"})
@@ -23,19 +23,24 @@ type RizzResponse struct {
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
-// RizzStreak tracks user's daily usage and streak gamification.
-type RizzStreak struct {
-	ID            uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	AppID         string         `gorm:"size:50;not null;index;uniqueIndex:idx_rizz_streak_app_user" json:"app_id"`
-	UserID        uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_rizz_streak_app_user" json:"user_id"`
-	CurrentStreak int            `gorm:"default:0" json:"current_streak"`
-	LongestStreak int            `gorm:"default:0" json:"longest_streak"`
-	TotalRizzes   int            `gorm:"default:0" json:"total_rizzes"`
-	FreeUsesToday int            `gorm:"default:0" json:"free_uses_today"`
-	LastUseDate   *time.Time     `gorm:"type:date" json:"last_use_date"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+// RizzUsage tracks a user's free generations for the current day. Streaks
+// live in the shared streaks table.
+type RizzUsage struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID         string     `gorm:"size:50;not null;index;uniqueIndex:idx_rizz_usage_app_user" json:"app_id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_rizz_usage_app_user" json:"user_id"`
+	FreeUsesToday int        `gorm:"default:0" json:"free_uses_today"`
+	LastUseDate   *time.Time `gorm:"type:date" json:"last_use_date"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// RizzStats combines the user's streak with today's usage.
+type RizzStats struct {
+	CurrentStreak int `json:"current_streak"`
+	LongestStreak int `json:"longest_streak"`
+	TotalRizzes   int `json:"total_rizzes"`
+	FreeUsesToday int `json:"free_uses_today"`
 }
 
 // ValidTones lists all valid tone options.
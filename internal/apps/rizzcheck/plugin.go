@@ -19,7 +19,7 @@ func (p *Plugin) ID() string { return "rizzcheck" }
 func (p *Plugin) Models() []interface{} {
 	return []interface{}{
 		&RizzResponse{},
-		&RizzStreak{},
+		&RizzUsage{},
 	}
 }
 
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -24,9 +25,10 @@ const (
 
 // RizzService handles AI response generation and streak tracking.
 type RizzService struct {
-	db     *gorm.DB
-	cfg    *config.Config
-	client *http.Client
+	db      *gorm.DB
+	cfg     *config.Config
+	client  *http.Client
+	streaks *streaks.Engine
 }
 
 // NewRizzService creates a new RizzService.
@@ -36,9 +38,10 @@ func NewRizzService(db *gorm.DB, cfg *config.Config) *RizzService {
 		timeout = 60 * time.Second
 	}
 	return &RizzService{
-		db:     db,
-		cfg:    cfg,
-		client: &http.Client{Timeout: timeout},
+		db:      db,
+		cfg:     cfg,
+		client:  &http.Client{Timeout: timeout},
+		streaks: newRizzStreaks(db),
 	}
 }
 
@@ -59,18 +62,18 @@ func (s *RizzService) GenerateResponses(userID uuid.UUID, appID, inputText, tone
 	}
 
 	// Check free usage limit
-	streak, err := s.getOrCreateStreak(userID, appID)
+	usage, err := s.getOrCreateUsage(userID, appID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check usage: %w", err)
 	}
 
 	// Reset daily counter if new day
-	s.resetDailyIfNeeded(streak)
+	s.resetDailyIfNeeded(usage)
 
 	// Note: Premium check would happen in the handler layer via subscription context
 	// Here we just enforce the free limit — handlers should check subscription before calling
-	if streak.FreeUsesToday >= freeDailyLimit {
-		return nil, fmt.Errorf("daily free limit reached (%d/%d). Upgrade to Premium for unlimited rizz!", streak.FreeUsesToday, freeDailyLimit)
+	if usage.FreeUsesToday >= freeDailyLimit {
+		return nil, fmt.Errorf("daily free limit reached (%d/%d). Upgrade to Premium for unlimited rizz!", usage.FreeUsesToday, freeDailyLimit)
 	}
 
 	// Generate via LLM
@@ -96,20 +99,29 @@ func (s *RizzService) GenerateResponses(userID uuid.UUID, appID, inputText, tone
 		return nil, fmt.Errorf("failed to save response: %w", err)
 	}
 
-	// Update streak
-	s.updateStreak(streak)
+	// Update usage and streak
+	s.recordUse(usage)
 
 	return response, nil
 }
 
 // GetStreak returns user's streak and stats.
-func (s *RizzService) GetStreak(userID uuid.UUID, appID string) (*RizzStreak, error) {
-	streak, err := s.getOrCreateStreak(userID, appID)
+func (s *RizzService) GetStreak(userID uuid.UUID, appID string) (*RizzStats, error) {
+	streak, err := s.streaks.Get(appID, userID)
 	if err != nil {
 		return nil, err
 	}
-	s.resetDailyIfNeeded(streak)
-	return streak, nil
+	usage, err := s.getOrCreateUsage(userID, appID)
+	if err != nil {
+		return nil, err
+	}
+	s.resetDailyIfNeeded(usage)
+	return &RizzStats{
+		CurrentStreak: streak.CurrentStreak,
+		LongestStreak: streak.LongestStreak,
+		TotalRizzes:   streak.TotalCount,
+		FreeUsesToday: usage.FreeUsesToday,
+	}, nil
 }
 
 // GetHistory returns paginated response history.
@@ -154,54 +166,39 @@ func (s *RizzService) SelectResponse(userID uuid.UUID, appID string, responseID
 
 // --- Internal helpers ---
 
-func (s *RizzService) getOrCreateStreak(userID uuid.UUID, appID string) (*RizzStreak, error) {
-	var streak RizzStreak
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error
+func (s *RizzService) getOrCreateUsage(userID uuid.UUID, appID string) (*RizzUsage, error) {
+	var usage RizzUsage
+	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&usage).Error
 	if err == nil {
-		return &streak, nil
+		return &usage, nil
 	}
 
-	streak = RizzStreak{
+	usage = RizzUsage{
 		AppID:  appID,
 		UserID: userID,
 	}
-	if err := s.db.Create(&streak).Error; err != nil {
+	if err := s.db.Create(&usage).Error; err != nil {
 		return nil, err
 	}
-	return &streak, nil
+	return &usage, nil
 }
 
-func (s *RizzService) resetDailyIfNeeded(streak *RizzStreak) {
+func (s *RizzService) resetDailyIfNeeded(usage *RizzUsage) {
 	today := time.Now().Truncate(24 * time.Hour)
-	if streak.LastUseDate == nil || !streak.LastUseDate.Truncate(24*time.Hour).Equal(today) {
-		streak.FreeUsesToday = 0
+	if usage.LastUseDate == nil || !usage.LastUseDate.Truncate(24*time.Hour).Equal(today) {
+		usage.FreeUsesToday = 0
 	}
 }
 
-func (s *RizzService) updateStreak(streak *RizzStreak) {
+func (s *RizzService) recordUse(usage *RizzUsage) {
 	today := time.Now().Truncate(24 * time.Hour)
-	yesterday := today.AddDate(0, 0, -1)
-
-	streak.TotalRizzes++
-	streak.FreeUsesToday++
+	usage.FreeUsesToday++
+	usage.LastUseDate = &today
+	s.db.Save(usage)
 
-	if streak.LastUseDate != nil {
-		lastDate := streak.LastUseDate.Truncate(24 * time.Hour)
-		if lastDate.Equal(yesterday) {
-			streak.CurrentStreak++
-		} else if !lastDate.Equal(today) {
-			streak.CurrentStreak = 1
-		}
-	} else {
-		streak.CurrentStreak = 1
-	}
-
-	if streak.CurrentStreak > streak.LongestStreak {
-		streak.LongestStreak = streak.CurrentStreak
+	if _, err := s.streaks.Record(usage.AppID, usage.UserID, time.Now()); err != nil {
+		slog.Warn("failed to update rizz streak", "app_id", usage.AppID, "user_id", usage.UserID, "error", err)
 	}
-
-	streak.LastUseDate = &today
-	s.db.Save(streak)
 }
 
 // --- LLM integration ---
@@ -0,0 +1,42 @@
+package rizzcheck
+
+import (
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// rizzStreakPolicy counts consecutive days with at least one generation.
+var rizzStreakPolicy = streaks.Policy{MaxGap: 1}
+
+func newRizzStreaks(db *gorm.DB) *streaks.Engine {
+	return streaks.New(db, "rizz", rizzStreakPolicy)
+}
+
+// Migrate implements apps.MigrationPlugin, carrying rizz_streaks rows over to
+// the shared streaks table.
+func (p *Plugin) Migrate(db *gorm.DB) error {
+	_, err := newRizzStreaks(db).ImportLegacy(streaks.Legacy{
+		Table:      "rizz_streaks",
+		Where:      "deleted_at IS NULL",
+		Total:      "total_rizzes",
+		LastActive: "last_use_date",
+	})
+	return err
+}
+
+// RecomputeStreaks implements services.StreakRecomputer, rebuilding the rizz
+// streak from generation times bucketed by local day in loc.
+func (p *Plugin) RecomputeStreaks(db *gorm.DB, appID string, userID uuid.UUID, loc *time.Location) error {
+	var times []time.Time
+	if err := db.Model(&RizzResponse{}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ?", userID).
+		Pluck("created_at", &times).Error; err != nil {
+		return err
+	}
+	return newRizzStreaks(db).Recompute(appID, userID, loc, times)
+}
@@ -0,0 +1,65 @@
+package sdk
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/gofiber/fiber/v2"
+)
+
+type mapping struct {
+	err    error
+	status int
+}
+
+var (
+	mappingsMu sync.RWMutex
+	mappings   []mapping
+)
+
+// RegisterErrors maps a plugin's sentinel errors to HTTP statuses, so its
+// handlers can return service errors as is. Call it from init:
+//
+//	sdk.RegisterErrors(map[error]int{
+//		ErrNotFound: fiber.StatusNotFound,
+//		ErrNotOwner: fiber.StatusForbidden,
+//	})
+func RegisterErrors(statuses map[error]int) {
+	mappingsMu.Lock()
+	for err, status := range statuses {
+		mappings = append(mappings, mapping{err: err, status: status})
+	}
+	mappingsMu.Unlock()
+}
+
+// Error converts a handler error for the app's error handler. *apierr.Error
+// and *fiber.Error are returned as is; a registered sentinel, or an error
+// wrapping one, becomes an *apierr.Error with the sentinel's status and the
+// error's message. Anything else is returned unchanged and becomes a 500.
+func Error(err error) error {
+	if err == nil || isAPIError(err) {
+		return err
+	}
+	if status, ok := statusOf(err); ok {
+		return apierr.New(status, err.Error())
+	}
+	return err
+}
+
+func statusOf(err error) (int, bool) {
+	mappingsMu.RLock()
+	defer mappingsMu.RUnlock()
+	for _, m := range mappings {
+		if errors.Is(err, m.err) {
+			return m.status, true
+		}
+	}
+	return 0, false
+}
+
+func isAPIError(err error) bool {
+	var ae *apierr.Error
+	var fe *fiber.Error
+	return errors.As(err, &ae) || errors.As(err, &fe)
+}
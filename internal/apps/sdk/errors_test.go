@@ -0,0 +1,52 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/gofiber/fiber/v2"
+)
+
+var errTestInvalid = errors.New("value must be positive")
+
+func init() {
+	RegisterErrors(map[error]int{errTestInvalid: fiber.StatusBadRequest})
+}
+
+func TestErrorMapsRegisteredSentinels(t *testing.T) {
+	for _, err := range []error{errTestInvalid, fmt.Errorf("%w, got -1", errTestInvalid)} {
+		var ae *apierr.Error
+		if !errors.As(Error(err), &ae) {
+			t.Fatalf("Error(%v) is not an *apierr.Error", err)
+		}
+		if ae.Status != fiber.StatusBadRequest || ae.Message != err.Error() {
+			t.Errorf("Error(%v) = %d %q, want 400 with the error's message", err, ae.Status, ae.Message)
+		}
+	}
+}
+
+func TestErrorKeepsUnknownErrorsInternal(t *testing.T) {
+	// A storage failure must not reach the client: it stays a plain error,
+	// which the app's error handler turns into a generic 500.
+	err := fmt.Errorf("create failed: %w", errors.New(`pq: duplicate key value violates unique constraint "users_pkey"`))
+	got := apierr.From(Error(err))
+	if got.Status != fiber.StatusInternalServerError || got.Message != "" {
+		t.Errorf("unknown error became %d %q, want a 500 without a message", got.Status, got.Message)
+	}
+}
+
+func TestErrorPassesAPIErrorsThrough(t *testing.T) {
+	ae := apierr.New(fiber.StatusConflict, "already exists")
+	if got := Error(ae); got != ae {
+		t.Errorf("Error(*apierr.Error) = %v, want it unchanged", got)
+	}
+	fe := fiber.NewError(fiber.StatusTeapot, "teapot")
+	if got := Error(fe); got != fe {
+		t.Errorf("Error(*fiber.Error) = %v, want it unchanged", got)
+	}
+	if Error(nil) != nil {
+		t.Error("Error(nil) != nil")
+	}
+}
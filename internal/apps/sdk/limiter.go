@@ -0,0 +1,55 @@
+package sdk
+
+import (
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// Limit is a per-user rate limit on plugin routes.
+type Limit struct {
+	// Name scopes the bucket within the app, e.g. "ai_heavy". Routes
+	// sharing a Limiter share its budget.
+	Name string
+	Max  int
+	// Per is the sliding window; an hour when zero.
+	Per time.Duration
+	// Message is returned with the 429.
+	Message string
+}
+
+// Limiter returns middleware enforcing l. Signed-in callers, guests
+// included, are counted per user within appID; anonymous callers per IP.
+func Limiter(appID string, l Limit) fiber.Handler {
+	if l.Per == 0 {
+		l.Per = time.Hour
+	}
+	prefix := appID + ":" + l.Name + ":"
+	return limiter.New(limiter.Config{
+		Max:               l.Max,
+		Expiration:        l.Per,
+		LimiterMiddleware: limiter.SlidingWindow{},
+		KeyGenerator: func(c *fiber.Ctx) string {
+			if id, err := tenant.GetUserID(c); err == nil {
+				return prefix + id.String()
+			}
+			return prefix + "ip:" + c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return apierr.New(fiber.StatusTooManyRequests, l.Message)
+		},
+	})
+}
+
+// AILimiter limits an AI-backed route to perHour calls per user, to bound
+// provider costs.
+func AILimiter(appID, name string, perHour int) fiber.Handler {
+	return Limiter(appID, Limit{
+		Name:    name,
+		Max:     perHour,
+		Message: "AI rate limit exceeded. Please try again in an hour.",
+	})
+}
@@ -0,0 +1,114 @@
+package sdk
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Page bounds for list routes.
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+	// MaxOffset caps offset paging, which gets slower the deeper it goes;
+	// clients paging further use cursors.
+	MaxOffset = 10000
+)
+
+// ErrInvalidCursor is returned for a cursor this package did not issue.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is a position in a list ordered newest first by a timestamp
+// column, with the row ID breaking ties.
+type Cursor struct {
+	Time time.Time
+	ID   uuid.UUID
+}
+
+// String encodes the cursor for the next_cursor field of a list response.
+func (c Cursor) String() string {
+	raw := strconv.FormatInt(c.Time.UnixNano(), 10) + "." + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor decodes a cursor from Cursor.String.
+func ParseCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return Cursor{Time: time.Unix(0, n).UTC(), ID: uid}, nil
+}
+
+// Page is the page of a list a client asked for, by offset or, when After
+// is set, by cursor.
+type Page struct {
+	Limit  int
+	Offset int
+	After  *Cursor
+}
+
+// PageFrom reads ?limit, ?offset and ?cursor. limit defaults to
+// defaultLimit and out-of-range values are clamped rather than rejected;
+// only a malformed cursor is a 400. A cursor takes precedence over offset.
+func PageFrom(c *fiber.Ctx, defaultLimit int) (Page, error) {
+	p := Page{Limit: c.QueryInt("limit", defaultLimit), Offset: c.QueryInt("offset", 0)}
+	if p.Limit < 1 {
+		p.Limit = 1
+	}
+	if p.Limit > MaxLimit {
+		p.Limit = MaxLimit
+	}
+	if p.Offset < 0 {
+		p.Offset = 0
+	}
+	if p.Offset > MaxOffset {
+		p.Offset = MaxOffset
+	}
+	if s := c.Query("cursor"); s != "" {
+		cursor, err := ParseCursor(s)
+		if err != nil {
+			return Page{}, apierr.New(fiber.StatusBadRequest, err.Error())
+		}
+		p.After, p.Offset = &cursor, 0
+	}
+	return p, nil
+}
+
+// Apply orders db newest first by column, then id, and limits it to the
+// page. column must be a trusted column name, never client input.
+func (p Page) Apply(db *gorm.DB, column string) *gorm.DB {
+	db = db.Order(column + " DESC").Order("id DESC").Limit(p.Limit)
+	if p.After != nil {
+		return db.Where("("+column+", id) < (?, ?)", p.After.Time, p.After.ID)
+	}
+	return db.Offset(p.Offset)
+}
+
+// Next returns the cursor for the page after one that returned n rows, the
+// last of them at last, or "" when the list is exhausted.
+func (p Page) Next(n int, last Cursor) string {
+	if n < p.Limit {
+		return ""
+	}
+	return last.String()
+}
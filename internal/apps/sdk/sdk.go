@@ -0,0 +1,133 @@
+// Package sdk holds the pieces every plugin's routes share: the caller,
+// typed handler adapters, error mapping, pagination and per-user rate
+// limits. A plugin handler takes the caller and its decoded request and
+// returns the response:
+//
+//	func (h *SleepHandler) Get(c *fiber.Ctx, u sdk.User, _ sdk.Empty) (*SleepResponse, error) {
+//		id, err := sdk.ParamUUID(c, "id")
+//		if err != nil {
+//			return nil, err
+//		}
+//		return h.service.Get(u.AppID, u.ID, id)
+//	}
+//
+//	router.Get("/sleeps/:id", sdk.Handle(handler.Get))
+//
+// Service errors registered with RegisterErrors get their status; any other
+// error is a 500.
+package sdk
+
+import (
+	"reflect"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// User is the caller of a plugin route.
+type User struct {
+	// ID is uuid.Nil for anonymous callers of Optional routes.
+	ID    uuid.UUID
+	AppID string
+	// Guest is set for guest accounts.
+	Guest bool
+}
+
+// Anonymous reports whether the caller sent no user token.
+func (u User) Anonymous() bool {
+	return u.ID == uuid.Nil
+}
+
+// Caller returns the signed-in user, or a 401 when the request has no user
+// token.
+func Caller(c *fiber.Ctx) (User, error) {
+	id, err := tenant.GetUserID(c)
+	if err != nil {
+		return User{}, apierr.New(fiber.StatusUnauthorized, "authentication required")
+	}
+	return User{ID: id, AppID: tenant.GetAppID(c), Guest: tenant.IsGuest(c)}, nil
+}
+
+// Empty is the request type of handlers that read no body or query.
+type Empty struct{}
+
+// Handler serves a route for a signed-in user. req is decoded and validated
+// before the call; the response is sent as JSON with the status already set
+// on c, 200 unless the handler changes it. A handler that sets 204 sends no
+// body.
+type Handler[Req, Resp any] func(c *fiber.Ctx, u User, req Req) (Resp, error)
+
+// Handle adapts h to a fiber.Handler that rejects anonymous callers.
+func Handle[Req, Resp any](h Handler[Req, Resp]) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		u, err := Caller(c)
+		if err != nil {
+			return err
+		}
+		var req Req
+		if err := bind(c, &req); err != nil {
+			return err
+		}
+		resp, err := h(c, u, req)
+		if err != nil {
+			return Error(err)
+		}
+		return respond(c, resp)
+	}
+}
+
+// Optional adapts h to a fiber.Handler that also serves anonymous callers,
+// passing them as an Anonymous User of the request's app.
+func Optional[Req, Resp any](h Handler[Req, Resp]) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		u, err := Caller(c)
+		if err != nil {
+			u = User{AppID: tenant.GetAppID(c)}
+		}
+		var req Req
+		if err := bind(c, &req); err != nil {
+			return err
+		}
+		resp, err := h(c, u, req)
+		if err != nil {
+			return Error(err)
+		}
+		return respond(c, resp)
+	}
+}
+
+func respond(c *fiber.Ctx, resp interface{}) error {
+	if c.Response().StatusCode() == fiber.StatusNoContent {
+		return nil
+	}
+	return c.JSON(resp)
+}
+
+// bind decodes the request into req and validates it: the query string for
+// GET and DELETE, the body otherwise. Empty and other field-less types read
+// nothing, so routes without a body accept requests without a content type.
+func bind(c *fiber.Ctx, req interface{}) error {
+	if t := reflect.TypeOf(req).Elem(); t.Kind() == reflect.Struct && t.NumField() == 0 {
+		return nil
+	}
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodDelete:
+		if err := c.QueryParser(req); err != nil {
+			return apierr.New(fiber.StatusBadRequest, "Invalid query parameters")
+		}
+		return validation.Struct(req)
+	}
+	return validation.Bind(c, req)
+}
+
+// ParamUUID parses the route parameter name as a UUID, or returns a 400.
+func ParamUUID(c *fiber.Ctx, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Params(name))
+	if err != nil {
+		return uuid.Nil, apierr.New(fiber.StatusBadRequest, "invalid "+name)
+	}
+	return id, nil
+}
@@ -6,7 +6,8 @@ import (
 	"os"
 	"path/filepath"
 
-	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -25,24 +26,18 @@ func (h *SnapHandler) CreateSnap(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	// Get the uploaded file
 	file, err := c.FormFile("image")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Image file is required",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Image file is required")
 	}
 
 	// Validate file size (max 10MB)
 	if file.Size > 10*1024*1024 {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Image size must be less than 10MB",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Image size must be less than 10MB")
 	}
 
 	// Validate content type
@@ -54,9 +49,7 @@ func (h *SnapHandler) CreateSnap(c *fiber.Ctx) error {
 		"image/heic": true,
 	}
 	if !validTypes[contentType] {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid image format. Only JPEG, PNG, and HEIC are allowed",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid image format. Only JPEG, PNG, and HEIC are allowed")
 	}
 
 	caption := c.FormValue("caption", "")
@@ -73,9 +66,7 @@ func (h *SnapHandler) CreateSnap(c *fiber.Ctx) error {
 	uploadDir := "./uploads/snaps"
 	savePath := filepath.Join(uploadDir, filename)
 	if err := c.SaveFile(file, savePath); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to save image",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to save image")
 	}
 
 	imageURL := fmt.Sprintf("/uploads/snaps/%s", filename)
@@ -84,13 +75,9 @@ func (h *SnapHandler) CreateSnap(c *fiber.Ctx) error {
 	if err != nil {
 		os.Remove(savePath)
 		if errors.Is(err, ErrInvalidFilter) {
-			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-				Error: true, Message: err.Error(),
-			})
+			return apierr.New(fiber.StatusBadRequest, err.Error())
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to create snap",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to create snap")
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(SnapResponse{
@@ -110,9 +97,7 @@ func (h *SnapHandler) GetMySnaps(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	page := c.QueryInt("page", 1)
@@ -127,9 +112,7 @@ func (h *SnapHandler) GetMySnaps(c *fiber.Ctx) error {
 	offset := (page - 1) * limit
 	snaps, total, err := h.snapService.GetUserSnaps(appID, userID, limit, offset)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to fetch snaps",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch snaps")
 	}
 
 	baseURL := c.Protocol() + "://" + c.Hostname()
@@ -164,34 +147,31 @@ func (h *SnapHandler) GetStreak(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	streak, err := h.snapService.GetStreak(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to fetch streak",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch streak")
 	}
 
 	todaySnap, err := h.snapService.GetTodaySnap(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to check today's snap",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to check today's snap")
 	}
 
-	return c.JSON(StreakResponse{
+	resp := StreakResponse{
 		CurrentStreak:    streak.CurrentStreak,
 		LongestStreak:    streak.LongestStreak,
-		TotalSnaps:       streak.TotalSnaps,
-		LastSnapDate:     streak.LastSnapDate,
+		TotalSnaps:       streak.TotalCount,
 		HasSnappedToday:  todaySnap != nil,
 		FreezesAvailable: streak.FreezesAvailable,
 		FreezesUsed:      streak.FreezesUsed,
-	})
+	}
+	if streak.LastActivityAt != nil {
+		resp.LastSnapDate = *streak.LastActivityAt
+	}
+	return c.JSON(resp)
 }
 
 // AddFreeze handles POST /snaps/streak/freeze - adds a streak freeze to the user's account.
@@ -199,32 +179,24 @@ func (h *SnapHandler) AddFreeze(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
-	err = h.snapService.AddStreakFreeze(appID, userID)
+	streak, err := h.snapService.AddStreakFreeze(appID, userID)
 	if err != nil {
-		if err.Error() == "maximum freezes reached (3)" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "You already have the maximum of 3 freezes",
-			})
+		if errors.Is(err, streaks.ErrFreezeLimit) {
+			return apierr.New(fiber.StatusBadRequest, "You already have the maximum of 3 freezes")
 		}
-		if err.Error() == "no streak record found for user" {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": "No streak record found. Start your streak first!",
-			})
+		if errors.Is(err, streaks.ErrNoStreak) {
+			return apierr.New(fiber.StatusNotFound, "No streak record found. Start your streak first!")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to add streak freeze",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to add streak freeze")
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Streak freeze added successfully",
 		"data": fiber.Map{
-			"freezes_available": 1,
+			"freezes_available": streak.FreezesAvailable,
 		},
 	})
 }
@@ -234,9 +206,7 @@ func (h *SnapHandler) GetSnapCalendar(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	days := c.QueryInt("days", 35)
@@ -249,9 +219,7 @@ func (h *SnapHandler) GetSnapCalendar(c *fiber.Ctx) error {
 
 	dates, err := h.snapService.GetSnapDates(appID, userID, days)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve calendar data",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to retrieve calendar data")
 	}
 
 	return c.JSON(fiber.Map{
@@ -265,27 +233,19 @@ func (h *SnapHandler) DeleteSnap(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	snapID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid snap ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid snap ID")
 	}
 
 	if err := h.snapService.DeleteSnap(appID, userID, snapID); err != nil {
 		if errors.Is(err, ErrSnapNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{
-				Error: true, Message: "Snap not found",
-			})
+			return apierr.New(fiber.StatusNotFound, "Snap not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to delete snap",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to delete snap")
 	}
 
 	return c.JSON(fiber.Map{"message": "Snap deleted"})
@@ -296,20 +256,14 @@ func (h *SnapHandler) LikeSnap(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	snapID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid snap ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid snap ID")
 	}
 
 	if err := h.snapService.LikeSnap(appID, snapID); err != nil {
 		if errors.Is(err, ErrSnapNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{
-				Error: true, Message: "Snap not found",
-			})
+			return apierr.New(fiber.StatusNotFound, "Snap not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to like snap",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to like snap")
 	}
 
 	return c.JSON(fiber.Map{"message": "Snap liked"})
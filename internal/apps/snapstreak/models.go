@@ -20,21 +20,6 @@ type Snap struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
-type SnapStreak struct {
-	ID               uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	AppID            string    `gorm:"size:50;not null;index;uniqueIndex:idx_snapstreak_app_user" json:"app_id"`
-	UserID           uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_snapstreak_app_user" json:"user_id"`
-	CurrentStreak    int       `gorm:"default:0" json:"current_streak"`
-	LongestStreak    int       `gorm:"default:0" json:"longest_streak"`
-	TotalSnaps       int       `gorm:"default:0" json:"total_snaps"`
-	LastSnapDate     time.Time `json:"last_snap_date"`
-	CreatedAt        time.Time `json:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at"`
-	FreezesAvailable int       `json:"freezes_available" gorm:"default:0"`
-	FreezesUsed      int       `json:"freezes_used" gorm:"default:0"`
-	LastFreezeDate   time.Time `json:"last_freeze_date"`
-}
-
 var SnapFilters = []string{"none", "vintage", "warm", "cool", "dramatic", "minimal", "vibrant", "noir"}
 
 // --- DTOs ---
@@ -17,7 +17,6 @@ func (p *SnapstreakPlugin) ID() string { return "snapstreak" }
 func (p *SnapstreakPlugin) Models() []interface{} {
 	return []interface{}{
 		&Snap{},
-		&SnapStreak{},
 	}
 }
 
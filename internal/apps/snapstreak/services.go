@@ -6,6 +6,8 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -18,11 +20,12 @@ var (
 )
 
 type SnapService struct {
-	db *gorm.DB
+	db      *gorm.DB
+	streaks *streaks.Engine
 }
 
 func NewSnapService(db *gorm.DB) *SnapService {
-	return &SnapService{db: db}
+	return &SnapService{db: db, streaks: newSnapStreaks(db)}
 }
 
 // CreateSnap creates a new snap and updates the user's streak.
@@ -53,67 +56,13 @@ func (s *SnapService) CreateSnap(appID string, userID uuid.UUID, imageURL string
 	}
 
 	// Update streak after successful snap creation
-	if err := s.updateStreak(appID, userID); err != nil {
+	if _, err := s.streaks.Record(appID, userID, snap.SnapDate); err != nil {
 		slog.Warn("failed to update streak", "user_id", userID, "error", err)
 	}
 
 	return &snap, nil
 }
 
-// updateStreak updates the streak record for the user based on when they last snapped.
-func (s *SnapService) updateStreak(appID string, userID uuid.UUID) error {
-	var streak SnapStreak
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error
-
-	now := time.Now()
-	today := now.Truncate(24 * time.Hour)
-
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		streak = SnapStreak{
-			ID:            uuid.New(),
-			AppID:         appID,
-			UserID:        userID,
-			CurrentStreak: 1,
-			LongestStreak: 1,
-			TotalSnaps:    1,
-			LastSnapDate:  now,
-		}
-		return s.db.Create(&streak).Error
-	} else if err != nil {
-		return fmt.Errorf("failed to find streak: %w", err)
-	}
-
-	lastSnapDay := streak.LastSnapDate.Truncate(24 * time.Hour)
-
-	if lastSnapDay.Equal(today) {
-		streak.TotalSnaps++
-		streak.LastSnapDate = now
-		return s.db.Save(&streak).Error
-	}
-
-	yesterday := today.Add(-24 * time.Hour)
-	if lastSnapDay.Equal(yesterday) {
-		streak.CurrentStreak++
-	} else {
-		if streak.FreezesAvailable > 0 {
-			streak.FreezesAvailable--
-			streak.FreezesUsed++
-			streak.LastFreezeDate = time.Now()
-		} else {
-			streak.CurrentStreak = 1
-		}
-	}
-
-	if streak.CurrentStreak > streak.LongestStreak {
-		streak.LongestStreak = streak.CurrentStreak
-	}
-
-	streak.TotalSnaps++
-	streak.LastSnapDate = now
-
-	return s.db.Save(&streak).Error
-}
-
 // GetUserSnaps returns paginated snaps for a user.
 func (s *SnapService) GetUserSnaps(appID string, userID uuid.UUID, limit int, offset int) ([]Snap, int64, error) {
 	var snaps []Snap
@@ -131,21 +80,12 @@ func (s *SnapService) GetUserSnaps(appID string, userID uuid.UUID, limit int, of
 }
 
 // GetStreak returns the streak record for a user.
-func (s *SnapService) GetStreak(appID string, userID uuid.UUID) (*SnapStreak, error) {
-	var streak SnapStreak
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return &SnapStreak{
-			UserID:        userID,
-			CurrentStreak: 0,
-			LongestStreak: 0,
-			TotalSnaps:    0,
-		}, nil
-	}
+func (s *SnapService) GetStreak(appID string, userID uuid.UUID) (*models.Streak, error) {
+	streak, err := s.streaks.Get(appID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get streak: %w", err)
 	}
-	return &streak, nil
+	return streak, nil
 }
 
 // GetTodaySnap checks if the user has already posted a snap today.
@@ -192,22 +132,8 @@ func (s *SnapService) LikeSnap(appID string, snapID uuid.UUID) error {
 
 // AddStreakFreeze adds one streak freeze to the user's available freezes.
 // Maximum of 3 freezes can be stored at once.
-func (s *SnapService) AddStreakFreeze(appID string, userID uuid.UUID) error {
-	var streak SnapStreak
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("no streak record found for user")
-		}
-		return err
-	}
-
-	if streak.FreezesAvailable >= 3 {
-		return errors.New("maximum freezes reached (3)")
-	}
-
-	streak.FreezesAvailable++
-	return s.db.Save(&streak).Error
+func (s *SnapService) AddStreakFreeze(appID string, userID uuid.UUID) (*models.Streak, error) {
+	return s.streaks.AddFreeze(appID, userID)
 }
 
 // GetSnapDates retrieves all snap dates for a user within the specified number of days.
@@ -238,29 +164,3 @@ func (s *SnapService) GetSnapDates(appID string, userID uuid.UUID, days int) ([]
 
 	return dates, nil
 }
-
-// GetStreakWithFreezes retrieves the streak data including freeze information.
-func (s *SnapService) GetStreakWithFreezes(appID string, userID uuid.UUID) (*SnapStreak, error) {
-	var streak SnapStreak
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			streak = SnapStreak{
-				AppID:            appID,
-				UserID:           userID,
-				CurrentStreak:    0,
-				LongestStreak:    0,
-				FreezesAvailable: 0,
-				FreezesUsed:      0,
-			}
-			err = s.db.Create(&streak).Error
-			if err != nil {
-				return nil, err
-			}
-			return &streak, nil
-		}
-		return nil, err
-	}
-
-	return &streak, nil
-}
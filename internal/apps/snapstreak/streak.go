@@ -0,0 +1,48 @@
+package snapstreak
+
+import (
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// snapStreakPolicy counts consecutive snapping days. Freezes (up to 3 held
+// at once) are spent automatically to cover missed days.
+var snapStreakPolicy = streaks.Policy{
+	MaxGap:     1,
+	AutoFreeze: true,
+	MaxFreezes: 3,
+}
+
+func newSnapStreaks(db *gorm.DB) *streaks.Engine {
+	return streaks.New(db, "snap", snapStreakPolicy)
+}
+
+// Migrate implements apps.MigrationPlugin, carrying snap_streaks rows over to
+// the shared streaks table.
+func (p *SnapstreakPlugin) Migrate(db *gorm.DB) error {
+	_, err := newSnapStreaks(db).ImportLegacy(streaks.Legacy{
+		Table:            "snap_streaks",
+		Total:            "total_snaps",
+		LastActive:       "last_snap_date",
+		FreezesAvailable: "freezes_available",
+		FreezesUsed:      "freezes_used",
+	})
+	return err
+}
+
+// RecomputeStreaks implements services.StreakRecomputer, rebuilding the snap
+// streak from snap times bucketed by local day in loc.
+func (p *SnapstreakPlugin) RecomputeStreaks(db *gorm.DB, appID string, userID uuid.UUID, loc *time.Location) error {
+	var times []time.Time
+	if err := db.Model(&Snap{}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ?", userID).
+		Pluck("snap_date", &times).Error; err != nil {
+		return err
+	}
+	return newSnapStreaks(db).Recompute(appID, userID, loc, times)
+}
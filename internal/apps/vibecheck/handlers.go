@@ -3,7 +3,9 @@ package vibecheck
 import (
 	"strconv"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -22,25 +24,19 @@ func (h *VibeHandler) CreateVibeCheck(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	var req struct {
 		MoodText string `json:"mood_text"`
 	}
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	check, err := h.service.CreateVibeCheck(appID, userID, req.MoodText)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": err.Error(),
-		})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(check)
@@ -54,16 +50,12 @@ func (h *VibeHandler) CreateGuestVibeCheck(c *fiber.Ctx) error {
 		MoodText string `json:"mood_text"`
 		DeviceID string `json:"device_id"`
 	}
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	if req.MoodText == "" || req.DeviceID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "mood_text and device_id are required",
-		})
+		return apierr.New(fiber.StatusBadRequest, "mood_text and device_id are required")
 	}
 
 	check, err := h.service.CreateGuestVibeCheck(appID, req.MoodText, req.DeviceID)
@@ -72,9 +64,7 @@ func (h *VibeHandler) CreateGuestVibeCheck(c *fiber.Ctx) error {
 		if err.Error() == "free limit reached, sign up for unlimited vibes" {
 			status = fiber.StatusForbidden
 		}
-		return c.Status(status).JSON(fiber.Map{
-			"error": true, "message": err.Error(),
-		})
+		return apierr.New(status, err.Error())
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(check)
@@ -85,16 +75,12 @@ func (h *VibeHandler) GetTodayCheck(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	check, err := h.service.GetTodayCheck(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": true, "message": "No vibe check today",
-		})
+		return apierr.New(fiber.StatusNotFound, "No vibe check today")
 	}
 
 	return c.JSON(check)
@@ -105,9 +91,7 @@ func (h *VibeHandler) GetVibeHistory(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
@@ -124,9 +108,7 @@ func (h *VibeHandler) GetVibeHistory(c *fiber.Ctx) error {
 
 	checks, total, err := h.service.GetVibeHistory(appID, userID, limit, offset)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to fetch history",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch history")
 	}
 
 	return c.JSON(fiber.Map{
@@ -140,16 +122,12 @@ func (h *VibeHandler) GetVibeStats(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	stats, err := h.service.GetVibeStats(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to fetch stats",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch stats")
 	}
 
 	return c.JSON(stats)
@@ -160,9 +138,7 @@ func (h *VibeHandler) GetVibeTrend(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	days, err := strconv.Atoi(c.Query("days", "7"))
@@ -175,9 +151,7 @@ func (h *VibeHandler) GetVibeTrend(c *fiber.Ctx) error {
 
 	trendData, err := h.service.GetVibeTrend(appID, userID, days)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to fetch vibe trend",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch vibe trend")
 	}
 
 	return c.JSON(fiber.Map{
@@ -27,20 +27,6 @@ type VibeCheck struct {
 	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
-// VibeStreak tracks user's vibe check streak.
-type VibeStreak struct {
-	ID            uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	AppID         string         `gorm:"size:50;not null;index;uniqueIndex:idx_vibe_streak_app_user" json:"app_id"`
-	UserID        uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_vibe_streak_app_user" json:"user_id"`
-	CurrentStreak int            `gorm:"default:0" json:"current_streak"`
-	LongestStreak int            `gorm:"default:0" json:"longest_streak"`
-	TotalChecks   int            `gorm:"default:0" json:"total_checks"`
-	LastCheckDate time.Time      `gorm:"type:date" json:"last_check_date"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
-}
-
 // Aesthetic presets
 var Aesthetics = map[string]struct {
 	Name           string
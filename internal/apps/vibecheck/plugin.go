@@ -19,7 +19,6 @@ func (p *Plugin) ID() string { return "vibecheck" }
 func (p *Plugin) Models() []interface{} {
 	return []interface{}{
 		&VibeCheck{},
-		&VibeStreak{},
 	}
 }
 
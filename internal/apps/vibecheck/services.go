@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -18,12 +19,13 @@ import (
 // VibeService handles vibe check-in business logic.
 type VibeService struct {
 	db        *gorm.DB
+	streaks   *streaks.Engine
 	openaiKey string
 }
 
 // NewVibeService creates a new VibeService.
 func NewVibeService(db *gorm.DB, openaiKey string) *VibeService {
-	return &VibeService{db: db, openaiKey: openaiKey}
+	return &VibeService{db: db, streaks: newVibeStreaks(db), openaiKey: openaiKey}
 }
 
 // --- OpenAI types ---
@@ -151,7 +153,7 @@ func (s *VibeService) CreateVibeCheck(appID string, userID uuid.UUID, moodText s
 		return nil, err
 	}
 
-	s.updateStreak(appID, userID, today)
+	s.updateStreak(appID, userID)
 	return check, nil
 }
 
@@ -433,37 +435,6 @@ func (s *VibeService) generateInsight(aestheticKey string, vibeScore int, moodTe
 	return templates[templateIndex]
 }
 
-func (s *VibeService) updateStreak(appID string, userID uuid.UUID, today time.Time) {
-	var streak VibeStreak
-	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error; err != nil {
-		streak = VibeStreak{
-			AppID:         appID,
-			UserID:        userID,
-			CurrentStreak: 1,
-			LongestStreak: 1,
-			TotalChecks:   1,
-			LastCheckDate: today,
-		}
-		s.db.Create(&streak)
-		return
-	}
-
-	yesterday := today.AddDate(0, 0, -1)
-	streak.TotalChecks++
-
-	if streak.LastCheckDate.Equal(yesterday) {
-		streak.CurrentStreak++
-	} else if !streak.LastCheckDate.Equal(today) {
-		streak.CurrentStreak = 1
-	}
-
-	if streak.CurrentStreak > streak.LongestStreak {
-		streak.LongestStreak = streak.CurrentStreak
-	}
-	streak.LastCheckDate = today
-	s.db.Save(&streak)
-}
-
 // GetTodayCheck returns today's check-in for a user.
 func (s *VibeService) GetTodayCheck(appID string, userID uuid.UUID) (*VibeCheck, error) {
 	today := time.Now().Truncate(24 * time.Hour)
@@ -494,8 +465,10 @@ func (s *VibeService) GetVibeHistory(appID string, userID uuid.UUID, limit, offs
 
 // GetVibeStats returns user's vibe statistics.
 func (s *VibeService) GetVibeStats(appID string, userID uuid.UUID) (map[string]interface{}, error) {
-	var streak VibeStreak
-	s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak)
+	streak, err := s.streaks.Get(appID, userID)
+	if err != nil {
+		return nil, err
+	}
 
 	var avgScore float64
 	s.db.Model(&VibeCheck{}).Scopes(tenant.ForTenant(appID)).
@@ -539,7 +512,7 @@ func (s *VibeService) GetVibeStats(appID string, userID uuid.UUID) (map[string]i
 	return map[string]interface{}{
 		"current_streak":    streak.CurrentStreak,
 		"longest_streak":    streak.LongestStreak,
-		"total_checks":      streak.TotalChecks,
+		"total_checks":      streak.TotalCount,
 		"avg_vibe_score":    avgScore,
 		"top_aesthetic":     topAesthetic,
 		"last_7_avg":        last7Avg,
@@ -0,0 +1,50 @@
+package vibecheck
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// vibeStreakPolicy counts consecutive vibe check days.
+var vibeStreakPolicy = streaks.Policy{MaxGap: 1}
+
+func newVibeStreaks(db *gorm.DB) *streaks.Engine {
+	return streaks.New(db, "vibe", vibeStreakPolicy)
+}
+
+// updateStreak records a vibe check day for the user.
+func (s *VibeService) updateStreak(appID string, userID uuid.UUID) {
+	if _, err := s.streaks.Record(appID, userID, time.Now()); err != nil {
+		slog.Warn("failed to update vibe streak", "app_id", appID, "user_id", userID, "error", err)
+	}
+}
+
+// Migrate implements apps.MigrationPlugin, carrying vibe_streaks rows over to
+// the shared streaks table.
+func (p *Plugin) Migrate(db *gorm.DB) error {
+	_, err := newVibeStreaks(db).ImportLegacy(streaks.Legacy{
+		Table:      "vibe_streaks",
+		Where:      "deleted_at IS NULL",
+		Total:      "total_checks",
+		LastActive: "last_check_date",
+	})
+	return err
+}
+
+// RecomputeStreaks implements services.StreakRecomputer, rebuilding the vibe
+// streak from check-in times bucketed by local day in loc.
+func (p *Plugin) RecomputeStreaks(db *gorm.DB, appID string, userID uuid.UUID, loc *time.Location) error {
+	var times []time.Time
+	if err := db.Model(&VibeCheck{}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ?", userID).
+		Pluck("created_at", &times).Error; err != nil {
+		return err
+	}
+	return newVibeStreaks(db).Recompute(appID, userID, loc, times)
+}
@@ -3,7 +3,9 @@ package wouldyou
 import (
 	"strconv"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -29,9 +31,7 @@ func (h *ChallengeHandler) GetDailyChallenge(c *fiber.Ctx) error {
 
 	challenge, err := h.service.GetDailyChallenge(appID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to get challenge",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to get challenge")
 	}
 
 	userID, guestID := extractIdentity(c)
@@ -70,33 +70,25 @@ func (h *ChallengeHandler) Vote(c *fiber.Ctx) error {
 	userID, guestID := extractIdentity(c)
 
 	if userID == uuid.Nil && guestID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Authentication required. Sign up or use guest mode.",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Authentication required. Sign up or use guest mode.")
 	}
 
 	var req struct {
 		ChallengeID string `json:"challenge_id"`
 		Choice      string `json:"choice"`
 	}
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	challengeID, err := uuid.Parse(req.ChallengeID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid challenge ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid challenge ID")
 	}
 
 	vote, err := h.service.Vote(appID, userID, guestID, challengeID, req.Choice)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": err.Error(),
-		})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(vote)
@@ -107,16 +99,12 @@ func (h *ChallengeHandler) GetStats(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	stats, err := h.service.GetStats(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to get stats",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to get stats")
 	}
 
 	return c.JSON(stats)
@@ -127,16 +115,12 @@ func (h *ChallengeHandler) GetHistory(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": true, "message": "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	history, err := h.service.GetChallengeHistory(appID, userID, 20)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to get history",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to get history")
 	}
 
 	return c.JSON(fiber.Map{"data": history})
@@ -149,9 +133,7 @@ func (h *ChallengeHandler) GetRandom(c *fiber.Ctx) error {
 
 	challenge, err := h.service.GetRandomChallenge(appID, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "No challenges available",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "No challenges available")
 	}
 
 	total := challenge.VotesA + challenge.VotesB
@@ -173,18 +155,14 @@ func (h *ChallengeHandler) GetByCategory(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	category := c.Params("category")
 	if category == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Category is required",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Category is required")
 	}
 
 	userID, _ := extractIdentity(c)
 
 	challenges, err := h.service.GetChallengesByCategory(appID, category, userID, 20)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to get challenges",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to get challenges")
 	}
 
 	return c.JSON(fiber.Map{"data": challenges, "total": len(challenges)})
@@ -198,10 +176,8 @@ func (h *ChallengeHandler) GenerateQuestions(c *fiber.Ctx) error {
 		Category string `json:"category"`
 		Count    int    `json:"count"`
 	}
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	if req.Category == "" {
@@ -211,23 +187,16 @@ func (h *ChallengeHandler) GenerateQuestions(c *fiber.Ctx) error {
 		req.Count = 10
 	}
 	if req.Count > 50 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true, "message": "Count cannot exceed 50",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Count cannot exceed 50")
 	}
 
 	if !h.questionGenerator.IsAvailable() {
-		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-			"error": true, "message": "AI question generation is not configured",
-			"hint":  "Set GLM_API_KEY environment variable",
-		})
+		return apierr.New(fiber.StatusServiceUnavailable, "AI question generation is not configured")
 	}
 
 	challenges, err := h.questionGenerator.GenerateBatch(appID, req.Category, req.Count)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to generate questions",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to generate questions")
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -251,16 +220,12 @@ func (h *ChallengeHandler) GenerateAllCategories(c *fiber.Ctx) error {
 	}
 
 	if !h.questionGenerator.IsAvailable() {
-		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-			"error": true, "message": "AI question generation is not configured",
-		})
+		return apierr.New(fiber.StatusServiceUnavailable, "AI question generation is not configured")
 	}
 
 	results, err := h.questionGenerator.GenerateForAllCategories(appID, count)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true, "message": "Failed to generate questions",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to generate questions")
 	}
 
 	totalCount := 0
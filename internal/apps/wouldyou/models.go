@@ -35,20 +35,6 @@ type Vote struct {
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
-// ChallengeStreak tracks user's voting streak.
-type ChallengeStreak struct {
-	ID            uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	AppID         string         `gorm:"size:50;not null;index;uniqueIndex:idx_wy_streak_app_user" json:"app_id"`
-	UserID        uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_wy_streak_app_user" json:"user_id"`
-	CurrentStreak int            `gorm:"default:0" json:"current_streak"`
-	LongestStreak int            `gorm:"default:0" json:"longest_streak"`
-	TotalVotes    int            `gorm:"default:0" json:"total_votes"`
-	LastVoteDate  time.Time      `gorm:"type:date" json:"last_vote_date"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
-}
-
 // DailyChallenges - expanded pool of 60 challenges across 6 categories.
 var DailyChallenges = []struct {
 	OptionA  string
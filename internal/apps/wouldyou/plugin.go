@@ -20,7 +20,6 @@ func (p *Plugin) Models() []interface{} {
 	return []interface{}{
 		&Challenge{},
 		&Vote{},
-		&ChallengeStreak{},
 	}
 }
 
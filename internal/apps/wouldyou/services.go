@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -21,12 +22,13 @@ import (
 // ChallengeService handles WouldYouRather challenge logic.
 type ChallengeService struct {
 	db                *gorm.DB
+	streaks           *streaks.Engine
 	questionGenerator *QuestionGeneratorService
 }
 
 // NewChallengeService creates a new ChallengeService.
 func NewChallengeService(db *gorm.DB, qg *QuestionGeneratorService) *ChallengeService {
-	return &ChallengeService{db: db, questionGenerator: qg}
+	return &ChallengeService{db: db, streaks: newVoteStreaks(db), questionGenerator: qg}
 }
 
 // GetDailyChallenge returns today's challenge, creating one with rotation if needed.
@@ -285,48 +287,17 @@ func (s *ChallengeService) GetGuestVote(appID, guestID string, challengeID uuid.
 	return &vote, nil
 }
 
-func (s *ChallengeService) updateStreak(appID string, userID uuid.UUID) {
-	today := time.Now().Truncate(24 * time.Hour)
-
-	var streak ChallengeStreak
-	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak).Error; err != nil {
-		streak = ChallengeStreak{
-			AppID:         appID,
-			UserID:        userID,
-			CurrentStreak: 1,
-			LongestStreak: 1,
-			TotalVotes:    1,
-			LastVoteDate:  today,
-		}
-		s.db.Create(&streak)
-		return
-	}
-
-	yesterday := today.AddDate(0, 0, -1)
-	streak.TotalVotes++
-
-	if streak.LastVoteDate.Equal(yesterday) {
-		streak.CurrentStreak++
-	} else if !streak.LastVoteDate.Equal(today) {
-		streak.CurrentStreak = 1
-	}
-
-	if streak.CurrentStreak > streak.LongestStreak {
-		streak.LongestStreak = streak.CurrentStreak
-	}
-	streak.LastVoteDate = today
-	s.db.Save(&streak)
-}
-
 // GetStats returns user's voting stats.
 func (s *ChallengeService) GetStats(appID string, userID uuid.UUID) (map[string]interface{}, error) {
-	var streak ChallengeStreak
-	s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&streak)
+	streak, err := s.streaks.Get(appID, userID)
+	if err != nil {
+		return nil, err
+	}
 
 	return map[string]interface{}{
 		"current_streak": streak.CurrentStreak,
 		"longest_streak": streak.LongestStreak,
-		"total_votes":    streak.TotalVotes,
+		"total_votes":    streak.TotalCount,
 	}, nil
 }
 
@@ -0,0 +1,50 @@
+package wouldyou
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// voteStreakPolicy counts consecutive voting days.
+var voteStreakPolicy = streaks.Policy{MaxGap: 1}
+
+func newVoteStreaks(db *gorm.DB) *streaks.Engine {
+	return streaks.New(db, "vote", voteStreakPolicy)
+}
+
+// updateStreak records a voting day for the user.
+func (s *ChallengeService) updateStreak(appID string, userID uuid.UUID) {
+	if _, err := s.streaks.Record(appID, userID, time.Now()); err != nil {
+		slog.Warn("failed to update vote streak", "app_id", appID, "user_id", userID, "error", err)
+	}
+}
+
+// Migrate implements apps.MigrationPlugin, carrying challenge_streaks rows
+// over to the shared streaks table.
+func (p *Plugin) Migrate(db *gorm.DB) error {
+	_, err := newVoteStreaks(db).ImportLegacy(streaks.Legacy{
+		Table:      "challenge_streaks",
+		Where:      "deleted_at IS NULL",
+		Total:      "total_votes",
+		LastActive: "last_vote_date",
+	})
+	return err
+}
+
+// RecomputeStreaks implements services.StreakRecomputer, rebuilding the vote
+// streak from vote times bucketed by local day in loc.
+func (p *Plugin) RecomputeStreaks(db *gorm.DB, appID string, userID uuid.UUID, loc *time.Location) error {
+	var times []time.Time
+	if err := db.Model(&Vote{}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ?", userID).
+		Pluck("created_at", &times).Error; err != nil {
+		return err
+	}
+	return newVoteStreaks(db).Recompute(appID, userID, loc, times)
+}
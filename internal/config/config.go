@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -31,15 +33,36 @@ type Config struct {
 
 	OpenAIAPIKey string
 	OpenAIModel  string
+	// OpenAIBaseURL is the API root that chat, embedding and Whisper paths
+	// are appended to, e.g. to route through a proxy or a test fake.
+	OpenAIBaseURL string
 
 	FalAPIKey string
 
 	AITimeout time.Duration
 
-	// Admin
-	AdminEmails  string
-	AdminUserIDs string
-	AdminToken   string
+	// Admin. AdminToken is a bootstrap credential that can only manage admin
+	// identities and API keys; everything else needs an admin login or key.
+	AdminToken         string
+	AdminSessionExpiry time.Duration
+	// LegacyAdminEmails and LegacyAdminUserIDs are the pre-RBAC ADMIN_EMAILS
+	// and ADMIN_USER_IDS lists. They grant nothing at runtime: the first start
+	// after the cutover turns them, and users with role "admin", into admin
+	// identities (AdminService.SeedLegacyAdmins). Both can be unset after that.
+	LegacyAdminEmails  []string
+	LegacyAdminUserIDs []string
+
+	// AccountDeletionGrace is how long a deleted account can be restored by
+	// signing in again before its data is purged. 0 purges immediately.
+	AccountDeletionGrace time.Duration
+
+	// OpenAPIPublic serves /api/openapi.json without admin credentials.
+	OpenAPIPublic bool
+
+	// MFASecretKey encrypts TOTP secrets at rest. It defaults to JWTSecret;
+	// set it separately so JWT_SECRET can be rotated without every user and
+	// admin re-enrolling their authenticator.
+	MFASecretKey string
 
 	// Server
 	Port        string
@@ -50,14 +73,44 @@ type Config struct {
 	AppleKeyID     string
 	ApplePrivateKey string
 
+	// Extra OpenID Connect sign-in providers, as "name=issuer" pairs
+	// separated by commas. Apple and Google are built in; client IDs are set
+	// per app in apps.json.
+	OIDCIssuers map[string]string
+
+	// Push notifications. APNs token auth reuses the Apple key above, which
+	// must have the APNs capability enabled.
+	PushProvider          string // "mock" delivers nothing and records messages in memory
+	FCMServiceAccountJSON string
+
 	// App registry
 	AppsConfigPath string
 
 	// File uploads root directory (absolute path preferred; defaults to ./uploads)
 	UploadsRoot string
+	// MediaBaseURL is the public origin that serves UploadsRoot at /uploads/.
+	MediaBaseURL string
+
+	// Transactional email over SMTP. MailProvider "mock" records messages
+	// in memory instead; with neither, features that send email are off.
+	MailProvider string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
 
 	// EmotionSenseML service URL for async emotion analysis on journal entries
 	EmotionSenseMLURL string
+
+	// Reported content is hidden automatically once this many distinct users
+	// have reported it and no admin has decided yet (0 disables).
+	ModerationAutoHideThreshold int
+
+	// Optional content classifier: an HTTP endpoint, or "stub" for the local
+	// stand-in. Empty disables it.
+	ModerationClassifierURL string
+	ModerationClassifierKey string
 }
 
 func Load() *Config {
@@ -84,16 +137,22 @@ func Load() *Config {
 		DeepSeekAPIURL: getEnv("DEEPSEEK_API_URL", "https://api.deepseek.com/v1/chat/completions"),
 		DeepSeekModel:  getEnv("DEEPSEEK_MODEL", "deepseek-chat"),
 
-		OpenAIAPIKey: getEnv("OPENAI_API_KEY", ""),
-		OpenAIModel:  getEnv("OPENAI_MODEL", "gpt-4o-mini"),
+		OpenAIAPIKey:  getEnv("OPENAI_API_KEY", ""),
+		OpenAIModel:   getEnv("OPENAI_MODEL", "gpt-4o-mini"),
+		OpenAIBaseURL: strings.TrimSuffix(getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"), "/"),
 
 		FalAPIKey: getEnv("FAL_API_KEY", ""),
 
 		AITimeout: parseDuration(getEnv("AI_TIMEOUT", "60s")),
 
-		AdminEmails:  getEnv("ADMIN_EMAILS", ""),
-		AdminUserIDs: getEnv("ADMIN_USER_IDS", ""),
-		AdminToken:   getEnv("ADMIN_TOKEN", ""),
+		AdminToken:         getEnv("ADMIN_TOKEN", ""),
+		AdminSessionExpiry: parseDuration(getEnv("ADMIN_SESSION_EXPIRY", "12h")),
+		LegacyAdminEmails:  parseList(getEnv("ADMIN_EMAILS", "")),
+		LegacyAdminUserIDs: parseList(getEnv("ADMIN_USER_IDS", "")),
+		MFASecretKey:       getEnv("MFA_SECRET_KEY", getEnv("JWT_SECRET", "")),
+
+		AccountDeletionGrace: parseDuration(getEnv("ACCOUNT_DELETION_GRACE", "336h")),
+		OpenAPIPublic:        getEnv("OPENAPI_PUBLIC", "false") == "true",
 
 		Port:        getEnv("PORT", "8080"),
 		CORSOrigins: getEnv("CORS_ORIGINS", ""),
@@ -102,11 +161,28 @@ func Load() *Config {
 		AppleKeyID:     getEnv("APPLE_KEY_ID", ""),
 		ApplePrivateKey: getEnv("APPLE_PRIVATE_KEY", ""),
 
+		OIDCIssuers: parsePairs(getEnv("OIDC_ISSUERS", "")),
+
+		PushProvider:          getEnv("PUSH_PROVIDER", ""),
+		FCMServiceAccountJSON: getEnv("FCM_SERVICE_ACCOUNT_JSON", ""),
+
 		AppsConfigPath: getEnv("APPS_CONFIG_PATH", "apps.json"),
 
-		UploadsRoot: getEnv("UPLOADS_ROOT", "./uploads"),
+		UploadsRoot:  getEnv("UPLOADS_ROOT", "./uploads"),
+		MediaBaseURL: getEnv("MEDIA_BASE_URL", "https://api.vexellabspro.com"),
+
+		MailProvider: getEnv("MAIL_PROVIDER", ""),
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		MailFrom:     getEnv("MAIL_FROM", ""),
 
 		EmotionSenseMLURL: getEnv("EMOTION_SENSE_ML_URL", "http://89.47.113.196:8001"),
+
+		ModerationAutoHideThreshold: parseInt(getEnv("MODERATION_AUTO_HIDE_THRESHOLD", "3"), 3),
+		ModerationClassifierURL:     getEnv("MODERATION_CLASSIFIER_URL", ""),
+		ModerationClassifierKey:     getEnv("MODERATION_CLASSIFIER_KEY", ""),
 	}
 }
 
@@ -127,6 +203,37 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func parseInt(s string, fallback int) int {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return fallback
+	}
+	return n
+}
+
+// parseList parses "a,b,c", skipping empty entries.
+func parseList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// parsePairs parses "k1=v1,k2=v2"; malformed entries are skipped.
+func parsePairs(s string) map[string]string {
+	out := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && k != "" && v != "" {
+			out[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return out
+}
+
 func parseDuration(s string) time.Duration {
 	d, err := time.ParseDuration(s)
 	if err != nil {
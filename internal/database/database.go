@@ -38,8 +38,8 @@ func Connect(cfg *config.Config) error {
 }
 
 // MigrateShared runs AutoMigrate for shared models.
-func MigrateShared() error {
-	return DB.AutoMigrate(
+func MigrateShared(db *gorm.DB) error {
+	if err := db.AutoMigrate(
 		&models.User{},
 		&models.RefreshToken{},
 		&models.Subscription{},
@@ -47,20 +47,81 @@ func MigrateShared() error {
 		&models.Block{},
 		&models.SystemLog{},
 		&models.RemoteConfig{},
+		&models.RemoteConfigRule{},
+		&models.RemoteConfigRevision{},
 		&models.ProcessedWebhookEvent{},
-	)
+		&models.Experiment{},
+		&models.ExperimentAssignment{},
+		&models.ExperimentEvent{},
+		&models.RealtimeEvent{},
+		&models.DeviceToken{},
+		&models.PushNotification{},
+		&models.PushDelivery{},
+		&models.Streak{},
+		&models.UserAchievement{},
+		&models.OutboxMessage{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.ModerationAction{},
+		&models.UserBan{},
+		&models.AdminUser{},
+		&models.AdminRoleBinding{},
+		&models.AdminAPIKey{},
+		&models.AdminAuditLog{},
+		&models.UserIdentity{},
+		&models.UserTOTP{},
+		&models.MFARecoveryCode{},
+		&models.MFAChallenge{},
+		&models.WebAuthnCredential{},
+		&models.WebAuthnChallenge{},
+		&models.UserPreference{},
+		&models.EmailChangeRequest{},
+		&models.AccountDeletion{},
+		&models.DataMigration{},
+	); err != nil {
+		return err
+	}
+	if err := db.Exec(adminAuditAppendOnlySQL).Error; err != nil {
+		return err
+	}
+	return db.Exec(appleIdentityBackfillSQL).Error
 }
 
+// appleIdentityBackfillSQL links Apple accounts created before
+// user_identities existed, when the Apple ID lived on users.apple_user_id.
+const appleIdentityBackfillSQL = `
+INSERT INTO user_identities (app_id, user_id, provider, subject, email, created_at)
+SELECT app_id, id, 'apple', apple_user_id, email, created_at
+FROM users
+WHERE apple_user_id IS NOT NULL AND apple_user_id <> '' AND deleted_at IS NULL
+ON CONFLICT (app_id, provider, subject) DO NOTHING
+`
+
+// adminAuditAppendOnlySQL makes admin_audit_logs append-only, so the trail
+// survives a compromised admin credential or application bug.
+const adminAuditAppendOnlySQL = `
+CREATE OR REPLACE FUNCTION admin_audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'admin_audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS admin_audit_logs_append_only ON admin_audit_logs;
+CREATE TRIGGER admin_audit_logs_append_only
+	BEFORE UPDATE OR DELETE OR TRUNCATE ON admin_audit_logs
+	FOR EACH STATEMENT EXECUTE FUNCTION admin_audit_logs_append_only();
+`
+
 // MigrateModels runs AutoMigrate for arbitrary models (used by plugins).
-func MigrateModels(modelList []interface{}) error {
+func MigrateModels(db *gorm.DB, modelList []interface{}) error {
 	if len(modelList) == 0 {
 		return nil
 	}
-	return DB.AutoMigrate(modelList...)
+	return db.AutoMigrate(modelList...)
 }
 
-func Ping() error {
-	sqlDB, err := DB.DB()
+func Ping(db *gorm.DB) error {
+	sqlDB, err := db.DB()
 	if err != nil {
 		return err
 	}
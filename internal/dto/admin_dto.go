@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/rbac"
+)
+
+type AdminLoginRequest struct {
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type AdminLoginResponse struct {
+	Token     string           `json:"token"`
+	ExpiresAt time.Time        `json:"expires_at"`
+	Admin     models.AdminUser `json:"admin"`
+}
+
+// AdminResponse is an admin with their role bindings.
+type AdminResponse struct {
+	models.AdminUser
+	Roles []models.AdminRoleBinding `json:"roles"`
+}
+
+type CreateAdminRequest struct {
+	Email    string         `json:"email" validate:"required,email,max=254"`
+	Name     string         `json:"name" validate:"max=100"`
+	Password string         `json:"password" validate:"required,password"`
+	Roles    []rbac.Binding `json:"roles"`
+}
+
+// UpdateAdminRequest changes only the fields that are set.
+type UpdateAdminRequest struct {
+	Name     *string `json:"name" validate:"omitempty,max=100"`
+	Password *string `json:"password" validate:"omitempty,password"`
+	Disabled *bool   `json:"disabled"`
+	// ResetTOTP clears the admin's authenticator so they enrol a new one at
+	// their next login.
+	ResetTOTP bool `json:"reset_totp"`
+}
+
+// CreateAdminAPIKeyRequest issues a key acting for AdminID (the caller when
+// empty). Scopes and AppID optionally narrow the owner's permissions.
+type CreateAdminAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	AdminID   string     `json:"admin_id"`
+	Scopes    []string   `json:"scopes"`
+	AppID     string     `json:"app_id"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// AdminMeResponse describes the calling admin and what they may do in the
+// request's app.
+type AdminMeResponse struct {
+	ActorType   string            `json:"actor_type"`
+	AdminID     string            `json:"admin_id,omitempty"`
+	Email       string            `json:"email,omitempty"`
+	AppID       string            `json:"app_id"`
+	Roles       []rbac.Binding    `json:"roles"`
+	Permissions []rbac.Permission `json:"permissions"`
+}
@@ -1,19 +1,25 @@
 package dto
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type RegisterRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,password"`
+	// Timezone is the device's IANA zone; optional, defaults to UTC.
+	Timezone string `json:"timezone,omitempty"`
 }
 
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,max=254"`
+	Password string `json:"password" validate:"required,max=72"`
 }
 
 type RefreshRequest struct {
-	RefreshToken string `json:"refresh_token"`
+	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
 type LogoutRequest struct {
@@ -24,12 +30,24 @@ type AuthResponse struct {
 	AccessToken  string       `json:"access_token"`
 	RefreshToken string       `json:"refresh_token"`
 	User         UserResponse `json:"user"`
+	// Restored is set when signing in cancelled a pending account deletion.
+	Restored bool `json:"restored,omitempty"`
 }
 
 type UserResponse struct {
 	ID          uuid.UUID `json:"id"`
 	Email       string    `json:"email"`
 	IsAppleUser bool      `json:"is_apple_user"`
+	IsGuest     bool      `json:"is_guest"`
+	Timezone    string    `json:"timezone"`
+	DisplayName string    `json:"display_name"`
+	Locale      string    `json:"locale"`
+}
+
+// GuestRequest creates an anonymous account bound to the device.
+type GuestRequest struct {
+	DeviceID string `json:"device_id" validate:"required,max=140"`
+	Timezone string `json:"timezone,omitempty"`
 }
 
 type DeleteAccountRequest struct {
@@ -38,7 +56,7 @@ type DeleteAccountRequest struct {
 }
 
 type AppleSignInRequest struct {
-	IdentityToken string `json:"identity_token"`
+	IdentityToken string `json:"identity_token" validate:"required"`
 	AuthCode      string `json:"authorization_code"`
 	FullName      string `json:"full_name,omitempty"`
 	Email         string `json:"email,omitempty"`
@@ -46,10 +64,44 @@ type AppleSignInRequest struct {
 	// When provided, the backend verifies sha256(Nonce) == token.nonce_claim to prevent
 	// identity token replay attacks. Optional for backwards compatibility.
 	Nonce string `json:"nonce,omitempty"`
+	// Timezone is the device's IANA zone, used only when creating the account.
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// OIDCSignInRequest signs in with an ID token from Google or a configured
+// OpenID Connect provider.
+type OIDCSignInRequest struct {
+	IDToken string `json:"id_token" validate:"required"`
+	// Nonce is the raw nonce the client passed to the provider, if any.
+	Nonce string `json:"nonce,omitempty"`
+	// Timezone is the device's IANA zone, used only when creating the account.
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// LinkIdentityRequest links a provider account to the signed-in user.
+type LinkIdentityRequest struct {
+	IDToken string `json:"id_token" validate:"required"`
+	Nonce   string `json:"nonce,omitempty"`
 }
 
-type ErrorResponse struct {
-	Error   bool   `json:"error"`
+type IdentityResponse struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IdentitiesResponse lists the ways the user can sign in.
+type IdentitiesResponse struct {
+	Password   bool               `json:"password"`
+	Identities []IdentityResponse `json:"identities"`
+}
+
+// FieldError is one invalid request field. Field is the JSON path, e.g.
+// "items[2].name"; Param is the rule's argument, e.g. "8" for min=8.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Param   string `json:"param,omitempty"`
 	Message string `json:"message"`
 }
 
@@ -59,3 +111,20 @@ type HealthResponse struct {
 	DB        string `json:"db"`
 	AppCount  int    `json:"app_count"`
 }
+
+type UpdateTimezoneRequest struct {
+	Timezone string `json:"timezone" validate:"required,timezone"`
+}
+
+// DeletionReceiptResponse is the public view of an account deletion
+// receipt. It leaves out the email and user ID, so holding the receipt ID
+// is enough to check on a deletion.
+type DeletionReceiptResponse struct {
+	ID              uuid.UUID  `json:"id"`
+	Status          string     `json:"status"`
+	AppleRevocation string     `json:"apple_revocation,omitempty"`
+	RequestedAt     time.Time  `json:"requested_at"`
+	PurgeAfter      time.Time  `json:"purge_after"`
+	RestoredAt      *time.Time `json:"restored_at,omitempty"`
+	PurgedAt        *time.Time `json:"purged_at,omitempty"`
+}
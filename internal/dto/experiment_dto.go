@@ -0,0 +1,49 @@
+package dto
+
+import "github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+
+type ExperimentRequest struct {
+	Key         string                     `json:"key" validate:"required,max=100"`
+	Name        string                     `json:"name" validate:"max=200"`
+	Description string                     `json:"description" validate:"max=1000"`
+	ConfigKey   string                     `json:"config_key" validate:"max=100"`
+	GoalEvent   string                     `json:"goal_event" validate:"max=100"`
+	Variants    []models.ExperimentVariant `json:"variants" validate:"min=2"`
+	Conditions  models.RuleConditions      `json:"conditions"`
+}
+
+type ExperimentStatusRequest struct {
+	Status string `json:"status" validate:"required"`
+}
+
+type ExperimentExposureRequest struct {
+	ExperimentKey string `json:"experiment_key" validate:"required"`
+}
+
+type ExperimentEventRequest struct {
+	Event string `json:"event"`
+	// ExperimentKey limits the conversion to one experiment; empty matches every
+	// running experiment whose goal_event equals Event.
+	ExperimentKey string `json:"experiment_key,omitempty"`
+}
+
+type VariantReport struct {
+	Variant string `json:"variant"`
+	// Assigned counts enrolled subjects; subjects are enrolled on first exposure.
+	Assigned       int64   `json:"assigned"`
+	Exposed        int64   `json:"exposed"`
+	Converted      int64   `json:"converted"`
+	ConversionRate float64 `json:"conversion_rate"`
+	CILow          float64 `json:"ci_low"`
+	CIHigh         float64 `json:"ci_high"`
+	// Lift fields compare against the control (first) variant; omitted for the control itself.
+	DiffVsControl *float64 `json:"diff_vs_control,omitempty"`
+	DiffCILow     *float64 `json:"diff_ci_low,omitempty"`
+	DiffCIHigh    *float64 `json:"diff_ci_high,omitempty"`
+}
+
+type ExperimentReport struct {
+	Experiment      models.Experiment `json:"experiment"`
+	ConfidenceLevel float64           `json:"confidence_level"`
+	Variants        []VariantReport   `json:"variants"`
+}
@@ -0,0 +1,103 @@
+package dto
+
+import "time"
+
+// MFAChallengeResponse is returned by login when the account needs a second
+// factor. The client completes sign-in at POST /api/auth/2fa/verify, or with
+// a passkey assertion, passing MFAToken.
+type MFAChallengeResponse struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	// Methods lists what the account can use: "totp", "recovery_code" and
+	// "webauthn".
+	Methods []string `json:"methods"`
+}
+
+// MFAVerifyRequest completes a login with a TOTP code or a recovery code.
+type MFAVerifyRequest struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+// MFACodeRequest proves the second factor for changes to it.
+type MFACodeRequest struct {
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+type MFAStatusResponse struct {
+	TOTPEnabled            bool `json:"totp_enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+	Passkeys               int  `json:"passkeys"`
+}
+
+// TOTPEnrollmentResponse carries a new authenticator secret. URI is the
+// otpauth:// URI to show as a QR code.
+type TOTPEnrollmentResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// RecoveryCodesResponse shows recovery codes once; they cannot be read back.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// PasskeyOptionsResponse starts a WebAuthn ceremony. PublicKey is passed to
+// navigator.credentials.create() or .get() (binary fields base64url encoded);
+// ChallengeID is sent back with the result.
+type PasskeyOptionsResponse struct {
+	ChallengeID string      `json:"challenge_id"`
+	PublicKey   interface{} `json:"public_key"`
+}
+
+// PasskeyLoginBeginRequest starts a passkey sign-in. With MFAToken it is the
+// second step of a password login and only that user's passkeys are offered.
+type PasskeyLoginBeginRequest struct {
+	MFAToken string `json:"mfa_token,omitempty"`
+}
+
+// PasskeyCredential is a PublicKeyCredential serialised by the client, with
+// binary fields base64url encoded.
+type PasskeyCredential struct {
+	ID       string `json:"id" validate:"required"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"client_data_json" validate:"required"`
+		AttestationObject string `json:"attestation_object,omitempty"`
+		AuthenticatorData string `json:"authenticator_data,omitempty"`
+		Signature         string `json:"signature,omitempty"`
+		UserHandle        string `json:"user_handle,omitempty"`
+	} `json:"response"`
+}
+
+type PasskeyRegisterFinishRequest struct {
+	ChallengeID string            `json:"challenge_id" validate:"required"`
+	Name        string            `json:"name"`
+	Credential  PasskeyCredential `json:"credential"`
+}
+
+type PasskeyLoginFinishRequest struct {
+	ChallengeID string            `json:"challenge_id" validate:"required"`
+	Credential  PasskeyCredential `json:"credential"`
+}
+
+// AdminMFAChallengeResponse is the result of an admin password login. Every
+// admin session needs a TOTP code; EnrollmentRequired means the admin has no
+// authenticator yet and must call the enrol endpoint first.
+type AdminMFAChallengeResponse struct {
+	MFAToken           string    `json:"mfa_token"`
+	ExpiresAt          time.Time `json:"expires_at"`
+	EnrollmentRequired bool      `json:"enrollment_required"`
+}
+
+type AdminMFATokenRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+}
+
+type AdminMFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
@@ -1,18 +1,45 @@
 package dto
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type CreateReportRequest struct {
-	ContentType string `json:"content_type"`
+	ContentType string `json:"content_type" validate:"required,oneof=user post comment"`
 	ContentID   string `json:"content_id"`
-	Reason      string `json:"reason"`
+	Reason      string `json:"reason" validate:"required,max=500"`
 }
 
 type ActionReportRequest struct {
-	Status    string `json:"status"`
+	Status    string `json:"status" validate:"required,oneof=reviewed actioned dismissed"`
 	AdminNote string `json:"admin_note"`
+	// Actions apply when Status is "actioned": hide_content, suspend_user,
+	// shadow_ban. SuspendDays defaults to 7.
+	Actions     []string `json:"actions" validate:"dive,oneof=hide_content suspend_user shadow_ban"`
+	SuspendDays int      `json:"suspend_days" validate:"gte=0"`
+}
+
+// MyReportResponse is a report as shown to the user who filed it.
+type MyReportResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	ContentType string     `json:"content_type"`
+	ContentID   string     `json:"content_id"`
+	Reason      string     `json:"reason"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
 }
 
 type BlockUserRequest struct {
-	BlockedID uuid.UUID `json:"blocked_id"`
+	BlockedID uuid.UUID `json:"blocked_id" validate:"required"`
+}
+
+// BanUserRequest suspends a user from the app. Set ExpiresAt or DurationDays
+// for a temporary ban; with neither, the ban is permanent.
+type BanUserRequest struct {
+	Reason       string     `json:"reason" validate:"required,max=1000"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	DurationDays int        `json:"duration_days" validate:"gte=0"`
 }
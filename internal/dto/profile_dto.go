@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ProfileResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Email       string    `json:"email"`
+	DisplayName string    `json:"display_name"`
+	AvatarURL   string    `json:"avatar_url"`
+	Locale      string    `json:"locale"`
+	Timezone    string    `json:"timezone"`
+	BirthYear   *int      `json:"birth_year"`
+	IsGuest     bool      `json:"is_guest"`
+	// PendingEmail is an address awaiting confirmation by POST
+	// /api/auth/email/verify.
+	PendingEmail string    `json:"pending_email,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// UpdateProfileRequest changes only the fields present. An empty locale
+// resets it to the app's default language and a birth_year of 0 clears it.
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name" validate:"omitempty,max=100"`
+	Locale      *string `json:"locale" validate:"omitempty,locale"`
+	Timezone    *string `json:"timezone" validate:"timezone"`
+	BirthYear   *int    `json:"birth_year"`
+}
+
+// ChangeEmailRequest starts an email change. Password is required for
+// accounts that sign in with one.
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" validate:"required,email,max=254"`
+	Password string `json:"password,omitempty"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -0,0 +1,19 @@
+package dto
+
+import "github.com/google/uuid"
+
+type RegisterDeviceRequest struct {
+	Token    string `json:"token" validate:"required,max=512"`
+	Platform string `json:"platform" validate:"required"` // ios, android
+	// Environment is sandbox for development iOS builds; defaults to production.
+	Environment string `json:"environment"`
+}
+
+type SendPushRequest struct {
+	UserID uuid.UUID         `json:"user_id" validate:"required"`
+	Title  string            `json:"title" validate:"required"`
+	Body   string            `json:"body"`
+	Data   map[string]string `json:"data"`
+	// Marketing sends are skipped for users who opted out of them.
+	Marketing bool `json:"marketing"`
+}
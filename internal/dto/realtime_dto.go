@@ -0,0 +1,8 @@
+package dto
+
+type AnnouncementRequest struct {
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	// Level is a display hint for clients: info, warning or critical.
+	Level string `json:"level" validate:"omitempty,oneof=info warning critical"`
+}
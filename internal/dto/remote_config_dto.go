@@ -0,0 +1,23 @@
+package dto
+
+import "github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+
+type RemoteConfigRuleRequest struct {
+	Name           string                `json:"name" validate:"max=100"`
+	Priority       int                   `json:"priority"`
+	Conditions     models.RuleConditions `json:"conditions"`
+	RolloutPercent *int                  `json:"rollout_percent" validate:"omitempty,gte=0,lte=100"`
+	// Value replaces the key's value, so it must satisfy the key's type and
+	// schema. Type may be omitted; when sent it must be the key's type.
+	Value   string `json:"value"`
+	Type    string `json:"type"`
+	Enabled *bool  `json:"enabled"`
+}
+
+type SetConfigRequest struct {
+	Value string `json:"value" validate:"required"`
+	Type  string `json:"type"`
+	// Schema is an optional JSON Schema for json values. Omit to keep the
+	// current schema; send "" to remove it.
+	Schema *string `json:"schema"`
+}
@@ -25,3 +25,20 @@ type RevenueCatEvent struct {
 	Price                    float64  `json:"price"`
 	PriceInPurchasedCurrency float64  `json:"price_in_purchased_currency"`
 }
+
+// CreateWebhookEndpointRequest registers an outbound webhook. Events lists the
+// event types to send; empty means all.
+type CreateWebhookEndpointRequest struct {
+	URL         string   `json:"url" validate:"required"`
+	Description string   `json:"description"`
+	Events      []string `json:"events"`
+}
+
+// UpdateWebhookEndpointRequest changes an outbound webhook; nil fields are
+// left as they are.
+type UpdateWebhookEndpointRequest struct {
+	URL         *string   `json:"url"`
+	Description *string   `json:"description"`
+	Events      *[]string `json:"events"`
+	Active      *bool     `json:"active"`
+}
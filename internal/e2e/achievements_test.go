@@ -0,0 +1,69 @@
+//go:build integration
+
+package e2e
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/achievements"
+)
+
+func TestAchievementUnlocksOnce(t *testing.T) {
+	u := h.NewUser(t, "daiyly")
+	engine := achievements.New(h.DB, []achievements.Definition{
+		{Key: "e2e_first", Event: "e2e.count", Threshold: 1, XP: 60},
+		{Key: "e2e_fifth", Event: "e2e.count", Threshold: 5, XP: 60},
+	})
+	var published atomic.Int32
+	achievements.OnUnlock(func(un achievements.Unlock) {
+		if un.UserID == u.ID {
+			published.Add(1)
+		}
+	})
+
+	if got, err := engine.Emit("daiyly", u.ID, "e2e.count", 0); err != nil || len(got) != 0 {
+		t.Fatalf("below every threshold = %v, %v; want nothing", got, err)
+	}
+	got, err := engine.Emit("daiyly", u.ID, "e2e.count", 1)
+	if err != nil || len(got) != 1 || got[0].Key != "e2e_first" {
+		t.Fatalf("first emit = %v, %v; want e2e_first", got, err)
+	}
+
+	// Re-emitting, concurrently too, hits the unique constraint and unlocks
+	// only what is new.
+	var wg sync.WaitGroup
+	var unlocked atomic.Int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := engine.Emit("daiyly", u.ID, "e2e.count", 5)
+			if err != nil {
+				t.Error(err)
+			}
+			unlocked.Add(int32(len(got)))
+		}()
+	}
+	wg.Wait()
+	if n := unlocked.Load(); n != 1 {
+		t.Fatalf("concurrent re-emits unlocked %d achievements, want 1", n)
+	}
+	if n := published.Load(); n != 2 {
+		t.Fatalf("OnUnlock fired %d times, want 2", n)
+	}
+
+	sum, err := engine.Summary("daiyly", u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.XP != 120 || sum.Level != 2 || sum.LevelXP != 100 || sum.NextLevelXP != 300 {
+		t.Fatalf("summary = %+v, want 120 XP at level 2", sum)
+	}
+	for _, st := range sum.Achievements {
+		if !st.Unlocked || st.UnlockedAt == nil {
+			t.Fatalf("%s is not unlocked", st.Key)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+//go:build integration
+
+package e2e
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/rbac"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+)
+
+func TestSeedLegacyAdminsRunsOnce(t *testing.T) {
+	global := h.NewUser(t, "daiyly")
+	appAdmin := h.NewUser(t, "moodpulse")
+	if err := h.DB.Model(&models.User{}).Where("id = ?", appAdmin.ID).Update("role", "admin").Error; err != nil {
+		t.Fatal(err)
+	}
+	// The harness seeded on start; run it again as an upgraded instance would.
+	if err := h.DB.Where("name = ?", "admins.seed_legacy").Delete(&models.DataMigration{}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := *h.Config
+	cfg.LegacyAdminEmails = []string{strings.ToUpper(global.Email)}
+	admins := services.NewAdminService(h.DB, &cfg)
+	n, err := admins.SeedLegacyAdmins()
+	if err != nil || n != 2 {
+		t.Fatalf("seed = %d, %v; want 2 admins", n, err)
+	}
+
+	bindings := func(email string) []rbac.Binding {
+		t.Helper()
+		var admin models.AdminUser
+		if err := h.DB.Where("email = ?", strings.ToLower(email)).First(&admin).Error; err != nil {
+			t.Fatalf("no admin for %s: %v", email, err)
+		}
+		var rows []models.AdminRoleBinding
+		h.DB.Where("admin_user_id = ?", admin.ID).Find(&rows)
+		out := make([]rbac.Binding, 0, len(rows))
+		for _, r := range rows {
+			out = append(out, rbac.Binding{AppID: r.AppID, Role: r.Role})
+		}
+		return out
+	}
+	if got := bindings(global.Email); len(got) != 1 || got[0] != (rbac.Binding{AppID: rbac.AllApps, Role: "owner"}) {
+		t.Errorf("ADMIN_EMAILS admin bindings = %v, want owner on every app", got)
+	}
+	if got := bindings(appAdmin.Email); len(got) != 1 || got[0] != (rbac.Binding{AppID: "moodpulse", Role: "owner"}) {
+		t.Errorf("role admin bindings = %v, want owner on moodpulse", got)
+	}
+
+	// The end-user password carries over; login stops at the TOTP step.
+	h.Client("daiyly").Post(t, "/api/admin/auth/login", map[string]string{
+		"email":    appAdmin.Email,
+		"password": appAdmin.Password,
+	}).Expect(t, 200)
+
+	n, err = admins.SeedLegacyAdmins()
+	if err != nil || n != 0 {
+		t.Fatalf("second seed = %d, %v; want nothing", n, err)
+	}
+}
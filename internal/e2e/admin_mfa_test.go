@@ -0,0 +1,78 @@
+//go:build integration
+
+package e2e
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// totpCode computes the RFC 6238 code an authenticator shows for secret.
+func totpCode(secret string, at time.Time) string {
+	key, _ := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(at.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+func TestAdminTOTPLocksAfterRepeatedInvalidCodes(t *testing.T) {
+	bootstrap := h.Client("daiyly")
+	bootstrap.Header.Set("X-Admin-Token", h.Config.AdminToken)
+	email := "admin-" + uuid.NewString()[:8] + "@harness.test"
+	bootstrap.Post(t, "/api/admin/admins", map[string]interface{}{
+		"email":    email,
+		"password": "Harness-Passw0rd",
+		"roles":    []map[string]string{{"app_id": "daiyly", "role": "analyst"}},
+	}).Expect(t, 201)
+
+	// Each step uses its own client so the per-IP admin limiter stays out
+	// of the way; the lockout is per admin, not per address.
+	login := func() string {
+		var challenge struct {
+			MFAToken string `json:"mfa_token"`
+		}
+		h.Client("daiyly").Post(t, "/api/admin/auth/login", map[string]string{
+			"email":    email,
+			"password": "Harness-Passw0rd",
+		}).Expect(t, 200).Decode(t, &challenge)
+		return challenge.MFAToken
+	}
+	verify := func(token, code string) int {
+		return h.Client("daiyly").Post(t, "/api/admin/auth/totp/verify", map[string]string{
+			"mfa_token": token,
+			"code":      code,
+		}).Status
+	}
+
+	token := login()
+	var enrollment struct {
+		Secret string `json:"secret"`
+	}
+	h.Client("daiyly").Post(t, "/api/admin/auth/totp/enroll", map[string]string{"mfa_token": token}).Expect(t, 200).Decode(t, &enrollment)
+
+	wrong := "000000"
+	if totpCode(enrollment.Secret, time.Now()) == wrong {
+		wrong = "999999"
+	}
+	for i := 0; i < 5; i++ {
+		if status := verify(token, wrong); status != 401 {
+			t.Fatalf("wrong code %d: status %d, want 401", i+1, status)
+		}
+	}
+	// A fresh MFA token does not reset the count, and neither does the
+	// right code.
+	if status := verify(login(), totpCode(enrollment.Secret, time.Now())); status != 429 {
+		t.Fatalf("correct code after 5 wrong ones: status %d, want 429", status)
+	}
+}
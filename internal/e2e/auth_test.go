@@ -0,0 +1,169 @@
+//go:build integration
+
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/testharness"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type authResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	User         struct {
+		ID      uuid.UUID `json:"id"`
+		Email   string    `json:"email"`
+		IsGuest bool      `json:"is_guest"`
+	} `json:"user"`
+}
+
+func TestAuthPasswordLifecycle(t *testing.T) {
+	u := h.NewUser(t, "daiyly")
+	c := h.Client("daiyly")
+
+	c.Post(t, "/api/auth/register", map[string]string{"email": u.Email, "password": u.Password}).Expect(t, 409)
+	c.Post(t, "/api/auth/login", map[string]string{"email": u.Email, "password": "Wrong-Passw0rd"}).Expect(t, 401)
+
+	var login authResponse
+	c.Post(t, "/api/auth/login", map[string]string{"email": u.Email, "password": u.Password}).Expect(t, 200).Decode(t, &login)
+	if login.User.ID != u.ID {
+		t.Fatalf("login returned user %s, registered %s", login.User.ID, u.ID)
+	}
+
+	me := c.WithToken(login.AccessToken).Get(t, "/api/me").Expect(t, 200).Map(t)
+	if me["email"] != u.Email {
+		t.Fatalf("GET /api/me email = %v, want %s", me["email"], u.Email)
+	}
+
+	var refreshed authResponse
+	c.Post(t, "/api/auth/refresh", map[string]string{"refresh_token": login.RefreshToken}).Expect(t, 200).Decode(t, &refreshed)
+	if refreshed.AccessToken == "" || refreshed.RefreshToken == login.RefreshToken {
+		t.Fatalf("refresh did not rotate tokens: %+v", refreshed)
+	}
+
+	c.WithToken(refreshed.AccessToken).Post(t, "/api/auth/logout", map[string]string{"refresh_token": refreshed.RefreshToken}).Expect(t, 200)
+	c.Post(t, "/api/auth/refresh", map[string]string{"refresh_token": refreshed.RefreshToken}).Expect(t, 401)
+}
+
+func TestAuthRejectsBadTokens(t *testing.T) {
+	u := h.NewUser(t, "driftoff")
+
+	h.Client("driftoff").Get(t, "/api/me").Expect(t, 401)
+	h.Client("driftoff").WithToken("not-a-jwt").Get(t, "/api/me").Expect(t, 401)
+
+	// Tokens are scoped to the app that issued them.
+	h.Client("moodpulse").WithToken(u.AccessToken).Get(t, "/api/me").Expect(t, 403)
+
+	expired := h.SignToken(jwt.MapClaims{
+		"sub":    u.ID.String(),
+		"email":  u.Email,
+		"app_id": "driftoff",
+		"iat":    time.Now().Add(-time.Hour).Unix(),
+		"exp":    time.Now().Add(-time.Minute).Unix(),
+	})
+	h.Client("driftoff").WithToken(expired).Get(t, "/api/me").Expect(t, 401)
+
+	// A token minted by the harness is as good as one from login.
+	h.Client("driftoff").WithToken(h.Token("driftoff", u.ID)).Get(t, "/api/me").Expect(t, 200)
+}
+
+func TestAuthGuest(t *testing.T) {
+	var guest authResponse
+	h.Client("lucky_draw").Post(t, "/api/auth/guest", map[string]string{
+		"device_id": "device-" + uuid.NewString(),
+	}).Expect(t, 201).Decode(t, &guest)
+	if !guest.User.IsGuest {
+		t.Fatalf("guest sign-up returned a full account: %+v", guest.User)
+	}
+
+	me := h.Client("lucky_draw").WithToken(guest.AccessToken).Get(t, "/api/me").Expect(t, 200).Map(t)
+	if me["is_guest"] != true {
+		t.Fatalf("GET /api/me is_guest = %v, want true", me["is_guest"])
+	}
+}
+
+func TestAuthOIDC(t *testing.T) {
+	subject := uuid.NewString()
+	email := subject + "@oidc.harness.test"
+	signIn := func(aud string) *testharness.Response {
+		token, err := h.OIDC.Token(subject, aud, map[string]interface{}{"email": email, "email_verified": true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h.Client("moodpulse").Post(t, "/api/auth/oidc/"+testharness.OIDCProvider, map[string]string{"id_token": token})
+	}
+
+	var first, second authResponse
+	signIn(testharness.OIDCClientID("moodpulse")).Expect(t, 200).Decode(t, &first)
+	if first.User.Email != email {
+		t.Fatalf("OIDC sign-in email = %q, want %q", first.User.Email, email)
+	}
+	signIn(testharness.OIDCClientID("moodpulse")).Expect(t, 200).Decode(t, &second)
+	if second.User.ID != first.User.ID {
+		t.Fatalf("second OIDC sign-in created user %s, want %s", second.User.ID, first.User.ID)
+	}
+
+	// Another app's audience is not accepted.
+	signIn(testharness.OIDCClientID("daiyly")).Expect(t, 401)
+}
+
+func TestAuthOIDCLinkAndUnlink(t *testing.T) {
+	const path = "/api/auth/identities/" + testharness.OIDCProvider
+	token := func(subject, email string) string {
+		tok, err := h.OIDC.Token(subject, testharness.OIDCClientID("moodpulse"), map[string]interface{}{"email": email, "email_verified": true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+	signIn := func(subject, email string) *testharness.Response {
+		return h.Client("moodpulse").Post(t, "/api/auth/oidc/"+testharness.OIDCProvider, map[string]string{"id_token": token(subject, email)})
+	}
+
+	// A password account never proved it owns its email, so a provider
+	// sign-in with that address must not take it over.
+	u := h.NewUser(t, "moodpulse")
+	subject := uuid.NewString()
+	signIn(subject, u.Email).Expect(t, 409)
+
+	c := h.As(u)
+	c.Post(t, path, map[string]string{"id_token": token(subject, u.Email)}).Expect(t, 200)
+	c.Post(t, path, map[string]string{"id_token": token(subject, u.Email)}).Expect(t, 200)
+	c.Post(t, path, map[string]string{"id_token": token(uuid.NewString(), u.Email)}).Expect(t, 409)
+	c.Post(t, path, map[string]string{"id_token": "not-a-token"}).Expect(t, 401)
+
+	var ids struct {
+		Password   bool `json:"password"`
+		Identities []struct {
+			Provider string `json:"provider"`
+			Email    string `json:"email"`
+		} `json:"identities"`
+	}
+	c.Get(t, "/api/auth/identities").Expect(t, 200).Decode(t, &ids)
+	if !ids.Password || len(ids.Identities) != 1 || ids.Identities[0].Provider != testharness.OIDCProvider || ids.Identities[0].Email != u.Email {
+		t.Fatalf("identities = %+v, want the password and the linked provider", ids)
+	}
+
+	var resp authResponse
+	signIn(subject, u.Email).Expect(t, 200).Decode(t, &resp)
+	if resp.User.ID != u.ID {
+		t.Fatalf("sign-in with the linked identity = user %s, want %s", resp.User.ID, u.ID)
+	}
+
+	// The provider account belongs to u now.
+	other := h.NewUser(t, "moodpulse")
+	h.As(other).Post(t, path, map[string]string{"id_token": token(subject, other.Email)}).Expect(t, 409)
+
+	c.Delete(t, path).Expect(t, 200)
+	c.Delete(t, path).Expect(t, 404)
+	signIn(subject, u.Email).Expect(t, 409)
+
+	// An account created by the provider has no password to fall back on.
+	var created authResponse
+	signIn(uuid.NewString(), uuid.NewString()+"@oidc.harness.test").Expect(t, 200).Decode(t, &created)
+	h.Client("moodpulse").WithToken(created.AccessToken).Delete(t, path).Expect(t, 409)
+}
@@ -0,0 +1,21 @@
+//go:build integration
+
+package e2e
+
+import "testing"
+
+func TestBanRevokesAccessImmediately(t *testing.T) {
+	u := h.NewUser(t, "moodpulse")
+	c := h.As(u)
+	admin := h.Admin(t, "moodpulse")
+	path := "/api/admin/users/" + u.ID.String() + "/ban"
+
+	c.Get(t, "/api/me").Expect(t, 200)
+	admin.Post(t, path, map[string]interface{}{"reason": "spam", "duration_days": 1}).Expect(t, 201)
+	// The access token is still valid; the denylist rejects it.
+	c.Get(t, "/api/me").Expect(t, 403)
+
+	admin.Delete(t, path).Expect(t, 200)
+	c.Get(t, "/api/me").Expect(t, 200)
+	admin.Delete(t, path).Expect(t, 404)
+}
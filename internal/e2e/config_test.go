@@ -0,0 +1,98 @@
+//go:build integration
+
+package e2e
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRemoteConfig(t *testing.T) {
+	key := "e2e_flag_" + uuid.NewString()[:8]
+	path := "/api/admin/config/" + key
+	get := func(platform string) map[string]interface{} {
+		t.Helper()
+		c := h.Client("moodpulse")
+		if platform != "" {
+			c.Header.Set("X-Platform", platform)
+		}
+		return c.Get(t, "/api/config").Expect(t, 200).Map(t)
+	}
+
+	h.Admin(t, "moodpulse").Put(t, path, map[string]string{"value": "false", "type": "bool"}).Expect(t, 200)
+	if v := get("")[key]; v != false {
+		t.Fatalf("%s = %v, want false", key, v)
+	}
+
+	// Unchanged config is served from the client's cache.
+	resp := h.Client("moodpulse").Get(t, "/api/config").Expect(t, 200)
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("GET /api/config sent no ETag")
+	}
+	cached := h.Client("moodpulse")
+	cached.Header.Set("If-None-Match", etag)
+	cached.Get(t, "/api/config").Expect(t, 304)
+
+	h.Admin(t, "moodpulse").Put(t, path, map[string]string{"value": "true", "type": "bool"}).Expect(t, 200)
+	if v := get("")[key]; v != true {
+		t.Fatalf("%s after update = %v, want true", key, v)
+	}
+	cached.Get(t, "/api/config").Expect(t, 200)
+
+	// Config is per app.
+	if v, ok := h.Client("daiyly").Get(t, "/api/config").Expect(t, 200).Map(t)[key]; ok {
+		t.Fatalf("daiyly sees moodpulse's %s = %v", key, v)
+	}
+
+	var history struct {
+		Revisions []struct {
+			Revision int    `json:"revision"`
+			Action   string `json:"action"`
+		} `json:"revisions"`
+		Total int64 `json:"total"`
+	}
+	h.Admin(t, "moodpulse").Get(t, path+"/history").Expect(t, 200).Decode(t, &history)
+	if history.Total != 2 {
+		t.Fatalf("history = %+v, want 2 revisions", history)
+	}
+
+	h.Admin(t, "moodpulse").Post(t, path+"/rollback/1", nil).Expect(t, 200)
+	if v := get("")[key]; v != false {
+		t.Fatalf("%s after rollback = %v, want false", key, v)
+	}
+
+	h.Admin(t, "moodpulse").Post(t, path+"/rules", map[string]interface{}{
+		"name":       "iOS only",
+		"conditions": map[string]interface{}{"platforms": []string{"ios"}},
+		"value":      "true",
+		"type":       "bool",
+		"enabled":    true,
+	}).Expect(t, 201)
+	if v := get("ios")[key]; v != true {
+		t.Fatalf("%s on ios = %v, want true", key, v)
+	}
+	if v := get("android")[key]; v != false {
+		t.Fatalf("%s on android = %v, want false", key, v)
+	}
+
+	// Rules must fit the key's type, and are part of its history.
+	h.Admin(t, "moodpulse").Post(t, path+"/rules", map[string]string{"value": "maybe"}).Expect(t, 400)
+	h.Admin(t, "moodpulse").Post(t, path+"_missing/rules", map[string]string{"value": "true"}).Expect(t, 404)
+	h.Admin(t, "moodpulse").Get(t, path+"/history").Expect(t, 200).Decode(t, &history)
+	if history.Total != 4 || history.Revisions[0].Action != "rule_create" {
+		t.Fatalf("history = %+v, want the rule as revision 4", history)
+	}
+
+	h.Admin(t, "moodpulse").Delete(t, path).Expect(t, 200)
+	if v, ok := get("")[key]; ok {
+		t.Fatalf("%s after delete = %v, want absent", key, v)
+	}
+}
+
+func TestRemoteConfigRequiresAdmin(t *testing.T) {
+	u := h.NewUser(t, "moodpulse")
+	h.As(u).Put(t, "/api/admin/config/e2e_forbidden", map[string]string{"value": "1"}).Expect(t, 401)
+	h.Client("moodpulse").Get(t, "/api/admin/config/e2e_forbidden/history").Expect(t, 401)
+}
@@ -0,0 +1,104 @@
+//go:build integration
+
+package e2e
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/daiyly"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/testharness"
+)
+
+func TestDaiylyJournalCRUD(t *testing.T) {
+	u := h.NewUser(t, "daiyly")
+	c := h.As(u)
+
+	h.Client("daiyly").Get(t, "/api/p/journals").Expect(t, 401)
+
+	var invalid struct {
+		Code   string `json:"code"`
+		Fields []struct {
+			Field string `json:"field"`
+		} `json:"fields"`
+	}
+	c.Post(t, "/api/p/journals", map[string]interface{}{"mood_emoji": "🦄", "mood_score": 50}).Expect(t, 400).Decode(t, &invalid)
+	if invalid.Code != "validation_failed" || len(invalid.Fields) != 1 || invalid.Fields[0].Field != "mood_emoji" {
+		t.Fatalf("invalid mood error = %+v, want validation_failed on mood_emoji", invalid)
+	}
+
+	var entry daiyly.JournalEntry
+	c.Post(t, "/api/p/journals", map[string]interface{}{
+		"mood_emoji": "😊",
+		"mood_score": 80,
+		"content":    "Grateful for a long walk with my family today.",
+	}).Expect(t, 201).Decode(t, &entry)
+	if entry.UserID != u.ID || entry.CardColor != "#dbeafe" {
+		t.Fatalf("created entry = %+v", entry)
+	}
+	id := entry.ID.String()
+
+	var list daiyly.JournalListResponse
+	c.Get(t, "/api/p/journals").Expect(t, 200).Decode(t, &list)
+	if list.Total != 1 || len(list.Entries) != 1 || list.Entries[0].ID != entry.ID {
+		t.Fatalf("list = %+v, want the one entry", list)
+	}
+
+	c.Put(t, "/api/p/journals/"+id, map[string]interface{}{"mood_score": 60}).Expect(t, 200).Decode(t, &entry)
+	if entry.MoodScore != 60 {
+		t.Fatalf("updated mood_score = %d, want 60", entry.MoodScore)
+	}
+	c.Put(t, "/api/p/journals/"+id, map[string]interface{}{"mood_score": 101}).Expect(t, 400)
+
+	// Entries are private to their author.
+	other := h.NewUser(t, "daiyly")
+	h.As(other).Get(t, "/api/p/journals/"+id).Expect(t, 403)
+	h.As(other).Delete(t, "/api/p/journals/"+id).Expect(t, 403)
+
+	c.Delete(t, "/api/p/journals/"+id).Expect(t, 200)
+	c.Get(t, "/api/p/journals/"+id).Expect(t, 404)
+}
+
+func TestDaiylyEntryAnalysis(t *testing.T) {
+	h.AI.Reply("compassionate journal analyst",
+		`{"themes":["gratitude","family"],"sentiment_label":"positive","sentiment_score":0.8,"cognitive_patterns":[],"insight":"Time outdoors with family lifts you."}`)
+	h.EmotionSense.SetDominant("joy")
+
+	u := h.NewUser(t, "daiyly")
+	c := h.As(u)
+	var entry daiyly.JournalEntry
+	c.Post(t, "/api/p/journals", map[string]interface{}{
+		"mood_emoji": "🥳",
+		"mood_score": 90,
+		"content":    "Walked by the sea with my sister and laughed all afternoon.",
+	}).Expect(t, 201).Decode(t, &entry)
+
+	// Both analyses run in the background after the entry is saved.
+	testharness.Eventually(t, 10*time.Second, "entry analysis", func() bool {
+		var a daiyly.EntryAnalysis
+		return h.DB.Where("entry_id = ? AND status <> ?", entry.ID, "pending").First(&a).Error == nil
+	})
+	testharness.Eventually(t, 10*time.Second, "emotion analysis", func() bool {
+		var e daiyly.JournalEntry
+		return h.DB.Where("id = ? AND detected_emotion <> ''", entry.ID).First(&e).Error == nil
+	})
+
+	var analysis daiyly.EntryAnalysisResponse
+	c.Get(t, "/api/p/journals/"+entry.ID.String()+"/analysis").Expect(t, 200).Decode(t, &analysis)
+	if analysis.Status != "completed" || analysis.SentimentLabel != "positive" || len(analysis.Themes) != 2 {
+		t.Fatalf("analysis = %+v, want the fake's completed analysis", analysis)
+	}
+	c.Get(t, "/api/p/journals/"+entry.ID.String()).Expect(t, 200).Decode(t, &entry)
+	if entry.DetectedEmotion != "joy" {
+		t.Fatalf("detected_emotion = %q, want joy", entry.DetectedEmotion)
+	}
+
+	found := false
+	for _, r := range h.AI.Requests("/chat/completions") {
+		found = found || strings.Contains(r.Prompt, "Walked by the sea")
+	}
+	if !found {
+		t.Fatal("the entry was not sent to the AI provider")
+	}
+}
@@ -0,0 +1,8 @@
+// Package e2e holds end-to-end tests that drive the whole API through
+// internal/testharness. They are built with the integration tag:
+//
+//	go test -tags integration ./internal/e2e/...
+//
+// Without a database to run against (see testharness.StartPostgres) the
+// suite is skipped.
+package e2e
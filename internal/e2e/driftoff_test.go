@@ -0,0 +1,101 @@
+//go:build integration
+
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/driftoff"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/testharness"
+)
+
+func sleepSession(score int, night time.Time) map[string]interface{} {
+	bedtime := time.Date(night.Year(), night.Month(), night.Day(), 23, 0, 0, 0, time.UTC)
+	return map[string]interface{}{
+		"score":            score,
+		"duration_minutes": 450,
+		"efficiency":       92.5,
+		"latency_minutes":  12,
+		"bedtime":          bedtime.Format(time.RFC3339),
+		"wake_time":        bedtime.Add(450 * time.Minute).Format(time.RFC3339),
+	}
+}
+
+func TestDriftoffSleepCRUD(t *testing.T) {
+	u := h.NewUser(t, "driftoff")
+	c := h.As(u)
+	lastNight := time.Now().UTC().AddDate(0, 0, -1)
+
+	h.Client("driftoff").Post(t, "/api/p/sleeps", sleepSession(80, lastNight)).Expect(t, 401)
+	c.Post(t, "/api/p/sleeps", sleepSession(101, lastNight)).Expect(t, 400)
+	bad := sleepSession(80, lastNight)
+	bad["bedtime"] = "last night"
+	c.Post(t, "/api/p/sleeps", bad).Expect(t, 400)
+
+	var session driftoff.SleepResponse
+	c.Post(t, "/api/p/sleeps", sleepSession(82, lastNight)).Expect(t, 201).Decode(t, &session)
+	if session.Score != 82 || session.DurationMinutes != 450 {
+		t.Fatalf("created session = %+v", session)
+	}
+	c.Post(t, "/api/p/sleeps", sleepSession(64, lastNight.AddDate(0, 0, -1))).Expect(t, 201)
+
+	var list driftoff.SleepListResponse
+	c.Get(t, "/api/p/sleeps?limit=1").Expect(t, 200).Decode(t, &list)
+	if list.Total != 2 || len(list.Sessions) != 1 || list.Limit != 1 {
+		t.Fatalf("list = %+v, want 1 of 2 sessions", list)
+	}
+
+	id := session.ID.String()
+	c.Put(t, "/api/p/sleeps/"+id, map[string]interface{}{"score": 90}).Expect(t, 200).Decode(t, &session)
+	if session.Score != 90 {
+		t.Fatalf("updated score = %d, want 90", session.Score)
+	}
+
+	// Sessions are private to their owner.
+	other := h.NewUser(t, "driftoff")
+	h.As(other).Get(t, "/api/p/sleeps/"+id).Expect(t, 404)
+
+	c.Delete(t, "/api/p/sleeps/"+id).Expect(t, 200)
+	c.Get(t, "/api/p/sleeps/"+id).Expect(t, 404)
+}
+
+func TestDriftoffBatchImportCountsTowardsStreak(t *testing.T) {
+	u := h.NewUser(t, "driftoff")
+	c := h.As(u)
+
+	sessions := make([]map[string]interface{}, 2)
+	for i := range sessions {
+		sessions[i] = sleepSession(75, time.Now().UTC().AddDate(0, 0, -i-1))
+		sessions[i]["client_id"] = string(rune('a' + i))
+	}
+	var resp driftoff.BatchImportResponse
+	c.Post(t, "/api/p/sleeps/batch", map[string]interface{}{"sessions": sessions}).Expect(t, 201).Decode(t, &resp)
+	if resp.Imported != 2 {
+		t.Fatalf("import = %+v, want both sessions imported", resp)
+	}
+
+	// Imported sessions go through the same sleep.session_logged subscriber.
+	testharness.Eventually(t, 10*time.Second, "sleep streak", func() bool {
+		var streak driftoff.StreakResponse
+		c.Get(t, "/api/p/sleeps/streak").Expect(t, 200).Decode(t, &streak)
+		return streak.TotalSessions == 2 && streak.CurrentStreak == 1
+	})
+}
+
+func TestDriftoffSleepCoach(t *testing.T) {
+	const advice = "Keep your bedtime within 30 minutes of 23:00 this week."
+	h.AI.Reply("expert sleep coach", advice)
+
+	u := h.NewUser(t, "driftoff")
+	c := h.As(u)
+	c.Post(t, "/api/p/sleeps", sleepSession(70, time.Now().UTC().AddDate(0, 0, -1))).Expect(t, 201)
+
+	var coach struct {
+		Message string `json:"message"`
+	}
+	c.Get(t, "/api/p/sleeps/coach").Expect(t, 200).Decode(t, &coach)
+	if coach.Message != advice {
+		t.Fatalf("coach message = %q, want the fake's reply", coach.Message)
+	}
+}
@@ -0,0 +1,103 @@
+//go:build integration
+
+package e2e
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/testharness"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestSyncSubscriberErrorRollsBackPublisher(t *testing.T) {
+	bus := h.Server.Events
+	eventType := "e2e.rollback." + uuid.NewString()[:8]
+	marker := func(name string) string { return eventType + ":" + name }
+
+	// The first handler writes through the publisher's transaction; the
+	// second fails, which must undo it along with the publisher's own write.
+	bus.Subscribe(eventType, "writer", func(db *gorm.DB, ev events.Event) error {
+		return db.Create(&models.DataMigration{Name: marker("subscriber"), AppliedAt: time.Now()}).Error
+	})
+	bus.Subscribe(eventType, "failing", func(db *gorm.DB, ev events.Event) error {
+		return errors.New("boom")
+	})
+	bus.SubscribeAsync(eventType, "queued", func(db *gorm.DB, ev events.Event) error { return nil })
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&models.DataMigration{Name: marker("publisher"), AppliedAt: time.Now()}).Error; err != nil {
+			return err
+		}
+		return bus.Publish(tx, "daiyly", uuid.Nil, eventType, map[string]string{"k": "v"})
+	})
+	if err == nil || !strings.Contains(err.Error(), "failing: boom") {
+		t.Fatalf("publish error = %v, want the failing subscriber's", err)
+	}
+
+	var rows int64
+	h.DB.Model(&models.DataMigration{}).Where("name LIKE ?", eventType+":%").Count(&rows)
+	if rows != 0 {
+		t.Errorf("%d rows written in the rolled-back transaction survived", rows)
+	}
+	h.DB.Model(&models.OutboxMessage{}).Where("type = ?", eventType).Count(&rows)
+	if rows != 0 {
+		t.Errorf("%d outbox rows survived the rollback", rows)
+	}
+}
+
+func TestAsyncSubscriberIsRedeliveredAfterFailure(t *testing.T) {
+	bus := h.Server.Events
+	eventType := "e2e.redeliver." + uuid.NewString()[:8]
+	u := h.NewUser(t, "daiyly")
+
+	var calls atomic.Int32
+	var delivered atomic.Value // events.Event
+	bus.SubscribeAsync(eventType, "flaky", func(db *gorm.DB, ev events.Event) error {
+		if calls.Add(1) == 1 {
+			return errors.New("transient")
+		}
+		delivered.Store(ev)
+		return nil
+	})
+	if err := bus.Publish(nil, "daiyly", u.ID, eventType, map[string]int{"n": 7}); err != nil {
+		t.Fatal(err)
+	}
+
+	message := func() models.OutboxMessage {
+		var m models.OutboxMessage
+		h.DB.Where("type = ?", eventType).First(&m)
+		return m
+	}
+	testharness.Eventually(t, 10*time.Second, "first delivery attempt", func() bool {
+		return message().Attempts == 1
+	})
+	m := message()
+	if m.Status != "pending" || m.LastError != "transient" || !m.NextAttemptAt.After(time.Now()) {
+		t.Fatalf("after a failure: status %s, error %q, next attempt %s; want a pending retry with backoff", m.Status, m.LastError, m.NextAttemptAt)
+	}
+
+	// Skip the backoff rather than wait it out.
+	h.DB.Model(&models.OutboxMessage{}).Where("id = ?", m.ID).Update("next_attempt_at", time.Now())
+	testharness.Eventually(t, 10*time.Second, "redelivery", func() bool {
+		return message().Status == "delivered"
+	})
+	if m := message(); m.Attempts != 2 || m.LastError != "" || m.DeliveredAt == nil {
+		t.Fatalf("after redelivery: %d attempts, error %q, delivered at %v", m.Attempts, m.LastError, m.DeliveredAt)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("handler ran %d times, want 2", n)
+	}
+	ev := delivered.Load().(events.Event)
+	var payload map[string]int
+	if err := json.Unmarshal(ev.Payload, &payload); err != nil || ev.UserID != u.ID || ev.AppID != "daiyly" || payload["n"] != 7 {
+		t.Fatalf("redelivered event = %+v (%v), want the published one", ev, err)
+	}
+}
@@ -0,0 +1,120 @@
+//go:build integration
+
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/testharness"
+	"github.com/google/uuid"
+)
+
+// runningExperiment starts a purchase-goal experiment on appID.
+func runningExperiment(t *testing.T, appID string) models.Experiment {
+	t.Helper()
+	now := time.Now()
+	exp := models.Experiment{
+		AppID:     appID,
+		Key:       "e2e_" + uuid.NewString()[:8],
+		GoalEvent: services.ExperimentGoalPurchase,
+		Status:    "running",
+		Variants:  []byte(`[{"name":"control","weight":50},{"name":"treatment","weight":50}]`),
+		StartedAt: &now,
+	}
+	if err := h.DB.Create(&exp).Error; err != nil {
+		t.Fatal(err)
+	}
+	return exp
+}
+
+// purchase delivers a RevenueCat INITIAL_PURCHASE for userID.
+func purchase(t *testing.T, appID string, userID uuid.UUID) {
+	t.Helper()
+	now := time.Now()
+	rc := h.Client("")
+	rc.Header.Set("Authorization", testharness.RevenueCatAuth(appID))
+	rc.Post(t, "/api/webhooks/revenuecat/"+appID, map[string]interface{}{
+		"api_version": "1.0",
+		"event": map[string]interface{}{
+			"type":             "INITIAL_PURCHASE",
+			"id":               uuid.NewString(),
+			"app_user_id":      userID.String(),
+			"product_id":       "premium_monthly",
+			"purchased_at_ms":  now.UnixMilli(),
+			"expiration_at_ms": now.Add(30 * 24 * time.Hour).UnixMilli(),
+		},
+	}).Expect(t, 200)
+}
+
+// assignments returns exp's assignments by subject.
+func assignments(t *testing.T, exp models.Experiment) map[string]models.ExperimentAssignment {
+	t.Helper()
+	var rows []models.ExperimentAssignment
+	if err := h.DB.Where("experiment_id = ?", exp.ID).Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]models.ExperimentAssignment, len(rows))
+	for _, a := range rows {
+		out[a.SubjectID] = a
+	}
+	return out
+}
+
+func TestExperimentPurchaseCountsForDeviceExposure(t *testing.T) {
+	exp := runningExperiment(t, "daiyly")
+	device := "device-" + uuid.NewString()
+
+	// The variant is shown before the user signs in.
+	anon := h.Client("daiyly")
+	anon.Header.Set("X-Device-ID", device)
+	var exposure struct {
+		Variant string `json:"variant"`
+	}
+	anon.Post(t, "/api/experiments/exposures", map[string]string{"experiment_key": exp.Key}).Expect(t, 200).Decode(t, &exposure)
+
+	var user authResponse
+	anon.Post(t, "/api/auth/register", map[string]string{
+		"email":    "exp-" + uuid.NewString()[:8] + "@harness.test",
+		"password": "Harness-Passw0rd",
+	}).Expect(t, 201).Decode(t, &user)
+
+	got := assignments(t, exp)
+	a, ok := got[user.User.ID.String()]
+	if len(got) != 1 || !ok || a.Variant != exposure.Variant || a.ExposedAt == nil {
+		t.Fatalf("assignments after sign-in = %+v, want the device's %s exposure under the user", got, exposure.Variant)
+	}
+
+	purchase(t, "daiyly", user.User.ID)
+	if a := assignments(t, exp)[user.User.ID.String()]; a.ConvertedAt == nil {
+		t.Fatal("purchase did not convert the device's assignment")
+	}
+}
+
+func TestExperimentPurchaseCountsForClaimedGuestDevice(t *testing.T) {
+	exp := runningExperiment(t, "lucky_draw")
+	device := "device-" + uuid.NewString()
+
+	anon := h.Client("lucky_draw")
+	anon.Header.Set("X-Device-ID", device)
+	anon.Post(t, "/api/experiments/exposures", map[string]string{"experiment_key": exp.Key}).Expect(t, 200)
+
+	// The device becomes a guest, and the guest registers.
+	var guest, user authResponse
+	h.Client("lucky_draw").Post(t, "/api/auth/guest", map[string]string{"device_id": device}).Expect(t, 201).Decode(t, &guest)
+	if _, ok := assignments(t, exp)[guest.User.ID.String()]; !ok {
+		t.Fatal("guest sign-in did not take over the device's assignment")
+	}
+	h.Client("lucky_draw").WithToken(guest.AccessToken).Post(t, "/api/auth/register", map[string]string{
+		"email":    "exp-" + uuid.NewString()[:8] + "@harness.test",
+		"password": "Harness-Passw0rd",
+	}).Expect(t, 201).Decode(t, &user)
+
+	purchase(t, "lucky_draw", user.User.ID)
+	got := assignments(t, exp)
+	if a, ok := got[user.User.ID.String()]; len(got) != 1 || !ok || a.ConvertedAt == nil {
+		t.Fatalf("assignments after purchase = %+v, want one converted under the user", got)
+	}
+}
@@ -0,0 +1,122 @@
+//go:build integration
+
+package e2e
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/lucky_draw"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/google/uuid"
+)
+
+func TestLuckyDrawGuestDraw(t *testing.T) {
+	anon := h.Client("lucky_draw")
+	anon.Post(t, "/api/p/draw", map[string]string{"input": ""}).Expect(t, 400)
+
+	var draw lucky_draw.LuckyDraw
+	anon.Post(t, "/api/p/draw", map[string]string{"input": "Will it rain tomorrow?"}).Expect(t, 201).Decode(t, &draw)
+	if !draw.IsGuest || draw.UserID != nil {
+		t.Fatalf("anonymous draw = %+v, want a guest draw", draw)
+	}
+	anon.Get(t, "/api/p/draw/"+draw.ID.String()).Expect(t, 200)
+
+	// Only signed-in users delete draws or have stats.
+	anon.Delete(t, "/api/p/draw/"+draw.ID.String()).Expect(t, 401)
+	anon.Get(t, "/api/p/stats").Expect(t, 401)
+}
+
+func TestLuckyDrawUserDraws(t *testing.T) {
+	u := h.NewUser(t, "lucky_draw")
+	c := h.As(u)
+
+	var draw lucky_draw.LuckyDraw
+	c.Post(t, "/api/p/draw", map[string]interface{}{
+		"input":    "Lucky number for my exam",
+		"metadata": map[string]string{"source": "e2e"},
+	}).Expect(t, 201).Decode(t, &draw)
+	if draw.IsGuest || draw.UserID == nil || *draw.UserID != u.ID {
+		t.Fatalf("draw = %+v, want one owned by %s", draw, u.ID)
+	}
+	id := draw.ID.String()
+
+	var list lucky_draw.ListDrawsResponse
+	c.Get(t, "/api/p/draw").Expect(t, 200).Decode(t, &list)
+	if list.Total != 1 || len(list.Results) != 1 || list.Results[0].ID != draw.ID {
+		t.Fatalf("list = %+v, want the one draw", list)
+	}
+	c.Get(t, "/api/p/stats").Expect(t, 200)
+
+	other := h.NewUser(t, "lucky_draw")
+	h.As(other).Get(t, "/api/p/draw/"+id).Expect(t, 404)
+	h.As(other).Delete(t, "/api/p/draw/"+id).Expect(t, 404)
+
+	c.Delete(t, "/api/p/draw/"+id).Expect(t, 200)
+	c.Get(t, "/api/p/draw/"+id).Expect(t, 404)
+}
+
+func TestLuckyDrawReportedGuestDrawIsHidden(t *testing.T) {
+	anon := h.Client("lucky_draw")
+	var draw lucky_draw.LuckyDraw
+	anon.Post(t, "/api/p/draw", map[string]string{"input": "Reported guest draw"}).Expect(t, 201).Decode(t, &draw)
+	id := draw.ID.String()
+
+	// The harness auto-hides content at three distinct reporters.
+	for i := 0; i < 3; i++ {
+		anon.Get(t, "/api/p/draw/"+id).Expect(t, 200)
+		var report models.Report
+		h.As(h.NewUser(t, "lucky_draw")).Post(t, "/api/reports", map[string]string{
+			"content_type": "post",
+			"content_id":   id,
+			"reason":       "spam",
+		}).Expect(t, 201).Decode(t, &report)
+
+		var snapshot struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(report.Snapshot, &snapshot); err != nil || snapshot.Text != draw.Input {
+			t.Fatalf("report snapshot = %s (%v), want the draw's input", report.Snapshot, err)
+		}
+	}
+
+	anon.Get(t, "/api/p/draw/"+id).Expect(t, 404)
+	h.As(h.NewUser(t, "lucky_draw")).Get(t, "/api/p/draw/"+id).Expect(t, 404)
+}
+
+func TestLuckyDrawSharedDrawHiddenAcrossBlocks(t *testing.T) {
+	var guest authResponse
+	h.Client("lucky_draw").Post(t, "/api/auth/guest", map[string]string{
+		"device_id": "device-" + uuid.NewString(),
+	}).Expect(t, 201).Decode(t, &guest)
+	var draw lucky_draw.LuckyDraw
+	h.Client("lucky_draw").WithToken(guest.AccessToken).
+		Post(t, "/api/p/draw", map[string]string{"input": "Shared by a guest account"}).
+		Expect(t, 201).Decode(t, &draw)
+	if !draw.IsGuest || draw.UserID == nil {
+		t.Fatalf("guest account draw = %+v, want a shared draw with an owner", draw)
+	}
+	id := draw.ID.String()
+
+	viewer := h.NewUser(t, "lucky_draw")
+	h.As(viewer).Get(t, "/api/p/draw/"+id).Expect(t, 200)
+	h.As(viewer).Post(t, "/api/blocks", map[string]string{"blocked_id": draw.UserID.String()}).Expect(t, 200)
+
+	// Blocks hide in both directions.
+	for name, pair := range map[string][2]uuid.UUID{
+		"blocker": {viewer.ID, *draw.UserID},
+		"blocked": {*draw.UserID, viewer.ID},
+	} {
+		set, err := services.LoadBlockSet(h.DB, "lucky_draw", pair[0])
+		if err != nil || len(set) != 1 || !set.Contains(pair[1]) {
+			t.Fatalf("%s's block set = %v, %v; want only the other side", name, set, err)
+		}
+	}
+
+	h.As(viewer).Get(t, "/api/p/draw/"+id).Expect(t, 404)
+	// Others, signed in or not, and the owner still see it.
+	h.As(h.NewUser(t, "lucky_draw")).Get(t, "/api/p/draw/"+id).Expect(t, 200)
+	h.Client("lucky_draw").Get(t, "/api/p/draw/"+id).Expect(t, 200)
+	h.Client("lucky_draw").WithToken(guest.AccessToken).Get(t, "/api/p/draw/"+id).Expect(t, 200)
+}
@@ -0,0 +1,32 @@
+//go:build integration
+
+package e2e
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/testharness"
+)
+
+// h is shared by every test; tests isolate themselves by registering their
+// own users and using their own clients.
+var h *testharness.Harness
+
+func TestMain(m *testing.M) {
+	var err error
+	h, err = testharness.Start(testharness.Options{})
+	if errors.Is(err, testharness.ErrNoPostgres) {
+		fmt.Fprintln(os.Stderr, "skipping e2e tests:", err)
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	h.Close()
+	os.Exit(code)
+}
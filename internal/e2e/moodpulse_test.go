@@ -0,0 +1,103 @@
+//go:build integration
+
+package e2e
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/moodpulse"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/testharness"
+)
+
+func moodEntry(intensity int, note string) map[string]interface{} {
+	return map[string]interface{}{
+		"emotion":    map[string]string{"id": "happy", "name": "Happy", "emoji": "😊", "color": "#FFD700"},
+		"intensity":  intensity,
+		"note":       note,
+		"triggers":   []map[string]string{{"id": "work", "name": "Work", "icon": "briefcase"}},
+		"activities": []map[string]string{{"id": "walk", "name": "Walk", "icon": "figure.walk"}},
+	}
+}
+
+func TestMoodpulseMoodCRUD(t *testing.T) {
+	u := h.NewUser(t, "moodpulse")
+	c := h.As(u)
+
+	h.Client("moodpulse").Get(t, "/api/p/moods").Expect(t, 401)
+	c.Post(t, "/api/p/moods", moodEntry(11, "")).Expect(t, 400)
+	c.Post(t, "/api/p/moods", map[string]interface{}{"intensity": 5}).Expect(t, 400)
+
+	var entry moodpulse.MoodEntryResponse
+	c.Post(t, "/api/p/moods", moodEntry(7, "Shipped the release")).Expect(t, 201).Decode(t, &entry)
+	if entry.Emotion.Name != "Happy" || entry.Intensity != 7 || len(entry.Triggers) != 1 || len(entry.Activities) != 1 {
+		t.Fatalf("created entry = %+v", entry)
+	}
+	id := entry.ID.String()
+
+	var list moodpulse.MoodListResponse
+	c.Get(t, "/api/p/moods").Expect(t, 200).Decode(t, &list)
+	if list.Total != 1 || len(list.Entries) != 1 || list.Entries[0].ID != entry.ID {
+		t.Fatalf("list = %+v, want the one entry", list)
+	}
+
+	c.Put(t, "/api/p/moods/"+id, map[string]interface{}{"intensity": 4, "note": "Calmer now"}).Expect(t, 200).Decode(t, &entry)
+	if entry.Intensity != 4 || entry.Note != "Calmer now" {
+		t.Fatalf("updated entry = %+v", entry)
+	}
+
+	// Entries are private to their owner, and to their app.
+	other := h.NewUser(t, "moodpulse")
+	h.As(other).Get(t, "/api/p/moods/"+id).Expect(t, 404)
+	h.Client("moodpulse").WithToken(h.Token("moodpulse", u.ID)).Get(t, "/api/p/moods/"+id).Expect(t, 200)
+
+	c.Delete(t, "/api/p/moods/"+id).Expect(t, 200)
+	c.Get(t, "/api/p/moods/"+id).Expect(t, 404)
+}
+
+func TestMoodpulseStreakCountsCheckInsAndImports(t *testing.T) {
+	u := h.NewUser(t, "moodpulse")
+	c := h.As(u)
+
+	c.Post(t, "/api/p/moods", moodEntry(6, "")).Expect(t, 201)
+	batch := make([]map[string]interface{}, 2)
+	for i := range batch {
+		batch[i] = moodEntry(5, "")
+		batch[i]["client_id"] = string(rune('a' + i))
+		batch[i]["created_at"] = time.Now().Add(-time.Duration(i+1) * time.Hour).Format(time.RFC3339)
+	}
+	c.Post(t, "/api/p/moods/batch", map[string]interface{}{"entries": batch}).Expect(t, 201)
+
+	// The streak is updated by the bus subscriber, not the request.
+	testharness.Eventually(t, 10*time.Second, "mood streak", func() bool {
+		var streak moodpulse.StreakResponse
+		c.Get(t, "/api/p/moods/streak").Expect(t, 200).Decode(t, &streak)
+		return streak.TotalEntries == 3 && streak.CurrentStreak == 1
+	})
+}
+
+func TestMoodpulseAsk(t *testing.T) {
+	const answer = "You were happiest on days you went for a walk."
+	h.AI.Reply("access to all of a user's mood tracking entries", answer)
+
+	u := h.NewUser(t, "moodpulse")
+	c := h.As(u)
+	c.Post(t, "/api/p/moods", moodEntry(8, "Long walk in the park")).Expect(t, 201)
+
+	var resp struct {
+		Answer string `json:"answer"`
+	}
+	c.Post(t, "/api/p/moods/ask", map[string]string{"question": "When am I happiest?"}).Expect(t, 200).Decode(t, &resp)
+	if resp.Answer != answer {
+		t.Fatalf("answer = %q, want the fake's reply", resp.Answer)
+	}
+
+	found := false
+	for _, r := range h.AI.Requests("/chat/completions") {
+		found = found || strings.Contains(r.Prompt, "Long walk in the park") && strings.Contains(r.Prompt, "When am I happiest?")
+	}
+	if !found {
+		t.Fatal("the entries and question were not sent to the AI provider")
+	}
+}
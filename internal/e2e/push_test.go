@@ -0,0 +1,62 @@
+//go:build integration
+
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/daiyly"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+)
+
+func TestDaiylyRemindersSendOncePerDay(t *testing.T) {
+	u := h.NewUser(t, "daiyly")
+	token := "reminder-device-" + u.ID.String()
+	h.As(u).Post(t, "/api/push/devices", map[string]string{"token": token, "platform": "android"}).Expect(t, 201)
+
+	mock := services.NewMockPushProvider()
+	push := services.NewPushServiceWithProviders(h.DB, h.Registry, mock, mock)
+	source := daiyly.New()
+	sentTo := func() []services.PushMessage {
+		var mine []services.PushMessage
+		for _, m := range mock.Sent() {
+			if m.Token == token {
+				mine = append(mine, m)
+			}
+		}
+		return mine
+	}
+
+	// Without entries the reminder goes out at 20:00 in the user's zone (UTC).
+	evening := time.Date(2026, 3, 14, 20, 0, 0, 0, time.UTC)
+	push.RunReminders("daiyly", source, evening.Add(-time.Hour))
+	if got := sentTo(); len(got) != 0 {
+		t.Fatalf("reminder sent at 19:00: %+v", got)
+	}
+
+	push.RunReminders("daiyly", source, evening)
+	got := sentTo()
+	if len(got) != 1 || got[0].Title == "" || got[0].Data["kind"] != "journal_reminder" {
+		t.Fatalf("sent at 20:00 = %+v, want one journal reminder", got)
+	}
+
+	// A restart or a second instance reruns the hour; the dedupe key holds.
+	push.RunReminders("daiyly", source, evening)
+	if got := sentTo(); len(got) != 1 {
+		t.Fatalf("rerun sent %d reminders, want still 1", len(got))
+	}
+	var n models.PushNotification
+	if err := h.DB.Where("user_id = ? AND dedupe_key = ?", u.ID, "journal_reminder:2026-03-14").First(&n).Error; err != nil {
+		t.Fatalf("notification row: %v", err)
+	}
+	if n.Status != "sent" || n.Delivered != 1 {
+		t.Errorf("notification = %s with %d delivered, want sent with 1", n.Status, n.Delivered)
+	}
+
+	push.RunReminders("daiyly", source, evening.AddDate(0, 0, 1))
+	if got := sentTo(); len(got) != 2 {
+		t.Fatalf("next evening: %d reminders in total, want 2", len(got))
+	}
+}
@@ -0,0 +1,66 @@
+//go:build integration
+
+package e2e
+
+import (
+	"testing"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/streaks"
+	"github.com/google/uuid"
+)
+
+func TestStreakLegacyImportRunsOnce(t *testing.T) {
+	u := h.NewUser(t, "daiyly")
+	suffix := uuid.NewString()[:8]
+	table := "legacy_streaks_e2e_" + suffix
+	kind := "e2e_" + suffix
+
+	createLegacy := func() {
+		t.Helper()
+		if err := h.DB.Exec("CREATE TABLE " + table + " (app_id varchar(50), user_id uuid, current_streak int, longest_streak int, total int)").Error; err != nil {
+			t.Fatal(err)
+		}
+		// The second row belongs to a purged user and must never be copied.
+		if err := h.DB.Exec("INSERT INTO "+table+" VALUES ('daiyly', ?, 4, 6, 10), ('daiyly', ?, 9, 9, 9)", u.ID, uuid.New()).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { h.DB.Exec("DROP TABLE IF EXISTS " + table) })
+
+	createLegacy()
+	engine := streaks.New(h.DB, kind, streaks.Policy{})
+	legacy := streaks.Legacy{Table: table, Total: "total"}
+	n, err := engine.ImportLegacy(legacy)
+	if err != nil || n != 1 {
+		t.Fatalf("first import = %d, %v; want 1 row", n, err)
+	}
+	if h.DB.Migrator().HasTable(table) {
+		t.Fatal("legacy table was kept after the import")
+	}
+	st, err := engine.Get("daiyly", u.ID)
+	if err != nil || st.CurrentStreak != 4 || st.LongestStreak != 6 || st.TotalCount != 10 {
+		t.Fatalf("imported streak = %+v, %v", st, err)
+	}
+	var markers int64
+	h.DB.Model(&models.DataMigration{}).Where("name = ?", "streaks.import:"+kind+":"+table).Count(&markers)
+	if markers != 1 {
+		t.Fatalf("markers = %d, want 1", markers)
+	}
+
+	// The user's streak is purged and the legacy table reappears, e.g. from
+	// a stale replica: the marker keeps the import from running again.
+	if err := h.DB.Where("kind = ? AND user_id = ?", kind, u.ID).Delete(&models.Streak{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	createLegacy()
+	n, err = engine.ImportLegacy(legacy)
+	if err != nil || n != 0 {
+		t.Fatalf("second import = %d, %v; want nothing", n, err)
+	}
+	var count int64
+	h.DB.Model(&models.Streak{}).Where("kind = ? AND user_id = ?", kind, u.ID).Count(&count)
+	if count != 0 {
+		t.Fatal("second import resurrected a purged streak")
+	}
+}
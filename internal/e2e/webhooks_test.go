@@ -0,0 +1,117 @@
+//go:build integration
+
+package e2e
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/testharness"
+	"github.com/google/uuid"
+)
+
+// createWebhook registers inbox as an outbound webhook for appID and
+// returns its ID and signing secret.
+func createWebhook(t *testing.T, appID string, inbox *testharness.Inbox, eventTypes ...string) (string, string) {
+	t.Helper()
+	var created struct {
+		Webhook struct {
+			ID string `json:"id"`
+		} `json:"webhook"`
+		Secret string `json:"secret"`
+	}
+	h.Admin(t, appID).Post(t, "/api/admin/webhooks", map[string]interface{}{
+		"url":    inbox.URL,
+		"events": eventTypes,
+	}).Expect(t, 201).Decode(t, &created)
+	return created.Webhook.ID, created.Secret
+}
+
+// verify checks d's signature and decodes its payload.
+func verify(t *testing.T, secret string, d testharness.Delivery) services.WebhookPayload {
+	t.Helper()
+	if err := services.VerifyWebhookSignature(secret, d.Header.Get(services.WebhookSignatureHeader), d.Body, 5*time.Minute); err != nil {
+		t.Fatalf("webhook signature: %v", err)
+	}
+	var payload services.WebhookPayload
+	if err := json.Unmarshal(d.Body, &payload); err != nil {
+		t.Fatalf("decode webhook payload: %v", err)
+	}
+	return payload
+}
+
+func TestOutboundWebhookEndpoints(t *testing.T) {
+	admin := h.Admin(t, "driftoff")
+	admin.Post(t, "/api/admin/webhooks", map[string]string{"url": "http://example.com/hook"}).Expect(t, 400)
+
+	inbox := h.Webhooks.Inbox()
+	id, secret := createWebhook(t, "driftoff", inbox)
+
+	// Test deliveries are sent synchronously.
+	h.Admin(t, "driftoff").Post(t, "/api/admin/webhooks/"+id+"/test", nil).Expect(t, 200)
+	payload := verify(t, secret, inbox.Next(t, 5*time.Second))
+	if payload.Type != services.WebhookTestEvent || payload.AppID != "driftoff" {
+		t.Fatalf("test delivery = %+v, want a %s for driftoff", payload, services.WebhookTestEvent)
+	}
+
+	// Endpoints belong to one app.
+	h.Admin(t, "moodpulse").Delete(t, "/api/admin/webhooks/"+id).Expect(t, 404)
+	h.Admin(t, "driftoff").Delete(t, "/api/admin/webhooks/"+id).Expect(t, 200)
+}
+
+func TestRevenueCatWebhook(t *testing.T) {
+	u := h.NewUser(t, "daiyly")
+	inbox := h.Webhooks.Inbox()
+	_, secret := createWebhook(t, "daiyly", inbox, events.SubscriptionChanged)
+
+	now := time.Now()
+	body := map[string]interface{}{
+		"api_version": "1.0",
+		"event": map[string]interface{}{
+			"type":             "INITIAL_PURCHASE",
+			"id":               uuid.NewString(),
+			"app_user_id":      u.ID.String(),
+			"product_id":       "premium_monthly",
+			"period_type":      "NORMAL",
+			"purchased_at_ms":  now.UnixMilli(),
+			"expiration_at_ms": now.Add(30 * 24 * time.Hour).UnixMilli(),
+			"environment":      "SANDBOX",
+			"store":            "APP_STORE",
+		},
+	}
+
+	bad := h.Client("")
+	bad.Header.Set("Authorization", "Bearer wrong")
+	bad.Post(t, "/api/webhooks/revenuecat/daiyly", body).Expect(t, 401)
+
+	rc := h.Client("")
+	rc.Header.Set("Authorization", testharness.RevenueCatAuth("daiyly"))
+	rc.Post(t, "/api/webhooks/revenuecat/daiyly", body).Expect(t, 200)
+	// RevenueCat retries deliveries; a replay must not add a subscription.
+	rc.Post(t, "/api/webhooks/revenuecat/daiyly", body).Expect(t, 200)
+
+	var subs []models.Subscription
+	if err := h.DB.Where("app_id = ? AND user_id = ?", "daiyly", u.ID).Find(&subs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 1 || subs[0].Status != "active" || subs[0].ProductID != "premium_monthly" {
+		t.Fatalf("subscriptions after purchase = %+v, want one active premium_monthly", subs)
+	}
+
+	// The change is forwarded by the event bus and webhook dispatchers.
+	payload := verify(t, secret, inbox.Next(t, 30*time.Second))
+	if payload.Type != events.SubscriptionChanged || payload.UserID == nil || *payload.UserID != u.ID {
+		t.Fatalf("webhook payload = %+v, want %s for %s", payload, events.SubscriptionChanged, u.ID)
+	}
+	var change events.SubscriptionChangedPayload
+	if err := json.Unmarshal(payload.Data, &change); err != nil {
+		t.Fatal(err)
+	}
+	if change.Status != "active" || change.Event != "INITIAL_PURCHASE" {
+		t.Fatalf("subscription change = %+v, want active INITIAL_PURCHASE", change)
+	}
+}
@@ -0,0 +1,291 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Handler handles one event. Synchronous handlers get the publisher's
+// transaction (or the bus's DB when there is none); asynchronous handlers get
+// the bus's DB.
+type Handler func(db *gorm.DB, ev Event) error
+
+const (
+	outboxStatusPending   = "pending"
+	outboxStatusDelivered = "delivered"
+	outboxStatusFailed    = "failed"
+
+	// A claimed message is invisible to other dispatchers for this long; if
+	// the handler hasn't finished by then (e.g. the instance died) it is
+	// delivered again.
+	outboxLease       = 5 * time.Minute
+	outboxBatch       = 50
+	outboxMaxAttempts = 8
+	outboxRetention   = 7 * 24 * time.Hour
+)
+
+type subscriber struct {
+	name    string
+	handler Handler
+}
+
+// Bus routes published events to subscribers.
+type Bus struct {
+	db    *gorm.DB
+	mu    sync.RWMutex
+	sync  map[string][]subscriber
+	async map[string][]subscriber
+	// byName finds an async handler from an outbox row.
+	byName map[string]Handler
+}
+
+func NewBus(db *gorm.DB) *Bus {
+	return &Bus{
+		db:     db,
+		sync:   make(map[string][]subscriber),
+		async:  make(map[string][]subscriber),
+		byName: make(map[string]Handler),
+	}
+}
+
+// Subscribe registers a synchronous handler. It runs inside Publish and its
+// error is returned to the publisher, so it should be quick and local (e.g.
+// deleting a plugin's rows for user.deleted).
+func (b *Bus) Subscribe(eventType, name string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sync[eventType] = append(b.sync[eventType], subscriber{name: name, handler: h})
+}
+
+// SubscribeAsync registers a job-queued handler. Each publish writes an
+// outbox row for it, and the dispatcher retries it with backoff until it
+// succeeds. name must be unique and stable across deploys since it is
+// persisted with queued rows.
+func (b *Bus) SubscribeAsync(eventType, name string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := eventType + "/" + name
+	if _, dup := b.byName[key]; dup {
+		panic(fmt.Sprintf("events: duplicate async subscriber %q for %s", name, eventType))
+	}
+	b.async[eventType] = append(b.async[eventType], subscriber{name: name, handler: h})
+	b.byName[key] = h
+}
+
+// Publish emits an event. Pass the caller's transaction as tx to make the
+// outbox rows commit (or roll back) with the change that caused the event;
+// nil uses the bus's DB. Synchronous handlers run before Publish returns and
+// their errors are joined into the result.
+func (b *Bus) Publish(tx *gorm.DB, appID string, userID uuid.UUID, eventType string, payload interface{}) error {
+	if tx == nil {
+		tx = b.db
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal %s payload: %w", eventType, err)
+	}
+	ev := Event{
+		ID:         uuid.New(),
+		Type:       eventType,
+		AppID:      appID,
+		UserID:     userID,
+		Payload:    raw,
+		OccurredAt: time.Now(),
+	}
+
+	b.mu.RLock()
+	syncSubs := b.sync[eventType]
+	asyncSubs := b.async[eventType]
+	b.mu.RUnlock()
+
+	if len(asyncSubs) > 0 {
+		var uid *uuid.UUID
+		if userID != uuid.Nil {
+			uid = &userID
+		}
+		rows := make([]models.OutboxMessage, len(asyncSubs))
+		for i, sub := range asyncSubs {
+			rows[i] = models.OutboxMessage{
+				EventID:       ev.ID,
+				AppID:         appID,
+				UserID:        uid,
+				Type:          eventType,
+				Subscriber:    sub.name,
+				Payload:       raw,
+				Status:        outboxStatusPending,
+				NextAttemptAt: ev.OccurredAt,
+				OccurredAt:    ev.OccurredAt,
+			}
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return fmt.Errorf("enqueue %s: %w", eventType, err)
+		}
+	}
+
+	var errs []error
+	for _, sub := range syncSubs {
+		if err := sub.handler(tx, ev); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sub.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PublishAsync publishes outside any transaction and only logs failures. It
+// suits best-effort events fired after the main write has committed.
+func (b *Bus) PublishAsync(appID string, userID uuid.UUID, eventType string, payload interface{}) {
+	if err := b.Publish(nil, appID, userID, eventType, payload); err != nil {
+		slog.Error("event publish failed", "type", eventType, "app_id", appID, "error", err)
+	}
+}
+
+// StartDispatcher delivers queued outbox messages every few seconds and
+// prunes old delivered rows daily.
+func (b *Bus) StartDispatcher(done chan struct{}) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("panic in event dispatcher", "recover", r)
+			}
+		}()
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+		var lastPrune time.Time
+		for {
+			select {
+			case <-ticker.C:
+				// Keep draining while batches come back full.
+				for b.dispatchBatch() == outboxBatch {
+					select {
+					case <-done:
+						return
+					default:
+					}
+				}
+				if time.Since(lastPrune) > 24*time.Hour {
+					lastPrune = time.Now()
+					b.prune()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// dispatchBatch claims up to outboxBatch due messages and runs their
+// handlers, returning how many were claimed.
+func (b *Bus) dispatchBatch() int {
+	var msgs []models.OutboxMessage
+	err := b.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", outboxStatusPending, time.Now()).
+			Order("next_attempt_at ASC").
+			Limit(outboxBatch).
+			Find(&msgs).Error; err != nil {
+			return err
+		}
+		if len(msgs) == 0 {
+			return nil
+		}
+		ids := make([]uuid.UUID, len(msgs))
+		for i, m := range msgs {
+			ids[i] = m.ID
+		}
+		return tx.Model(&models.OutboxMessage{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(outboxLease)).Error
+	})
+	if err != nil {
+		slog.Error("outbox claim failed", "error", err)
+		return 0
+	}
+
+	for _, m := range msgs {
+		b.deliver(m)
+	}
+	return len(msgs)
+}
+
+func (b *Bus) deliver(m models.OutboxMessage) {
+	b.mu.RLock()
+	h, ok := b.byName[m.Type+"/"+m.Subscriber]
+	b.mu.RUnlock()
+
+	var err error
+	if !ok {
+		err = fmt.Errorf("no subscriber %q for %s", m.Subscriber, m.Type)
+	} else {
+		err = b.run(h, m)
+	}
+
+	attempts := m.Attempts + 1
+	updates := map[string]interface{}{"attempts": attempts}
+	switch {
+	case err == nil:
+		now := time.Now()
+		updates["status"] = outboxStatusDelivered
+		updates["delivered_at"] = &now
+		updates["last_error"] = ""
+	case attempts >= outboxMaxAttempts:
+		updates["status"] = outboxStatusFailed
+		updates["last_error"] = err.Error()
+		slog.Error("event delivery gave up", "type", m.Type, "subscriber", m.Subscriber, "event_id", m.EventID, "attempts", attempts, "error", err)
+	default:
+		updates["next_attempt_at"] = time.Now().Add(backoff(attempts))
+		updates["last_error"] = err.Error()
+		slog.Warn("event delivery failed", "type", m.Type, "subscriber", m.Subscriber, "event_id", m.EventID, "attempts", attempts, "error", err)
+	}
+	if err := b.db.Model(&models.OutboxMessage{}).Where("id = ?", m.ID).Updates(updates).Error; err != nil {
+		slog.Error("outbox update failed", "id", m.ID, "error", err)
+	}
+}
+
+// run calls h, turning a panic into an error so one bad subscriber can't
+// stop the dispatcher.
+func (b *Bus) run(h Handler, m models.OutboxMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	ev := Event{
+		ID:         m.EventID,
+		Type:       m.Type,
+		AppID:      m.AppID,
+		Payload:    json.RawMessage(m.Payload),
+		OccurredAt: m.OccurredAt,
+	}
+	if m.UserID != nil {
+		ev.UserID = *m.UserID
+	}
+	return h(b.db, ev)
+}
+
+// backoff doubles from 10s per attempt, capped at an hour.
+func backoff(attempts int) time.Duration {
+	d := 10 * time.Second << (attempts - 1)
+	if d > time.Hour || d <= 0 {
+		return time.Hour
+	}
+	return d
+}
+
+func (b *Bus) prune() {
+	cutoff := time.Now().Add(-outboxRetention)
+	result := b.db.Where("status = ? AND delivered_at < ?", outboxStatusDelivered, cutoff).Delete(&models.OutboxMessage{})
+	if result.Error != nil {
+		slog.Error("outbox prune failed", "error", result.Error)
+	} else if result.RowsAffected > 0 {
+		slog.Info("outbox prune completed", "deleted", result.RowsAffected)
+	}
+}
@@ -0,0 +1,105 @@
+// Package events is the in-process domain event bus. Core services and
+// plugins publish typed events; subscribers are either synchronous (run
+// inside Publish, in the publisher's transaction) or asynchronous (queued in
+// the outbox_messages table and delivered at least once by the dispatcher).
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types. Each has a matching payload struct below.
+const (
+	UserDeleted         = "user.deleted"
+	GuestClaimed        = "user.guest_claimed"
+	SubscriptionChanged = "subscription.changed"
+	JournalEntryCreated = "journal.entry_created"
+	SleepSessionLogged  = "sleep.session_logged"
+	AchievementUnlocked = "achievement.unlocked"
+	ReportFiled         = "report.filed"
+	MoodCrisisFlagged   = "mood.crisis_flagged"
+	MoodCheckInLogged   = "mood.check_in_logged"
+)
+
+// Event is a published domain event. UserID is uuid.Nil for events that
+// aren't about a single user.
+type Event struct {
+	ID         uuid.UUID
+	Type       string
+	AppID      string
+	UserID     uuid.UUID
+	Payload    json.RawMessage
+	OccurredAt time.Time
+}
+
+// Decode unmarshals the payload into v, normally the payload struct for the
+// event's type.
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// UserDeletedPayload is published when an account is deleted. Subscribers
+// should remove their per-user data.
+type UserDeletedPayload struct{}
+
+// GuestClaimedPayload is published, with the registered user as the event's
+// user, when a guest account is claimed on register or Apple sign-in.
+// Subscribers must move their rows from GuestID to the event's user (see
+// services.MoveUserRows); they run in the claim transaction, so an error
+// aborts the sign-in.
+type GuestClaimedPayload struct {
+	GuestID uuid.UUID `json:"guest_id"`
+}
+
+// SubscriptionChangedPayload is published for every RevenueCat lifecycle
+// event that changes a user's subscription.
+type SubscriptionChangedPayload struct {
+	Event     string     `json:"event"` // RevenueCat event type, e.g. INITIAL_PURCHASE
+	Status    string     `json:"status"`
+	ProductID string     `json:"product_id,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// JournalEntryCreatedPayload is published by daiyly after a journal entry is
+// saved.
+type JournalEntryCreatedPayload struct {
+	EntryID uuid.UUID `json:"entry_id"`
+}
+
+// SleepSessionLoggedPayload is published by driftoff after a sleep session is
+// saved, including sessions imported from the device.
+type SleepSessionLoggedPayload struct {
+	SessionID uuid.UUID `json:"session_id"`
+}
+
+// MoodCheckInLoggedPayload is published by moodpulse after a check-in is
+// saved, including check-ins imported from the device.
+type MoodCheckInLoggedPayload struct {
+	CheckInID uuid.UUID `json:"check_in_id"`
+}
+
+// AchievementUnlockedPayload is published when a user earns an achievement.
+type AchievementUnlockedPayload struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	XP   int    `json:"xp"`
+}
+
+// ReportFiledPayload is published when a user reports content.
+type ReportFiledPayload struct {
+	ReportID    uuid.UUID `json:"report_id"`
+	ContentType string    `json:"content_type"`
+	ContentID   string    `json:"content_id"`
+	Reason      string    `json:"reason"`
+}
+
+// MoodCrisisFlaggedPayload is published by moodpulse the first time on a
+// local day that a user's crisis check comes back positive.
+type MoodCrisisFlaggedPayload struct {
+	Day                string  `json:"day"`
+	ConsecutiveLowDays int     `json:"consecutive_low_days"`
+	AvgIntensityLast7  float64 `json:"avg_intensity_last_7"`
+}
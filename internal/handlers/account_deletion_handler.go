@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func deletionReceipt(d *models.AccountDeletion) dto.DeletionReceiptResponse {
+	return dto.DeletionReceiptResponse{
+		ID:              d.ID,
+		Status:          d.Status,
+		AppleRevocation: d.AppleRevocation,
+		RequestedAt:     d.RequestedAt,
+		PurgeAfter:      d.PurgeAfter,
+		RestoredAt:      d.RestoredAt,
+		PurgedAt:        d.PurgedAt,
+	}
+}
+
+// DeletionReceipt returns the deletion receipt in :id. It needs no token:
+// a pending account is signed out, and a purged one no longer exists.
+func (h *AuthHandler) DeletionReceipt(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid receipt ID")
+	}
+
+	receipt, err := h.authService.DeletionReceipt(appID, id)
+	if err != nil {
+		if errors.Is(err, services.ErrDeletionNotFound) {
+			return apierr.New(fiber.StatusNotFound, err.Error())
+		}
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch deletion receipt")
+	}
+	return c.JSON(fiber.Map{"receipt": deletionReceipt(receipt)})
+}
+
+// ListDeletions returns the app's account deletions, soonest purge first.
+// ?status defaults to pending; ?status=all lists every receipt.
+func (h *AuthHandler) ListDeletions(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	status := c.Query("status", models.DeletionPending)
+	switch status {
+	case "all":
+		status = ""
+	case models.DeletionPending, models.DeletionRestored, models.DeletionPurged:
+	default:
+		return apierr.New(fiber.StatusBadRequest, "status must be pending, restored, purged or all")
+	}
+
+	deletions, total, err := h.authService.ListDeletions(appID, status, limit, offset)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch account deletions")
+	}
+
+	return c.JSON(fiber.Map{
+		"deletions": deletions,
+		"total":     total,
+		"limit":     limit,
+		"offset":    offset,
+	})
+}
+
+// RestoreDeletion cancels the pending deletion in :id.
+func (h *AuthHandler) RestoreDeletion(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid deletion ID")
+	}
+
+	deletion, err := h.authService.RestoreDeletion(appID, id, tenant.GetAdminActor(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDeletionNotFound):
+			return apierr.New(fiber.StatusNotFound, err.Error())
+		case errors.Is(err, services.ErrNotRestorable):
+			return apierr.New(fiber.StatusConflict, err.Error())
+		}
+		slog.Error("restore account failed", "app_id", appID, "deletion_id", id, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to restore account")
+	}
+	return c.JSON(fiber.Map{"deletion": deletion})
+}
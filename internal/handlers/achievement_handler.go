@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"log/slog"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AchievementHandler struct {
+	service *services.AchievementService
+}
+
+func NewAchievementHandler(service *services.AchievementService) *AchievementHandler {
+	return &AchievementHandler{service: service}
+}
+
+// List returns the caller's XP, level and every achievement of the app with
+// its unlock state.
+func (h *AchievementHandler) List(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	summary, err := h.service.Summary(appID, userID)
+	if err != nil {
+		slog.Error("achievement summary failed", "app_id", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load achievements")
+	}
+	return c.JSON(summary)
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/rbac"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// AdminHandler serves admin sign-in, admin and API key management, and the
+// admin audit log.
+type AdminHandler struct {
+	adminService *services.AdminService
+}
+
+func NewAdminHandler(adminService *services.AdminService) *AdminHandler {
+	return &AdminHandler{adminService: adminService}
+}
+
+// Login exchanges an admin's email and password for a session token.
+func (h *AdminHandler) Login(c *fiber.Ctx) error {
+	var req dto.AdminLoginRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	resp, err := h.adminService.Login(req.Email, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAdminCredentials):
+			return apierr.New(fiber.StatusUnauthorized, err.Error())
+		case errors.Is(err, services.ErrAdminDisabled):
+			return apierr.New(fiber.StatusForbidden, err.Error())
+		}
+		slog.Error("admin login failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Login failed")
+	}
+
+	return c.JSON(resp)
+}
+
+// EnrollTOTP issues an authenticator secret to an admin who has none, using
+// the MFA token from Login.
+func (h *AdminHandler) EnrollTOTP(c *fiber.Ctx) error {
+	var req dto.AdminMFATokenRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	resp, err := h.adminService.EnrollTOTP(req.MFAToken)
+	if err != nil {
+		if status, ok := mfaErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("admin TOTP enrolment failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Enrolment failed")
+	}
+	return c.JSON(resp)
+}
+
+// VerifyTOTP completes an admin login with a TOTP code and returns the
+// session token.
+func (h *AdminHandler) VerifyTOTP(c *fiber.Ctx) error {
+	var req dto.AdminMFAVerifyRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	resp, err := h.adminService.VerifyTOTP(req.MFAToken, req.Code)
+	if err != nil {
+		if status, ok := mfaErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("admin TOTP verification failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Login failed")
+	}
+	return c.JSON(resp)
+}
+
+// Me describes the calling admin and their permissions on the request's app.
+func (h *AdminHandler) Me(c *fiber.Ctx) error {
+	p := rbac.GetPrincipal(c)
+	if p == nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+	appID := tenant.GetAppID(c)
+
+	resp := dto.AdminMeResponse{
+		ActorType:   p.Actor.Type,
+		Email:       p.Actor.Email,
+		AppID:       appID,
+		Roles:       p.Bindings,
+		Permissions: p.Permissions(appID),
+	}
+	if p.AdminID != nil {
+		resp.AdminID = p.AdminID.String()
+	}
+	return c.JSON(resp)
+}
+
+// ListAdmins returns every admin with their role bindings.
+func (h *AdminHandler) ListAdmins(c *fiber.Ctx) error {
+	admins, err := h.adminService.ListAdmins()
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch admins")
+	}
+	return c.JSON(fiber.Map{"admins": admins})
+}
+
+// CreateAdmin adds an admin identity with optional initial roles.
+func (h *AdminHandler) CreateAdmin(c *fiber.Ctx) error {
+	var req dto.CreateAdminRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	admin, err := h.adminService.CreateAdmin(req)
+	if err != nil {
+		if status, ok := adminErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("create admin failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to create admin")
+	}
+
+	slog.Info("admin created", "admin_id", admin.ID, "by", tenant.GetAdminActor(c))
+	return c.Status(fiber.StatusCreated).JSON(admin)
+}
+
+// UpdateAdmin changes an admin's name, password or disabled flag.
+func (h *AdminHandler) UpdateAdmin(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid admin ID")
+	}
+	var req dto.UpdateAdminRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	admin, err := h.adminService.UpdateAdmin(id, req)
+	if err != nil {
+		if status, ok := adminErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("update admin failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to update admin")
+	}
+	return c.JSON(admin)
+}
+
+// GrantRole binds a role to the admin in :id on one app or on every app.
+func (h *AdminHandler) GrantRole(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid admin ID")
+	}
+	var req rbac.Binding
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	binding, err := h.adminService.GrantRole(id, req)
+	if err != nil {
+		if status, ok := adminErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("grant role failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to grant role")
+	}
+
+	slog.Info("admin role granted", "admin_id", id, "app_id", binding.AppID, "role", binding.Role,
+		"by", tenant.GetAdminActor(c))
+	return c.Status(fiber.StatusCreated).JSON(binding)
+}
+
+// RevokeRole removes one of the admin's role bindings.
+func (h *AdminHandler) RevokeRole(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid admin ID")
+	}
+	bindingID, err := uuid.Parse(c.Params("binding_id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid binding ID")
+	}
+
+	if err := h.adminService.RevokeRole(id, bindingID); err != nil {
+		if status, ok := adminErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("revoke role failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to revoke role")
+	}
+
+	slog.Info("admin role revoked", "admin_id", id, "binding_id", bindingID, "by", tenant.GetAdminActor(c))
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// ListAPIKeys returns API keys, optionally for one admin (?admin_id=).
+func (h *AdminHandler) ListAPIKeys(c *fiber.Ctx) error {
+	var adminID *uuid.UUID
+	if raw := c.Query("admin_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return apierr.New(fiber.StatusBadRequest, "Invalid admin_id")
+		}
+		adminID = &id
+	}
+
+	keys, err := h.adminService.ListAPIKeys(adminID)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch API keys")
+	}
+	return c.JSON(fiber.Map{"api_keys": keys})
+}
+
+// CreateAPIKey issues a key for admin_id, or for the calling admin when it is
+// omitted. The raw key is returned once and cannot be recovered.
+func (h *AdminHandler) CreateAPIKey(c *fiber.Ctx) error {
+	var req dto.CreateAdminAPIKeyRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	var adminID uuid.UUID
+	switch {
+	case req.AdminID != "":
+		id, err := uuid.Parse(req.AdminID)
+		if err != nil {
+			return apierr.New(fiber.StatusBadRequest, "Invalid admin_id")
+		}
+		adminID = id
+	case rbac.GetPrincipal(c) != nil && rbac.GetPrincipal(c).AdminID != nil:
+		adminID = *rbac.GetPrincipal(c).AdminID
+	default:
+		return apierr.New(fiber.StatusBadRequest, "admin_id is required")
+	}
+
+	key, raw, err := h.adminService.CreateAPIKey(adminID, req)
+	if err != nil {
+		if status, ok := adminErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("create API key failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to create API key")
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"api_key": key, "key": raw})
+}
+
+// RevokeAPIKey disables the key in :id immediately.
+func (h *AdminHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid API key ID")
+	}
+
+	if err := h.adminService.RevokeAPIKey(id); err != nil {
+		if status, ok := adminErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("revoke API key failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to revoke API key")
+	}
+
+	slog.Info("admin API key revoked by admin", "key_id", id, "by", tenant.GetAdminActor(c))
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// ListAudit returns the app's admin audit log, newest first. ?all=true lists
+// every app for admins with audit:read on all apps; ?actor_id= filters to
+// one admin.
+func (h *AdminHandler) ListAudit(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	if c.QueryBool("all") {
+		if p := rbac.GetPrincipal(c); p == nil || !p.Can(rbac.AllApps, rbac.AuditRead) {
+			return apierr.New(fiber.StatusForbidden, "Forbidden: requires audit:read on every app")
+		}
+		appID = rbac.AllApps
+	}
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	entries, total, err := h.adminService.ListAudit(appID, c.Query("actor_id"), limit, offset)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch audit log")
+	}
+
+	return c.JSON(fiber.Map{
+		"entries": entries,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+func adminErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, services.ErrAdminNotFound),
+		errors.Is(err, services.ErrAPIKeyNotFound),
+		errors.Is(err, services.ErrRoleBindingNotFound):
+		return fiber.StatusNotFound, true
+	case errors.Is(err, services.ErrAdminExists):
+		return fiber.StatusConflict, true
+	case errors.Is(err, services.ErrInvalidRole),
+		errors.Is(err, services.ErrInvalidBindingApp),
+		errors.Is(err, services.ErrInvalidPermission),
+		errors.Is(err, services.ErrInvalidAPIKeyExpiry):
+		return fiber.StatusBadRequest, true
+	}
+	return 0, false
+}
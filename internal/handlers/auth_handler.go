@@ -5,10 +5,14 @@ import (
 	"log/slog"
 	"strings"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
@@ -23,13 +27,11 @@ func NewAuthHandler(authService *services.AuthService, registry *tenant.Registry
 func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	var req dto.RegisterRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
-	resp, err := h.authService.Register(appID, &req)
+	resp, err := h.authService.Register(appID, &req, claimedGuest(c))
 	if err != nil {
 		if errors.Is(err, services.ErrEmailTaken) {
 			// Return 201 with a generic message instead of 409 to prevent email enumeration.
@@ -41,59 +43,60 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 				"message": "Registration processed. Check your email to continue.",
 			})
 		}
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: err.Error(),
-		})
+		if status, ok := guestClaimErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
+	h.adoptDevice(c, appID, resp)
 	return c.Status(fiber.StatusCreated).JSON(resp)
 }
 
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	var req dto.LoginRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	resp, err := h.authService.Login(appID, &req)
 	if err != nil {
+		var mfaErr *services.MFARequiredError
+		if errors.As(err, &mfaErr) {
+			return c.JSON(mfaErr.Challenge)
+		}
+		if errors.Is(err, services.ErrAccountSuspended) {
+			return apierr.New(fiber.StatusForbidden, err.Error())
+		}
 		if errors.Is(err, services.ErrInvalidCredentials) {
-			return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-				Error: true, Message: err.Error(),
-			})
+			return apierr.New(fiber.StatusUnauthorized, err.Error())
 		}
 		slog.Error("login failed", "app", appID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Internal server error",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Internal server error")
 	}
 
+	h.adoptDevice(c, appID, resp)
 	return c.JSON(resp)
 }
 
 func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	var req dto.RefreshRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	resp, err := h.authService.Refresh(appID, &req)
 	if err != nil {
+		if errors.Is(err, services.ErrAccountSuspended) {
+			return apierr.New(fiber.StatusForbidden, err.Error())
+		}
 		if errors.Is(err, services.ErrInvalidToken) {
-			return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-				Error: true, Message: err.Error(),
-			})
+			return apierr.New(fiber.StatusUnauthorized, err.Error())
 		}
 		slog.Error("token refresh failed", "app", appID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Internal server error",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Internal server error")
 	}
 
 	return c.JSON(resp)
@@ -102,17 +105,13 @@ func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
 func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	var req dto.LogoutRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	if err := h.authService.Logout(appID, &req); err != nil {
 		slog.Error("logout failed", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to logout",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to logout")
 	}
 
 	return c.JSON(fiber.Map{"message": "Logged out successfully"})
@@ -122,73 +121,248 @@ func (h *AuthHandler) DeleteAccount(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	var req dto.DeleteAccountRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	bundleID := h.registry.GetBundleID(appID)
-	if err := h.authService.DeleteAccount(appID, userID, req.Password, req.AuthorizationCode, bundleID); err != nil {
+	receipt, err := h.authService.DeleteAccount(appID, userID, req.Password, req.AuthorizationCode, bundleID)
+	if err != nil {
 		if errors.Is(err, services.ErrInvalidCredentials) {
-			return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-				Error: true, Message: "Incorrect password. Please try again.",
-			})
+			return apierr.New(fiber.StatusUnauthorized, "Incorrect password. Please try again.")
 		}
 		if errors.Is(err, services.ErrUserNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{
-				Error: true, Message: "User not found",
-			})
+			return apierr.New(fiber.StatusNotFound, "User not found")
 		}
-		if strings.Contains(err.Error(), "password is required") {
-			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-				Error: true, Message: "Password is required",
-			})
+		if errors.Is(err, services.ErrPasswordRequired) {
+			return apierr.New(fiber.StatusBadRequest, "Password is required")
+		}
+		if errors.Is(err, services.ErrDeletionPending) {
+			return apierr.New(fiber.StatusConflict, err.Error())
 		}
 		slog.Error("delete account failed", "app", appID, "user", userID, "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to delete account",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to delete account")
 	}
 
-	return c.JSON(fiber.Map{"message": "Account deleted successfully"})
+	message := "Account deleted successfully"
+	if receipt.Status == models.DeletionPending {
+		message = "Account scheduled for deletion. Sign in again before purge_after to restore it."
+	}
+	return c.JSON(fiber.Map{"message": message, "receipt": deletionReceipt(receipt)})
 }
 
 func (h *AuthHandler) AppleSignIn(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	var req dto.AppleSignInRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
-	if req.IdentityToken == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Identity token is required",
-		})
+	audiences := h.registry.ClientIDs(appID, services.ProviderApple)
+	if len(audiences) == 0 {
+		return apierr.New(fiber.StatusBadRequest, "Sign-in not available")
 	}
 
-	bundleID := h.registry.GetBundleID(appID)
-	if bundleID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Sign-in not available",
-		})
+	resp, err := h.authService.AppleSignIn(appID, audiences, &req, claimedGuest(c))
+	if err != nil {
+		var mfaErr *services.MFARequiredError
+		if errors.As(err, &mfaErr) {
+			return c.JSON(mfaErr.Challenge)
+		}
+		if status, ok := guestClaimErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		if errors.Is(err, services.ErrLinkRequired) {
+			return apierr.New(fiber.StatusConflict, err.Error())
+		}
+		slog.Error("apple sign-in failed", "app", appID, "error", err)
+		return apierr.New(fiber.StatusUnauthorized, "Authentication failed")
 	}
 
-	resp, err := h.authService.AppleSignIn(appID, bundleID, &req)
+	h.adoptDevice(c, appID, resp)
+	return c.JSON(resp)
+}
+
+// GoogleSignIn signs in with a Google ID token.
+func (h *AuthHandler) GoogleSignIn(c *fiber.Ctx) error {
+	return h.providerSignIn(c, services.ProviderGoogle)
+}
+
+// OIDCSignIn signs in with an ID token from a provider configured in
+// OIDC_ISSUERS, named by the :provider path parameter.
+func (h *AuthHandler) OIDCSignIn(c *fiber.Ctx) error {
+	return h.providerSignIn(c, c.Params("provider"))
+}
+
+func (h *AuthHandler) providerSignIn(c *fiber.Ctx, provider string) error {
+	appID := tenant.GetAppID(c)
+	var req dto.OIDCSignInRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	audiences := h.registry.ClientIDs(appID, provider)
+	if !h.authService.HasProvider(provider) || len(audiences) == 0 {
+		return apierr.New(fiber.StatusBadRequest, "Sign-in not available")
+	}
+
+	resp, err := h.authService.ProviderSignIn(appID, provider, audiences, &req, claimedGuest(c))
 	if err != nil {
-		slog.Error("apple sign-in failed", "app", appID, "error", err)
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Authentication failed",
-		})
+		var mfaErr *services.MFARequiredError
+		if errors.As(err, &mfaErr) {
+			return c.JSON(mfaErr.Challenge)
+		}
+		if status, ok := guestClaimErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		if errors.Is(err, services.ErrLinkRequired) {
+			return apierr.New(fiber.StatusConflict, err.Error())
+		}
+		slog.Error("provider sign-in failed", "app", appID, "provider", provider, "error", err)
+		return apierr.New(fiber.StatusUnauthorized, "Authentication failed")
 	}
 
+	h.adoptDevice(c, appID, resp)
 	return c.JSON(resp)
 }
+
+// ListIdentities returns the caller's password and linked providers.
+func (h *AuthHandler) ListIdentities(c *fiber.Ctx) error {
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	resp, err := h.authService.ListIdentities(tenant.GetAppID(c), userID)
+	if err != nil {
+		if status, ok := identityErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("list identities failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to list identities")
+	}
+	return c.JSON(resp)
+}
+
+// LinkIdentity links the provider account in the ID token to the caller.
+func (h *AuthHandler) LinkIdentity(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+	var req dto.LinkIdentityRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	provider := c.Params("provider")
+	audiences := h.registry.ClientIDs(appID, provider)
+	if !h.authService.HasProvider(provider) || len(audiences) == 0 {
+		return apierr.New(fiber.StatusBadRequest, "Sign-in not available")
+	}
+
+	if err := h.authService.LinkIdentity(appID, userID, provider, audiences, &req); err != nil {
+		if errors.Is(err, services.ErrInvalidIDToken) {
+			// Verification details stay in the server log.
+			return apierr.New(fiber.StatusUnauthorized, "Authentication failed")
+		}
+		if status, ok := identityErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("link identity failed", "provider", provider, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to link identity")
+	}
+	return c.JSON(fiber.Map{"message": "Identity linked"})
+}
+
+// UnlinkIdentity removes one of the caller's linked providers.
+func (h *AuthHandler) UnlinkIdentity(c *fiber.Ctx) error {
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	if err := h.authService.UnlinkIdentity(tenant.GetAppID(c), userID, c.Params("provider")); err != nil {
+		if status, ok := identityErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("unlink identity failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to unlink identity")
+	}
+	return c.JSON(fiber.Map{"message": "Identity unlinked"})
+}
+
+func identityErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrIdentityNotFound):
+		return fiber.StatusNotFound, true
+	case errors.Is(err, services.ErrIdentityLinked),
+		errors.Is(err, services.ErrProviderAlreadyLinked),
+		errors.Is(err, services.ErrLastSignInMethod):
+		return fiber.StatusConflict, true
+	case errors.Is(err, services.ErrUnknownProvider):
+		return fiber.StatusBadRequest, true
+	}
+	return 0, false
+}
+
+// Guest creates an anonymous device-bound account with a restricted token.
+func (h *AuthHandler) Guest(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	var req dto.GuestRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	resp, err := h.authService.GuestSignIn(appID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrAccountSuspended) {
+			return apierr.New(fiber.StatusForbidden, err.Error())
+		}
+		return apierr.New(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// adoptDevice hands the experiment variants the caller's device (X-Device-ID)
+// was assigned before signing in to the signed-in user. Failures are logged
+// only: analytics must not fail a sign-in.
+func (h *AuthHandler) adoptDevice(c *fiber.Ctx, appID string, resp *dto.AuthResponse) {
+	deviceID := strings.TrimSpace(c.Get("X-Device-ID"))
+	if deviceID == "" {
+		return
+	}
+	if err := h.authService.AdoptDevice(appID, deviceID, resp.User.ID); err != nil {
+		slog.Error("failed to adopt device experiment assignments", "app", appID, "user_id", resp.User.ID, "error", err)
+	}
+}
+
+// claimedGuest returns the guest account to claim on register or Apple
+// sign-in: the caller's own, when they send their guest token as a Bearer
+// token. Otherwise it returns uuid.Nil.
+func claimedGuest(c *fiber.Ctx) uuid.UUID {
+	if !tenant.IsGuest(c) {
+		return uuid.Nil
+	}
+	id, err := tenant.GetUserID(c)
+	if err != nil {
+		return uuid.Nil
+	}
+	return id
+}
+
+func guestClaimErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, services.ErrAccountSuspended):
+		return fiber.StatusForbidden, true
+	case errors.Is(err, services.ErrGuestNotFound):
+		return fiber.StatusConflict, true
+	}
+	return 0, false
+}
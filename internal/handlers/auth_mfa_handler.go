@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/webauthn"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// VerifyMFA completes a password login with a TOTP or recovery code.
+func (h *AuthHandler) VerifyMFA(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	var req dto.MFAVerifyRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	resp, err := h.authService.VerifyMFA(appID, &req)
+	if err != nil {
+		if status, ok := mfaErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("mfa verification failed", "app", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Internal server error")
+	}
+	h.adoptDevice(c, appID, resp)
+	return c.JSON(resp)
+}
+
+// MFAStatus reports the caller's second factors.
+func (h *AuthHandler) MFAStatus(c *fiber.Ctx) error {
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	resp, err := h.authService.MFAStatus(tenant.GetAppID(c), userID)
+	if err != nil {
+		slog.Error("mfa status failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load two-factor status")
+	}
+	return c.JSON(resp)
+}
+
+// EnrollTOTP starts authenticator-app enrolment.
+func (h *AuthHandler) EnrollTOTP(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	issuer := appID
+	if app := h.registry.Get(appID); app != nil && app.AppName != "" {
+		issuer = app.AppName
+	}
+	resp, err := h.authService.EnrollTOTP(appID, userID, issuer)
+	if err != nil {
+		if status, ok := mfaErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("totp enrolment failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to start enrolment")
+	}
+	return c.JSON(resp)
+}
+
+// ConfirmTOTP enables 2FA with a code from the authenticator and returns
+// the recovery codes.
+func (h *AuthHandler) ConfirmTOTP(c *fiber.Ctx) error {
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+	var req dto.MFACodeRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	resp, err := h.authService.ConfirmTOTP(tenant.GetAppID(c), userID, req.Code)
+	if err != nil {
+		if status, ok := mfaErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("totp confirmation failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to enable two-factor authentication")
+	}
+	return c.JSON(resp)
+}
+
+// DisableTOTP turns 2FA off; the body must carry a current code.
+func (h *AuthHandler) DisableTOTP(c *fiber.Ctx) error {
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+	var req dto.MFACodeRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.authService.DisableTOTP(tenant.GetAppID(c), userID, &req); err != nil {
+		if status, ok := mfaErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("totp disable failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to disable two-factor authentication")
+	}
+	return c.JSON(fiber.Map{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the caller's recovery codes.
+func (h *AuthHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+	var req dto.MFACodeRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	resp, err := h.authService.RegenerateRecoveryCodes(tenant.GetAppID(c), userID, &req)
+	if err != nil {
+		if status, ok := mfaErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("recovery code regeneration failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to regenerate recovery codes")
+	}
+	return c.JSON(resp)
+}
+
+// relyingParty returns the app's passkey settings, or nil when the app has
+// no webauthn_rp_id configured.
+func (h *AuthHandler) relyingParty(appID string) *webauthn.RelyingParty {
+	app := h.registry.Get(appID)
+	if app == nil || app.WebAuthnRPID == "" {
+		return nil
+	}
+	origins := app.WebAuthnOrigins
+	if len(origins) == 0 {
+		origins = []string{"https://" + app.WebAuthnRPID}
+	}
+	return &webauthn.RelyingParty{ID: app.WebAuthnRPID, Name: app.AppName, Origins: origins}
+}
+
+// BeginPasskeyRegistration returns options for navigator.credentials.create().
+func (h *AuthHandler) BeginPasskeyRegistration(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+	rp := h.relyingParty(appID)
+	if rp == nil {
+		return apierr.New(fiber.StatusBadRequest, "Passkeys not available")
+	}
+
+	resp, err := h.authService.BeginPasskeyRegistration(appID, userID, rp)
+	if err != nil {
+		if status, ok := passkeyErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("passkey registration begin failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to start passkey registration")
+	}
+	return c.JSON(resp)
+}
+
+// FinishPasskeyRegistration stores the passkey created by the client.
+func (h *AuthHandler) FinishPasskeyRegistration(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+	rp := h.relyingParty(appID)
+	if rp == nil {
+		return apierr.New(fiber.StatusBadRequest, "Passkeys not available")
+	}
+	var req dto.PasskeyRegisterFinishRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	cred, err := h.authService.FinishPasskeyRegistration(appID, userID, rp, &req)
+	if err != nil {
+		if status, ok := passkeyErrorStatus(err); ok {
+			return apierr.New(status, passkeyErrorMessage(err))
+		}
+		slog.Error("passkey registration failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to register passkey")
+	}
+	return c.Status(fiber.StatusCreated).JSON(cred)
+}
+
+// ListPasskeys returns the caller's passkeys.
+func (h *AuthHandler) ListPasskeys(c *fiber.Ctx) error {
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	creds, err := h.authService.ListPasskeys(tenant.GetAppID(c), userID)
+	if err != nil {
+		slog.Error("list passkeys failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to list passkeys")
+	}
+	return c.JSON(fiber.Map{"passkeys": creds})
+}
+
+// DeletePasskey removes one of the caller's passkeys.
+func (h *AuthHandler) DeletePasskey(c *fiber.Ctx) error {
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid passkey ID")
+	}
+
+	if err := h.authService.DeletePasskey(tenant.GetAppID(c), userID, id); err != nil {
+		if status, ok := passkeyErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("delete passkey failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to delete passkey")
+	}
+	return c.JSON(fiber.Map{"message": "Passkey deleted"})
+}
+
+// BeginPasskeyLogin returns options for navigator.credentials.get().
+func (h *AuthHandler) BeginPasskeyLogin(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	rp := h.relyingParty(appID)
+	if rp == nil {
+		return apierr.New(fiber.StatusBadRequest, "Passkeys not available")
+	}
+	var req dto.PasskeyLoginBeginRequest
+	if len(c.Body()) > 0 {
+		if err := validation.Bind(c, &req); err != nil {
+			return err
+		}
+	}
+
+	resp, err := h.authService.BeginPasskeyLogin(appID, rp, &req)
+	if err != nil {
+		if status, ok := mfaErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("passkey login begin failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to start passkey sign-in")
+	}
+	return c.JSON(resp)
+}
+
+// FinishPasskeyLogin verifies the assertion and returns a token pair.
+func (h *AuthHandler) FinishPasskeyLogin(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	rp := h.relyingParty(appID)
+	if rp == nil {
+		return apierr.New(fiber.StatusBadRequest, "Passkeys not available")
+	}
+	var req dto.PasskeyLoginFinishRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	resp, err := h.authService.FinishPasskeyLogin(appID, rp, &req)
+	if err != nil {
+		if status, ok := passkeyErrorStatus(err); ok {
+			return apierr.New(status, passkeyErrorMessage(err))
+		}
+		if status, ok := mfaErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		if errors.Is(err, services.ErrAccountSuspended) {
+			return apierr.New(fiber.StatusForbidden, err.Error())
+		}
+		slog.Error("passkey login failed", "app", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Internal server error")
+	}
+	h.adoptDevice(c, appID, resp)
+	return c.JSON(resp)
+}
+
+func mfaErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, services.ErrInvalidMFAToken),
+		errors.Is(err, services.ErrInvalidMFACode):
+		return fiber.StatusUnauthorized, true
+	case errors.Is(err, services.ErrAccountSuspended),
+		errors.Is(err, services.ErrAdminDisabled):
+		return fiber.StatusForbidden, true
+	case errors.Is(err, services.ErrTOTPAlreadyEnabled),
+		errors.Is(err, services.ErrTOTPNotEnrolled):
+		return fiber.StatusConflict, true
+	case errors.Is(err, services.ErrMFACodeRequired):
+		return fiber.StatusBadRequest, true
+	case errors.Is(err, services.ErrTOTPLocked):
+		return fiber.StatusTooManyRequests, true
+	case errors.Is(err, services.ErrUserNotFound):
+		return fiber.StatusNotFound, true
+	}
+	return 0, false
+}
+
+func passkeyErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPasskey),
+		errors.Is(err, services.ErrPasskeyChallenge):
+		return fiber.StatusUnauthorized, true
+	case errors.Is(err, services.ErrPasskeyNotFound),
+		errors.Is(err, services.ErrUserNotFound):
+		return fiber.StatusNotFound, true
+	case errors.Is(err, services.ErrPasskeyExists):
+		return fiber.StatusConflict, true
+	}
+	return 0, false
+}
+
+// passkeyErrorMessage hides verification details, which stay in the log.
+func passkeyErrorMessage(err error) string {
+	if errors.Is(err, services.ErrInvalidPasskey) {
+		return services.ErrInvalidPasskey.Error()
+	}
+	return err.Error()
+}
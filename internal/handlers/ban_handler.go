@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// BanHandler manages user bans (admin only).
+type BanHandler struct {
+	banService *services.BanService
+}
+
+func NewBanHandler(banService *services.BanService) *BanHandler {
+	return &BanHandler{banService: banService}
+}
+
+// List returns the app's bans, newest first. ?active=true leaves out lifted
+// and expired bans.
+func (h *BanHandler) List(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	bans, total, err := h.banService.ListBans(appID, c.QueryBool("active"), limit, offset)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch bans")
+	}
+
+	return c.JSON(fiber.Map{
+		"bans":   bans,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// Ban suspends the user in :id and revokes their sessions.
+func (h *BanHandler) Ban(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	var req dto.BanUserRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.DurationDays > 0 && req.ExpiresAt != nil {
+		return apierr.New(fiber.StatusBadRequest, "Set either expires_at or a positive duration_days, not both")
+	}
+	expiresAt := req.ExpiresAt
+	if req.DurationDays > 0 {
+		until := time.Now().AddDate(0, 0, req.DurationDays)
+		expiresAt = &until
+	}
+
+	ban, err := h.banService.Ban(appID, userID, req.Reason, expiresAt, tenant.GetAdminActor(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			return apierr.New(fiber.StatusNotFound, err.Error())
+		case errors.Is(err, services.ErrInvalidBanTerm):
+			return apierr.New(fiber.StatusBadRequest, err.Error())
+		}
+		slog.Error("ban failed", "app_id", appID, "user_id", userID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to ban user")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"ban": ban})
+}
+
+// Unban lifts the active bans on the user in :id.
+func (h *BanHandler) Unban(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	if err := h.banService.Unban(appID, userID, tenant.GetAdminActor(c)); err != nil {
+		if errors.Is(err, services.ErrBanNotFound) {
+			return apierr.New(fiber.StatusNotFound, err.Error())
+		}
+		slog.Error("unban failed", "app_id", appID, "user_id", userID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to unban user")
+	}
+
+	return c.JSON(fiber.Map{"success": true})
+}
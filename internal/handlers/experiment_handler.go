@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ExperimentHandler struct {
+	experimentService *services.ExperimentService
+}
+
+func NewExperimentHandler(experimentService *services.ExperimentService) *ExperimentHandler {
+	return &ExperimentHandler{experimentService: experimentService}
+}
+
+// RecordExposure is called by the client when the variant is actually shown
+// (e.g. the paywall rendered), not merely fetched with the config.
+func (h *ExperimentHandler) RecordExposure(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	var req dto.ExperimentExposureRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	variant, err := h.experimentService.RecordExposure(appID, clientContextFromRequest(c), req.ExperimentKey)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNoSubject):
+			return apierr.New(fiber.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrExperimentNotFound), errors.Is(err, services.ErrNotEnrolled):
+			return apierr.New(fiber.StatusNotFound, err.Error())
+		}
+		return apierr.New(fiber.StatusInternalServerError, "Failed to record exposure")
+	}
+
+	return c.JSON(fiber.Map{"experiment_key": req.ExperimentKey, "variant": variant})
+}
+
+// TrackEvent records an arbitrary client event as a conversion for experiments
+// whose goal_event matches.
+func (h *ExperimentHandler) TrackEvent(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	var req dto.ExperimentEventRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	subject := services.SubjectFor(clientContextFromRequest(c))
+	converted, err := h.experimentService.TrackEvent(appID, subject, req.Event, req.ExperimentKey, "client")
+	if err != nil {
+		if errors.Is(err, services.ErrNoSubject) || errors.Is(err, services.ErrInvalidEvent) {
+			return apierr.New(fiber.StatusBadRequest, err.Error())
+		}
+		return apierr.New(fiber.StatusInternalServerError, "Failed to track event")
+	}
+
+	return c.JSON(fiber.Map{"received": true, "conversions": converted})
+}
+
+func (h *ExperimentHandler) ListExperiments(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	experiments, err := h.experimentService.ListExperiments(appID)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch experiments")
+	}
+	return c.JSON(fiber.Map{"experiments": experiments})
+}
+
+func (h *ExperimentHandler) CreateExperiment(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	var req dto.ExperimentRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	exp, err := h.experimentService.CreateExperiment(appID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrExperimentKeyTaken) {
+			return apierr.New(fiber.StatusConflict, err.Error())
+		}
+		return apierr.New(fiber.StatusBadRequest, err.Error())
+	}
+	return c.Status(fiber.StatusCreated).JSON(exp)
+}
+
+func (h *ExperimentHandler) UpdateExperiment(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid experiment ID")
+	}
+
+	var req dto.ExperimentRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	exp, err := h.experimentService.UpdateExperiment(appID, id, &req)
+	if err != nil {
+		return experimentError(c, err)
+	}
+	return c.JSON(exp)
+}
+
+func (h *ExperimentHandler) SetStatus(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid experiment ID")
+	}
+
+	var req dto.ExperimentStatusRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	exp, err := h.experimentService.SetStatus(appID, id, req.Status)
+	if err != nil {
+		return experimentError(c, err)
+	}
+	return c.JSON(exp)
+}
+
+func (h *ExperimentHandler) Report(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid experiment ID")
+	}
+
+	report, err := h.experimentService.Report(appID, id)
+	if err != nil {
+		if errors.Is(err, services.ErrExperimentNotFound) {
+			return apierr.New(fiber.StatusNotFound, err.Error())
+		}
+		return apierr.New(fiber.StatusInternalServerError, "Failed to build experiment report")
+	}
+	return c.JSON(report)
+}
+
+func experimentError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrExperimentNotFound):
+		return apierr.New(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrExperimentNotEditable):
+		return apierr.New(fiber.StatusConflict, err.Error())
+	}
+	return apierr.New(fiber.StatusBadRequest, err.Error())
+}
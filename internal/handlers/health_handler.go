@@ -8,19 +8,21 @@ import (
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 type HealthHandler struct {
+	db       *gorm.DB
 	registry *tenant.Registry
 }
 
-func NewHealthHandler(registry *tenant.Registry) *HealthHandler {
-	return &HealthHandler{registry: registry}
+func NewHealthHandler(db *gorm.DB, registry *tenant.Registry) *HealthHandler {
+	return &HealthHandler{db: db, registry: registry}
 }
 
 func (h *HealthHandler) Check(c *fiber.Ctx) error {
 	dbStatus := "ok"
-	if err := database.Ping(); err != nil {
+	if err := database.Ping(h.db); err != nil {
 		slog.Error("database ping failed", "error", err)
 		dbStatus = "unhealthy"
 	}
@@ -4,9 +4,11 @@ import (
 	"errors"
 	"strconv"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -23,23 +25,20 @@ func (h *ModerationHandler) CreateReport(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	userID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	var req dto.CreateReportRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	report, err := h.moderationService.CreateReport(appID, userID, &req)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: err.Error(),
-		})
+		if errors.Is(err, services.ErrAlreadyReported) {
+			return apierr.New(fiber.StatusConflict, err.Error())
+		}
+		return apierr.New(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(report)
@@ -49,27 +48,19 @@ func (h *ModerationHandler) BlockUser(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	blockerID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	var req dto.BlockUserRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	if err := h.moderationService.BlockUser(appID, blockerID, req.BlockedID); err != nil {
 		if errors.Is(err, services.ErrSelfBlock) || errors.Is(err, services.ErrAlreadyBlocked) {
-			return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{
-				Error: true, Message: err.Error(),
-			})
+			return apierr.New(fiber.StatusConflict, err.Error())
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to block user",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to block user")
 	}
 
 	return c.JSON(fiber.Map{"message": "User blocked successfully"})
@@ -79,22 +70,16 @@ func (h *ModerationHandler) UnblockUser(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	blockerID, err := tenant.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-			Error: true, Message: "Unauthorized",
-		})
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
 	blockedID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid user ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid user ID")
 	}
 
 	if err := h.moderationService.UnblockUser(appID, blockerID, blockedID); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to unblock user",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to unblock user")
 	}
 
 	return c.JSON(fiber.Map{"message": "User unblocked successfully"})
@@ -114,9 +99,7 @@ func (h *ModerationHandler) ListReports(c *fiber.Ctx) error {
 
 	reports, total, err := h.moderationService.ListReports(appID, status, limit, offset)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
-			Error: true, Message: "Failed to fetch reports",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch reports")
 	}
 
 	return c.JSON(fiber.Map{
@@ -131,28 +114,84 @@ func (h *ModerationHandler) ActionReport(c *fiber.Ctx) error {
 	appID := tenant.GetAppID(c)
 	reportID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid report ID",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Invalid report ID")
 	}
 
 	var req dto.ActionReportRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: "Invalid request body",
-		})
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
-	if err := h.moderationService.ActionReport(appID, reportID, &req); err != nil {
+	report, err := h.moderationService.ActionReport(appID, reportID, &req, tenant.GetAdminActor(c))
+	if err != nil {
 		if errors.Is(err, services.ErrReportNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{
-				Error: true, Message: err.Error(),
-			})
+			return apierr.New(fiber.StatusNotFound, err.Error())
 		}
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error: true, Message: err.Error(),
-		})
+		return apierr.New(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(fiber.Map{"message": "Report updated successfully", "report": report})
+}
+
+// ListMyReports shows the caller the reports they filed and their outcome.
+func (h *ModerationHandler) ListMyReports(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+	limit, err := strconv.Atoi(c.Query("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
 	}
 
-	return c.JSON(fiber.Map{"message": "Report updated successfully"})
+	reports, total, err := h.moderationService.ListMyReports(appID, userID, limit, offset)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch reports")
+	}
+
+	return c.JSON(fiber.Map{
+		"reports": reports,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// ListActions returns the moderation audit log (admin only).
+func (h *ModerationHandler) ListActions(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	var userID *uuid.UUID
+	if raw := c.Query("user_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return apierr.New(fiber.StatusBadRequest, "Invalid user_id")
+		}
+		userID = &id
+	}
+
+	actions, total, err := h.moderationService.ListActions(appID, c.Query("content_id"), userID, limit, offset)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch moderation actions")
+	}
+
+	return c.JSON(fiber.Map{
+		"actions": actions,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
 }
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/openapi"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/gofiber/fiber/v2"
+)
+
+// openAPIVersion is the info.version of the generated documents.
+const openAPIVersion = "1.0.0"
+
+// OpenAPIHandler serves the OpenAPI document of the request's app.
+type OpenAPIHandler struct {
+	spec     *openapi.Spec
+	registry *tenant.Registry
+}
+
+func NewOpenAPIHandler(spec *openapi.Spec, registry *tenant.Registry) *OpenAPIHandler {
+	return &OpenAPIHandler{spec: spec, registry: registry}
+}
+
+// Document returns the OpenAPI 3.1 document for the app in X-App-ID: the
+// core routes plus the app's plugin routes.
+func (h *OpenAPIHandler) Document(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	app := h.registry.Get(appID)
+	if app == nil {
+		return apierr.New(fiber.StatusBadRequest, "X-App-ID header is required")
+	}
+	title := app.AppName
+	if title == "" {
+		title = appID
+	}
+	doc := h.spec.Document(appID, openapi.Info{Title: title + " API", Version: openAPIVersion}, c.BaseURL())
+	return c.JSON(doc)
+}
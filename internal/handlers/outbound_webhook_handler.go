@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// OutboundWebhookHandler manages an app's outbound webhook endpoints (admin
+// only).
+type OutboundWebhookHandler struct {
+	webhookService *services.OutboundWebhookService
+}
+
+func NewOutboundWebhookHandler(webhookService *services.OutboundWebhookService) *OutboundWebhookHandler {
+	return &OutboundWebhookHandler{webhookService: webhookService}
+}
+
+func webhookErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, services.ErrWebhookNotFound):
+		return fiber.StatusNotFound, true
+	case errors.Is(err, services.ErrInvalidWebhookURL), errors.Is(err, services.ErrInvalidWebhookEvent):
+		return fiber.StatusBadRequest, true
+	}
+	return 0, false
+}
+
+// List returns the app's endpoints and the event types they can subscribe to.
+func (h *OutboundWebhookHandler) List(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	endpoints, err := h.webhookService.ListEndpoints(appID)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch webhooks")
+	}
+	return c.JSON(fiber.Map{
+		"webhooks":    endpoints,
+		"event_types": services.WebhookEventTypes,
+	})
+}
+
+// Create registers an endpoint. The signing secret is only returned here.
+func (h *OutboundWebhookHandler) Create(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	var req dto.CreateWebhookEndpointRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	endpoint, secret, err := h.webhookService.CreateEndpoint(appID, req)
+	if err != nil {
+		if status, ok := webhookErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("webhook create failed", "app_id", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to create webhook")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"webhook": endpoint,
+		"secret":  secret,
+	})
+}
+
+// Update changes an endpoint's URL, description, events or active flag.
+func (h *OutboundWebhookHandler) Update(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid webhook ID")
+	}
+	var req dto.UpdateWebhookEndpointRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	endpoint, err := h.webhookService.UpdateEndpoint(appID, id, req)
+	if err != nil {
+		if status, ok := webhookErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		return apierr.New(fiber.StatusInternalServerError, "Failed to update webhook")
+	}
+
+	return c.JSON(endpoint)
+}
+
+// Delete removes an endpoint and its delivery log.
+func (h *OutboundWebhookHandler) Delete(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid webhook ID")
+	}
+
+	if err := h.webhookService.DeleteEndpoint(appID, id); err != nil {
+		if status, ok := webhookErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		return apierr.New(fiber.StatusInternalServerError, "Failed to delete webhook")
+	}
+
+	return c.JSON(fiber.Map{"message": "Webhook deleted"})
+}
+
+// ListDeliveries returns an endpoint's delivery log, newest first.
+func (h *OutboundWebhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid webhook ID")
+	}
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	deliveries, total, err := h.webhookService.ListDeliveries(appID, id, limit, offset)
+	if err != nil {
+		if status, ok := webhookErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch deliveries")
+	}
+
+	return c.JSON(fiber.Map{
+		"deliveries": deliveries,
+		"total":      total,
+		"limit":      limit,
+		"offset":     offset,
+	})
+}
+
+// Test sends a webhook.test event to the endpoint and returns the delivery.
+func (h *OutboundWebhookHandler) Test(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid webhook ID")
+	}
+
+	delivery, err := h.webhookService.TestFire(c.UserContext(), appID, id)
+	if err != nil {
+		if status, ok := webhookErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		return apierr.New(fiber.StatusInternalServerError, "Failed to send test webhook")
+	}
+
+	return c.JSON(delivery)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PushHandler struct {
+	pushService *services.PushService
+}
+
+func NewPushHandler(pushService *services.PushService) *PushHandler {
+	return &PushHandler{pushService: pushService}
+}
+
+// RegisterDevice stores or refreshes the caller's push token. Clients should
+// call it on every launch so last_seen_at stays current.
+func (h *PushHandler) RegisterDevice(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req dto.RegisterDeviceRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	device, err := h.pushService.RegisterDevice(appID, userID, req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDevice) {
+			return apierr.New(fiber.StatusBadRequest, err.Error())
+		}
+		slog.Error("device registration failed", "app_id", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to register device")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(device)
+}
+
+// UnregisterDevice removes a push token, e.g. on sign-out.
+func (h *PushHandler) UnregisterDevice(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	if err := h.pushService.UnregisterDevice(appID, userID, c.Params("token")); err != nil {
+		if errors.Is(err, services.ErrDeviceNotFound) {
+			return apierr.New(fiber.StatusNotFound, err.Error())
+		}
+		return apierr.New(fiber.StatusInternalServerError, "Failed to unregister device")
+	}
+
+	return c.JSON(fiber.Map{"message": "Device unregistered"})
+}
+
+// MarkOpened records that the user opened a notification (client receipt).
+func (h *PushHandler) MarkOpened(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid notification ID")
+	}
+
+	if err := h.pushService.MarkOpened(appID, userID, id); err != nil {
+		if errors.Is(err, services.ErrNotificationNotFound) {
+			return apierr.New(fiber.StatusNotFound, err.Error())
+		}
+		return apierr.New(fiber.StatusInternalServerError, "Failed to record receipt")
+	}
+
+	return c.JSON(fiber.Map{"message": "Receipt recorded"})
+}
+
+// Send delivers a one-off notification to a user's devices (admin only).
+func (h *PushHandler) Send(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	var req dto.SendPushRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	category := ""
+	if req.Marketing {
+		category = services.PushCategoryMarketing
+	}
+	notification, err := h.pushService.SendToUser(c.UserContext(), appID, req.UserID, services.PushNotificationRequest{
+		Kind:     "manual",
+		Category: category,
+		Title:    req.Title,
+		Body:     req.Body,
+		Data:     req.Data,
+	})
+	if err != nil {
+		slog.Error("admin push send failed", "app_id", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to send notification")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(notification)
+}
+
+// ListNotifications lists sent notifications with delivery counts (admin only).
+func (h *PushHandler) ListNotifications(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	var userID *uuid.UUID
+	if raw := c.Query("user_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return apierr.New(fiber.StatusBadRequest, "Invalid user_id")
+		}
+		userID = &id
+	}
+
+	notifications, total, err := h.pushService.ListNotifications(appID, userID, limit, offset)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch notifications")
+	}
+
+	return c.JSON(fiber.Map{
+		"notifications": notifications,
+		"total":         total,
+		"limit":         limit,
+		"offset":        offset,
+	})
+}
+
+// ListDeliveries returns per-device receipts for a notification (admin only).
+func (h *PushHandler) ListDeliveries(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid notification ID")
+	}
+
+	deliveries, err := h.pushService.DeliveriesFor(appID, id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotificationNotFound) {
+			return apierr.New(fiber.StatusNotFound, err.Error())
+		}
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch deliveries")
+	}
+
+	return c.JSON(fiber.Map{"deliveries": deliveries})
+}
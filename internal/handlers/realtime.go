@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/middleware"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/realtime"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// sseKeepalive must stay below proxy idle timeouts (nginx defaults to 60s).
+	sseKeepalive = 20 * time.Second
+	// sseWriteTimeout replaces the server-wide WriteTimeout, which would
+	// otherwise cut every stream after 30s.
+	sseWriteTimeout = 30 * time.Second
+	sseRetryMillis  = 3000
+)
+
+// RealtimeHandler streams config changes and announcements over Server-Sent Events.
+type RealtimeHandler struct {
+	hub           *realtime.Hub
+	configService *services.RemoteConfigService
+	denylist      middleware.Denylist
+	fanout        *configFanout
+}
+
+func NewRealtimeHandler(hub *realtime.Hub, configService *services.RemoteConfigService, denylist middleware.Denylist) *RealtimeHandler {
+	return &RealtimeHandler{
+		hub:           hub,
+		configService: configService,
+		denylist:      denylist,
+		fanout:        newConfigFanout(configService.Resolve),
+	}
+}
+
+// Stream opens an SSE connection for the caller's app.
+//
+// "config" events carry the caller's resolved config: the full payload of
+// GET /api/config on first connect, or a delta (same shape as ?since=) once
+// the client's config_version is known. "announcement" events carry admin
+// broadcasts verbatim. On reconnect the client sends Last-Event-ID and gets
+// everything it missed, with config changes coalesced into one delta.
+func (h *RealtimeHandler) Stream(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	if appID == "" {
+		return apierr.New(fiber.StatusBadRequest, "X-App-ID header is required")
+	}
+
+	var version int64
+	if raw := c.Query("since"); raw != "" {
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || v < 0 {
+			return apierr.New(fiber.StatusBadRequest, "since must be a config_version")
+		}
+		version = v
+	}
+
+	lastID := int64(0)
+	rawLastID := c.Get("Last-Event-ID")
+	if rawLastID == "" {
+		// EventSource polyfills that can't set headers on reconnect use the query.
+		rawLastID = c.Query("last_event_id")
+	}
+	if rawLastID != "" {
+		id, err := strconv.ParseInt(rawLastID, 10, 64)
+		if err != nil || id < 0 {
+			return apierr.New(fiber.StatusBadRequest, "Invalid Last-Event-ID")
+		}
+		lastID = id
+	}
+
+	cc := clientContextFromRequest(c)
+	// Banned users are closed out of open streams, not only refused new ones.
+	banned := func() bool {
+		return h.denylist != nil && cc.UserID != nil && h.denylist.IsDenied(appID, *cc.UserID)
+	}
+
+	// Subscribe before reading the backlog so nothing published in between is
+	// lost; duplicates are skipped by ID below.
+	sub := h.hub.Subscribe(appID)
+
+	var backlog []models.RealtimeEvent
+	var err error
+	if lastID > 0 {
+		backlog, err = h.hub.Since(appID, lastID)
+	} else {
+		lastID, err = h.hub.LatestID(appID)
+	}
+	if err != nil {
+		h.hub.Unsubscribe(sub)
+		slog.Error("realtime backlog load failed", "app_id", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to open stream")
+	}
+	initialConfig := rawLastID == ""
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	conn := c.Context().Conn()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer h.hub.Unsubscribe(sub)
+
+		flush := func() error {
+			if err := conn.SetWriteDeadline(time.Now().Add(sseWriteTimeout)); err != nil {
+				return err
+			}
+			return w.Flush()
+		}
+
+		// sendConfig pushes the caller's config as of now, as a delta when the
+		// client's version is known. Live config events reach every stream of
+		// the app at once, so they resolve through the shared fan-out.
+		sendConfig := func(id int64, live bool) error {
+			var resolved *services.ResolvedConfig
+			var err error
+			if live {
+				resolved, err = h.fanout.resolve(appID, id, cc)
+			} else {
+				resolved, err = h.configService.Resolve(appID, cc)
+			}
+			if err != nil {
+				slog.Error("realtime config resolve failed", "app_id", appID, "error", err)
+				return nil
+			}
+			if version > 0 && resolved.UpdatedAt.Unix() == version {
+				return nil
+			}
+			var since time.Time
+			if version > 0 {
+				since = time.Unix(version, 0)
+			}
+			version = resolved.UpdatedAt.Unix()
+			return writeSSE(w, id, realtime.EventConfig, configResponse(resolved, cc, since))
+		}
+
+		fmt.Fprintf(w, "retry: %d\n\n", sseRetryMillis)
+
+		// Replay: announcements in order, config changes coalesced into one
+		// delta tagged with the newest config event's ID.
+		var configID int64
+		for _, event := range backlog {
+			if event.Type == realtime.EventConfig {
+				configID = event.ID
+				continue
+			}
+			if err := writeSSE(w, event.ID, event.Type, event.Data); err != nil {
+				return
+			}
+		}
+		if len(backlog) > 0 {
+			lastID = backlog[len(backlog)-1].ID
+		}
+		if initialConfig || configID > 0 {
+			if configID == 0 {
+				configID = lastID
+			}
+			if err := sendConfig(configID, false); err != nil {
+				return
+			}
+		}
+		if err := flush(); err != nil {
+			return
+		}
+
+		keepalive := time.NewTicker(sseKeepalive)
+		defer keepalive.Stop()
+		for {
+			select {
+			case event, ok := <-sub.Events():
+				if !ok {
+					// Dropped for falling behind, or the server is shutting down.
+					return
+				}
+				if event.ID <= lastID {
+					continue
+				}
+				if banned() {
+					return
+				}
+				lastID = event.ID
+				if event.Type == realtime.EventConfig {
+					err = sendConfig(event.ID, true)
+				} else {
+					err = writeSSE(w, event.ID, event.Type, event.Data)
+				}
+			case <-keepalive.C:
+				if banned() {
+					return
+				}
+				_, err = w.WriteString(": keepalive\n\n")
+			}
+			if err == nil {
+				err = flush()
+			}
+			if err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// configFanout resolves config once per config event and client context, so
+// a change pushed to thousands of streams doesn't resolve thousands of times.
+// Apps without rules or experiments resolve the same for every client; once
+// one stream has resolved such an event, all the others share its result.
+type configFanout struct {
+	resolveFn func(appID string, cc services.ClientContext) (*services.ResolvedConfig, error)
+
+	mu   sync.Mutex
+	apps map[string]*fanoutEvent
+}
+
+// fanoutEvent holds the results for an app's latest config event.
+type fanoutEvent struct {
+	id       int64
+	shared   *fanoutResult
+	byClient map[string]*fanoutResult
+}
+
+type fanoutResult struct {
+	done     chan struct{}
+	resolved *services.ResolvedConfig
+	err      error
+}
+
+func newConfigFanout(resolve func(appID string, cc services.ClientContext) (*services.ResolvedConfig, error)) *configFanout {
+	return &configFanout{resolveFn: resolve, apps: make(map[string]*fanoutEvent)}
+}
+
+// resolve returns cc's config for config event id. Each caller gets its own
+// copy of the values, since configResponse adds per-client fields to them.
+func (f *configFanout) resolve(appID string, id int64, cc services.ClientContext) (*services.ResolvedConfig, error) {
+	key := clientKey(cc)
+
+	f.mu.Lock()
+	ev := f.apps[appID]
+	if ev == nil || ev.id < id {
+		ev = &fanoutEvent{id: id, byClient: make(map[string]*fanoutResult)}
+		f.apps[appID] = ev
+	}
+	r := ev.shared
+	if r == nil {
+		r = ev.byClient[key]
+	}
+	owner := r == nil
+	if owner {
+		r = &fanoutResult{done: make(chan struct{})}
+		ev.byClient[key] = r
+	}
+	f.mu.Unlock()
+
+	if owner {
+		r.resolved, r.err = f.resolveFn(appID, cc)
+		f.mu.Lock()
+		switch {
+		case r.err != nil:
+			// Callers already waiting share the error; later ones retry.
+			delete(ev.byClient, key)
+		case !r.resolved.Personalized:
+			ev.shared = r
+		}
+		f.mu.Unlock()
+		close(r.done)
+	}
+	<-r.done
+	if r.err != nil {
+		return nil, r.err
+	}
+	resolved := *r.resolved
+	resolved.Values = maps.Clone(r.resolved.Values)
+	return &resolved, nil
+}
+
+// clientKey identifies the inputs Resolve depends on.
+func clientKey(cc services.ClientContext) string {
+	user := ""
+	if cc.UserID != nil {
+		user = cc.UserID.String()
+	}
+	return strings.Join([]string{cc.AppVersion, cc.Platform, cc.Locale, cc.Country, cc.DeviceID, user}, "\x00")
+}
+
+// Announce broadcasts an announcement to every connected client of the app (admin only).
+func (h *RealtimeHandler) Announce(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	var req dto.AnnouncementRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Title == "" && req.Message == "" {
+		return apierr.New(fiber.StatusBadRequest, "title or message is required")
+	}
+	if req.Level == "" {
+		req.Level = "info"
+	}
+
+	event, err := h.hub.Publish(appID, realtime.EventAnnouncement, req)
+	if err != nil {
+		slog.Error("announcement publish failed", "app_id", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to publish announcement")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(event)
+}
+
+// writeSSE writes one event frame with a JSON payload.
+func writeSSE(w *bufio.Writer, id int64, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	// Compact JSON never contains raw newlines, so a single data line is enough.
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, payload)
+	return err
+}
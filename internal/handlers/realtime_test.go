@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/google/uuid"
+)
+
+func countingResolver(personalized bool, calls *atomic.Int32) func(string, services.ClientContext) (*services.ResolvedConfig, error) {
+	return func(appID string, cc services.ClientContext) (*services.ResolvedConfig, error) {
+		n := calls.Add(1)
+		return &services.ResolvedConfig{
+			Values:       map[string]interface{}{"platform": cc.Platform, "call": n},
+			Personalized: personalized,
+		}, nil
+	}
+}
+
+func TestConfigFanoutSharesUnpersonalizedConfig(t *testing.T) {
+	var calls atomic.Int32
+	f := newConfigFanout(countingResolver(false, &calls))
+
+	// Two waves of streams for the same event, all different users.
+	for wave := 0; wave < 2; wave++ {
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				id := uuid.New()
+				if _, err := f.resolve("app", 7, services.ClientContext{UserID: &id}); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+		if wave == 0 {
+			calls.Store(0)
+		}
+	}
+	// The first wave may race before the first result is known to be shared;
+	// once it is, nobody resolves again.
+	if n := calls.Load(); n != 0 {
+		t.Errorf("second wave resolved %d times, want 0", n)
+	}
+
+	f.resolve("app", 8, services.ClientContext{})
+	if n := calls.Load(); n != 1 {
+		t.Errorf("a new config event resolved %d times, want 1", n)
+	}
+}
+
+func TestConfigFanoutResolvesPersonalizedConfigPerClient(t *testing.T) {
+	var calls atomic.Int32
+	f := newConfigFanout(countingResolver(true, &calls))
+
+	user := uuid.New()
+	for i := 0; i < 3; i++ {
+		for _, platform := range []string{"ios", "android"} {
+			res, err := f.resolve("app", 1, services.ClientContext{Platform: platform, UserID: &user})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Values["platform"] != platform {
+				t.Fatalf("%s stream got config for %v", platform, res.Values["platform"])
+			}
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("resolved %d times, want once per distinct client", n)
+	}
+
+	other := uuid.New()
+	f.resolve("app", 1, services.ClientContext{Platform: "ios", UserID: &other})
+	f.resolve("other_app", 1, services.ClientContext{Platform: "ios", UserID: &user})
+	if n := calls.Load(); n != 4 {
+		t.Errorf("resolved %d times, want another user and another app resolved separately", n)
+	}
+}
+
+func TestConfigFanoutCopiesValues(t *testing.T) {
+	var calls atomic.Int32
+	f := newConfigFanout(countingResolver(false, &calls))
+
+	a, _ := f.resolve("app", 1, services.ClientContext{})
+	a.Values["update_required"] = true
+	b, _ := f.resolve("app", 1, services.ClientContext{})
+	if _, ok := b.Values["update_required"]; ok {
+		t.Error("a per-stream field leaked into another stream's config")
+	}
+}
+
+func TestConfigFanoutRetriesErrors(t *testing.T) {
+	var calls atomic.Int32
+	boom := errors.New("boom")
+	f := newConfigFanout(func(string, services.ClientContext) (*services.ResolvedConfig, error) {
+		calls.Add(1)
+		return nil, boom
+	})
+	for i := 0; i < 3; i++ {
+		if _, err := f.resolve("app", 1, services.ClientContext{DeviceID: fmt.Sprint(i % 2)}); !errors.Is(err, boom) {
+			t.Fatalf("err = %v, want the resolve error", err)
+		}
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("resolved %d times, want failed results not to be reused", n)
+	}
+}
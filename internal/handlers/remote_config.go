@@ -2,168 +2,225 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
+	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
+	"github.com/google/uuid"
 )
 
 // RemoteConfigHandler handles remote configuration operations
 type RemoteConfigHandler struct {
-	db *gorm.DB
+	configService *services.RemoteConfigService
 }
 
 // NewRemoteConfigHandler creates a new remote config handler
-func NewRemoteConfigHandler(db *gorm.DB) *RemoteConfigHandler {
-	return &RemoteConfigHandler{db: db}
+func NewRemoteConfigHandler(configService *services.RemoteConfigService) *RemoteConfigHandler {
+	return &RemoteConfigHandler{configService: configService}
 }
 
 // GetConfig returns all config for the current app (public endpoint)
-// Tenant is identified via X-App-ID header by TenantMiddleware
+// Tenant is identified via X-App-ID header by TenantMiddleware.
+// Values are resolved against targeting rules using the client context sent in
+// X-App-Version / X-Platform / X-Locale / X-Country / X-Device-ID headers (or the
+// matching query params) and the optional Bearer token.
 func (h *RemoteConfigHandler) GetConfig(c *fiber.Ctx) error {
 	appID := c.Locals("app_id")
 	if appID == nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   true,
-			"message": "X-App-ID header is required",
-		})
+		return apierr.New(fiber.StatusBadRequest, "X-App-ID header is required")
 	}
 
-	var configs []models.RemoteConfig
-	if err := h.db.Where("app_id = ?", appID).Find(&configs).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   true,
-			"message": "Database error",
-		})
+	appIDStr, ok := appID.(string)
+	if !ok {
+		return apierr.New(fiber.StatusInternalServerError, "Internal error: invalid app context")
 	}
 
-	result := make(map[string]interface{})
-	var maxUpdated time.Time
-
-	for _, cfg := range configs {
-		switch cfg.Type {
-		case "bool":
-			result[cfg.Key] = cfg.Value == "true" || cfg.Value == "1"
-		case "int":
-			if v, err := strconv.Atoi(cfg.Value); err == nil {
-				result[cfg.Key] = v
-			} else {
-				result[cfg.Key] = cfg.Value
-			}
-		case "json":
-			var parsed interface{}
-			if err := json.Unmarshal([]byte(cfg.Value), &parsed); err == nil {
-				result[cfg.Key] = parsed
-			} else {
-				slog.Warn("remote config value is not valid JSON, using raw string", "key", cfg.Key, "error", err)
-				result[cfg.Key] = cfg.Value
-			}
-		default:
-			result[cfg.Key] = cfg.Value
+	var since time.Time
+	if raw := c.Query("since"); raw != "" {
+		secs, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || secs < 0 {
+			return apierr.New(fiber.StatusBadRequest, "since must be a config_version")
 		}
-		if cfg.UpdatedAt.After(maxUpdated) {
-			maxUpdated = cfg.UpdatedAt
+		since = time.Unix(secs, 0)
+	}
+
+	cc := clientContextFromRequest(c)
+	resolved, err := h.configService.Resolve(appIDStr, cc)
+	if err != nil {
+		slog.Error("remote config resolve failed", "app_id", appIDStr, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Database error")
+	}
+
+	// Set cache headers. Once targeting rules exist the response depends on the
+	// caller, so shared caches must not serve one user's values to another.
+	if resolved.Personalized {
+		c.Set("Cache-Control", "private, max-age=60")
+		c.Set("Vary", "Authorization, X-App-ID, X-App-Version, X-Platform, X-Locale, X-Country, X-Device-ID")
+	} else {
+		c.Set("Cache-Control", "public, max-age=60")
+	}
+	if !resolved.UpdatedAt.IsZero() {
+		c.Set("Last-Modified", resolved.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+
+	var body []byte
+	var etag string
+	if !resolved.Personalized && cc.AppVersion == "" && since.IsZero() {
+		// Every client gets identical bytes; reuse the snapshot's serialisation.
+		body, etag, err = resolved.SharedBody()
+	} else {
+		body, err = json.Marshal(configResponse(resolved, cc, since))
+		etag = services.StrongETag(body)
+	}
+	if err != nil {
+		slog.Error("remote config encode failed", "app_id", appIDStr, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to encode config")
+	}
+
+	c.Set(fiber.HeaderETag, etag)
+	if notModified(c, etag, resolved.UpdatedAt) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(body)
+}
+
+// configResponse builds the GET /api/config payload. With a non-zero since it
+// returns only keys changed after that config_version plus deleted keys.
+func configResponse(resolved *services.ResolvedConfig, cc services.ClientContext, since time.Time) map[string]interface{} {
+	result := resolved.Values
+
+	// min_app_version is rule-aware, so the client can't compare against a single
+	// global value — tell it directly whether this build must update.
+	if minVersion, ok := result["min_app_version"].(string); ok && cc.AppVersion != "" && minVersion != "" {
+		if cmp, err := services.CompareVersions(cc.AppVersion, minVersion); err == nil {
+			result["update_required"] = cmp < 0
 		}
 	}
 
-	// Add config version for cache invalidation
-	result["config_version"] = maxUpdated.Unix()
+	if since.IsZero() {
+		// Add config version for cache invalidation
+		result["config_version"] = resolved.UpdatedAt.Unix()
+		return result
+	}
 
-	// Set cache headers
-	c.Set("Cache-Control", "public, max-age=60")
-	if !maxUpdated.IsZero() {
-		c.Set("Last-Modified", maxUpdated.UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT"))
+	changed, deleted := resolved.Delta(since)
+	sort.Strings(deleted)
+	delta := map[string]interface{}{
+		"delta":          true,
+		"since":          since.Unix(),
+		"config_version": resolved.UpdatedAt.Unix(),
+		"changed":        changed,
+		"deleted":        deleted,
+	}
+	if v, ok := result["update_required"]; ok {
+		delta["update_required"] = v
 	}
+	return delta
+}
 
-	return c.JSON(result)
+// notModified evaluates If-None-Match, falling back to If-Modified-Since only
+// when no entity tag was sent (RFC 9110 §13.2.2).
+func notModified(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if inm := c.Get(fiber.HeaderIfNoneMatch); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := c.Get(fiber.HeaderIfModifiedSince); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !lastModified.Truncate(time.Second).After(t)
+	}
+	return false
 }
 
-// SetConfigKey creates or updates a config value (admin only)
+// clientContextFromRequest reads targeting attributes from headers, falling back
+// to query params for clients that can't set custom headers.
+func clientContextFromRequest(c *fiber.Ctx) services.ClientContext {
+	pick := func(header, query string) string {
+		if v := strings.TrimSpace(c.Get(header)); v != "" {
+			return v
+		}
+		return strings.TrimSpace(c.Query(query))
+	}
+
+	cc := services.ClientContext{
+		AppVersion: pick("X-App-Version", "app_version"),
+		Platform:   strings.ToLower(pick("X-Platform", "platform")),
+		Locale:     pick("X-Locale", "locale"),
+		Country:    strings.ToUpper(pick("X-Country", "country")),
+		DeviceID:   pick("X-Device-ID", "device_id"),
+	}
+	if cc.Locale == "" {
+		// First tag of Accept-Language, without quality weights.
+		if al := c.Get("Accept-Language"); al != "" {
+			tag := strings.SplitN(al, ",", 2)[0]
+			cc.Locale = strings.TrimSpace(strings.SplitN(tag, ";", 2)[0])
+		}
+	}
+	if len(cc.DeviceID) > 128 {
+		cc.DeviceID = cc.DeviceID[:128]
+	}
+	if userID, err := tenant.GetUserID(c); err == nil {
+		cc.UserID = &userID
+	}
+	return cc
+}
+
+// SetConfigKey creates or updates a config value (admin only).
+// Values are validated against their type (and JSON Schema, if set) and every
+// change is recorded as a revision attributed to the calling admin.
 func (h *RemoteConfigHandler) SetConfigKey(c *fiber.Ctx) error {
 	appID := c.Locals("app_id")
 	if appID == nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   true,
-			"message": "X-App-ID header is required",
-		})
+		return apierr.New(fiber.StatusBadRequest, "X-App-ID header is required")
 	}
 
 	key := c.Params("key")
 	if key == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   true,
-			"message": "Key is required",
-		})
-	}
-
-	var req struct {
-		Value string `json:"value"`
-		Type  string `json:"type"`
-	}
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   true,
-			"message": "Invalid request body",
-		})
-	}
-
-	if req.Value == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   true,
-			"message": "Value is required",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Key is required")
 	}
 
-	if req.Type == "" {
-		req.Type = "string"
+	var req dto.SetConfigRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
 	}
 
 	appIDStr, ok := appID.(string)
 	if !ok {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   true,
-			"message": "Internal error: invalid app context",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Internal error: invalid app context")
 	}
 
-	var cfg models.RemoteConfig
-	result := h.db.Where("app_id = ? AND key = ?", appIDStr, key).First(&cfg)
-
-	if result.Error != nil {
-		// Create new
-		cfg = models.RemoteConfig{
-			AppID: appIDStr,
-			Key:   key,
-			Value: req.Value,
-			Type:  req.Type,
-		}
-		if err := h.db.Create(&cfg).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error":   true,
-				"message": "Failed to create config",
-			})
-		}
-	} else {
-		// Update existing
-		if err := h.db.Model(&cfg).Updates(map[string]interface{}{
-			"value":      req.Value,
-			"type":       req.Type,
-			"updated_at": time.Now(),
-		}).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error":   true,
-				"message": "Failed to update config",
-			})
+	cfg, err := h.configService.SetKey(appIDStr, key, &req, tenant.GetAdminActor(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidConfigValue) {
+			return apierr.New(fiber.StatusBadRequest, err.Error())
 		}
+		slog.Error("remote config update failed", "app_id", appIDStr, "key", key, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to update config")
 	}
 
 	return c.JSON(fiber.Map{
-		"key":        key,
-		"value":      req.Value,
-		"type":       req.Type,
+		"key":        cfg.Key,
+		"value":      cfg.Value,
+		"type":       cfg.Type,
+		"schema":     cfg.Schema,
 		"updated_at": cfg.UpdatedAt,
 	})
 }
@@ -172,33 +229,25 @@ func (h *RemoteConfigHandler) SetConfigKey(c *fiber.Ctx) error {
 func (h *RemoteConfigHandler) DeleteConfigKey(c *fiber.Ctx) error {
 	appID := c.Locals("app_id")
 	if appID == nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   true,
-			"message": "X-App-ID header is required",
-		})
+		return apierr.New(fiber.StatusBadRequest, "X-App-ID header is required")
 	}
 
 	appIDStr, ok := appID.(string)
 	if !ok {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   true,
-			"message": "Internal error: invalid app context",
-		})
+		return apierr.New(fiber.StatusInternalServerError, "Internal error: invalid app context")
 	}
 
 	key := c.Params("key")
 	if key == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   true,
-			"message": "Key is required",
-		})
+		return apierr.New(fiber.StatusBadRequest, "Key is required")
 	}
 
-	if err := h.db.Where("app_id = ? AND key = ?", appIDStr, key).Delete(&models.RemoteConfig{}).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   true,
-			"message": "Failed to delete config",
-		})
+	if err := h.configService.DeleteKey(appIDStr, key, tenant.GetAdminActor(c)); err != nil {
+		if errors.Is(err, services.ErrConfigKeyNotFound) {
+			return apierr.New(fiber.StatusNotFound, err.Error())
+		}
+		slog.Error("remote config delete failed", "app_id", appIDStr, "key", key, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to delete config")
 	}
 
 	return c.JSON(fiber.Map{
@@ -206,6 +255,152 @@ func (h *RemoteConfigHandler) DeleteConfigKey(c *fiber.Ctx) error {
 	})
 }
 
+// GetConfigHistory lists the revisions of a config key, newest first (admin only)
+func (h *RemoteConfigHandler) GetConfigHistory(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	key := c.Params("key")
+	limit, err := strconv.Atoi(c.Query("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	revisions, total, err := h.configService.History(appID, key, limit, offset)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch config history")
+	}
+
+	return c.JSON(fiber.Map{
+		"key":       key,
+		"revisions": revisions,
+		"total":     total,
+		"limit":     limit,
+		"offset":    offset,
+	})
+}
+
+// RollbackConfigKey restores a config key to the state written by a revision (admin only)
+func (h *RemoteConfigHandler) RollbackConfigKey(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	key := c.Params("key")
+	revision, err := strconv.Atoi(c.Params("revision"))
+	if err != nil || revision <= 0 {
+		return apierr.New(fiber.StatusBadRequest, "Invalid revision")
+	}
+
+	cfg, err := h.configService.Rollback(appID, key, revision, tenant.GetAdminActor(c))
+	if err != nil {
+		if errors.Is(err, services.ErrRevisionNotFound) {
+			return apierr.New(fiber.StatusNotFound, err.Error())
+		}
+		slog.Error("remote config rollback failed", "app_id", appID, "key", key, "revision", revision, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to roll back config")
+	}
+
+	if cfg == nil {
+		return c.JSON(fiber.Map{
+			"message":  "Config key deleted",
+			"key":      key,
+			"revision": revision,
+		})
+	}
+	return c.JSON(fiber.Map{
+		"key":        cfg.Key,
+		"value":      cfg.Value,
+		"type":       cfg.Type,
+		"schema":     cfg.Schema,
+		"updated_at": cfg.UpdatedAt,
+		"revision":   revision,
+	})
+}
+
+// ListRules returns the targeting rules for a config key (admin only)
+func (h *RemoteConfigHandler) ListRules(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	key := c.Params("key")
+	if key == "" {
+		return apierr.New(fiber.StatusBadRequest, "Key is required")
+	}
+
+	rules, err := h.configService.ListRules(appID, key)
+	if err != nil {
+		return apierr.New(fiber.StatusInternalServerError, "Failed to fetch config rules")
+	}
+
+	return c.JSON(fiber.Map{"key": key, "rules": rules})
+}
+
+// CreateRule adds a targeting rule to a config key (admin only)
+func (h *RemoteConfigHandler) CreateRule(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	key := c.Params("key")
+	if key == "" {
+		return apierr.New(fiber.StatusBadRequest, "Key is required")
+	}
+
+	var req dto.RemoteConfigRuleRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	rule, err := h.configService.CreateRule(appID, key, &req, tenant.GetAdminActor(c))
+	if err != nil {
+		if errors.Is(err, services.ErrConfigKeyNotFound) {
+			return apierr.New(fiber.StatusNotFound, err.Error())
+		}
+		return apierr.New(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+// UpdateRule replaces a targeting rule (admin only)
+func (h *RemoteConfigHandler) UpdateRule(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	ruleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid rule ID")
+	}
+
+	var req dto.RemoteConfigRuleRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	rule, err := h.configService.UpdateRule(appID, ruleID, &req, tenant.GetAdminActor(c))
+	if err != nil {
+		if errors.Is(err, services.ErrRuleNotFound) || errors.Is(err, services.ErrConfigKeyNotFound) {
+			return apierr.New(fiber.StatusNotFound, err.Error())
+		}
+		return apierr.New(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(rule)
+}
+
+// DeleteRule removes a targeting rule (admin only)
+func (h *RemoteConfigHandler) DeleteRule(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	ruleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "Invalid rule ID")
+	}
+
+	if err := h.configService.DeleteRule(appID, ruleID, tenant.GetAdminActor(c)); err != nil {
+		if errors.Is(err, services.ErrRuleNotFound) {
+			return apierr.New(fiber.StatusNotFound, err.Error())
+		}
+		return apierr.New(fiber.StatusInternalServerError, "Failed to delete config rule")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Config rule deleted",
+	})
+}
+
 // paywallConfigJSON is the default paywall config for subscription apps.
 // Edit via admin API: PUT /api/admin/config/paywall_config
 // with body: {"value": "<escaped JSON>", "type": "json"}
@@ -271,11 +466,8 @@ func (h *RemoteConfigHandler) SeedDefaults(appRegistry map[string]string) {
 		}
 
 		for _, def := range defaults {
-			var existing models.RemoteConfig
-			if h.db.Where("app_id = ? AND key = ?", def.AppID, def.Key).First(&existing).Error != nil {
-				if err := h.db.Create(&def).Error; err != nil {
-					slog.Error("failed to seed config", "key", def.Key, "error", err)
-				}
+			if err := h.configService.SeedDefault(def); err != nil {
+				slog.Error("failed to seed config", "key", def.Key, "error", err)
 			}
 		}
 	}
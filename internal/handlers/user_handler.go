@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/media"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type UserHandler struct {
+	authService *services.AuthService
+	profiles    *services.ProfileService
+	preferences *services.PreferenceService
+	streaks     *services.StreakRecomputeService
+}
+
+func NewUserHandler(authService *services.AuthService, profiles *services.ProfileService, preferences *services.PreferenceService, streaks *services.StreakRecomputeService) *UserHandler {
+	return &UserHandler{authService: authService, profiles: profiles, preferences: preferences, streaks: streaks}
+}
+
+// GetProfile returns the caller's profile.
+func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	profile, err := h.profiles.GetProfile(appID, userID)
+	if err != nil {
+		if status, ok := profileErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("profile fetch failed", "app_id", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load profile")
+	}
+	return c.JSON(profile)
+}
+
+// UpdateProfile changes the fields present in the body. A timezone change
+// rebuilds streaks like PUT /me/timezone.
+func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req dto.UpdateProfileRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	profile, tzChanged, err := h.profiles.UpdateProfile(appID, userID, req)
+	if err != nil {
+		if status, ok := profileErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("profile update failed", "app_id", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to update profile")
+	}
+	if tzChanged {
+		h.recomputeStreaks(appID, userID)
+	}
+	return c.JSON(profile)
+}
+
+// UploadAvatar replaces the caller's avatar with the multipart "avatar" image.
+func (h *UserHandler) UploadAvatar(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	fileHeader, err := c.FormFile("avatar")
+	if err != nil {
+		return apierr.New(fiber.StatusBadRequest, "avatar field is required")
+	}
+	data, err := media.ReadUpload(fileHeader, services.AvatarMaxBytes)
+	if err != nil {
+		if errors.Is(err, media.ErrTooLarge) {
+			return apierr.New(fiber.StatusBadRequest, "avatar exceeds maximum size of 5MB")
+		}
+		slog.Error("avatar upload: read failed", "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "failed to read uploaded file")
+	}
+
+	profile, err := h.profiles.SetAvatar(appID, userID, data, fileHeader.Header.Get("Content-Type"))
+	if err != nil {
+		if status, ok := profileErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("avatar upload failed", "app_id", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to save avatar")
+	}
+	return c.JSON(profile)
+}
+
+func (h *UserHandler) DeleteAvatar(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	profile, err := h.profiles.RemoveAvatar(appID, userID)
+	if err != nil {
+		if status, ok := profileErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("avatar removal failed", "app_id", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to remove avatar")
+	}
+	return c.JSON(profile)
+}
+
+// ChangeEmail mails a confirmation link to the new address; the email
+// changes once it is confirmed at POST /auth/email/verify.
+func (h *UserHandler) ChangeEmail(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req dto.ChangeEmailRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.profiles.RequestEmailChange(appID, userID, req); err != nil {
+		if status, ok := profileErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("email change request failed", "app_id", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to send verification email")
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"message": "Verification email sent"})
+}
+
+// VerifyEmail confirms an email change with the mailed token. It is public:
+// the link may be opened on a device that isn't signed in.
+func (h *UserHandler) VerifyEmail(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	var req dto.VerifyEmailRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.profiles.ConfirmEmailChange(appID, req.Token); err != nil {
+		if status, ok := profileErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("email change confirmation failed", "app_id", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to confirm email")
+	}
+	return c.JSON(fiber.Map{"message": "Email updated. Please sign in again."})
+}
+
+// ListPreferences returns every preferences namespace available to the app,
+// with the caller's values.
+func (h *UserHandler) ListPreferences(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	out := make(map[string]interface{})
+	for _, ns := range h.preferences.Namespaces(appID) {
+		prefs, err := h.preferences.Get(appID, userID, ns)
+		if err != nil {
+			slog.Error("preferences fetch failed", "app_id", appID, "namespace", ns, "error", err)
+			return apierr.New(fiber.StatusInternalServerError, "Failed to load preferences")
+		}
+		out[ns] = prefs
+	}
+	return c.JSON(out)
+}
+
+func (h *UserHandler) GetPreferences(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	prefs, err := h.preferences.Get(appID, userID, c.Params("namespace"))
+	if err != nil {
+		if status, ok := profileErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("preferences fetch failed", "app_id", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to load preferences")
+	}
+	return c.JSON(prefs)
+}
+
+// UpdatePreferences merges a JSON object into one namespace.
+func (h *UserHandler) UpdatePreferences(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	prefs, err := h.preferences.Patch(appID, userID, c.Params("namespace"), c.Body())
+	if err != nil {
+		if status, ok := profileErrorStatus(err); ok {
+			return apierr.New(status, err.Error())
+		}
+		slog.Error("preferences update failed", "app_id", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to update preferences")
+	}
+	return c.JSON(prefs)
+}
+
+func profileErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, services.ErrInvalidDisplayName),
+		errors.Is(err, services.ErrInvalidLocale),
+		errors.Is(err, services.ErrInvalidBirthYear),
+		errors.Is(err, services.ErrInvalidTimezone),
+		errors.Is(err, services.ErrInvalidEmail),
+		errors.Is(err, services.ErrEmailUnchanged),
+		errors.Is(err, services.ErrPasswordRequired),
+		errors.Is(err, services.ErrInvalidEmailToken),
+		errors.Is(err, services.ErrInvalidPreferences),
+		errors.Is(err, media.ErrUnsupportedType),
+		errors.Is(err, media.ErrContentMismatch):
+		return fiber.StatusBadRequest, true
+	case errors.Is(err, services.ErrInvalidCredentials):
+		return fiber.StatusUnauthorized, true
+	case errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrUnknownPreferences):
+		return fiber.StatusNotFound, true
+	case errors.Is(err, services.ErrEmailTaken):
+		return fiber.StatusConflict, true
+	case errors.Is(err, services.ErrMailNotConfigured):
+		return fiber.StatusServiceUnavailable, true
+	}
+	return 0, false
+}
+
+// UpdateTimezone sets the caller's IANA timezone. Streaks are rebuilt in the
+// background so existing days are re-bucketed into the new local day.
+func (h *UserHandler) UpdateTimezone(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	userID, err := tenant.GetUserID(c)
+	if err != nil {
+		return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req dto.UpdateTimezoneRequest
+	if err := validation.Bind(c, &req); err != nil {
+		return err
+	}
+
+	changed, err := h.authService.UpdateTimezone(appID, userID, req.Timezone)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTimezone):
+			return apierr.New(fiber.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrUserNotFound):
+			return apierr.New(fiber.StatusNotFound, err.Error())
+		}
+		slog.Error("timezone update failed", "app_id", appID, "error", err)
+		return apierr.New(fiber.StatusInternalServerError, "Failed to update timezone")
+	}
+
+	if changed {
+		h.recomputeStreaks(appID, userID)
+	}
+
+	return c.JSON(fiber.Map{"timezone": strings.TrimSpace(req.Timezone), "recomputing": changed})
+}
+
+// recomputeStreaks rebuilds the user's streaks in the background after a
+// timezone change, so existing days are re-bucketed into the new local day.
+func (h *UserHandler) recomputeStreaks(appID string, userID uuid.UUID) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("panic in streak recompute goroutine", "recover", r)
+			}
+		}()
+		if err := h.streaks.RecomputeUser(appID, userID); err != nil {
+			slog.Error("streak recompute after timezone change failed", "app_id", appID, "user_id", userID, "error", err)
+		}
+	}()
+}
+
+// RecomputeStreaks rebuilds streak rows for every user of the app (admin only).
+// Runs in the background; progress is logged.
+func (h *UserHandler) RecomputeStreaks(c *fiber.Ctx) error {
+	appID := tenant.GetAppID(c)
+	if !h.streaks.Supports(appID) {
+		return apierr.New(fiber.StatusNotFound, services.ErrNoStreaks.Error())
+	}
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("panic in streak recompute goroutine", "recover", r)
+			}
+		}()
+		done, failed, err := h.streaks.RecomputeApp(appID)
+		if err != nil {
+			slog.Error("app streak recompute failed", "app_id", appID, "error", err)
+			return
+		}
+		slog.Info("app streak recompute completed", "app_id", appID, "users", done, "failed", failed)
+	}()
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"message": "Streak recompute started"})
+}
@@ -0,0 +1,73 @@
+// Package locale resolves a user's preferred language, for copy and AI
+// responses written on their behalf.
+package locale
+
+import (
+	"strings"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+	"gorm.io/gorm"
+)
+
+// Normalize parses a BCP 47 tag such as "pt-br" and returns its canonical
+// form ("pt-BR"). ok is false for malformed or unknown tags.
+func Normalize(tag string) (string, bool) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" || len(tag) > 35 {
+		return "", false
+	}
+	t, err := language.Parse(tag)
+	if err != nil || t == language.Und {
+		return "", false
+	}
+	return t.String(), true
+}
+
+// ForUser returns the user's locale tag, or "" if unset or the user can't
+// be loaded.
+func ForUser(db *gorm.DB, appID string, userID uuid.UUID) string {
+	var tags []string
+	db.Model(&models.User{}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("id = ?", userID).
+		Limit(1).
+		Pluck("locale", &tags)
+	if len(tags) == 0 {
+		return ""
+	}
+	return tags[0]
+}
+
+// Name returns the English name of the tag's language, e.g. "Brazilian
+// Portuguese" for "pt-BR", or "" if it can't be named.
+func Name(tag string) string {
+	t, err := language.Parse(tag)
+	if err != nil {
+		return ""
+	}
+	return display.English.Tags().Name(t)
+}
+
+// PromptInstruction returns a sentence to append to an AI system prompt so
+// the model answers in the user's language. It is empty for unset and
+// English locales, whose prompts need no change. JSON keys and enum values
+// stay in English so responses still parse.
+func PromptInstruction(tag string) string {
+	t, err := language.Parse(tag)
+	if err != nil || tag == "" {
+		return ""
+	}
+	if base, _ := t.Base(); base.String() == "en" {
+		return ""
+	}
+	name := Name(tag)
+	if name == "" {
+		return ""
+	}
+	return "\n\nWrite all human-readable text in " + name + ". " +
+		"Keep JSON keys, enum values and dates exactly as specified, in English."
+}
@@ -0,0 +1,125 @@
+// Package localtime maps instants onto a user's local calendar day.
+//
+// Civil dates are represented as time.Time values at midnight UTC, so they
+// compare with Equal, subtract to whole days, and format with "2006-01-02"
+// without carrying the user's zone around.
+package localtime
+
+import (
+	"sort"
+	"sync"
+	"time"
+	// Embed the IANA database so zones resolve on hosts without tzdata.
+	_ "time/tzdata"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DateFormat is the layout for civil dates in API responses and date columns.
+const DateFormat = "2006-01-02"
+
+var locations sync.Map // name -> *time.Location
+
+// Valid reports whether name is a loadable IANA zone. "Local" is rejected
+// because it means the server's zone, not the user's.
+func Valid(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// Location returns the named zone, or UTC when it is empty or unknown.
+func Location(name string) *time.Location {
+	if name == "" || name == "UTC" || name == "Local" {
+		return time.UTC
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	locations.Store(name, loc)
+	return loc
+}
+
+// ForUser returns the user's configured zone, or UTC if unset or the user
+// can't be loaded.
+func ForUser(db *gorm.DB, appID string, userID uuid.UUID) *time.Location {
+	var names []string
+	db.Model(&models.User{}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("id = ?", userID).
+		Limit(1).
+		Pluck("timezone", &names)
+	if len(names) == 0 {
+		return time.UTC
+	}
+	return Location(names[0])
+}
+
+// Day returns the civil date of t in loc.
+func Day(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Today returns the current civil date in loc.
+func Today(loc *time.Location) time.Time {
+	return Day(time.Now(), loc)
+}
+
+// DaysBetween returns the number of calendar days from one civil date to
+// another (negative if to is earlier).
+func DaysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// StartOfDay returns the instant a civil date begins in loc, for range
+// queries against timestamp columns. DST-safe: days may be 23 or 25 hours.
+func StartOfDay(day time.Time, loc *time.Location) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+}
+
+// DateString formats t as the civil date in loc.
+func DateString(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(DateFormat)
+}
+
+// Runs walks ascending, de-duplicated civil dates and returns the length of
+// the run ending at the last date and the longest run. Dates at most maxGap
+// days apart continue a run (1 means strictly consecutive days).
+func Runs(days []time.Time, maxGap int) (current, longest int) {
+	for i, day := range days {
+		if i > 0 && DaysBetween(days[i-1], day) <= maxGap {
+			current++
+		} else {
+			current = 1
+		}
+		if current > longest {
+			longest = current
+		}
+	}
+	return current, longest
+}
+
+// UniqueDays maps instants to sorted, de-duplicated civil dates in loc.
+func UniqueDays(instants []time.Time, loc *time.Location) []time.Time {
+	seen := make(map[time.Time]bool, len(instants))
+	days := make([]time.Time, 0, len(instants))
+	for _, t := range instants {
+		day := Day(t, loc)
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
@@ -0,0 +1,151 @@
+package localtime
+
+import (
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestLocation(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"", "UTC"},
+		{"UTC", "UTC"},
+		{"Local", "UTC"},
+		{"Not/AZone", "UTC"},
+		{"Europe/Istanbul", "Europe/Istanbul"},
+		{"Pacific/Kiritimati", "Pacific/Kiritimati"},
+	}
+	for _, tt := range tests {
+		if got := Location(tt.name).String(); got != tt.want {
+			t.Errorf("Location(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+	for name, valid := range map[string]bool{"": false, "Local": false, "Not/AZone": false, "UTC": true, "Etc/GMT+12": true} {
+		if got := Valid(name); got != valid {
+			t.Errorf("Valid(%q) = %v, want %v", name, got, valid)
+		}
+	}
+}
+
+func TestDay(t *testing.T) {
+	newYork := Location("America/New_York")
+	kiritimati := Location("Pacific/Kiritimati") // UTC+14
+	bakerIsland := Location("Etc/GMT+12")        // UTC-12
+	istanbul := Location("Europe/Istanbul")
+
+	tests := []struct {
+		name string
+		at   time.Time
+		loc  *time.Location
+		want time.Time
+	}{
+		{"last minute of the day", time.Date(2026, 6, 1, 23, 59, 59, 0, istanbul), istanbul, date(2026, 6, 1)},
+		{"first instant of the day", time.Date(2026, 6, 2, 0, 0, 0, 0, istanbul), istanbul, date(2026, 6, 2)},
+		{"UTC+14 is a day ahead", time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC), kiritimati, date(2026, 6, 2)},
+		{"UTC-12 is a day behind", time.Date(2026, 6, 1, 11, 0, 0, 0, time.UTC), bakerIsland, date(2026, 5, 31)},
+		{"same instant, UTC", time.Date(2026, 6, 1, 11, 0, 0, 0, time.UTC), time.UTC, date(2026, 6, 1)},
+		{"across the year in UTC+14", time.Date(2026, 12, 31, 10, 0, 0, 0, time.UTC), kiritimati, date(2027, 1, 1)},
+		{"spring forward, after the gap", time.Date(2026, 3, 8, 3, 30, 0, 0, newYork), newYork, date(2026, 3, 8)},
+		{"spring forward, last minute", time.Date(2026, 3, 8, 23, 59, 0, 0, newYork), newYork, date(2026, 3, 8)},
+		{"fall back, repeated hour", time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC), newYork, date(2026, 11, 1)},
+		{"fall back, last minute", time.Date(2026, 11, 1, 23, 59, 0, 0, newYork), newYork, date(2026, 11, 1)},
+	}
+	for _, tt := range tests {
+		if got := Day(tt.at, tt.loc); !got.Equal(tt.want) {
+			t.Errorf("%s: Day = %s, want %s", tt.name, got.Format(DateFormat), tt.want.Format(DateFormat))
+		}
+		if got := DateString(tt.at, tt.loc); got != tt.want.Format(DateFormat) {
+			t.Errorf("%s: DateString = %s, want %s", tt.name, got, tt.want.Format(DateFormat))
+		}
+	}
+}
+
+func TestStartOfDay(t *testing.T) {
+	newYork := Location("America/New_York")
+	tests := []struct {
+		name   string
+		day    time.Time
+		loc    *time.Location
+		start  string // RFC 3339
+		length time.Duration
+	}{
+		{"regular day", date(2026, 6, 1), newYork, "2026-06-01T00:00:00-04:00", 24 * time.Hour},
+		{"spring forward", date(2026, 3, 8), newYork, "2026-03-08T00:00:00-05:00", 23 * time.Hour},
+		{"fall back", date(2026, 11, 1), newYork, "2026-11-01T00:00:00-04:00", 25 * time.Hour},
+		{"UTC+14", date(2026, 6, 1), Location("Pacific/Kiritimati"), "2026-06-01T00:00:00+14:00", 24 * time.Hour},
+		{"UTC-12", date(2026, 6, 1), Location("Etc/GMT+12"), "2026-06-01T00:00:00-12:00", 24 * time.Hour},
+		{"unknown zone falls back to UTC", date(2026, 6, 1), Location("Not/AZone"), "2026-06-01T00:00:00Z", 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		start := StartOfDay(tt.day, tt.loc)
+		if got := start.Format(time.RFC3339); got != tt.start {
+			t.Errorf("%s: StartOfDay = %s, want %s", tt.name, got, tt.start)
+		}
+		if got := StartOfDay(tt.day.AddDate(0, 0, 1), tt.loc).Sub(start); got != tt.length {
+			t.Errorf("%s: day length = %s, want %s", tt.name, got, tt.length)
+		}
+		// The day's last instant still maps back to it.
+		if got := Day(start.Add(tt.length-time.Nanosecond), tt.loc); !got.Equal(tt.day) {
+			t.Errorf("%s: last instant is on %s", tt.name, got.Format(DateFormat))
+		}
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	tests := []struct {
+		from, to time.Time
+		want     int
+	}{
+		{date(2026, 6, 1), date(2026, 6, 1), 0},
+		{date(2026, 6, 1), date(2026, 6, 2), 1},
+		{date(2026, 6, 2), date(2026, 6, 1), -1},
+		{date(2026, 3, 7), date(2026, 3, 9), 2}, // across spring forward
+		{date(2026, 10, 31), date(2026, 11, 2), 2},
+		{date(2026, 12, 31), date(2027, 1, 1), 1},
+	}
+	for _, tt := range tests {
+		if got := DaysBetween(tt.from, tt.to); got != tt.want {
+			t.Errorf("DaysBetween(%s, %s) = %d, want %d", tt.from.Format(DateFormat), tt.to.Format(DateFormat), got, tt.want)
+		}
+	}
+}
+
+func TestRuns(t *testing.T) {
+	tests := []struct {
+		name             string
+		days             []time.Time
+		maxGap           int
+		current, longest int
+	}{
+		{"none", nil, 1, 0, 0},
+		{"one day", []time.Time{date(2026, 6, 1)}, 1, 1, 1},
+		{"consecutive", []time.Time{date(2026, 6, 1), date(2026, 6, 2), date(2026, 6, 3)}, 1, 3, 3},
+		{"broken run", []time.Time{date(2026, 6, 1), date(2026, 6, 2), date(2026, 6, 4)}, 1, 1, 2},
+		{"gap allowed", []time.Time{date(2026, 6, 1), date(2026, 6, 3)}, 2, 2, 2},
+	}
+	for _, tt := range tests {
+		current, longest := Runs(tt.days, tt.maxGap)
+		if current != tt.current || longest != tt.longest {
+			t.Errorf("%s: Runs = %d, %d; want %d, %d", tt.name, current, longest, tt.current, tt.longest)
+		}
+	}
+}
+
+func TestUniqueDays(t *testing.T) {
+	istanbul := Location("Europe/Istanbul") // UTC+3
+	got := UniqueDays([]time.Time{
+		time.Date(2026, 6, 2, 10, 0, 0, 0, time.UTC),
+		time.Date(2026, 6, 1, 21, 0, 0, 0, time.UTC), // 00:00 on June 2 in Istanbul
+		time.Date(2026, 6, 1, 20, 59, 0, 0, time.UTC),
+	}, istanbul)
+	want := []time.Time{date(2026, 6, 1), date(2026, 6, 2)}
+	if len(got) != len(want) || !got[0].Equal(want[0]) || !got[1].Equal(want[1]) {
+		t.Errorf("UniqueDays = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,135 @@
+// Package media stores user-uploaded images under the uploads root, which
+// the reverse proxy serves at <base URL>/uploads/.
+package media
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTooLarge        = errors.New("file exceeds the maximum size")
+	ErrUnsupportedType = errors.New("unsupported image type; allowed: jpeg, png, webp, heic")
+	ErrContentMismatch = errors.New("file content does not match declared image type")
+)
+
+var imageExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/webp": "webp",
+	"image/heic": "heic",
+}
+
+// ReadUpload reads a multipart file of at most maxBytes.
+func ReadUpload(fh *multipart.FileHeader, maxBytes int64) ([]byte, error) {
+	if fh.Size > maxBytes {
+		return nil, ErrTooLarge
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open upload: %w", err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read upload: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// ImageExtension checks data against its declared Content-Type, by both the
+// header and the file's magic bytes, and returns the extension to store it
+// under.
+func ImageExtension(data []byte, contentType string) (string, error) {
+	if i := strings.Index(contentType, ";"); i != -1 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return "", ErrUnsupportedType
+	}
+	if !matchesMagic(data, contentType) {
+		return "", ErrContentMismatch
+	}
+	return ext, nil
+}
+
+func matchesMagic(data []byte, contentType string) bool {
+	switch contentType {
+	case "image/jpeg":
+		return len(data) >= 3 && data[0] == 0xFF && data[1] == 0xD8 && data[2] == 0xFF
+	case "image/png":
+		return len(data) >= 4 && data[0] == 0x89 && data[1] == 'P' && data[2] == 'N' && data[3] == 'G'
+	case "image/webp":
+		return len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP"
+	case "image/heic":
+		// ISO BMFF: an ftyp box at offset 4
+		return len(data) >= 8 && string(data[4:8]) == "ftyp"
+	}
+	return false
+}
+
+// Store writes files below root and builds their public URLs.
+type Store struct {
+	root    string
+	baseURL string
+}
+
+func NewStore(root, baseURL string) *Store {
+	return &Store{root: root, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Save writes data under the directory made of dir's segments, named by a
+// random UUID, and returns its public URL. Segments must be server-chosen
+// (app and user IDs), never client input.
+func (s *Store) Save(dir []string, ext string, data []byte) (string, error) {
+	rel := filepath.Join(append(append([]string{}, dir...), uuid.NewString()+"."+ext)...)
+	path := filepath.Join(s.root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("create directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("write file: %w", err)
+	}
+	return s.baseURL + "/uploads/" + filepath.ToSlash(rel), nil
+}
+
+// Delete removes the file behind a URL returned by Save. URLs that don't
+// point into the store are ignored, as are files that are already gone.
+func (s *Store) Delete(url string) error {
+	rel, ok := strings.CutPrefix(url, s.baseURL+"/uploads/")
+	if !ok || rel == "" {
+		return nil
+	}
+	root, err := filepath.Abs(s.root)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if !strings.HasPrefix(path, root+string(filepath.Separator)) {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// DeleteDir removes a directory written by Save, with everything in it.
+func (s *Store) DeleteDir(dir []string) error {
+	if len(dir) == 0 {
+		return nil
+	}
+	return os.RemoveAll(filepath.Join(append([]string{s.root}, dir...)...))
+}
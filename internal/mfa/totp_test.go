@@ -0,0 +1,124 @@
+package mfa
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the RFC 4226 / RFC 6238 SHA-1 test key "12345678901234567890",
+// base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestHOTPRFC4226(t *testing.T) {
+	key := []byte("12345678901234567890")
+	// RFC 4226 Appendix D.
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		if got := hotp(key, int64(counter)); got != code {
+			t.Errorf("hotp(%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestValidateRFC6238(t *testing.T) {
+	// RFC 6238 Appendix B (SHA-1), cut to the six digits authenticators show.
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		now := time.Unix(tt.unix, 0)
+		step, ok := Validate(rfcSecret, tt.code, now, 0)
+		if !ok {
+			t.Errorf("T=%d: code %s rejected", tt.unix, tt.code)
+			continue
+		}
+		if want := tt.unix / 30; step != want {
+			t.Errorf("T=%d: step = %d, want %d", tt.unix, step, want)
+		}
+	}
+}
+
+func TestValidateReplay(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step, ok := Validate(rfcSecret, "050471", now, 0)
+	if !ok {
+		t.Fatal("code rejected")
+	}
+	// The same code, or any code from an earlier step, is spent.
+	if _, ok := Validate(rfcSecret, "050471", now, step); ok {
+		t.Error("replayed code accepted")
+	}
+	if _, ok := Validate(rfcSecret, "050471", now.Add(Period), step); ok {
+		t.Error("replayed code accepted one step later")
+	}
+	// The next step's code still works.
+	next := hotp([]byte("12345678901234567890"), step+1)
+	if got, ok := Validate(rfcSecret, next, now.Add(Period), step); !ok || got != step+1 {
+		t.Errorf("next code = %d, %v; want step %d", got, ok, step+1)
+	}
+}
+
+func TestValidateWindow(t *testing.T) {
+	key := []byte("12345678901234567890")
+	now := time.Unix(1234567890, 0)
+	current := now.Unix() / 30
+	for offset := int64(-3); offset <= 3; offset++ {
+		_, ok := Validate(rfcSecret, hotp(key, current+offset), now, 0)
+		if want := offset >= -skew && offset <= skew; ok != want {
+			t.Errorf("code from step %+d: ok = %v, want %v", offset, ok, want)
+		}
+	}
+}
+
+func TestValidateInput(t *testing.T) {
+	now := time.Unix(59, 0)
+	tests := []struct {
+		secret, code string
+		ok           bool
+	}{
+		{rfcSecret, " 287 082 ", true},
+		{strings.ToLower(rfcSecret), "287082", true},
+		{rfcSecret, "28708", false},
+		{rfcSecret, "2870821", false},
+		{rfcSecret, "94287082", false},
+		{rfcSecret, "", false},
+		{rfcSecret, "287083", false},
+		{"not base32!", "287082", false},
+	}
+	for _, tt := range tests {
+		if _, ok := Validate(tt.secret, tt.code, now, 0); ok != tt.ok {
+			t.Errorf("Validate(%q, %q) = %v, want %v", tt.secret, tt.code, ok, tt.ok)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateSecret()
+	if len(a) != 32 || a == b {
+		t.Errorf("secrets %q and %q, want distinct 32-character secrets", a, b)
+	}
+	if _, err := b32.DecodeString(a); err != nil {
+		t.Errorf("secret is not base32: %v", err)
+	}
+}
+
+func TestKeyURI(t *testing.T) {
+	got := KeyURI("Unified Backend", "a@example.com", rfcSecret)
+	want := "otpauth://totp/Unified%20Backend:a@example.com?algorithm=SHA1&digits=6&issuer=Unified+Backend&period=30&secret=" + rfcSecret
+	if got != want {
+		t.Errorf("KeyURI = %s, want %s", got, want)
+	}
+}
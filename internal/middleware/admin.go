@@ -1,97 +1,125 @@
 package middleware
 
 import (
+	"crypto/sha256"
 	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
 	"strings"
+	"time"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
-	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/rbac"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/google/uuid"
-	"gorm.io/gorm"
 )
 
-// AdminRequired is a unified admin middleware that checks:
-// 1. Config-based admin emails/IDs/token
-// 2. DB-based user Role field
-func AdminRequired(db *gorm.DB, cfg *config.Config) fiber.Handler {
-	adminEmails := parseCSV(cfg.AdminEmails)
-	adminUserIDs := parseCSV(cfg.AdminUserIDs)
+// AdminAuthenticator resolves admin credentials. services.AdminService
+// implements it.
+type AdminAuthenticator interface {
+	AuthenticateSession(token string) (*rbac.Principal, error)
+	AuthenticateAPIKey(key string) (*rbac.Principal, error)
+}
 
+// AdminRequired authenticates the admin API. It accepts, in order:
+//  1. an admin API key in X-Admin-Key
+//  2. an admin session token as a Bearer token. Sessions are only issued
+//     after the password (POST /api/admin/auth/login) and a TOTP code
+//     (POST /api/admin/auth/totp/verify), so every admin login has a
+//     second factor
+//  3. the ADMIN_TOKEN bootstrap credential in X-Admin-Token, which can only
+//     manage admins and API keys
+//
+// End-user tokens are never accepted. Routes check what the admin may do
+// with RequirePermission.
+func AdminRequired(auth AdminAuthenticator, cfg *config.Config, bootstrap *rbac.Principal) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Check admin token header (timing-safe comparison)
-		if cfg.AdminToken != "" {
-			headerToken := c.Get("X-Admin-Token")
-			if headerToken != "" && subtle.ConstantTimeCompare([]byte(headerToken), []byte(cfg.AdminToken)) == 1 {
-				return c.Next()
+		var (
+			principal *rbac.Principal
+			err       error
+		)
+		switch {
+		case c.Get("X-Admin-Key") != "":
+			principal, err = auth.AuthenticateAPIKey(c.Get("X-Admin-Key"))
+		case strings.HasPrefix(c.Get("Authorization"), "Bearer "):
+			principal, err = auth.AuthenticateSession(strings.TrimPrefix(c.Get("Authorization"), "Bearer "))
+		case cfg.AdminToken != "" && c.Get("X-Admin-Token") != "":
+			// Timing-safe comparison
+			if subtle.ConstantTimeCompare([]byte(c.Get("X-Admin-Token")), []byte(cfg.AdminToken)) == 1 {
+				principal = bootstrap
 			}
 		}
-
-		token, ok := c.Locals("user").(*jwt.Token)
-		if !ok || token == nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-				Error: true, Message: "Unauthorized",
-			})
+		if err != nil {
+			slog.Warn("admin authentication failed", "path", c.Path(), "ip", c.IP(), "error", err)
 		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-				Error: true, Message: "Invalid claims",
-			})
+		if principal == nil {
+			return apierr.New(fiber.StatusUnauthorized, "Unauthorized")
 		}
 
-		email, _ := claims["email"].(string)
-		sub, _ := claims["sub"].(string)
+		rbac.SetPrincipal(c, principal)
+		tenant.SetAdminActor(c, principal.Actor)
+		return c.Next()
+	}
+}
 
-		// Check config-based admin lists
-		if contains(adminEmails, email) || contains(adminUserIDs, sub) {
-			return c.Next()
+// RequirePermission rejects admins without perm on the request's app.
+// Admin-management permissions are checked against every-app bindings.
+func RequirePermission(perm rbac.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		appID := tenant.GetAppID(c)
+		if rbac.IsGlobal(perm) {
+			appID = rbac.AllApps
 		}
-
-		// Check DB-based role
-		if sub != "" {
-			userID, err := uuid.Parse(sub)
-			if err == nil {
-				appID := tenant.GetAppID(c)
-				var user models.User
-				if err := db.Scopes(tenant.ForTenant(appID)).First(&user, "id = ?", userID).Error; err == nil {
-					if user.Role == "admin" {
-						return c.Next()
-					}
-				}
-			}
+		principal := rbac.GetPrincipal(c)
+		if principal == nil || !principal.Can(appID, perm) {
+			return apierr.New(fiber.StatusForbidden, "Forbidden: requires "+string(perm))
 		}
-
-		return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{
-			Error: true, Message: "Admin access required",
-		})
+		return c.Next()
 	}
 }
 
-func parseCSV(s string) []string {
-	if s == "" {
-		return nil
-	}
-	parts := strings.Split(s, ",")
-	result := make([]string, 0, len(parts))
-	for _, p := range parts {
-		trimmed := strings.TrimSpace(p)
-		if trimmed != "" {
-			result = append(result, trimmed)
-		}
-	}
-	return result
+// AdminAuditRecorder stores admin audit entries. services.AdminService
+// implements it.
+type AdminAuditRecorder interface {
+	RecordAudit(entry *models.AdminAuditLog) error
 }
 
-func contains(list []string, val string) bool {
-	for _, item := range list {
-		if item == val {
-			return true
+// AdminAudit records every admin request after it completes, including
+// rejected ones, with the actor, app, route, status and a SHA-256 of the body.
+// It must run before AdminRequired so failed logins are recorded too.
+func AdminAudit(recorder AdminAuditRecorder) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = apierr.From(err).Status
+		}
+		entry := &models.AdminAuditLog{
+			AppID:      tenant.GetAppID(c),
+			Method:     c.Method(),
+			Route:      c.Route().Path,
+			Path:       c.Path(),
+			Status:     status,
+			IP:         c.IP(),
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if body := c.Body(); len(body) > 0 {
+			sum := sha256.Sum256(body)
+			entry.PayloadHash = hex.EncodeToString(sum[:])
+		}
+		if p := rbac.GetPrincipal(c); p != nil {
+			entry.ActorType = p.Actor.Type
+			entry.ActorID = p.Actor.ID
+			entry.ActorEmail = p.Actor.Email
+			entry.APIKeyID = p.APIKeyID
+		}
+		if rerr := recorder.RecordAudit(entry); rerr != nil {
+			slog.Error("failed to record admin audit entry", "route", entry.Route, "error", rerr)
 		}
+		return err
 	}
-	return false
 }
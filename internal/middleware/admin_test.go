@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/rbac"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRequirePermission(t *testing.T) {
+	daiylyModerator := &rbac.Principal{Bindings: []rbac.Binding{{AppID: "daiyly", Role: "moderator"}}}
+	appOwner := &rbac.Principal{Bindings: []rbac.Binding{{AppID: "daiyly", Role: "owner"}}}
+	globalOwner := &rbac.Principal{Bindings: []rbac.Binding{{AppID: rbac.AllApps, Role: "owner"}}}
+
+	tests := []struct {
+		name      string
+		principal *rbac.Principal
+		appID     string
+		perm      rbac.Permission
+		status    int
+	}{
+		{"unauthenticated", nil, "daiyly", rbac.UsersRead, fiber.StatusForbidden},
+		{"permission on the request's app", daiylyModerator, "daiyly", rbac.UsersWrite, fiber.StatusOK},
+		{"permission on another app", daiylyModerator, "moodpulse", rbac.UsersWrite, fiber.StatusForbidden},
+		{"missing permission", daiylyModerator, "daiyly", rbac.ConfigWrite, fiber.StatusForbidden},
+		{"admin management needs an AllApps binding", appOwner, "daiyly", rbac.AdminsWrite, fiber.StatusForbidden},
+		{"admin management with an AllApps binding", globalOwner, "daiyly", rbac.AdminsWrite, fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+			return c.SendStatus(apierr.From(err).Status)
+		}})
+		app.Get("/", func(c *fiber.Ctx) error {
+			c.Locals("app_id", tt.appID)
+			if tt.principal != nil {
+				rbac.SetPrincipal(c, tt.principal)
+			}
+			return c.Next()
+		}, RequirePermission(tt.perm), func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, resp.StatusCode, tt.status)
+		}
+	}
+}
@@ -2,16 +2,35 @@ package middleware
 
 import (
 	"log/slog"
+	"strings"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
-	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	jwtware "github.com/gofiber/contrib/jwt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-func JWTProtected(cfg *config.Config) fiber.Handler {
+// Denylist reports users whose access has been revoked before their tokens
+// expire. services.BanService implements it from an in-memory cache, so the
+// check costs no database round trip.
+type Denylist interface {
+	IsDenied(appID string, userID uuid.UUID) bool
+}
+
+// denied reports whether the token's subject is on the denylist.
+func denied(denylist Denylist, appID string, claims jwt.MapClaims) bool {
+	if denylist == nil {
+		return false
+	}
+	sub, _ := claims["sub"].(string)
+	userID, err := uuid.Parse(sub)
+	return err == nil && denylist.IsDenied(appID, userID)
+}
+
+func JWTProtected(cfg *config.Config, denylist Denylist) fiber.Handler {
 	return jwtware.New(jwtware.Config{
 		SigningKey: jwtware.SigningKey{JWTAlg: "HS256", Key: []byte(cfg.JWTSecret)},
 		// Cross-app scope enforcement: the JWT's app_id claim must match the request's
@@ -21,17 +40,11 @@ func JWTProtected(cfg *config.Config) fiber.Handler {
 		SuccessHandler: func(c *fiber.Ctx) error {
 			tok, ok := c.Locals("user").(*jwt.Token)
 			if !ok {
-				return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-					Error:   true,
-					Message: "Unauthorized: invalid token",
-				})
+				return apierr.New(fiber.StatusUnauthorized, "Unauthorized: invalid token")
 			}
 			claims, ok := tok.Claims.(jwt.MapClaims)
 			if !ok {
-				return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-					Error:   true,
-					Message: "Unauthorized: invalid token claims",
-				})
+				return apierr.New(fiber.StatusUnauthorized, "Unauthorized: invalid token claims")
 			}
 			tokenAppID, _ := claims["app_id"].(string)
 			requestAppID := tenant.GetAppID(c)
@@ -41,18 +54,56 @@ func JWTProtected(cfg *config.Config) fiber.Handler {
 					"request_app_id", requestAppID,
 					"path", c.Path(),
 				)
-				return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{
-					Error:   true,
-					Message: "Forbidden: token not valid for this app",
-				})
+				return apierr.New(fiber.StatusForbidden, "Forbidden: token not valid for this app")
+			}
+			if denied(denylist, tokenAppID, claims) {
+				return apierr.New(fiber.StatusForbidden, "Account is suspended")
 			}
 			return c.Next()
 		},
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
-				Error:   true,
-				Message: "Unauthorized: invalid or expired token",
-			})
+			return apierr.New(fiber.StatusUnauthorized, "Unauthorized: invalid or expired token")
 		},
 	})
 }
+
+// JWTOptional validates a Bearer token when one is present but never rejects the
+// request. Public endpoints use it to personalise responses for signed-in users
+// while still serving anonymous clients; tokens that are invalid or issued for a
+// different app, or belonging to a banned user, are ignored rather than trusted.
+func JWTOptional(cfg *config.Config, denylist Denylist) fiber.Handler {
+	return jwtware.New(jwtware.Config{
+		SigningKey: jwtware.SigningKey{JWTAlg: "HS256", Key: []byte(cfg.JWTSecret)},
+		Filter: func(c *fiber.Ctx) bool {
+			return !strings.HasPrefix(c.Get("Authorization"), "Bearer ")
+		},
+		SuccessHandler: func(c *fiber.Ctx) error {
+			tok, ok := c.Locals("user").(*jwt.Token)
+			if !ok {
+				c.Locals("user", nil)
+				return c.Next()
+			}
+			claims, ok := tok.Claims.(jwt.MapClaims)
+			tokenAppID, _ := claims["app_id"].(string)
+			if !ok || tokenAppID == "" || tokenAppID != tenant.GetAppID(c) || denied(denylist, tokenAppID, claims) {
+				c.Locals("user", nil)
+			}
+			return c.Next()
+		},
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			c.Locals("user", nil)
+			return c.Next()
+		},
+	})
+}
+
+// RegisteredOnly rejects guest tokens. It runs after JWTProtected on routes
+// guests may not use until they register, such as reporting and blocking.
+func RegisteredOnly() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if tenant.IsGuest(c) {
+			return apierr.New(fiber.StatusForbidden, "Forbidden: create an account to use this feature")
+		}
+		return c.Next()
+	}
+}
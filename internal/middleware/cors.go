@@ -20,7 +20,7 @@ func CORS(cfg *config.Config) fiber.Handler {
 	}
 	return cors.New(cors.Config{
 		AllowOrigins:     origins,
-		AllowHeaders:     "Origin, Content-Type, Authorization, Accept, X-App-ID",
+		AllowHeaders:     "Origin, Content-Type, Authorization, Accept, X-App-ID, X-App-Version, X-Platform, X-Locale, X-Country, X-Device-ID, Last-Event-ID",
 		AllowMethods:     "GET, POST, PUT, DELETE, PATCH, OPTIONS",
 		AllowCredentials: false,
 	})
@@ -5,7 +5,7 @@ import (
 	"encoding/json"
 	"strings"
 
-	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
@@ -16,6 +16,10 @@ var tenantSkipPaths = []string{
 	"/api/health",
 	"/api/legal/",
 	"/api/webhooks/", // webhooks use :app_id path param instead
+	// Admin identities and API keys span apps
+	"/api/admin/auth/",
+	"/api/admin/admins",
+	"/api/admin/api-keys",
 }
 
 // TenantMiddleware extracts app_id from JWT claims, X-App-ID header, or query param.
@@ -44,10 +48,7 @@ func TenantMiddleware(registry *tenant.Registry) fiber.Handler {
 		appID := c.Get("X-App-ID")
 		if appID != "" {
 			if !registry.Exists(appID) {
-				return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-					Error:   true,
-					Message: "Invalid or missing X-App-ID",
-				})
+				return apierr.New(fiber.StatusBadRequest, "Invalid or missing X-App-ID")
 			}
 			c.Locals("app_id", appID)
 			return c.Next()
@@ -57,10 +58,7 @@ func TenantMiddleware(registry *tenant.Registry) fiber.Handler {
 		appID = c.Query("app_id")
 		if appID != "" {
 			if !registry.Exists(appID) {
-				return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-					Error:   true,
-					Message: "Invalid or missing X-App-ID",
-				})
+				return apierr.New(fiber.StatusBadRequest, "Invalid or missing X-App-ID")
 			}
 			c.Locals("app_id", appID)
 			return c.Next()
@@ -77,10 +75,7 @@ func TenantMiddleware(registry *tenant.Registry) fiber.Handler {
 		}
 
 		// 5. Missing app_id
-		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
-			Error:   true,
-			Message: "X-App-ID header is required",
-		})
+		return apierr.New(fiber.StatusBadRequest, "X-App-ID header is required")
 	}
 }
 
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountDeletion is the receipt for an account deletion request. While
+// Status is pending the account is disabled; signing in again before
+// PurgeAfter restores it, otherwise the purge job removes the user's data.
+// Receipts outlive the account as proof of deletion, with Email cleared.
+type AccountDeletion struct {
+	ID     uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID  string    `gorm:"size:50;not null;index:idx_account_deletion_app_status,priority:1" json:"-"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Email  string    `gorm:"size:255" json:"email,omitempty"`
+	// Status is pending, restored or purged.
+	Status string `gorm:"size:20;not null;index:idx_account_deletion_app_status,priority:2" json:"status"`
+	// AppleRevocation is the outcome of revoking Sign in with Apple tokens:
+	// pending, revoked, failed or skipped; empty when not applicable.
+	AppleRevocation string     `gorm:"size:20" json:"apple_revocation,omitempty"`
+	RequestedAt     time.Time  `gorm:"not null" json:"requested_at"`
+	PurgeAfter      time.Time  `gorm:"not null;index" json:"purge_after"`
+	RestoredAt      *time.Time `json:"restored_at,omitempty"`
+	RestoredBy      string     `gorm:"size:255" json:"restored_by,omitempty"` // "user" or the admin's email, ID or type
+	PurgedAt        *time.Time `json:"purged_at,omitempty"`
+}
+
+// Account deletion statuses.
+const (
+	DeletionPending  = "pending"
+	DeletionRestored = "restored"
+	DeletionPurged   = "purged"
+)
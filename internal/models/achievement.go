@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserAchievement records that a user unlocked an achievement. XP is copied
+// from the definition at unlock time so later rebalancing doesn't rewrite
+// history. The unique index makes unlocking idempotent.
+type UserAchievement struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID      string    `gorm:"size:50;not null;uniqueIndex:idx_user_achievement_app_user_key,priority:1" json:"-"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_user_achievement_app_user_key,priority:2" json:"user_id"`
+	Key        string    `gorm:"size:100;not null;uniqueIndex:idx_user_achievement_app_user_key,priority:3" json:"key"`
+	XP         int       `gorm:"not null;default:0" json:"xp"`
+	UnlockedAt time.Time `gorm:"not null" json:"unlocked_at"`
+}
+
+// TableName specifies the table name for UserAchievement
+func (UserAchievement) TableName() string {
+	return "user_achievements"
+}
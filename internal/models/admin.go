@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// AdminUser is an operator of the backend. Admins are separate from each
+// app's end users and get their permissions from AdminRoleBinding rows.
+type AdminUser struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Email        string     `gorm:"size:255;not null;uniqueIndex" json:"email"`
+	Name         string     `gorm:"size:255" json:"name"`
+	PasswordHash string     `gorm:"not null" json:"-"`
+	Disabled     bool       `gorm:"not null;default:false" json:"disabled"`
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
+	// TOTPSecret is sealed with mfa.Sealer. Admins must confirm a TOTP
+	// authenticator before they get a session; TOTPEnabledAt marks that.
+	TOTPSecret    string     `json:"-"`
+	TOTPEnabledAt *time.Time `json:"totp_enabled_at,omitempty"`
+	TOTPLastStep  int64      `gorm:"not null;default:0" json:"-"`
+	// TOTPFailedAttempts counts codes tried since the last success, as of
+	// TOTPFailedAt; too many lock VerifyTOTP for a while.
+	TOTPFailedAttempts int        `gorm:"not null;default:0" json:"-"`
+	TOTPFailedAt       *time.Time `json:"-"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+func (AdminUser) TableName() string {
+	return "admin_users"
+}
+
+// AdminRoleBinding grants an admin a role on one app, or on every app when
+// AppID is "*".
+type AdminRoleBinding struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AdminUserID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_admin_role_binding,priority:1" json:"admin_user_id"`
+	AppID       string    `gorm:"size:50;not null;uniqueIndex:idx_admin_role_binding,priority:2" json:"app_id"`
+	Role        string    `gorm:"size:50;not null;uniqueIndex:idx_admin_role_binding,priority:3" json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (AdminRoleBinding) TableName() string {
+	return "admin_role_bindings"
+}
+
+// AdminAPIKey lets a script act for its owning admin. Only the SHA-256 of
+// the key is stored; Prefix identifies it in listings. Scopes (a JSON array
+// of permissions) and AppID optionally narrow what the key may do.
+type AdminAPIKey struct {
+	ID          uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AdminUserID uuid.UUID      `gorm:"type:uuid;not null;index" json:"admin_user_id"`
+	Name        string         `gorm:"size:100;not null" json:"name"`
+	Prefix      string         `gorm:"size:16;not null" json:"prefix"`
+	KeyHash     string         `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Scopes      datatypes.JSON `gorm:"type:jsonb" json:"scopes,omitempty"`
+	AppID       string         `gorm:"size:50" json:"app_id,omitempty"`
+	ExpiresAt   *time.Time     `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time     `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time     `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+func (AdminAPIKey) TableName() string {
+	return "admin_api_keys"
+}
+
+// AdminAuditLog records every request to the admin API, including rejected
+// ones. Rows are append-only: a database trigger rejects updates and
+// deletes. The request body is stored only as a SHA-256 hash.
+type AdminAuditLog struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID       string     `gorm:"size:50;index:idx_admin_audit_app_created,priority:1" json:"app_id"`
+	ActorType   string     `gorm:"size:20" json:"actor_type,omitempty"` // admin, api-key, admin-token; empty when unauthenticated
+	ActorID     string     `gorm:"size:64;index" json:"actor_id,omitempty"`
+	ActorEmail  string     `gorm:"size:255" json:"actor_email,omitempty"`
+	APIKeyID    *uuid.UUID `gorm:"type:uuid" json:"api_key_id,omitempty"`
+	Method      string     `gorm:"size:10;not null" json:"method"`
+	Route       string     `gorm:"size:255;not null" json:"route"`
+	Path        string     `gorm:"size:1000;not null" json:"path"`
+	Status      int        `gorm:"not null" json:"status"`
+	PayloadHash string     `gorm:"size:64" json:"payload_hash,omitempty"`
+	IP          string     `gorm:"size:64" json:"ip"`
+	DurationMs  int64      `json:"duration_ms"`
+	CreatedAt   time.Time  `gorm:"index:idx_admin_audit_app_created,priority:2" json:"created_at"`
+}
+
+func (AdminAuditLog) TableName() string {
+	return "admin_audit_logs"
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// DataMigration marks a one-shot data migration as done. Startup migrations
+// that must not repeat, such as copying a legacy table that is then dropped,
+// check for their row before running and insert it in the same transaction.
+type DataMigration struct {
+	Name      string    `gorm:"primaryKey;size:150" json:"name"`
+	AppliedAt time.Time `gorm:"not null" json:"applied_at"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Experiment is an A/B test layered on top of remote config. When ConfigKey is
+// set, each assigned subject receives its variant's value for that key in
+// GET /api/config.
+type Experiment struct {
+	ID          uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID       string         `gorm:"size:50;not null;uniqueIndex:idx_experiment_app_key,priority:1" json:"app_id"`
+	Key         string         `gorm:"size:100;not null;uniqueIndex:idx_experiment_app_key,priority:2" json:"key"`
+	Name        string         `gorm:"size:200" json:"name"`
+	Description string         `gorm:"size:1000" json:"description,omitempty"`
+	ConfigKey   string         `gorm:"size:100" json:"config_key,omitempty"`
+	GoalEvent   string         `gorm:"size:100;not null;default:'purchase'" json:"goal_event"`
+	Status      string         `gorm:"size:20;not null;default:'draft';index" json:"status"` // draft, running, paused, completed
+	Variants    datatypes.JSON `gorm:"type:jsonb;not null" json:"variants"`
+	Conditions  datatypes.JSON `gorm:"type:jsonb;default:'{}'" json:"conditions"`
+	StartedAt   *time.Time     `json:"started_at,omitempty"`
+	EndedAt     *time.Time     `json:"ended_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+func (Experiment) TableName() string {
+	return "experiments"
+}
+
+// ExperimentVariant is the JSON element stored in Experiment.Variants.
+// The first variant is treated as the control in reports.
+type ExperimentVariant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+	// Value overrides the experiment's config key. For json configs an object
+	// value is shallow-merged over the base value.
+	Value string `json:"value,omitempty"`
+}
+
+// ExperimentAssignment is the sticky variant for one subject (a user ID or
+// "device:<id>" for signed-out clients), written on the subject's first
+// exposure. Once written it never changes, even if variant weights are edited
+// later.
+type ExperimentAssignment struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID        string     `gorm:"size:50;not null;index" json:"-"`
+	ExperimentID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_experiment_assignment_subject,priority:1" json:"experiment_id"`
+	SubjectID    string     `gorm:"size:140;not null;uniqueIndex:idx_experiment_assignment_subject,priority:2" json:"subject_id"`
+	Variant      string     `gorm:"size:100;not null" json:"variant"`
+	AssignedAt   time.Time  `gorm:"not null" json:"assigned_at"`
+	ExposedAt    *time.Time `json:"exposed_at,omitempty"`
+	ConvertedAt  *time.Time `json:"converted_at,omitempty"`
+}
+
+func (ExperimentAssignment) TableName() string {
+	return "experiment_assignments"
+}
+
+// ExperimentEvent is the append-only log of exposures and conversions.
+type ExperimentEvent struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID        string    `gorm:"size:50;not null;index" json:"-"`
+	ExperimentID uuid.UUID `gorm:"type:uuid;not null;index" json:"experiment_id"`
+	SubjectID    string    `gorm:"size:140;not null;index" json:"subject_id"`
+	Variant      string    `gorm:"size:100;not null" json:"variant"`
+	Type         string    `gorm:"size:20;not null" json:"type"` // exposure, conversion
+	Name         string    `gorm:"size:100" json:"name"`
+	Source       string    `gorm:"size:20;not null" json:"source"` // client, revenuecat
+	CreatedAt    time.Time `gorm:"index" json:"created_at"`
+}
+
+func (ExperimentEvent) TableName() string {
+	return "experiment_events"
+}
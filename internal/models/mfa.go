@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserTOTP is a user's authenticator-app secret. Until ConfirmedAt is set the
+// enrolment is pending and sign-in does not ask for a code.
+type UserTOTP struct {
+	ID     uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"-"`
+	AppID  string    `gorm:"size:50;not null;uniqueIndex:idx_user_totp_user,priority:1" json:"-"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_user_totp_user,priority:2" json:"-"`
+	// Secret is sealed with mfa.Sealer.
+	Secret      string     `gorm:"not null" json:"-"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+	// LastStep is the last accepted TOTP time step; codes are single-use.
+	LastStep  int64     `gorm:"not null;default:0" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (UserTOTP) TableName() string {
+	return "user_totps"
+}
+
+// MFARecoveryCode is a single-use code that stands in for a TOTP code. Only
+// its hash is stored.
+type MFARecoveryCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	AppID     string     `gorm:"size:50;not null;index:idx_mfa_recovery_user,priority:1"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index:idx_mfa_recovery_user,priority:2"`
+	CodeHash  string     `gorm:"size:64;not null"`
+	UsedAt    *time.Time ``
+	CreatedAt time.Time
+}
+
+func (MFARecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+// MFAChallenge is the pending second step of a password login. The client
+// holds the raw token; only its hash is stored.
+type MFAChallenge struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	AppID     string    `gorm:"size:50;not null;index"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	Attempts  int       `gorm:"not null;default:0"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+}
+
+func (MFAChallenge) TableName() string {
+	return "mfa_challenges"
+}
+
+// WebAuthnCredential is a registered passkey.
+type WebAuthnCredential struct {
+	ID     uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID  string    `gorm:"size:50;not null;uniqueIndex:idx_webauthn_credential,priority:1" json:"-"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	// CredentialID is the authenticator's credential ID, base64url encoded.
+	CredentialID string     `gorm:"size:1400;not null;uniqueIndex:idx_webauthn_credential,priority:2" json:"credential_id"`
+	PublicKey    []byte     `gorm:"not null" json:"-"` // COSE_Key
+	Algorithm    int64      `gorm:"not null" json:"-"`
+	SignCount    uint32     `gorm:"not null;default:0" json:"-"`
+	Name         string     `gorm:"size:100" json:"name"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
+
+// WebAuthnChallenge is an issued registration or assertion challenge. Each
+// is consumed by its finish call. MFAChallengeID is set when the assertion is
+// the second step of a password login.
+type WebAuthnChallenge struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	AppID          string     `gorm:"size:50;not null;index"`
+	UserID         *uuid.UUID `gorm:"type:uuid"`
+	Kind           string     `gorm:"size:20;not null"` // "register" or "login"
+	Challenge      string     `gorm:"size:64;not null"`
+	MFAChallengeID *uuid.UUID `gorm:"type:uuid"`
+	ExpiresAt      time.Time  `gorm:"not null;index"`
+	CreatedAt      time.Time
+}
+
+func (WebAuthnChallenge) TableName() string {
+	return "webauthn_challenges"
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ModerationAction is the audit log of moderation decisions. Admin decisions
+// record the admin as actor; automatic ones (e.g. auto-hide after repeated
+// reports) use actor type "system".
+type ModerationAction struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID        string     `gorm:"size:50;not null;index:idx_moderation_action_app_created,priority:1" json:"-"`
+	ReportID     *uuid.UUID `gorm:"type:uuid;index" json:"report_id,omitempty"`
+	Action       string     `gorm:"size:50;not null" json:"action"` // review, dismiss, action, hide_content, suspend_user, shadow_ban, auto_hide
+	ContentType  string     `gorm:"size:50" json:"content_type,omitempty"`
+	ContentID    string     `gorm:"size:255;index" json:"content_id,omitempty"`
+	TargetUserID *uuid.UUID `gorm:"type:uuid;index" json:"target_user_id,omitempty"`
+	ActorType    string     `gorm:"size:20;not null" json:"actor_type"` // admin, api-key, admin-token, system
+	ActorID      string     `gorm:"size:64" json:"actor_id,omitempty"`
+	ActorEmail   string     `gorm:"size:255" json:"actor_email,omitempty"`
+	Note         string     `gorm:"size:1000" json:"note,omitempty"`
+	CreatedAt    time.Time  `gorm:"index:idx_moderation_action_app_created,priority:2" json:"created_at"`
+}
+
+func (ModerationAction) TableName() string {
+	return "moderation_actions"
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// WebhookEndpoint is an app team's URL that receives signed event
+// notifications. An empty Events list subscribes to every event type.
+type WebhookEndpoint struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID       string    `gorm:"size:50;not null;index" json:"-"`
+	URL         string    `gorm:"size:2048;not null" json:"url"`
+	Description string    `gorm:"size:255" json:"description"`
+	Events      []string  `gorm:"type:jsonb;serializer:json;default:'[]'" json:"events"`
+	// Secret signs payloads (see services.SignWebhookPayload). It is only
+	// returned when the endpoint is created.
+	Secret    string    `gorm:"size:100;not null" json:"-"`
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for WebhookEndpoint
+func (WebhookEndpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+// WebhookDelivery is one event sent (or to be sent) to one endpoint, and
+// doubles as the delivery log. The unique index keeps fan-out idempotent
+// when the same domain event is handled twice.
+type WebhookDelivery struct {
+	ID         uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID      string         `gorm:"size:50;not null;index" json:"-"`
+	EndpointID uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_webhook_delivery_endpoint_event,priority:1" json:"endpoint_id"`
+	EventID    uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_webhook_delivery_endpoint_event,priority:2" json:"event_id"`
+	EventType  string         `gorm:"size:100;not null" json:"event_type"`
+	Payload    datatypes.JSON `gorm:"type:jsonb;not null" json:"payload"`
+	// Status is pending until a 2xx response (succeeded) or until retries
+	// run out (failed).
+	Status         string     `gorm:"size:20;not null;default:'pending';index:idx_webhook_delivery_due,priority:1" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"not null;index:idx_webhook_delivery_due,priority:2" json:"next_attempt_at"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	ResponseBody   string     `gorm:"type:text" json:"response_body,omitempty"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	DurationMs     int64      `json:"duration_ms"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for WebhookDelivery
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
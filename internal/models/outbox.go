@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// OutboxMessage is one domain event queued for one asynchronous subscriber.
+// Rows are written in the publisher's transaction and delivered at least
+// once by the event dispatcher; subscribers must tolerate duplicates.
+type OutboxMessage struct {
+	ID         uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EventID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"event_id"`
+	AppID      string         `gorm:"size:50;not null;index" json:"app_id"`
+	UserID     *uuid.UUID     `gorm:"type:uuid" json:"user_id,omitempty"`
+	Type       string         `gorm:"size:100;not null" json:"type"`
+	Subscriber string         `gorm:"size:100;not null" json:"subscriber"`
+	Payload    datatypes.JSON `gorm:"type:jsonb" json:"payload"`
+	// Status is pending until the subscriber succeeds (delivered) or runs
+	// out of attempts (failed).
+	Status        string     `gorm:"size:20;not null;default:'pending';index:idx_outbox_pending,priority:1" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_pending,priority:2" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	OccurredAt    time.Time  `gorm:"not null" json:"occurred_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for OutboxMessage
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// DeviceToken is a push token registered by a signed-in user's device. A token
+// belongs to at most one user per app; re-registering it moves it.
+type DeviceToken struct {
+	ID     uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID  string    `gorm:"size:50;not null;uniqueIndex:idx_device_token_app_token,priority:1;index:idx_device_token_app_user,priority:1" json:"-"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index:idx_device_token_app_user,priority:2" json:"user_id"`
+	Token  string    `gorm:"size:512;not null;uniqueIndex:idx_device_token_app_token,priority:2" json:"token"`
+	// Platform is ios or android; it selects the provider (APNs or FCM).
+	Platform string `gorm:"size:20;not null" json:"platform"`
+	// Environment is sandbox for development builds talking to the APNs
+	// sandbox gateway, production otherwise. Ignored for FCM.
+	Environment string    `gorm:"size:20;not null;default:'production'" json:"environment"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for DeviceToken
+func (DeviceToken) TableName() string {
+	return "device_tokens"
+}
+
+// PushNotification is one logical notification to a user, fanned out to all
+// of their devices. DedupeKey makes scheduled sends idempotent across
+// instances and restarts.
+type PushNotification struct {
+	ID        uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID     string         `gorm:"size:50;not null;uniqueIndex:idx_push_notification_dedupe,priority:1;index:idx_push_notification_app_user,priority:1" json:"-"`
+	UserID    uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_push_notification_dedupe,priority:2;index:idx_push_notification_app_user,priority:2" json:"user_id"`
+	Kind      string         `gorm:"size:50;not null" json:"kind"` // journal_reminder, streak_reminder, manual
+	DedupeKey string         `gorm:"size:100;not null;uniqueIndex:idx_push_notification_dedupe,priority:3" json:"dedupe_key"`
+	Title     string         `gorm:"size:200" json:"title"`
+	Body      string         `gorm:"size:1000" json:"body"`
+	Data      datatypes.JSON `gorm:"type:jsonb" json:"data,omitempty"`
+	// Status is pending, sent (all devices), partial, failed, no_devices or
+	// opted_out (the user's notification preferences blocked it).
+	Status    string     `gorm:"size:20;not null;default:'pending'" json:"status"`
+	Delivered int        `gorm:"not null;default:0" json:"delivered"`
+	Failed    int        `gorm:"not null;default:0" json:"failed"`
+	SentAt    *time.Time `json:"sent_at"`
+	OpenedAt  *time.Time `json:"opened_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for PushNotification
+func (PushNotification) TableName() string {
+	return "push_notifications"
+}
+
+// PushDelivery is the provider receipt for one notification on one device.
+type PushDelivery struct {
+	ID             uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	NotificationID uuid.UUID `gorm:"type:uuid;not null;index" json:"notification_id"`
+	DeviceTokenID  uuid.UUID `gorm:"type:uuid;not null" json:"device_token_id"`
+	Provider       string    `gorm:"size:20;not null" json:"provider"` // apns, fcm, mock
+	// Status is delivered, failed, or invalid_token (the token was pruned).
+	Status string `gorm:"size:20;not null" json:"status"`
+	// ProviderMessageID is the apns-id or FCM message name.
+	ProviderMessageID string    `gorm:"size:255" json:"provider_message_id,omitempty"`
+	Error             string    `gorm:"size:500" json:"error,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for PushDelivery
+func (PushDelivery) TableName() string {
+	return "push_deliveries"
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// RealtimeEvent is a message pushed to connected clients over SSE. Events are
+// persisted so reconnecting clients can replay what they missed via
+// Last-Event-ID, regardless of which instance they reconnect to.
+type RealtimeEvent struct {
+	ID        int64          `gorm:"primaryKey;autoIncrement;index:idx_realtime_event_app_id,priority:2" json:"id"`
+	AppID     string         `gorm:"size:50;not null;index:idx_realtime_event_app_id,priority:1" json:"app_id"`
+	Type      string         `gorm:"size:50;not null" json:"type"` // config, announcement
+	Data      datatypes.JSON `gorm:"type:jsonb;not null" json:"data"`
+	CreatedAt time.Time      `gorm:"index" json:"created_at"`
+}
+
+// TableName specifies the table name for RealtimeEvent
+func (RealtimeEvent) TableName() string {
+	return "realtime_events"
+}
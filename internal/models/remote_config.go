@@ -1,19 +1,23 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
 // RemoteConfig stores per-app configuration values
 type RemoteConfig struct {
-	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	AppID     string    `gorm:"size:50;not null;uniqueIndex:idx_remote_config_app_key,priority:1;index:idx_remote_config_app" json:"app_id"`
-	Key       string    `gorm:"size:100;not null;uniqueIndex:idx_remote_config_app_key,priority:2" json:"key"`
-	Value     string    `gorm:"type:text;not null" json:"value"`
-	Type      string    `gorm:"size:20;default:'string'" json:"type"` // string, bool, int, json
+	ID    uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID string    `gorm:"size:50;not null;uniqueIndex:idx_remote_config_app_key,priority:1;index:idx_remote_config_app" json:"app_id"`
+	Key   string    `gorm:"size:100;not null;uniqueIndex:idx_remote_config_app_key,priority:2" json:"key"`
+	Value string    `gorm:"type:text;not null" json:"value"`
+	Type  string    `gorm:"size:20;default:'string'" json:"type"` // string, bool, int, json
+	// Schema is an optional JSON Schema that json-typed values must satisfy.
+	Schema    string    `gorm:"type:text" json:"schema,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -30,3 +34,103 @@ func (rc *RemoteConfig) BeforeCreate(tx *gorm.DB) error {
 func (RemoteConfig) TableName() string {
 	return "remote_configs"
 }
+
+// RemoteConfigRule overrides a config key's value for clients that match its
+// conditions. Rules for the same key are evaluated in descending priority order
+// and the first match wins; a key with no matching rule falls back to its
+// RemoteConfig row.
+type RemoteConfigRule struct {
+	ID             uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID          string         `gorm:"size:50;not null;index:idx_remote_config_rule_app_key,priority:1" json:"app_id"`
+	Key            string         `gorm:"size:100;not null;index:idx_remote_config_rule_app_key,priority:2" json:"key"`
+	Name           string         `gorm:"size:100" json:"name"`
+	Priority       int            `gorm:"not null;default:0" json:"priority"`
+	Conditions     datatypes.JSON `gorm:"type:jsonb;default:'{}'" json:"conditions"`
+	RolloutPercent int            `gorm:"not null;default:100" json:"rollout_percent"`
+	Value          string         `gorm:"type:text;not null" json:"value"`
+	Type           string         `gorm:"size:20;default:'string'" json:"type"`
+	Enabled        bool           `gorm:"not null" json:"enabled"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// BeforeCreate ensures UUID is set before creation
+func (r *RemoteConfigRule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName specifies the table name for RemoteConfigRule
+func (RemoteConfigRule) TableName() string {
+	return "remote_config_rules"
+}
+
+// RuleConditions is the JSON shape stored in RemoteConfigRule.Conditions.
+// Every non-empty field must match; an empty conditions object matches everyone.
+type RuleConditions struct {
+	MinAppVersion string   `json:"min_app_version,omitempty"`
+	MaxAppVersion string   `json:"max_app_version,omitempty"`
+	Platforms     []string `json:"platforms,omitempty"` // ios, android, web
+	// Locales match either exactly ("pt-BR") or by language prefix ("pt").
+	Locales   []string `json:"locales,omitempty"`
+	Countries []string `json:"countries,omitempty"` // ISO 3166-1 alpha-2
+	// UserBucketMin/Max select a stable slice of users by hash(user_id) % 100 (inclusive).
+	UserBucketMin *int `json:"user_bucket_min,omitempty"`
+	UserBucketMax *int `json:"user_bucket_max,omitempty"`
+	// SubscriptionStatuses uses Subscription.Status values plus "none" for users
+	// without a subscription row.
+	SubscriptionStatuses []string   `json:"subscription_statuses,omitempty"`
+	SignedUpAfter        *time.Time `json:"signed_up_after,omitempty"`
+	SignedUpBefore       *time.Time `json:"signed_up_before,omitempty"`
+}
+
+// RequiresUser reports whether evaluating the conditions needs an authenticated user.
+func (rc *RuleConditions) RequiresUser() bool {
+	return rc.UserBucketMin != nil || rc.UserBucketMax != nil ||
+		len(rc.SubscriptionStatuses) > 0 || rc.SignedUpAfter != nil || rc.SignedUpBefore != nil
+}
+
+// ErrRevisionImmutable is returned when code tries to modify a stored revision.
+var ErrRevisionImmutable = errors.New("config revisions are immutable")
+
+// RemoteConfigRevision is an append-only record of one change to a config key
+// or one of its rules. Revision numbers increase per (app, key); the New*
+// fields hold the key's state after the change, so rolling back to revision N
+// restores exactly what N wrote. Rule changes leave the key's state as it was
+// and carry the rule before and after in Diff.
+type RemoteConfigRevision struct {
+	ID           uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID        string         `gorm:"size:50;not null;uniqueIndex:idx_remote_config_revision,priority:1" json:"app_id"`
+	Key          string         `gorm:"size:100;not null;uniqueIndex:idx_remote_config_revision,priority:2" json:"key"`
+	Revision     int            `gorm:"not null;uniqueIndex:idx_remote_config_revision,priority:3" json:"revision"`
+	Action       string         `gorm:"size:20;not null" json:"action"` // create, update, delete, rollback, rule_create, rule_update, rule_delete
+	OldValue     *string        `gorm:"type:text" json:"old_value"`
+	NewValue     *string        `gorm:"type:text" json:"new_value"`
+	OldType      string         `gorm:"size:20" json:"old_type,omitempty"`
+	NewType      string         `gorm:"size:20" json:"new_type,omitempty"`
+	OldSchema    string         `gorm:"type:text" json:"old_schema,omitempty"`
+	NewSchema    string         `gorm:"type:text" json:"new_schema,omitempty"`
+	Diff         datatypes.JSON `gorm:"type:jsonb" json:"diff"`
+	RolledBackTo *int           `json:"rolled_back_to,omitempty"`
+	ActorType    string         `gorm:"size:20;not null" json:"actor_type"` // admin, api-key, admin-token, system
+	ActorID      string         `gorm:"size:64" json:"actor_id,omitempty"`
+	ActorEmail   string         `gorm:"size:255" json:"actor_email,omitempty"`
+	CreatedAt    time.Time      `gorm:"index" json:"created_at"`
+}
+
+// BeforeUpdate rejects updates so history cannot be rewritten through GORM.
+func (RemoteConfigRevision) BeforeUpdate(tx *gorm.DB) error {
+	return ErrRevisionImmutable
+}
+
+// BeforeDelete rejects deletes for the same reason.
+func (RemoteConfigRevision) BeforeDelete(tx *gorm.DB) error {
+	return ErrRevisionImmutable
+}
+
+// TableName specifies the table name for RemoteConfigRevision
+func (RemoteConfigRevision) TableName() string {
+	return "remote_config_revisions"
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 )
 
 // Report implements UGC safety governance (Apple Guideline 1.2).
@@ -16,7 +17,12 @@ type Report struct {
 	Reason      string    `gorm:"not null;size:500" json:"reason"`
 	Status      string    `gorm:"not null;default:'pending';size:50" json:"status"`
 	AdminNote   string    `gorm:"size:1000" json:"admin_note,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Reporter    User      `gorm:"foreignKey:ReporterID" json:"-"`
+	// Snapshot is the reported content as it was when the report was filed,
+	// so admins can review it even if the author has since deleted it.
+	Snapshot   datatypes.JSON `gorm:"type:jsonb" json:"snapshot,omitempty"`
+	AuthorID   *uuid.UUID     `gorm:"type:uuid;index" json:"author_id,omitempty"`
+	ResolvedAt *time.Time     `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	Reporter   User           `gorm:"foreignKey:ReporterID" json:"-"`
 }
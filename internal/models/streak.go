@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Streak is a user's run of active days for one kind of activity in an app
+// (e.g. daiyly "journal", driftoff "sleep"). Rows are maintained by the
+// streaks engine; plugins never write them directly.
+type Streak struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID         string    `gorm:"size:50;not null;uniqueIndex:idx_streak_app_user_kind,priority:1" json:"-"`
+	UserID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_streak_app_user_kind,priority:2" json:"user_id"`
+	Kind          string    `gorm:"size:50;not null;uniqueIndex:idx_streak_app_user_kind,priority:3" json:"kind"`
+	CurrentStreak int       `gorm:"not null;default:0" json:"current_streak"`
+	LongestStreak int       `gorm:"not null;default:0" json:"longest_streak"`
+	TotalCount    int       `gorm:"not null;default:0" json:"total_count"`
+	// LastActiveDay is the civil date of the latest activity in the user's
+	// timezone at the time it was recorded.
+	LastActiveDay  *time.Time `gorm:"type:date" json:"last_active_day"`
+	LastActivityAt *time.Time `json:"last_activity_at"`
+	// Freezes bridge missed days, either automatically or on request
+	// depending on the kind's policy.
+	FreezesAvailable int        `gorm:"not null;default:0" json:"freezes_available"`
+	FreezesUsed      int        `gorm:"not null;default:0" json:"freezes_used"`
+	LastFreezeAt     *time.Time `json:"last_freeze_at"`
+	// GraceActive is set while the streak is being carried over a missed day
+	// by the grace window and cleared by the next consecutive day.
+	GraceActive bool       `gorm:"not null;default:false" json:"grace_active"`
+	GraceUsedAt *time.Time `json:"grace_used_at"`
+	// UnlockedBadges holds the keys of milestones reached so far; they stay
+	// unlocked when the streak later breaks.
+	UnlockedBadges []string  `gorm:"type:jsonb;serializer:json;default:'[]'" json:"unlocked_badges"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for Streak
+func (Streak) TableName() string {
+	return "streaks"
+}
@@ -9,13 +9,28 @@ import (
 
 // User is the unified user model (superset of all 11 app variants).
 type User struct {
-	ID           uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	AppID        string         `gorm:"size:50;not null;uniqueIndex:idx_users_app_email" json:"-"`
-	Email        string         `gorm:"not null;size:255;uniqueIndex:idx_users_app_email" json:"email"`
-	Password     string         `gorm:"not null" json:"-"`
-	Role         string         `gorm:"size:20;default:'user'" json:"role"`
-	AppleUserID  *string        `gorm:"size:255;index" json:"-"`
-	AuthProvider string         `gorm:"size:50;default:'email'" json:"-"`
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID        string    `gorm:"size:50;not null;uniqueIndex:idx_users_app_email" json:"-"`
+	Email        string    `gorm:"not null;size:255;uniqueIndex:idx_users_app_email" json:"email"`
+	Password     string    `gorm:"not null" json:"-"`
+	Role         string    `gorm:"size:20;default:'user'" json:"role"`
+	AppleUserID  *string   `gorm:"size:255;index" json:"-"`                        // legacy; Apple links are UserIdentity rows
+	AuthProvider string    `gorm:"size:50;default:'email'" json:"-"`               // email, guest, or the identity provider last used to sign in
+	Timezone     string    `gorm:"size:64;not null;default:'UTC'" json:"timezone"` // IANA zone; per-day features use the user's local day
+	// Profile. Locale is a BCP 47 tag; empty means the app's default
+	// language. AvatarURL points at the media store.
+	DisplayName string `gorm:"size:100" json:"display_name"`
+	AvatarURL   string `gorm:"size:500" json:"avatar_url"`
+	Locale      string `gorm:"size:35" json:"locale"`
+	BirthYear   *int   `json:"birth_year"`
+	// IsGuest marks an anonymous account created by POST /api/auth/guest for
+	// DeviceID. Guests get restricted tokens until they register or sign in
+	// with Apple, which claims their data into the full account.
+	IsGuest  bool   `gorm:"not null;default:false" json:"is_guest"`
+	DeviceID string `gorm:"size:140;index" json:"-"`
+	// ShadowBanned users can keep posting, but their content is only visible
+	// to themselves. Suspensions are UserBan rows.
+	ShadowBanned bool           `gorm:"not null;default:false" json:"-"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
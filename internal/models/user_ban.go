@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserBan suspends a user from one app. A ban is active until ExpiresAt
+// passes (nil means permanent) or it is lifted; lifted and expired bans are
+// kept as history.
+type UserBan struct {
+	ID         uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID      string     `gorm:"size:50;not null;index:idx_user_ban_app_user,priority:1" json:"-"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index:idx_user_ban_app_user,priority:2" json:"user_id"`
+	Reason     string     `gorm:"size:1000;not null" json:"reason"`
+	ExpiresAt  *time.Time `gorm:"index" json:"expires_at"`
+	ReportID   *uuid.UUID `gorm:"type:uuid" json:"report_id,omitempty"` // set when issued from a moderation report
+	ActorType  string     `gorm:"size:20;not null" json:"actor_type"`   // admin, api-key, admin-token, system
+	ActorID    string     `gorm:"size:64" json:"actor_id,omitempty"`
+	ActorEmail string     `gorm:"size:255" json:"actor_email,omitempty"`
+	LiftedAt   *time.Time `json:"lifted_at,omitempty"`
+	LiftedBy   string     `gorm:"size:255" json:"lifted_by,omitempty"` // actor email, ID or type
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (UserBan) TableName() string {
+	return "user_bans"
+}
+
+// Active reports whether the ban is in force at now.
+func (b *UserBan) Active(now time.Time) bool {
+	return b.LiftedAt == nil && (b.ExpiresAt == nil || now.Before(*b.ExpiresAt))
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity links a user to an external identity provider account
+// (Apple, Google or a configured OIDC issuer). A user may link several
+// providers; each provider account belongs to at most one user per app.
+type UserIdentity struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID     string    `gorm:"size:50;not null;uniqueIndex:idx_user_identity_provider_subject,priority:1" json:"-"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	Provider  string    `gorm:"size:50;not null;uniqueIndex:idx_user_identity_provider_subject,priority:2" json:"provider"`
+	Subject   string    `gorm:"size:255;not null;uniqueIndex:idx_user_identity_provider_subject,priority:3" json:"-"`
+	Email     string    `gorm:"size:255" json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
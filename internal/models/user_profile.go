@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// UserPreference holds one namespace of a user's preferences as JSON. Core
+// namespaces (e.g. "notifications") and plugin namespaces share the table;
+// the shape of Data is owned by the namespace's registered schema.
+type UserPreference struct {
+	ID        uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"-"`
+	AppID     string         `gorm:"size:50;not null;uniqueIndex:idx_user_preferences_namespace,priority:1" json:"-"`
+	UserID    uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_user_preferences_namespace,priority:2" json:"-"`
+	Namespace string         `gorm:"size:50;not null;uniqueIndex:idx_user_preferences_namespace,priority:3" json:"namespace"`
+	Data      datatypes.JSON `gorm:"type:jsonb;not null" json:"data"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// EmailChangeRequest is a pending move to NewEmail. It takes effect when the
+// token mailed to the new address is confirmed; only its hash is stored.
+type EmailChangeRequest struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	AppID       string    `gorm:"size:50;not null;index"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	NewEmail    string    `gorm:"size:255;not null"`
+	TokenHash   string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt   time.Time `gorm:"not null"`
+	ConfirmedAt *time.Time
+	CreatedAt   time.Time
+}
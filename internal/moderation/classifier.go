@@ -0,0 +1,138 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Classifier scores text with a model. Scores are 0-1 per category.
+type Classifier interface {
+	Classify(ctx context.Context, in Input) (map[string]float64, error)
+}
+
+// ClassifierStage adds a Classifier's scores to the result. Failures are
+// recorded in Result.ClassifierError and otherwise ignored.
+type ClassifierStage struct {
+	Classifier Classifier
+}
+
+func (ClassifierStage) Name() string { return "classifier" }
+
+func (s ClassifierStage) Run(ctx context.Context, in Input, res *Result) error {
+	scores, err := s.Classifier.Classify(ctx, in)
+	if err != nil {
+		res.ClassifierError = err.Error()
+		return err
+	}
+	for cat, score := range scores {
+		res.Flag(cat, score, "")
+	}
+	return nil
+}
+
+// HTTPClassifier calls an external classification service. It POSTs
+//
+//	{"app_id": "...", "locale": "...", "text": "..."}
+//
+// with an optional bearer token, and expects {"scores": {"<category>": 0.93}}.
+type HTTPClassifier struct {
+	URL    string
+	APIKey string
+	Client *http.Client
+}
+
+// NewHTTPClassifier returns a classifier with a short timeout, since it runs
+// inline with the user's request.
+func NewHTTPClassifier(url, apiKey string) *HTTPClassifier {
+	return &HTTPClassifier{URL: url, APIKey: apiKey, Client: &http.Client{Timeout: 3 * time.Second}}
+}
+
+func (c *HTTPClassifier) Classify(ctx context.Context, in Input) (map[string]float64, error) {
+	body, err := json.Marshal(map[string]string{"app_id": in.AppID, "locale": in.Locale, "text": in.Text})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("classifier returned %d: %s", resp.StatusCode, msg)
+	}
+
+	var out struct {
+		Scores map[string]float64 `json:"scores"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode classifier response: %w", err)
+	}
+	return out.Scores, nil
+}
+
+// StubClassifier is a local stand-in for development and tests. It scores a
+// few fixed phrases so the classifier path can be exercised without a model.
+type StubClassifier struct{}
+
+var stubPhrases = []struct {
+	phrase   string
+	category string
+	score    float64
+}{
+	{"kill yourself", CategoryHarassment, 0.97},
+	{"kys", CategoryHarassment, 0.9},
+	{"nobody likes you", CategoryHarassment, 0.85},
+	{"kill myself", CategorySelfHarm, 0.6},
+	{"want to die", CategorySelfHarm, 0.6},
+}
+
+func (StubClassifier) Classify(_ context.Context, in Input) (map[string]float64, error) {
+	text := " " + strings.Join(Tokenize(in.Text), " ") + " "
+	scores := make(map[string]float64)
+	for _, p := range stubPhrases {
+		if strings.Contains(text, " "+p.phrase+" ") && p.score > scores[p.category] {
+			scores[p.category] = p.score
+		}
+	}
+	return scores, nil
+}
+
+// Options configure NewDefault.
+type Options struct {
+	Threshold float64
+	// ClassifierURL is an HTTP endpoint, "stub" for StubClassifier, or empty
+	// for no classifier.
+	ClassifierURL string
+	ClassifierKey string
+	Config        ConfigSource
+}
+
+// NewDefault returns the standard pipeline: Normalize, WordList, Patterns
+// and, when configured, a classifier.
+func NewDefault(opts Options) *Moderator {
+	stages := []Stage{Normalize{}, WordList{Config: opts.Config}, Patterns{}}
+	switch opts.ClassifierURL {
+	case "":
+	case "stub":
+		stages = append(stages, ClassifierStage{Classifier: StubClassifier{}})
+	default:
+		stages = append(stages, ClassifierStage{Classifier: NewHTTPClassifier(opts.ClassifierURL, opts.ClassifierKey)})
+	}
+	return New(opts.Threshold, stages...)
+}
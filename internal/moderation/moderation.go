@@ -0,0 +1,123 @@
+// Package moderation screens user-generated text before it is shown to other
+// users. A Moderator runs the text through a pipeline of stages: Normalize
+// folds obfuscation (leetspeak, look-alike Unicode, spacing), WordList and
+// Patterns score it against banned terms and spam/contact-info patterns, and
+// an optional Classifier adds model scores. The result carries a score per
+// category rather than a single pass/fail reason.
+package moderation
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+)
+
+// Categories scored by the built-in stages. Classifiers may add their own.
+const (
+	CategoryProfanity   = "profanity"
+	CategoryHate        = "hate"
+	CategorySexual      = "sexual"
+	CategoryHarassment  = "harassment"
+	CategorySelfHarm    = "self_harm"
+	CategorySpam        = "spam"
+	CategoryURL         = "url"
+	CategoryContactInfo = "contact_info"
+)
+
+// DefaultThreshold is the score at which a category blocks content.
+const DefaultThreshold = 0.8
+
+// Input is one piece of text to check. Locale is a BCP 47 tag such as
+// "tr-TR"; only its language selects a word list.
+type Input struct {
+	AppID  string
+	Locale string
+	Text   string
+}
+
+// Result is the pipeline's verdict.
+type Result struct {
+	Allowed bool `json:"allowed"`
+	// Scores maps each flagged category to a 0-1 score.
+	Scores map[string]float64 `json:"scores"`
+	// Categories lists the categories at or above the threshold, worst first.
+	Categories []string `json:"categories"`
+	// Matches are the terms or patterns that matched, for logs and admins.
+	Matches []string `json:"matches,omitempty"`
+	// ClassifierError is set when the classifier failed and was skipped.
+	ClassifierError string `json:"classifier_error,omitempty"`
+
+	// Normalized is the folded text from the Normalize stage and Tokens its
+	// words, for later stages.
+	Normalized string   `json:"-"`
+	Tokens     []string `json:"-"`
+}
+
+// Reason returns the worst blocking category, or "" when allowed.
+func (r *Result) Reason() string {
+	if len(r.Categories) == 0 {
+		return ""
+	}
+	return r.Categories[0]
+}
+
+// Flag raises category to at least score and records what matched.
+func (r *Result) Flag(category string, score float64, match string) {
+	if score > r.Scores[category] {
+		r.Scores[category] = score
+	}
+	if match != "" {
+		r.Matches = append(r.Matches, match)
+	}
+}
+
+// Stage is one step of the pipeline. Stages read the input and earlier
+// stages' output from res and add scores to it. An error skips the stage.
+type Stage interface {
+	Name() string
+	Run(ctx context.Context, in Input, res *Result) error
+}
+
+// Moderator runs stages in order.
+type Moderator struct {
+	stages    []Stage
+	threshold float64
+}
+
+// New returns a Moderator that blocks content scoring threshold or more in
+// any category. A threshold of 0 uses DefaultThreshold.
+func New(threshold float64, stages ...Stage) *Moderator {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	return &Moderator{stages: stages, threshold: threshold}
+}
+
+// Check runs the pipeline. It never fails: a stage error is logged and the
+// stage skipped, so an unreachable classifier doesn't block posting.
+func (m *Moderator) Check(ctx context.Context, in Input) *Result {
+	res := &Result{Allowed: true, Scores: make(map[string]float64), Normalized: in.Text}
+	if in.Text == "" {
+		return res
+	}
+	for _, st := range m.stages {
+		if err := st.Run(ctx, in, res); err != nil {
+			slog.Warn("moderation stage failed", "stage", st.Name(), "app_id", in.AppID, "error", err)
+		}
+	}
+
+	for cat, score := range res.Scores {
+		if score >= m.threshold {
+			res.Categories = append(res.Categories, cat)
+		}
+	}
+	sort.Slice(res.Categories, func(i, j int) bool {
+		a, b := res.Categories[i], res.Categories[j]
+		if res.Scores[a] != res.Scores[b] {
+			return res.Scores[a] > res.Scores[b]
+		}
+		return a < b
+	})
+	res.Allowed = len(res.Categories) == 0
+	return res
+}
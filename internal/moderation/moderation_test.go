@@ -0,0 +1,180 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type fakeConfig map[string]interface{}
+
+func (f fakeConfig) ConfigValue(appID, key string) (interface{}, bool) {
+	v, ok := f[appID+"/"+key]
+	return v, ok
+}
+
+type failingClassifier struct{}
+
+func (failingClassifier) Classify(context.Context, Input) (map[string]float64, error) {
+	return nil, errors.New("classifier down")
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Hello, World!", []string{"hello", "world"}},
+		{"wow!!! sh!t", []string{"wow", "shit"}},
+		// Leetspeak inside words.
+		{"sh1t", []string{"shit"}},
+		{"@ss", []string{"ass"}},
+		{"$h!7", []string{"shit"}},
+		{"p0rn0", []string{"porno"}},
+		{"b1tch3s", []string{"bitches"}},
+		// Numbers on their own are not words.
+		{"call me at 5 or 2024", []string{"call", "me", "at", "or"}},
+		// Fullwidth, styled and accented letters.
+		{"ｆｕｃｋ", []string{"fuck"}},
+		{"𝐟𝐮𝐜𝐤", []string{"fuck"}},
+		{"fück", []string{"fuck"}},
+		// Cyrillic and Greek look-alikes.
+		{"аss", []string{"ass"}},
+		{"ѕріс", []string{"spic"}},
+		{"ροrn", []string{"porn"}},
+		// Spaced-out letters are joined, keeping the singles.
+		{"f u c k off", []string{"f", "u", "c", "k", "off", "fuck", "uck"}},
+		{"f.u.c.k", []string{"f", "u", "c", "k", "fuck", "uck"}},
+		{"a s h i t", []string{"a", "s", "h", "i", "t", "ashit", "shit", "hit"}},
+		{"a b", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCheckWordList(t *testing.T) {
+	m := NewDefault(Options{})
+	tests := []struct {
+		text     string
+		category string // "" when allowed
+	}{
+		{"Had a lovely walk in the park today.", ""},
+		{"What a shit day", CategoryProfanity},
+		{"what a sh1t day", CategoryProfanity},
+		{"what a $h!t day", CategoryProfanity},
+		{"what a ｓｈｉｔ day", CategoryProfanity},
+		{"what a ѕhіt day", CategoryProfanity},
+		{"what a s h i t day", CategoryProfanity},
+		{"what a shiiiiit day", CategoryProfanity},
+		{"you a$$", CategoryProfanity},
+		{"send nud3s", CategorySexual},
+		{"you are a f a g", CategoryHate},
+		{"just kill yourself", CategoryHarassment},
+		// Words containing a banned word are fine.
+		{"I passed my class assessment", ""},
+		{"Scunthorpe is in England", ""},
+		{"Get your assignment done", ""},
+	}
+	for _, tt := range tests {
+		res := m.Check(context.Background(), Input{Text: tt.text})
+		if res.Reason() != tt.category {
+			t.Errorf("%q: reason = %q, want %q (scores %v)", tt.text, res.Reason(), tt.category, res.Scores)
+		}
+		if res.Allowed != (tt.category == "") {
+			t.Errorf("%q: allowed = %v", tt.text, res.Allowed)
+		}
+	}
+}
+
+func TestCheckPatterns(t *testing.T) {
+	m := NewDefault(Options{})
+	tests := []struct {
+		text     string
+		category string
+	}{
+		{"see https://example.com/deal", CategoryURL},
+		{"go to www.example.com now", CategoryURL},
+		{"mail me at jane.doe@example.com", CategoryContactInfo},
+		{"text me 555-123-4567", CategoryContactInfo},
+		{"call (555) 123 4567", CategoryContactInfo},
+		{"sooooo good", CategorySpam},
+		{"what?!!!!", CategorySpam},
+		{"BUY CHEAP WATCHES TODAY", CategorySpam},
+		{"I LOVE NASA and ESA", ""},
+		{"slept 7.5 hours", ""},
+	}
+	for _, tt := range tests {
+		if got := m.Check(context.Background(), Input{Text: tt.text}).Reason(); got != tt.category {
+			t.Errorf("%q: reason = %q, want %q", tt.text, got, tt.category)
+		}
+	}
+}
+
+func TestCheckLocaleWordLists(t *testing.T) {
+	cfg := fakeConfig{
+		"app/" + BannedWordsKey:         []interface{}{"dingus"},
+		"app/" + BannedWordsKey + ".tr": map[string]interface{}{"salak": CategoryHarassment, "aptal": ""},
+		"app/" + AllowedWordsKey:        []interface{}{"Scam"},
+	}
+	m := NewDefault(Options{Config: cfg})
+	tests := []struct {
+		appID, locale, text string
+		category            string
+	}{
+		{"app", "", "what a dingus", CategoryProfanity},
+		{"app", "tr-TR", "sen bir s@lak", CategoryHarassment},
+		{"app", "tr_TR", "çok aptal", CategoryProfanity},
+		{"app", "TR", "salak", CategoryHarassment},
+		// Other languages don't get the Turkish list.
+		{"app", "en-US", "salak", ""},
+		{"app", "", "salak", ""},
+		// Allowed words come off the list for every language.
+		{"app", "tr", "this is a scam", ""},
+		// Other apps only have the defaults.
+		{"other", "tr", "salak", ""},
+		{"other", "tr", "this is a scam", CategorySpam},
+	}
+	for _, tt := range tests {
+		got := m.Check(context.Background(), Input{AppID: tt.appID, Locale: tt.locale, Text: tt.text}).Reason()
+		if got != tt.category {
+			t.Errorf("%s %q %q: reason = %q, want %q", tt.appID, tt.locale, tt.text, got, tt.category)
+		}
+	}
+}
+
+func TestCheckClassifier(t *testing.T) {
+	m := NewDefault(Options{ClassifierURL: "stub"})
+	res := m.Check(context.Background(), Input{Text: "nobody likes you"})
+	if res.Allowed || res.Reason() != CategoryHarassment {
+		t.Errorf("stub classifier: %+v, want harassment", res)
+	}
+	res = m.Check(context.Background(), Input{Text: "some days I want to die"})
+	if !res.Allowed || res.Scores[CategorySelfHarm] != 0.6 {
+		t.Errorf("below threshold: %+v, want allowed with a self_harm score", res)
+	}
+
+	m = New(0, Normalize{}, WordList{}, ClassifierStage{Classifier: failingClassifier{}})
+	res = m.Check(context.Background(), Input{Text: "a calm evening"})
+	if !res.Allowed || res.ClassifierError == "" {
+		t.Errorf("failing classifier: %+v, want allowed with the error recorded", res)
+	}
+	res = m.Check(context.Background(), Input{Text: "shit"})
+	if res.Allowed {
+		t.Error("failing classifier let earlier stages' verdict through")
+	}
+}
+
+func TestResultOrdersCategoriesWorstFirst(t *testing.T) {
+	res := NewDefault(Options{}).Check(context.Background(), Input{Text: "porn at https://example.com"})
+	want := []string{CategorySexual, CategoryURL}
+	if !reflect.DeepEqual(res.Categories, want) {
+		t.Errorf("categories = %v, want %v", res.Categories, want)
+	}
+	if empty := NewDefault(Options{}).Check(context.Background(), Input{}); !empty.Allowed {
+		t.Error("empty text blocked")
+	}
+}
@@ -0,0 +1,150 @@
+package moderation
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize folds text so simple obfuscation doesn't slip past word lists:
+//
+//   - compatibility decomposition turns fullwidth and styled letters into
+//     plain ones, and accents are dropped (ｆｕｃｋ, fück → fuck)
+//   - Cyrillic and Greek look-alikes map to Latin (аss → ass)
+//   - leetspeak digits and symbols inside words map to letters (sh1t, @ss)
+//   - runs of single letters are joined (f u c k, f.u.c.k → fuck), also
+//     without the letters that lead the run (a s h i t → shit)
+//
+// It sets res.Normalized and res.Tokens. Tokens also include each word with
+// repeated letters squeezed (fuuuck → fuck, asssss → ass).
+type Normalize struct{}
+
+func (Normalize) Name() string { return "normalize" }
+
+func (Normalize) Run(_ context.Context, in Input, res *Result) error {
+	words := Tokenize(in.Text)
+	res.Normalized = strings.Join(words, " ")
+
+	seen := make(map[string]bool, len(words)*2)
+	add := func(t string) {
+		if t != "" && !seen[t] {
+			seen[t] = true
+			res.Tokens = append(res.Tokens, t)
+		}
+	}
+	for _, w := range words {
+		add(w)
+		add(squeeze(w, 1))
+		add(squeeze(w, 2))
+	}
+	return nil
+}
+
+// Tokenize folds text and splits it into lowercase words, joining runs of
+// three or more single letters into one word. Word lists are folded the same
+// way so entries match regardless of how they're written.
+func Tokenize(text string) []string {
+	var words []string
+	for _, chunk := range strings.Fields(fold(text)) {
+		// A trailing ! is punctuation, not an i.
+		chunk = strings.TrimRight(chunk, "!")
+		if strings.IndexFunc(chunk, unicode.IsLetter) >= 0 {
+			chunk = strings.Map(unleet, chunk)
+		}
+		words = append(words, strings.FieldsFunc(chunk, func(r rune) bool {
+			return !unicode.IsLetter(r)
+		})...)
+	}
+	return joinSingles(words)
+}
+
+func fold(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range norm.NFKD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		r = unicode.ToLower(r)
+		if m, ok := homoglyphs[r]; ok {
+			r = m
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// homoglyphs maps lowercase Cyrillic and Greek letters that render like
+// Latin ones.
+var homoglyphs = map[rune]rune{
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h',
+	'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i',
+	'ї': 'i', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd', 'ɡ': 'g', 'һ': 'h', 'ԛ': 'q',
+	'ԝ': 'w', 'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k',
+	'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'ı': 'i',
+}
+
+func unleet(r rune) rune {
+	switch r {
+	case '0':
+		return 'o'
+	case '1', '!', '|':
+		return 'i'
+	case '3':
+		return 'e'
+	case '4', '@':
+		return 'a'
+	case '5', '$':
+		return 's'
+	case '7', '+':
+		return 't'
+	case '8':
+		return 'b'
+	case '9':
+		return 'g'
+	}
+	return r
+}
+
+// joinSingles appends the concatenation of each run of three or more
+// one-letter words, and of the run's tails down to three letters, so a
+// leading "a" or "i" doesn't hide the word. The letters themselves are kept.
+func joinSingles(words []string) []string {
+	out := words
+	run := 0
+	flush := func(end int) {
+		for start := end - run; end-start >= 3; start++ {
+			out = append(out, strings.Join(words[start:end], ""))
+		}
+		run = 0
+	}
+	for i, w := range words {
+		if len([]rune(w)) == 1 {
+			run++
+			continue
+		}
+		flush(i)
+	}
+	flush(len(words))
+	return out
+}
+
+// squeeze limits runs of the same letter to max.
+func squeeze(s string, max int) string {
+	var b strings.Builder
+	var prev rune
+	n := 0
+	for _, r := range s {
+		if r == prev {
+			n++
+		} else {
+			prev, n = r, 1
+		}
+		if n <= max {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
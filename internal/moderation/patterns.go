@@ -0,0 +1,63 @@
+package moderation
+
+import (
+	"context"
+	"regexp"
+	"unicode"
+)
+
+var (
+	urlPattern   = regexp.MustCompile(`(?i)(https?://\S+|www\.\S+\.\S+)`)
+	emailPattern = regexp.MustCompile(`(?i)\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Z|a-z]{2,}\b`)
+	phonePattern = regexp.MustCompile(`\d{3}[-.\s]?\d{3}[-.\s]?\d{4}|\(\d{3}\)\s*\d{3}[-.\s]?\d{4}`)
+	capsPattern  = regexp.MustCompile(`[A-Z]{5,}`)
+)
+
+// Patterns flags links, contact details and spam-like text (a letter or
+// !?. repeated four times, or more than two shouted words). It runs on the
+// raw text, since normalisation would erase what it looks for.
+type Patterns struct{}
+
+func (Patterns) Name() string { return "patterns" }
+
+func (Patterns) Run(_ context.Context, in Input, res *Result) error {
+	if m := urlPattern.FindString(in.Text); m != "" {
+		res.Flag(CategoryURL, 0.9, m)
+	}
+	if m := emailPattern.FindString(in.Text); m != "" {
+		res.Flag(CategoryContactInfo, 0.9, "email")
+	}
+	if phonePattern.MatchString(in.Text) {
+		res.Flag(CategoryContactInfo, 0.9, "phone")
+	}
+	if repeatedChars(in.Text, 4) {
+		res.Flag(CategorySpam, 0.8, "repeated characters")
+	}
+	if len(capsPattern.FindAllString(in.Text, -1)) > 2 {
+		res.Flag(CategorySpam, 0.8, "excessive caps")
+	}
+	return nil
+}
+
+// repeatedChars reports whether a letter or one of !?. occurs n times in a
+// row, case-insensitively.
+func repeatedChars(s string, n int) bool {
+	var prev rune
+	count := 0
+	for _, r := range s {
+		r = unicode.ToLower(r)
+		if !unicode.IsLetter(r) && r != '!' && r != '?' && r != '.' {
+			prev, count = 0, 0
+			continue
+		}
+		if r == prev {
+			count++
+		} else {
+			prev, count = r, 1
+		}
+		if count >= n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package moderation
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// ConfigSource reads an app's remote config values. services.RemoteConfigService
+// implements it.
+type ConfigSource interface {
+	ConfigValue(appID, key string) (interface{}, bool)
+}
+
+// Remote config keys read by WordList. The per-language key is suffixed with
+// the locale's language, e.g. "moderation.banned_words.tr".
+const (
+	BannedWordsKey  = "moderation.banned_words"
+	AllowedWordsKey = "moderation.allowed_words"
+)
+
+// DefaultWords is the built-in English list, applied to every app.
+var DefaultWords = map[string]string{
+	"fuck": CategoryProfanity, "fucking": CategoryProfanity, "fucker": CategoryProfanity,
+	"shit": CategoryProfanity, "shitty": CategoryProfanity, "bullshit": CategoryProfanity,
+	"ass": CategoryProfanity, "asshole": CategoryProfanity, "bastard": CategoryProfanity,
+	"bitch": CategoryProfanity, "cunt": CategoryProfanity,
+	"nigger": CategoryHate, "nigga": CategoryHate, "chink": CategoryHate, "spic": CategoryHate,
+	"kike": CategoryHate, "faggot": CategoryHate, "fag": CategoryHate,
+	"retard": CategoryHate, "retarded": CategoryHate, "tranny": CategoryHate,
+	"porn": CategorySexual, "porno": CategorySexual, "nude": CategorySexual, "nudes": CategorySexual,
+	"spam": CategorySpam, "scam": CategorySpam, "scammer": CategorySpam,
+	"phishing": CategorySpam, "malware": CategorySpam,
+	"kill yourself": CategoryHarassment,
+}
+
+// WordList flags banned words and phrases. On top of DefaultWords, each app
+// can set "moderation.banned_words" and "moderation.banned_words.<lang>" in
+// remote config, either as a JSON array of words (scored as profanity) or an
+// object mapping word to category, and exempt words with
+// "moderation.allowed_words". Entries are normalised like the text, so "sh1t"
+// and "shit" are the same entry.
+type WordList struct {
+	Config ConfigSource // nil uses DefaultWords only
+}
+
+func (WordList) Name() string { return "word_list" }
+
+func (w WordList) Run(_ context.Context, in Input, res *Result) error {
+	terms := w.terms(in)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	tokens := make(map[string]bool, len(res.Tokens))
+	for _, t := range res.Tokens {
+		tokens[t] = true
+	}
+	padded := " " + res.Normalized + " "
+	for term, category := range terms {
+		var hit bool
+		if strings.Contains(term, " ") {
+			hit = strings.Contains(padded, " "+term+" ")
+		} else {
+			hit = tokens[term]
+		}
+		if hit {
+			res.Flag(category, 1, term)
+		}
+	}
+	return nil
+}
+
+// terms returns the normalised term → category map for the input's app and
+// language.
+func (w WordList) terms(in Input) map[string]string {
+	defaultTermsOnce.Do(func() {
+		defaultTerms = make(map[string]string, len(DefaultWords))
+		for word, cat := range DefaultWords {
+			addTerm(defaultTerms, word, cat)
+		}
+	})
+	if w.Config == nil || in.AppID == "" {
+		return defaultTerms
+	}
+	terms := make(map[string]string, len(defaultTerms))
+	for t, cat := range defaultTerms {
+		terms[t] = cat
+	}
+
+	keys := []string{BannedWordsKey}
+	if lang := language(in.Locale); lang != "" {
+		keys = append(keys, BannedWordsKey+"."+lang)
+	}
+	for _, key := range keys {
+		v, ok := w.Config.ConfigValue(in.AppID, key)
+		if !ok {
+			continue
+		}
+		switch list := v.(type) {
+		case []interface{}:
+			for _, word := range list {
+				if s, ok := word.(string); ok {
+					addTerm(terms, s, CategoryProfanity)
+				}
+			}
+		case map[string]interface{}:
+			for word, cat := range list {
+				c, _ := cat.(string)
+				if c == "" {
+					c = CategoryProfanity
+				}
+				addTerm(terms, word, c)
+			}
+		}
+	}
+
+	if v, ok := w.Config.ConfigValue(in.AppID, AllowedWordsKey); ok {
+		if list, ok := v.([]interface{}); ok {
+			for _, word := range list {
+				if s, ok := word.(string); ok {
+					delete(terms, strings.Join(Tokenize(s), " "))
+				}
+			}
+		}
+	}
+	return terms
+}
+
+var (
+	defaultTermsOnce sync.Once
+	defaultTerms     map[string]string
+)
+
+func addTerm(terms map[string]string, word, category string) {
+	if t := strings.Join(Tokenize(word), " "); t != "" {
+		terms[t] = category
+	}
+}
+
+// language returns the lowercase language subtag of a locale ("tr-TR" → "tr").
+func language(locale string) string {
+	lang, _, _ := strings.Cut(locale, "-")
+	lang, _, _ = strings.Cut(lang, "_")
+	return strings.ToLower(strings.TrimSpace(lang))
+}
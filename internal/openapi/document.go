@@ -0,0 +1,280 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+)
+
+// Info is the document's info object.
+type Info struct {
+	Title       string
+	Version     string
+	Description string
+}
+
+var pathParam = regexp.MustCompile(`:([A-Za-z0-9_]+)\??|\*`)
+
+// Document builds the OpenAPI 3.1 document for appID: the core routes plus
+// the routes plugins registered for that app. serverURL is the API's base
+// URL, without the /api prefix.
+func (s *Spec) Document(appID string, info Info, serverURL string) map[string]interface{} {
+	s.mu.RLock()
+	routes := make([]route, 0, len(s.routes))
+	for _, r := range s.routes {
+		if r.app == "" || r.app == appID {
+			routes = append(routes, r)
+		}
+	}
+	s.mu.RUnlock()
+
+	gen := newSchemas()
+	paths := map[string]interface{}{}
+	usedIDs := map[string]int{}
+	tags := map[string]bool{}
+	for _, r := range routes {
+		path, params := openAPIPath(r.path)
+		item, _ := paths[path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+		op := r.operation(gen, path, params, appID)
+		id := operationID(r)
+		usedIDs[id]++
+		if n := usedIDs[id]; n > 1 {
+			id = fmt.Sprintf("%s%d", id, n)
+		}
+		op["operationId"] = id
+		for _, t := range op["tags"].([]string) {
+			tags[t] = true
+		}
+		item[strings.ToLower(r.method)] = op
+	}
+
+	tagList := make([]string, 0, len(tags))
+	for t := range tags {
+		tagList = append(tagList, t)
+	}
+	sort.Strings(tagList)
+	tagObjects := make([]interface{}, len(tagList))
+	for i, t := range tagList {
+		tagObjects[i] = map[string]interface{}{"name": t}
+	}
+
+	gen.component(reflect.TypeOf(apierr.ErrorResponse{}))
+	infoObj := map[string]interface{}{"title": info.Title, "version": info.Version}
+	if info.Description != "" {
+		infoObj["description"] = info.Description
+	}
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info":    infoObj,
+		"servers": []interface{}{map[string]interface{}{"url": strings.TrimRight(serverURL, "/")}},
+		"tags":    tagObjects,
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": gen.components,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth":   map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT", "description": "Access token from sign-in"},
+				"adminSession": map[string]interface{}{"type": "http", "scheme": "bearer", "description": "Admin session token from POST /api/admin/auth/totp/verify"},
+				"adminKey":     map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-Admin-Key"},
+				"webhookAuth":  map[string]interface{}{"type": "http", "scheme": "bearer", "description": "The app's RevenueCat webhook secret"},
+			},
+		},
+	}
+}
+
+// openAPIPath converts fiber's /users/:id to /users/{id}.
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	out := pathParam.ReplaceAllStringFunc(path, func(m string) string {
+		name := "wildcard"
+		if m != "*" {
+			name = strings.TrimSuffix(strings.TrimPrefix(m, ":"), "?")
+		}
+		params = append(params, name)
+		return "{" + name + "}"
+	})
+	return out, params
+}
+
+func (r route) operation(gen *schemas, path string, params []string, appID string) map[string]interface{} {
+	op := r.op
+	tags := op.Tags
+	if len(tags) == 0 {
+		tags = []string{defaultTag(r.path)}
+	}
+	summary := op.Summary
+	if summary == "" {
+		summary = r.method + " " + path
+	}
+	out := map[string]interface{}{
+		"summary": summary,
+		"tags":    tags,
+	}
+	description := op.Description
+	if op.RateLimit != "" {
+		description = strings.TrimSpace(description + "\n\nRate limit: " + op.RateLimit + ".")
+	}
+	if op.Permission != "" {
+		description = strings.TrimSpace(description + "\n\nRequires the " + string(op.Permission) + " permission.")
+	}
+	if description != "" {
+		out["description"] = description
+	}
+
+	parameters := []interface{}{
+		map[string]interface{}{
+			"name":        "X-App-ID",
+			"in":          "header",
+			"description": "The app; not needed when the bearer token carries it",
+			"schema":      map[string]interface{}{"type": "string", "default": appID},
+		},
+	}
+	for _, p := range params {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     p,
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	for _, q := range op.Query {
+		typ := q.Type
+		if typ == "" {
+			typ = "string"
+		}
+		param := map[string]interface{}{
+			"name":   q.Name,
+			"in":     "query",
+			"schema": map[string]interface{}{"type": typ},
+		}
+		if q.Description != "" {
+			param["description"] = q.Description
+		}
+		if q.Required {
+			param["required"] = true
+		}
+		parameters = append(parameters, param)
+	}
+	out["parameters"] = parameters
+
+	switch {
+	case op.Upload != "":
+		out["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"multipart/form-data": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"type":       "object",
+						"required":   []string{op.Upload},
+						"properties": map[string]interface{}{op.Upload: map[string]interface{}{"type": "string", "contentMediaType": "application/octet-stream"}},
+					},
+				},
+			},
+		}
+	case op.Request != nil:
+		out["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": gen.of(op.Request)}},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]interface{}{"description": http.StatusText(status)}
+	switch {
+	case op.Stream:
+		success["content"] = map[string]interface{}{"text/event-stream": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}}
+	case op.HTML:
+		success["content"] = map[string]interface{}{"text/html": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}}
+	case op.Response != nil:
+		success["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": gen.of(op.Response)}}
+	}
+	errRef := map[string]interface{}{
+		"description": "Error",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/ErrorResponse"}},
+		},
+	}
+	responses := map[string]interface{}{
+		fmt.Sprint(status): success,
+		"default":          errRef,
+	}
+	if op.RateLimit != "" {
+		responses["429"] = errRef
+	}
+
+	switch op.Auth {
+	case AuthUser, AuthRegistered:
+		out["security"] = []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+		responses["401"] = errRef
+		if op.Auth == AuthRegistered {
+			responses["403"] = errRef
+		}
+	case AuthOptional:
+		out["security"] = []interface{}{map[string]interface{}{}, map[string]interface{}{"bearerAuth": []string{}}}
+	case AuthAdmin:
+		out["security"] = []interface{}{
+			map[string]interface{}{"adminSession": []string{}},
+			map[string]interface{}{"adminKey": []string{}},
+		}
+		responses["401"] = errRef
+		responses["403"] = errRef
+	case AuthWebhook:
+		out["security"] = []interface{}{map[string]interface{}{"webhookAuth": []string{}}}
+	}
+	out["responses"] = responses
+	return out
+}
+
+// defaultTag is the first path segment after /api (or /api/p for plugin
+// routes); admin routes share the admin tag.
+func defaultTag(path string) string {
+	for _, seg := range strings.Split(strings.Trim(path, "/"), "/") {
+		if seg == "api" || seg == "p" || seg == "" || strings.HasPrefix(seg, ":") {
+			continue
+		}
+		return seg
+	}
+	return "default"
+}
+
+// operationID is the handler's name, e.g. authLogin for AuthHandler.Login,
+// or the method and path for closures.
+func operationID(r route) string {
+	if r.handler != "" {
+		recv, method, _ := strings.Cut(r.handler, ".")
+		recv = strings.TrimSuffix(recv, "Handler")
+		if r.app != "" {
+			recv = exportName(r.app) + recv
+		}
+		return lowerFirst(recv + method)
+	}
+	var b strings.Builder
+	b.WriteString(strings.ToLower(r.method))
+	for _, seg := range strings.Split(r.path, "/") {
+		seg = strings.Trim(seg, ":?*")
+		if seg == "" || seg == "api" {
+			continue
+		}
+		b.WriteString(exportName(seg))
+	}
+	return b.String()
+}
+
+func lowerFirst(s string) string {
+	for i, r := range s {
+		return string(unicode.ToLower(r)) + s[i+len(string(r)):]
+	}
+	return s
+}
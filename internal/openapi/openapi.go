@@ -0,0 +1,252 @@
+// Package openapi describes the API as an OpenAPI 3.1 document. Routes are
+// recorded as they are registered through a Router, with metadata attached
+// by With; the document for an app holds the core routes and that app's
+// plugin routes.
+package openapi
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/rbac"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Auth is the credential a route requires.
+type Auth string
+
+const (
+	AuthNone       Auth = ""
+	AuthOptional   Auth = "optional"   // a user token is used when present
+	AuthUser       Auth = "user"       // user or guest token
+	AuthRegistered Auth = "registered" // user token; guests are rejected
+	AuthAdmin      Auth = "admin"      // admin session or API key
+	AuthWebhook    Auth = "webhook"    // per-app shared secret
+)
+
+// Param is a query parameter.
+type Param struct {
+	Name        string
+	Type        string // string (default), integer, number or boolean
+	Description string
+	Required    bool
+}
+
+// Query describes an optional query parameter.
+func Query(name, typ, description string) Param {
+	return Param{Name: name, Type: typ, Description: description}
+}
+
+// Op is the metadata for one route. Request and Response are values of the
+// body types, e.g. dto.LoginRequest{}; a fiber.Map with typed zero values
+// describes an ad-hoc object. Zero fields fall back to the group's Op.
+type Op struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Auth        Auth
+	// Permission is the admin permission the route checks.
+	Permission rbac.Permission
+	Request    interface{}
+	Response   interface{}
+	// Status is the success status code; 200 when zero.
+	Status int
+	Query  []Param
+	// RateLimit describes a route-specific limit, e.g. "5/min per email".
+	RateLimit string
+	// Upload is the multipart form field of a file upload.
+	Upload string
+	// Stream marks a Server-Sent Events response.
+	Stream bool
+	// HTML marks an HTML page response.
+	HTML bool
+}
+
+// merge fills op's zero fields from defaults.
+func (op Op) merge(defaults Op) Op {
+	if len(op.Tags) == 0 {
+		op.Tags = defaults.Tags
+	}
+	if op.Auth == AuthNone {
+		op.Auth = defaults.Auth
+	}
+	if op.Permission == "" {
+		op.Permission = defaults.Permission
+	}
+	if op.RateLimit == "" {
+		op.RateLimit = defaults.RateLimit
+	}
+	return op
+}
+
+type route struct {
+	method   string
+	path     string // fiber syntax, e.g. /api/sleeps/:id
+	app      string // "" for core routes
+	op       Op
+	handler  string // name of the final handler, for the operation ID
+	explicit bool   // op came from With
+}
+
+// Spec collects the routes registered through its Routers.
+type Spec struct {
+	mu     sync.RWMutex
+	routes []route
+}
+
+func NewSpec() *Spec {
+	return &Spec{}
+}
+
+func (s *Spec) add(r route) {
+	s.mu.Lock()
+	s.routes = append(s.routes, r)
+	s.mu.Unlock()
+}
+
+// Router returns a Router that registers on r and records each route for
+// app ("" for core routes). prefix is r's path prefix, e.g. "/api".
+func (s *Spec) Router(r fiber.Router, prefix, app string) *Router {
+	return &Router{Router: r, spec: s, prefix: prefix, app: app}
+}
+
+// Router is a fiber.Router that records the routes registered on it. Plain
+// registrations are recorded without metadata; With attaches it:
+//
+//	api.With(openapi.Op{Summary: "Sign in", Request: dto.LoginRequest{}}).Post("/auth/login", h.Login)
+type Router struct {
+	fiber.Router
+	spec     *Spec
+	prefix   string
+	app      string
+	defaults Op
+	op       *Op
+}
+
+// With returns a Router whose next route carries op. Called before Group,
+// op becomes the defaults (tags, auth, permission, rate limit) for every
+// route in the group.
+func (r *Router) With(op Op) *Router {
+	next := *r
+	next.op = &op
+	return &next
+}
+
+// Describe attaches op to the next route registered on router, if it is a
+// Router. Plugins receive a plain fiber.Router and use this.
+func Describe(router fiber.Router, op Op) fiber.Router {
+	if r, ok := router.(*Router); ok {
+		return r.With(op)
+	}
+	return router
+}
+
+func (r *Router) record(method, path string, handlers []fiber.Handler) {
+	op, explicit := r.defaults, false
+	if r.op != nil {
+		op, explicit = r.op.merge(r.defaults), true
+	}
+	rt := route{
+		method:   method,
+		path:     joinPath(r.prefix, path),
+		app:      r.app,
+		op:       op,
+		explicit: explicit,
+	}
+	if len(handlers) > 0 {
+		rt.handler = handlerName(handlers[len(handlers)-1])
+	}
+	r.spec.add(rt)
+}
+
+func (r *Router) Get(path string, handlers ...fiber.Handler) fiber.Router {
+	r.record(fiber.MethodGet, path, handlers)
+	return r.Router.Get(path, handlers...)
+}
+
+func (r *Router) Post(path string, handlers ...fiber.Handler) fiber.Router {
+	r.record(fiber.MethodPost, path, handlers)
+	return r.Router.Post(path, handlers...)
+}
+
+func (r *Router) Put(path string, handlers ...fiber.Handler) fiber.Router {
+	r.record(fiber.MethodPut, path, handlers)
+	return r.Router.Put(path, handlers...)
+}
+
+func (r *Router) Patch(path string, handlers ...fiber.Handler) fiber.Router {
+	r.record(fiber.MethodPatch, path, handlers)
+	return r.Router.Patch(path, handlers...)
+}
+
+func (r *Router) Delete(path string, handlers ...fiber.Handler) fiber.Router {
+	r.record(fiber.MethodDelete, path, handlers)
+	return r.Router.Delete(path, handlers...)
+}
+
+func (r *Router) Add(method, path string, handlers ...fiber.Handler) fiber.Router {
+	r.record(strings.ToUpper(method), path, handlers)
+	return r.Router.Add(method, path, handlers...)
+}
+
+// Group returns a recording Router for the sub-group.
+func (r *Router) Group(prefix string, handlers ...fiber.Handler) fiber.Router {
+	return r.Sub(prefix, handlers...)
+}
+
+// Sub is Group returning a *Router, so the group's routes can use With.
+func (r *Router) Sub(prefix string, handlers ...fiber.Handler) *Router {
+	g := &Router{
+		Router:   r.Router.Group(prefix, handlers...),
+		spec:     r.spec,
+		prefix:   joinPath(r.prefix, prefix),
+		app:      r.app,
+		defaults: r.defaults,
+	}
+	if r.op != nil {
+		g.defaults = r.op.merge(r.defaults)
+	}
+	return g
+}
+
+// ForApp returns a Router that records routes as belonging to app.
+func (r *Router) ForApp(app string) *Router {
+	next := *r
+	next.app = app
+	next.op = nil
+	return &next
+}
+
+func joinPath(prefix, path string) string {
+	prefix = strings.TrimRight(prefix, "/")
+	path = strings.Trim(path, "/")
+	if path == "" {
+		if prefix == "" {
+			return "/"
+		}
+		return prefix
+	}
+	return prefix + "/" + path
+}
+
+// handlerName returns "Receiver.Method" for a method value such as
+// authHandler.Login, or "" for closures.
+func handlerName(h fiber.Handler) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(h).Pointer())
+	if fn == nil {
+		return ""
+	}
+	name := strings.TrimSuffix(fn.Name(), "-fm")
+	if i := strings.LastIndex(name, "/"); i != -1 {
+		name = name[i+1:]
+	}
+	// pkg.(*Type).Method
+	parts := strings.Split(name, ".")
+	if len(parts) != 3 || !strings.HasPrefix(parts[1], "(") {
+		return ""
+	}
+	recv := strings.Trim(parts[1], "(*)")
+	return recv + "." + parts[2]
+}
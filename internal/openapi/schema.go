@@ -0,0 +1,233 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	uuidType       = reflect.TypeOf(uuid.UUID{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemas turns Go types into JSON Schemas, collecting named structs under
+// components/schemas.
+type schemas struct {
+	components map[string]interface{}
+	names      map[reflect.Type]string
+	taken      map[string]reflect.Type
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: map[string]interface{}{},
+		names:      map[reflect.Type]string{},
+		taken:      map[string]reflect.Type{},
+	}
+}
+
+// of returns the schema for v's type. Maps are described by their entries,
+// so a fiber.Map with typed zero values documents an ad-hoc object.
+func (s *schemas) of(v interface{}) map[string]interface{} {
+	if m, ok := v.(map[string]interface{}); ok {
+		return s.ofMap(m)
+	}
+	return s.schema(reflect.TypeOf(v))
+}
+
+func (s *schemas) ofMap(m map[string]interface{}) map[string]interface{} {
+	props := map[string]interface{}{}
+	required := make([]string, 0, len(m))
+	for k, v := range m {
+		if v == nil {
+			props[k] = map[string]interface{}{}
+			continue
+		}
+		props[k] = s.of(v)
+		required = append(required, k)
+	}
+	sort.Strings(required)
+	out := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
+
+func (s *schemas) schema(t reflect.Type) map[string]interface{} {
+	if t == nil {
+		return map[string]interface{}{}
+	}
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+	// Types with their own JSON encoding (datatypes.JSON, gorm.DeletedAt)
+	// can't be described by their Go structure.
+	if t.Kind() != reflect.Ptr {
+		if t.Implements(jsonMarshaler) || reflect.PointerTo(t).Implements(jsonMarshaler) {
+			return map[string]interface{}{}
+		}
+		if t.Implements(textMarshaler) {
+			return map[string]interface{}{"type": "string"}
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return nullable(s.schema(t.Elem()))
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + s.component(t)}
+	}
+	return map[string]interface{}{}
+}
+
+var (
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// component registers a named struct and returns its component name. Names
+// that clash across packages are prefixed with the package name.
+func (s *schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if other, ok := s.taken[name]; ok && other != t {
+		pkg := t.PkgPath()
+		if i := strings.LastIndex(pkg, "/"); i != -1 {
+			pkg = pkg[i+1:]
+		}
+		name = exportName(pkg) + name
+	}
+	s.names[t] = name
+	s.taken[name] = t
+	// Reserve the name before recursing, for self-referencing types.
+	s.components[name] = map[string]interface{}{}
+	s.components[name] = s.object(t)
+	return name
+}
+
+func (s *schemas) object(t reflect.Type) map[string]interface{} {
+	props := map[string]interface{}{}
+	var required []string
+	s.fields(t, props, &required)
+	out := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		out["required"] = required
+	}
+	return out
+}
+
+// fields adds t's JSON fields to props, flattening embedded structs like
+// encoding/json does. A field is required when it is always encoded (no
+// omitempty, not a pointer) or tagged validate:"required".
+func (s *schemas) fields(t reflect.Type, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.fields(ft, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		schema := s.schema(f.Type)
+		if strings.Contains(","+opts+",", ",string,") {
+			schema = map[string]interface{}{"type": "string"}
+		}
+		props[name] = schema
+
+		validate := f.Tag.Get("validate")
+		omitempty := strings.Contains(","+opts+",", ",omitempty,")
+		if strings.Contains(","+validate+",", ",required,") ||
+			(!omitempty && f.Type.Kind() != reflect.Ptr && !strings.HasPrefix(validate, "omitempty")) {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// nullable allows null alongside schema, in OpenAPI 3.1 style.
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	if typ, ok := schema["type"].(string); ok {
+		out := make(map[string]interface{}, len(schema))
+		for k, v := range schema {
+			out[k] = v
+		}
+		out["type"] = []string{typ, "null"}
+		return out
+	}
+	if len(schema) == 0 {
+		return schema
+	}
+	return map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+}
+
+func exportName(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if r == '_' || r == '-' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
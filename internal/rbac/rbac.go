@@ -0,0 +1,169 @@
+// Package rbac defines admin permissions and the roles that bundle them.
+// Roles are granted per app (or on every app with AllApps), so an admin can
+// moderate one app without being able to change another's config.
+package rbac
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Permission is a "<area>:<read|write>" capability checked by admin routes.
+type Permission string
+
+const (
+	ModerationRead    Permission = "moderation:read"
+	ModerationWrite   Permission = "moderation:write"
+	UsersRead         Permission = "users:read"
+	UsersWrite        Permission = "users:write"
+	ConfigRead        Permission = "config:read"
+	ConfigWrite       Permission = "config:write"
+	ExperimentsRead   Permission = "experiments:read"
+	ExperimentsWrite  Permission = "experiments:write"
+	PushRead          Permission = "push:read"
+	PushWrite         Permission = "push:write"
+	WebhooksRead      Permission = "webhooks:read"
+	WebhooksWrite     Permission = "webhooks:write"
+	BillingRead       Permission = "billing:read"
+	AnnouncementWrite Permission = "announcements:write"
+	PluginWrite       Permission = "plugin:write" // app-specific admin routes
+	AuditRead         Permission = "audit:read"
+	AdminsRead        Permission = "admins:read"
+	AdminsWrite       Permission = "admins:write"
+)
+
+// AllPermissions lists every permission, for validation and the owner role.
+var AllPermissions = []Permission{
+	ModerationRead, ModerationWrite, UsersRead, UsersWrite,
+	ConfigRead, ConfigWrite, ExperimentsRead, ExperimentsWrite,
+	PushRead, PushWrite, WebhooksRead, WebhooksWrite, BillingRead,
+	AnnouncementWrite, PluginWrite, AuditRead, AdminsRead, AdminsWrite,
+}
+
+// AllApps is the binding app ID that applies to every app. Managing admins
+// (AdminsRead, AdminsWrite) only counts bindings on AllApps.
+const AllApps = "*"
+
+// Roles maps each role name to its permissions.
+var Roles = map[string][]Permission{
+	"owner": AllPermissions,
+	"moderator": {
+		ModerationRead, ModerationWrite, UsersRead, UsersWrite,
+	},
+	"developer": {
+		ConfigRead, ConfigWrite, ExperimentsRead, ExperimentsWrite,
+		PushRead, PushWrite, WebhooksRead, WebhooksWrite,
+		AnnouncementWrite, PluginWrite, ModerationRead,
+	},
+	"analyst": {
+		ModerationRead, UsersRead, ConfigRead, ExperimentsRead,
+		PushRead, WebhooksRead, BillingRead, AuditRead,
+	},
+	"support": {
+		ModerationRead, UsersRead, BillingRead,
+	},
+}
+
+// ValidPermission reports whether p is a known permission.
+func ValidPermission(p string) bool {
+	for _, known := range AllPermissions {
+		if string(known) == p {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleNames returns the role names in sorted order.
+func RoleNames() []string {
+	names := make([]string, 0, len(Roles))
+	for name := range Roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Binding grants a role on one app, or on every app when AppID is AllApps.
+type Binding struct {
+	AppID string `json:"app_id"`
+	Role  string `json:"role"`
+}
+
+// Principal is an authenticated admin: a signed-in admin user, an API key
+// acting for its owner, or the bootstrap token.
+type Principal struct {
+	Actor    tenant.AdminActor
+	AdminID  *uuid.UUID // nil for the bootstrap token
+	APIKeyID *uuid.UUID
+	Bindings []Binding
+	// Scopes narrows an API key to a subset of its owner's permissions;
+	// empty means no narrowing.
+	Scopes []Permission
+	// KeyAppID restricts an API key to one app; empty means no restriction.
+	KeyAppID string
+}
+
+// Can reports whether the principal holds perm on appID. Only AllApps
+// bindings count when appID is AllApps.
+func (p *Principal) Can(appID string, perm Permission) bool {
+	if p.KeyAppID != "" && appID != p.KeyAppID {
+		return false
+	}
+	if len(p.Scopes) > 0 && !containsPermission(p.Scopes, perm) {
+		return false
+	}
+	for _, b := range p.Bindings {
+		if b.AppID != AllApps && b.AppID != appID {
+			continue
+		}
+		if containsPermission(Roles[b.Role], perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions returns the principal's permissions on appID, sorted.
+func (p *Principal) Permissions(appID string) []Permission {
+	var perms []Permission
+	for _, perm := range AllPermissions {
+		if p.Can(appID, perm) {
+			perms = append(perms, perm)
+		}
+	}
+	sort.Slice(perms, func(i, j int) bool { return perms[i] < perms[j] })
+	return perms
+}
+
+// IsGlobal reports whether perm is an admin-management permission, which is
+// only granted by AllApps bindings.
+func IsGlobal(perm Permission) bool {
+	return strings.HasPrefix(string(perm), "admins:")
+}
+
+func containsPermission(list []Permission, perm Permission) bool {
+	for _, p := range list {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+const principalLocal = "admin_principal"
+
+// SetPrincipal stores the authenticated admin on the request context.
+func SetPrincipal(c *fiber.Ctx, p *Principal) {
+	c.Locals(principalLocal, p)
+}
+
+// GetPrincipal returns the admin set by AdminRequired, or nil.
+func GetPrincipal(c *fiber.Ctx) *Principal {
+	p, _ := c.Locals(principalLocal).(*Principal)
+	return p
+}
@@ -0,0 +1,69 @@
+package rbac
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPrincipalCan(t *testing.T) {
+	moderator := []Binding{{AppID: "daiyly", Role: "moderator"}}
+	globalDeveloper := []Binding{{AppID: AllApps, Role: "developer"}}
+	owner := []Binding{{AppID: AllApps, Role: "owner"}}
+
+	tests := []struct {
+		name      string
+		principal Principal
+		appID     string
+		perm      Permission
+		want      bool
+	}{
+		{"per-app binding on its app", Principal{Bindings: moderator}, "daiyly", ModerationWrite, true},
+		{"per-app binding on another app", Principal{Bindings: moderator}, "moodpulse", ModerationWrite, false},
+		{"per-app binding lacks the role's other permissions", Principal{Bindings: moderator}, "daiyly", ConfigWrite, false},
+		{"AllApps binding on any app", Principal{Bindings: globalDeveloper}, "moodpulse", ConfigWrite, true},
+		{"AllApps binding on AllApps", Principal{Bindings: globalDeveloper}, AllApps, ConfigWrite, true},
+		{"per-app binding never matches AllApps", Principal{Bindings: []Binding{{AppID: "daiyly", Role: "owner"}}}, AllApps, AdminsWrite, false},
+		{"bindings add up", Principal{Bindings: append(moderator, Binding{AppID: "daiyly", Role: "developer"})}, "daiyly", ConfigWrite, true},
+		{"unknown role grants nothing", Principal{Bindings: []Binding{{AppID: AllApps, Role: "root"}}}, "daiyly", UsersRead, false},
+		{"no bindings", Principal{}, "daiyly", UsersRead, false},
+
+		{"scopes narrow the owner", Principal{Bindings: owner, Scopes: []Permission{ConfigRead}}, "daiyly", ConfigWrite, false},
+		{"scoped permission the owner has", Principal{Bindings: owner, Scopes: []Permission{ConfigRead}}, "daiyly", ConfigRead, true},
+		{"scopes never widen", Principal{Bindings: moderator, Scopes: []Permission{ConfigWrite}}, "daiyly", ConfigWrite, false},
+
+		{"key app restriction matches", Principal{Bindings: owner, KeyAppID: "daiyly"}, "daiyly", UsersWrite, true},
+		{"key app restriction blocks other apps", Principal{Bindings: owner, KeyAppID: "daiyly"}, "moodpulse", UsersWrite, false},
+		{"key app restriction blocks AllApps", Principal{Bindings: owner, KeyAppID: "daiyly"}, AllApps, AdminsRead, false},
+	}
+	for _, tt := range tests {
+		if got := tt.principal.Can(tt.appID, tt.perm); got != tt.want {
+			t.Errorf("%s: Can(%q, %s) = %v, want %v", tt.name, tt.appID, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestPrincipalPermissions(t *testing.T) {
+	p := Principal{Bindings: []Binding{{AppID: "daiyly", Role: "support"}}}
+	want := []Permission{BillingRead, ModerationRead, UsersRead}
+	if got := p.Permissions("daiyly"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Permissions = %v, want %v", got, want)
+	}
+	if got := p.Permissions("moodpulse"); len(got) != 0 {
+		t.Errorf("Permissions on another app = %v, want none", got)
+	}
+}
+
+func TestRolesUseKnownPermissions(t *testing.T) {
+	for role, perms := range Roles {
+		for _, perm := range perms {
+			if !ValidPermission(string(perm)) {
+				t.Errorf("role %s has unknown permission %q", role, perm)
+			}
+		}
+	}
+	for _, perm := range AllPermissions {
+		if IsGlobal(perm) != (perm == AdminsRead || perm == AdminsWrite) {
+			t.Errorf("IsGlobal(%s) = %v", perm, IsGlobal(perm))
+		}
+	}
+}
@@ -0,0 +1,279 @@
+// Package realtime fans out app-scoped events to clients connected over SSE.
+//
+// Events are written to realtime_events and announced with Postgres
+// NOTIFY, so every instance delivers them to its own subscribers and a client
+// can resume from Last-Event-ID on any instance.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	// EventConfig signals that an app's resolved config changed.
+	EventConfig = "config"
+	// EventAnnouncement carries an admin broadcast verbatim.
+	EventAnnouncement = "announcement"
+
+	notifyChannel = "realtime_events"
+
+	// subscriberBuffer is how many events a slow client may fall behind before
+	// it is dropped; it reconnects and replays from Last-Event-ID.
+	subscriberBuffer = 32
+
+	// ReplayLimit caps how many stored events a reconnecting client is sent.
+	ReplayLimit = 500
+
+	// retention is how long events stay available for replay.
+	retention = 24 * time.Hour
+)
+
+// Subscriber receives events for one app until Unsubscribe is called or the
+// hub drops it for falling behind, in which case Events is closed.
+type Subscriber struct {
+	appID  string
+	events chan models.RealtimeEvent
+}
+
+// Events returns the subscriber's delivery channel.
+func (s *Subscriber) Events() <-chan models.RealtimeEvent {
+	return s.events
+}
+
+type Hub struct {
+	db         *gorm.DB
+	dsn        string
+	instanceID string
+
+	mu          sync.RWMutex
+	subscribers map[string]map[*Subscriber]struct{}
+	onRemote    []func(models.RealtimeEvent)
+}
+
+func NewHub(db *gorm.DB, dsn string) *Hub {
+	return &Hub{
+		db:          db,
+		dsn:         dsn,
+		instanceID:  uuid.NewString(),
+		subscribers: make(map[string]map[*Subscriber]struct{}),
+	}
+}
+
+// OnRemoteEvent registers a callback for events published by other instances,
+// e.g. to invalidate local caches on config changes.
+func (h *Hub) OnRemoteEvent(fn func(models.RealtimeEvent)) {
+	h.mu.Lock()
+	h.onRemote = append(h.onRemote, fn)
+	h.mu.Unlock()
+}
+
+// Subscribe registers a subscriber for an app's events.
+func (h *Hub) Subscribe(appID string) *Subscriber {
+	sub := &Subscriber{appID: appID, events: make(chan models.RealtimeEvent, subscriberBuffer)}
+	h.mu.Lock()
+	if h.subscribers[appID] == nil {
+		h.subscribers[appID] = make(map[*Subscriber]struct{})
+	}
+	h.subscribers[appID][sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// Unsubscribe removes a subscriber. Safe to call after the hub dropped it.
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[sub.appID][sub]; !ok {
+		return
+	}
+	delete(h.subscribers[sub.appID], sub)
+	if len(h.subscribers[sub.appID]) == 0 {
+		delete(h.subscribers, sub.appID)
+	}
+	close(sub.events)
+}
+
+// Publish stores an event, delivers it to local subscribers and notifies
+// other instances.
+func (h *Hub) Publish(appID, eventType string, data interface{}) (*models.RealtimeEvent, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("encode realtime event: %w", err)
+	}
+	event := models.RealtimeEvent{AppID: appID, Type: eventType, Data: payload}
+	if err := h.db.Create(&event).Error; err != nil {
+		return nil, fmt.Errorf("store realtime event: %w", err)
+	}
+
+	h.dispatch(event)
+
+	notice := h.instanceID + ":" + strconv.FormatInt(event.ID, 10)
+	if err := h.db.Exec("SELECT pg_notify(?, ?)", notifyChannel, notice).Error; err != nil {
+		// Other instances still catch up when their clients reconnect.
+		slog.Warn("realtime notify failed", "app_id", appID, "event_id", event.ID, "error", err)
+	}
+	return &event, nil
+}
+
+// Since returns an app's stored events after lastID, oldest first.
+func (h *Hub) Since(appID string, lastID int64) ([]models.RealtimeEvent, error) {
+	var events []models.RealtimeEvent
+	err := h.db.Where("app_id = ? AND id > ?", appID, lastID).
+		Order("id ASC").
+		Limit(ReplayLimit).
+		Find(&events).Error
+	return events, err
+}
+
+// LatestID returns the ID of an app's newest stored event, or 0.
+func (h *Hub) LatestID(appID string) (int64, error) {
+	var id int64
+	err := h.db.Model(&models.RealtimeEvent{}).
+		Where("app_id = ?", appID).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&id).Error
+	return id, err
+}
+
+// dispatch delivers an event to local subscribers without blocking. A
+// subscriber whose buffer is full is dropped rather than stalling the hub.
+func (h *Hub) dispatch(event models.RealtimeEvent) {
+	h.mu.RLock()
+	var slow []*Subscriber
+	for sub := range h.subscribers[event.AppID] {
+		select {
+		case sub.events <- event:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, sub := range slow {
+		slog.Warn("realtime subscriber fell behind, dropping", "app_id", event.AppID)
+		h.Unsubscribe(sub)
+	}
+}
+
+// Listen consumes NOTIFY messages from other instances until ctx is done,
+// reconnecting with backoff. Runs on its own connection outside the GORM pool
+// because LISTEN holds the session.
+func (h *Hub) Listen(ctx context.Context) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("panic in realtime listener", "recover", r)
+			}
+		}()
+		backoff := time.Second
+		for {
+			err := h.listen(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Error("realtime listener disconnected", "error", err, "retry_in", backoff)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			if backoff < time.Minute {
+				backoff *= 2
+			}
+		}
+	}()
+}
+
+func (h *Hub) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, h.dsn)
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	slog.Info("realtime listener connected")
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		origin, rawID, ok := strings.Cut(n.Payload, ":")
+		if !ok || origin == h.instanceID {
+			continue
+		}
+		id, err := strconv.ParseInt(rawID, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		var event models.RealtimeEvent
+		if err := h.db.First(&event, id).Error; err != nil {
+			slog.Warn("realtime event lookup failed", "event_id", id, "error", err)
+			continue
+		}
+
+		h.mu.RLock()
+		callbacks := h.onRemote
+		h.mu.RUnlock()
+		for _, fn := range callbacks {
+			fn(event)
+		}
+		h.dispatch(event)
+	}
+}
+
+// StartCleanup runs a daily goroutine that deletes events past the replay window.
+func (h *Hub) StartCleanup(done chan struct{}) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("panic in realtime cleanup goroutine", "recover", r)
+			}
+		}()
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				cutoff := time.Now().Add(-retention)
+				result := h.db.Where("created_at < ?", cutoff).Delete(&models.RealtimeEvent{})
+				if result.Error != nil {
+					slog.Error("realtime event cleanup failed", "error", result.Error)
+				} else if result.RowsAffected > 0 {
+					slog.Info("realtime event cleanup completed", "deleted", result.RowsAffected)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// Close drops every subscriber so open streams finish and server shutdown
+// isn't held up by long-lived connections.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for appID, subs := range h.subscribers {
+		for sub := range subs {
+			close(sub.events)
+		}
+		delete(h.subscribers, appID)
+	}
+}
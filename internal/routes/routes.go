@@ -5,10 +5,18 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/achievements"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/handlers"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/middleware"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/openapi"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/rbac"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"gorm.io/gorm"
@@ -18,15 +26,38 @@ func Setup(
 	app *fiber.App,
 	cfg *config.Config,
 	db *gorm.DB,
+	denylist middleware.Denylist,
 	authHandler *handlers.AuthHandler,
 	healthHandler *handlers.HealthHandler,
 	webhookHandler *handlers.WebhookHandler,
 	moderationHandler *handlers.ModerationHandler,
 	legalHandler *handlers.LegalHandler,
 	configHandler *handlers.RemoteConfigHandler,
+	experimentHandler *handlers.ExperimentHandler,
+	realtimeHandler *handlers.RealtimeHandler,
+	pushHandler *handlers.PushHandler,
+	userHandler *handlers.UserHandler,
+	achievementHandler *handlers.AchievementHandler,
+	outboundWebhookHandler *handlers.OutboundWebhookHandler,
+	banHandler *handlers.BanHandler,
+	adminService *services.AdminService,
+	adminHandler *handlers.AdminHandler,
+	openAPIHandler *handlers.OpenAPIHandler,
+	docs *openapi.Spec,
 	plugins []apps.Plugin,
 ) {
-	api := app.Group("/api")
+	// Every route registered through api is recorded in docs; With adds the
+	// summary, body types, auth and rate limit to its OpenAPI operation.
+	api := docs.Router(app.Group("/api"), "/api", "")
+
+	// Shapes shared by the route descriptions
+	page := []openapi.Param{
+		openapi.Query("limit", "integer", "Page size, at most 100"),
+		openapi.Query("offset", "integer", ""),
+	}
+	message := fiber.Map{"message": ""}
+	success := fiber.Map{"success": true}
+	configValue := fiber.Map{"key": "", "value": "", "type": "", "schema": "", "updated_at": time.Time{}}
 
 	// General API rate limiter: 60 req/min per IP
 	api.Use(limiter.New(limiter.Config{
@@ -37,18 +68,49 @@ func Setup(
 	}))
 
 	// Health (no tenant required)
-	api.Get("/health", healthHandler.Check)
+	api.With(openapi.Op{Summary: "Service health", Response: dto.HealthResponse{}}).Get("/health", healthHandler.Check)
+
+	// Remote Config (public, tenant-scoped via X-App-ID header).
+	// An optional Bearer token lets targeting rules match on user attributes.
+	api.With(openapi.Op{
+		Summary:     "Resolved remote config for the app",
+		Description: "Targeting rules match the signed-in user and the X-App-Version, X-Platform and X-Device-ID headers. ?since=<config_version> returns only changed and deleted keys.",
+		Auth:        openapi.AuthOptional,
+		Query:       []openapi.Param{openapi.Query("since", "integer", "config_version of the client's cached copy")},
+		Response:    fiber.Map{},
+	}).Get("/config", middleware.JWTOptional(cfg, denylist), configHandler.GetConfig)
 
-	// Remote Config (public, tenant-scoped via X-App-ID header)
-	api.Get("/config", configHandler.GetConfig)
+	// Experiments — exposure and conversion events. Subject is the signed-in user
+	// or the X-Device-ID header, matching the assignment made by GET /config.
+	// Signing in with X-Device-ID hands the device's assignments to the user.
+	api.With(openapi.Op{
+		Summary:  "Record an experiment exposure",
+		Auth:     openapi.AuthOptional,
+		Request:  dto.ExperimentExposureRequest{},
+		Response: fiber.Map{"experiment_key": "", "variant": ""},
+	}).Post("/experiments/exposures", middleware.JWTOptional(cfg, denylist), experimentHandler.RecordExposure)
+	api.With(openapi.Op{
+		Summary:  "Track a conversion event",
+		Auth:     openapi.AuthOptional,
+		Request:  dto.ExperimentEventRequest{},
+		Response: fiber.Map{"received": true, "conversions": 0},
+	}).Post("/experiments/events", middleware.JWTOptional(cfg, denylist), experimentHandler.TrackEvent)
+
+	// Realtime — SSE stream of config changes and announcements
+	api.With(openapi.Op{
+		Summary: "Stream config changes and announcements",
+		Auth:    openapi.AuthUser,
+		Query:   []openapi.Param{openapi.Query("since", "integer", "Replay events after this config_version"), openapi.Query("last_event_id", "integer", "For clients that can't send Last-Event-ID")},
+		Stream:  true,
+	}).Get("/realtime", middleware.JWTProtected(cfg, denylist), realtimeHandler.Stream)
 
 	// Legal pages (tenant optional for display)
-	api.Get("/legal/privacy", legalHandler.PrivacyPolicy)
-	api.Get("/legal/terms", legalHandler.TermsOfService)
+	api.With(openapi.Op{Summary: "Privacy policy", HTML: true}).Get("/legal/privacy", legalHandler.PrivacyPolicy)
+	api.With(openapi.Op{Summary: "Terms of service", HTML: true}).Get("/legal/terms", legalHandler.TermsOfService)
 
 	// Auth — public (tenant middleware already applied globally)
 	// Auth-specific rate limit: 10 req/min per IP (stricter)
-	auth := api.Group("/auth")
+	auth := api.With(openapi.Op{RateLimit: "10/min per IP"}).Sub("/auth")
 	auth.Use(limiter.New(limiter.Config{
 		Max:               10,
 		Expiration:        1 * time.Minute,
@@ -56,7 +118,22 @@ func Setup(
 		// c.IP() is now safe: TrustedProxies in main.go ensures real client IP
 		KeyGenerator: func(c *fiber.Ctx) string { return c.IP() },
 	}))
-	auth.Post("/register", authHandler.Register)
+	// A guest's Bearer token on register or Apple sign-in claims the guest's
+	// data into the new account.
+	auth.With(openapi.Op{
+		Summary:     "Register with email and password",
+		Description: "A guest's bearer token claims the guest's data into the new account.",
+		Auth:        openapi.AuthOptional,
+		Request:     dto.RegisterRequest{},
+		Response:    dto.AuthResponse{},
+		Status:      fiber.StatusCreated,
+	}).Post("/register", middleware.JWTOptional(cfg, denylist), authHandler.Register)
+	auth.With(openapi.Op{
+		Summary:  "Sign in as a guest",
+		Request:  dto.GuestRequest{},
+		Response: dto.AuthResponse{},
+		Status:   fiber.StatusCreated,
+	}).Post("/guest", authHandler.Guest)
 
 	// Login gets an additional per-email limiter (5 attempts/min per email).
 	// IP-based limits alone can be bypassed via X-Forwarded-For spoofing, but
@@ -75,45 +152,154 @@ func Setup(
 			return "login:ip:" + c.IP()
 		},
 		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   true,
-				"message": "Too many login attempts. Please try again later.",
-			})
+			return apierr.New(fiber.StatusTooManyRequests, "Too many login attempts. Please try again later.")
 		},
 	})
-	auth.Post("/login", loginEmailLimiter, authHandler.Login)
-	auth.Post("/refresh", authHandler.Refresh)
+	auth.With(openapi.Op{
+		Summary:     "Sign in with email and password",
+		Description: "Accounts with two-factor authentication get an MFAChallengeResponse instead; finish with POST /api/auth/2fa/verify.",
+		Request:     dto.LoginRequest{},
+		Response:    dto.AuthResponse{},
+		RateLimit:   "5/min per email",
+	}).Post("/login", loginEmailLimiter, authHandler.Login)
+	auth.With(openapi.Op{
+		Summary:  "Exchange a refresh token for new tokens",
+		Request:  dto.RefreshRequest{},
+		Response: dto.AuthResponse{},
+	}).Post("/refresh", authHandler.Refresh)
+
+	// Second login step when 2FA is on, and passkey sign-in. A passkey
+	// assertion can also be the second step by passing the login's mfa_token.
+	auth.With(openapi.Op{
+		Summary:  "Complete a two-factor sign-in",
+		Request:  dto.MFAVerifyRequest{},
+		Response: dto.AuthResponse{},
+	}).Post("/2fa/verify", authHandler.VerifyMFA)
+	auth.With(openapi.Op{
+		Summary:  "Start a passkey sign-in",
+		Request:  dto.PasskeyLoginBeginRequest{},
+		Response: dto.PasskeyOptionsResponse{},
+	}).Post("/passkeys/login/begin", authHandler.BeginPasskeyLogin)
+	auth.With(openapi.Op{
+		Summary:  "Finish a passkey sign-in",
+		Request:  dto.PasskeyLoginFinishRequest{},
+		Response: dto.AuthResponse{},
+	}).Post("/passkeys/login/finish", authHandler.FinishPasskeyLogin)
 
-	// Apple Sign In gets an additional per-token limiter (5 attempts/min per token prefix)
-	// to prevent rapid replay of stolen Apple identity tokens.
-	appleSignInLimiter := limiter.New(limiter.Config{
+	// Provider sign-in gets an additional per-token limiter (5 attempts/min per token prefix)
+	// to prevent rapid replay of stolen identity tokens. Apple sends identity_token,
+	// Google and OIDC providers id_token.
+	idTokenLimiter := limiter.New(limiter.Config{
 		Max:               5,
 		Expiration:        1 * time.Minute,
 		LimiterMiddleware: limiter.SlidingWindow{},
 		KeyGenerator: func(c *fiber.Ctx) string {
 			var body struct {
 				IdentityToken string `json:"identity_token"`
+				IDToken       string `json:"id_token"`
 			}
-			if err := json.Unmarshal(c.Body(), &body); err == nil && len(body.IdentityToken) > 0 {
+			if err := json.Unmarshal(c.Body(), &body); err == nil {
 				tok := body.IdentityToken
-				if len(tok) > 32 {
-					tok = tok[:32]
+				if tok == "" {
+					tok = body.IDToken
+				}
+				if len(tok) > 0 {
+					if len(tok) > 32 {
+						tok = tok[:32]
+					}
+					return "idtok:tok:" + tok
 				}
-				return "apple:tok:" + tok
 			}
-			return "apple:ip:" + c.IP()
+			return "idtok:ip:" + c.IP()
 		},
 		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   true,
-				"message": "Too many sign-in attempts. Please try again later.",
-			})
+			return apierr.New(fiber.StatusTooManyRequests, "Too many sign-in attempts. Please try again later.")
 		},
 	})
-	auth.Post("/apple", appleSignInLimiter, authHandler.AppleSignIn)
+	auth.With(openapi.Op{
+		Summary:   "Sign in with Apple",
+		Auth:      openapi.AuthOptional,
+		Request:   dto.AppleSignInRequest{},
+		Response:  dto.AuthResponse{},
+		RateLimit: "5/min per identity token",
+	}).Post("/apple", idTokenLimiter, middleware.JWTOptional(cfg, denylist), authHandler.AppleSignIn)
+	auth.With(openapi.Op{
+		Summary:   "Sign in with Google",
+		Auth:      openapi.AuthOptional,
+		Request:   dto.OIDCSignInRequest{},
+		Response:  dto.AuthResponse{},
+		RateLimit: "5/min per identity token",
+	}).Post("/google", idTokenLimiter, middleware.JWTOptional(cfg, denylist), authHandler.GoogleSignIn)
+	auth.With(openapi.Op{
+		Summary:   "Sign in with an OpenID Connect provider",
+		Auth:      openapi.AuthOptional,
+		Request:   dto.OIDCSignInRequest{},
+		Response:  dto.AuthResponse{},
+		RateLimit: "5/min per identity token",
+	}).Post("/oidc/:provider", idTokenLimiter, middleware.JWTOptional(cfg, denylist), authHandler.OIDCSignIn)
 
 	// Protected routes (JWT required) - apply middleware to individual routes
-	api.Post("/auth/logout", middleware.JWTProtected(cfg), authHandler.Logout)
+	api.With(openapi.Op{Summary: "Sign out", Auth: openapi.AuthUser, Request: dto.LogoutRequest{}, Response: message}).Post("/auth/logout", middleware.JWTProtected(cfg, denylist), authHandler.Logout)
+
+	// Linked sign-in providers. Guests sign in with a provider instead of
+	// linking one, which claims the guest's data.
+	api.With(openapi.Op{Summary: "List linked sign-in providers", Auth: openapi.AuthUser, Response: dto.IdentitiesResponse{}}).Get("/auth/identities", middleware.JWTProtected(cfg, denylist), authHandler.ListIdentities)
+	api.With(openapi.Op{
+		Summary:   "Link a sign-in provider",
+		Auth:      openapi.AuthRegistered,
+		Request:   dto.LinkIdentityRequest{},
+		Response:  message,
+		RateLimit: "5/min per identity token",
+	}).Post("/auth/identities/:provider", middleware.JWTProtected(cfg, denylist), middleware.RegisteredOnly(), idTokenLimiter, authHandler.LinkIdentity)
+	api.With(openapi.Op{Summary: "Unlink a sign-in provider", Auth: openapi.AuthRegistered, Response: message}).Delete("/auth/identities/:provider", middleware.JWTProtected(cfg, denylist), middleware.RegisteredOnly(), authHandler.UnlinkIdentity)
+
+	// Two-factor authentication and passkey management (registered users only).
+	api.With(openapi.Op{
+		Summary:  "Two-factor authentication status",
+		Auth:     openapi.AuthRegistered,
+		Response: dto.MFAStatusResponse{},
+	}).Get("/auth/2fa", middleware.JWTProtected(cfg, denylist), middleware.RegisteredOnly(), authHandler.MFAStatus)
+	api.With(openapi.Op{
+		Summary:  "Start authenticator app enrollment",
+		Auth:     openapi.AuthRegistered,
+		Response: dto.TOTPEnrollmentResponse{},
+	}).Post("/auth/2fa/totp", middleware.JWTProtected(cfg, denylist), middleware.RegisteredOnly(), authHandler.EnrollTOTP)
+	api.With(openapi.Op{
+		Summary:  "Confirm authenticator app enrollment",
+		Auth:     openapi.AuthRegistered,
+		Request:  dto.MFACodeRequest{},
+		Response: dto.RecoveryCodesResponse{},
+	}).Post("/auth/2fa/totp/confirm", middleware.JWTProtected(cfg, denylist), middleware.RegisteredOnly(), authHandler.ConfirmTOTP)
+	api.With(openapi.Op{
+		Summary:  "Turn off two-factor authentication",
+		Auth:     openapi.AuthRegistered,
+		Request:  dto.MFACodeRequest{},
+		Response: message,
+	}).Delete("/auth/2fa/totp", middleware.JWTProtected(cfg, denylist), middleware.RegisteredOnly(), authHandler.DisableTOTP)
+	api.With(openapi.Op{
+		Summary:  "Regenerate recovery codes",
+		Auth:     openapi.AuthRegistered,
+		Request:  dto.MFACodeRequest{},
+		Response: dto.RecoveryCodesResponse{},
+	}).Post("/auth/2fa/recovery-codes", middleware.JWTProtected(cfg, denylist), middleware.RegisteredOnly(), authHandler.RegenerateRecoveryCodes)
+	api.With(openapi.Op{
+		Summary:  "List passkeys",
+		Auth:     openapi.AuthRegistered,
+		Response: fiber.Map{"passkeys": []models.WebAuthnCredential{}},
+	}).Get("/auth/passkeys", middleware.JWTProtected(cfg, denylist), middleware.RegisteredOnly(), authHandler.ListPasskeys)
+	api.With(openapi.Op{
+		Summary:  "Start passkey registration",
+		Auth:     openapi.AuthRegistered,
+		Response: dto.PasskeyOptionsResponse{},
+	}).Post("/auth/passkeys/register/begin", middleware.JWTProtected(cfg, denylist), middleware.RegisteredOnly(), authHandler.BeginPasskeyRegistration)
+	api.With(openapi.Op{
+		Summary:  "Finish passkey registration",
+		Auth:     openapi.AuthRegistered,
+		Request:  dto.PasskeyRegisterFinishRequest{},
+		Response: models.WebAuthnCredential{},
+		Status:   fiber.StatusCreated,
+	}).Post("/auth/passkeys/register/finish", middleware.JWTProtected(cfg, denylist), middleware.RegisteredOnly(), authHandler.FinishPasskeyRegistration)
+	api.With(openapi.Op{Summary: "Delete a passkey", Auth: openapi.AuthRegistered, Response: message}).Delete("/auth/passkeys/:id", middleware.JWTProtected(cfg, denylist), middleware.RegisteredOnly(), authHandler.DeletePasskey)
 
 	// Account deletion: 1 successful attempt per user per day is more than enough.
 	// Per-user key (from JWT sub claim in body or fallback to IP) prevents DoS
@@ -133,15 +319,22 @@ func Setup(
 			return "del_acct:ip:" + c.IP()
 		},
 		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   true,
-				"message": "Too many deletion attempts. Please try again later.",
-			})
+			return apierr.New(fiber.StatusTooManyRequests, "Too many deletion attempts. Please try again later.")
 		},
 	})
-	api.Delete("/auth/account", middleware.JWTProtected(cfg), deleteAccountLimiter, authHandler.DeleteAccount)
+	api.With(openapi.Op{
+		Summary:     "Delete the account",
+		Description: "The account is disabled at once and purged after the grace period; signing in again before then restores it.",
+		Auth:        openapi.AuthUser,
+		Request:     dto.DeleteAccountRequest{},
+		Response:    fiber.Map{"message": "", "receipt": dto.DeletionReceiptResponse{}},
+		RateLimit:   "3/day per token",
+	}).Delete("/auth/account", middleware.JWTProtected(cfg, denylist), deleteAccountLimiter, authHandler.DeleteAccount)
+	// Receipts are looked up by their unguessable ID; the account itself may
+	// be signed out or gone.
+	api.With(openapi.Op{Summary: "Account deletion receipt", Response: fiber.Map{"receipt": dto.DeletionReceiptResponse{}}}).Get("/auth/account/deletions/:id", authHandler.DeletionReceipt)
 
-	// Moderation — user endpoints (protected)
+	// Moderation — user endpoints (protected; guests cannot report or block)
 	// Per-user report limiter (5/hour). The global 60 req/min per-IP is insufficient;
 	// a single authenticated user could spam 60 reports before it triggers. Keyed on
 	// JWT token prefix so each user gets their own bucket.
@@ -157,56 +350,432 @@ func Setup(
 			return "report:ip:" + c.IP()
 		},
 		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   true,
-				"message": "Too many reports submitted. Please try again later.",
-			})
+			return apierr.New(fiber.StatusTooManyRequests, "Too many reports submitted. Please try again later.")
 		},
 	})
-	api.Post("/reports", middleware.JWTProtected(cfg), reportLimiter, moderationHandler.CreateReport)
-	api.Post("/blocks", middleware.JWTProtected(cfg), moderationHandler.BlockUser)
-	api.Delete("/blocks/:id", middleware.JWTProtected(cfg), moderationHandler.UnblockUser)
+	api.With(openapi.Op{
+		Summary:   "Report content or a user",
+		Auth:      openapi.AuthRegistered,
+		Request:   dto.CreateReportRequest{},
+		Response:  models.Report{},
+		Status:    fiber.StatusCreated,
+		RateLimit: "5/hour per user",
+	}).Post("/reports", middleware.JWTProtected(cfg, denylist), middleware.RegisteredOnly(), reportLimiter, moderationHandler.CreateReport)
+	api.With(openapi.Op{
+		Summary:  "List my reports",
+		Auth:     openapi.AuthUser,
+		Query:    page,
+		Response: fiber.Map{"reports": []dto.MyReportResponse{}, "total": int64(0), "limit": 0, "offset": 0},
+	}).Get("/reports/mine", middleware.JWTProtected(cfg, denylist), moderationHandler.ListMyReports)
+	api.With(openapi.Op{
+		Summary:  "Block a user",
+		Auth:     openapi.AuthRegistered,
+		Request:  dto.BlockUserRequest{},
+		Response: message,
+	}).Post("/blocks", middleware.JWTProtected(cfg, denylist), middleware.RegisteredOnly(), moderationHandler.BlockUser)
+	api.With(openapi.Op{Summary: "Unblock a user", Auth: openapi.AuthUser, Response: message}).Delete("/blocks/:id", middleware.JWTProtected(cfg, denylist), moderationHandler.UnblockUser)
+
+	// Profile, avatar and preferences (protected)
+	api.With(openapi.Op{Summary: "Get my profile", Auth: openapi.AuthUser, Response: dto.ProfileResponse{}}).Get("/me", middleware.JWTProtected(cfg, denylist), userHandler.GetProfile)
+	api.With(openapi.Op{
+		Summary:  "Update my profile",
+		Auth:     openapi.AuthUser,
+		Request:  dto.UpdateProfileRequest{},
+		Response: dto.ProfileResponse{},
+	}).Patch("/me", middleware.JWTProtected(cfg, denylist), userHandler.UpdateProfile)
+	api.With(openapi.Op{
+		Summary:  "Set my timezone",
+		Auth:     openapi.AuthUser,
+		Request:  dto.UpdateTimezoneRequest{},
+		Response: fiber.Map{"timezone": "", "recomputing": true},
+	}).Put("/me/timezone", middleware.JWTProtected(cfg, denylist), userHandler.UpdateTimezone)
+	api.With(openapi.Op{
+		Summary:     "Upload my avatar",
+		Description: "JPEG, PNG, WebP or HEIC, at most 5 MB.",
+		Auth:        openapi.AuthUser,
+		Upload:      "avatar",
+		Response:    dto.ProfileResponse{},
+	}).Post("/me/avatar", middleware.JWTProtected(cfg, denylist), userHandler.UploadAvatar)
+	api.With(openapi.Op{Summary: "Remove my avatar", Auth: openapi.AuthUser, Response: dto.ProfileResponse{}}).Delete("/me/avatar", middleware.JWTProtected(cfg, denylist), userHandler.DeleteAvatar)
+	api.With(openapi.Op{Summary: "Get all my preferences, by namespace", Auth: openapi.AuthUser, Response: fiber.Map{}}).Get("/me/preferences", middleware.JWTProtected(cfg, denylist), userHandler.ListPreferences)
+	api.With(openapi.Op{Summary: "Get one preferences namespace", Auth: openapi.AuthUser, Response: fiber.Map{}}).Get("/me/preferences/:namespace", middleware.JWTProtected(cfg, denylist), userHandler.GetPreferences)
+	api.With(openapi.Op{
+		Summary:     "Update one preferences namespace",
+		Description: "Fields in the body are merged into the saved values.",
+		Auth:        openapi.AuthUser,
+		Request:     fiber.Map{},
+		Response:    fiber.Map{},
+	}).Patch("/me/preferences/:namespace", middleware.JWTProtected(cfg, denylist), userHandler.UpdatePreferences)
+
+	// Email change with re-verification: the new address must confirm a
+	// mailed token. Guests have no email to change; they register instead.
+	// Per-user limit (5/hour) keeps the endpoint from being used to spam
+	// arbitrary inboxes or brute-force the password.
+	emailChangeLimiter := limiter.New(limiter.Config{
+		Max:               5,
+		Expiration:        1 * time.Hour,
+		LimiterMiddleware: limiter.SlidingWindow{},
+		// Runs after JWTProtected, so the user ID is known.
+		KeyGenerator: func(c *fiber.Ctx) string {
+			if userID, err := tenant.GetUserID(c); err == nil {
+				return "email_change:" + userID.String()
+			}
+			return "email_change:ip:" + c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return apierr.New(fiber.StatusTooManyRequests, "Too many email change requests. Please try again later.")
+		},
+	})
+	api.With(openapi.Op{
+		Summary:     "Change my email",
+		Description: "Mails a confirmation token to the new address; confirm with POST /api/auth/email/verify.",
+		Auth:        openapi.AuthRegistered,
+		Request:     dto.ChangeEmailRequest{},
+		Response:    message,
+		Status:      fiber.StatusAccepted,
+		RateLimit:   "5/hour per user",
+	}).Post("/me/email", middleware.JWTProtected(cfg, denylist), middleware.RegisteredOnly(), emailChangeLimiter, userHandler.ChangeEmail)
+	auth.With(openapi.Op{Summary: "Confirm an email change", Request: dto.VerifyEmailRequest{}, Response: message}).Post("/email/verify", userHandler.VerifyEmail)
+
+	// Achievements — XP, level and unlock state (protected)
+	api.With(openapi.Op{Summary: "My XP, level and achievements", Auth: openapi.AuthUser, Response: achievements.Summary{}}).Get("/achievements", middleware.JWTProtected(cfg, denylist), achievementHandler.List)
+
+	// Push notifications — device registry and open receipts (protected)
+	api.With(openapi.Op{
+		Summary:  "Register a device for push",
+		Auth:     openapi.AuthUser,
+		Request:  dto.RegisterDeviceRequest{},
+		Response: models.DeviceToken{},
+		Status:   fiber.StatusCreated,
+	}).Post("/push/devices", middleware.JWTProtected(cfg, denylist), pushHandler.RegisterDevice)
+	api.With(openapi.Op{Summary: "Unregister a push device", Auth: openapi.AuthUser, Response: message}).Delete("/push/devices/:token", middleware.JWTProtected(cfg, denylist), pushHandler.UnregisterDevice)
+	api.With(openapi.Op{Summary: "Record that a notification was opened", Auth: openapi.AuthUser, Response: message}).Post("/push/notifications/:id/opened", middleware.JWTProtected(cfg, denylist), pushHandler.MarkOpened)
 
-	// Admin moderation panel (protected + admin required)
-	// Strict rate limiter (10 req/min per IP) protects admin token brute-force.
+	// Admin API. Admins sign in with their own identities (never end-user
+	// tokens) or API keys; every route checks a per-app permission and every
+	// request lands in the admin audit log.
+	// Strict rate limiter (10 req/min per IP) protects admin credential brute-force.
 	adminLimiter := limiter.New(limiter.Config{
 		Max:               10,
 		Expiration:        1 * time.Minute,
 		LimiterMiddleware: limiter.SlidingWindow{},
 		KeyGenerator:      func(c *fiber.Ctx) string { return c.IP() },
 		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   true,
-				"message": "Too many requests. Please try again later.",
-			})
+			return apierr.New(fiber.StatusTooManyRequests, "Too many requests. Please try again later.")
 		},
 	})
-	admin := api.Group("/admin", adminLimiter, middleware.JWTProtected(cfg), middleware.AdminRequired(db, cfg))
-	admin.Get("/moderation/reports", moderationHandler.ListReports)
-	admin.Put("/moderation/reports/:id", moderationHandler.ActionReport)
+	adminAudit := middleware.AdminAudit(adminService)
+	// Registered before the group so the session check does not apply
+	api.With(openapi.Op{
+		Summary:   "Admin sign-in, first step",
+		Request:   dto.AdminLoginRequest{},
+		Response:  dto.AdminMFAChallengeResponse{},
+		RateLimit: "10/min per IP, 5/min per email",
+	}).Post("/admin/auth/login", adminLimiter, loginEmailLimiter, adminAudit, adminHandler.Login)
+	api.With(openapi.Op{
+		Summary:   "Enroll an admin authenticator",
+		Request:   dto.AdminMFATokenRequest{},
+		Response:  dto.TOTPEnrollmentResponse{},
+		RateLimit: "10/min per IP",
+	}).Post("/admin/auth/totp/enroll", adminLimiter, adminAudit, adminHandler.EnrollTOTP)
+	api.With(openapi.Op{
+		Summary:   "Admin sign-in, second step",
+		Request:   dto.AdminMFAVerifyRequest{},
+		Response:  dto.AdminLoginResponse{},
+		RateLimit: "10/min per IP",
+	}).Post("/admin/auth/totp/verify", adminLimiter, adminAudit, adminHandler.VerifyTOTP)
 
-	// Admin config management (protected + admin required)
-	admin.Put("/config/:key", configHandler.SetConfigKey)
-	admin.Delete("/config/:key", configHandler.DeleteConfigKey)
+	admin := api.With(openapi.Op{Auth: openapi.AuthAdmin, RateLimit: "10/min per IP"}).
+		Sub("/admin", adminLimiter, adminAudit, middleware.AdminRequired(adminService, cfg, services.BootstrapPrincipal()))
+	perm := middleware.RequirePermission
+
+	// OpenAPI document for the request's app, for generating mobile clients.
+	// Admin-only unless OPENAPI_PUBLIC is set.
+	openAPIOp := openapi.Op{Summary: "OpenAPI document for the app", Response: fiber.Map{}}
+	if cfg.OpenAPIPublic {
+		api.With(openAPIOp).Get("/openapi.json", openAPIHandler.Document)
+	} else {
+		openAPIOp.Auth = openapi.AuthAdmin
+		api.With(openAPIOp).Get("/openapi.json", adminLimiter, adminAudit, middleware.AdminRequired(adminService, cfg, services.BootstrapPrincipal()), openAPIHandler.Document)
+	}
+	admin.With(openapi.Op{Summary: "The signed-in admin and their permissions", Response: dto.AdminMeResponse{}}).Get("/me", adminHandler.Me)
+
+	// Admin identities, role bindings and API keys (admins:* on every app)
+	admin.With(openapi.Op{
+		Summary:    "List admins",
+		Permission: rbac.AdminsRead,
+		Response:   fiber.Map{"admins": []dto.AdminResponse{}},
+	}).Get("/admins", perm(rbac.AdminsRead), adminHandler.ListAdmins)
+	admin.With(openapi.Op{
+		Summary:    "Create an admin",
+		Permission: rbac.AdminsWrite,
+		Request:    dto.CreateAdminRequest{},
+		Response:   dto.AdminResponse{},
+		Status:     fiber.StatusCreated,
+	}).Post("/admins", perm(rbac.AdminsWrite), adminHandler.CreateAdmin)
+	admin.With(openapi.Op{
+		Summary:    "Update an admin",
+		Permission: rbac.AdminsWrite,
+		Request:    dto.UpdateAdminRequest{},
+		Response:   dto.AdminResponse{},
+	}).Put("/admins/:id", perm(rbac.AdminsWrite), adminHandler.UpdateAdmin)
+	admin.With(openapi.Op{
+		Summary:    "Grant an admin a role",
+		Permission: rbac.AdminsWrite,
+		Request:    rbac.Binding{},
+		Response:   models.AdminRoleBinding{},
+		Status:     fiber.StatusCreated,
+	}).Post("/admins/:id/roles", perm(rbac.AdminsWrite), adminHandler.GrantRole)
+	admin.With(openapi.Op{Summary: "Revoke an admin role", Permission: rbac.AdminsWrite, Response: success}).Delete("/admins/:id/roles/:binding_id", perm(rbac.AdminsWrite), adminHandler.RevokeRole)
+	admin.With(openapi.Op{
+		Summary:    "List admin API keys",
+		Permission: rbac.AdminsRead,
+		Query:      []openapi.Param{openapi.Query("admin_id", "string", "Only this admin's keys")},
+		Response:   fiber.Map{"api_keys": []models.AdminAPIKey{}},
+	}).Get("/api-keys", perm(rbac.AdminsRead), adminHandler.ListAPIKeys)
+	admin.With(openapi.Op{
+		Summary:     "Create an admin API key",
+		Description: "The key is only returned here.",
+		Permission:  rbac.AdminsWrite,
+		Request:     dto.CreateAdminAPIKeyRequest{},
+		Response:    fiber.Map{"api_key": models.AdminAPIKey{}, "key": ""},
+		Status:      fiber.StatusCreated,
+	}).Post("/api-keys", perm(rbac.AdminsWrite), adminHandler.CreateAPIKey)
+	admin.With(openapi.Op{Summary: "Revoke an admin API key", Permission: rbac.AdminsWrite, Response: success}).Delete("/api-keys/:id", perm(rbac.AdminsWrite), adminHandler.RevokeAPIKey)
+	admin.With(openapi.Op{
+		Summary:    "Admin audit log",
+		Permission: rbac.AuditRead,
+		Query:      append(page, openapi.Query("actor_id", "string", "Only this admin's or API key's requests")),
+		Response:   fiber.Map{"entries": []models.AdminAuditLog{}, "total": int64(0), "limit": 0, "offset": 0},
+	}).Get("/audit-log", perm(rbac.AuditRead), adminHandler.ListAudit)
+
+	admin.With(openapi.Op{
+		Summary:    "List reports",
+		Permission: rbac.ModerationRead,
+		Query:      append(page, openapi.Query("status", "string", "pending, reviewed, actioned or dismissed")),
+		Response:   fiber.Map{"reports": []models.Report{}, "total": int64(0), "limit": 0, "offset": 0},
+	}).Get("/moderation/reports", perm(rbac.ModerationRead), moderationHandler.ListReports)
+	admin.With(openapi.Op{
+		Summary:    "Action a report",
+		Permission: rbac.ModerationWrite,
+		Request:    dto.ActionReportRequest{},
+		Response:   fiber.Map{"message": "", "report": models.Report{}},
+	}).Put("/moderation/reports/:id", perm(rbac.ModerationWrite), moderationHandler.ActionReport)
+	admin.With(openapi.Op{
+		Summary:    "List moderation actions",
+		Permission: rbac.ModerationRead,
+		Query:      append(page, openapi.Query("content_id", "string", ""), openapi.Query("user_id", "string", "")),
+		Response:   fiber.Map{"actions": []models.ModerationAction{}, "total": int64(0), "limit": 0, "offset": 0},
+	}).Get("/moderation/actions", perm(rbac.ModerationRead), moderationHandler.ListActions)
+
+	// User bans; banned users lose access within seconds
+	admin.With(openapi.Op{
+		Summary:    "List bans",
+		Permission: rbac.UsersRead,
+		Query:      append(page, openapi.Query("active", "boolean", "Leave out lifted and expired bans")),
+		Response:   fiber.Map{"bans": []models.UserBan{}, "total": int64(0), "limit": 0, "offset": 0},
+	}).Get("/bans", perm(rbac.UsersRead), banHandler.List)
+	admin.With(openapi.Op{
+		Summary:    "Ban a user",
+		Permission: rbac.UsersWrite,
+		Request:    dto.BanUserRequest{},
+		Response:   fiber.Map{"ban": models.UserBan{}},
+		Status:     fiber.StatusCreated,
+	}).Post("/users/:id/ban", perm(rbac.UsersWrite), banHandler.Ban)
+	admin.With(openapi.Op{Summary: "Lift a user's bans", Permission: rbac.UsersWrite, Response: success}).Delete("/users/:id/ban", perm(rbac.UsersWrite), banHandler.Unban)
+
+	// Account deletions in their grace period, and their receipts
+	admin.With(openapi.Op{
+		Summary:    "List account deletions",
+		Permission: rbac.UsersRead,
+		Query:      append(page, openapi.Query("status", "string", "pending (default), restored, purged or all")),
+		Response:   fiber.Map{"deletions": []models.AccountDeletion{}, "total": int64(0), "limit": 0, "offset": 0},
+	}).Get("/account-deletions", perm(rbac.UsersRead), authHandler.ListDeletions)
+	admin.With(openapi.Op{
+		Summary:    "Cancel a pending account deletion",
+		Permission: rbac.UsersWrite,
+		Response:   fiber.Map{"deletion": models.AccountDeletion{}},
+	}).Post("/account-deletions/:id/restore", perm(rbac.UsersWrite), authHandler.RestoreDeletion)
+
+	// Admin config management
+	admin.With(openapi.Op{
+		Summary:    "Set a config key",
+		Permission: rbac.ConfigWrite,
+		Request:    dto.SetConfigRequest{},
+		Response:   configValue,
+	}).Put("/config/:key", perm(rbac.ConfigWrite), configHandler.SetConfigKey)
+	admin.With(openapi.Op{Summary: "Delete a config key", Permission: rbac.ConfigWrite, Response: message}).Delete("/config/:key", perm(rbac.ConfigWrite), configHandler.DeleteConfigKey)
+	admin.With(openapi.Op{
+		Summary:    "Config key revisions",
+		Permission: rbac.ConfigRead,
+		Query:      page,
+		Response:   fiber.Map{"key": "", "revisions": []models.RemoteConfigRevision{}, "total": int64(0), "limit": 0, "offset": 0},
+	}).Get("/config/:key/history", perm(rbac.ConfigRead), configHandler.GetConfigHistory)
+	admin.With(openapi.Op{
+		Summary:    "Roll a config key back to a revision",
+		Permission: rbac.ConfigWrite,
+		Response:   configValue,
+	}).Post("/config/:key/rollback/:revision", perm(rbac.ConfigWrite), configHandler.RollbackConfigKey)
+
+	// Remote config targeting rules
+	admin.With(openapi.Op{
+		Summary:    "List a key's targeting rules",
+		Permission: rbac.ConfigRead,
+		Response:   fiber.Map{"key": "", "rules": []models.RemoteConfigRule{}},
+	}).Get("/config/:key/rules", perm(rbac.ConfigRead), configHandler.ListRules)
+	admin.With(openapi.Op{
+		Summary:    "Create a targeting rule",
+		Permission: rbac.ConfigWrite,
+		Request:    dto.RemoteConfigRuleRequest{},
+		Response:   models.RemoteConfigRule{},
+		Status:     fiber.StatusCreated,
+	}).Post("/config/:key/rules", perm(rbac.ConfigWrite), configHandler.CreateRule)
+	admin.With(openapi.Op{
+		Summary:    "Update a targeting rule",
+		Permission: rbac.ConfigWrite,
+		Request:    dto.RemoteConfigRuleRequest{},
+		Response:   models.RemoteConfigRule{},
+	}).Put("/config/rules/:id", perm(rbac.ConfigWrite), configHandler.UpdateRule)
+	admin.With(openapi.Op{Summary: "Delete a targeting rule", Permission: rbac.ConfigWrite, Response: message}).Delete("/config/rules/:id", perm(rbac.ConfigWrite), configHandler.DeleteRule)
+
+	// A/B experiments
+	admin.With(openapi.Op{
+		Summary:    "List experiments",
+		Permission: rbac.ExperimentsRead,
+		Response:   fiber.Map{"experiments": []models.Experiment{}},
+	}).Get("/experiments", perm(rbac.ExperimentsRead), experimentHandler.ListExperiments)
+	admin.With(openapi.Op{
+		Summary:    "Create an experiment",
+		Permission: rbac.ExperimentsWrite,
+		Request:    dto.ExperimentRequest{},
+		Response:   models.Experiment{},
+		Status:     fiber.StatusCreated,
+	}).Post("/experiments", perm(rbac.ExperimentsWrite), experimentHandler.CreateExperiment)
+	admin.With(openapi.Op{
+		Summary:    "Update an experiment",
+		Permission: rbac.ExperimentsWrite,
+		Request:    dto.ExperimentRequest{},
+		Response:   models.Experiment{},
+	}).Put("/experiments/:id", perm(rbac.ExperimentsWrite), experimentHandler.UpdateExperiment)
+	admin.With(openapi.Op{
+		Summary:    "Start, pause or stop an experiment",
+		Permission: rbac.ExperimentsWrite,
+		Request:    dto.ExperimentStatusRequest{},
+		Response:   models.Experiment{},
+	}).Put("/experiments/:id/status", perm(rbac.ExperimentsWrite), experimentHandler.SetStatus)
+	admin.With(openapi.Op{Summary: "Experiment results", Permission: rbac.ExperimentsRead, Response: dto.ExperimentReport{}}).Get("/experiments/:id/report", perm(rbac.ExperimentsRead), experimentHandler.Report)
+
+	// Realtime announcements pushed to connected clients
+	admin.With(openapi.Op{
+		Summary:    "Push an announcement to connected clients",
+		Permission: rbac.AnnouncementWrite,
+		Request:    dto.AnnouncementRequest{},
+		Response:   models.RealtimeEvent{},
+		Status:     fiber.StatusCreated,
+	}).Post("/announcements", perm(rbac.AnnouncementWrite), realtimeHandler.Announce)
+
+	// Rebuild streak rows in each user's local day
+	admin.With(openapi.Op{
+		Summary:    "Rebuild streaks in each user's timezone",
+		Permission: rbac.UsersWrite,
+		Response:   message,
+		Status:     fiber.StatusAccepted,
+	}).Post("/streaks/recompute", perm(rbac.UsersWrite), userHandler.RecomputeStreaks)
+
+	// Push notifications
+	admin.With(openapi.Op{
+		Summary:    "Send a push notification to a user",
+		Permission: rbac.PushWrite,
+		Request:    dto.SendPushRequest{},
+		Response:   models.PushNotification{},
+		Status:     fiber.StatusCreated,
+	}).Post("/push/send", perm(rbac.PushWrite), pushHandler.Send)
+	admin.With(openapi.Op{
+		Summary:    "List push notifications",
+		Permission: rbac.PushRead,
+		Query:      append(page, openapi.Query("user_id", "string", "")),
+		Response:   fiber.Map{"notifications": []models.PushNotification{}, "total": int64(0), "limit": 0, "offset": 0},
+	}).Get("/push/notifications", perm(rbac.PushRead), pushHandler.ListNotifications)
+	admin.With(openapi.Op{
+		Summary:    "List a notification's deliveries",
+		Permission: rbac.PushRead,
+		Response:   fiber.Map{"deliveries": []models.PushDelivery{}},
+	}).Get("/push/notifications/:id/deliveries", perm(rbac.PushRead), pushHandler.ListDeliveries)
+
+	// Outbound webhooks to the app team's systems
+	admin.With(openapi.Op{
+		Summary:    "List outbound webhooks",
+		Permission: rbac.WebhooksRead,
+		Response:   fiber.Map{"webhooks": []models.WebhookEndpoint{}, "event_types": []string{}},
+	}).Get("/webhooks", perm(rbac.WebhooksRead), outboundWebhookHandler.List)
+	admin.With(openapi.Op{
+		Summary:     "Create an outbound webhook",
+		Description: "The signing secret is only returned here.",
+		Permission:  rbac.WebhooksWrite,
+		Request:     dto.CreateWebhookEndpointRequest{},
+		Response:    fiber.Map{"webhook": models.WebhookEndpoint{}, "secret": ""},
+		Status:      fiber.StatusCreated,
+	}).Post("/webhooks", perm(rbac.WebhooksWrite), outboundWebhookHandler.Create)
+	admin.With(openapi.Op{
+		Summary:    "Update an outbound webhook",
+		Permission: rbac.WebhooksWrite,
+		Request:    dto.UpdateWebhookEndpointRequest{},
+		Response:   models.WebhookEndpoint{},
+	}).Put("/webhooks/:id", perm(rbac.WebhooksWrite), outboundWebhookHandler.Update)
+	admin.With(openapi.Op{Summary: "Delete an outbound webhook", Permission: rbac.WebhooksWrite, Response: message}).Delete("/webhooks/:id", perm(rbac.WebhooksWrite), outboundWebhookHandler.Delete)
+	admin.With(openapi.Op{
+		Summary:    "List webhook deliveries",
+		Permission: rbac.WebhooksRead,
+		Query:      page,
+		Response:   fiber.Map{"deliveries": []models.WebhookDelivery{}, "total": int64(0), "limit": 0, "offset": 0},
+	}).Get("/webhooks/:id/deliveries", perm(rbac.WebhooksRead), outboundWebhookHandler.ListDeliveries)
+	admin.With(openapi.Op{
+		Summary:    "Send a test event to a webhook",
+		Permission: rbac.WebhooksWrite,
+		Response:   models.WebhookDelivery{},
+	}).Post("/webhooks/:id/test", perm(rbac.WebhooksWrite), outboundWebhookHandler.Test)
 
 	// Webhooks — per-app auth via :app_id path param (no JWT)
-	webhooks := api.Group("/webhooks")
-	webhooks.Post("/revenuecat/:app_id", webhookHandler.HandleRevenueCat)
+	webhooks := api.Sub("/webhooks")
+	webhooks.With(openapi.Op{
+		Summary:  "RevenueCat subscription events",
+		Auth:     openapi.AuthWebhook,
+		Request:  dto.RevenueCatWebhook{},
+		Response: fiber.Map{"received": true},
+	}).Post("/revenuecat/:app_id", webhookHandler.HandleRevenueCat)
 
 	// Plugin routes - create a protected group for plugins only
-	// This ensures JWT middleware doesn't affect public routes
-	protected := api.Group("/p")
-	
-	// DEBUG: direct route on protected group
-	protected.Get("/direct-test", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{"status": "protected-direct-ok"})
-	})
-	
+	// This ensures JWT middleware doesn't affect public routes. Apps whose
+	// plugin serves anonymous callers get an optional token instead; their
+	// handlers resolve the caller themselves (see apps.AnonymousPlugin).
+	anonymousApps := map[string]bool{}
+	for _, p := range plugins {
+		if ap, ok := p.(apps.AnonymousPlugin); ok && ap.AllowsAnonymous() {
+			anonymousApps[p.ID()] = true
+		}
+	}
+	jwtRequired := middleware.JWTProtected(cfg, denylist)
+	jwtOptional := middleware.JWTOptional(cfg, denylist)
+	pluginAuth := func(c *fiber.Ctx) error {
+		if anonymousApps[tenant.GetAppID(c)] {
+			return jwtOptional(c)
+		}
+		return jwtRequired(c)
+	}
+	protected := api.With(openapi.Op{Auth: openapi.AuthUser}).Sub("/p", pluginAuth)
+
+	// App-specific admin routes all need plugin:write on the app
+	pluginAdmin := admin.With(openapi.Op{Permission: rbac.PluginWrite}).Sub("", perm(rbac.PluginWrite))
 	for _, p := range plugins {
-		p.RegisterRoutes(protected, db, cfg)
+		// Plugin routes land in the OpenAPI document of the plugin's app only
+		router := protected.ForApp(p.ID())
+		if anonymousApps[p.ID()] {
+			router = router.With(openapi.Op{Auth: openapi.AuthOptional}).Sub("")
+		}
+		p.RegisterRoutes(router, db, cfg)
 		// If the plugin also implements AdminPlugin, register admin routes
 		if ap, ok := p.(apps.AdminPlugin); ok {
-			ap.RegisterAdminRoutes(admin, db, cfg)
+			ap.RegisterAdminRoutes(pluginAdmin.ForApp(p.ID()), db, cfg)
 		}
 	}
 }
@@ -0,0 +1,331 @@
+// Package server assembles the API: it migrates the database, builds the
+// services, starts their background jobs and mounts every route and plugin
+// on a Fiber app. cmd/server runs it behind a listener; the integration
+// harness boots the same app against a scratch database.
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/achievements"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/daiyly"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/driftoff"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/lucky_draw"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apps/moodpulse"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/database"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/handlers"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/middleware"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/openapi"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/realtime"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/routes"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/services"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/gofiber/fiber/v2"
+	fiberlogger "github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"gorm.io/gorm"
+)
+
+// Plugins returns the active app plugins (archived apps are left out to
+// reduce attack surface).
+func Plugins() []apps.Plugin {
+	return []apps.Plugin{
+		daiyly.New(),
+		driftoff.New(),
+		lucky_draw.New(),
+		moodpulse.New(),
+	}
+}
+
+// Options customises New.
+type Options struct {
+	// Plugins defaults to Plugins().
+	Plugins []apps.Plugin
+	// Middleware runs before the global middleware, e.g. error tracking.
+	Middleware []fiber.Handler
+	// AccessLog writes one line per request to stdout.
+	AccessLog bool
+	// DisableStartupMessage hides Fiber's banner when the app starts
+	// listening.
+	DisableStartupMessage bool
+}
+
+// Server is the assembled API. Close stops its background jobs; shutting
+// down App and closing DB is left to the caller.
+type Server struct {
+	App      *fiber.App
+	DB       *gorm.DB
+	Config   *config.Config
+	Registry *tenant.Registry
+	Events   *events.Bus
+	Push     *services.PushService
+	Webhooks *services.OutboundWebhookService
+
+	stop []func()
+}
+
+// New migrates db and builds the API on it.
+func New(cfg *config.Config, registry *tenant.Registry, db *gorm.DB, opts Options) (*Server, error) {
+	plugins := opts.Plugins
+	if plugins == nil {
+		plugins = Plugins()
+	}
+	s := &Server{DB: db, Config: cfg, Registry: registry}
+
+	// Migrate shared models
+	if err := database.MigrateShared(db); err != nil {
+		return nil, fmt.Errorf("shared migration: %w", err)
+	}
+
+	// Migrate plugin models
+	for _, p := range plugins {
+		if models := p.Models(); len(models) > 0 {
+			if err := database.MigrateModels(db, models); err != nil {
+				return nil, fmt.Errorf("plugin %s migration: %w", p.ID(), err)
+			}
+			slog.Info("plugin migrated", "plugin", p.ID(), "models", len(models))
+		}
+		if mp, ok := p.(apps.MigrationPlugin); ok {
+			if err := mp.Migrate(db); err != nil {
+				return nil, fmt.Errorf("plugin %s data migration: %w", p.ID(), err)
+			}
+		}
+	}
+
+	// Domain event bus (sync subscribers + outbox-backed async delivery)
+	eventBus := events.NewBus(db)
+	s.Events = eventBus
+
+	// Services
+	experimentService := services.NewExperimentService(db)
+	subscriptionService := services.NewSubscriptionService(db, experimentService, eventBus)
+	remoteConfigService := services.NewRemoteConfigService(db, experimentService)
+	banService := services.NewBanService(db)
+	authService := services.NewAuthService(db, cfg, eventBus, banService)
+	adminService := services.NewAdminService(db, cfg)
+	// Pre-RBAC admins (ADMIN_EMAILS, ADMIN_USER_IDS, role "admin") become
+	// admin identities on the first start after the cutover.
+	if n, err := adminService.SeedLegacyAdmins(); err != nil {
+		return nil, fmt.Errorf("seed legacy admins: %w", err)
+	} else if n > 0 {
+		slog.Info("seeded legacy admins", "count", n)
+	}
+	moderationService := services.NewModerationService(db, cfg, eventBus, banService, remoteConfigService)
+	profileService := services.NewProfileService(db, cfg, registry, services.NewMailer(cfg))
+	profileService.Subscribe(eventBus)
+
+	// Realtime hub (SSE fan-out, cross-instance via LISTEN/NOTIFY)
+	realtimeHub := realtime.NewHub(db, cfg.DSN())
+	realtimeHub.OnRemoteEvent(func(event models.RealtimeEvent) {
+		// Another instance changed config; drop our cached snapshot before
+		// streams re-resolve it.
+		if event.Type == realtime.EventConfig {
+			remoteConfigService.Invalidate(event.AppID)
+		}
+	})
+	listenCtx, stopListen := context.WithCancel(context.Background())
+	realtimeHub.Listen(listenCtx)
+	s.stop = append(s.stop, stopListen, realtimeHub.Close)
+	realtimeHub.StartCleanup(s.done())
+
+	// Event subscriptions are registered before routes so plugin services get
+	// the bus, and before the dispatcher starts so queued messages find their
+	// handlers.
+	for _, p := range plugins {
+		if ep, ok := p.(apps.EventPlugin); ok {
+			ep.RegisterEvents(eventBus, db, cfg)
+		}
+	}
+	achievements.OnUnlock(func(u achievements.Unlock) {
+		eventBus.PublishAsync(u.AppID, u.UserID, events.AchievementUnlocked, events.AchievementUnlockedPayload{
+			Key:  u.Definition.Key,
+			Name: u.Definition.Name,
+			XP:   u.Definition.XP,
+		})
+	})
+	eventBus.StartDispatcher(s.done())
+
+	// Outbound webhooks: events fan out to each app's endpoints, which a
+	// separate dispatcher delivers with retries.
+	outboundWebhookService := services.NewOutboundWebhookService(db)
+	outboundWebhookService.Subscribe(eventBus)
+	outboundWebhookService.StartDispatcher(s.done())
+	s.Webhooks = outboundWebhookService
+
+	// Ban denylist: authenticated requests check it in memory; the refresh
+	// picks up bans issued or lifted on other instances within seconds.
+	banService.StartDenylistRefresh(s.done())
+
+	// Deleted accounts are purged once their grace period ends
+	authService.StartDeletionPurge(s.done())
+
+	// Push notifications: plugins that implement ReminderPlugin get hourly
+	// reminder delivery at each user's optimal time.
+	pushService := services.NewPushService(db, cfg, registry)
+	s.Push = pushService
+	reminderSources := make(map[string]services.ReminderSource)
+	for _, p := range plugins {
+		if rp, ok := p.(apps.ReminderPlugin); ok {
+			reminderSources[p.ID()] = rp
+		}
+	}
+	pushService.StartReminderScheduler(reminderSources, s.done())
+
+	// Streak rows are rebuilt when a user changes timezone
+	streakRecomputers := make(map[string]services.StreakRecomputer)
+	for _, p := range plugins {
+		if sp, ok := p.(apps.StreakPlugin); ok {
+			streakRecomputers[p.ID()] = sp
+		}
+	}
+	streakRecomputeService := services.NewStreakRecomputeService(db, streakRecomputers)
+
+	// Achievements: plugins unlock them; the core lists them per app
+	achievementDefs := make(map[string][]achievements.Definition)
+	for _, p := range plugins {
+		if ap, ok := p.(apps.AchievementPlugin); ok {
+			achievementDefs[p.ID()] = ap.Achievements()
+		}
+	}
+	achievementService := services.NewAchievementService(db, achievementDefs)
+
+	// Moderation: plugins with reportable content snapshot and hide it
+	contentResolvers := make(map[string]services.ContentResolver)
+	for _, p := range plugins {
+		if mp, ok := p.(apps.ModerationPlugin); ok {
+			contentResolvers[p.ID()] = mp
+		}
+	}
+	moderationService.SetContentResolvers(contentResolvers)
+
+	// Typed per-user preferences; plugins add their own namespaces
+	preferenceService := services.NewPreferenceService(db)
+	for _, p := range plugins {
+		if pp, ok := p.(apps.PreferencesPlugin); ok {
+			for _, schema := range pp.Preferences() {
+				preferenceService.Register(p.ID(), schema)
+			}
+		}
+	}
+
+	// Handlers
+	authHandler := handlers.NewAuthHandler(authService, registry)
+	healthHandler := handlers.NewHealthHandler(db, registry)
+	webhookHandler := handlers.NewWebhookHandler(subscriptionService, registry)
+	moderationHandler := handlers.NewModerationHandler(moderationService)
+	legalHandler := handlers.NewLegalHandler(registry)
+	configHandler := handlers.NewRemoteConfigHandler(remoteConfigService)
+	experimentHandler := handlers.NewExperimentHandler(experimentService)
+	realtimeHandler := handlers.NewRealtimeHandler(realtimeHub, remoteConfigService, banService)
+	pushHandler := handlers.NewPushHandler(pushService)
+	userHandler := handlers.NewUserHandler(authService, profileService, preferenceService, streakRecomputeService)
+	achievementHandler := handlers.NewAchievementHandler(achievementService)
+	outboundWebhookHandler := handlers.NewOutboundWebhookHandler(outboundWebhookService)
+	banHandler := handlers.NewBanHandler(banService)
+	adminHandler := handlers.NewAdminHandler(adminService)
+
+	// OpenAPI: routes.Setup records every route in apiSpec as it registers it
+	apiSpec := openapi.NewSpec()
+	openAPIHandler := handlers.NewOpenAPIHandler(apiSpec, registry)
+
+	// Seed default remote config values
+	slog.Info("seeding remote config defaults")
+	configHandler.SeedDefaults(registry.ToMap())
+
+	// Push config changes to connected clients. Registered after seeding so
+	// startup doesn't publish an event per default.
+	remoteConfigService.OnChange(func(appID string) {
+		version, err := remoteConfigService.ConfigVersion(appID)
+		if err != nil {
+			slog.Error("config version lookup failed", "app_id", appID, "error", err)
+			return
+		}
+		if _, err := realtimeHub.Publish(appID, realtime.EventConfig, fiber.Map{"config_version": version}); err != nil {
+			slog.Error("config change publish failed", "app_id", appID, "error", err)
+		}
+	})
+
+	// Fiber app
+	app := fiber.New(fiber.Config{
+		BodyLimit:    4 * 1024 * 1024,
+		ErrorHandler: errorHandler,
+		// Only trust X-Forwarded-For from known proxy (Coolify nginx on same host)
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          []string{"127.0.0.1", "::1"},
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		// Explicit timeouts prevent slow-client (Slowloris) attacks.
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  120 * time.Second,
+
+		DisableStartupMessage: opts.DisableStartupMessage,
+	})
+	s.App = app
+
+	for _, h := range opts.Middleware {
+		app.Use(h)
+	}
+
+	// Global middleware
+	app.Use(recover.New())
+	app.Use(requestid.New())
+	if opts.AccessLog {
+		app.Use(fiberlogger.New(fiberlogger.Config{
+			Format: "${time} | ${status} | ${latency} | ${ip} | ${method} | ${path}\n",
+		}))
+	}
+	app.Use(middleware.CORS(cfg))
+	app.Use(func(c *fiber.Ctx) error {
+		c.Set("X-Content-Type-Options", "nosniff")
+		c.Set("X-Frame-Options", "DENY")
+		c.Set("X-XSS-Protection", "1; mode=block")
+		c.Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains; preload")
+		c.Set("Referrer-Policy", "strict-no-referrer")
+		return c.Next()
+	})
+	app.Use(middleware.TenantMiddleware(registry))
+
+	// Routes
+	routes.Setup(app, cfg, db, banService, authHandler, healthHandler, webhookHandler, moderationHandler, legalHandler, configHandler, experimentHandler, realtimeHandler, pushHandler, userHandler, achievementHandler, outboundWebhookHandler, banHandler, adminService, adminHandler, openAPIHandler, apiSpec, plugins)
+
+	return s, nil
+}
+
+// Close stops the background jobs started by New.
+func (s *Server) Close() {
+	for i := len(s.stop) - 1; i >= 0; i-- {
+		s.stop[i]()
+	}
+	s.stop = nil
+}
+
+// done returns a channel that Close closes, for jobs that stop on one.
+func (s *Server) done() chan struct{} {
+	ch := make(chan struct{})
+	s.stop = append(s.stop, func() { close(ch) })
+	return ch
+}
+
+// errorHandler renders every error returned by a handler as an
+// apierr.ErrorResponse. *apierr.Error carries its own status and code; a
+// *fiber.Error keeps its status; anything else is a 500. Catalog messages
+// are localised from Accept-Language.
+func errorHandler(c *fiber.Ctx, err error) error {
+	e := apierr.From(err)
+
+	// Only expose error details for client errors (4xx), not server errors (5xx)
+	if e.Status >= 500 {
+		slog.Error("unhandled server error", "method", c.Method(), "path", c.Path(), "error", err.Error())
+	}
+
+	return c.Status(e.Status).JSON(e.Response(apierr.Language(c.Get(fiber.HeaderAcceptLanguage))))
+}
@@ -0,0 +1,368 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDeletionPending  = errors.New("account deletion is already pending")
+	ErrDeletionNotFound = errors.New("account deletion not found")
+	ErrNotRestorable    = errors.New("only pending deletions can be restored")
+)
+
+const deletionPurgeInterval = time.Hour
+
+// Apple token revocation outcomes recorded on the deletion receipt.
+const (
+	appleRevocationPending = "pending"
+	appleRevocationRevoked = "revoked"
+	appleRevocationFailed  = "failed"
+	appleRevocationSkipped = "skipped"
+)
+
+// DeleteAccount disables the account and schedules its data for purging
+// once the grace period (cfg.AccountDeletionGrace) ends; signing in again
+// before then restores it. With no grace period the data is purged at once.
+// The returned receipt stays behind after the purge.
+func (s *AuthService) DeleteAccount(appID string, userID uuid.UUID, password string, authorizationCode string, bundleID string) (*models.AccountDeletion, error) {
+	var user models.User
+	if err := s.db.Scopes(tenant.ForTenant(appID)).First(&user, "id = ?", userID).Error; err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	// Guests and provider sign-ins have no password to confirm; holding the
+	// token is enough.
+	if user.AuthProvider == "email" {
+		if password == "" {
+			return nil, ErrPasswordRequired
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+			return nil, ErrInvalidCredentials
+		}
+	}
+
+	now := time.Now()
+	receipt := &models.AccountDeletion{
+		AppID:       appID,
+		UserID:      userID,
+		Email:       user.Email,
+		Status:      models.DeletionPending,
+		RequestedAt: now,
+		PurgeAfter:  now.Add(s.cfg.AccountDeletionGrace),
+	}
+	revokeApple := authorizationCode != "" && bundleID != "" && s.hasIdentity(appID, userID, ProviderApple)
+	if revokeApple {
+		receipt.AppleRevocation = appleRevocationPending
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var pending int64
+		if err := tx.Model(&models.AccountDeletion{}).Scopes(tenant.ForTenant(appID)).
+			Where("user_id = ? AND status = ?", userID, models.DeletionPending).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return ErrDeletionPending
+		}
+
+		if s.cfg.AccountDeletionGrace <= 0 {
+			if err := s.purgeUser(tx, &user); err != nil {
+				return err
+			}
+			receipt.Status = models.DeletionPurged
+			receipt.Email = ""
+			receipt.PurgedAt = &now
+			return tx.Create(receipt).Error
+		}
+
+		if err := tx.Create(receipt).Error; err != nil {
+			return fmt.Errorf("create deletion receipt: %w", err)
+		}
+		// Sign the user out everywhere and stop pushes; the rest of their
+		// data waits for the purge.
+		if err := tx.Model(&models.RefreshToken{}).Scopes(tenant.ForTenant(appID)).
+			Where("user_id = ? AND revoked = false", userID).Update("revoked", true).Error; err != nil {
+			return fmt.Errorf("revoke sessions: %w", err)
+		}
+		if err := tx.Where("user_id = ? AND app_id = ?", userID, appID).Delete(&models.DeviceToken{}).Error; err != nil {
+			return fmt.Errorf("delete device tokens: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Status == models.DeletionPending {
+		s.bans.SetDeleting(appID, userID, true)
+	}
+	slog.Info("account deletion requested", "app_id", appID, "user_id", userID, "status", receipt.Status, "purge_after", receipt.PurgeAfter)
+
+	// Apple token revocation (Guideline 5.1.1) is fire-and-forget: it must
+	// not hold up or fail the deletion. Its outcome lands on the receipt.
+	if revokeApple {
+		go s.revokeAppleForDeletion(receipt.ID, bundleID, authorizationCode)
+	}
+	return receipt, nil
+}
+
+func (s *AuthService) revokeAppleForDeletion(receiptID uuid.UUID, bundleID, code string) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("panic in apple token revocation goroutine", "recover", r)
+		}
+	}()
+	outcome := appleRevocationRevoked
+	if err := RevokeAppleTokens(s.cfg, bundleID, code); err != nil {
+		outcome = appleRevocationFailed
+		if errors.Is(err, ErrAppleRevocationSkipped) {
+			outcome = appleRevocationSkipped
+		} else {
+			slog.Error("apple token revocation failed", "receipt_id", receiptID, "error", err)
+		}
+	}
+	if err := s.db.Model(&models.AccountDeletion{}).Where("id = ?", receiptID).
+		Update("apple_revocation", outcome).Error; err != nil {
+		slog.Error("failed to record apple revocation", "receipt_id", receiptID, "error", err)
+	}
+}
+
+// restoreAccount cancels the user's pending deletion, if any. It runs on
+// every sign-in, which is how users get their account back.
+func (s *AuthService) restoreAccount(appID string, userID uuid.UUID) (bool, error) {
+	now := time.Now()
+	res := s.db.Model(&models.AccountDeletion{}).Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ? AND status = ?", userID, models.DeletionPending).
+		Updates(map[string]interface{}{
+			"status":      models.DeletionRestored,
+			"restored_at": now,
+			"restored_by": "user",
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+	s.bans.SetDeleting(appID, userID, false)
+	slog.Info("account deletion cancelled by sign-in", "app_id", appID, "user_id", userID)
+	return true, nil
+}
+
+// purgeUser removes the user and all their data in tx. Plugins delete their
+// own rows through the UserDeleted event; a failing subscriber aborts the
+// purge.
+func (s *AuthService) purgeUser(tx *gorm.DB, user *models.User) error {
+	appID, userID := user.AppID, user.ID
+	if err := tx.Where("user_id = ? AND app_id = ?", userID, appID).Delete(&models.RefreshToken{}).Error; err != nil {
+		return fmt.Errorf("delete refresh tokens: %w", err)
+	}
+	if err := tx.Where("user_id = ? AND app_id = ?", userID, appID).Delete(&models.Subscription{}).Error; err != nil {
+		return fmt.Errorf("delete subscriptions: %w", err)
+	}
+	if err := tx.Where("reporter_id = ? AND app_id = ?", userID, appID).Delete(&models.Report{}).Error; err != nil {
+		return fmt.Errorf("delete reports: %w", err)
+	}
+	if err := tx.Where("(blocker_id = ? OR blocked_id = ?) AND app_id = ?", userID, userID, appID).Delete(&models.Block{}).Error; err != nil {
+		return fmt.Errorf("delete blocks: %w", err)
+	}
+	if err := tx.Where("user_id = ? AND app_id = ?", userID, appID).Delete(&models.DeviceToken{}).Error; err != nil {
+		return fmt.Errorf("delete device tokens: %w", err)
+	}
+	if err := tx.Where("user_id = ? AND app_id = ?", userID, appID).Delete(&models.Streak{}).Error; err != nil {
+		return fmt.Errorf("delete streaks: %w", err)
+	}
+	if err := tx.Where("user_id = ? AND app_id = ?", userID, appID).Delete(&models.UserAchievement{}).Error; err != nil {
+		return fmt.Errorf("delete achievements: %w", err)
+	}
+	if err := tx.Where("user_id = ? AND app_id = ?", userID, appID).Delete(&models.UserIdentity{}).Error; err != nil {
+		return fmt.Errorf("delete identities: %w", err)
+	}
+	for _, m := range []interface{}{&models.UserTOTP{}, &models.MFARecoveryCode{}, &models.MFAChallenge{}, &models.WebAuthnCredential{}} {
+		if err := tx.Where("user_id = ? AND app_id = ?", userID, appID).Delete(m).Error; err != nil {
+			return fmt.Errorf("delete second factors: %w", err)
+		}
+	}
+	for _, m := range []interface{}{&models.UserPreference{}, &models.EmailChangeRequest{}} {
+		if err := tx.Where("user_id = ? AND app_id = ?", userID, appID).Delete(m).Error; err != nil {
+			return fmt.Errorf("delete profile data: %w", err)
+		}
+	}
+	if err := s.events.Publish(tx, appID, userID, events.UserDeleted, events.UserDeletedPayload{}); err != nil {
+		return fmt.Errorf("publish user deleted: %w", err)
+	}
+	// Hard delete, so the email can be registered again.
+	return tx.Unscoped().Delete(user).Error
+}
+
+// StartDeletionPurge purges accounts whose grace period has ended, hourly
+// until done is closed.
+func (s *AuthService) StartDeletionPurge(done chan struct{}) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("panic in account deletion purge", "recover", r)
+			}
+		}()
+		ticker := time.NewTicker(deletionPurgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.purgeDueDeletions(done)
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// purgeDueDeletions purges due accounts one per transaction, so one failing
+// plugin only holds back its own user. SKIP LOCKED lets several instances
+// run the job at once.
+func (s *AuthService) purgeDueDeletions(done chan struct{}) {
+	purged := 0
+	failed := map[uuid.UUID]bool{}
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		var receiptID uuid.UUID
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var d models.AccountDeletion
+			query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("status = ? AND purge_after <= ?", models.DeletionPending, time.Now())
+			if len(failed) > 0 {
+				ids := make([]uuid.UUID, 0, len(failed))
+				for id := range failed {
+					ids = append(ids, id)
+				}
+				query = query.Where("id NOT IN ?", ids)
+			}
+			if err := query.Order("purge_after").Limit(1).Find(&d).Error; err != nil {
+				return err
+			}
+			if d.ID == uuid.Nil {
+				return gorm.ErrRecordNotFound
+			}
+			receiptID = d.ID
+
+			var user models.User
+			err := tx.Scopes(tenant.ForTenant(d.AppID)).First(&user, "id = ?", d.UserID).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err == nil {
+				if err := s.purgeUser(tx, &user); err != nil {
+					return err
+				}
+			}
+			now := time.Now()
+			return tx.Model(&d).Updates(map[string]interface{}{
+				"status":    models.DeletionPurged,
+				"email":     "",
+				"purged_at": now,
+			}).Error
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			break
+		}
+		if err != nil {
+			slog.Error("account purge failed", "receipt_id", receiptID, "error", err)
+			if receiptID == uuid.Nil {
+				break
+			}
+			failed[receiptID] = true
+			continue
+		}
+		purged++
+	}
+	if purged > 0 || len(failed) > 0 {
+		slog.Info("account deletion purge finished", "purged", purged, "failed", len(failed))
+	}
+}
+
+// DeletionReceipt returns a deletion receipt by ID.
+func (s *AuthService) DeletionReceipt(appID string, id uuid.UUID) (*models.AccountDeletion, error) {
+	var d models.AccountDeletion
+	if err := s.db.Scopes(tenant.ForTenant(appID)).First(&d, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeletionNotFound
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ListDeletions returns an app's deletion receipts, soonest purge first. An
+// empty status lists all of them.
+func (s *AuthService) ListDeletions(appID, status string, limit, offset int) ([]models.AccountDeletion, int64, error) {
+	query := s.db.Model(&models.AccountDeletion{}).Scopes(tenant.ForTenant(appID))
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var deletions []models.AccountDeletion
+	if err := query.Order("purge_after ASC").Limit(limit).Offset(offset).Find(&deletions).Error; err != nil {
+		return nil, 0, err
+	}
+	return deletions, total, nil
+}
+
+// RestoreDeletion cancels a pending deletion on an admin's behalf. The user
+// signs in again to get new tokens.
+func (s *AuthService) RestoreDeletion(appID string, id uuid.UUID, actor tenant.AdminActor) (*models.AccountDeletion, error) {
+	restoredBy := actor.Email
+	if restoredBy == "" {
+		restoredBy = actor.ID
+	}
+	if restoredBy == "" {
+		restoredBy = actor.Type
+	}
+
+	var d models.AccountDeletion
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(tenant.ForTenant(appID)).First(&d, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDeletionNotFound
+			}
+			return err
+		}
+		if d.Status != models.DeletionPending {
+			return ErrNotRestorable
+		}
+		now := time.Now()
+		d.Status = models.DeletionRestored
+		d.RestoredAt = &now
+		d.RestoredBy = restoredBy
+		return tx.Model(&d).Updates(map[string]interface{}{
+			"status":      d.Status,
+			"restored_at": now,
+			"restored_by": restoredBy,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.bans.SetDeleting(appID, d.UserID, false)
+	slog.Info("account deletion cancelled", "app_id", appID, "user_id", d.UserID, "actor", restoredBy)
+	return &d, nil
+}
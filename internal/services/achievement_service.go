@@ -0,0 +1,32 @@
+package services
+
+import (
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/achievements"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AchievementService serves achievement progress for every app. Definitions
+// come from plugins; unlocking happens inside the plugins themselves.
+type AchievementService struct {
+	db      *gorm.DB
+	engines map[string]*achievements.Engine
+}
+
+func NewAchievementService(db *gorm.DB, defs map[string][]achievements.Definition) *AchievementService {
+	engines := make(map[string]*achievements.Engine, len(defs))
+	for appID, d := range defs {
+		engines[appID] = achievements.New(db, d)
+	}
+	return &AchievementService{db: db, engines: engines}
+}
+
+// Summary returns the user's XP, level and achievements. Apps without
+// definitions still report XP (always zero) and an empty list.
+func (s *AchievementService) Summary(appID string, userID uuid.UUID) (*achievements.Summary, error) {
+	engine, ok := s.engines[appID]
+	if !ok {
+		engine = achievements.New(s.db, nil)
+	}
+	return engine.Summary(appID, userID)
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/rbac"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// legacyAdminsMarker records in models.DataMigration that pre-RBAC admins
+// were seeded.
+const legacyAdminsMarker = "admins.seed_legacy"
+
+// noPassword is a password hash no password matches. Seeded admins without a
+// usable end-user password get it until the bootstrap token sets one.
+const noPassword = "!"
+
+// SeedLegacyAdmins turns the pre-RBAC admins into admin identities, once:
+//   - users listed in ADMIN_EMAILS or ADMIN_USER_IDS, who were admins of
+//     every app, become owners on every app;
+//   - users with role "admin", who were admins of their own app, become
+//     owners of that app.
+//
+// Each admin keeps the password of their end-user account (the most
+// recently updated one when the email is used on several apps) and enrols
+// TOTP at their first admin login. Emails that already have an admin
+// identity are left alone. It returns the number of admins created.
+func (s *AdminService) SeedLegacyAdmins() (int, error) {
+	type seed struct {
+		password string
+		bindings map[rbac.Binding]bool
+	}
+	var created int
+	var withoutPassword []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Instances starting together take turns; the loser sees the marker.
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", legacyAdminsMarker).Error; err != nil {
+			return err
+		}
+		var done int64
+		if err := tx.Model(&models.DataMigration{}).Where("name = ?", legacyAdminsMarker).Count(&done).Error; err != nil {
+			return err
+		}
+		if done > 0 {
+			return nil
+		}
+
+		var userIDs []uuid.UUID
+		for _, raw := range s.cfg.LegacyAdminUserIDs {
+			if id, err := uuid.Parse(raw); err == nil {
+				userIDs = append(userIDs, id)
+			} else {
+				slog.Warn("skipping invalid ADMIN_USER_IDS entry", "value", raw)
+			}
+		}
+		emails := make([]string, 0, len(s.cfg.LegacyAdminEmails))
+		for _, e := range s.cfg.LegacyAdminEmails {
+			emails = append(emails, strings.ToLower(e))
+		}
+		global := func(u models.User) bool {
+			if slices.Contains(emails, strings.ToLower(u.Email)) {
+				return true
+			}
+			for _, id := range userIDs {
+				if u.ID == id {
+					return true
+				}
+			}
+			return false
+		}
+
+		query := tx.Where("role = ?", "admin")
+		if len(emails) > 0 {
+			query = query.Or("LOWER(email) IN ?", emails)
+		}
+		if len(userIDs) > 0 {
+			query = query.Or("id IN ?", userIDs)
+		}
+		var users []models.User
+		if err := query.Order("updated_at DESC").Find(&users).Error; err != nil {
+			return err
+		}
+
+		seeds := make(map[string]*seed)
+		order := []string{}
+		add := func(email, password string, b rbac.Binding) {
+			sd, ok := seeds[email]
+			if !ok {
+				sd = &seed{bindings: make(map[rbac.Binding]bool)}
+				seeds[email] = sd
+				order = append(order, email)
+			}
+			if sd.password == "" && strings.HasPrefix(password, "$2") {
+				sd.password = password
+			}
+			sd.bindings[b] = true
+		}
+		for _, u := range users {
+			email := strings.ToLower(strings.TrimSpace(u.Email))
+			if global(u) {
+				add(email, u.Password, rbac.Binding{AppID: rbac.AllApps, Role: "owner"})
+			}
+			if u.Role == "admin" {
+				add(email, u.Password, rbac.Binding{AppID: u.AppID, Role: "owner"})
+			}
+		}
+		// Listed emails without an end-user account still get an identity.
+		for _, email := range emails {
+			add(email, "", rbac.Binding{AppID: rbac.AllApps, Role: "owner"})
+		}
+
+		for _, email := range order {
+			sd := seeds[email]
+			var existing int64
+			if err := tx.Model(&models.AdminUser{}).Where("email = ?", email).Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing > 0 {
+				continue
+			}
+			admin := models.AdminUser{Email: email, PasswordHash: sd.password}
+			if admin.PasswordHash == "" {
+				admin.PasswordHash = noPassword
+				withoutPassword = append(withoutPassword, email)
+			}
+			if err := tx.Create(&admin).Error; err != nil {
+				return fmt.Errorf("create admin %s: %w", email, err)
+			}
+			for b := range sd.bindings {
+				binding := models.AdminRoleBinding{AdminUserID: admin.ID, AppID: b.AppID, Role: b.Role}
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&binding).Error; err != nil {
+					return fmt.Errorf("grant %s on %s to %s: %w", b.Role, b.AppID, email, err)
+				}
+			}
+			created++
+		}
+		return tx.Create(&models.DataMigration{Name: legacyAdminsMarker, AppliedAt: time.Now()}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(withoutPassword) > 0 {
+		slog.Warn("seeded admins have no password; set one with the bootstrap token", "emails", withoutPassword)
+	}
+	return created, nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/mfa"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// adminTOTPIssuer names the backend in authenticator apps.
+const adminTOTPIssuer = "Unified Backend Admin"
+
+const (
+	// adminTOTPMaxAttempts caps code guesses per admin. MFA tokens are
+	// stateless and a new login mints another, so the count is kept on
+	// the admin rather than the token.
+	adminTOTPMaxAttempts = 5
+	// adminTOTPLockout is how long the cap holds after the last attempt.
+	adminTOTPLockout = 15 * time.Minute
+)
+
+// ErrTOTPLocked is returned by VerifyTOTP after too many wrong codes.
+var ErrTOTPLocked = errors.New("too many invalid codes; try again later")
+
+// adminFromMFAToken resolves the admin behind a Login MFA token.
+func (s *AdminService) adminFromMFAToken(token string) (*models.AdminUser, error) {
+	parsed, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		return []byte(s.cfg.JWTSecret), nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithExpirationRequired())
+	if err != nil || !parsed.Valid {
+		return nil, ErrInvalidMFAToken
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != adminMFATokenType {
+		return nil, ErrInvalidMFAToken
+	}
+	sub, _ := claims["sub"].(string)
+	id, err := uuid.Parse(sub)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	var admin models.AdminUser
+	if err := s.db.First(&admin, "id = ?", id).Error; err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	if admin.Disabled {
+		return nil, ErrAdminDisabled
+	}
+	return &admin, nil
+}
+
+// EnrollTOTP gives an admin without an authenticator a new secret, replacing
+// any unconfirmed one. VerifyTOTP confirms it.
+func (s *AdminService) EnrollTOTP(mfaToken string) (*dto.TOTPEnrollmentResponse, error) {
+	admin, err := s.adminFromMFAToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if admin.TOTPEnabledAt != nil {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	secret, err := mfa.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("generate TOTP secret: %w", err)
+	}
+	sealed, err := s.sealer.Seal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("seal TOTP secret: %w", err)
+	}
+	res := s.db.Model(&models.AdminUser{}).
+		Where("id = ? AND totp_enabled_at IS NULL", admin.ID).
+		Updates(map[string]interface{}{"totp_secret": sealed, "totp_last_step": 0})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	return &dto.TOTPEnrollmentResponse{Secret: secret, URI: mfa.KeyURI(adminTOTPIssuer, admin.Email, secret)}, nil
+}
+
+// VerifyTOTP checks the admin's code and issues the session. The first
+// valid code after enrolment also confirms the authenticator. After
+// adminTOTPMaxAttempts wrong codes it returns ErrTOTPLocked until
+// adminTOTPLockout has passed since the last one.
+func (s *AdminService) VerifyTOTP(mfaToken, code string) (*dto.AdminLoginResponse, error) {
+	admin, err := s.adminFromMFAToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if admin.TOTPSecret == "" {
+		return nil, ErrTOTPNotEnrolled
+	}
+	secret, err := s.sealer.Open(admin.TOTPSecret)
+	if err != nil {
+		return nil, fmt.Errorf("open TOTP secret: %w", err)
+	}
+	if err := s.countTOTPAttempt(admin); err != nil {
+		return nil, err
+	}
+	step, ok := mfa.Validate(secret, code, time.Now(), admin.TOTPLastStep)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	updates := map[string]interface{}{"totp_last_step": step, "totp_failed_attempts": 0}
+	if admin.TOTPEnabledAt == nil {
+		updates["totp_enabled_at"] = time.Now()
+	}
+	res := s.db.Model(&models.AdminUser{}).
+		Where("id = ? AND totp_last_step < ?", admin.ID, step).
+		Updates(updates)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrInvalidMFACode
+	}
+	if admin.TOTPEnabledAt == nil {
+		slog.Info("admin enabled two-factor authentication", "admin_id", admin.ID)
+	}
+	if err := s.db.First(admin, "id = ?", admin.ID).Error; err != nil {
+		return nil, err
+	}
+	return s.issueSession(admin)
+}
+
+// countTOTPAttempt records a code attempt before it is checked, so parallel
+// guesses cannot slip past the cap. A success resets the count; a count
+// older than adminTOTPLockout starts over.
+func (s *AdminService) countTOTPAttempt(admin *models.AdminUser) error {
+	now := time.Now()
+	stale := now.Add(-adminTOTPLockout)
+	res := s.db.Model(&models.AdminUser{}).
+		Where("id = ? AND (totp_failed_attempts < ? OR totp_failed_at < ?)", admin.ID, adminTOTPMaxAttempts, stale).
+		Updates(map[string]interface{}{
+			"totp_failed_attempts": gorm.Expr("CASE WHEN totp_failed_at < ? THEN 1 ELSE totp_failed_attempts + 1 END", stale),
+			"totp_failed_at":       now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		slog.Warn("admin TOTP locked after repeated invalid codes", "admin_id", admin.ID)
+		return ErrTOTPLocked
+	}
+	return nil
+}
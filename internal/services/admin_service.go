@@ -0,0 +1,476 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/mfa"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/rbac"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAdminNotFound           = errors.New("admin not found")
+	ErrAdminExists             = errors.New("an admin with this email already exists")
+	ErrInvalidAdminCredentials = errors.New("invalid email or password")
+	ErrAdminDisabled           = errors.New("admin account is disabled")
+	ErrInvalidAdminToken       = errors.New("invalid or expired admin session")
+	ErrInvalidAPIKey           = errors.New("invalid, expired or revoked API key")
+	ErrAPIKeyNotFound          = errors.New("API key not found")
+	ErrInvalidRole             = errors.New("unknown role")
+	ErrInvalidPermission       = errors.New("unknown permission")
+	ErrRoleBindingNotFound     = errors.New("role binding not found")
+	ErrInvalidBindingApp       = errors.New(`app_id is required (use "*" for every app)`)
+	ErrInvalidAPIKeyExpiry     = errors.New("expires_at must be in the future")
+)
+
+// adminKeyPrefix starts every admin API key, so leaked keys are easy to
+// recognise in logs and secret scanners.
+const adminKeyPrefix = "adm_"
+
+// adminTokenType marks admin session JWTs; end-user tokens never carry it.
+const adminTokenType = "admin"
+
+// adminMFATokenType marks the short-lived token between an admin's password
+// and TOTP steps. It is not a session.
+const adminMFATokenType = "admin_mfa"
+
+// AdminService manages admin identities, their per-app roles and API keys,
+// and the admin audit log.
+type AdminService struct {
+	db     *gorm.DB
+	cfg    *config.Config
+	sealer *mfa.Sealer
+}
+
+func NewAdminService(db *gorm.DB, cfg *config.Config) *AdminService {
+	return &AdminService{db: db, cfg: cfg, sealer: mfa.NewSealer(cfg.MFASecretKey)}
+}
+
+// Login checks an admin's password and returns an MFA challenge. The
+// session itself is only issued by VerifyTOTP.
+func (s *AdminService) Login(email, password string) (*dto.AdminMFAChallengeResponse, error) {
+	var admin models.AdminUser
+	if err := s.db.Where("email = ?", strings.ToLower(strings.TrimSpace(email))).First(&admin).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+			return nil, ErrInvalidAdminCredentials
+		}
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidAdminCredentials
+	}
+	if admin.Disabled {
+		return nil, ErrAdminDisabled
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(mfaChallengeExpiry)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": admin.ID.String(),
+		"typ": adminMFATokenType,
+		"iat": now.Unix(),
+		"exp": expiresAt.Unix(),
+	}).SignedString([]byte(s.cfg.JWTSecret))
+	if err != nil {
+		return nil, fmt.Errorf("sign admin MFA token: %w", err)
+	}
+	return &dto.AdminMFAChallengeResponse{
+		MFAToken:           token,
+		ExpiresAt:          expiresAt,
+		EnrollmentRequired: admin.TOTPEnabledAt == nil,
+	}, nil
+}
+
+// issueSession signs an admin session token. Callers must have checked both
+// the password and the second factor.
+func (s *AdminService) issueSession(admin *models.AdminUser) (*dto.AdminLoginResponse, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.AdminSessionExpiry)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   admin.ID.String(),
+		"email": admin.Email,
+		"typ":   adminTokenType,
+		"mfa":   true,
+		"iat":   now.Unix(),
+		"exp":   expiresAt.Unix(),
+	}).SignedString([]byte(s.cfg.JWTSecret))
+	if err != nil {
+		return nil, fmt.Errorf("sign admin session: %w", err)
+	}
+	s.db.Model(admin).Update("last_login_at", now)
+
+	return &dto.AdminLoginResponse{Token: token, ExpiresAt: expiresAt, Admin: *admin}, nil
+}
+
+// AuthenticateSession resolves an admin session token. The admin is read on
+// every call, so disabling them or changing roles applies immediately.
+func (s *AdminService) AuthenticateSession(token string) (*rbac.Principal, error) {
+	parsed, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		return []byte(s.cfg.JWTSecret), nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithExpirationRequired())
+	if err != nil || !parsed.Valid {
+		return nil, ErrInvalidAdminToken
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	// Sessions issued before admin 2FA was mandatory lack the mfa claim.
+	if !ok || claims["typ"] != adminTokenType || claims["mfa"] != true {
+		return nil, ErrInvalidAdminToken
+	}
+	sub, _ := claims["sub"].(string)
+	adminID, err := uuid.Parse(sub)
+	if err != nil {
+		return nil, ErrInvalidAdminToken
+	}
+
+	admin, bindings, err := s.loadAdmin(adminID)
+	if err != nil {
+		if errors.Is(err, ErrAdminNotFound) {
+			return nil, ErrInvalidAdminToken
+		}
+		return nil, err
+	}
+	return &rbac.Principal{
+		Actor:    tenant.AdminActor{Type: "admin", ID: admin.ID.String(), Email: admin.Email},
+		AdminID:  &admin.ID,
+		Bindings: bindings,
+	}, nil
+}
+
+// AuthenticateAPIKey resolves an API key to its owner's roles, narrowed by
+// the key's scopes and app.
+func (s *AdminService) AuthenticateAPIKey(raw string) (*rbac.Principal, error) {
+	if !strings.HasPrefix(raw, adminKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	var key models.AdminAPIKey
+	if err := s.db.Where("key_hash = ?", hashToken(raw)).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	admin, bindings, err := s.loadAdmin(key.AdminUserID)
+	if err != nil {
+		if errors.Is(err, ErrAdminNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	var scopes []rbac.Permission
+	if len(key.Scopes) > 0 {
+		if err := json.Unmarshal(key.Scopes, &scopes); err != nil {
+			return nil, fmt.Errorf("decode key scopes: %w", err)
+		}
+	}
+	// Only touch last_used_at once a minute per key to keep writes down.
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > time.Minute {
+		s.db.Model(&key).Update("last_used_at", now)
+	}
+
+	return &rbac.Principal{
+		Actor:    tenant.AdminActor{Type: "api-key", ID: admin.ID.String(), Email: admin.Email},
+		AdminID:  &admin.ID,
+		APIKeyID: &key.ID,
+		Bindings: bindings,
+		Scopes:   scopes,
+		KeyAppID: key.AppID,
+	}, nil
+}
+
+// BootstrapPrincipal is the principal for the ADMIN_TOKEN holder. It can only
+// manage admins and keys, enough to create the first owner.
+func BootstrapPrincipal() *rbac.Principal {
+	return &rbac.Principal{
+		Actor:    tenant.AdminActor{Type: "admin-token"},
+		Bindings: []rbac.Binding{{AppID: rbac.AllApps, Role: "owner"}},
+		Scopes:   []rbac.Permission{rbac.AdminsRead, rbac.AdminsWrite},
+	}
+}
+
+func (s *AdminService) loadAdmin(id uuid.UUID) (*models.AdminUser, []rbac.Binding, error) {
+	var admin models.AdminUser
+	if err := s.db.First(&admin, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrAdminNotFound
+		}
+		return nil, nil, err
+	}
+	if admin.Disabled {
+		return nil, nil, ErrAdminDisabled
+	}
+	var rows []models.AdminRoleBinding
+	if err := s.db.Where("admin_user_id = ?", id).Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	bindings := make([]rbac.Binding, len(rows))
+	for i, r := range rows {
+		bindings[i] = rbac.Binding{AppID: r.AppID, Role: r.Role}
+	}
+	return &admin, bindings, nil
+}
+
+// CreateAdmin adds an admin identity with optional initial roles.
+func (s *AdminService) CreateAdmin(req dto.CreateAdminRequest) (*dto.AdminResponse, error) {
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	for _, b := range req.Roles {
+		if err := validateBinding(b); err != nil {
+			return nil, err
+		}
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("hash password: %w", err)
+	}
+
+	admin := models.AdminUser{Email: email, Name: strings.TrimSpace(req.Name), PasswordHash: string(hash)}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.AdminUser{}).Where("email = ?", email).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrAdminExists
+		}
+		if err := tx.Create(&admin).Error; err != nil {
+			return err
+		}
+		for _, b := range req.Roles {
+			if err := tx.Create(&models.AdminRoleBinding{AdminUserID: admin.ID, AppID: b.AppID, Role: b.Role}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAdmin(admin.ID)
+}
+
+// GetAdmin returns an admin with their role bindings.
+func (s *AdminService) GetAdmin(id uuid.UUID) (*dto.AdminResponse, error) {
+	var admin models.AdminUser
+	if err := s.db.First(&admin, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAdminNotFound
+		}
+		return nil, err
+	}
+	var roles []models.AdminRoleBinding
+	if err := s.db.Where("admin_user_id = ?", id).Order("app_id, role").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return &dto.AdminResponse{AdminUser: admin, Roles: roles}, nil
+}
+
+// ListAdmins returns every admin with their role bindings.
+func (s *AdminService) ListAdmins() ([]dto.AdminResponse, error) {
+	var admins []models.AdminUser
+	if err := s.db.Order("email").Find(&admins).Error; err != nil {
+		return nil, err
+	}
+	var roles []models.AdminRoleBinding
+	if err := s.db.Order("app_id, role").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	byAdmin := make(map[uuid.UUID][]models.AdminRoleBinding)
+	for _, r := range roles {
+		byAdmin[r.AdminUserID] = append(byAdmin[r.AdminUserID], r)
+	}
+	out := make([]dto.AdminResponse, len(admins))
+	for i, a := range admins {
+		out[i] = dto.AdminResponse{AdminUser: a, Roles: byAdmin[a.ID]}
+		if out[i].Roles == nil {
+			out[i].Roles = []models.AdminRoleBinding{}
+		}
+	}
+	return out, nil
+}
+
+// UpdateAdmin changes an admin's name, password or disabled flag.
+func (s *AdminService) UpdateAdmin(id uuid.UUID, req dto.UpdateAdminRequest) (*dto.AdminResponse, error) {
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Disabled != nil {
+		updates["disabled"] = *req.Disabled
+	}
+	if req.ResetTOTP {
+		updates["totp_secret"] = ""
+		updates["totp_enabled_at"] = nil
+		updates["totp_last_step"] = 0
+	}
+	if req.Password != nil {
+		hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("hash password: %w", err)
+		}
+		updates["password_hash"] = string(hash)
+	}
+	if len(updates) > 0 {
+		res := s.db.Model(&models.AdminUser{}).Where("id = ?", id).Updates(updates)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil, ErrAdminNotFound
+		}
+	}
+	return s.GetAdmin(id)
+}
+
+// GrantRole binds a role to an admin on one app or on every app ("*").
+// Granting an existing binding is a no-op.
+func (s *AdminService) GrantRole(adminID uuid.UUID, b rbac.Binding) (*models.AdminRoleBinding, error) {
+	if err := validateBinding(b); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetAdmin(adminID); err != nil {
+		return nil, err
+	}
+	binding := models.AdminRoleBinding{AdminUserID: adminID, AppID: b.AppID, Role: b.Role}
+	if err := s.db.Where(binding).FirstOrCreate(&binding).Error; err != nil {
+		return nil, err
+	}
+	return &binding, nil
+}
+
+// RevokeRole removes one of an admin's role bindings.
+func (s *AdminService) RevokeRole(adminID, bindingID uuid.UUID) error {
+	res := s.db.Where("id = ? AND admin_user_id = ?", bindingID, adminID).Delete(&models.AdminRoleBinding{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRoleBindingNotFound
+	}
+	return nil
+}
+
+func validateBinding(b rbac.Binding) error {
+	if _, ok := rbac.Roles[b.Role]; !ok {
+		return fmt.Errorf("%w %q: must be one of %s", ErrInvalidRole, b.Role, strings.Join(rbac.RoleNames(), ", "))
+	}
+	if strings.TrimSpace(b.AppID) == "" {
+		return ErrInvalidBindingApp
+	}
+	return nil
+}
+
+// CreateAPIKey issues a key for adminID. The raw key is only returned here.
+func (s *AdminService) CreateAPIKey(adminID uuid.UUID, req dto.CreateAdminAPIKeyRequest) (*models.AdminAPIKey, string, error) {
+	name := strings.TrimSpace(req.Name)
+	for _, p := range req.Scopes {
+		if !rbac.ValidPermission(p) {
+			return nil, "", fmt.Errorf("%w %q", ErrInvalidPermission, p)
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, "", ErrInvalidAPIKeyExpiry
+	}
+	if _, err := s.GetAdmin(adminID); err != nil {
+		return nil, "", err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", fmt.Errorf("generate key: %w", err)
+	}
+	raw := adminKeyPrefix + hex.EncodeToString(buf)
+	key := models.AdminAPIKey{
+		AdminUserID: adminID,
+		Name:        name,
+		Prefix:      raw[:len(adminKeyPrefix)+8],
+		KeyHash:     hashToken(raw),
+		AppID:       strings.TrimSpace(req.AppID),
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if len(req.Scopes) > 0 {
+		scopes, _ := json.Marshal(req.Scopes)
+		key.Scopes = datatypes.JSON(scopes)
+	}
+	if err := s.db.Create(&key).Error; err != nil {
+		return nil, "", err
+	}
+	slog.Info("admin API key created", "key_id", key.ID, "admin_id", adminID, "name", name)
+	return &key, raw, nil
+}
+
+// ListAPIKeys returns API keys, newest first, optionally for one admin.
+func (s *AdminService) ListAPIKeys(adminID *uuid.UUID) ([]models.AdminAPIKey, error) {
+	query := s.db.Order("created_at DESC")
+	if adminID != nil {
+		query = query.Where("admin_user_id = ?", *adminID)
+	}
+	var keys []models.AdminAPIKey
+	err := query.Find(&keys).Error
+	return keys, err
+}
+
+// RevokeAPIKey disables a key immediately. Revoking twice is a no-op.
+func (s *AdminService) RevokeAPIKey(id uuid.UUID) error {
+	var key models.AdminAPIKey
+	if err := s.db.First(&key, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+	if err := s.db.Model(&key).Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+	slog.Info("admin API key revoked", "key_id", id)
+	return nil
+}
+
+// RecordAudit appends to the admin audit log.
+func (s *AdminService) RecordAudit(entry *models.AdminAuditLog) error {
+	return s.db.Create(entry).Error
+}
+
+// ListAudit returns audit entries, newest first. appID "*" lists every app;
+// actorID optionally filters to one admin.
+func (s *AdminService) ListAudit(appID, actorID string, limit, offset int) ([]models.AdminAuditLog, int64, error) {
+	query := s.db.Model(&models.AdminAuditLog{})
+	if appID != rbac.AllApps {
+		query = query.Where("app_id = ?", appID)
+	}
+	if actorID != "" {
+		query = query.Where("actor_id = ?", actorID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var entries []models.AdminAuditLog
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
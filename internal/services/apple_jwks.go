@@ -1,254 +0,0 @@
-package services
-
-import (
-	"crypto"
-	"crypto/rsa"
-	"crypto/sha256"
-	"encoding/base64"
-	"encoding/json"
-	"fmt"
-	"io"
-	"math/big"
-	"net/http"
-	"strings"
-	"sync"
-	"time"
-)
-
-// appleTokenMaxAge is the maximum age we accept for Apple Identity Tokens.
-// Apple tokens expire after 10 minutes; rejecting anything older than this
-// limits the replay window for intercepted tokens.
-const appleTokenMaxAge = 10 * time.Minute
-
-type AppleJWKS struct {
-	Keys []AppleJWK `json:"keys"`
-}
-
-type AppleJWK struct {
-	Kty string `json:"kty"`
-	Kid string `json:"kid"`
-	Use string `json:"use"`
-	Alg string `json:"alg"`
-	N   string `json:"n"`
-	E   string `json:"e"`
-}
-
-type AppleJWKSCache struct {
-	keys      map[string]*rsa.PublicKey
-	expiresAt time.Time
-	mu        sync.RWMutex
-}
-
-type AppleJWKSClient struct {
-	cache      *AppleJWKSCache
-	httpClient *http.Client
-	jwksURL    string
-}
-
-type AppleJWTHeader struct {
-	Alg string `json:"alg"`
-	Kid string `json:"kid"`
-	Typ string `json:"typ"`
-}
-
-type AppleJWTClaims struct {
-	Iss           string      `json:"iss"`
-	Sub           string      `json:"sub"`
-	Aud           string      `json:"aud"`
-	Iat           int64       `json:"iat"`
-	Exp           int64       `json:"exp"`
-	Email         string      `json:"email"`
-	EmailVerified interface{} `json:"email_verified"`
-	// Nonce is the SHA-256 hash of the raw nonce passed during Apple Sign-In.
-	// Present only when the client provides a nonce for replay protection.
-	Nonce string `json:"nonce"`
-}
-
-func NewAppleJWKSClient() *AppleJWKSClient {
-	return &AppleJWKSClient{
-		cache: &AppleJWKSCache{
-			keys: make(map[string]*rsa.PublicKey),
-		},
-		httpClient: &http.Client{Timeout: 10 * time.Second},
-		jwksURL:    "https://appleid.apple.com/auth/keys",
-	}
-}
-
-func (c *AppleJWKSClient) fetchKeys() error {
-	c.cache.mu.Lock()
-	defer c.cache.mu.Unlock()
-
-	// Double-check under write lock: another goroutine may have already refreshed
-	// the cache while this goroutine was waiting for the lock (thundering-herd guard).
-	if time.Now().Before(c.cache.expiresAt) {
-		return nil
-	}
-
-	resp, err := c.httpClient.Get(c.jwksURL)
-	if err != nil {
-		return fmt.Errorf("failed to fetch JWKS: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("JWKS endpoint returned status %d", resp.StatusCode)
-	}
-
-	var jwks AppleJWKS
-	// Limit response body to 1 MiB — prevents OOM if Apple's JWKS endpoint is
-	// compromised or returns an unexpectedly large payload.
-	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&jwks); err != nil {
-		return fmt.Errorf("failed to decode JWKS: %w", err)
-	}
-
-	newKeys := make(map[string]*rsa.PublicKey)
-	for _, jwk := range jwks.Keys {
-		pubKey, err := parseRSAPublicKey(jwk.N, jwk.E)
-		if err != nil {
-			continue
-		}
-		newKeys[jwk.Kid] = pubKey
-	}
-	// Only replace keys if we parsed at least one — empty set likely means parse errors
-	if len(newKeys) > 0 {
-		c.cache.keys = newKeys
-	}
-	// 1-hour TTL: tighter window if Apple emergency-rotates a compromised key.
-	// 24h was too long — a revoked key would remain trusted for up to a day.
-	c.cache.expiresAt = time.Now().Add(1 * time.Hour)
-	return nil
-}
-
-func parseRSAPublicKey(nStr, eStr string) (*rsa.PublicKey, error) {
-	nBytes, err := base64.RawURLEncoding.DecodeString(nStr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode modulus: %w", err)
-	}
-
-	eBytes, err := base64.RawURLEncoding.DecodeString(eStr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode exponent: %w", err)
-	}
-
-	var e int
-	for _, b := range eBytes {
-		e = e<<8 | int(b)
-	}
-
-	return &rsa.PublicKey{
-		N: new(big.Int).SetBytes(nBytes),
-		E: e,
-	}, nil
-}
-
-func (c *AppleJWKSClient) GetPublicKey(kid string) (*rsa.PublicKey, error) {
-	c.cache.mu.RLock()
-	key, ok := c.cache.keys[kid]
-	fresh := time.Now().Before(c.cache.expiresAt)
-	c.cache.mu.RUnlock()
-
-	if ok && fresh {
-		return key, nil
-	}
-
-	// Cache miss or stale — refresh. fetchKeys uses double-checked locking
-	// so concurrent calls only result in one actual HTTP request to Apple.
-	if err := c.fetchKeys(); err != nil {
-		return nil, err
-	}
-
-	c.cache.mu.RLock()
-	defer c.cache.mu.RUnlock()
-	if key, ok := c.cache.keys[kid]; ok {
-		return key, nil
-	}
-	return nil, fmt.Errorf("public key with kid %s not found", kid)
-}
-
-// VerifyToken validates an Apple Identity Token.
-// nonce is the raw (pre-hash) nonce generated by the client before calling Apple Sign-In.
-// Pass an empty string if the client did not use nonce protection (backwards-compatible).
-func (c *AppleJWKSClient) VerifyToken(identityToken, bundleID, nonce string) (*AppleJWTClaims, error) {
-	parts := strings.Split(identityToken, ".")
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("invalid token format")
-	}
-
-	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode header: %w", err)
-	}
-
-	var header AppleJWTHeader
-	if err := json.Unmarshal(headerBytes, &header); err != nil {
-		return nil, fmt.Errorf("failed to parse header: %w", err)
-	}
-
-	if header.Alg != "RS256" {
-		return nil, fmt.Errorf("unsupported algorithm: %s", header.Alg)
-	}
-
-	pubKey, err := c.GetPublicKey(header.Kid)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get public key: %w", err)
-	}
-
-	claimsBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode claims: %w", err)
-	}
-
-	var claims AppleJWTClaims
-	if err := json.Unmarshal(claimsBytes, &claims); err != nil {
-		return nil, fmt.Errorf("failed to parse claims: %w", err)
-	}
-
-	if claims.Iss != "https://appleid.apple.com" {
-		return nil, fmt.Errorf("invalid issuer: %s", claims.Iss)
-	}
-	if claims.Aud != bundleID {
-		return nil, fmt.Errorf("invalid audience: %s (expected %s)", claims.Aud, bundleID)
-	}
-
-	now := time.Now()
-	if now.Unix() > claims.Exp {
-		return nil, fmt.Errorf("token expired")
-	}
-
-	// Reject tokens issued too long ago. Apple tokens are valid for ≤10 minutes;
-	// a stale iat with a still-valid exp would indicate a replayed token.
-	// Allow 5 seconds of clock skew.
-	iatTime := time.Unix(claims.Iat, 0)
-	if now.Sub(iatTime) > appleTokenMaxAge+5*time.Second {
-		return nil, fmt.Errorf("token issued too long ago (iat=%d)", claims.Iat)
-	}
-	if iatTime.After(now.Add(5 * time.Second)) {
-		return nil, fmt.Errorf("token iat is in the future (iat=%d)", claims.Iat)
-	}
-
-	// Nonce validation: if the token carries a nonce, the caller must prove they
-	// know the original raw nonce (sha256(nonce) must match claims.Nonce).
-	// This prevents identity token replay attacks within the expiry window.
-	if claims.Nonce != "" {
-		if nonce == "" {
-			return nil, fmt.Errorf("token requires nonce verification but none was provided")
-		}
-		nonceHash := fmt.Sprintf("%x", sha256.Sum256([]byte(nonce)))
-		if nonceHash != claims.Nonce {
-			return nil, fmt.Errorf("nonce mismatch")
-		}
-	}
-
-	signingInput := parts[0] + "." + parts[1]
-	signatureBytes, err := base64.RawURLEncoding.DecodeString(parts[2])
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode signature: %w", err)
-	}
-
-	hashed := sha256.Sum256([]byte(signingInput))
-	if err := rsa.VerifyPKCS1v15(pubKey, crypto.SHA256, hashed[:], signatureBytes); err != nil {
-		return nil, fmt.Errorf("signature verification failed: %w", err)
-	}
-
-	return &claims, nil
-}
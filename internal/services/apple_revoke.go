@@ -4,6 +4,7 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -24,58 +25,63 @@ const (
 	appleRevokeURL = "https://appleid.apple.com/auth/revoke"
 )
 
+// ErrAppleRevocationSkipped means revocation was not attempted because the
+// Apple credentials or the authorization code are missing.
+var ErrAppleRevocationSkipped = errors.New("apple token revocation skipped")
+
 // RevokeAppleTokens exchanges the authorization code for tokens, then revokes them.
 // This is required by Apple Guideline 5.1.1 when deleting user accounts.
-// If credentials are not configured, this is a no-op (logs a warning).
-func RevokeAppleTokens(cfg *config.Config, bundleID, authorizationCode string) {
-	defer func() {
-		if r := recover(); r != nil {
-			slog.Error("apple revocation goroutine panicked", "panic", r)
-		}
-	}()
-
+// If credentials are not configured, it returns ErrAppleRevocationSkipped.
+func RevokeAppleTokens(cfg *config.Config, bundleID, authorizationCode string) error {
 	if cfg.AppleTeamID == "" || cfg.AppleKeyID == "" || cfg.ApplePrivateKey == "" {
 		slog.Warn("apple token revocation skipped: credentials not configured")
-		return
+		return ErrAppleRevocationSkipped
 	}
 	if authorizationCode == "" {
 		slog.Warn("apple token revocation skipped: no authorization code provided")
-		return
+		return ErrAppleRevocationSkipped
 	}
 
 	clientSecret, err := generateAppleClientSecret(cfg, bundleID)
 	if err != nil {
-		slog.Error("apple revocation: failed to generate client secret", "error", err)
-		return
+		return fmt.Errorf("generate client secret: %w", err)
 	}
 
 	// Step 1: Exchange authorization code for refresh token
 	refreshToken, err := exchangeAppleCode(bundleID, clientSecret, authorizationCode)
 	if err != nil {
-		slog.Error("apple revocation: failed to exchange auth code", "error", err)
-		return
+		return fmt.Errorf("exchange auth code: %w", err)
 	}
 
 	// Step 2: Revoke the refresh token
 	if err := revokeAppleToken(bundleID, clientSecret, refreshToken); err != nil {
-		slog.Error("apple revocation: failed to revoke token", "error", err)
-		return
+		return fmt.Errorf("revoke token: %w", err)
 	}
 
 	slog.Info("apple token revoked successfully", "bundle_id", bundleID)
+	return nil
 }
 
-func generateAppleClientSecret(cfg *config.Config, bundleID string) (string, error) {
-	// Parse the .p8 private key (may be passed with literal \n or real newlines)
-	keyPEM := strings.ReplaceAll(cfg.ApplePrivateKey, "\\n", "\n")
+// parseApplePrivateKey parses the .p8 key shared by Sign in with Apple and
+// APNs token auth. It may be passed with literal \n or real newlines.
+func parseApplePrivateKey(raw string) (interface{}, error) {
+	keyPEM := strings.ReplaceAll(raw, "\\n", "\n")
 	block, _ := pem.Decode([]byte(keyPEM))
 	if block == nil {
-		return "", fmt.Errorf("failed to decode PEM block from APPLE_PRIVATE_KEY")
+		return nil, fmt.Errorf("failed to decode PEM block from APPLE_PRIVATE_KEY")
 	}
 
 	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse private key: %w", err)
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return privateKey, nil
+}
+
+func generateAppleClientSecret(cfg *config.Config, bundleID string) (string, error) {
+	privateKey, err := parseApplePrivateKey(cfg.ApplePrivateKey)
+	if err != nil {
+		return "", err
 	}
 
 	now := time.Now()
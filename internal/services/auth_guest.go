@@ -0,0 +1,184 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrGuestNotFound = errors.New("guest account not found or already claimed")
+
+// guestEmailDomain fills the required, unique email column for guests. The
+// .invalid TLD can never receive mail.
+const guestEmailDomain = "@guest.invalid"
+
+// GuestSignIn creates an anonymous account for the device and returns its
+// token pair. Guest tokens carry a "guest" claim that core social routes
+// reject (see middleware.RegisteredOnly). Each call creates a new guest: the
+// device ID is not a credential, so it never signs back in to an old one.
+func (s *AuthService) GuestSignIn(appID string, req *dto.GuestRequest) (*dto.AuthResponse, error) {
+	deviceID := strings.TrimSpace(req.DeviceID)
+	if deviceID == "" || len(deviceID) > 140 {
+		return nil, errors.New("device_id is required and must be 140 characters or fewer")
+	}
+
+	id := uuid.New()
+	user := models.User{
+		ID:           id,
+		AppID:        appID,
+		Email:        "guest-" + id.String() + guestEmailDomain,
+		AuthProvider: "guest",
+		Timezone:     initialTimezone(req.Timezone),
+		IsGuest:      true,
+		DeviceID:     deviceID,
+	}
+	// Variants the device saw before signing in now belong to the guest,
+	// and move on with it when the guest is claimed.
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return fmt.Errorf("failed to create guest: %w", err)
+		}
+		return moveExperimentSubject(tx, appID, DeviceSubject(deviceID), id.String())
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.generateTokenPair(appID, &user)
+}
+
+// claimGuest moves everything the guest owns to userID inside tx, then
+// retires the guest account. Core rows are moved here; plugins move theirs
+// in their events.GuestClaimed subscribers. Where both accounts hold a row
+// under the same unique key, the registered account's row is kept.
+func (s *AuthService) claimGuest(tx *gorm.DB, appID string, guestID, userID uuid.UUID) error {
+	var guest models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("id = ? AND is_guest = true", guestID).
+		First(&guest).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrGuestNotFound
+		}
+		return err
+	}
+	// A banned guest must not launder its data into a fresh account.
+	for _, id := range []uuid.UUID{guestID, userID} {
+		ban, err := findActiveBan(tx, appID, id)
+		if err != nil {
+			return fmt.Errorf("check ban: %w", err)
+		}
+		if ban != nil {
+			return ErrAccountSuspended
+		}
+	}
+
+	moves := []struct {
+		model interface{}
+		keys  []string // nil when the rows have no per-user unique key
+	}{
+		{&models.Subscription{}, nil},
+		{&models.DeviceToken{}, nil},
+		{&models.Streak{}, []string{"kind"}},
+		{&models.UserAchievement{}, []string{"key"}},
+		{&models.PushNotification{}, []string{"dedupe_key"}},
+		{&models.UserPreference{}, []string{"namespace"}},
+	}
+	for _, m := range moves {
+		var err error
+		if m.keys == nil {
+			err = MoveUserRows(tx, m.model, appID, guestID, userID)
+		} else {
+			err = MoveUniqueUserRows(tx, m.model, appID, guestID, userID, m.keys...)
+		}
+		if err != nil {
+			return fmt.Errorf("move %T: %w", m.model, err)
+		}
+	}
+	if err := claimGuestProfile(tx, appID, &guest, userID); err != nil {
+		return fmt.Errorf("claim profile: %w", err)
+	}
+	// Experiment assignments use the user ID as subject.
+	if err := moveExperimentSubject(tx, appID, guestID.String(), userID.String()); err != nil {
+		return err
+	}
+
+	if err := s.events.Publish(tx, appID, userID, events.GuestClaimed, events.GuestClaimedPayload{GuestID: guestID}); err != nil {
+		return fmt.Errorf("publish guest claimed: %w", err)
+	}
+
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("app_id = ? AND user_id = ? AND revoked = false", appID, guestID).
+		Update("revoked", true).Error; err != nil {
+		return fmt.Errorf("revoke guest tokens: %w", err)
+	}
+	if err := tx.Delete(&guest).Error; err != nil {
+		return fmt.Errorf("retire guest: %w", err)
+	}
+
+	slog.Info("guest account claimed", "app_id", appID, "guest_id", guestID, "user_id", userID)
+	return nil
+}
+
+// claimGuestProfile copies the profile fields the guest set onto the
+// registered account where it has none. The avatar stays behind: its file
+// belongs to the guest's media directory.
+func claimGuestProfile(tx *gorm.DB, appID string, guest *models.User, userID uuid.UUID) error {
+	var user models.User
+	if err := tx.Scopes(tenant.ForTenant(appID)).First(&user, "id = ?", userID).Error; err != nil {
+		return err
+	}
+	updates := make(map[string]interface{})
+	if user.DisplayName == "" && guest.DisplayName != "" {
+		updates["display_name"] = guest.DisplayName
+	}
+	if user.Locale == "" && guest.Locale != "" {
+		updates["locale"] = guest.Locale
+	}
+	if user.BirthYear == nil && guest.BirthYear != nil {
+		updates["birth_year"] = *guest.BirthYear
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return tx.Model(&user).Updates(updates).Error
+}
+
+// MoveUserRows reassigns model's rows in appID from one user to another, for
+// tables with no unique key on the user column. It is meant for
+// events.GuestClaimed subscribers.
+func MoveUserRows(tx *gorm.DB, model interface{}, appID string, from, to uuid.UUID) error {
+	return tx.Unscoped().Model(model).
+		Where("app_id = ? AND user_id = ?", appID, from).
+		Update("user_id", to).Error
+}
+
+// MoveUniqueUserRows is MoveUserRows for tables with a unique index on
+// (user_id, keys...). from's rows that would collide with one of to's rows
+// are deleted first; with no keys, to keeps its single row if it has one.
+func MoveUniqueUserRows(tx *gorm.DB, model interface{}, appID string, from, to uuid.UUID, keys ...string) error {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	table := stmt.Schema.Table
+
+	match := ""
+	for _, k := range keys {
+		match += fmt.Sprintf(" AND t.%s = %s.%s", k, table, k)
+	}
+	del := fmt.Sprintf(`DELETE FROM %s WHERE app_id = ? AND user_id = ? AND EXISTS
+		(SELECT 1 FROM %s t WHERE t.app_id = %s.app_id AND t.user_id = ?%s)`, table, table, table, match)
+	if err := tx.Exec(del, appID, from, to).Error; err != nil {
+		return err
+	}
+	return MoveUserRows(tx, model, appID, from, to)
+}
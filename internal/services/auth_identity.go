@@ -0,0 +1,357 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidIDToken        = errors.New("invalid identity token")
+	ErrIdentityLinked        = errors.New("this account is already linked to another user")
+	ErrProviderAlreadyLinked = errors.New("a different account from this provider is already linked")
+	ErrIdentityNotFound      = errors.New("identity not linked")
+	ErrLastSignInMethod      = errors.New("cannot remove the last sign-in method")
+	ErrLinkRequired          = errors.New("an account with this email already exists; sign in to it and link this provider")
+)
+
+// newProviders builds the identity providers: Apple, Google and every
+// OIDC_ISSUERS entry. Configured issuers cannot replace the built-in two.
+func newProviders(cfg *config.Config) map[string]*OIDCProvider {
+	providers := map[string]*OIDCProvider{
+		ProviderApple:  NewAppleProvider(),
+		ProviderGoogle: NewGoogleProvider(),
+	}
+	for name, issuer := range cfg.OIDCIssuers {
+		if _, builtin := providers[name]; builtin {
+			slog.Warn("ignoring OIDC issuer that shadows a built-in provider", "provider", name)
+			continue
+		}
+		providers[name] = NewOIDCProvider(name, issuer)
+	}
+	return providers
+}
+
+// HasProvider reports whether name is a configured identity provider.
+func (s *AuthService) HasProvider(name string) bool {
+	_, ok := s.providers[name]
+	return ok
+}
+
+// identityToken is an ID token presented for sign-in.
+type identityToken struct {
+	idToken string
+	nonce   string
+	// clientEmail is the client-reported email (Apple only sends it to the
+	// app on first sign-in). It is only used to name a new account.
+	clientEmail string
+	timezone    string
+}
+
+// verifyIdentity checks an ID token against the provider and the app's
+// client IDs.
+func (s *AuthService) verifyIdentity(appID, provider string, audiences []string, idToken, nonce string) (*IDTokenClaims, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	if idToken == "" {
+		return nil, fmt.Errorf("%w: id_token is required", ErrInvalidIDToken)
+	}
+	claims, err := p.Verify(idToken, audiences, nonce)
+	if err != nil {
+		slog.Error("identity token verification failed", "provider", provider, "error", err, "app_id", appID)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	return claims, nil
+}
+
+// ProviderSignIn signs in or creates an account with a Google or configured
+// OIDC ID token. audiences are the app's client IDs for provider.
+func (s *AuthService) ProviderSignIn(appID, provider string, audiences []string, req *dto.OIDCSignInRequest, guestID uuid.UUID) (*dto.AuthResponse, error) {
+	return s.identitySignIn(appID, provider, audiences, identityToken{
+		idToken:  req.IDToken,
+		nonce:    req.Nonce,
+		timezone: req.Timezone,
+	}, guestID)
+}
+
+// identitySignIn resolves the user for a provider account: by linked
+// identity first, then by an email verified by both this provider and a
+// linked one, else a new account. An email taken by an account nobody has
+// verified returns ErrLinkRequired. A non-nil guestID is claimed into the
+// result.
+func (s *AuthService) identitySignIn(appID, provider string, audiences []string, tok identityToken, guestID uuid.UUID) (*dto.AuthResponse, error) {
+	claims, err := s.verifyIdentity(appID, provider, audiences, tok.idToken, tok.nonce)
+	if err != nil {
+		return nil, err
+	}
+	verifiedEmail := claims.VerifiedEmail()
+
+	user, err := s.userForIdentity(appID, provider, claims.Sub)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil && verifiedEmail != "" {
+		// The provider vouches for the address, so the identity may join an
+		// existing account with that email, but only if another provider has
+		// verified the account's address too. Password sign-ups never prove
+		// they own their email, so whoever registered first could otherwise
+		// wait for the real owner to sign in here. Those accounts must sign
+		// in and link the provider explicitly. Never match on
+		// tok.clientEmail: an attacker could set it to a victim's address.
+		var existing models.User
+		err := s.db.Scopes(tenant.ForTenant(appID)).Where("email = ?", verifiedEmail).First(&existing).Error
+		if err == nil {
+			var verified int64
+			if err := s.db.Model(&models.UserIdentity{}).Scopes(tenant.ForTenant(appID)).
+				Where("user_id = ? AND email = ?", existing.ID, verifiedEmail).
+				Count(&verified).Error; err != nil {
+				return nil, err
+			}
+			if verified == 0 {
+				return nil, ErrLinkRequired
+			}
+			if err := s.db.Create(&models.UserIdentity{
+				AppID:    appID,
+				UserID:   existing.ID,
+				Provider: provider,
+				Subject:  claims.Sub,
+				Email:    verifiedEmail,
+			}).Error; err != nil {
+				return nil, fmt.Errorf("failed to link identity: %w", err)
+			}
+			user = &existing
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	if user == nil {
+		user = &models.User{
+			ID:           uuid.New(),
+			AppID:        appID,
+			Email:        newIdentityEmail(provider, claims.Sub, verifiedEmail, tok.clientEmail),
+			AuthProvider: provider,
+			Timezone:     initialTimezone(tok.timezone),
+		}
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(user).Error; err != nil {
+				return fmt.Errorf("failed to create %s user: %w", provider, err)
+			}
+			if err := tx.Create(&models.UserIdentity{
+				AppID:    appID,
+				UserID:   user.ID,
+				Provider: provider,
+				Subject:  claims.Sub,
+				Email:    verifiedEmail,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to create identity: %w", err)
+			}
+			if guestID != uuid.Nil {
+				return s.claimGuest(tx, appID, guestID, user.ID)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return s.generateTokenPair(appID, user)
+	}
+
+	// The provider stands in for the password only; an account with 2FA on
+	// still needs its second factor. See VerifyMFA.
+	if err := s.mfaChallenge(appID, user); err != nil {
+		return nil, err
+	}
+
+	if user.AuthProvider != provider {
+		if err := s.db.Model(user).Update("auth_provider", provider).Error; err != nil {
+			return nil, fmt.Errorf("failed to update auth provider: %w", err)
+		}
+	}
+
+	if guestID != uuid.Nil && guestID != user.ID {
+		if err := s.db.Transaction(func(tx *gorm.DB) error {
+			return s.claimGuest(tx, appID, guestID, user.ID)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.generateTokenPair(appID, user)
+}
+
+// userForIdentity returns the user linked to the provider account, or nil.
+func (s *AuthService) userForIdentity(appID, provider, subject string) (*models.User, error) {
+	var identity models.UserIdentity
+	err := s.db.Scopes(tenant.ForTenant(appID)).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	err = s.db.Scopes(tenant.ForTenant(appID)).First(&user, "id = ?", identity.UserID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The user was deleted; free the provider account for a new sign-up.
+		if err := s.db.Delete(&identity).Error; err != nil {
+			return nil, fmt.Errorf("failed to drop stale identity: %w", err)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// newIdentityEmail picks the email for an account created from a provider
+// sign-in. Apple only reveals the address on first sign-in and may hide it,
+// so it falls back to the client-reported one and then a relay-style address.
+func newIdentityEmail(provider, subject, verifiedEmail, clientEmail string) string {
+	if verifiedEmail != "" {
+		return verifiedEmail
+	}
+	if provider == ProviderApple {
+		if email := strings.ToLower(strings.TrimSpace(clientEmail)); email != "" {
+			return email
+		}
+		return subject + "@privaterelay.appleid.com"
+	}
+	return subject + "@" + provider + ".invalid"
+}
+
+// ListIdentities returns the user's sign-in methods.
+func (s *AuthService) ListIdentities(appID string, userID uuid.UUID) (*dto.IdentitiesResponse, error) {
+	var user models.User
+	if err := s.db.Scopes(tenant.ForTenant(appID)).First(&user, "id = ?", userID).Error; err != nil {
+		return nil, ErrUserNotFound
+	}
+	var identities []models.UserIdentity
+	if err := s.db.Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&identities).Error; err != nil {
+		return nil, err
+	}
+
+	resp := &dto.IdentitiesResponse{
+		Password:   user.Password != "",
+		Identities: make([]dto.IdentityResponse, 0, len(identities)),
+	}
+	for _, id := range identities {
+		resp.Identities = append(resp.Identities, dto.IdentityResponse{
+			Provider:  id.Provider,
+			Email:     id.Email,
+			CreatedAt: id.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+// LinkIdentity attaches a provider account to the user. A user holds at most
+// one account per provider; relinking the same account is a no-op.
+func (s *AuthService) LinkIdentity(appID string, userID uuid.UUID, provider string, audiences []string, req *dto.LinkIdentityRequest) error {
+	claims, err := s.verifyIdentity(appID, provider, audiences, req.IDToken, req.Nonce)
+	if err != nil {
+		return err
+	}
+
+	var existing []models.UserIdentity
+	if err := s.db.Scopes(tenant.ForTenant(appID)).
+		Where("provider = ? AND (subject = ? OR user_id = ?)", provider, claims.Sub, userID).
+		Find(&existing).Error; err != nil {
+		return err
+	}
+	for _, id := range existing {
+		switch {
+		case id.Subject == claims.Sub && id.UserID == userID:
+			return nil
+		case id.Subject == claims.Sub:
+			return ErrIdentityLinked
+		default:
+			return ErrProviderAlreadyLinked
+		}
+	}
+
+	if err := s.db.Create(&models.UserIdentity{
+		AppID:    appID,
+		UserID:   userID,
+		Provider: provider,
+		Subject:  claims.Sub,
+		Email:    claims.VerifiedEmail(),
+	}).Error; err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	slog.Info("identity linked", "app_id", appID, "user_id", userID, "provider", provider)
+	return nil
+}
+
+// UnlinkIdentity removes the user's account for provider, as long as a
+// password or another identity is left to sign in with.
+func (s *AuthService) UnlinkIdentity(appID string, userID uuid.UUID, provider string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Scopes(tenant.ForTenant(appID)).First(&user, "id = ?", userID).Error; err != nil {
+			return ErrUserNotFound
+		}
+		var identities []models.UserIdentity
+		if err := tx.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).Find(&identities).Error; err != nil {
+			return err
+		}
+
+		var target *models.UserIdentity
+		var remaining []string
+		for i := range identities {
+			if identities[i].Provider == provider {
+				target = &identities[i]
+			} else {
+				remaining = append(remaining, identities[i].Provider)
+			}
+		}
+		if target == nil {
+			return ErrIdentityNotFound
+		}
+		if user.Password == "" && len(remaining) == 0 {
+			return ErrLastSignInMethod
+		}
+
+		if err := tx.Delete(target).Error; err != nil {
+			return fmt.Errorf("failed to unlink identity: %w", err)
+		}
+		if user.AuthProvider == provider {
+			next := "email"
+			if user.Password == "" {
+				next = remaining[0]
+			}
+			if err := tx.Model(&user).Update("auth_provider", next).Error; err != nil {
+				return fmt.Errorf("failed to update auth provider: %w", err)
+			}
+		}
+		slog.Info("identity unlinked", "app_id", appID, "user_id", userID, "provider", provider)
+		return nil
+	})
+}
+
+// hasIdentity reports whether the user has linked provider.
+func (s *AuthService) hasIdentity(appID string, userID uuid.UUID, provider string) bool {
+	var count int64
+	s.db.Model(&models.UserIdentity{}).Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ? AND provider = ?", userID, provider).
+		Count(&count)
+	return count > 0
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/oidctest"
+)
+
+func TestVerifyIdentity(t *testing.T) {
+	iss, err := oidctest.NewIssuer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iss.Close()
+	s := &AuthService{providers: map[string]*OIDCProvider{"fake": NewOIDCProvider("fake", iss.URL+"/")}}
+	audiences := []string{"app.ios", "app.web"}
+
+	sign := func(aud string, extra map[string]interface{}) string {
+		t.Helper()
+		tok, err := iss.Token("subject-1", aud, extra)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+
+	claims, err := s.verifyIdentity("app", "fake", audiences, sign("app.web", map[string]interface{}{
+		"email":          " Jane@Example.com ",
+		"email_verified": true,
+		"nonce":          "n-1",
+	}), "n-1")
+	if err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+	if claims.Sub != "subject-1" || claims.VerifiedEmail() != "jane@example.com" {
+		t.Errorf("claims = %+v, verified email %q", claims, claims.VerifiedEmail())
+	}
+
+	expired := time.Now().Add(-time.Hour).Unix()
+	tests := []struct {
+		name     string
+		provider string
+		token    string
+		nonce    string
+		want     error
+	}{
+		{"unknown provider", "nope", sign("app.ios", nil), "", ErrUnknownProvider},
+		{"missing token", "fake", "", "", ErrInvalidIDToken},
+		{"garbage", "fake", "a.b.c", "", ErrInvalidIDToken},
+		{"other app's audience", "fake", sign("other.ios", nil), "", ErrInvalidIDToken},
+		{"expired", "fake", sign("app.ios", map[string]interface{}{"exp": expired}), "", ErrInvalidIDToken},
+		{"issued in the future", "fake", sign("app.ios", map[string]interface{}{"iat": time.Now().Add(time.Hour).Unix()}), "", ErrInvalidIDToken},
+		{"wrong issuer", "fake", sign("app.ios", map[string]interface{}{"iss": "https://evil.example"}), "", ErrInvalidIDToken},
+		{"no subject", "fake", sign("app.ios", map[string]interface{}{"sub": ""}), "", ErrInvalidIDToken},
+		{"nonce withheld", "fake", sign("app.ios", map[string]interface{}{"nonce": "n-1"}), "", ErrInvalidIDToken},
+		{"nonce mismatch", "fake", sign("app.ios", map[string]interface{}{"nonce": "n-1"}), "n-2", ErrInvalidIDToken},
+	}
+	for _, tt := range tests {
+		if _, err := s.verifyIdentity("app", tt.provider, audiences, tt.token, tt.nonce); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	tok := sign("app.ios", nil)
+	tampered := tok[:len(tok)-4] + "AAAA"
+	if tampered == tok {
+		tampered = tok[:len(tok)-4] + "BBBB"
+	}
+	if _, err := s.verifyIdentity("app", "fake", audiences, tampered, ""); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("tampered signature: err = %v, want ErrInvalidIDToken", err)
+	}
+}
+
+func TestVerifiedEmail(t *testing.T) {
+	tests := []struct {
+		verified interface{}
+		want     string
+	}{
+		{true, "a@example.com"},
+		{"true", "a@example.com"},
+		{false, ""},
+		{"false", ""},
+		{nil, ""},
+		{1, ""},
+	}
+	for _, tt := range tests {
+		c := IDTokenClaims{Email: "A@Example.com", EmailVerified: tt.verified}
+		if got := c.VerifiedEmail(); got != tt.want {
+			t.Errorf("email_verified=%#v: VerifiedEmail = %q, want %q", tt.verified, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/mfa"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidMFAToken    = errors.New("invalid or expired verification session")
+	ErrInvalidMFACode     = errors.New("invalid verification code")
+	ErrMFACodeRequired    = errors.New("code or recovery_code is required")
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnrolled    = errors.New("two-factor authentication is not set up")
+)
+
+const (
+	// mfaChallengeExpiry is how long the client has to supply the second
+	// factor after a correct password.
+	mfaChallengeExpiry = 5 * time.Minute
+	// mfaMaxAttempts caps code guesses per challenge; the login limiter
+	// caps challenges.
+	mfaMaxAttempts = 5
+)
+
+// MFARequiredError is returned by Login and the Apple and OIDC sign-ins when
+// the first factor was correct but the account has two-factor
+// authentication enabled.
+type MFARequiredError struct {
+	Challenge dto.MFAChallengeResponse
+}
+
+func (e *MFARequiredError) Error() string {
+	return "second factor required"
+}
+
+// mfaChallenge checks whether user must pass a second factor and, if so,
+// returns an *MFARequiredError carrying a new challenge.
+func (s *AuthService) mfaChallenge(appID string, user *models.User) error {
+	totp, err := s.confirmedTOTP(s.db, appID, user.ID)
+	if err != nil {
+		return err
+	}
+	if totp == nil {
+		return nil
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	challenge := models.MFAChallenge{
+		AppID:     appID,
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(mfaChallengeExpiry),
+	}
+	s.db.Where("expires_at < ?", time.Now()).Delete(&models.MFAChallenge{})
+	if err := s.db.Create(&challenge).Error; err != nil {
+		return fmt.Errorf("failed to create MFA challenge: %w", err)
+	}
+
+	methods := []string{"totp", "recovery_code"}
+	var passkeys int64
+	s.db.Model(&models.WebAuthnCredential{}).Scopes(tenant.ForTenant(appID)).Where("user_id = ?", user.ID).Count(&passkeys)
+	if passkeys > 0 {
+		methods = append(methods, "webauthn")
+	}
+	return &MFARequiredError{Challenge: dto.MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresAt:   challenge.ExpiresAt,
+		Methods:     methods,
+	}}
+}
+
+// openMFAChallenge resolves an MFA token and counts an attempt against it.
+func (s *AuthService) openMFAChallenge(appID, token string) (*models.MFAChallenge, error) {
+	if token == "" {
+		return nil, ErrInvalidMFAToken
+	}
+	var challenge models.MFAChallenge
+	if err := s.db.Scopes(tenant.ForTenant(appID)).
+		Where("token_hash = ? AND expires_at > ?", hashToken(token), time.Now()).
+		First(&challenge).Error; err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	res := s.db.Model(&models.MFAChallenge{}).
+		Where("id = ? AND attempts < ?", challenge.ID, mfaMaxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		s.db.Delete(&challenge)
+		return nil, ErrInvalidMFAToken
+	}
+	return &challenge, nil
+}
+
+// VerifyMFA completes a login that returned an MFA challenge.
+func (s *AuthService) VerifyMFA(appID string, req *dto.MFAVerifyRequest) (*dto.AuthResponse, error) {
+	challenge, err := s.openMFAChallenge(appID, req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkSecondFactor(appID, challenge.UserID, req.Code, req.RecoveryCode); err != nil {
+		return nil, err
+	}
+	// Single use: a concurrent verify with the same token loses here.
+	if res := s.db.Delete(challenge); res.Error != nil || res.RowsAffected == 0 {
+		return nil, ErrInvalidMFAToken
+	}
+
+	var user models.User
+	if err := s.db.Scopes(tenant.ForTenant(appID)).First(&user, "id = ?", challenge.UserID).Error; err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	return s.generateTokenPair(appID, &user)
+}
+
+// checkSecondFactor accepts a current TOTP code or an unused recovery code,
+// consuming whichever was used.
+func (s *AuthService) checkSecondFactor(appID string, userID uuid.UUID, code, recoveryCode string) error {
+	switch {
+	case code != "":
+		totp, err := s.confirmedTOTP(s.db, appID, userID)
+		if err != nil {
+			return err
+		}
+		if totp == nil {
+			return ErrTOTPNotEnrolled
+		}
+		secret, err := s.sealer.Open(totp.Secret)
+		if err != nil {
+			return fmt.Errorf("open TOTP secret: %w", err)
+		}
+		step, ok := mfa.Validate(secret, code, time.Now(), totp.LastStep)
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		// The step guard makes each code single-use even under concurrency.
+		res := s.db.Model(&models.UserTOTP{}).
+			Where("id = ? AND last_step < ?", totp.ID, step).
+			Update("last_step", step)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrInvalidMFACode
+		}
+		return nil
+	case recoveryCode != "":
+		res := s.db.Model(&models.MFARecoveryCode{}).
+			Scopes(tenant.ForTenant(appID)).
+			Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, mfa.HashRecoveryCode(recoveryCode)).
+			Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrInvalidMFACode
+		}
+		slog.Info("recovery code used", "app_id", appID, "user_id", userID)
+		return nil
+	}
+	return ErrMFACodeRequired
+}
+
+// confirmedTOTP returns the user's enabled TOTP enrolment, or nil.
+func (s *AuthService) confirmedTOTP(db *gorm.DB, appID string, userID uuid.UUID) (*models.UserTOTP, error) {
+	var totp models.UserTOTP
+	err := db.Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ? AND confirmed_at IS NOT NULL", userID).
+		First(&totp).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &totp, nil
+}
+
+// MFAStatus reports the user's second factors.
+func (s *AuthService) MFAStatus(appID string, userID uuid.UUID) (*dto.MFAStatusResponse, error) {
+	totp, err := s.confirmedTOTP(s.db, appID, userID)
+	if err != nil {
+		return nil, err
+	}
+	var codes, passkeys int64
+	if err := s.db.Model(&models.MFARecoveryCode{}).Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ? AND used_at IS NULL", userID).Count(&codes).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&models.WebAuthnCredential{}).Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ?", userID).Count(&passkeys).Error; err != nil {
+		return nil, err
+	}
+	return &dto.MFAStatusResponse{
+		TOTPEnabled:            totp != nil,
+		RecoveryCodesRemaining: int(codes),
+		Passkeys:               int(passkeys),
+	}, nil
+}
+
+// EnrollTOTP starts TOTP enrolment with a new secret, replacing any pending
+// one. It takes effect once ConfirmTOTP sees a code from the authenticator.
+// issuer names the app in the authenticator.
+func (s *AuthService) EnrollTOTP(appID string, userID uuid.UUID, issuer string) (*dto.TOTPEnrollmentResponse, error) {
+	var user models.User
+	if err := s.db.Scopes(tenant.ForTenant(appID)).First(&user, "id = ?", userID).Error; err != nil {
+		return nil, ErrUserNotFound
+	}
+	secret, err := mfa.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("generate TOTP secret: %w", err)
+	}
+	sealed, err := s.sealer.Seal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("seal TOTP secret: %w", err)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		existing, err := s.confirmedTOTP(tx, appID, userID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return ErrTOTPAlreadyEnabled
+		}
+		if err := tx.Where("app_id = ? AND user_id = ?", appID, userID).Delete(&models.UserTOTP{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.UserTOTP{AppID: appID, UserID: userID, Secret: sealed}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TOTPEnrollmentResponse{Secret: secret, URI: mfa.KeyURI(issuer, user.Email, secret)}, nil
+}
+
+// ConfirmTOTP enables the pending enrolment when code matches, and returns
+// the first set of recovery codes.
+func (s *AuthService) ConfirmTOTP(appID string, userID uuid.UUID, code string) (*dto.RecoveryCodesResponse, error) {
+	var totp models.UserTOTP
+	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).First(&totp).Error; err != nil {
+		return nil, ErrTOTPNotEnrolled
+	}
+	if totp.ConfirmedAt != nil {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	secret, err := s.sealer.Open(totp.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("open TOTP secret: %w", err)
+	}
+	step, ok := mfa.Validate(secret, code, time.Now(), totp.LastStep)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.UserTOTP{}).
+			Where("id = ? AND confirmed_at IS NULL", totp.ID).
+			Updates(map[string]interface{}{"confirmed_at": time.Now(), "last_step": step})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrTOTPAlreadyEnabled
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, appID, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("two-factor authentication enabled", "app_id", appID, "user_id", userID)
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP turns two-factor authentication off after checking a code.
+func (s *AuthService) DisableTOTP(appID string, userID uuid.UUID, req *dto.MFACodeRequest) error {
+	if err := s.checkSecondFactor(appID, userID, req.Code, req.RecoveryCode); err != nil {
+		return err
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("app_id = ? AND user_id = ?", appID, userID).Delete(&models.UserTOTP{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("app_id = ? AND user_id = ?", appID, userID).Delete(&models.MFARecoveryCode{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	slog.Info("two-factor authentication disabled", "app_id", appID, "user_id", userID)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a code.
+func (s *AuthService) RegenerateRecoveryCodes(appID string, userID uuid.UUID, req *dto.MFACodeRequest) (*dto.RecoveryCodesResponse, error) {
+	if err := s.checkSecondFactor(appID, userID, req.Code, req.RecoveryCode); err != nil {
+		return nil, err
+	}
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, appID, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, appID string, userID uuid.UUID) ([]string, error) {
+	codes, err := mfa.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("generate recovery codes: %w", err)
+	}
+	if err := tx.Where("app_id = ? AND user_id = ?", appID, userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	rows := make([]models.MFARecoveryCode, len(codes))
+	for i, c := range codes {
+		rows[i] = models.MFARecoveryCode{AppID: appID, UserID: userID, CodeHash: mfa.HashRecoveryCode(c)}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
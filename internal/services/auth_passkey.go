@@ -0,0 +1,306 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/webauthn"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPasskeyChallenge = errors.New("passkey challenge expired or already used")
+	ErrInvalidPasskey   = errors.New("passkey verification failed")
+	ErrPasskeyNotFound  = errors.New("passkey not found")
+	ErrPasskeyExists    = errors.New("this passkey is already registered")
+)
+
+// passkeyChallengeExpiry matches the timeout sent to the client.
+const passkeyChallengeExpiry = 5 * time.Minute
+
+// newPasskeyChallenge stores a fresh challenge for a ceremony.
+func (s *AuthService) newPasskeyChallenge(appID, kind string, userID, mfaChallengeID *uuid.UUID) (*models.WebAuthnChallenge, error) {
+	value, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, fmt.Errorf("generate challenge: %w", err)
+	}
+	ch := models.WebAuthnChallenge{
+		AppID:          appID,
+		UserID:         userID,
+		Kind:           kind,
+		Challenge:      value,
+		MFAChallengeID: mfaChallengeID,
+		ExpiresAt:      time.Now().Add(passkeyChallengeExpiry),
+	}
+	s.db.Where("expires_at < ?", time.Now()).Delete(&models.WebAuthnChallenge{})
+	if err := s.db.Create(&ch).Error; err != nil {
+		return nil, fmt.Errorf("store challenge: %w", err)
+	}
+	return &ch, nil
+}
+
+// consumePasskeyChallenge loads and deletes a challenge, so each one is
+// used at most once.
+func (s *AuthService) consumePasskeyChallenge(appID, kind, id string) (*models.WebAuthnChallenge, error) {
+	chID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrPasskeyChallenge
+	}
+	var ch models.WebAuthnChallenge
+	if err := s.db.Scopes(tenant.ForTenant(appID)).
+		Where("id = ? AND kind = ? AND expires_at > ?", chID, kind, time.Now()).
+		First(&ch).Error; err != nil {
+		return nil, ErrPasskeyChallenge
+	}
+	if res := s.db.Delete(&ch); res.Error != nil || res.RowsAffected == 0 {
+		return nil, ErrPasskeyChallenge
+	}
+	return &ch, nil
+}
+
+type credentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// BeginPasskeyRegistration returns creation options for a new passkey on the
+// signed-in user's account.
+func (s *AuthService) BeginPasskeyRegistration(appID string, userID uuid.UUID, rp *webauthn.RelyingParty) (*dto.PasskeyOptionsResponse, error) {
+	var user models.User
+	if err := s.db.Scopes(tenant.ForTenant(appID)).First(&user, "id = ?", userID).Error; err != nil {
+		return nil, ErrUserNotFound
+	}
+	var existing []models.WebAuthnCredential
+	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	ch, err := s.newPasskeyChallenge(appID, "register", &userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]map[string]interface{}, 0, len(webauthn.SupportedAlgorithms))
+	for _, alg := range webauthn.SupportedAlgorithms {
+		params = append(params, map[string]interface{}{"type": "public-key", "alg": alg})
+	}
+	exclude := make([]credentialDescriptor, 0, len(existing))
+	for _, c := range existing {
+		exclude = append(exclude, credentialDescriptor{Type: "public-key", ID: c.CredentialID})
+	}
+	return &dto.PasskeyOptionsResponse{
+		ChallengeID: ch.ID.String(),
+		PublicKey: map[string]interface{}{
+			"challenge": ch.Challenge,
+			"rp":        map[string]string{"id": rp.ID, "name": rp.Name},
+			"user": map[string]string{
+				"id":          webauthn.Encode(userID[:]),
+				"name":        user.Email,
+				"displayName": user.Email,
+			},
+			"pubKeyCredParams":   params,
+			"timeout":            passkeyChallengeExpiry.Milliseconds(),
+			"attestation":        "none",
+			"excludeCredentials": exclude,
+			"authenticatorSelection": map[string]string{
+				"residentKey":      "preferred",
+				"userVerification": "preferred",
+			},
+		},
+	}, nil
+}
+
+// FinishPasskeyRegistration verifies the authenticator's response and stores
+// the passkey.
+func (s *AuthService) FinishPasskeyRegistration(appID string, userID uuid.UUID, rp *webauthn.RelyingParty, req *dto.PasskeyRegisterFinishRequest) (*models.WebAuthnCredential, error) {
+	ch, err := s.consumePasskeyChallenge(appID, "register", req.ChallengeID)
+	if err != nil {
+		return nil, err
+	}
+	if ch.UserID == nil || *ch.UserID != userID {
+		return nil, ErrPasskeyChallenge
+	}
+
+	clientData, err := webauthn.Decode(req.Credential.Response.ClientDataJSON)
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	attestation, err := webauthn.Decode(req.Credential.Response.AttestationObject)
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	cred, err := rp.VerifyRegistration(clientData, attestation, ch.Challenge, false)
+	if err != nil {
+		slog.Warn("passkey registration rejected", "app_id", appID, "user_id", userID, "error", err)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+
+	credentialID := webauthn.Encode(cred.ID)
+	var count int64
+	s.db.Model(&models.WebAuthnCredential{}).Scopes(tenant.ForTenant(appID)).
+		Where("credential_id = ?", credentialID).Count(&count)
+	if count > 0 {
+		return nil, ErrPasskeyExists
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	if name == "" {
+		name = "Passkey"
+	}
+	record := models.WebAuthnCredential{
+		AppID:        appID,
+		UserID:       userID,
+		CredentialID: credentialID,
+		PublicKey:    cred.PublicKey,
+		Algorithm:    cred.Algorithm,
+		SignCount:    cred.SignCount,
+		Name:         name,
+	}
+	if err := s.db.Create(&record).Error; err != nil {
+		return nil, fmt.Errorf("store passkey: %w", err)
+	}
+	slog.Info("passkey registered", "app_id", appID, "user_id", userID)
+	return &record, nil
+}
+
+// ListPasskeys returns the user's passkeys.
+func (s *AuthService) ListPasskeys(appID string, userID uuid.UUID) ([]models.WebAuthnCredential, error) {
+	var creds []models.WebAuthnCredential
+	err := s.db.Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&creds).Error
+	return creds, err
+}
+
+// DeletePasskey removes one of the user's passkeys.
+func (s *AuthService) DeletePasskey(appID string, userID, id uuid.UUID) error {
+	res := s.db.Scopes(tenant.ForTenant(appID)).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&models.WebAuthnCredential{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrPasskeyNotFound
+	}
+	return nil
+}
+
+// BeginPasskeyLogin returns request options for a passkey sign-in. With an
+// MFA token it is the second step of a password login: only that user's
+// passkeys are allowed and user verification is not required, as the
+// password was the first factor. Without one the passkey alone signs in, so
+// the authenticator must verify the user.
+func (s *AuthService) BeginPasskeyLogin(appID string, rp *webauthn.RelyingParty, req *dto.PasskeyLoginBeginRequest) (*dto.PasskeyOptionsResponse, error) {
+	options := map[string]interface{}{
+		"rpId":             rp.ID,
+		"timeout":          passkeyChallengeExpiry.Milliseconds(),
+		"userVerification": "required",
+	}
+
+	var userID, mfaChallengeID *uuid.UUID
+	if req.MFAToken != "" {
+		var challenge models.MFAChallenge
+		if err := s.db.Scopes(tenant.ForTenant(appID)).
+			Where("token_hash = ? AND expires_at > ?", hashToken(req.MFAToken), time.Now()).
+			First(&challenge).Error; err != nil {
+			return nil, ErrInvalidMFAToken
+		}
+		userID, mfaChallengeID = &challenge.UserID, &challenge.ID
+
+		creds, err := s.ListPasskeys(appID, challenge.UserID)
+		if err != nil {
+			return nil, err
+		}
+		allow := make([]credentialDescriptor, 0, len(creds))
+		for _, c := range creds {
+			allow = append(allow, credentialDescriptor{Type: "public-key", ID: c.CredentialID})
+		}
+		options["allowCredentials"] = allow
+		options["userVerification"] = "preferred"
+	}
+
+	ch, err := s.newPasskeyChallenge(appID, "login", userID, mfaChallengeID)
+	if err != nil {
+		return nil, err
+	}
+	options["challenge"] = ch.Challenge
+	return &dto.PasskeyOptionsResponse{ChallengeID: ch.ID.String(), PublicKey: options}, nil
+}
+
+// FinishPasskeyLogin verifies a passkey assertion and signs the user in.
+func (s *AuthService) FinishPasskeyLogin(appID string, rp *webauthn.RelyingParty, req *dto.PasskeyLoginFinishRequest) (*dto.AuthResponse, error) {
+	ch, err := s.consumePasskeyChallenge(appID, "login", req.ChallengeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var cred models.WebAuthnCredential
+	if err := s.db.Scopes(tenant.ForTenant(appID)).
+		Where("credential_id = ?", strings.TrimRight(req.Credential.ID, "=")).
+		First(&cred).Error; err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	if ch.UserID != nil && *ch.UserID != cred.UserID {
+		return nil, ErrInvalidPasskey
+	}
+	if req.Credential.Response.UserHandle != "" {
+		handle, err := webauthn.Decode(req.Credential.Response.UserHandle)
+		if err != nil || string(handle) != string(cred.UserID[:]) {
+			return nil, ErrInvalidPasskey
+		}
+	}
+
+	clientData, err := webauthn.Decode(req.Credential.Response.ClientDataJSON)
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	authData, err := webauthn.Decode(req.Credential.Response.AuthenticatorData)
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	signature, err := webauthn.Decode(req.Credential.Response.Signature)
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	secondFactor := ch.MFAChallengeID != nil
+	count, err := rp.VerifyAssertion(cred.PublicKey, cred.SignCount, clientData, authData, signature, ch.Challenge, !secondFactor)
+	if err != nil {
+		slog.Warn("passkey assertion rejected", "app_id", appID, "user_id", cred.UserID, "error", err)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+
+	if secondFactor {
+		// The password step's challenge is spent with this assertion.
+		res := s.db.Scopes(tenant.ForTenant(appID)).
+			Where("id = ? AND expires_at > ?", *ch.MFAChallengeID, time.Now()).
+			Delete(&models.MFAChallenge{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return nil, ErrInvalidMFAToken
+		}
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&cred).Updates(map[string]interface{}{"sign_count": count, "last_used_at": now}).Error; err != nil {
+		slog.Error("failed to update passkey counter", "credential", cred.ID, "error", err)
+	}
+
+	var user models.User
+	if err := s.db.Scopes(tenant.ForTenant(appID)).First(&user, "id = ?", cred.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidPasskey
+		}
+		return nil, err
+	}
+	return s.generateTokenPair(appID, &user)
+}
@@ -12,6 +12,9 @@ import (
 
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/localtime"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/mfa"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/golang-jwt/jwt/v5"
@@ -25,6 +28,8 @@ var (
 	ErrInvalidCredentials = errors.New("invalid email or password")
 	ErrInvalidToken       = errors.New("invalid or expired refresh token")
 	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidTimezone    = errors.New("timezone must be an IANA zone name such as Europe/Istanbul")
+	ErrAccountSuspended   = errors.New("account is suspended")
 )
 
 // dummyHash is a precomputed bcrypt hash used to equalize login timing
@@ -43,62 +48,30 @@ func init() {
 type AuthService struct {
 	db        *gorm.DB
 	cfg       *config.Config
-	appleJWKS *AppleJWKSClient
+	providers map[string]*OIDCProvider
+	sealer    *mfa.Sealer
+	events    *events.Bus
+	bans      *BanService
 }
 
-func NewAuthService(db *gorm.DB, cfg *config.Config) *AuthService {
+func NewAuthService(db *gorm.DB, cfg *config.Config, bus *events.Bus, bans *BanService) *AuthService {
 	return &AuthService{
 		db:        db,
 		cfg:       cfg,
-		appleJWKS: NewAppleJWKSClient(),
+		providers: newProviders(cfg),
+		sealer:    mfa.NewSealer(cfg.MFASecretKey),
+		events:    bus,
+		bans:      bans,
 	}
 }
 
-func validatePassword(password string) error {
-	if len(password) < 8 {
-		return errors.New("password must be at least 8 characters, contain at least one uppercase letter and one digit")
-	}
-	// bcrypt silently truncates passwords at 72 bytes (Okta-class vulnerability).
-	// Reject passwords that would be silently truncated so two different passwords
-	// cannot hash to the same bcrypt digest.
-	if len(password) > 72 {
-		return errors.New("password must not exceed 72 characters")
-	}
-	hasUpper := false
-	hasDigit := false
-	for _, ch := range password {
-		if ch >= 'A' && ch <= 'Z' {
-			hasUpper = true
-		}
-		if ch >= '0' && ch <= '9' {
-			hasDigit = true
-		}
-	}
-	if !hasUpper || !hasDigit {
-		return errors.New("password must be at least 8 characters, contain at least one uppercase letter and one digit")
-	}
-	return nil
-}
-
-func (s *AuthService) Register(appID string, req *dto.RegisterRequest) (*dto.AuthResponse, error) {
-	if len(req.Email) == 0 {
-		return nil, errors.New("email is required")
-	}
-	if len(req.Email) > 254 {
-		return nil, errors.New("email too long")
-	}
-	if err := validatePassword(req.Password); err != nil {
-		return nil, err
-	}
-
-	// Basic email format validation — normalize to lowercase to prevent duplicate accounts
-	// from the same address with mixed case (e.g. User@domain.com vs user@domain.com).
-	email := strings.ToLower(strings.TrimSpace(req.Email))
-	atIdx := strings.Index(email, "@")
-	if atIdx < 1 || atIdx >= len(email)-1 || !strings.Contains(email[atIdx+1:], ".") {
-		return nil, errors.New("invalid email format")
-	}
-	req.Email = email
+// Register creates an email account. A non-nil guestID is the caller's own
+// guest account, whose data is claimed in the same transaction.
+func (s *AuthService) Register(appID string, req *dto.RegisterRequest, guestID uuid.UUID) (*dto.AuthResponse, error) {
+	// The handler validated the format and password strength. Normalize to
+	// lowercase to prevent duplicate accounts from the same address with mixed
+	// case (e.g. User@domain.com vs user@domain.com).
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
 
 	var existing models.User
 	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("email = ?", req.Email).First(&existing).Error; err == nil {
@@ -116,10 +89,20 @@ func (s *AuthService) Register(appID string, req *dto.RegisterRequest) (*dto.Aut
 		Email:        req.Email,
 		Password:     string(hash),
 		AuthProvider: "email",
+		Timezone:     initialTimezone(req.Timezone),
 	}
 
-	if err := s.db.Create(&user).Error; err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		if guestID != uuid.Nil {
+			return s.claimGuest(tx, appID, guestID, user.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return s.generateTokenPair(appID, &user)
@@ -141,6 +124,11 @@ func (s *AuthService) Login(appID string, req *dto.LoginRequest) (*dto.AuthRespo
 		return nil, ErrInvalidCredentials
 	}
 
+	// With 2FA on, the password only earns an MFA challenge; see VerifyMFA.
+	if err := s.mfaChallenge(appID, &user); err != nil {
+		return nil, err
+	}
+
 	return s.generateTokenPair(appID, &user)
 }
 
@@ -206,135 +194,45 @@ func (s *AuthService) Logout(appID string, req *dto.LogoutRequest) error {
 		Update("revoked", true).Error
 }
 
-func (s *AuthService) DeleteAccount(appID string, userID uuid.UUID, password string, authorizationCode string, bundleID string) error {
-	var user models.User
-	if err := s.db.Scopes(tenant.ForTenant(appID)).First(&user, "id = ?", userID).Error; err != nil {
-		return ErrUserNotFound
-	}
-
-	if user.AuthProvider != "apple" {
-		if password == "" {
-			return errors.New("password is required")
-		}
-		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-			return ErrInvalidCredentials
-		}
-	}
+// AppleSignIn signs in or creates an Apple account. audiences are the app's
+// bundle ID and extra Apple client IDs. A non-nil guestID is the caller's own
+// guest account, whose data is claimed into the Apple account.
+func (s *AuthService) AppleSignIn(appID string, audiences []string, req *dto.AppleSignInRequest, guestID uuid.UUID) (*dto.AuthResponse, error) {
+	return s.identitySignIn(appID, ProviderApple, audiences, identityToken{
+		idToken:     req.IdentityToken,
+		nonce:       req.Nonce,
+		clientEmail: req.Email,
+		timezone:    req.Timezone,
+	}, guestID)
+}
 
-	// Apple token revocation (Guideline 5.1.1) — fire-and-forget, don't block deletion.
-	// Wrapped with a 30s timeout to prevent goroutine leaks if Apple APIs are slow.
-	if user.AuthProvider == "apple" && authorizationCode != "" && bundleID != "" {
-		go func(cfg *config.Config, bid, code string) {
-			defer func() {
-				if r := recover(); r != nil {
-					slog.Error("panic in apple token revocation goroutine", "recover", r)
-				}
-			}()
-			done := make(chan struct{}, 1)
-			go func() {
-				RevokeAppleTokens(cfg, bid, code)
-				close(done)
-			}()
-			select {
-			case <-done:
-			case <-time.After(30 * time.Second):
-				slog.Warn("apple token revocation timed out")
-			}
-		}(s.cfg, bundleID, authorizationCode)
+// AdoptDevice moves the experiment assignments made to deviceID before
+// sign-in to userID, so the user's conversions (a purchase reported by
+// RevenueCat under the user ID, say) count for the variants the device was
+// shown.
+func (s *AuthService) AdoptDevice(appID, deviceID string, userID uuid.UUID) error {
+	if deviceID == "" {
+		return nil
 	}
-
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("user_id = ? AND app_id = ?", userID, appID).Delete(&models.RefreshToken{}).Error; err != nil {
-			return fmt.Errorf("delete refresh tokens: %w", err)
-		}
-		if err := tx.Where("user_id = ? AND app_id = ?", userID, appID).Delete(&models.Subscription{}).Error; err != nil {
-			return fmt.Errorf("delete subscriptions: %w", err)
-		}
-		if err := tx.Where("reporter_id = ? AND app_id = ?", userID, appID).Delete(&models.Report{}).Error; err != nil {
-			return fmt.Errorf("delete reports: %w", err)
-		}
-		if err := tx.Where("(blocker_id = ? OR blocked_id = ?) AND app_id = ?", userID, userID, appID).Delete(&models.Block{}).Error; err != nil {
-			return fmt.Errorf("delete blocks: %w", err)
-		}
-		return tx.Delete(&user).Error
+		return moveExperimentSubject(tx, appID, DeviceSubject(deviceID), userID.String())
 	})
 }
 
-func (s *AuthService) AppleSignIn(appID string, bundleID string, req *dto.AppleSignInRequest) (*dto.AuthResponse, error) {
-	if req.IdentityToken == "" {
-		return nil, errors.New("identity token is required")
-	}
-
-	claims, err := s.appleJWKS.VerifyToken(req.IdentityToken, bundleID, req.Nonce)
+func (s *AuthService) generateTokenPair(appID string, user *models.User) (*dto.AuthResponse, error) {
+	// Every sign-in path (password, Apple, refresh) ends here.
+	ban, err := findActiveBan(s.db, appID, user.ID)
 	if err != nil {
-		slog.Error("apple token verification failed", "error", err, "app_id", appID)
-		return nil, fmt.Errorf("failed to verify Apple identity token: %w", err)
+		return nil, fmt.Errorf("check ban: %w", err)
 	}
-
-	appleUserID := claims.Sub
-
-	// tokenEmail is the email from the cryptographically-verified Apple JWT.
-	// It is only present on the FIRST sign-in; subsequent sign-ins return empty.
-	tokenEmail := strings.ToLower(strings.TrimSpace(claims.Email))
-
-	// accountEmail is used solely for new-account creation, never for DB lookups.
-	// req.Email is client-supplied and MUST NOT be used to find existing accounts —
-	// doing so allows an attacker to merge their Apple ID into a victim's account
-	// by passing the victim's email when their own token email is empty.
-	accountEmail := tokenEmail
-	if accountEmail == "" {
-		accountEmail = strings.ToLower(strings.TrimSpace(req.Email))
+	if ban != nil {
+		return nil, ErrAccountSuspended
 	}
-	if accountEmail == "" {
-		accountEmail = appleUserID + "@privaterelay.appleid.com"
-	}
-
-	var user models.User
-	var lookupErr error
-	if tokenEmail != "" {
-		// Email is token-verified — safe to match by email (handles the case where
-		// a user first registered via email/password, then signs in with Apple using
-		// the same address on their first Apple sign-in).
-		lookupErr = s.db.Scopes(tenant.ForTenant(appID)).
-			Where("apple_user_id = ? OR email = ?", appleUserID, tokenEmail).First(&user).Error
-	} else {
-		// No email in the token — match ONLY by apple_user_id.
-		// Never use req.Email here: an attacker could set it to any address to
-		// hijack an existing account.
-		lookupErr = s.db.Scopes(tenant.ForTenant(appID)).
-			Where("apple_user_id = ?", appleUserID).First(&user).Error
-	}
-
-	if lookupErr != nil {
-		user = models.User{
-			ID:           uuid.New(),
-			AppID:        appID,
-			Email:        accountEmail,
-			Password:     "",
-			AppleUserID:  &appleUserID,
-			AuthProvider: "apple",
-		}
-		if err := s.db.Create(&user).Error; err != nil {
-			return nil, fmt.Errorf("failed to create Apple user: %w", err)
-		}
-	} else {
-		if user.AppleUserID == nil {
-			if err := s.db.Model(&user).Updates(map[string]interface{}{
-				"apple_user_id": appleUserID,
-				"auth_provider": "apple",
-			}).Error; err != nil {
-				slog.Error("failed to update user with Apple ID", "error", err, "user_id", user.ID, "app_id", appID)
-				return nil, fmt.Errorf("failed to update user with Apple ID: %w", err)
-			}
-			user.AppleUserID = &appleUserID
-			user.AuthProvider = "apple"
-		}
+	restored, err := s.restoreAccount(appID, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("restore account: %w", err)
 	}
 
-	return s.generateTokenPair(appID, &user)
-}
-
-func (s *AuthService) generateTokenPair(appID string, user *models.User) (*dto.AuthResponse, error) {
 	accessToken, err := s.generateAccessToken(appID, user)
 	if err != nil {
 		return nil, err
@@ -352,7 +250,12 @@ func (s *AuthService) generateTokenPair(appID string, user *models.User) (*dto.A
 			ID:          user.ID,
 			Email:       user.Email,
 			IsAppleUser: user.AuthProvider == "apple",
+			IsGuest:     user.IsGuest,
+			Timezone:    user.Timezone,
+			DisplayName: user.DisplayName,
+			Locale:      user.Locale,
 		},
+		Restored: restored,
 	}, nil
 }
 
@@ -365,6 +268,9 @@ func (s *AuthService) generateAccessToken(appID string, user *models.User) (stri
 		"iat":           time.Now().Unix(),
 		"exp":           time.Now().Add(s.cfg.JWTAccessExpiry).Unix(),
 	}
+	if user.IsGuest {
+		claims["guest"] = true
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.cfg.JWTSecret))
@@ -398,3 +304,36 @@ func hashToken(token string) string {
 	h := sha256.Sum256([]byte(token))
 	return fmt.Sprintf("%x", h)
 }
+
+// initialTimezone returns the timezone reported at sign-up if valid, else UTC.
+// Sign-up never fails over a bad zone; the client can correct it later.
+func initialTimezone(tz string) string {
+	if tz = strings.TrimSpace(tz); localtime.Valid(tz) {
+		return tz
+	}
+	return "UTC"
+}
+
+// UpdateTimezone sets the user's IANA timezone. Returns whether it changed, so
+// callers only recompute day-bucketed data when needed.
+func (s *AuthService) UpdateTimezone(appID string, userID uuid.UUID, timezone string) (bool, error) {
+	timezone = strings.TrimSpace(timezone)
+	if !localtime.Valid(timezone) {
+		return false, ErrInvalidTimezone
+	}
+
+	var user models.User
+	if err := s.db.Scopes(tenant.ForTenant(appID)).First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ErrUserNotFound
+		}
+		return false, fmt.Errorf("load user: %w", err)
+	}
+	if user.Timezone == timezone {
+		return false, nil
+	}
+	if err := s.db.Model(&user).Update("timezone", timezone).Error; err != nil {
+		return false, fmt.Errorf("update timezone: %w", err)
+	}
+	return true, nil
+}
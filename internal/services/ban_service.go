@@ -0,0 +1,340 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBanNotFound    = errors.New("user is not banned")
+	ErrInvalidBanTerm = errors.New("expires_at must be in the future")
+)
+
+// denylistRefreshInterval bounds how long a ban issued on another instance
+// takes to reach this one. Bans issued here apply immediately.
+const denylistRefreshInterval = 5 * time.Second
+
+type banKey struct {
+	appID  string
+	userID uuid.UUID
+}
+
+// BanService suspends users from an app. Bans are enforced when tokens are
+// issued (login, refresh) and, through an in-memory denylist of active bans,
+// on every authenticated request, so a banned user loses access within
+// seconds rather than when their access token expires. Accounts pending
+// deletion are on the denylist too.
+type BanService struct {
+	db *gorm.DB
+
+	mu sync.RWMutex
+	// denied maps each banned user to the ban's expiry; the zero time means
+	// permanent.
+	denied map[banKey]time.Time
+	// deleting holds accounts with a pending deletion.
+	deleting map[banKey]bool
+	// gen counts local changes to the denylist. deniedGen and deletingGen
+	// record the generation of each key's last local change, so a refresh
+	// that read the database before the change doesn't undo it.
+	gen         uint64
+	deniedGen   map[banKey]uint64
+	deletingGen map[banKey]uint64
+}
+
+func NewBanService(db *gorm.DB) *BanService {
+	return &BanService{
+		db:          db,
+		denied:      make(map[banKey]time.Time),
+		deleting:    make(map[banKey]bool),
+		deniedGen:   make(map[banKey]uint64),
+		deletingGen: make(map[banKey]uint64),
+	}
+}
+
+// Ban suspends userID until expiresAt (nil for a permanent ban) and signs
+// them out of every session.
+func (s *BanService) Ban(appID string, userID uuid.UUID, reason string, expiresAt *time.Time, actor tenant.AdminActor) (*models.UserBan, error) {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, ErrInvalidBanTerm
+	}
+	var ban *models.UserBan
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.User{}).Scopes(tenant.ForTenant(appID)).Where("id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrUserNotFound
+		}
+		var err error
+		ban, err = createBan(tx, appID, userID, reason, expiresAt, nil, actor)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.Deny(ban)
+	slog.Info("user banned", "app_id", appID, "user_id", userID, "expires_at", expiresAt, "actor", actor.Email)
+	return ban, nil
+}
+
+// createBan records a ban and revokes the user's refresh tokens in tx.
+// Callers must call Deny once tx commits.
+func createBan(tx *gorm.DB, appID string, userID uuid.UUID, reason string, expiresAt *time.Time, reportID *uuid.UUID, actor tenant.AdminActor) (*models.UserBan, error) {
+	ban := &models.UserBan{
+		AppID:      appID,
+		UserID:     userID,
+		Reason:     reason,
+		ExpiresAt:  expiresAt,
+		ReportID:   reportID,
+		ActorType:  actor.Type,
+		ActorID:    actor.ID,
+		ActorEmail: actor.Email,
+	}
+	if err := tx.Create(ban).Error; err != nil {
+		return nil, fmt.Errorf("create ban: %w", err)
+	}
+	if err := tx.Model(&models.RefreshToken{}).Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ? AND revoked = false", userID).Update("revoked", true).Error; err != nil {
+		return nil, fmt.Errorf("revoke sessions: %w", err)
+	}
+	return ban, nil
+}
+
+// Unban lifts every active ban on userID.
+func (s *BanService) Unban(appID string, userID uuid.UUID, actor tenant.AdminActor) error {
+	liftedBy := actor.Email
+	if liftedBy == "" {
+		liftedBy = actor.ID
+	}
+	if liftedBy == "" {
+		liftedBy = actor.Type
+	}
+	now := time.Now()
+	res := s.db.Model(&models.UserBan{}).Scopes(tenant.ForTenant(appID), activeBans(now)).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"lifted_at": now, "lifted_by": liftedBy})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrBanNotFound
+	}
+
+	s.allow(appID, userID)
+	slog.Info("user unbanned", "app_id", appID, "user_id", userID, "actor", liftedBy)
+	return nil
+}
+
+// ListBans returns an app's bans, newest first. With activeOnly, lifted and
+// expired bans are left out.
+func (s *BanService) ListBans(appID string, activeOnly bool, limit, offset int) ([]models.UserBan, int64, error) {
+	query := s.db.Model(&models.UserBan{}).Scopes(tenant.ForTenant(appID))
+	if activeOnly {
+		query = query.Scopes(activeBans(time.Now()))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var bans []models.UserBan
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&bans).Error; err != nil {
+		return nil, 0, err
+	}
+	return bans, total, nil
+}
+
+// ActiveBan returns the user's active ban that ends last, or nil.
+func (s *BanService) ActiveBan(appID string, userID uuid.UUID) (*models.UserBan, error) {
+	return findActiveBan(s.db, appID, userID)
+}
+
+func findActiveBan(db *gorm.DB, appID string, userID uuid.UUID) (*models.UserBan, error) {
+	var ban models.UserBan
+	err := db.Scopes(tenant.ForTenant(appID), activeBans(time.Now())).
+		Where("user_id = ?", userID).
+		Order("expires_at DESC NULLS FIRST").
+		First(&ban).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ban, nil
+}
+
+func activeBans(now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", now)
+	}
+}
+
+// IsDenied reports whether the denylist has an active ban or a pending
+// deletion for the user. It never touches the database.
+func (s *BanService) IsDenied(appID string, userID uuid.UUID) bool {
+	key := banKey{appID, userID}
+	s.mu.RLock()
+	until, ok := s.denied[key]
+	deleting := s.deleting[key]
+	s.mu.RUnlock()
+	return deleting || ok && (until.IsZero() || time.Now().Before(until))
+}
+
+// SetDeleting adds or removes an account pending deletion on this
+// instance's denylist without waiting for the next refresh.
+func (s *BanService) SetDeleting(appID string, userID uuid.UUID, pending bool) {
+	key := banKey{appID, userID}
+	s.mu.Lock()
+	if pending {
+		s.deleting[key] = true
+	} else {
+		delete(s.deleting, key)
+	}
+	s.gen++
+	s.deletingGen[key] = s.gen
+	s.mu.Unlock()
+}
+
+// Deny adds a ban to this instance's denylist without waiting for the next
+// refresh.
+func (s *BanService) Deny(ban *models.UserBan) {
+	var until time.Time
+	if ban.ExpiresAt != nil {
+		until = *ban.ExpiresAt
+	}
+	key := banKey{ban.AppID, ban.UserID}
+	s.mu.Lock()
+	if cur, ok := s.denied[key]; !ok || outlasts(until, cur) {
+		s.denied[key] = until
+	}
+	s.gen++
+	s.deniedGen[key] = s.gen
+	s.mu.Unlock()
+}
+
+// allow removes a lifted ban from this instance's denylist.
+func (s *BanService) allow(appID string, userID uuid.UUID) {
+	key := banKey{appID, userID}
+	s.mu.Lock()
+	delete(s.denied, key)
+	s.gen++
+	s.deniedGen[key] = s.gen
+	s.mu.Unlock()
+}
+
+// outlasts reports whether a ban ending at until ends after one ending at cur.
+// The zero time means permanent.
+func outlasts(until, cur time.Time) bool {
+	return !cur.IsZero() && (until.IsZero() || until.After(cur))
+}
+
+// refreshDenylist replaces the denylist with the active bans and pending
+// deletions in the database, keeping local changes made since the read.
+func (s *BanService) refreshDenylist() error {
+	s.mu.RLock()
+	start := s.gen
+	s.mu.RUnlock()
+
+	var bans []models.UserBan
+	if err := s.db.Scopes(activeBans(time.Now())).
+		Select("app_id", "user_id", "expires_at").
+		Find(&bans).Error; err != nil {
+		return err
+	}
+	denied := make(map[banKey]time.Time, len(bans))
+	for _, b := range bans {
+		key := banKey{b.AppID, b.UserID}
+		var until time.Time
+		if b.ExpiresAt != nil {
+			until = *b.ExpiresAt
+		}
+		if cur, ok := denied[key]; !ok || outlasts(until, cur) {
+			denied[key] = until
+		}
+	}
+
+	var pending []models.AccountDeletion
+	if err := s.db.Where("status = ?", models.DeletionPending).
+		Select("app_id", "user_id").
+		Find(&pending).Error; err != nil {
+		return err
+	}
+	deleting := make(map[banKey]bool, len(pending))
+	for _, d := range pending {
+		deleting[banKey{d.AppID, d.UserID}] = true
+	}
+
+	s.merge(start, denied, deleting)
+	return nil
+}
+
+// merge swaps in a denylist read from the database when the generation was
+// start. Keys changed locally after that keep their local state; older local
+// changes are already part of the read and are forgotten.
+func (s *BanService) merge(start uint64, denied map[banKey]time.Time, deleting map[banKey]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, gen := range s.deniedGen {
+		if gen <= start {
+			delete(s.deniedGen, key)
+			continue
+		}
+		if until, ok := s.denied[key]; ok {
+			if cur, read := denied[key]; !read || outlasts(until, cur) {
+				denied[key] = until
+			}
+		} else {
+			delete(denied, key)
+		}
+	}
+	for key, gen := range s.deletingGen {
+		if gen <= start {
+			delete(s.deletingGen, key)
+			continue
+		}
+		if s.deleting[key] {
+			deleting[key] = true
+		} else {
+			delete(deleting, key)
+		}
+	}
+	s.denied = denied
+	s.deleting = deleting
+}
+
+// StartDenylistRefresh loads the denylist and keeps it in sync with bans
+// issued or lifted on other instances until done is closed.
+func (s *BanService) StartDenylistRefresh(done chan struct{}) {
+	if err := s.refreshDenylist(); err != nil {
+		slog.Error("failed to load ban denylist", "error", err)
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("panic in ban denylist refresh", "recover", r)
+			}
+		}()
+		ticker := time.NewTicker(denylistRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.refreshDenylist(); err != nil {
+					slog.Error("failed to refresh ban denylist", "error", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+}
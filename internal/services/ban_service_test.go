@@ -0,0 +1,147 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestOutlasts(t *testing.T) {
+	now := time.Now()
+	var permanent time.Time
+	tests := []struct {
+		name      string
+		until     time.Time
+		cur       time.Time
+		outlasted bool
+	}{
+		{"later expiry", now.Add(2 * time.Hour), now.Add(time.Hour), true},
+		{"earlier expiry", now.Add(time.Hour), now.Add(2 * time.Hour), false},
+		{"same expiry", now, now, false},
+		{"permanent over temporary", permanent, now, true},
+		{"temporary over permanent", now, permanent, false},
+		{"permanent over permanent", permanent, permanent, false},
+	}
+	for _, tt := range tests {
+		if got := outlasts(tt.until, tt.cur); got != tt.outlasted {
+			t.Errorf("%s: outlasts = %v, want %v", tt.name, got, tt.outlasted)
+		}
+	}
+}
+
+func ban(appID string, userID uuid.UUID, expiresAt *time.Time) *models.UserBan {
+	return &models.UserBan{AppID: appID, UserID: userID, ExpiresAt: expiresAt}
+}
+
+func TestIsDenied(t *testing.T) {
+	s := NewBanService(nil)
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	permanent, temporary, expired, deleting := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	s.Deny(ban("app", permanent, nil))
+	s.Deny(ban("app", temporary, &future))
+	s.Deny(ban("app", expired, &past))
+	s.SetDeleting("app", deleting, true)
+
+	tests := []struct {
+		name   string
+		appID  string
+		userID uuid.UUID
+		denied bool
+	}{
+		{"permanent ban", "app", permanent, true},
+		{"temporary ban", "app", temporary, true},
+		{"expired ban", "app", expired, false},
+		{"pending deletion", "app", deleting, true},
+		{"other app", "other", permanent, false},
+		{"unknown user", "app", uuid.New(), false},
+	}
+	for _, tt := range tests {
+		if got := s.IsDenied(tt.appID, tt.userID); got != tt.denied {
+			t.Errorf("%s: IsDenied = %v, want %v", tt.name, got, tt.denied)
+		}
+	}
+
+	s.SetDeleting("app", deleting, false)
+	if s.IsDenied("app", deleting) {
+		t.Error("restored account is still denied")
+	}
+	s.allow("app", permanent)
+	if s.IsDenied("app", permanent) {
+		t.Error("lifted ban is still denied")
+	}
+}
+
+func TestDenyKeepsLongestBan(t *testing.T) {
+	s := NewBanService(nil)
+	user := uuid.New()
+	soon, later := time.Now().Add(time.Minute), time.Now().Add(time.Hour)
+
+	s.Deny(ban("app", user, &later))
+	s.Deny(ban("app", user, &soon))
+	if got := s.denied[banKey{"app", user}]; !got.Equal(later) {
+		t.Errorf("shorter ban replaced a longer one: until = %v", got)
+	}
+	s.Deny(ban("app", user, nil))
+	s.Deny(ban("app", user, &later))
+	if got := s.denied[banKey{"app", user}]; !got.IsZero() {
+		t.Errorf("temporary ban replaced a permanent one: until = %v", got)
+	}
+}
+
+func TestRefreshMergeKeepsNewerLocalChanges(t *testing.T) {
+	s := NewBanService(nil)
+	banned, lifted, deleting, restored := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	s.Deny(ban("app", lifted, nil))
+	s.SetDeleting("app", restored, true)
+
+	// A refresh reads the database, then these land before it swaps.
+	start := s.gen
+	s.Deny(ban("app", banned, nil))
+	s.allow("app", lifted)
+	s.SetDeleting("app", deleting, true)
+	s.SetDeleting("app", restored, false)
+	s.merge(start,
+		map[banKey]time.Time{{"app", lifted}: {}},
+		map[banKey]bool{{"app", restored}: true})
+
+	for name, tt := range map[string]struct {
+		userID uuid.UUID
+		denied bool
+	}{
+		"fresh ban":        {banned, true},
+		"lifted ban":       {lifted, false},
+		"pending deletion": {deleting, true},
+		"restored account": {restored, false},
+	} {
+		if got := s.IsDenied("app", tt.userID); got != tt.denied {
+			t.Errorf("%s: IsDenied after merge = %v, want %v", name, got, tt.denied)
+		}
+	}
+
+	// The next refresh started after all of them, so the database wins.
+	s.merge(s.gen,
+		map[banKey]time.Time{{"app", lifted}: {}},
+		map[banKey]bool{})
+	if s.IsDenied("app", banned) || !s.IsDenied("app", lifted) || s.IsDenied("app", deleting) {
+		t.Error("second merge kept local state older than its read")
+	}
+	if len(s.deniedGen) != 0 || len(s.deletingGen) != 0 {
+		t.Errorf("merge kept %d+%d stale generations", len(s.deniedGen), len(s.deletingGen))
+	}
+}
+
+func TestRefreshMergeKeepsLongerBan(t *testing.T) {
+	s := NewBanService(nil)
+	user := uuid.New()
+	soon := time.Now().Add(time.Minute)
+
+	start := s.gen
+	s.Deny(ban("app", user, &soon))
+	// Another instance banned the user permanently before the read.
+	s.merge(start, map[banKey]time.Time{{"app", user}: {}}, map[banKey]bool{})
+	if got := s.denied[banKey{"app", user}]; !got.IsZero() {
+		t.Errorf("merge kept the shorter local ban: until = %v", got)
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrUserBlocked is returned when an action targets a user on either side of
+// a block with the requester.
+var ErrUserBlocked = errors.New("user is not available")
+
+// BlockSet is the set of users hidden from a viewer: everyone they blocked
+// and everyone who blocked them. A nil BlockSet hides no one.
+type BlockSet map[uuid.UUID]struct{}
+
+// LoadBlockSet reads both directions of userID's blocks. Anonymous viewers
+// (uuid.Nil) get an empty set without a query.
+func LoadBlockSet(db *gorm.DB, appID string, userID uuid.UUID) (BlockSet, error) {
+	set := BlockSet{}
+	if userID == uuid.Nil {
+		return set, nil
+	}
+	var blocks []models.Block
+	if err := db.Scopes(tenant.ForTenant(appID)).
+		Where("blocker_id = ? OR blocked_id = ?", userID, userID).
+		Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range blocks {
+		if b.BlockerID == userID {
+			set[b.BlockedID] = struct{}{}
+		} else {
+			set[b.BlockerID] = struct{}{}
+		}
+	}
+	return set, nil
+}
+
+// Locals is the part of *fiber.Ctx used to cache per-request values, so
+// services don't depend on Fiber.
+type Locals interface {
+	Locals(key interface{}, value ...interface{}) interface{}
+}
+
+const blockSetLocal = "block_set"
+
+// RequestBlockSet returns the viewer's BlockSet, loading it on first use and
+// caching it for the rest of the request.
+func RequestBlockSet(c Locals, db *gorm.DB, appID string, userID uuid.UUID) (BlockSet, error) {
+	if set, ok := c.Locals(blockSetLocal).(BlockSet); ok {
+		return set, nil
+	}
+	set, err := LoadBlockSet(db, appID, userID)
+	if err != nil {
+		return nil, err
+	}
+	c.Locals(blockSetLocal, set)
+	return set, nil
+}
+
+// Contains reports whether id is hidden from the viewer.
+func (b BlockSet) Contains(id uuid.UUID) bool {
+	_, ok := b[id]
+	return ok
+}
+
+// IDs returns the hidden user IDs in no particular order.
+func (b BlockSet) IDs() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(b))
+	for id := range b {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Filter returns ids without the hidden users.
+func (b BlockSet) Filter(ids []uuid.UUID) []uuid.UUID {
+	if len(b) == 0 {
+		return ids
+	}
+	out := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !b.Contains(id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// Exclude is a GORM scope that drops rows whose authorColumn is a hidden
+// user. Rows without an author (NULL) are kept. It adds nothing when the set
+// is empty.
+func (b BlockSet) Exclude(authorColumn string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(b) == 0 {
+			return db
+		}
+		return db.Where(authorColumn+" IS NULL OR "+authorColumn+" NOT IN ?", b.IDs())
+	}
+}
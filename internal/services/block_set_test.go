@@ -0,0 +1,99 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRun is a Postgres *gorm.DB that builds SQL without a server.
+func dryRun(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestBlockSetExclude(t *testing.T) {
+	db := dryRun(t)
+	blocked := uuid.New()
+	tests := []struct {
+		name string
+		set  BlockSet
+		want string
+	}{
+		{"nil set", nil, `SELECT * FROM "blocks"`},
+		{"empty set", BlockSet{}, `SELECT * FROM "blocks"`},
+		{"one hidden user", BlockSet{blocked: {}}, `SELECT * FROM "blocks" WHERE blocker_id IS NULL OR blocker_id NOT IN ('` + blocked.String() + `')`},
+	}
+	for _, tt := range tests {
+		got := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Scopes(tt.set.Exclude("blocker_id")).Find(&[]models.Block{})
+		})
+		if got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+
+	// GORM groups the OR when other conditions are present.
+	got := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("app_id = ?", "app").Or("blocked_id = ?", blocked).
+			Scopes(BlockSet{blocked: {}}.Exclude("blocker_id")).Find(&[]models.Block{})
+	})
+	if !strings.Contains(got, "AND (blocker_id IS NULL OR blocker_id NOT IN") {
+		t.Errorf("Exclude is not a separate AND group: %s", got)
+	}
+}
+
+func TestBlockSetFilter(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	set := BlockSet{b: {}}
+	got := set.Filter([]uuid.UUID{a, b, c})
+	if len(got) != 2 || got[0] != a || got[1] != c {
+		t.Errorf("Filter = %v, want [%s %s]", got, a, c)
+	}
+	if got := BlockSet(nil).Filter([]uuid.UUID{a}); len(got) != 1 {
+		t.Errorf("nil set filtered %v", got)
+	}
+	if !set.Contains(b) || set.Contains(a) {
+		t.Error("Contains disagrees with the set")
+	}
+}
+
+type locals map[interface{}]interface{}
+
+func (l locals) Locals(key interface{}, value ...interface{}) interface{} {
+	if len(value) > 0 {
+		l[key] = value[0]
+	}
+	return l[key]
+}
+
+func TestRequestBlockSetCachesPerRequest(t *testing.T) {
+	// Anonymous viewers load without a query, so a nil DB is enough.
+	c := locals{}
+	set, err := RequestBlockSet(c, nil, "app", uuid.Nil)
+	if err != nil || set == nil || len(set) != 0 {
+		t.Fatalf("anonymous set = %v, %v; want empty", set, err)
+	}
+	if _, ok := c[blockSetLocal].(BlockSet); !ok {
+		t.Fatal("set was not cached on the request")
+	}
+
+	// A cached set is returned as is; a nil DB would panic on a reload.
+	hidden := uuid.New()
+	c = locals{blockSetLocal: BlockSet{hidden: {}}}
+	set, err = RequestBlockSet(c, nil, "app", uuid.New())
+	if err != nil || !set.Contains(hidden) {
+		t.Fatalf("cached set = %v, %v", set, err)
+	}
+}
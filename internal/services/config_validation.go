@@ -0,0 +1,202 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// ValidateConfigValue checks that a raw config value parses as its declared
+// type. For json values an optional JSON Schema is enforced as well.
+func ValidateConfigValue(typ, value, schema string) error {
+	switch typ {
+	case "string":
+	case "int":
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("value %q is not a valid int", value)
+		}
+	case "bool":
+		switch value {
+		case "true", "false", "1", "0":
+		default:
+			return fmt.Errorf("value %q is not a valid bool: use true, false, 1 or 0", value)
+		}
+	case "json":
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+			return fmt.Errorf("value is not valid JSON: %w", err)
+		}
+		if schema != "" {
+			var s map[string]interface{}
+			if err := json.Unmarshal([]byte(schema), &s); err != nil {
+				return fmt.Errorf("schema is not a valid JSON object: %w", err)
+			}
+			if err := validateJSONSchema(s, parsed, "$"); err != nil {
+				return fmt.Errorf("value does not match schema: %w", err)
+			}
+		}
+	default:
+		return errors.New("invalid type: must be string, bool, int, or json")
+	}
+	if schema != "" && typ != "json" {
+		return errors.New("schema is only supported for json values")
+	}
+	return nil
+}
+
+// validateJSONSchema implements the subset of JSON Schema that config payloads
+// actually use: type, enum, const, required, properties, additionalProperties,
+// items, minimum/maximum, minLength/maxLength, minItems/maxItems and pattern.
+// Unknown keywords are ignored, as the spec allows.
+func validateJSONSchema(schema map[string]interface{}, value interface{}, path string) error {
+	if t, ok := schema["type"]; ok {
+		var types []string
+		switch tv := t.(type) {
+		case string:
+			types = []string{tv}
+		case []interface{}:
+			for _, x := range tv {
+				if s, ok := x.(string); ok {
+					types = append(types, s)
+				}
+			}
+		}
+		matched := false
+		for _, typ := range types {
+			if jsonTypeMatches(typ, value) {
+				matched = true
+				break
+			}
+		}
+		if len(types) > 0 && !matched {
+			return fmt.Errorf("%s: expected type %v", path, t)
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value is not one of the allowed enum values", path)
+		}
+	}
+	if c, ok := schema["const"]; ok && !jsonEqual(c, value) {
+		return fmt.Errorf("%s: value must equal the schema const", path)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					if _, present := v[name]; !present {
+						return fmt.Errorf("%s: missing required property %q", path, name)
+					}
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		for name, child := range v {
+			if sub, ok := props[name].(map[string]interface{}); ok {
+				if err := validateJSONSchema(sub, child, path+"."+name); err != nil {
+					return err
+				}
+				continue
+			}
+			switch ap := schema["additionalProperties"].(type) {
+			case bool:
+				if !ap {
+					return fmt.Errorf("%s: unexpected property %q", path, name)
+				}
+			case map[string]interface{}:
+				if err := validateJSONSchema(ap, child, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if n, ok := schemaNumber(schema, "minItems"); ok && float64(len(v)) < n {
+			return fmt.Errorf("%s: expected at least %v items", path, n)
+		}
+		if n, ok := schemaNumber(schema, "maxItems"); ok && float64(len(v)) > n {
+			return fmt.Errorf("%s: expected at most %v items", path, n)
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, child := range v {
+				if err := validateJSONSchema(items, child, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if n, ok := schemaNumber(schema, "minLength"); ok && length < n {
+			return fmt.Errorf("%s: expected at least %v characters", path, n)
+		}
+		if n, ok := schemaNumber(schema, "maxLength"); ok && length > n {
+			return fmt.Errorf("%s: expected at most %v characters", path, n)
+		}
+		if p, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return fmt.Errorf("%s: invalid pattern in schema: %w", path, err)
+			}
+			if !re.MatchString(v) {
+				return fmt.Errorf("%s: value does not match pattern %q", path, p)
+			}
+		}
+	case float64:
+		if n, ok := schemaNumber(schema, "minimum"); ok && v < n {
+			return fmt.Errorf("%s: expected minimum %v", path, n)
+		}
+		if n, ok := schemaNumber(schema, "maximum"); ok && v > n {
+			return fmt.Errorf("%s: expected maximum %v", path, n)
+		}
+	}
+	return nil
+}
+
+func jsonTypeMatches(typ string, value interface{}) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+func schemaNumber(schema map[string]interface{}, key string) (float64, bool) {
+	n, ok := schema[key].(float64)
+	return n, ok
+}
+
+func jsonEqual(a, b interface{}) bool {
+	ab, errA := json.Marshal(a)
+	bb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ab) == string(bb)
+}
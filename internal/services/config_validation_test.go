@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+)
+
+func TestValidateConfigValueTypes(t *testing.T) {
+	tests := []struct {
+		typ, value, schema string
+		ok                 bool
+	}{
+		{"string", "anything", "", true},
+		{"int", "42", "", true},
+		{"int", "-7", "", true},
+		{"int", "4.2", "", false},
+		{"int", "", "", false},
+		{"bool", "true", "", true},
+		{"bool", "0", "", true},
+		{"bool", "yes", "", false},
+		{"json", `{"a":1}`, "", true},
+		{"json", `[1,2]`, "", true},
+		{"json", `{"a":`, "", false},
+		{"json", `{}`, `not json`, false},
+		{"string", "x", `{"type":"string"}`, false},
+		{"float", "1.5", "", false},
+	}
+	for _, tt := range tests {
+		err := ValidateConfigValue(tt.typ, tt.value, tt.schema)
+		if (err == nil) != tt.ok {
+			t.Errorf("ValidateConfigValue(%s, %q, %q) = %v, want ok=%v", tt.typ, tt.value, tt.schema, err, tt.ok)
+		}
+	}
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	const paywall = `{
+		"type": "object",
+		"required": ["title", "price"],
+		"additionalProperties": false,
+		"properties": {
+			"title": {"type": "string", "minLength": 1, "maxLength": 10},
+			"price": {"type": "number", "minimum": 0, "maximum": 100},
+			"trial_days": {"type": "integer"},
+			"layout": {"enum": ["grid", "list"]},
+			"version": {"const": 2},
+			"sku": {"type": "string", "pattern": "^com\\.app\\.[a-z]+$"},
+			"badges": {"type": "array", "minItems": 1, "maxItems": 2, "items": {"type": "string"}},
+			"note": {"type": ["string", "null"]},
+			"extra": {"type": "object", "additionalProperties": {"type": "boolean"}}
+		}
+	}`
+	tests := []struct {
+		name  string
+		value string
+		ok    bool
+	}{
+		{"minimal", `{"title":"Pro","price":9.99}`, true},
+		{"every property", `{"title":"Pro","price":0,"trial_days":7,"layout":"grid","version":2,"sku":"com.app.pro","badges":["new"],"note":null,"extra":{"a":true}}`, true},
+		{"wrong root type", `[]`, false},
+		{"missing required", `{"title":"Pro"}`, false},
+		{"unexpected property", `{"title":"Pro","price":1,"color":"red"}`, false},
+		{"string too short", `{"title":"","price":1}`, false},
+		{"string too long", `{"title":"Professional","price":1}`, false},
+		{"length counts runes", `{"title":"Über Größe","price":1}`, true},
+		{"below minimum", `{"title":"Pro","price":-1}`, false},
+		{"above maximum", `{"title":"Pro","price":100.5}`, false},
+		{"integer with fraction", `{"title":"Pro","price":1,"trial_days":1.5}`, false},
+		{"integer written as float", `{"title":"Pro","price":1,"trial_days":7.0}`, true},
+		{"not in enum", `{"title":"Pro","price":1,"layout":"carousel"}`, false},
+		{"const mismatch", `{"title":"Pro","price":1,"version":3}`, false},
+		{"pattern mismatch", `{"title":"Pro","price":1,"sku":"com.other.pro"}`, false},
+		{"too few items", `{"title":"Pro","price":1,"badges":[]}`, false},
+		{"too many items", `{"title":"Pro","price":1,"badges":["a","b","c"]}`, false},
+		{"bad item type", `{"title":"Pro","price":1,"badges":[1]}`, false},
+		{"type union", `{"title":"Pro","price":1,"note":"hi"}`, true},
+		{"type union mismatch", `{"title":"Pro","price":1,"note":5}`, false},
+		{"additionalProperties schema", `{"title":"Pro","price":1,"extra":{"a":"yes"}}`, false},
+	}
+	for _, tt := range tests {
+		err := ValidateConfigValue("json", tt.value, paywall)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestValidateJSONSchemaEdgeCases(t *testing.T) {
+	tests := []struct {
+		name, schema, value string
+		ok                  bool
+	}{
+		{"unknown keywords are ignored", `{"format":"email","title":"x"}`, `"not an email"`, true},
+		{"empty schema accepts anything", `{}`, `{"a":[1,{"b":null}]}`, true},
+		{"invalid pattern", `{"pattern":"("}`, `"x"`, false},
+		{"enum of objects", `{"enum":[{"a":1}]}`, `{"a":1}`, true},
+		{"enum of objects mismatch", `{"enum":[{"a":1}]}`, `{"a":2}`, false},
+		{"nested path", `{"properties":{"a":{"items":{"type":"integer"}}}}`, `{"a":[1,"2"]}`, false},
+		{"null type", `{"type":"null"}`, `null`, true},
+		{"boolean type", `{"type":"boolean"}`, `0`, false},
+	}
+	for _, tt := range tests {
+		err := ValidateConfigValue("json", tt.value, tt.schema)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestApplyRuleRequestUsesKeyTypeAndSchema(t *testing.T) {
+	base := &models.RemoteConfig{
+		Key:    "paywall",
+		Type:   "json",
+		Value:  `{"title":"Pro"}`,
+		Schema: `{"type":"object","required":["title"]}`,
+	}
+	tests := []struct {
+		name string
+		req  dto.RemoteConfigRuleRequest
+		ok   bool
+	}{
+		{"matches schema", dto.RemoteConfigRuleRequest{Value: `{"title":"Sale"}`}, true},
+		{"same type given", dto.RemoteConfigRuleRequest{Value: `{"title":"Sale"}`, Type: "json"}, true},
+		{"breaks schema", dto.RemoteConfigRuleRequest{Value: `{"price":1}`}, false},
+		{"not json", dto.RemoteConfigRuleRequest{Value: `Sale`}, false},
+		{"other type", dto.RemoteConfigRuleRequest{Value: `Sale`, Type: "string"}, false},
+	}
+	for _, tt := range tests {
+		var rule models.RemoteConfigRule
+		err := applyRuleRequest(&rule, &tt.req, base)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok=%v", tt.name, err, tt.ok)
+			continue
+		}
+		if err != nil && !errors.Is(err, ErrInvalidConfigValue) {
+			t.Errorf("%s: err = %v, want ErrInvalidConfigValue", tt.name, err)
+		}
+		if err == nil && rule.Type != "json" {
+			t.Errorf("%s: rule type = %q, want the key's type", tt.name, rule.Type)
+		}
+	}
+}
@@ -0,0 +1,631 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrExperimentNotFound    = errors.New("experiment not found")
+	ErrExperimentKeyTaken    = errors.New("experiment key already exists")
+	ErrExperimentNotEditable = errors.New("only draft experiments can be edited")
+	ErrNotEnrolled           = errors.New("subject is not enrolled in this experiment")
+	ErrNoSubject             = errors.New("a signed-in user or X-Device-ID header is required")
+	ErrInvalidEvent          = errors.New("event is required and must be 100 characters or fewer")
+)
+
+// ExperimentGoalPurchase is the goal event emitted for RevenueCat INITIAL_PURCHASE webhooks.
+const ExperimentGoalPurchase = "purchase"
+
+// experimentZ is the z-score for the 95% confidence intervals in reports.
+const experimentZ = 1.959964
+
+var experimentTransitions = map[string]map[string]bool{
+	"draft":   {"running": true},
+	"running": {"paused": true, "completed": true},
+	"paused":  {"running": true, "completed": true},
+}
+
+type ExperimentService struct {
+	db *gorm.DB
+
+	listenersMu sync.RWMutex
+	listeners   []func(appID string)
+}
+
+func NewExperimentService(db *gorm.DB) *ExperimentService {
+	return &ExperimentService{db: db}
+}
+
+// OnChange registers a callback fired after experiments are created or
+// modified, so cached config snapshots can be invalidated.
+func (s *ExperimentService) OnChange(fn func(appID string)) {
+	s.listenersMu.Lock()
+	s.listeners = append(s.listeners, fn)
+	s.listenersMu.Unlock()
+}
+
+func (s *ExperimentService) notifyChange(appID string) {
+	s.listenersMu.RLock()
+	listeners := s.listeners
+	s.listenersMu.RUnlock()
+	for _, fn := range listeners {
+		fn(appID)
+	}
+}
+
+// SubjectFor returns the stable assignment key for a client: the user ID when
+// signed in, otherwise the device ID. Empty when neither is known.
+func SubjectFor(cc ClientContext) string {
+	if cc.UserID != nil {
+		return cc.UserID.String()
+	}
+	if cc.DeviceID != "" {
+		return DeviceSubject(cc.DeviceID)
+	}
+	return ""
+}
+
+// DeviceSubject is the assignment key of a client known only by its device.
+func DeviceSubject(deviceID string) string {
+	return "device:" + deviceID
+}
+
+// moveExperimentSubject re-keys from's experiment assignments to to inside
+// tx, so conversions tracked under to count for variants served to from.
+// Where both were enrolled in an experiment, to's assignment is kept.
+func moveExperimentSubject(tx *gorm.DB, appID, from, to string) error {
+	if err := tx.Exec(`DELETE FROM experiment_assignments WHERE app_id = ? AND subject_id = ? AND experiment_id IN
+		(SELECT experiment_id FROM experiment_assignments WHERE app_id = ? AND subject_id = ?)`,
+		appID, from, appID, to).Error; err != nil {
+		return fmt.Errorf("drop overlapping experiment assignments: %w", err)
+	}
+	if err := tx.Model(&models.ExperimentAssignment{}).
+		Where("app_id = ? AND subject_id = ?", appID, from).
+		Update("subject_id", to).Error; err != nil {
+		return fmt.Errorf("move experiment assignments: %w", err)
+	}
+	return nil
+}
+
+// Apply overlays the client's variant of every running experiment it
+// qualifies for on the resolved config. The variants are also returned under
+// the "experiments" key so clients can tag analytics. Variants are derived
+// from a hash of the experiment and subject, so serving config writes
+// nothing; the assignment row is stored on first exposure (see enroll).
+func (s *ExperimentService) Apply(appID string, cc ClientContext, resolved *ResolvedConfig, types map[string]string, experiments []models.Experiment) {
+	subject := SubjectFor(cc)
+	if subject == "" {
+		return
+	}
+
+	traits := &userTraits{}
+	assigned := make(map[string]string)
+	for i := range experiments {
+		exp := &experiments[i]
+		if !s.qualifies(appID, exp, cc, traits) {
+			continue
+		}
+
+		variants, err := decodeVariants(exp.Variants)
+		if err != nil {
+			slog.Error("experiment has invalid variants, skipping", "experiment", exp.Key, "error", err)
+			continue
+		}
+		variant := pickVariant(exp.ID, subject, variants)
+		assigned[exp.Key] = variant.Name
+
+		if exp.ConfigKey != "" {
+			typ := types[exp.ConfigKey]
+			resolved.Values[exp.ConfigKey] = variantValue(exp.ConfigKey, typ, resolved.Values[exp.ConfigKey], &variant)
+		}
+	}
+
+	if len(assigned) > 0 {
+		resolved.Values["experiments"] = assigned
+	}
+}
+
+// qualifies reports whether the client matches the experiment's conditions.
+func (s *ExperimentService) qualifies(appID string, exp *models.Experiment, cc ClientContext, traits *userTraits) bool {
+	var cond models.RuleConditions
+	if len(exp.Conditions) > 0 {
+		if err := json.Unmarshal(exp.Conditions, &cond); err != nil {
+			slog.Warn("experiment has invalid conditions, skipping", "experiment", exp.Key, "error", err)
+			return false
+		}
+	}
+	return conditionsMatch(s.db, appID, &cond, cc, traits)
+}
+
+// enroll returns the subject's assignment, storing the variant Apply serves
+// on first sight. Weights are frozen while an experiment runs, so the stored
+// variant always matches the hash.
+func (s *ExperimentService) enroll(exp *models.Experiment, subject string) (*models.ExperimentAssignment, error) {
+	var existing models.ExperimentAssignment
+	err := s.db.Where("experiment_id = ? AND subject_id = ?", exp.ID, subject).First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("load assignment: %w", err)
+	}
+
+	variants, err := decodeVariants(exp.Variants)
+	if err != nil {
+		return nil, err
+	}
+	assignment := models.ExperimentAssignment{
+		ID:           uuid.New(),
+		AppID:        exp.AppID,
+		ExperimentID: exp.ID,
+		SubjectID:    subject,
+		Variant:      pickVariant(exp.ID, subject, variants).Name,
+		AssignedAt:   time.Now().UTC(),
+	}
+	// Concurrent first exposures race here; ON CONFLICT keeps whichever insert
+	// landed first and the re-read below returns it to both callers.
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&assignment).Error; err != nil {
+		return nil, fmt.Errorf("create assignment: %w", err)
+	}
+	if err := s.db.Where("experiment_id = ? AND subject_id = ?", exp.ID, subject).First(&existing).Error; err != nil {
+		return nil, fmt.Errorf("reload assignment: %w", err)
+	}
+	return &existing, nil
+}
+
+// pickVariant deterministically maps a subject onto the weighted variant list.
+func pickVariant(experimentID uuid.UUID, subject string, variants []models.ExperimentVariant) models.ExperimentVariant {
+	total := 0
+	for _, v := range variants {
+		total += v.Weight
+	}
+	h := fnv.New32a()
+	h.Write([]byte(experimentID.String() + ":" + subject))
+	point := int(h.Sum32() % uint32(total))
+	for _, v := range variants {
+		if point < v.Weight {
+			return v
+		}
+		point -= v.Weight
+	}
+	return variants[len(variants)-1]
+}
+
+func variantValue(key, typ string, base interface{}, variant *models.ExperimentVariant) interface{} {
+	baseObj, baseIsObj := base.(map[string]interface{})
+	if variant.Value == "" {
+		if baseIsObj {
+			if _, ok := baseObj["variant"]; ok {
+				merged := copyObject(baseObj)
+				merged["variant"] = variant.Name
+				return merged
+			}
+		}
+		return base
+	}
+
+	if typ == "" {
+		typ = "string"
+	}
+	parsed := ParseConfigValue(key, typ, variant.Value)
+	if patch, ok := parsed.(map[string]interface{}); ok && baseIsObj {
+		merged := copyObject(baseObj)
+		for k, v := range patch {
+			merged[k] = v
+		}
+		// paywall_config carries a "variant" field for client-side analytics.
+		if _, ok := baseObj["variant"]; ok {
+			merged["variant"] = variant.Name
+		}
+		return merged
+	}
+	return parsed
+}
+
+func copyObject(src map[string]interface{}) map[string]interface{} {
+	dst := make(map[string]interface{}, len(src)+1)
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+// RecordExposure marks the moment the client actually saw their variant,
+// enrolling them in the experiment if they qualify. Only the first exposure
+// is logged; repeats are accepted and ignored.
+func (s *ExperimentService) RecordExposure(appID string, cc ClientContext, experimentKey string) (string, error) {
+	subject := SubjectFor(cc)
+	if subject == "" {
+		return "", ErrNoSubject
+	}
+	var exp models.Experiment
+	if err := s.db.Scopes(tenant.ForTenant(appID)).
+		Where("key = ? AND status = ?", experimentKey, "running").
+		First(&exp).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrExperimentNotFound
+		}
+		return "", fmt.Errorf("load experiment: %w", err)
+	}
+	if !s.qualifies(appID, &exp, cc, &userTraits{}) {
+		return "", ErrNotEnrolled
+	}
+
+	assignment, err := s.enroll(&exp, subject)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	result := s.db.Model(&models.ExperimentAssignment{}).
+		Where("id = ? AND exposed_at IS NULL", assignment.ID).
+		Update("exposed_at", now)
+	if result.Error != nil {
+		return "", fmt.Errorf("record exposure: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		s.logEvent(appID, exp.ID, subject, assignment.Variant, "exposure", "", "client")
+	}
+	return assignment.Variant, nil
+}
+
+// TrackEvent records a conversion for every running experiment whose goal is
+// eventName (optionally restricted to one experiment key). Each assignment
+// converts at most once. Returns the number of conversions recorded.
+func (s *ExperimentService) TrackEvent(appID, subject, eventName, experimentKey, source string) (int, error) {
+	if subject == "" {
+		return 0, ErrNoSubject
+	}
+	eventName = strings.TrimSpace(eventName)
+	if eventName == "" || len(eventName) > 100 {
+		return 0, ErrInvalidEvent
+	}
+
+	query := s.db.Scopes(tenant.ForTenant(appID)).Where("status = ? AND goal_event = ?", "running", eventName)
+	if experimentKey != "" {
+		query = query.Where("key = ?", experimentKey)
+	}
+	var experiments []models.Experiment
+	if err := query.Find(&experiments).Error; err != nil {
+		return 0, fmt.Errorf("load experiments: %w", err)
+	}
+
+	converted := 0
+	now := time.Now().UTC()
+	for _, exp := range experiments {
+		var assignment models.ExperimentAssignment
+		if err := s.db.Where("experiment_id = ? AND subject_id = ?", exp.ID, subject).First(&assignment).Error; err != nil {
+			continue
+		}
+		result := s.db.Model(&models.ExperimentAssignment{}).
+			Where("id = ? AND converted_at IS NULL", assignment.ID).
+			Update("converted_at", now)
+		if result.Error != nil {
+			return converted, fmt.Errorf("record conversion: %w", result.Error)
+		}
+		if result.RowsAffected > 0 {
+			s.logEvent(appID, exp.ID, subject, assignment.Variant, "conversion", eventName, source)
+			converted++
+		}
+	}
+	return converted, nil
+}
+
+func (s *ExperimentService) logEvent(appID string, experimentID uuid.UUID, subject, variant, typ, name, source string) {
+	event := models.ExperimentEvent{
+		ID:           uuid.New(),
+		AppID:        appID,
+		ExperimentID: experimentID,
+		SubjectID:    subject,
+		Variant:      variant,
+		Type:         typ,
+		Name:         name,
+		Source:       source,
+	}
+	if err := s.db.Create(&event).Error; err != nil {
+		slog.Error("failed to log experiment event", "experiment_id", experimentID, "type", typ, "error", err)
+	}
+}
+
+func (s *ExperimentService) ListExperiments(appID string) ([]models.Experiment, error) {
+	var experiments []models.Experiment
+	if err := s.db.Scopes(tenant.ForTenant(appID)).Order("created_at DESC").Find(&experiments).Error; err != nil {
+		return nil, fmt.Errorf("list experiments: %w", err)
+	}
+	return experiments, nil
+}
+
+func (s *ExperimentService) CreateExperiment(appID string, req *dto.ExperimentRequest) (*models.Experiment, error) {
+	exp := models.Experiment{
+		ID:     uuid.New(),
+		AppID:  appID,
+		Status: "draft",
+	}
+	if err := applyExperimentRequest(&exp, req); err != nil {
+		return nil, err
+	}
+	if err := s.validateVariantValues(&exp); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&models.Experiment{}).Scopes(tenant.ForTenant(appID)).Where("key = ?", exp.Key).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("check experiment key: %w", err)
+	}
+	if count > 0 {
+		return nil, ErrExperimentKeyTaken
+	}
+
+	if err := s.db.Create(&exp).Error; err != nil {
+		return nil, fmt.Errorf("create experiment: %w", err)
+	}
+	s.notifyChange(appID)
+	return &exp, nil
+}
+
+// UpdateExperiment edits a draft. Running experiments are frozen so that
+// reassigning weights mid-flight cannot skew the results.
+func (s *ExperimentService) UpdateExperiment(appID string, id uuid.UUID, req *dto.ExperimentRequest) (*models.Experiment, error) {
+	exp, err := s.getExperiment(appID, id)
+	if err != nil {
+		return nil, err
+	}
+	if exp.Status != "draft" {
+		return nil, ErrExperimentNotEditable
+	}
+	req.Key = exp.Key
+	if err := applyExperimentRequest(exp, req); err != nil {
+		return nil, err
+	}
+	if err := s.validateVariantValues(exp); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(exp).Error; err != nil {
+		return nil, fmt.Errorf("update experiment: %w", err)
+	}
+	s.notifyChange(appID)
+	return exp, nil
+}
+
+func (s *ExperimentService) SetStatus(appID string, id uuid.UUID, status string) (*models.Experiment, error) {
+	exp, err := s.getExperiment(appID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !experimentTransitions[exp.Status][status] {
+		return nil, fmt.Errorf("cannot move experiment from %s to %s", exp.Status, status)
+	}
+
+	now := time.Now().UTC()
+	updates := map[string]interface{}{"status": status}
+	if status == "running" && exp.StartedAt == nil {
+		updates["started_at"] = now
+	}
+	if status == "completed" {
+		updates["ended_at"] = now
+	}
+	if err := s.db.Model(exp).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("update experiment status: %w", err)
+	}
+	s.notifyChange(appID)
+	return s.getExperiment(appID, id)
+}
+
+// Report aggregates per-variant conversion among exposed subjects with 95%
+// Wilson score intervals, plus the difference from control with a normal
+// approximation interval.
+func (s *ExperimentService) Report(appID string, id uuid.UUID) (*dto.ExperimentReport, error) {
+	exp, err := s.getExperiment(appID, id)
+	if err != nil {
+		return nil, err
+	}
+	variants, err := decodeVariants(exp.Variants)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Variant   string
+		Assigned  int64
+		Exposed   int64
+		Converted int64
+	}
+	if err := s.db.Model(&models.ExperimentAssignment{}).
+		Select(`variant,
+			COUNT(*) AS assigned,
+			COUNT(exposed_at) AS exposed,
+			COUNT(*) FILTER (WHERE exposed_at IS NOT NULL AND converted_at IS NOT NULL) AS converted`).
+		Where("experiment_id = ?", exp.ID).
+		Group("variant").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("aggregate experiment: %w", err)
+	}
+	byVariant := make(map[string]int, len(rows))
+	for i, r := range rows {
+		byVariant[r.Variant] = i
+	}
+
+	report := &dto.ExperimentReport{
+		Experiment:      *exp,
+		ConfidenceLevel: 0.95,
+		Variants:        make([]dto.VariantReport, 0, len(variants)),
+	}
+	for _, v := range variants {
+		vr := dto.VariantReport{Variant: v.Name}
+		if i, ok := byVariant[v.Name]; ok {
+			vr.Assigned = rows[i].Assigned
+			vr.Exposed = rows[i].Exposed
+			vr.Converted = rows[i].Converted
+		}
+		if vr.Exposed > 0 {
+			vr.ConversionRate = round4(float64(vr.Converted) / float64(vr.Exposed))
+		}
+		low, high := wilsonInterval(vr.Converted, vr.Exposed)
+		vr.CILow, vr.CIHigh = round4(low), round4(high)
+		report.Variants = append(report.Variants, vr)
+	}
+
+	control := report.Variants[0]
+	for i := 1; i < len(report.Variants); i++ {
+		vr := &report.Variants[i]
+		if vr.Exposed == 0 || control.Exposed == 0 {
+			continue
+		}
+		p0 := float64(control.Converted) / float64(control.Exposed)
+		p1 := float64(vr.Converted) / float64(vr.Exposed)
+		se := math.Sqrt(p1*(1-p1)/float64(vr.Exposed) + p0*(1-p0)/float64(control.Exposed))
+		diff, low, high := round4(p1-p0), round4(p1-p0-experimentZ*se), round4(p1-p0+experimentZ*se)
+		vr.DiffVsControl, vr.DiffCILow, vr.DiffCIHigh = &diff, &low, &high
+	}
+	return report, nil
+}
+
+func (s *ExperimentService) getExperiment(appID string, id uuid.UUID) (*models.Experiment, error) {
+	var exp models.Experiment
+	if err := s.db.Scopes(tenant.ForTenant(appID)).First(&exp, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExperimentNotFound
+		}
+		return nil, fmt.Errorf("load experiment: %w", err)
+	}
+	return &exp, nil
+}
+
+func applyExperimentRequest(exp *models.Experiment, req *dto.ExperimentRequest) error {
+	key := strings.TrimSpace(req.Key)
+	goal := strings.TrimSpace(req.GoalEvent)
+	if goal == "" {
+		goal = ExperimentGoalPurchase
+	}
+
+	seen := make(map[string]bool, len(req.Variants))
+	total := 0
+	for i := range req.Variants {
+		v := &req.Variants[i]
+		v.Name = strings.TrimSpace(v.Name)
+		if v.Name == "" || len(v.Name) > 100 {
+			return errors.New("variant names are required and must be 100 characters or fewer")
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("duplicate variant name %q", v.Name)
+		}
+		seen[v.Name] = true
+		if v.Weight < 0 {
+			return errors.New("variant weights must not be negative")
+		}
+		total += v.Weight
+	}
+	if total == 0 {
+		return errors.New("variant weights must add up to more than zero")
+	}
+	if err := validateRuleConditions(&req.Conditions); err != nil {
+		return err
+	}
+
+	variants, err := json.Marshal(req.Variants)
+	if err != nil {
+		return fmt.Errorf("encode variants: %w", err)
+	}
+	conditions, err := json.Marshal(req.Conditions)
+	if err != nil {
+		return fmt.Errorf("encode conditions: %w", err)
+	}
+
+	exp.Key = key
+	exp.Name = strings.TrimSpace(req.Name)
+	exp.Description = strings.TrimSpace(req.Description)
+	exp.ConfigKey = strings.TrimSpace(req.ConfigKey)
+	exp.GoalEvent = goal
+	exp.Variants = variants
+	exp.Conditions = conditions
+	return nil
+}
+
+// validateVariantValues checks each variant value against the experiment's
+// config key as Apply will serve it: an object is merged over the key's object
+// value before the key's schema is checked.
+func (s *ExperimentService) validateVariantValues(exp *models.Experiment) error {
+	if exp.ConfigKey == "" {
+		return nil
+	}
+	var base models.RemoteConfig
+	if err := s.db.Scopes(tenant.ForTenant(exp.AppID)).Where("key = ?", exp.ConfigKey).First(&base).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %s", ErrConfigKeyNotFound, exp.ConfigKey)
+		}
+		return fmt.Errorf("load config key: %w", err)
+	}
+	variants, err := decodeVariants(exp.Variants)
+	if err != nil {
+		return err
+	}
+
+	baseValue := ParseConfigValue(base.Key, base.Type, base.Value)
+	for i := range variants {
+		v := &variants[i]
+		if v.Value == "" {
+			continue
+		}
+		if err := ValidateConfigValue(base.Type, v.Value, ""); err != nil {
+			return fmt.Errorf("%w: variant %q: %v", ErrInvalidConfigValue, v.Name, err)
+		}
+		if base.Schema == "" {
+			continue
+		}
+		served, err := json.Marshal(variantValue(base.Key, base.Type, baseValue, v))
+		if err != nil {
+			return fmt.Errorf("encode variant %q: %w", v.Name, err)
+		}
+		if err := ValidateConfigValue(base.Type, string(served), base.Schema); err != nil {
+			return fmt.Errorf("%w: variant %q: %v", ErrInvalidConfigValue, v.Name, err)
+		}
+	}
+	return nil
+}
+
+func decodeVariants(raw []byte) ([]models.ExperimentVariant, error) {
+	var variants []models.ExperimentVariant
+	if err := json.Unmarshal(raw, &variants); err != nil {
+		return nil, fmt.Errorf("decode variants: %w", err)
+	}
+	total := 0
+	for _, v := range variants {
+		total += v.Weight
+	}
+	if len(variants) == 0 || total <= 0 {
+		return nil, errors.New("experiment has no weighted variants")
+	}
+	return variants, nil
+}
+
+func wilsonInterval(successes, trials int64) (float64, float64) {
+	if trials == 0 {
+		return 0, 0
+	}
+	n := float64(trials)
+	p := float64(successes) / n
+	z2 := experimentZ * experimentZ
+	denom := 1 + z2/n
+	center := (p + z2/(2*n)) / denom
+	half := experimentZ * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / denom
+	return math.Max(0, center-half), math.Min(1, center+half)
+}
+
+func round4(f float64) float64 {
+	return math.Round(f*10000) / 10000
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func testExperiment(t *testing.T, configKey string, variants ...models.ExperimentVariant) models.Experiment {
+	t.Helper()
+	raw, err := json.Marshal(variants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return models.Experiment{
+		ID:        uuid.New(),
+		AppID:     "app",
+		Key:       "exp_" + configKey,
+		ConfigKey: configKey,
+		Status:    "running",
+		Variants:  raw,
+	}
+}
+
+func TestPickVariantIsStableAndWeighted(t *testing.T) {
+	variants := []models.ExperimentVariant{{Name: "control", Weight: 1}, {Name: "treatment", Weight: 3}, {Name: "off", Weight: 0}}
+	expID := uuid.New()
+
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		subject := fmt.Sprintf("device:%d", i)
+		v := pickVariant(expID, subject, variants)
+		if again := pickVariant(expID, subject, variants); again.Name != v.Name {
+			t.Fatalf("%s got %s then %s", subject, v.Name, again.Name)
+		}
+		counts[v.Name]++
+	}
+	if counts["off"] != 0 {
+		t.Errorf("zero-weight variant picked %d times", counts["off"])
+	}
+	if c := counts["treatment"]; c < 2800 || c > 3200 {
+		t.Errorf("treatment picked %d of 4000 times, want about 3000", c)
+	}
+}
+
+func TestApplyServesVariantsWithoutTheDatabase(t *testing.T) {
+	// No DB: reading config must not load or store assignments.
+	s := &ExperimentService{}
+	exp := testExperiment(t, "paywall",
+		models.ExperimentVariant{Name: "control", Weight: 1},
+		models.ExperimentVariant{Name: "big", Weight: 1, Value: `{"size":"big"}`},
+	)
+	types := map[string]string{"paywall": "json"}
+
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		resolved := &ResolvedConfig{Values: map[string]interface{}{
+			"paywall": map[string]interface{}{"size": "small", "variant": ""},
+		}}
+		cc := ClientContext{DeviceID: fmt.Sprintf("d%d", i)}
+		s.Apply("app", cc, resolved, types, []models.Experiment{exp})
+
+		variants, _ := decodeVariants(exp.Variants)
+		want := pickVariant(exp.ID, SubjectFor(cc), variants)
+		got := resolved.Values["experiments"].(map[string]string)[exp.Key]
+		if got != want.Name {
+			t.Fatalf("%s: variant = %s, want %s", cc.DeviceID, got, want.Name)
+		}
+		size := map[string]string{"control": "small", "big": "big"}[got]
+		wantPaywall := map[string]interface{}{"size": size, "variant": got}
+		if !reflect.DeepEqual(resolved.Values["paywall"], wantPaywall) {
+			t.Fatalf("%s: paywall = %v, want %v", cc.DeviceID, resolved.Values["paywall"], wantPaywall)
+		}
+		seen[got] = true
+	}
+	if len(seen) != 2 {
+		t.Errorf("50 subjects saw variants %v, want both", seen)
+	}
+
+	// Clients without a subject are not enrolled.
+	resolved := &ResolvedConfig{Values: map[string]interface{}{}}
+	s.Apply("app", ClientContext{}, resolved, types, []models.Experiment{exp})
+	if _, ok := resolved.Values["experiments"]; ok {
+		t.Error("anonymous client without a device ID got experiments")
+	}
+}
+
+func TestVariantValue(t *testing.T) {
+	base := map[string]interface{}{"title": "Pro", "variant": ""}
+	tests := []struct {
+		name    string
+		typ     string
+		base    interface{}
+		variant models.ExperimentVariant
+		want    interface{}
+	}{
+		{"empty value tags object", "json", base, models.ExperimentVariant{Name: "a"}, map[string]interface{}{"title": "Pro", "variant": "a"}},
+		{"object merges", "json", base, models.ExperimentVariant{Name: "b", Value: `{"price":2}`}, map[string]interface{}{"title": "Pro", "price": float64(2), "variant": "b"}},
+		{"scalar replaces", "int", 3, models.ExperimentVariant{Name: "c", Value: "5"}, 5},
+		{"empty value keeps scalar", "bool", true, models.ExperimentVariant{Name: "d"}, true},
+		{"untyped key is a string", "", "x", models.ExperimentVariant{Name: "e", Value: "y"}, "y"},
+	}
+	for _, tt := range tests {
+		got := variantValue("k", tt.typ, tt.base, &tt.variant)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+	if base["variant"] != "" {
+		t.Error("variantValue modified the base object")
+	}
+}
+
+func TestExperimentChangeNotifiesEveryListener(t *testing.T) {
+	experiments := NewExperimentService(nil)
+	// The config service registers first, as in server.New.
+	remoteConfig := NewRemoteConfigService(nil, experiments)
+	remoteConfig.cache["app"] = &configSnapshot{}
+
+	var configEvents, experimentEvents []string
+	remoteConfig.OnChange(func(appID string) { configEvents = append(configEvents, appID) })
+	experiments.OnChange(func(appID string) { experimentEvents = append(experimentEvents, appID) })
+
+	experiments.notifyChange("app")
+	if _, cached := remoteConfig.cache["app"]; cached {
+		t.Error("experiment change kept the config snapshot")
+	}
+	if !reflect.DeepEqual(configEvents, []string{"app"}) {
+		t.Errorf("config listeners got %v, want [app]", configEvents)
+	}
+	if !reflect.DeepEqual(experimentEvents, []string{"app"}) {
+		t.Errorf("second experiment listener got %v, want [app]", experimentEvents)
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKSCache struct {
+	keys      map[string]*rsa.PublicKey
+	expiresAt time.Time
+	mu        sync.RWMutex
+}
+
+// JWKSClient fetches and caches an identity provider's RSA signing keys.
+type JWKSClient struct {
+	cache      *JWKSCache
+	httpClient *http.Client
+	jwksURL    string
+}
+
+type JWTHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+func NewJWKSClient(jwksURL string) *JWKSClient {
+	return &JWKSClient{
+		cache: &JWKSCache{
+			keys: make(map[string]*rsa.PublicKey),
+		},
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		jwksURL:    jwksURL,
+	}
+}
+
+func (c *JWKSClient) fetchKeys() error {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+
+	// Double-check under write lock: another goroutine may have already refreshed
+	// the cache while this goroutine was waiting for the lock (thundering-herd guard).
+	if time.Now().Before(c.cache.expiresAt) {
+		return nil
+	}
+
+	resp, err := c.httpClient.Get(c.jwksURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("JWKS endpoint returned status %d", resp.StatusCode)
+	}
+
+	var jwks JWKS
+	// Limit response body to 1 MiB — prevents OOM if the provider's JWKS
+	// endpoint is compromised or returns an unexpectedly large payload.
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&jwks); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	newKeys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "" && jwk.Kty != "RSA" {
+			continue
+		}
+		pubKey, err := parseRSAPublicKey(jwk.N, jwk.E)
+		if err != nil {
+			continue
+		}
+		newKeys[jwk.Kid] = pubKey
+	}
+	// Only replace keys if we parsed at least one — empty set likely means parse errors
+	if len(newKeys) > 0 {
+		c.cache.keys = newKeys
+	}
+	// 1-hour TTL: tighter window if a provider emergency-rotates a compromised key.
+	// 24h was too long — a revoked key would remain trusted for up to a day.
+	c.cache.expiresAt = time.Now().Add(1 * time.Hour)
+	return nil
+}
+
+func parseRSAPublicKey(nStr, eStr string) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(nStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode modulus: %w", err)
+	}
+
+	eBytes, err := base64.RawURLEncoding.DecodeString(eStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode exponent: %w", err)
+	}
+
+	var e int
+	for _, b := range eBytes {
+		e = e<<8 | int(b)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: e,
+	}, nil
+}
+
+func (c *JWKSClient) GetPublicKey(kid string) (*rsa.PublicKey, error) {
+	c.cache.mu.RLock()
+	key, ok := c.cache.keys[kid]
+	fresh := time.Now().Before(c.cache.expiresAt)
+	c.cache.mu.RUnlock()
+
+	if ok && fresh {
+		return key, nil
+	}
+
+	// Cache miss or stale — refresh. fetchKeys uses double-checked locking
+	// so concurrent calls only result in one actual HTTP request.
+	if err := c.fetchKeys(); err != nil {
+		return nil, err
+	}
+
+	c.cache.mu.RLock()
+	defer c.cache.mu.RUnlock()
+	if key, ok := c.cache.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("public key with kid %s not found", kid)
+}
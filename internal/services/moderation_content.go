@@ -0,0 +1,66 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrContentNotFound    = errors.New("content not found")
+	ErrContentUnsupported = errors.New("content type not supported by this app")
+)
+
+// ContentSnapshot is a copy of reported content taken when the report is
+// filed.
+type ContentSnapshot struct {
+	AuthorID  *uuid.UUID             `json:"author_id,omitempty"`
+	Text      string                 `json:"text,omitempty"`
+	MediaURLs []string               `json:"media_urls,omitempty"`
+	CreatedAt *time.Time             `json:"created_at,omitempty"`
+	Extra     map[string]interface{} `json:"extra,omitempty"`
+}
+
+// ContentResolver is implemented by plugins with user-generated content that
+// can be reported. contentType is the report's content_type ("post" or
+// "comment"); reports on "user" are resolved by the core. Both methods return
+// ErrContentNotFound or ErrContentUnsupported when they can't act. Plugins
+// whose content only its author can see (daiyly, driftoff, moodpulse) don't
+// implement it; reports in those apps can only target users.
+type ContentResolver interface {
+	ResolveContent(db *gorm.DB, appID, contentType, contentID string) (*ContentSnapshot, error)
+	SetContentHidden(db *gorm.DB, appID, contentType, contentID string, hidden bool) error
+}
+
+// resolveUser snapshots a reported user profile.
+func resolveUser(db *gorm.DB, appID, contentID string) (*ContentSnapshot, error) {
+	id, err := uuid.Parse(contentID)
+	if err != nil {
+		return nil, ErrContentNotFound
+	}
+	var user models.User
+	if err := db.Scopes(tenant.ForTenant(appID)).First(&user, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrContentNotFound
+		}
+		return nil, err
+	}
+	created := user.CreatedAt
+	return &ContentSnapshot{AuthorID: &user.ID, CreatedAt: &created}, nil
+}
+
+// ExcludeShadowBanned is a GORM scope that drops rows whose authorColumn is a
+// shadow-banned user, except the viewer's own rows. Pass uuid.Nil for
+// anonymous viewers.
+func ExcludeShadowBanned(appID string, viewerID uuid.UUID, authorColumn string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(
+			"("+authorColumn+" = ? OR "+authorColumn+" NOT IN (SELECT id FROM users WHERE app_id = ? AND shadow_banned))",
+			viewerID, appID,
+		)
+	}
+}
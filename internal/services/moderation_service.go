@@ -1,141 +1,102 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"regexp"
-	"strings"
-	"sync"
+	"log/slog"
+	"time"
 
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/moderation"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
-	ErrReportNotFound = errors.New("report not found")
-	ErrAlreadyBlocked = errors.New("user already blocked")
-	ErrSelfBlock      = errors.New("cannot block yourself")
+	ErrReportNotFound  = errors.New("report not found")
+	ErrAlreadyReported = errors.New("you have already reported this content")
+	ErrAlreadyBlocked  = errors.New("user already blocked")
+	ErrSelfBlock       = errors.New("cannot block yourself")
 )
 
-var BannedWords = []string{
-	"fuck", "fucking", "fucker", "shit", "shitty", "bullshit",
-	"ass", "asshole", "bastard", "bitch", "cunt",
-	"nigger", "nigga", "chink", "spic", "kike", "faggot", "fag",
-	"retard", "retarded", "tranny",
-	"porn", "porno", "nude", "nudes",
-	"spam", "scam", "scammer", "phishing", "malware",
-}
-
 type ModerationService struct {
-	db                  *gorm.DB
-	bannedWordRegexps   []*regexp.Regexp
-	urlPattern          *regexp.Regexp
-	emailPattern        *regexp.Regexp
-	phonePattern        *regexp.Regexp
-	repeatedCharPattern *regexp.Regexp
-	allCapsPattern      *regexp.Regexp
-	compiled            bool
-	mu                  sync.RWMutex
-}
-
-func NewModerationService(db *gorm.DB) *ModerationService {
-	ms := &ModerationService{db: db}
-	ms.compilePatterns()
-	return ms
-}
-
-func (ms *ModerationService) compilePatterns() {
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
-	if ms.compiled {
-		return
-	}
+	db                *gorm.DB
+	bus               *events.Bus
+	moderator         *moderation.Moderator
+	resolvers         map[string]ContentResolver
+	bans              *BanService
+	autoHideThreshold int
+}
 
-	ms.bannedWordRegexps = make([]*regexp.Regexp, 0, len(BannedWords))
-	for _, word := range BannedWords {
-		pattern := `(?i)\b` + regexp.QuoteMeta(word) + `\b`
-		re, err := regexp.Compile(pattern)
-		if err == nil {
-			ms.bannedWordRegexps = append(ms.bannedWordRegexps, re)
-		}
+// NewModerationService builds the content pipeline from cfg. configSource
+// supplies per-app word lists from remote config and may be nil.
+func NewModerationService(db *gorm.DB, cfg *config.Config, bus *events.Bus, bans *BanService, configSource moderation.ConfigSource) *ModerationService {
+	return &ModerationService{
+		db:   db,
+		bus:  bus,
+		bans: bans,
+		moderator: moderation.NewDefault(moderation.Options{
+			ClassifierURL: cfg.ModerationClassifierURL,
+			ClassifierKey: cfg.ModerationClassifierKey,
+			Config:        configSource,
+		}),
+		resolvers:         make(map[string]ContentResolver),
+		autoHideThreshold: cfg.ModerationAutoHideThreshold,
 	}
-
-	ms.urlPattern = regexp.MustCompile(`(?i)(https?://\S+|www\.\S+\.\S+)`)
-	ms.emailPattern = regexp.MustCompile(`(?i)\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Z|a-z]{2,}\b`)
-	ms.phonePattern = regexp.MustCompile(`\d{3}[-.\s]?\d{3}[-.\s]?\d{4}|\(\d{3}\)\s*\d{3}[-.\s]?\d{4}`)
-	ms.repeatedCharPattern = regexp.MustCompile(`(?i)(a{4,}|b{4,}|c{4,}|d{4,}|e{4,}|f{4,}|g{4,}|h{4,}|i{4,}|j{4,}|k{4,}|l{4,}|m{4,}|n{4,}|o{4,}|p{4,}|q{4,}|r{4,}|s{4,}|t{4,}|u{4,}|v{4,}|w{4,}|x{4,}|y{4,}|z{4,}|!{4,}|\?{4,}|\.{4,})`)
-	ms.allCapsPattern = regexp.MustCompile(`[A-Z]{5,}`)
-	ms.compiled = true
 }
 
-func (ms *ModerationService) FilterContent(text string) (bool, string) {
-	ms.mu.RLock()
-	defer ms.mu.RUnlock()
-	if text == "" {
-		return true, ""
-	}
-	for _, re := range ms.bannedWordRegexps {
-		if re.MatchString(text) {
-			return false, "inappropriate_language"
-		}
-	}
-	if ms.urlPattern.MatchString(text) {
-		return false, "url_not_allowed"
-	}
-	if ms.emailPattern.MatchString(text) {
-		return false, "contact_info_not_allowed"
-	}
-	if ms.phonePattern.MatchString(text) {
-		return false, "contact_info_not_allowed"
-	}
-	if ms.repeatedCharPattern.MatchString(text) {
-		return false, "spam_detected"
-	}
-	capsMatches := ms.allCapsPattern.FindAllString(text, -1)
-	if len(capsMatches) > 2 {
-		return false, "excessive_caps"
-	}
-	return true, ""
+// SetContentResolvers registers the plugins that can snapshot and hide
+// reported content, keyed by app ID. Call it before serving requests.
+func (ms *ModerationService) SetContentResolvers(resolvers map[string]ContentResolver) {
+	ms.resolvers = resolvers
 }
 
-func (ms *ModerationService) ContainsProfanity(text string) bool {
-	ms.mu.RLock()
-	defer ms.mu.RUnlock()
-	for _, re := range ms.bannedWordRegexps {
-		if re.MatchString(text) {
-			return true
-		}
+// CheckContent is the single entry point for screening user-generated text
+// before it is shown to other users. locale may be empty.
+func (ms *ModerationService) CheckContent(ctx context.Context, appID, locale, text string) *moderation.Result {
+	res := ms.moderator.Check(ctx, moderation.Input{AppID: appID, Locale: locale, Text: text})
+	if !res.Allowed {
+		slog.Info("content rejected", "app_id", appID, "categories", res.Categories)
 	}
-	return false
+	return res
 }
 
-func (ms *ModerationService) GetRejectionMessage(reason string) string {
+// GetRejectionMessage returns user-facing copy for a blocking category.
+func (ms *ModerationService) GetRejectionMessage(category string) string {
 	messages := map[string]string{
-		"inappropriate_language":   "Your response contains inappropriate language.",
-		"url_not_allowed":          "URLs and web links are not allowed.",
-		"contact_info_not_allowed": "Contact information is not allowed.",
-		"spam_detected":            "Your response appears to be spam.",
-		"excessive_caps":           "Please avoid using excessive capital letters.",
+		moderation.CategoryProfanity:   "Your response contains inappropriate language.",
+		moderation.CategoryHate:        "Your response contains hateful language.",
+		moderation.CategorySexual:      "Sexual content is not allowed.",
+		moderation.CategoryHarassment:  "Please be kind to other people.",
+		moderation.CategoryURL:         "URLs and web links are not allowed.",
+		moderation.CategoryContactInfo: "Contact information is not allowed.",
+		moderation.CategorySpam:        "Your response appears to be spam.",
 	}
-	if msg, ok := messages[reason]; ok {
+	if msg, ok := messages[category]; ok {
 		return msg
 	}
 	return "Your response does not meet our content guidelines."
 }
 
+// CreateReport files a report and snapshots the reported content. Once enough
+// distinct users have reported the same content it is hidden until an admin
+// decides.
 func (s *ModerationService) CreateReport(appID string, reporterID uuid.UUID, req *dto.CreateReportRequest) (*models.Report, error) {
-	validTypes := map[string]bool{"user": true, "post": true, "comment": true}
-	if !validTypes[req.ContentType] {
-		return nil, errors.New("invalid content_type: must be user, post, or comment")
-	}
-	if strings.TrimSpace(req.Reason) == "" {
-		return nil, errors.New("reason is required")
+	var existing int64
+	if err := s.db.Model(&models.Report{}).Scopes(tenant.ForTenant(appID)).
+		Where("reporter_id = ? AND content_type = ? AND content_id = ? AND status = ?", reporterID, req.ContentType, req.ContentID, "pending").
+		Count(&existing).Error; err != nil {
+		return nil, fmt.Errorf("check existing report: %w", err)
 	}
-	if len(req.Reason) > 500 {
-		return nil, errors.New("reason must be 500 characters or fewer")
+	if existing > 0 {
+		return nil, ErrAlreadyReported
 	}
 
 	report := models.Report{
@@ -148,12 +109,99 @@ func (s *ModerationService) CreateReport(appID string, reporterID uuid.UUID, req
 		Status:      "pending",
 	}
 
-	if err := s.db.Create(&report).Error; err != nil {
+	// The content may already be gone; the report is still filed.
+	snapshot, err := s.resolveContent(appID, req.ContentType, req.ContentID)
+	if err != nil {
+		slog.Warn("report snapshot failed", "app_id", appID, "content_type", req.ContentType, "content_id", req.ContentID, "error", err)
+	} else if snapshot != nil {
+		report.AuthorID = snapshot.AuthorID
+		if raw, err := json.Marshal(snapshot); err == nil {
+			report.Snapshot = raw
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&report).Error; err != nil {
+			return err
+		}
+		return s.bus.Publish(tx, appID, reporterID, events.ReportFiled, events.ReportFiledPayload{
+			ReportID:    report.ID,
+			ContentType: report.ContentType,
+			ContentID:   report.ContentID,
+			Reason:      report.Reason,
+		})
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to create report: %w", err)
 	}
+
+	s.maybeAutoHide(appID, report.ContentType, report.ContentID)
 	return &report, nil
 }
 
+// resolveContent snapshots reported content. Apps without a ContentResolver
+// keep only their author-private content, so reports there are filed without
+// a snapshot.
+func (s *ModerationService) resolveContent(appID, contentType, contentID string) (*ContentSnapshot, error) {
+	if contentType == "user" {
+		return resolveUser(s.db, appID, contentID)
+	}
+	r, ok := s.resolvers[appID]
+	if !ok {
+		return nil, nil
+	}
+	return r.ResolveContent(s.db, appID, contentType, contentID)
+}
+
+// maybeAutoHide hides content once autoHideThreshold distinct users have
+// pending reports on it. Reports from shadow-banned users don't count.
+func (s *ModerationService) maybeAutoHide(appID, contentType, contentID string) {
+	r, ok := s.resolvers[appID]
+	if s.autoHideThreshold <= 0 || contentType == "user" || !ok {
+		return
+	}
+
+	var reporters int64
+	if err := s.db.Model(&models.Report{}).Scopes(tenant.ForTenant(appID)).
+		Where("content_type = ? AND content_id = ? AND status = ?", contentType, contentID, "pending").
+		Where("reporter_id NOT IN (SELECT id FROM users WHERE app_id = ? AND shadow_banned)", appID).
+		Distinct("reporter_id").
+		Count(&reporters).Error; err != nil {
+		slog.Error("auto-hide count failed", "app_id", appID, "content_id", contentID, "error", err)
+		return
+	}
+	if reporters < int64(s.autoHideThreshold) {
+		return
+	}
+
+	var already int64
+	s.db.Model(&models.ModerationAction{}).Scopes(tenant.ForTenant(appID)).
+		Where("action = ? AND content_type = ? AND content_id = ?", "auto_hide", contentType, contentID).
+		Count(&already)
+	if already > 0 {
+		return
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.SetContentHidden(tx, appID, contentType, contentID, true); err != nil {
+			return err
+		}
+		return tx.Create(&models.ModerationAction{
+			AppID:       appID,
+			Action:      "auto_hide",
+			ContentType: contentType,
+			ContentID:   contentID,
+			ActorType:   "system",
+			Note:        fmt.Sprintf("reported by %d users", reporters),
+		}).Error
+	})
+	if err != nil {
+		slog.Error("auto-hide failed", "app_id", appID, "content_type", contentType, "content_id", contentID, "error", err)
+		return
+	}
+	slog.Info("content auto-hidden", "app_id", appID, "content_type", contentType, "content_id", contentID, "reporters", reporters)
+}
+
 func (s *ModerationService) ListReports(appID string, status string, limit, offset int) ([]models.Report, int64, error) {
 	var reports []models.Report
 	var total int64
@@ -172,26 +220,191 @@ func (s *ModerationService) ListReports(appID string, status string, limit, offs
 	return reports, total, nil
 }
 
-func (s *ModerationService) ActionReport(appID string, reportID uuid.UUID, req *dto.ActionReportRequest) error {
-	validStatuses := map[string]bool{"reviewed": true, "actioned": true, "dismissed": true}
-	if !validStatuses[req.Status] {
-		return errors.New("invalid status: must be reviewed, actioned, or dismissed")
+// ListMyReports returns the reporter's own reports so they can see what
+// became of them.
+func (s *ModerationService) ListMyReports(appID string, reporterID uuid.UUID, limit, offset int) ([]dto.MyReportResponse, int64, error) {
+	var reports []models.Report
+	var total int64
+
+	query := s.db.Model(&models.Report{}).Scopes(tenant.ForTenant(appID)).Where("reporter_id = ?", reporterID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("count reports: %w", err)
+	}
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&reports).Error; err != nil {
+		return nil, 0, fmt.Errorf("list reports: %w", err)
+	}
+
+	out := make([]dto.MyReportResponse, len(reports))
+	for i, r := range reports {
+		out[i] = dto.MyReportResponse{
+			ID:          r.ID,
+			ContentType: r.ContentType,
+			ContentID:   r.ContentID,
+			Reason:      r.Reason,
+			Status:      r.Status,
+			CreatedAt:   r.CreatedAt,
+			ResolvedAt:  r.ResolvedAt,
+		}
+	}
+	return out, total, nil
+}
+
+// Moderation actions an admin can apply when marking a report "actioned".
+const (
+	ActionHideContent = "hide_content"
+	ActionSuspendUser = "suspend_user"
+	ActionShadowBan   = "shadow_ban"
+)
+
+const defaultSuspendDays = 7
+
+// ActionReport records an admin decision. The status applies to every
+// pending report on the same content, so all reporters see the outcome.
+// With status "actioned", req.Actions are applied to the content and its
+// author. Everything is audit-logged under actor.
+func (s *ModerationService) ActionReport(appID string, reportID uuid.UUID, req *dto.ActionReportRequest, actor tenant.AdminActor) (*models.Report, error) {
+	if len(req.Actions) > 0 && req.Status != "actioned" {
+		return nil, errors.New("actions require status actioned")
 	}
 
-	result := s.db.Model(&models.Report{}).
-		Scopes(tenant.ForTenant(appID)).
-		Where("id = ?", reportID).
-		Updates(map[string]interface{}{
-			"status":     req.Status,
-			"admin_note": req.AdminNote,
-		})
-	if result.Error != nil {
-		return fmt.Errorf("update report: %w", result.Error)
+	var report models.Report
+	var issued []*models.UserBan
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(tenant.ForTenant(appID)).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&report, "id = ?", reportID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrReportNotFound
+			}
+			return err
+		}
+
+		now := time.Now()
+		report.Status = req.Status
+		report.AdminNote = req.AdminNote
+		report.ResolvedAt = &now
+		if err := tx.Model(&report).Select("status", "admin_note", "resolved_at").Updates(&report).Error; err != nil {
+			return fmt.Errorf("update report: %w", err)
+		}
+		if req.Status != "reviewed" {
+			if err := tx.Model(&models.Report{}).Scopes(tenant.ForTenant(appID)).
+				Where("content_type = ? AND content_id = ? AND status = ? AND id <> ?", report.ContentType, report.ContentID, "pending", report.ID).
+				Updates(map[string]interface{}{"status": req.Status, "resolved_at": &now}).Error; err != nil {
+				return fmt.Errorf("resolve related reports: %w", err)
+			}
+		}
+
+		audit := func(action string, target *uuid.UUID, note string) error {
+			return tx.Create(&models.ModerationAction{
+				AppID:        appID,
+				ReportID:     &report.ID,
+				Action:       action,
+				ContentType:  report.ContentType,
+				ContentID:    report.ContentID,
+				TargetUserID: target,
+				ActorType:    actor.Type,
+				ActorID:      actor.ID,
+				ActorEmail:   actor.Email,
+				Note:         note,
+			}).Error
+		}
+		decision := map[string]string{"reviewed": "review", "actioned": "action", "dismissed": "dismiss"}[req.Status]
+		if err := audit(decision, report.AuthorID, req.AdminNote); err != nil {
+			return err
+		}
+
+		for _, a := range req.Actions {
+			target, note, ban, err := s.applyAction(tx, appID, &report, a, req.SuspendDays, actor)
+			if err != nil {
+				return err
+			}
+			if ban != nil {
+				issued = append(issued, ban)
+			}
+			if err := audit(a, target, note); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, ban := range issued {
+		s.bans.Deny(ban)
+	}
+	return &report, nil
+}
+
+// applyAction performs one moderation action for report and returns the
+// affected user, an audit note and, for suspensions, the ban to add to the
+// denylist once tx commits.
+func (s *ModerationService) applyAction(tx *gorm.DB, appID string, report *models.Report, action string, suspendDays int, actor tenant.AdminActor) (*uuid.UUID, string, *models.UserBan, error) {
+	if action == ActionHideContent {
+		if report.ContentType == "user" {
+			return nil, "", nil, errors.New("hide_content does not apply to user reports; suspend or shadow-ban instead")
+		}
+		r, ok := s.resolvers[appID]
+		if !ok {
+			return nil, "", nil, ErrContentUnsupported
+		}
+		if err := r.SetContentHidden(tx, appID, report.ContentType, report.ContentID, true); err != nil {
+			return nil, "", nil, fmt.Errorf("hide content: %w", err)
+		}
+		return report.AuthorID, "", nil, nil
+	}
+
+	target := report.AuthorID
+	if report.ContentType == "user" {
+		if id, err := uuid.Parse(report.ContentID); err == nil {
+			target = &id
+		}
+	}
+	if target == nil {
+		return nil, "", nil, fmt.Errorf("%s: the reported content has no known author", action)
+	}
+
+	switch action {
+	case ActionSuspendUser:
+		if suspendDays == 0 {
+			suspendDays = defaultSuspendDays
+		}
+		until := time.Now().AddDate(0, 0, suspendDays)
+		ban, err := createBan(tx, appID, *target, "moderation report: "+report.Reason, &until, &report.ID, actor)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("suspend user: %w", err)
+		}
+		return target, fmt.Sprintf("suspended until %s", until.UTC().Format(time.RFC3339)), ban, nil
+	case ActionShadowBan:
+		if err := tx.Model(&models.User{}).Scopes(tenant.ForTenant(appID)).
+			Where("id = ?", *target).Update("shadow_banned", true).Error; err != nil {
+			return nil, "", nil, fmt.Errorf("shadow-ban user: %w", err)
+		}
+		return target, "", nil, nil
+	}
+	return nil, "", nil, fmt.Errorf("invalid action %q", action)
+}
+
+// ListActions returns the moderation audit log, newest first, optionally
+// filtered to one content item or target user.
+func (s *ModerationService) ListActions(appID, contentID string, targetUserID *uuid.UUID, limit, offset int) ([]models.ModerationAction, int64, error) {
+	var actions []models.ModerationAction
+	var total int64
+
+	query := s.db.Model(&models.ModerationAction{}).Scopes(tenant.ForTenant(appID))
+	if contentID != "" {
+		query = query.Where("content_id = ?", contentID)
+	}
+	if targetUserID != nil {
+		query = query.Where("target_user_id = ?", *targetUserID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("count moderation actions: %w", err)
 	}
-	if result.RowsAffected == 0 {
-		return ErrReportNotFound
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&actions).Error; err != nil {
+		return nil, 0, fmt.Errorf("list moderation actions: %w", err)
 	}
-	return nil
+	return actions, total, nil
 }
 
 func (s *ModerationService) BlockUser(appID string, blockerID, blockedID uuid.UUID) error {
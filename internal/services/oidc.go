@@ -0,0 +1,262 @@
+package services
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Identity provider names. Additional OpenID Connect issuers are configured
+// by name with OIDC_ISSUERS.
+const (
+	ProviderApple  = "apple"
+	ProviderGoogle = "google"
+)
+
+var ErrUnknownProvider = errors.New("unknown identity provider")
+
+// oidcClockSkew is the clock difference tolerated on exp and iat.
+const oidcClockSkew = 5 * time.Second
+
+// Audience is the aud claim, which OIDC allows to be a string or an array.
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = Audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// IDTokenClaims are the ID token claims we use.
+type IDTokenClaims struct {
+	Iss           string      `json:"iss"`
+	Sub           string      `json:"sub"`
+	Aud           Audience    `json:"aud"`
+	Iat           int64       `json:"iat"`
+	Exp           int64       `json:"exp"`
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"` // Apple sends "true", others a bool
+	Nonce         string      `json:"nonce"`
+}
+
+// VerifiedEmail returns the lower-cased email when the provider vouches for it.
+func (c *IDTokenClaims) VerifiedEmail() string {
+	switch v := c.EmailVerified.(type) {
+	case bool:
+		if !v {
+			return ""
+		}
+	case string:
+		if v != "true" {
+			return ""
+		}
+	default:
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(c.Email))
+}
+
+// OIDCProvider verifies ID tokens from one OpenID Connect issuer.
+type OIDCProvider struct {
+	Name string
+	// Issuers lists the accepted iss values (Google uses two).
+	Issuers []string
+	// MaxAge rejects tokens issued longer ago than this; zero only checks exp.
+	MaxAge time.Duration
+	// HashedNonce means the token carries sha256(nonce) in hex, as Apple's
+	// does, rather than the raw nonce.
+	HashedNonce bool
+
+	discoveryURL string
+	jwksMu       sync.Mutex
+	jwks         *JWKSClient
+	httpClient   *http.Client
+}
+
+// NewAppleProvider verifies Sign in with Apple identity tokens. Apple tokens
+// expire after 10 minutes; rejecting anything older limits the replay window
+// for intercepted tokens.
+func NewAppleProvider() *OIDCProvider {
+	return &OIDCProvider{
+		Name:        ProviderApple,
+		Issuers:     []string{"https://appleid.apple.com"},
+		MaxAge:      10 * time.Minute,
+		HashedNonce: true,
+		jwks:        NewJWKSClient("https://appleid.apple.com/auth/keys"),
+	}
+}
+
+// NewGoogleProvider verifies Google Sign-In ID tokens.
+func NewGoogleProvider() *OIDCProvider {
+	return &OIDCProvider{
+		Name:    ProviderGoogle,
+		Issuers: []string{"https://accounts.google.com", "accounts.google.com"},
+		jwks:    NewJWKSClient("https://www.googleapis.com/oauth2/v3/certs"),
+	}
+}
+
+// NewOIDCProvider verifies tokens from any OpenID Connect issuer. The JWKS
+// URL is read from the issuer's discovery document on first use.
+func NewOIDCProvider(name, issuer string) *OIDCProvider {
+	issuer = strings.TrimSuffix(issuer, "/")
+	return &OIDCProvider{
+		Name:         name,
+		Issuers:      []string{issuer},
+		discoveryURL: issuer + "/.well-known/openid-configuration",
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// keys returns the provider's JWKS client, running discovery if needed.
+func (p *OIDCProvider) keys() (*JWKSClient, error) {
+	p.jwksMu.Lock()
+	defer p.jwksMu.Unlock()
+	if p.jwks != nil {
+		return p.jwks, nil
+	}
+
+	resp, err := p.httpClient.Get(p.discoveryURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetch discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery endpoint returned status %d", resp.StatusCode)
+	}
+	var doc struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode discovery document: %w", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != p.Issuers[0] || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document does not match issuer %s", p.Issuers[0])
+	}
+	p.jwks = NewJWKSClient(doc.JWKSURI)
+	return p.jwks, nil
+}
+
+// Verify checks an ID token's signature, issuer, audience, lifetime and
+// nonce. audiences are the app's client IDs; nonce is the raw nonce the
+// client generated, or empty if it used none.
+func (p *OIDCProvider) Verify(idToken string, audiences []string, nonce string) (*IDTokenClaims, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid token format")
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode header: %w", err)
+	}
+	var header JWTHeader
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported algorithm: %s", header.Alg)
+	}
+
+	jwks, err := p.keys()
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := jwks.GetPublicKey(header.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key: %w", err)
+	}
+	signatureBytes, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signature: %w", err)
+	}
+	hashed := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(pubKey, crypto.SHA256, hashed[:], signatureBytes); err != nil {
+		return nil, fmt.Errorf("signature verification failed: %w", err)
+	}
+
+	claimsBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode claims: %w", err)
+	}
+	var claims IDTokenClaims
+	if err := json.Unmarshal(claimsBytes, &claims); err != nil {
+		return nil, fmt.Errorf("failed to parse claims: %w", err)
+	}
+
+	if !containsString(p.Issuers, claims.Iss) {
+		return nil, fmt.Errorf("invalid issuer: %s", claims.Iss)
+	}
+	if !audienceMatches(claims.Aud, audiences) {
+		return nil, fmt.Errorf("invalid audience: %v", []string(claims.Aud))
+	}
+	if claims.Sub == "" {
+		return nil, fmt.Errorf("missing subject")
+	}
+
+	now := time.Now()
+	if now.Add(-oidcClockSkew).Unix() > claims.Exp {
+		return nil, fmt.Errorf("token expired")
+	}
+	// A stale iat with a still-valid exp would indicate a replayed token.
+	iatTime := time.Unix(claims.Iat, 0)
+	if p.MaxAge > 0 && now.Sub(iatTime) > p.MaxAge+oidcClockSkew {
+		return nil, fmt.Errorf("token issued too long ago (iat=%d)", claims.Iat)
+	}
+	if iatTime.After(now.Add(oidcClockSkew)) {
+		return nil, fmt.Errorf("token iat is in the future (iat=%d)", claims.Iat)
+	}
+
+	// Nonce validation: if the token carries a nonce, the caller must prove they
+	// know the original raw nonce. This prevents identity token replay attacks
+	// within the expiry window.
+	if claims.Nonce != "" {
+		if nonce == "" {
+			return nil, fmt.Errorf("token requires nonce verification but none was provided")
+		}
+		expected := nonce
+		if p.HashedNonce {
+			expected = fmt.Sprintf("%x", sha256.Sum256([]byte(nonce)))
+		}
+		if expected != claims.Nonce {
+			return nil, fmt.Errorf("nonce mismatch")
+		}
+	}
+
+	return &claims, nil
+}
+
+func audienceMatches(aud Audience, allowed []string) bool {
+	for _, a := range aud {
+		if containsString(allowed, a) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,608 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrWebhookNotFound     = errors.New("webhook endpoint not found")
+	ErrInvalidWebhookURL   = errors.New("url must be an absolute https URL")
+	ErrInvalidWebhookEvent = errors.New("unknown event type")
+	ErrInvalidSignature    = errors.New("invalid webhook signature")
+	// ErrWebhookAddressBlocked fails deliveries to endpoints that resolve
+	// to loopback, private or other non-public addresses.
+	ErrWebhookAddressBlocked = errors.New("webhook endpoint address is not public")
+)
+
+// WebhookEventTypes are the domain events that can be forwarded to outbound
+// webhooks. WebhookTestEvent is only sent by the test-fire endpoint.
+var WebhookEventTypes = []string{
+	events.SubscriptionChanged,
+	events.ReportFiled,
+	events.MoodCrisisFlagged,
+	events.AchievementUnlocked,
+	events.UserDeleted,
+	events.GuestClaimed,
+}
+
+const WebhookTestEvent = "webhook.test"
+
+// Signature scheme
+//
+// Every request carries
+//
+//	X-Webhook-Id:        <event id, stable across retries>
+//	X-Webhook-Signature: t=<unix seconds>,v1=<hex HMAC-SHA256>
+//
+// where the HMAC is keyed with the endpoint secret over "<t>.<raw body>".
+// Receivers recompute it, compare in constant time, and reject timestamps
+// older than a few minutes to stop replays. VerifyWebhookSignature does
+// exactly that.
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookIDHeader        = "X-Webhook-Id"
+)
+
+const (
+	webhookStatusPending   = "pending"
+	webhookStatusSucceeded = "succeeded"
+	webhookStatusFailed    = "failed"
+
+	webhookMaxAttempts  = 10
+	webhookBatch        = 50
+	webhookLease        = 2 * time.Minute
+	webhookTimeout      = 10 * time.Second
+	webhookMaxLoggedLen = 1024
+	webhookRetention    = 30 * 24 * time.Hour
+)
+
+// WebhookPayload is the JSON body sent to endpoints.
+type WebhookPayload struct {
+	ID         uuid.UUID       `json:"id"`
+	Type       string          `json:"type"`
+	AppID      string          `json:"app_id"`
+	UserID     *uuid.UUID      `json:"user_id,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature value for body sent at
+// ts.
+func SignWebhookPayload(secret string, ts time.Time, body []byte) string {
+	t := strconv.FormatInt(ts.Unix(), 10)
+	return "t=" + t + ",v1=" + webhookHMAC(secret, t, body)
+}
+
+func webhookHMAC(secret, t string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks a signature header against body. Signatures
+// older than tolerance are rejected.
+func VerifyWebhookSignature(secret, header string, body []byte, tolerance time.Duration) error {
+	var t, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			t = v
+		case "v1":
+			sig = v
+		}
+	}
+	if t == "" || sig == "" {
+		return ErrInvalidSignature
+	}
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(sig), []byte(webhookHMAC(secret, t, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// OutboundWebhookService manages per-app webhook endpoints and delivers
+// domain events to them with retries.
+type OutboundWebhookService struct {
+	db     *gorm.DB
+	client *http.Client
+}
+
+func NewOutboundWebhookService(db *gorm.DB) *OutboundWebhookService {
+	return &OutboundWebhookService{
+		db:     db,
+		client: newWebhookClient(),
+	}
+}
+
+// newWebhookClient returns the delivery client. Endpoint URLs are chosen by
+// app admins, so it only connects to public addresses, checked after DNS
+// resolution so a hostname can't point it at the internal network, and it
+// doesn't follow redirects; a 3xx is a failed delivery.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil || !publicAddr(ip) {
+				return fmt.Errorf("%w: %s", ErrWebhookAddressBlocked, host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// nonPublicPrefixes are the ranges publicAddr rejects beyond what netip
+// classifies: shared address space, IETF protocol assignments, benchmarking,
+// reserved and documentation blocks, NAT64 and 6to4.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// publicAddr reports whether ip is a globally routable unicast address:
+// not loopback, private, link-local (which includes the 169.254.169.254
+// metadata service), multicast or otherwise reserved.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetHTTPClient replaces the client used for deliveries, e.g. to trust an
+// in-process test receiver. The replacement skips the public-address and
+// redirect checks of the default client.
+func (s *OutboundWebhookService) SetHTTPClient(client *http.Client) {
+	s.client = client
+}
+
+// Subscribe queues a fan-out for every forwardable event type.
+func (s *OutboundWebhookService) Subscribe(bus *events.Bus) {
+	for _, t := range WebhookEventTypes {
+		bus.SubscribeAsync(t, "webhooks.fanout", s.fanOut)
+	}
+}
+
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+func validateWebhookEvents(types []string) error {
+	for _, t := range types {
+		known := false
+		for _, k := range WebhookEventTypes {
+			if t == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("%w: %s", ErrInvalidWebhookEvent, t)
+		}
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// CreateEndpoint registers an endpoint and returns it with its secret, which
+// is not retrievable afterwards.
+func (s *OutboundWebhookService) CreateEndpoint(appID string, req dto.CreateWebhookEndpointRequest) (*models.WebhookEndpoint, string, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, "", err
+	}
+	if err := validateWebhookEvents(req.Events); err != nil {
+		return nil, "", err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", fmt.Errorf("generate secret: %w", err)
+	}
+	if req.Events == nil {
+		req.Events = []string{}
+	}
+	ep := models.WebhookEndpoint{
+		AppID:       appID,
+		URL:         strings.TrimSpace(req.URL),
+		Description: req.Description,
+		Events:      req.Events,
+		Secret:      secret,
+		Active:      true,
+	}
+	if err := s.db.Create(&ep).Error; err != nil {
+		return nil, "", err
+	}
+	return &ep, secret, nil
+}
+
+func (s *OutboundWebhookService) ListEndpoints(appID string) ([]models.WebhookEndpoint, error) {
+	var eps []models.WebhookEndpoint
+	err := s.db.Scopes(tenant.ForTenant(appID)).Order("created_at ASC").Find(&eps).Error
+	return eps, err
+}
+
+func (s *OutboundWebhookService) endpoint(appID string, id uuid.UUID) (*models.WebhookEndpoint, error) {
+	var ep models.WebhookEndpoint
+	if err := s.db.Scopes(tenant.ForTenant(appID)).First(&ep, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &ep, nil
+}
+
+func (s *OutboundWebhookService) UpdateEndpoint(appID string, id uuid.UUID, req dto.UpdateWebhookEndpointRequest) (*models.WebhookEndpoint, error) {
+	ep, err := s.endpoint(appID, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return nil, err
+		}
+		ep.URL = strings.TrimSpace(*req.URL)
+	}
+	if req.Events != nil {
+		if err := validateWebhookEvents(*req.Events); err != nil {
+			return nil, err
+		}
+		ep.Events = *req.Events
+		if ep.Events == nil {
+			ep.Events = []string{}
+		}
+	}
+	if req.Description != nil {
+		ep.Description = *req.Description
+	}
+	if req.Active != nil {
+		ep.Active = *req.Active
+	}
+	if err := s.db.Save(ep).Error; err != nil {
+		return nil, err
+	}
+	return ep, nil
+}
+
+// DeleteEndpoint removes the endpoint and its delivery log.
+func (s *OutboundWebhookService) DeleteEndpoint(appID string, id uuid.UUID) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Scopes(tenant.ForTenant(appID)).Delete(&models.WebhookEndpoint{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrWebhookNotFound
+		}
+		return tx.Where("endpoint_id = ?", id).Delete(&models.WebhookDelivery{}).Error
+	})
+}
+
+// ListDeliveries returns an endpoint's delivery log, newest first.
+func (s *OutboundWebhookService) ListDeliveries(appID string, id uuid.UUID, limit, offset int) ([]models.WebhookDelivery, int64, error) {
+	if _, err := s.endpoint(appID, id); err != nil {
+		return nil, 0, err
+	}
+	q := s.db.Model(&models.WebhookDelivery{}).Where("endpoint_id = ?", id)
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var deliveries []models.WebhookDelivery
+	err := q.Order("created_at DESC").Limit(limit).Offset(offset).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// TestFire sends a webhook.test event to the endpoint right away and returns
+// the resulting delivery. Failed test deliveries are not retried.
+func (s *OutboundWebhookService) TestFire(ctx context.Context, appID string, id uuid.UUID) (*models.WebhookDelivery, error) {
+	ep, err := s.endpoint(appID, id)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(WebhookPayload{
+		ID:         uuid.New(),
+		Type:       WebhookTestEvent,
+		AppID:      appID,
+		OccurredAt: time.Now(),
+		Data:       json.RawMessage(`{"message":"This is a test webhook."}`),
+	})
+	if err != nil {
+		return nil, err
+	}
+	d := models.WebhookDelivery{
+		AppID:         appID,
+		EndpointID:    ep.ID,
+		EventID:       uuid.New(),
+		EventType:     WebhookTestEvent,
+		Payload:       payload,
+		Status:        webhookStatusPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := s.db.Create(&d).Error; err != nil {
+		return nil, err
+	}
+	s.attempt(ctx, ep, &d, true)
+	return &d, nil
+}
+
+// fanOut creates a pending delivery for each of the app's active endpoints
+// subscribed to the event.
+func (s *OutboundWebhookService) fanOut(db *gorm.DB, ev events.Event) error {
+	var eps []models.WebhookEndpoint
+	if err := db.Scopes(tenant.ForTenant(ev.AppID)).Where("active = ?", true).Find(&eps).Error; err != nil {
+		return err
+	}
+	var userID *uuid.UUID
+	if ev.UserID != uuid.Nil {
+		userID = &ev.UserID
+	}
+	payload, err := json.Marshal(WebhookPayload{
+		ID:         ev.ID,
+		Type:       ev.Type,
+		AppID:      ev.AppID,
+		UserID:     userID,
+		OccurredAt: ev.OccurredAt,
+		Data:       ev.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	var deliveries []models.WebhookDelivery
+	for _, ep := range eps {
+		if !endpointWants(&ep, ev.Type) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			AppID:         ev.AppID,
+			EndpointID:    ep.ID,
+			EventID:       ev.ID,
+			EventType:     ev.Type,
+			Payload:       payload,
+			Status:        webhookStatusPending,
+			NextAttemptAt: time.Now(),
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+func endpointWants(ep *models.WebhookEndpoint, eventType string) bool {
+	if len(ep.Events) == 0 {
+		return true
+	}
+	for _, t := range ep.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// StartDispatcher delivers due webhooks every few seconds and prunes the
+// delivery log daily.
+func (s *OutboundWebhookService) StartDispatcher(done chan struct{}) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("panic in webhook dispatcher", "recover", r)
+			}
+		}()
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		var lastPrune time.Time
+		for {
+			select {
+			case <-ticker.C:
+				s.dispatchBatch()
+				if time.Since(lastPrune) > 24*time.Hour {
+					lastPrune = time.Now()
+					s.prune()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+func (s *OutboundWebhookService) dispatchBatch() {
+	var deliveries []models.WebhookDelivery
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", webhookStatusPending, time.Now()).
+			Order("next_attempt_at ASC").
+			Limit(webhookBatch).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		ids := make([]uuid.UUID, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(webhookLease)).Error
+	})
+	if err != nil {
+		slog.Error("webhook claim failed", "error", err)
+		return
+	}
+
+	endpoints := make(map[uuid.UUID]*models.WebhookEndpoint)
+	for i := range deliveries {
+		d := &deliveries[i]
+		ep, ok := endpoints[d.EndpointID]
+		if !ok {
+			var loaded models.WebhookEndpoint
+			if err := s.db.First(&loaded, "id = ?", d.EndpointID).Error; err != nil {
+				ep = nil
+			} else {
+				ep = &loaded
+			}
+			endpoints[d.EndpointID] = ep
+		}
+		if ep == nil || !ep.Active {
+			s.db.Model(d).Updates(map[string]interface{}{
+				"status":     webhookStatusFailed,
+				"last_error": "endpoint deleted or disabled",
+			})
+			continue
+		}
+		s.attempt(context.Background(), ep, d, false)
+	}
+}
+
+// attempt POSTs the delivery and records the outcome. With final set, a
+// failure is not retried.
+func (s *OutboundWebhookService) attempt(ctx context.Context, ep *models.WebhookEndpoint, d *models.WebhookDelivery, final bool) {
+	start := time.Now()
+	status, body, err := s.post(ctx, ep, d)
+	d.Attempts++
+	d.DurationMs = time.Since(start).Milliseconds()
+	d.ResponseStatus = status
+	d.ResponseBody = body
+
+	switch {
+	case err == nil:
+		now := time.Now()
+		d.Status = webhookStatusSucceeded
+		d.DeliveredAt = &now
+		d.LastError = ""
+	case final || d.Attempts >= webhookMaxAttempts:
+		d.Status = webhookStatusFailed
+		d.LastError = err.Error()
+		slog.Warn("webhook delivery failed", "app_id", d.AppID, "endpoint_id", ep.ID, "event", d.EventType, "attempts", d.Attempts, "error", err)
+	default:
+		d.NextAttemptAt = time.Now().Add(webhookBackoff(d.Attempts))
+		d.LastError = err.Error()
+	}
+	if err := s.db.Save(d).Error; err != nil {
+		slog.Error("webhook delivery update failed", "id", d.ID, "error", err)
+	}
+}
+
+func (s *OutboundWebhookService) post(ctx context.Context, ep *models.WebhookEndpoint, d *models.WebhookDelivery) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "unified-backend-webhooks/1")
+	req.Header.Set(WebhookIDHeader, d.EventID.String())
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(ep.Secret, time.Now(), d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxLoggedLen))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(raw), fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(raw), nil
+}
+
+// webhookBackoff doubles from 30s per attempt, capped at six hours.
+func webhookBackoff(attempts int) time.Duration {
+	d := 30 * time.Second << (attempts - 1)
+	if d > 6*time.Hour || d <= 0 {
+		return 6 * time.Hour
+	}
+	return d
+}
+
+func (s *OutboundWebhookService) prune() {
+	cutoff := time.Now().Add(-webhookRetention)
+	result := s.db.Where("status <> ? AND created_at < ?", webhookStatusPending, cutoff).Delete(&models.WebhookDelivery{})
+	if result.Error != nil {
+		slog.Error("webhook delivery prune failed", "error", result.Error)
+	} else if result.RowsAffected > 0 {
+		slog.Info("webhook delivery prune completed", "deleted", result.RowsAffected)
+	}
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func testDelivery() (*models.WebhookEndpoint, *models.WebhookDelivery) {
+	ep := &models.WebhookEndpoint{ID: uuid.New(), Secret: "whsec_test"}
+	d := &models.WebhookDelivery{
+		EndpointID: ep.ID,
+		EventID:    uuid.New(),
+		EventType:  WebhookTestEvent,
+		Payload:    []byte(`{"type":"webhook.test"}`),
+	}
+	return ep, d
+}
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.5", false},
+		{"172.16.3.4", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00:ec2::254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"198.18.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.1.2.3", false},
+		{"64:ff9b::a9fe:a9fe", false},
+	}
+	for _, tt := range tests {
+		if got := publicAddr(netip.MustParseAddr(tt.addr)); got != tt.public {
+			t.Errorf("publicAddr(%s) = %v, want %v", tt.addr, got, tt.public)
+		}
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"https://hooks.example.com/in", true},
+		{"  https://hooks.example.com/in  ", true},
+		{"http://hooks.example.com/in", false},
+		{"https://", false},
+		{"/relative", false},
+		{"not a url", false},
+	}
+	for _, tt := range tests {
+		err := validateWebhookURL(tt.url)
+		if (err == nil) != tt.ok {
+			t.Errorf("validateWebhookURL(%q) = %v, want ok=%v", tt.url, err, tt.ok)
+		}
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	now := time.Now()
+	header := SignWebhookPayload("secret", now, body)
+
+	if err := VerifyWebhookSignature("secret", header, body, time.Minute); err != nil {
+		t.Fatalf("fresh signature rejected: %v", err)
+	}
+	for name, check := range map[string]func() error{
+		"wrong secret": func() error { return VerifyWebhookSignature("other", header, body, time.Minute) },
+		"tampered body": func() error {
+			return VerifyWebhookSignature("secret", header, []byte(`{"id":"2"}`), time.Minute)
+		},
+		"stale": func() error {
+			old := SignWebhookPayload("secret", now.Add(-10*time.Minute), body)
+			return VerifyWebhookSignature("secret", old, body, time.Minute)
+		},
+		"malformed": func() error { return VerifyWebhookSignature("secret", "v1=abc", body, time.Minute) },
+	} {
+		if err := check(); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: err = %v, want ErrInvalidSignature", name, err)
+		}
+	}
+}
+
+func TestWebhookPostDeliversSignedPayload(t *testing.T) {
+	ep, d := testDelivery()
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan received, 1)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{r.Header.Clone(), body}
+		w.Write([]byte(strings.Repeat("x", 2*webhookMaxLoggedLen)))
+	}))
+	defer srv.Close()
+	ep.URL = srv.URL
+
+	s := &OutboundWebhookService{}
+	s.SetHTTPClient(srv.Client())
+	status, body, err := s.post(context.Background(), ep, d)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("post = %d, %v; want 200", status, err)
+	}
+	if len(body) != webhookMaxLoggedLen {
+		t.Errorf("logged response is %d bytes, want it cut to %d", len(body), webhookMaxLoggedLen)
+	}
+
+	r := <-got
+	if string(r.body) != string(d.Payload) {
+		t.Errorf("body = %s, want %s", r.body, d.Payload)
+	}
+	if r.header.Get(WebhookIDHeader) != d.EventID.String() {
+		t.Errorf("%s = %q, want the event ID", WebhookIDHeader, r.header.Get(WebhookIDHeader))
+	}
+	if err := VerifyWebhookSignature(ep.Secret, r.header.Get(WebhookSignatureHeader), r.body, time.Minute); err != nil {
+		t.Errorf("receiver can't verify signature: %v", err)
+	}
+}
+
+func TestWebhookPostFailsOnErrorStatus(t *testing.T) {
+	ep, d := testDelivery()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	ep.URL = srv.URL
+
+	s := &OutboundWebhookService{}
+	s.SetHTTPClient(srv.Client())
+	status, body, err := s.post(context.Background(), ep, d)
+	if err == nil || status != http.StatusServiceUnavailable {
+		t.Fatalf("post = %d, %v; want a 503 failure", status, err)
+	}
+	if !strings.Contains(body, "down for maintenance") {
+		t.Errorf("logged response = %q, want the receiver's body", body)
+	}
+}
+
+func TestWebhookClientBlocksNonPublicAddresses(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	s := &OutboundWebhookService{client: newWebhookClient()}
+	for _, url := range []string{
+		srv.URL,
+		strings.Replace(srv.URL, "127.0.0.1", "localhost", 1),
+	} {
+		ep, d := testDelivery()
+		ep.URL = url
+		if _, _, err := s.post(context.Background(), ep, d); !errors.Is(err, ErrWebhookAddressBlocked) {
+			t.Errorf("post to %s: err = %v, want ErrWebhookAddressBlocked", url, err)
+		}
+	}
+	if n := hits.Load(); n != 0 {
+		t.Errorf("receiver on loopback got %d requests, want none", n)
+	}
+}
+
+func TestWebhookClientRefusesRedirects(t *testing.T) {
+	var followed atomic.Bool
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			followed.Store(true)
+			return
+		}
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	// The test receiver is on loopback, so take its transport but keep the
+	// delivery client's redirect policy.
+	client := newWebhookClient()
+	client.Transport = srv.Client().Transport
+	s := &OutboundWebhookService{client: client}
+
+	ep, d := testDelivery()
+	ep.URL = srv.URL + "/hook"
+	status, _, err := s.post(context.Background(), ep, d)
+	if err == nil || status != http.StatusFound {
+		t.Fatalf("post = %d, %v; want the 302 as a failure", status, err)
+	}
+	if followed.Load() {
+		t.Error("redirect was followed")
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrUnknownPreferences = errors.New("unknown preferences namespace")
+	ErrInvalidPreferences = errors.New("invalid preferences")
+)
+
+// NotificationPreferencesNamespace is the core namespace honoured by push
+// delivery.
+const NotificationPreferencesNamespace = "notifications"
+
+// PreferenceSchema describes one namespace of per-user preferences. New
+// returns a pointer to the namespace's struct filled with its defaults;
+// stored values are decoded on top, so fields added later read as their
+// default. Structs implementing PreferenceValidator are checked on update.
+type PreferenceSchema struct {
+	Namespace string
+	New       func() interface{}
+}
+
+// PreferenceValidator is implemented by preference structs with constraints
+// beyond their JSON types. Errors are shown to the client.
+type PreferenceValidator interface {
+	Validate() error
+}
+
+// NotificationPreferences lets users opt out of push categories. Push turns
+// off everything; Reminders covers scheduled reminders and Marketing admin
+// campaigns.
+type NotificationPreferences struct {
+	Push      bool `json:"push"`
+	Reminders bool `json:"reminders"`
+	Marketing bool `json:"marketing"`
+}
+
+// Allows reports whether a push in category may be delivered.
+func (p *NotificationPreferences) Allows(category string) bool {
+	switch {
+	case !p.Push:
+		return false
+	case category == PushCategoryReminder:
+		return p.Reminders
+	case category == PushCategoryMarketing:
+		return p.Marketing
+	}
+	return true
+}
+
+func defaultNotificationPreferences() interface{} {
+	return &NotificationPreferences{Push: true, Reminders: true, Marketing: true}
+}
+
+// PreferenceService reads and updates typed preferences. Core namespaces are
+// available to every app; plugin namespaces only to their own app.
+type PreferenceService struct {
+	db      *gorm.DB
+	schemas map[string]PreferenceSchema // "<app>/<namespace>", app empty for core
+}
+
+func NewPreferenceService(db *gorm.DB) *PreferenceService {
+	s := &PreferenceService{db: db, schemas: make(map[string]PreferenceSchema)}
+	s.Register("", PreferenceSchema{Namespace: NotificationPreferencesNamespace, New: defaultNotificationPreferences})
+	return s
+}
+
+// Register adds a namespace for appID, or for every app when appID is empty.
+// It must be called before the server starts.
+func (s *PreferenceService) Register(appID string, schema PreferenceSchema) {
+	s.schemas[appID+"/"+schema.Namespace] = schema
+}
+
+func (s *PreferenceService) schema(appID, namespace string) (PreferenceSchema, bool) {
+	if schema, ok := s.schemas[appID+"/"+namespace]; ok {
+		return schema, true
+	}
+	schema, ok := s.schemas["/"+namespace]
+	return schema, ok
+}
+
+// Namespaces lists the namespaces available to appID.
+func (s *PreferenceService) Namespaces(appID string) []string {
+	var out []string
+	for key, schema := range s.schemas {
+		if key == "/"+schema.Namespace || key == appID+"/"+schema.Namespace {
+			out = append(out, schema.Namespace)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Get returns the user's preferences for namespace, with defaults for
+// anything never set.
+func (s *PreferenceService) Get(appID string, userID uuid.UUID, namespace string) (interface{}, error) {
+	schema, ok := s.schema(appID, namespace)
+	if !ok {
+		return nil, ErrUnknownPreferences
+	}
+	prefs := schema.New()
+	if err := LoadPreferences(s.db, appID, userID, namespace, prefs); err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// Patch merges the JSON object patch into the user's preferences. Only the
+// fields present change; unknown fields and wrong types are rejected.
+func (s *PreferenceService) Patch(appID string, userID uuid.UUID, namespace string, patch []byte) (interface{}, error) {
+	schema, ok := s.schema(appID, namespace)
+	if !ok {
+		return nil, ErrUnknownPreferences
+	}
+	prefs := schema.New()
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var row models.UserPreference
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(tenant.ForTenant(appID)).
+			Where("user_id = ? AND namespace = ?", userID, namespace).
+			First(&row).Error
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(row.Data, prefs); err != nil {
+				return fmt.Errorf("decode stored preferences: %w", err)
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(patch))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(prefs); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPreferences, err)
+		}
+		if v, ok := prefs.(PreferenceValidator); ok {
+			if err := v.Validate(); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidPreferences, err)
+			}
+		}
+
+		data, err := json.Marshal(prefs)
+		if err != nil {
+			return fmt.Errorf("encode preferences: %w", err)
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "app_id"}, {Name: "user_id"}, {Name: "namespace"}},
+			DoUpdates: clause.AssignmentColumns([]string{"data", "updated_at"}),
+		}).Create(&models.UserPreference{
+			AppID:     appID,
+			UserID:    userID,
+			Namespace: namespace,
+			Data:      data,
+			UpdatedAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// LoadPreferences decodes the user's stored preferences for namespace into
+// dst, which should already hold the defaults. It leaves dst untouched when
+// nothing is stored. Plugins use it to read their own namespace.
+func LoadPreferences(db *gorm.DB, appID string, userID uuid.UUID, namespace string, dst interface{}) error {
+	var rows []models.UserPreference
+	if err := db.Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ? AND namespace = ?", userID, namespace).
+		Limit(1).
+		Find(&rows).Error; err != nil {
+		return fmt.Errorf("load preferences: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(rows[0].Data, dst); err != nil {
+		return fmt.Errorf("decode preferences: %w", err)
+	}
+	return nil
+}
+
+// notificationPreferences returns the user's push opt-outs. Errors fall back
+// to the defaults so a bad row never blocks transactional pushes.
+func notificationPreferences(db *gorm.DB, appID string, userID uuid.UUID) *NotificationPreferences {
+	prefs := defaultNotificationPreferences().(*NotificationPreferences)
+	if err := LoadPreferences(db, appID, userID, NotificationPreferencesNamespace, prefs); err != nil {
+		return defaultNotificationPreferences().(*NotificationPreferences)
+	}
+	return prefs
+}
@@ -0,0 +1,409 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/locale"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/localtime"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/media"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidDisplayName = errors.New("display_name must be at most 100 characters")
+	ErrInvalidLocale      = errors.New("locale must be a BCP 47 language tag such as en or pt-BR")
+	ErrInvalidBirthYear   = errors.New("birth_year must be between 1900 and the current year")
+	ErrInvalidEmail       = errors.New("invalid email format")
+	ErrEmailUnchanged     = errors.New("new email is the current email")
+	ErrPasswordRequired   = errors.New("password is required")
+	ErrInvalidEmailToken  = errors.New("invalid or expired verification token")
+)
+
+const (
+	// AvatarMaxBytes bounds avatar uploads.
+	AvatarMaxBytes    = 5 * 1024 * 1024
+	emailChangeExpiry = 24 * time.Hour
+)
+
+// ProfileService manages the profile fields on the user row, the avatar and
+// email changes.
+type ProfileService struct {
+	db       *gorm.DB
+	registry *tenant.Registry
+	media    *media.Store
+	mailer   Mailer
+}
+
+// NewProfileService builds the service. A nil mailer disables email changes.
+func NewProfileService(db *gorm.DB, cfg *config.Config, registry *tenant.Registry, mailer Mailer) *ProfileService {
+	return &ProfileService{
+		db:       db,
+		registry: registry,
+		media:    media.NewStore(cfg.UploadsRoot, cfg.MediaBaseURL),
+		mailer:   mailer,
+	}
+}
+
+// Subscribe removes a deleted user's avatar files once the deletion has
+// committed.
+func (s *ProfileService) Subscribe(bus *events.Bus) {
+	bus.SubscribeAsync(events.UserDeleted, "profile.avatar", func(db *gorm.DB, ev events.Event) error {
+		return s.media.DeleteDir(avatarDir(ev.AppID, ev.UserID))
+	})
+}
+
+func avatarDir(appID string, userID uuid.UUID) []string {
+	return []string{"avatars", appID, userID.String()}
+}
+
+func (s *ProfileService) loadUser(appID string, userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := s.db.Scopes(tenant.ForTenant(appID)).First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("load user: %w", err)
+	}
+	return &user, nil
+}
+
+func (s *ProfileService) profile(user *models.User) (*dto.ProfileResponse, error) {
+	var pending []string
+	if err := s.db.Model(&models.EmailChangeRequest{}).
+		Scopes(tenant.ForTenant(user.AppID)).
+		Where("user_id = ? AND confirmed_at IS NULL AND expires_at > ?", user.ID, time.Now()).
+		Order("created_at DESC").
+		Limit(1).
+		Pluck("new_email", &pending).Error; err != nil {
+		return nil, fmt.Errorf("load pending email: %w", err)
+	}
+	resp := &dto.ProfileResponse{
+		ID:          user.ID,
+		Email:       user.Email,
+		DisplayName: user.DisplayName,
+		AvatarURL:   user.AvatarURL,
+		Locale:      user.Locale,
+		Timezone:    user.Timezone,
+		BirthYear:   user.BirthYear,
+		IsGuest:     user.IsGuest,
+		CreatedAt:   user.CreatedAt,
+	}
+	if len(pending) > 0 {
+		resp.PendingEmail = pending[0]
+	}
+	return resp, nil
+}
+
+func (s *ProfileService) GetProfile(appID string, userID uuid.UUID) (*dto.ProfileResponse, error) {
+	user, err := s.loadUser(appID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.profile(user)
+}
+
+// UpdateProfile applies the fields present in req. It also reports whether
+// the timezone changed, so the caller can rebuild day-bucketed data.
+func (s *ProfileService) UpdateProfile(appID string, userID uuid.UUID, req dto.UpdateProfileRequest) (*dto.ProfileResponse, bool, error) {
+	updates := make(map[string]interface{})
+	if req.DisplayName != nil {
+		name := strings.TrimSpace(*req.DisplayName)
+		if utf8.RuneCountInString(name) > 100 || strings.ContainsAny(name, "\r\n\t") {
+			return nil, false, ErrInvalidDisplayName
+		}
+		updates["display_name"] = name
+	}
+	if req.Locale != nil {
+		tag := ""
+		if strings.TrimSpace(*req.Locale) != "" {
+			var ok bool
+			if tag, ok = locale.Normalize(*req.Locale); !ok {
+				return nil, false, ErrInvalidLocale
+			}
+		}
+		updates["locale"] = tag
+	}
+	if req.BirthYear != nil {
+		switch year := *req.BirthYear; {
+		case year == 0:
+			updates["birth_year"] = nil
+		case year < 1900 || year > time.Now().Year():
+			return nil, false, ErrInvalidBirthYear
+		default:
+			updates["birth_year"] = year
+		}
+	}
+
+	user, err := s.loadUser(appID, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	tzChanged := false
+	if req.Timezone != nil {
+		tz := strings.TrimSpace(*req.Timezone)
+		if !localtime.Valid(tz) {
+			return nil, false, ErrInvalidTimezone
+		}
+		if tz != user.Timezone {
+			updates["timezone"] = tz
+			tzChanged = true
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(user).Updates(updates).Error; err != nil {
+			return nil, false, fmt.Errorf("update profile: %w", err)
+		}
+		if user, err = s.loadUser(appID, userID); err != nil {
+			return nil, false, err
+		}
+	}
+	resp, err := s.profile(user)
+	if err != nil {
+		return nil, false, err
+	}
+	return resp, tzChanged, nil
+}
+
+// SetAvatar validates and stores an image as the user's avatar, replacing
+// the previous one.
+func (s *ProfileService) SetAvatar(appID string, userID uuid.UUID, data []byte, contentType string) (*dto.ProfileResponse, error) {
+	ext, err := media.ImageExtension(data, contentType)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.loadUser(appID, userID)
+	if err != nil {
+		return nil, err
+	}
+	avatarURL, err := s.media.Save(avatarDir(appID, userID), ext, data)
+	if err != nil {
+		return nil, fmt.Errorf("save avatar: %w", err)
+	}
+	previous := user.AvatarURL
+	if err := s.db.Model(user).Update("avatar_url", avatarURL).Error; err != nil {
+		_ = s.media.Delete(avatarURL)
+		return nil, fmt.Errorf("update avatar: %w", err)
+	}
+	user.AvatarURL = avatarURL
+	if err := s.media.Delete(previous); err != nil {
+		slog.Warn("failed to delete previous avatar", "app_id", appID, "user_id", userID, "error", err)
+	}
+	return s.profile(user)
+}
+
+func (s *ProfileService) RemoveAvatar(appID string, userID uuid.UUID) (*dto.ProfileResponse, error) {
+	user, err := s.loadUser(appID, userID)
+	if err != nil {
+		return nil, err
+	}
+	previous := user.AvatarURL
+	if err := s.db.Model(user).Update("avatar_url", "").Error; err != nil {
+		return nil, fmt.Errorf("remove avatar: %w", err)
+	}
+	user.AvatarURL = ""
+	if err := s.media.Delete(previous); err != nil {
+		slog.Warn("failed to delete avatar", "app_id", appID, "user_id", userID, "error", err)
+	}
+	return s.profile(user)
+}
+
+// normalizeEmail lowercases a bare address and rejects display names,
+// comments and anything that could inject mail headers.
+func normalizeEmail(raw string) (string, error) {
+	email := strings.ToLower(strings.TrimSpace(raw))
+	if email == "" || len(email) > 254 {
+		return "", ErrInvalidEmail
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", ErrInvalidEmail
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 1 || !strings.Contains(email[at+1:], ".") {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
+
+// RequestEmailChange mails a confirmation token to the new address. The
+// email only changes once ConfirmEmailChange is called with the token, and a
+// new request replaces any pending one.
+func (s *ProfileService) RequestEmailChange(appID string, userID uuid.UUID, req dto.ChangeEmailRequest) error {
+	if s.mailer == nil {
+		return ErrMailNotConfigured
+	}
+	email, err := normalizeEmail(req.NewEmail)
+	if err != nil {
+		return err
+	}
+	user, err := s.loadUser(appID, userID)
+	if err != nil {
+		return err
+	}
+	if user.AuthProvider == "email" {
+		if req.Password == "" {
+			return ErrPasswordRequired
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+			return ErrInvalidCredentials
+		}
+	}
+	if email == user.Email {
+		return ErrEmailUnchanged
+	}
+	if taken, err := s.emailTaken(s.db, appID, email); err != nil {
+		return err
+	} else if taken {
+		return ErrEmailTaken
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return fmt.Errorf("generate token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	change := models.EmailChangeRequest{
+		AppID:     appID,
+		UserID:    userID,
+		NewEmail:  email,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(emailChangeExpiry),
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("app_id = ? AND user_id = ? AND confirmed_at IS NULL", appID, userID).
+			Delete(&models.EmailChangeRequest{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&change).Error
+	})
+	if err != nil {
+		return fmt.Errorf("create email change: %w", err)
+	}
+
+	if err := s.mailer.Send(s.verificationMail(appID, email, token)); err != nil {
+		s.db.Delete(&change)
+		return fmt.Errorf("send verification email: %w", err)
+	}
+	slog.Info("email change requested", "app_id", appID, "user_id", userID)
+	return nil
+}
+
+func (s *ProfileService) appName(appID string) string {
+	if app := s.registry.Get(appID); app != nil && app.AppName != "" {
+		return app.AppName
+	}
+	return appID
+}
+
+func (s *ProfileService) verificationMail(appID, to, token string) MailMessage {
+	name := s.appName(appID)
+	body := "Confirm that you want to use this address for your " + name + " account.\n\n"
+	if app := s.registry.Get(appID); app != nil && app.EmailVerifyURL != "" {
+		sep := "?"
+		if strings.Contains(app.EmailVerifyURL, "?") {
+			sep = "&"
+		}
+		body += "Open this link to confirm:\n" + app.EmailVerifyURL + sep + "token=" + url.QueryEscape(token) + "\n\n"
+	} else {
+		body += "Enter this code in the app to confirm:\n" + token + "\n\n"
+	}
+	body += "The request expires in 24 hours. If you didn't ask for this, ignore this email."
+	return MailMessage{To: to, Subject: "Confirm your new email for " + name, Body: body}
+}
+
+func (s *ProfileService) emailTaken(db *gorm.DB, appID, email string) (bool, error) {
+	var count int64
+	if err := db.Model(&models.User{}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("email = ?", email).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("check email: %w", err)
+	}
+	return count > 0, nil
+}
+
+// ConfirmEmailChange applies the change behind token. Existing sessions are
+// revoked since their tokens carry the old address, and the old address is
+// told about the change.
+func (s *ProfileService) ConfirmEmailChange(appID, token string) error {
+	if token == "" {
+		return ErrInvalidEmailToken
+	}
+	var change models.EmailChangeRequest
+	var oldEmail string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(tenant.ForTenant(appID)).
+			Where("token_hash = ? AND confirmed_at IS NULL AND expires_at > ?", hashToken(token), time.Now()).
+			First(&change).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidEmailToken
+			}
+			return err
+		}
+		var user models.User
+		if err := tx.Scopes(tenant.ForTenant(appID)).First(&user, "id = ?", change.UserID).Error; err != nil {
+			return ErrInvalidEmailToken
+		}
+		if taken, err := s.emailTaken(tx, appID, change.NewEmail); err != nil {
+			return err
+		} else if taken {
+			return ErrEmailTaken
+		}
+
+		oldEmail = user.Email
+		if err := tx.Model(&user).Update("email", change.NewEmail).Error; err != nil {
+			return fmt.Errorf("update email: %w", err)
+		}
+		res := tx.Model(&change).Where("confirmed_at IS NULL").Update("confirmed_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrInvalidEmailToken
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("app_id = ? AND user_id = ? AND revoked = false", appID, user.ID).
+			Update("revoked", true).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.Info("email changed", "app_id", appID, "user_id", change.UserID)
+	if oldEmail != "" && s.mailer != nil {
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					slog.Error("panic in email change notice goroutine", "recover", r)
+				}
+			}()
+			name := s.appName(appID)
+			if err := s.mailer.Send(MailMessage{
+				To:      oldEmail,
+				Subject: "Your " + name + " email was changed",
+				Body: "The email address on your " + name + " account was changed to " + change.NewEmail + ".\n\n" +
+					"If you didn't make this change, contact support right away.",
+			}); err != nil {
+				slog.Warn("email change notice failed", "app_id", appID, "user_id", change.UserID, "error", err)
+			}
+		}()
+	}
+	return nil
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidPushToken means the provider rejected the device token for good;
+// the token should be pruned rather than retried.
+var ErrInvalidPushToken = errors.New("invalid push token")
+
+// PushMessage is a single alert to one device.
+type PushMessage struct {
+	Token string
+	Title string
+	Body  string
+	Data  map[string]string
+	// Topic is the app's bundle ID (APNs only).
+	Topic string
+	// Sandbox routes to the APNs development gateway.
+	Sandbox bool
+}
+
+// PushProvider delivers messages to one platform's push service.
+type PushProvider interface {
+	Name() string
+	// Send returns the provider's message ID. Errors wrapping
+	// ErrInvalidPushToken mean the token is dead.
+	Send(ctx context.Context, msg PushMessage) (string, error)
+}
+
+// pushHTTPClient is shared by APNs and FCM. APNs requires HTTP/2, which
+// net/http negotiates automatically over TLS.
+var pushHTTPClient = &http.Client{Timeout: 15 * time.Second}
+
+// --- APNs ---
+
+const (
+	apnsProductionURL = "https://api.push.apple.com"
+	apnsSandboxURL    = "https://api.sandbox.push.apple.com"
+	// Apple rejects provider tokens older than an hour and throttles ones
+	// refreshed more often than every 20 minutes.
+	apnsTokenTTL = 50 * time.Minute
+)
+
+// APNsProvider sends through Apple's HTTP/2 API with token-based (.p8) auth.
+type APNsProvider struct {
+	teamID     string
+	keyID      string
+	privateKey interface{}
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+func NewAPNsProvider(teamID, keyID, privateKeyPEM string) (*APNsProvider, error) {
+	key, err := parseApplePrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &APNsProvider{teamID: teamID, keyID: keyID, privateKey: key}, nil
+}
+
+func (p *APNsProvider) Name() string { return "apns" }
+
+func (p *APNsProvider) providerToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Since(p.issuedAt) < apnsTokenTTL {
+		return p.token, nil
+	}
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": p.teamID,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = p.keyID
+	signed, err := token.SignedString(p.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("sign apns token: %w", err)
+	}
+	p.token, p.issuedAt = signed, now
+	return signed, nil
+}
+
+func (p *APNsProvider) Send(ctx context.Context, msg PushMessage) (string, error) {
+	bearer, err := p.providerToken()
+	if err != nil {
+		return "", err
+	}
+
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{"title": msg.Title, "body": msg.Body},
+			"sound": "default",
+		},
+	}
+	for k, v := range msg.Data {
+		if k != "aps" {
+			payload[k] = v
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	base := apnsProductionURL
+	if msg.Sandbox {
+		base = apnsSandboxURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/3/device/"+url.PathEscape(msg.Token), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("authorization", "bearer "+bearer)
+	req.Header.Set("apns-topic", msg.Topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+	req.Header.Set("content-type", "application/json")
+
+	resp, err := pushHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("apns request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return resp.Header.Get("apns-id"), nil
+	}
+
+	var apnsErr struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&apnsErr)
+	switch {
+	case resp.StatusCode == http.StatusGone,
+		apnsErr.Reason == "BadDeviceToken",
+		apnsErr.Reason == "Unregistered",
+		apnsErr.Reason == "DeviceTokenNotForTopic":
+		return "", fmt.Errorf("%w: apns %s", ErrInvalidPushToken, apnsErr.Reason)
+	}
+	return "", fmt.Errorf("apns returned status %d: %s", resp.StatusCode, apnsErr.Reason)
+}
+
+// --- FCM ---
+
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// FCMProvider sends through the FCM HTTP v1 API, authenticating with a
+// service account via the OAuth2 JWT bearer flow.
+type FCMProvider struct {
+	projectID   string
+	clientEmail string
+	tokenURI    string
+	privateKey  interface{}
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+func NewFCMProvider(serviceAccountJSON string) (*FCMProvider, error) {
+	var sa struct {
+		ProjectID   string `json:"project_id"`
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenURI    string `json:"token_uri"`
+	}
+	if err := json.Unmarshal([]byte(serviceAccountJSON), &sa); err != nil {
+		return nil, fmt.Errorf("parse FCM service account: %w", err)
+	}
+	if sa.ProjectID == "" || sa.ClientEmail == "" || sa.PrivateKey == "" {
+		return nil, errors.New("FCM service account is missing project_id, client_email or private_key")
+	}
+	if sa.TokenURI == "" {
+		sa.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	block, _ := pem.Decode([]byte(sa.PrivateKey))
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block from FCM service account")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FCM private key: %w", err)
+	}
+
+	return &FCMProvider{
+		projectID:   sa.ProjectID,
+		clientEmail: sa.ClientEmail,
+		tokenURI:    sa.TokenURI,
+		privateKey:  key,
+	}, nil
+}
+
+func (p *FCMProvider) Name() string { return "fcm" }
+
+func (p *FCMProvider) token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.accessToken != "" && time.Until(p.expiresAt) > time.Minute {
+		return p.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   p.clientEmail,
+		"scope": fcmScope,
+		"aud":   p.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(p.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("sign fcm assertion: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := pushHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fcm token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fcm token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil || tokenResp.AccessToken == "" {
+		return "", errors.New("failed to parse fcm token response")
+	}
+	p.accessToken = tokenResp.AccessToken
+	p.expiresAt = now.Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	return p.accessToken, nil
+}
+
+func (p *FCMProvider) Send(ctx context.Context, msg PushMessage) (string, error) {
+	accessToken, err := p.token(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"message": map[string]interface{}{
+			"token":        msg.Token,
+			"notification": map[string]string{"title": msg.Title, "body": msg.Body},
+			"data":         msg.Data,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := "https://fcm.googleapis.com/v1/projects/" + url.PathEscape(p.projectID) + "/messages:send"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := pushHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fcm request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Name  string `json:"name"`
+		Error struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 16384)).Decode(&result)
+	if resp.StatusCode == http.StatusOK {
+		return result.Name, nil
+	}
+
+	if result.Error.Status == "NOT_FOUND" || result.Error.Status == "UNREGISTERED" {
+		return "", fmt.Errorf("%w: fcm %s", ErrInvalidPushToken, result.Error.Status)
+	}
+	for _, d := range result.Error.Details {
+		if d.ErrorCode == "UNREGISTERED" || (d.ErrorCode == "INVALID_ARGUMENT" && strings.Contains(result.Error.Message, "registration token")) {
+			return "", fmt.Errorf("%w: fcm %s", ErrInvalidPushToken, d.ErrorCode)
+		}
+	}
+	return "", fmt.Errorf("fcm returned status %d: %s", resp.StatusCode, result.Error.Status)
+}
+
+// --- Mock ---
+
+// MockPushProvider records messages instead of sending them, for local
+// development and tests. Tokens starting with "invalid" are rejected as dead.
+type MockPushProvider struct {
+	mu   sync.Mutex
+	sent []PushMessage
+}
+
+func NewMockPushProvider() *MockPushProvider {
+	return &MockPushProvider{}
+}
+
+func (p *MockPushProvider) Name() string { return "mock" }
+
+func (p *MockPushProvider) Send(ctx context.Context, msg PushMessage) (string, error) {
+	if strings.HasPrefix(msg.Token, "invalid") {
+		return "", fmt.Errorf("%w: mock", ErrInvalidPushToken)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sent = append(p.sent, msg)
+	return fmt.Sprintf("mock-%d", len(p.sent)), nil
+}
+
+// Sent returns a copy of every message delivered so far.
+func (p *MockPushProvider) Sent() []PushMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PushMessage(nil), p.sent...)
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Reminder is a scheduled notification a plugin wants delivered to one user.
+type Reminder struct {
+	UserID uuid.UUID
+	Kind   string // e.g. journal_reminder, streak_reminder
+	Title  string
+	Body   string
+	Data   map[string]string
+}
+
+// ReminderSource is implemented by plugins that send scheduled reminders.
+// DueReminders is called once per hour with the users who have devices
+// registered and returns the reminders due in the hour starting at now.
+type ReminderSource interface {
+	DueReminders(db *gorm.DB, appID string, userIDs []uuid.UUID, now time.Time) ([]Reminder, error)
+}
+
+const (
+	reminderUserBatch = 500
+	reminderWorkers   = 8
+)
+
+// StartReminderScheduler runs an hourly goroutine that asks each source for
+// due reminders and sends them. Each reminder is deduplicated per user, kind
+// and day, so restarts and multiple instances never double-send.
+func (s *PushService) StartReminderScheduler(sources map[string]ReminderSource, done chan struct{}) {
+	if len(sources) == 0 {
+		return
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("panic in push reminder scheduler", "recover", r)
+			}
+		}()
+		// Tick every minute so the run lands close to the top of the hour.
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		var lastHour time.Time
+		for {
+			if hour := time.Now().UTC().Truncate(time.Hour); !hour.Equal(lastHour) {
+				lastHour = hour
+				for appID, source := range sources {
+					s.RunReminders(appID, source, hour)
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// RunReminders sends one hour's due reminders from source to the app's
+// users with registered devices. The scheduler calls it at the top of each
+// hour; running it again for the same day skips reminders already sent.
+func (s *PushService) RunReminders(appID string, source ReminderSource, hour time.Time) {
+	sent, skipped, failed := 0, 0, 0
+	var lastUserID uuid.UUID
+	for {
+		var userIDs []uuid.UUID
+		if err := s.db.Model(&models.DeviceToken{}).
+			Scopes(tenant.ForTenant(appID)).
+			Where("user_id > ?", lastUserID).
+			Distinct("user_id").
+			Order("user_id ASC").
+			Limit(reminderUserBatch).
+			Pluck("user_id", &userIDs).Error; err != nil {
+			slog.Error("reminder scheduler: failed to load users", "app_id", appID, "error", err)
+			return
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+		lastUserID = userIDs[len(userIDs)-1]
+
+		reminders, err := source.DueReminders(s.db, appID, userIDs, hour)
+		if err != nil {
+			slog.Error("reminder scheduler: source failed", "app_id", appID, "error", err)
+			return
+		}
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		work := make(chan Reminder)
+		for i := 0; i < reminderWorkers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for r := range work {
+					ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+					_, err := s.SendToUser(ctx, appID, r.UserID, PushNotificationRequest{
+						Kind:      r.Kind,
+						Category:  PushCategoryReminder,
+						DedupeKey: reminderDedupeKey(r.Kind, hour),
+						Title:     r.Title,
+						Body:      r.Body,
+						Data:      r.Data,
+					})
+					cancel()
+					mu.Lock()
+					switch {
+					case err == nil:
+						sent++
+					case errors.Is(err, ErrDuplicateNotification):
+						skipped++
+					default:
+						failed++
+						slog.Error("reminder send failed", "app_id", appID, "kind", r.Kind, "error", err)
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+		for _, r := range reminders {
+			work <- r
+		}
+		close(work)
+		wg.Wait()
+
+		if len(userIDs) < reminderUserBatch {
+			break
+		}
+	}
+	if sent+skipped+failed > 0 {
+		slog.Info("push reminders processed", "app_id", appID, "hour", hour, "sent", sent, "duplicate", skipped, "failed", failed)
+	}
+}
+
+// reminderDedupeKey allows one reminder of a kind per user and UTC day.
+func reminderDedupeKey(kind string, hour time.Time) string {
+	return kind + ":" + hour.UTC().Format("2006-01-02")
+}
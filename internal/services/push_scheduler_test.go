@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReminderDedupeKey(t *testing.T) {
+	hour := time.Date(2026, 3, 14, 20, 0, 0, 0, time.UTC)
+	key := reminderDedupeKey("journal_reminder", hour)
+	if key != "journal_reminder:2026-03-14" {
+		t.Fatalf("key = %q", key)
+	}
+	if got := reminderDedupeKey("journal_reminder", hour.Add(3*time.Hour)); got != key {
+		t.Errorf("later hour the same day = %q, want %q", got, key)
+	}
+	if got := reminderDedupeKey("journal_reminder", hour.Add(4*time.Hour)); got == key {
+		t.Errorf("next day reuses %q", got)
+	}
+	if got := reminderDedupeKey("streak_reminder", hour); got == key {
+		t.Errorf("another kind reuses %q", got)
+	}
+	istanbul := time.FixedZone("TRT", 3*3600)
+	if got := reminderDedupeKey("journal_reminder", hour.In(istanbul)); got != key {
+		t.Errorf("same instant in another zone = %q, want %q", got, key)
+	}
+}
+
+func TestMockPushProvider(t *testing.T) {
+	p := NewMockPushProvider()
+	id, err := p.Send(context.Background(), PushMessage{Token: "device-1", Title: "Hi"})
+	if err != nil || id != "mock-1" {
+		t.Fatalf("Send = %q, %v; want mock-1", id, err)
+	}
+	if _, err := p.Send(context.Background(), PushMessage{Token: "invalid-device"}); !errors.Is(err, ErrInvalidPushToken) {
+		t.Errorf("dead token: err = %v, want ErrInvalidPushToken", err)
+	}
+
+	sent := p.Sent()
+	if len(sent) != 1 || sent[0].Token != "device-1" || sent[0].Title != "Hi" {
+		t.Fatalf("Sent = %+v, want the one delivered message", sent)
+	}
+	sent[0].Title = "changed"
+	if p.Sent()[0].Title != "Hi" {
+		t.Error("Sent returned the provider's own slice")
+	}
+}
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidDevice          = errors.New("token and platform (ios or android) are required")
+	ErrDeviceNotFound         = errors.New("device not found")
+	ErrNotificationNotFound   = errors.New("notification not found")
+	ErrDuplicateNotification  = errors.New("notification already sent")
+	ErrPushProviderNotEnabled = errors.New("push provider not configured for platform")
+)
+
+// Push categories users can opt out of in their notification preferences.
+// Notifications without a category are transactional and only respect the
+// master switch.
+const (
+	PushCategoryReminder  = "reminder"
+	PushCategoryMarketing = "marketing"
+)
+
+// PushNotificationRequest describes a notification to fan out to a user's devices.
+type PushNotificationRequest struct {
+	Kind     string
+	Category string
+	// DedupeKey makes the send idempotent per user; empty means always send.
+	DedupeKey string
+	Title     string
+	Body      string
+	Data      map[string]string
+}
+
+type PushService struct {
+	db       *gorm.DB
+	registry *tenant.Registry
+	apns     PushProvider
+	fcm      PushProvider
+}
+
+// NewPushService wires providers from config. A platform without credentials
+// has no provider and its devices are skipped; PUSH_PROVIDER=mock replaces both.
+func NewPushService(db *gorm.DB, cfg *config.Config, registry *tenant.Registry) *PushService {
+	s := &PushService{db: db, registry: registry}
+
+	if cfg.PushProvider == "mock" {
+		mock := NewMockPushProvider()
+		s.apns, s.fcm = mock, mock
+		slog.Info("push notifications using mock provider")
+		return s
+	}
+
+	if cfg.AppleTeamID != "" && cfg.AppleKeyID != "" && cfg.ApplePrivateKey != "" {
+		apns, err := NewAPNsProvider(cfg.AppleTeamID, cfg.AppleKeyID, cfg.ApplePrivateKey)
+		if err != nil {
+			slog.Error("apns provider disabled", "error", err)
+		} else {
+			s.apns = apns
+		}
+	}
+	if cfg.FCMServiceAccountJSON != "" {
+		fcm, err := NewFCMProvider(cfg.FCMServiceAccountJSON)
+		if err != nil {
+			slog.Error("fcm provider disabled", "error", err)
+		} else {
+			s.fcm = fcm
+		}
+	}
+	return s
+}
+
+// NewPushServiceWithProviders builds a service with explicit providers, e.g.
+// a MockPushProvider in tests.
+func NewPushServiceWithProviders(db *gorm.DB, registry *tenant.Registry, apns, fcm PushProvider) *PushService {
+	return &PushService{db: db, registry: registry, apns: apns, fcm: fcm}
+}
+
+func (s *PushService) providerFor(platform string) PushProvider {
+	if platform == "ios" {
+		return s.apns
+	}
+	return s.fcm
+}
+
+// RegisterDevice upserts a device token for the user. A token already owned by
+// another user of the same app (shared device, re-login) is moved.
+func (s *PushService) RegisterDevice(appID string, userID uuid.UUID, req dto.RegisterDeviceRequest) (*models.DeviceToken, error) {
+	token := strings.TrimSpace(req.Token)
+	platform := strings.ToLower(strings.TrimSpace(req.Platform))
+	if token == "" || len(token) > 512 || (platform != "ios" && platform != "android") {
+		return nil, ErrInvalidDevice
+	}
+	env := strings.ToLower(req.Environment)
+	if env != "sandbox" {
+		env = "production"
+	}
+
+	device := models.DeviceToken{
+		AppID:       appID,
+		UserID:      userID,
+		Token:       token,
+		Platform:    platform,
+		Environment: env,
+		LastSeenAt:  time.Now(),
+	}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "app_id"}, {Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "environment", "last_seen_at", "updated_at"}),
+	}).Create(&device).Error
+	if err != nil {
+		return nil, fmt.Errorf("register device: %w", err)
+	}
+	return &device, nil
+}
+
+// UnregisterDevice removes one of the user's tokens, e.g. on sign-out.
+func (s *PushService) UnregisterDevice(appID string, userID uuid.UUID, token string) error {
+	result := s.db.Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ? AND token = ?", userID, token).
+		Delete(&models.DeviceToken{})
+	if result.Error != nil {
+		return fmt.Errorf("unregister device: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrDeviceNotFound
+	}
+	return nil
+}
+
+// SendToUser records a notification and delivers it to every device the user
+// has registered for the app. Dead tokens are pruned as they are discovered.
+// Returns ErrDuplicateNotification if the dedupe key was already used.
+func (s *PushService) SendToUser(ctx context.Context, appID string, userID uuid.UUID, req PushNotificationRequest) (*models.PushNotification, error) {
+	dedupeKey := req.DedupeKey
+	if dedupeKey == "" {
+		dedupeKey = "once:" + uuid.NewString()
+	}
+	data, err := json.Marshal(req.Data)
+	if err != nil {
+		return nil, fmt.Errorf("encode push data: %w", err)
+	}
+
+	notification := models.PushNotification{
+		AppID:     appID,
+		UserID:    userID,
+		Kind:      req.Kind,
+		DedupeKey: dedupeKey,
+		Title:     req.Title,
+		Body:      req.Body,
+		Data:      data,
+		Status:    "pending",
+	}
+	// Claiming the dedupe key first keeps concurrent schedulers on other
+	// instances from sending the same reminder twice.
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&notification)
+	if result.Error != nil {
+		return nil, fmt.Errorf("record notification: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrDuplicateNotification
+	}
+
+	// Recorded but not delivered, so the dedupe key stays claimed.
+	if !notificationPreferences(s.db, appID, userID).Allows(req.Category) {
+		notification.Status = "opted_out"
+		if err := s.db.Model(&notification).Update("status", notification.Status).Error; err != nil {
+			return nil, fmt.Errorf("update notification: %w", err)
+		}
+		return &notification, nil
+	}
+
+	var devices []models.DeviceToken
+	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("user_id = ?", userID).Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("load devices: %w", err)
+	}
+
+	payload := map[string]string{"notification_id": notification.ID.String(), "kind": req.Kind}
+	for k, v := range req.Data {
+		payload[k] = v
+	}
+	topic := s.registry.GetBundleID(appID)
+
+	for _, device := range devices {
+		provider := s.providerFor(device.Platform)
+		if provider == nil {
+			continue
+		}
+		delivery := models.PushDelivery{
+			NotificationID: notification.ID,
+			DeviceTokenID:  device.ID,
+			Provider:       provider.Name(),
+		}
+
+		msgID, err := provider.Send(ctx, PushMessage{
+			Token:   device.Token,
+			Title:   req.Title,
+			Body:    req.Body,
+			Data:    payload,
+			Topic:   topic,
+			Sandbox: device.Environment == "sandbox",
+		})
+		switch {
+		case err == nil:
+			delivery.Status = "delivered"
+			delivery.ProviderMessageID = msgID
+			notification.Delivered++
+		case errors.Is(err, ErrInvalidPushToken):
+			delivery.Status = "invalid_token"
+			delivery.Error = truncate(err.Error(), 500)
+			notification.Failed++
+			if err := s.db.Delete(&device).Error; err != nil {
+				slog.Error("failed to prune push token", "device_id", device.ID, "error", err)
+			} else {
+				slog.Info("pruned invalid push token", "app_id", appID, "device_id", device.ID)
+			}
+		default:
+			delivery.Status = "failed"
+			delivery.Error = truncate(err.Error(), 500)
+			notification.Failed++
+			slog.Warn("push delivery failed", "app_id", appID, "provider", provider.Name(), "error", err)
+		}
+		if err := s.db.Create(&delivery).Error; err != nil {
+			slog.Error("failed to record push delivery", "notification_id", notification.ID, "error", err)
+		}
+	}
+
+	now := time.Now()
+	switch {
+	case notification.Delivered == 0 && notification.Failed == 0:
+		notification.Status = "no_devices"
+	case notification.Failed == 0:
+		notification.Status = "sent"
+		notification.SentAt = &now
+	case notification.Delivered > 0:
+		notification.Status = "partial"
+		notification.SentAt = &now
+	default:
+		notification.Status = "failed"
+	}
+	if err := s.db.Model(&notification).Updates(map[string]interface{}{
+		"status":    notification.Status,
+		"delivered": notification.Delivered,
+		"failed":    notification.Failed,
+		"sent_at":   notification.SentAt,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("update notification: %w", err)
+	}
+	return &notification, nil
+}
+
+// MarkOpened records the client-side receipt that the user opened a notification.
+func (s *PushService) MarkOpened(appID string, userID, notificationID uuid.UUID) error {
+	result := s.db.Model(&models.PushNotification{}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("id = ? AND user_id = ? AND opened_at IS NULL", notificationID, userID).
+		Update("opened_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("mark notification opened: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		s.db.Model(&models.PushNotification{}).Scopes(tenant.ForTenant(appID)).
+			Where("id = ? AND user_id = ?", notificationID, userID).Count(&count)
+		if count == 0 {
+			return ErrNotificationNotFound
+		}
+	}
+	return nil
+}
+
+// ListNotifications returns an app's notifications, newest first, optionally
+// for one user.
+func (s *PushService) ListNotifications(appID string, userID *uuid.UUID, limit, offset int) ([]models.PushNotification, int64, error) {
+	var notifications []models.PushNotification
+	var total int64
+
+	query := s.db.Model(&models.PushNotification{}).Scopes(tenant.ForTenant(appID))
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("count notifications: %w", err)
+	}
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&notifications).Error; err != nil {
+		return nil, 0, fmt.Errorf("list notifications: %w", err)
+	}
+	return notifications, total, nil
+}
+
+// DeliveriesFor returns the per-device receipts of a notification.
+func (s *PushService) DeliveriesFor(appID string, notificationID uuid.UUID) ([]models.PushDelivery, error) {
+	var count int64
+	if err := s.db.Model(&models.PushNotification{}).Scopes(tenant.ForTenant(appID)).
+		Where("id = ?", notificationID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("load notification: %w", err)
+	}
+	if count == 0 {
+		return nil, ErrNotificationNotFound
+	}
+	var deliveries []models.PushDelivery
+	if err := s.db.Where("notification_id = ?", notificationID).Order("created_at ASC").Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("list deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
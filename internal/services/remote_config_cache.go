@@ -0,0 +1,231 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+)
+
+// configSnapshotTTL bounds staleness when another instance handles the admin
+// write: local writes invalidate immediately, remote ones within this window.
+const configSnapshotTTL = 30 * time.Second
+
+// configSnapshot is everything GET /api/config needs for one app, loaded once
+// and shared by all requests until an admin write invalidates it.
+type configSnapshot struct {
+	values      map[string]interface{} // parsed base values
+	types       map[string]string
+	rules       []models.RemoteConfigRule // enabled only, evaluation order
+	experiments []models.Experiment       // running only
+	// keyChanged is the last time anything affecting a key's resolved value
+	// changed: its row, any of its rules, or an experiment targeting it.
+	keyChanged map[string]time.Time
+	// deletedAt is the last deletion time of keys that have ever been deleted.
+	deletedAt    map[string]time.Time
+	updatedAt    time.Time
+	personalized bool
+	loadedAt     time.Time
+
+	// body and etag are set by the first successful SharedBody.
+	bodyMu sync.Mutex
+	body   []byte
+	etag   string
+}
+
+// Invalidate drops the cached snapshot for an app. Also called when another
+// instance reports a change, so it must not notify listeners itself.
+func (s *RemoteConfigService) Invalidate(appID string) {
+	s.cacheMu.Lock()
+	delete(s.cache, appID)
+	s.cacheMu.Unlock()
+}
+
+// OnChange registers a callback fired after any local admin write that can
+// change resolved config: keys, rules or experiments.
+func (s *RemoteConfigService) OnChange(fn func(appID string)) {
+	s.cacheMu.Lock()
+	s.listeners = append(s.listeners, fn)
+	s.cacheMu.Unlock()
+}
+
+// changed invalidates the app's snapshot and notifies listeners.
+func (s *RemoteConfigService) changed(appID string) {
+	s.Invalidate(appID)
+	s.cacheMu.RLock()
+	listeners := s.listeners
+	s.cacheMu.RUnlock()
+	for _, fn := range listeners {
+		fn(appID)
+	}
+}
+
+// ConfigValue returns an app's base value for key, ignoring targeting rules
+// and experiments. It serves server-side settings such as moderation word
+// lists from the cached snapshot.
+func (s *RemoteConfigService) ConfigValue(appID, key string) (interface{}, bool) {
+	snap, err := s.snapshot(appID)
+	if err != nil {
+		return nil, false
+	}
+	v, ok := snap.values[key]
+	return v, ok
+}
+
+// ConfigVersion returns the app's current config_version.
+func (s *RemoteConfigService) ConfigVersion(appID string) (int64, error) {
+	snap, err := s.snapshot(appID)
+	if err != nil {
+		return 0, err
+	}
+	return snap.updatedAt.Unix(), nil
+}
+
+func (s *RemoteConfigService) snapshot(appID string) (*configSnapshot, error) {
+	s.cacheMu.RLock()
+	snap, ok := s.cache[appID]
+	s.cacheMu.RUnlock()
+	if ok && time.Since(snap.loadedAt) < configSnapshotTTL {
+		return snap, nil
+	}
+
+	snap, err := s.loadSnapshot(appID)
+	if err != nil {
+		return nil, err
+	}
+	s.cacheMu.Lock()
+	s.cache[appID] = snap
+	s.cacheMu.Unlock()
+	return snap, nil
+}
+
+func (s *RemoteConfigService) loadSnapshot(appID string) (*configSnapshot, error) {
+	var configs []models.RemoteConfig
+	if err := s.db.Scopes(tenant.ForTenant(appID)).Find(&configs).Error; err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+
+	// Disabled rules are loaded too: disabling one changes resolved values, so
+	// its updated_at has to move config_version and the key's delta timestamp.
+	var rules []models.RemoteConfigRule
+	if err := s.db.Scopes(tenant.ForTenant(appID)).
+		Order("key ASC, priority DESC, created_at ASC").
+		Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("load config rules: %w", err)
+	}
+
+	var experiments []models.Experiment
+	if err := s.db.Scopes(tenant.ForTenant(appID)).Find(&experiments).Error; err != nil {
+		return nil, fmt.Errorf("load experiments: %w", err)
+	}
+
+	var deletions []struct {
+		Key       string
+		DeletedAt time.Time
+	}
+	if err := s.db.Model(&models.RemoteConfigRevision{}).
+		Scopes(tenant.ForTenant(appID)).
+		Select("key, MAX(created_at) AS deleted_at").
+		Where("new_value IS NULL").
+		Group("key").
+		Scan(&deletions).Error; err != nil {
+		return nil, fmt.Errorf("load config deletions: %w", err)
+	}
+
+	snap := &configSnapshot{
+		values:     make(map[string]interface{}, len(configs)),
+		types:      make(map[string]string, len(configs)),
+		keyChanged: make(map[string]time.Time, len(configs)),
+		deletedAt:  make(map[string]time.Time, len(deletions)),
+		loadedAt:   time.Now(),
+	}
+	touch := func(key string, t time.Time) {
+		if t.After(snap.keyChanged[key]) {
+			snap.keyChanged[key] = t
+		}
+		if t.After(snap.updatedAt) {
+			snap.updatedAt = t
+		}
+	}
+
+	for _, cfg := range configs {
+		snap.values[cfg.Key] = ParseConfigValue(cfg.Key, cfg.Type, cfg.Value)
+		snap.types[cfg.Key] = cfg.Type
+		touch(cfg.Key, cfg.UpdatedAt)
+	}
+	for _, rule := range rules {
+		touch(rule.Key, rule.UpdatedAt)
+		if rule.Enabled {
+			snap.rules = append(snap.rules, rule)
+		}
+	}
+	for _, exp := range experiments {
+		touch("experiments", exp.UpdatedAt)
+		if exp.ConfigKey != "" {
+			touch(exp.ConfigKey, exp.UpdatedAt)
+		}
+		if exp.Status == "running" {
+			snap.experiments = append(snap.experiments, exp)
+		}
+	}
+	for _, d := range deletions {
+		snap.deletedAt[d.Key] = d.DeletedAt
+		if d.DeletedAt.After(snap.updatedAt) {
+			snap.updatedAt = d.DeletedAt
+		}
+	}
+	snap.personalized = len(snap.rules) > 0 || len(snap.experiments) > 0
+	return snap, nil
+}
+
+// SharedBody returns the serialised full response and its strong ETag for
+// apps without rules or experiments, where every client gets identical bytes.
+// Computed once per snapshot; a failed encoding is retried on the next call.
+func (r *ResolvedConfig) SharedBody() ([]byte, string, error) {
+	snap := r.snapshot
+	snap.bodyMu.Lock()
+	defer snap.bodyMu.Unlock()
+	if snap.body == nil {
+		values := copyObject(snap.values)
+		values["config_version"] = snap.updatedAt.Unix()
+		body, err := json.Marshal(values)
+		if err != nil {
+			return nil, "", err
+		}
+		snap.body, snap.etag = body, StrongETag(body)
+	}
+	return snap.body, snap.etag, nil
+}
+
+// Delta returns the resolved values of keys changed at or after since, and the
+// keys deleted since then. Second precision means a change in the same second
+// as since is sent again, which clients treat as a harmless overwrite.
+func (r *ResolvedConfig) Delta(since time.Time) (map[string]interface{}, []string) {
+	changed := make(map[string]interface{})
+	for key, value := range r.Values {
+		if !r.snapshot.keyChanged[key].Before(since) {
+			changed[key] = value
+		}
+	}
+	deleted := make([]string, 0)
+	for key, at := range r.snapshot.deletedAt {
+		if _, present := r.Values[key]; present {
+			continue
+		}
+		if !at.Before(since) {
+			deleted = append(deleted, key)
+		}
+	}
+	return changed, deleted
+}
+
+// StrongETag derives a quoted strong validator from a response body.
+func StrongETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
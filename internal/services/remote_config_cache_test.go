@@ -0,0 +1,33 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSharedBodyRetriesFailedEncoding(t *testing.T) {
+	snap := &configSnapshot{
+		values:    map[string]interface{}{"ratio": math.NaN()},
+		updatedAt: time.Unix(1700000000, 0),
+	}
+	resolved := &ResolvedConfig{snapshot: snap}
+
+	for i := 0; i < 2; i++ {
+		if body, etag, err := resolved.SharedBody(); err == nil || body != nil || etag != "" {
+			t.Fatalf("call %d: SharedBody = %q, %q, %v; want an encoding error", i+1, body, etag, err)
+		}
+	}
+
+	snap.values["ratio"] = 0.5
+	body, etag, err := resolved.SharedBody()
+	if err != nil || string(body) != `{"config_version":1700000000,"ratio":0.5}` || etag != StrongETag(body) {
+		t.Fatalf("SharedBody after the fix = %s, %q, %v", body, etag, err)
+	}
+
+	// A successful body is kept for the snapshot's lifetime.
+	snap.values["ratio"] = 0.75
+	if again, _, _ := resolved.SharedBody(); string(again) != string(body) {
+		t.Errorf("SharedBody re-encoded a cached body: %s", again)
+	}
+}
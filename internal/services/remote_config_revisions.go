@@ -0,0 +1,353 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrConfigKeyNotFound = errors.New("config key not found")
+	ErrRevisionNotFound  = errors.New("config revision not found")
+	// ErrInvalidConfigValue wraps type and schema validation failures.
+	ErrInvalidConfigValue = errors.New("invalid config value")
+)
+
+// configState is one point-in-time value of a config key; nil means deleted.
+type configState struct {
+	Value  string
+	Type   string
+	Schema string
+}
+
+// SetKey creates or updates a config value and records the change as a revision.
+// An omitted type keeps the key's current type so editing a json value doesn't
+// silently turn it into a string.
+func (s *RemoteConfigService) SetKey(appID, key string, req *dto.SetConfigRequest, actor tenant.AdminActor) (*models.RemoteConfig, error) {
+	var result *models.RemoteConfig
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		existing, err := lockConfigRow(tx, appID, key)
+		if err != nil {
+			return err
+		}
+
+		next := configState{Value: req.Value, Type: req.Type}
+		if existing != nil {
+			if next.Type == "" {
+				next.Type = existing.Type
+			}
+			next.Schema = existing.Schema
+		}
+		if next.Type == "" {
+			next.Type = "string"
+		}
+		if req.Schema != nil {
+			next.Schema = *req.Schema
+		}
+		if next.Type != "json" && req.Schema == nil {
+			// Changing a key away from json drops its schema implicitly.
+			next.Schema = ""
+		}
+		if err := ValidateConfigValue(next.Type, next.Value, next.Schema); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidConfigValue, err)
+		}
+
+		action := "update"
+		if existing == nil {
+			action = "create"
+		}
+		result, err = applyConfigChange(tx, appID, key, existing, &next, action, actor, nil)
+		return err
+	})
+	if err == nil {
+		s.changed(appID)
+	}
+	return result, err
+}
+
+// DeleteKey removes a config key and records the deletion as a revision.
+func (s *RemoteConfigService) DeleteKey(appID, key string, actor tenant.AdminActor) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		existing, err := lockConfigRow(tx, appID, key)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return ErrConfigKeyNotFound
+		}
+		_, err = applyConfigChange(tx, appID, key, existing, nil, "delete", actor, nil)
+		return err
+	})
+	if err == nil {
+		s.changed(appID)
+	}
+	return err
+}
+
+// Rollback restores the state written by the given revision. Rolling back to a
+// delete revision deletes the key. The rollback itself is a new revision, so
+// it can be undone the same way.
+func (s *RemoteConfigService) Rollback(appID, key string, revision int, actor tenant.AdminActor) (*models.RemoteConfig, error) {
+	var result *models.RemoteConfig
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var target models.RemoteConfigRevision
+		if err := tx.Scopes(tenant.ForTenant(appID)).
+			Where("key = ? AND revision = ?", key, revision).
+			First(&target).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRevisionNotFound
+			}
+			return fmt.Errorf("load revision: %w", err)
+		}
+
+		existing, err := lockConfigRow(tx, appID, key)
+		if err != nil {
+			return err
+		}
+
+		var next *configState
+		if target.NewValue != nil {
+			next = &configState{Value: *target.NewValue, Type: target.NewType, Schema: target.NewSchema}
+		} else if existing == nil {
+			return errors.New("key is already deleted")
+		}
+
+		result, err = applyConfigChange(tx, appID, key, existing, next, "rollback", actor, &revision)
+		return err
+	})
+	if err == nil {
+		s.changed(appID)
+	}
+	return result, err
+}
+
+// History returns a key's revisions, newest first.
+func (s *RemoteConfigService) History(appID, key string, limit, offset int) ([]models.RemoteConfigRevision, int64, error) {
+	var revisions []models.RemoteConfigRevision
+	var total int64
+
+	query := s.db.Model(&models.RemoteConfigRevision{}).Scopes(tenant.ForTenant(appID)).Where("key = ?", key)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("count revisions: %w", err)
+	}
+	if err := query.Order("revision DESC").Limit(limit).Offset(offset).Find(&revisions).Error; err != nil {
+		return nil, 0, fmt.Errorf("list revisions: %w", err)
+	}
+	return revisions, total, nil
+}
+
+// SeedDefault inserts a default value if the key doesn't exist yet, recording
+// revision 1 with the "system" actor. Existing keys are never touched.
+func (s *RemoteConfigService) SeedDefault(def models.RemoteConfig) error {
+	seeded := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		existing, err := lockConfigRow(tx, def.AppID, def.Key)
+		if err != nil || existing != nil {
+			return err
+		}
+		next := configState{Value: def.Value, Type: def.Type, Schema: def.Schema}
+		_, err = applyConfigChange(tx, def.AppID, def.Key, nil, &next, "create", tenant.AdminActor{Type: "system"}, nil)
+		seeded = err == nil
+		return err
+	})
+	if seeded {
+		s.changed(def.AppID)
+	}
+	return err
+}
+
+func lockConfigRow(tx *gorm.DB, appID, key string) (*models.RemoteConfig, error) {
+	var cfg models.RemoteConfig
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Scopes(tenant.ForTenant(appID)).
+		Where("key = ?", key).
+		First(&cfg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// applyConfigChange moves a key from existing to next (nil = delete) and appends
+// the matching revision in the same transaction. A change that leaves the value,
+// type and schema untouched is a no-op and records nothing.
+func applyConfigChange(tx *gorm.DB, appID, key string, existing *models.RemoteConfig, next *configState, action string, actor tenant.AdminActor, rolledBackTo *int) (*models.RemoteConfig, error) {
+	var prev *configState
+	if existing != nil {
+		prev = &configState{Value: existing.Value, Type: existing.Type, Schema: existing.Schema}
+	}
+	if prev != nil && next != nil && *prev == *next {
+		return existing, nil
+	}
+
+	var result *models.RemoteConfig
+	switch {
+	case next == nil:
+		if err := tx.Delete(existing).Error; err != nil {
+			return nil, fmt.Errorf("delete config: %w", err)
+		}
+	case existing == nil:
+		result = &models.RemoteConfig{
+			ID:     uuid.New(),
+			AppID:  appID,
+			Key:    key,
+			Value:  next.Value,
+			Type:   next.Type,
+			Schema: next.Schema,
+		}
+		if err := tx.Create(result).Error; err != nil {
+			return nil, fmt.Errorf("create config: %w", err)
+		}
+	default:
+		if err := tx.Model(existing).Updates(map[string]interface{}{
+			"value":      next.Value,
+			"type":       next.Type,
+			"schema":     next.Schema,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+			return nil, fmt.Errorf("update config: %w", err)
+		}
+		result = existing
+	}
+
+	rev := models.RemoteConfigRevision{
+		AppID:        appID,
+		Key:          key,
+		Action:       action,
+		Diff:         configDiff(key, prev, next),
+		RolledBackTo: rolledBackTo,
+	}
+	if prev != nil {
+		rev.OldValue, rev.OldType, rev.OldSchema = &prev.Value, prev.Type, prev.Schema
+	}
+	if next != nil {
+		rev.NewValue, rev.NewType, rev.NewSchema = &next.Value, next.Type, next.Schema
+	}
+	if err := appendRevision(tx, &rev, actor); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// recordRuleChange appends a revision to base's history for a rule edit; prev
+// or next is nil when the rule is created or deleted. The key itself is
+// unchanged, so its state is both the old and the new one and a rollback to
+// the revision restores only the key. The diff holds the rule before and
+// after.
+func recordRuleChange(tx *gorm.DB, base *models.RemoteConfig, action string, prev, next *models.RemoteConfigRule, actor tenant.AdminActor) error {
+	ruleID := uuid.Nil
+	if next != nil {
+		ruleID = next.ID
+	} else if prev != nil {
+		ruleID = prev.ID
+	}
+	diff, err := json.Marshal(map[string]interface{}{
+		"rule_id": ruleID,
+		"from":    prev,
+		"to":      next,
+	})
+	if err != nil {
+		return fmt.Errorf("encode rule diff: %w", err)
+	}
+	rev := models.RemoteConfigRevision{
+		AppID:     base.AppID,
+		Key:       base.Key,
+		Action:    action,
+		OldValue:  &base.Value,
+		NewValue:  &base.Value,
+		OldType:   base.Type,
+		NewType:   base.Type,
+		OldSchema: base.Schema,
+		NewSchema: base.Schema,
+		Diff:      datatypes.JSON(diff),
+	}
+	return appendRevision(tx, &rev, actor)
+}
+
+// appendRevision numbers rev after the key's latest revision and stores it.
+// Callers hold the key's row lock, which keeps the numbers gapless.
+func appendRevision(tx *gorm.DB, rev *models.RemoteConfigRevision, actor tenant.AdminActor) error {
+	var last int
+	if err := tx.Model(&models.RemoteConfigRevision{}).
+		Scopes(tenant.ForTenant(rev.AppID)).
+		Where("key = ?", rev.Key).
+		Select("COALESCE(MAX(revision), 0)").
+		Scan(&last).Error; err != nil {
+		return fmt.Errorf("next revision: %w", err)
+	}
+
+	rev.ID = uuid.New()
+	rev.Revision = last + 1
+	rev.ActorType, rev.ActorID, rev.ActorEmail = actor.Type, actor.ID, actor.Email
+	if rev.ActorType == "" {
+		rev.ActorType = "user"
+	}
+	if err := tx.Create(rev).Error; err != nil {
+		return fmt.Errorf("record revision: %w", err)
+	}
+	return nil
+}
+
+// configDiff summarises a change. JSON objects get a per-property diff so a
+// paywall_config edit shows which fields moved; everything else is from/to.
+func configDiff(key string, prev, next *configState) datatypes.JSON {
+	diff := make(map[string]interface{})
+	var from, to interface{}
+	if prev != nil {
+		from = ParseConfigValue(key, prev.Type, prev.Value)
+	}
+	if next != nil {
+		to = ParseConfigValue(key, next.Type, next.Value)
+	}
+
+	fromObj, fromIsObj := from.(map[string]interface{})
+	toObj, toIsObj := to.(map[string]interface{})
+	if fromIsObj && toIsObj {
+		added := make(map[string]interface{})
+		removed := make(map[string]interface{})
+		changed := make(map[string]interface{})
+		for k, v := range toObj {
+			old, ok := fromObj[k]
+			if !ok {
+				added[k] = v
+			} else if !jsonEqual(old, v) {
+				changed[k] = map[string]interface{}{"from": old, "to": v}
+			}
+		}
+		for k, v := range fromObj {
+			if _, ok := toObj[k]; !ok {
+				removed[k] = v
+			}
+		}
+		diff["added"], diff["removed"], diff["changed"] = added, removed, changed
+	} else {
+		diff["from"], diff["to"] = from, to
+	}
+
+	if prev != nil && next != nil {
+		if prev.Type != next.Type {
+			diff["type"] = map[string]string{"from": prev.Type, "to": next.Type}
+		}
+		if prev.Schema != next.Schema {
+			diff["schema_changed"] = true
+		}
+	}
+
+	b, err := json.Marshal(diff)
+	if err != nil {
+		return datatypes.JSON("{}")
+	}
+	return datatypes.JSON(b)
+}
@@ -0,0 +1,532 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrRuleNotFound = errors.New("config rule not found")
+
+var validPlatforms = map[string]bool{"ios": true, "android": true, "web": true}
+
+// ClientContext describes the caller of GET /api/config. Every field is optional;
+// rules whose conditions reference a missing field simply do not match.
+type ClientContext struct {
+	AppVersion string
+	Platform   string
+	Locale     string
+	Country    string
+	DeviceID   string
+	UserID     *uuid.UUID
+}
+
+// ResolvedConfig is the per-client view of an app's remote config.
+type ResolvedConfig struct {
+	Values map[string]interface{}
+	// UpdatedAt is the latest change across config rows, rules, experiments and
+	// deletions; it drives config_version.
+	UpdatedAt time.Time
+	// Personalized is true when the app has active rules or experiments, so
+	// responses must not be shared between clients.
+	Personalized bool
+
+	snapshot *configSnapshot
+}
+
+// userTraits are the user-level attributes rules can target, loaded lazily once per request.
+type userTraits struct {
+	loaded             bool
+	found              bool
+	signedUpAt         time.Time
+	subscriptionStatus string
+}
+
+type RemoteConfigService struct {
+	db          *gorm.DB
+	experiments *ExperimentService
+
+	cacheMu   sync.RWMutex
+	cache     map[string]*configSnapshot
+	listeners []func(appID string)
+}
+
+func NewRemoteConfigService(db *gorm.DB, experiments *ExperimentService) *RemoteConfigService {
+	s := &RemoteConfigService{
+		db:          db,
+		experiments: experiments,
+		cache:       make(map[string]*configSnapshot),
+	}
+	if experiments != nil {
+		experiments.OnChange(s.changed)
+	}
+	return s
+}
+
+// Resolve evaluates the app's config rows, targeting rules and running
+// experiments for one client. Rows and rules come from the in-memory snapshot;
+// only user traits for user-targeted conditions touch the database.
+func (s *RemoteConfigService) Resolve(appID string, cc ClientContext) (*ResolvedConfig, error) {
+	snap, err := s.snapshot(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := &ResolvedConfig{
+		Values:       copyObject(snap.values),
+		UpdatedAt:    snap.updatedAt,
+		Personalized: snap.personalized,
+		snapshot:     snap,
+	}
+
+	traits := &userTraits{}
+	matchedKeys := make(map[string]bool)
+	for i := range snap.rules {
+		rule := &snap.rules[i]
+		if matchedKeys[rule.Key] {
+			continue
+		}
+		if !s.ruleMatches(appID, rule, cc, traits) {
+			continue
+		}
+		matchedKeys[rule.Key] = true
+		typ := snap.types[rule.Key]
+		if typ == "" {
+			typ = rule.Type
+		}
+		resolved.Values[rule.Key] = ParseConfigValue(rule.Key, typ, rule.Value)
+	}
+
+	// Experiments run after rules so a variant can build on a targeted value.
+	if s.experiments != nil && len(snap.experiments) > 0 {
+		s.experiments.Apply(appID, cc, resolved, snap.types, snap.experiments)
+	}
+
+	return resolved, nil
+}
+
+func (s *RemoteConfigService) ruleMatches(appID string, rule *models.RemoteConfigRule, cc ClientContext, traits *userTraits) bool {
+	var cond models.RuleConditions
+	if len(rule.Conditions) > 0 {
+		if err := json.Unmarshal(rule.Conditions, &cond); err != nil {
+			slog.Warn("remote config rule has invalid conditions, skipping", "rule_id", rule.ID, "error", err)
+			return false
+		}
+	}
+	if !conditionsMatch(s.db, appID, &cond, cc, traits) {
+		return false
+	}
+
+	if rule.RolloutPercent < 100 {
+		subject := cc.DeviceID
+		if cc.UserID != nil {
+			subject = cc.UserID.String()
+		}
+		if subject == "" {
+			return false
+		}
+		// Salting with the rule ID keeps separate rollouts independent: the same
+		// 10% of users is not always first in line for every new feature.
+		if hashBucket(rule.ID.String()+":"+subject) >= rule.RolloutPercent {
+			return false
+		}
+	}
+
+	return true
+}
+
+// conditionsMatch evaluates targeting conditions shared by config rules and experiments.
+func conditionsMatch(db *gorm.DB, appID string, cond *models.RuleConditions, cc ClientContext, traits *userTraits) bool {
+	if cond.MinAppVersion != "" || cond.MaxAppVersion != "" {
+		if cc.AppVersion == "" {
+			return false
+		}
+		if cond.MinAppVersion != "" {
+			cmp, err := CompareVersions(cc.AppVersion, cond.MinAppVersion)
+			if err != nil || cmp < 0 {
+				return false
+			}
+		}
+		if cond.MaxAppVersion != "" {
+			cmp, err := CompareVersions(cc.AppVersion, cond.MaxAppVersion)
+			if err != nil || cmp > 0 {
+				return false
+			}
+		}
+	}
+	if len(cond.Platforms) > 0 && !containsFold(cond.Platforms, cc.Platform) {
+		return false
+	}
+	if len(cond.Locales) > 0 && !localeMatches(cond.Locales, cc.Locale) {
+		return false
+	}
+	if len(cond.Countries) > 0 && !containsFold(cond.Countries, cc.Country) {
+		return false
+	}
+
+	if cond.RequiresUser() {
+		if cc.UserID == nil {
+			return false
+		}
+		if cond.UserBucketMin != nil || cond.UserBucketMax != nil {
+			bucket := UserBucket(*cc.UserID)
+			if cond.UserBucketMin != nil && bucket < *cond.UserBucketMin {
+				return false
+			}
+			if cond.UserBucketMax != nil && bucket > *cond.UserBucketMax {
+				return false
+			}
+		}
+		if len(cond.SubscriptionStatuses) > 0 || cond.SignedUpAfter != nil || cond.SignedUpBefore != nil {
+			loadUserTraits(db, appID, *cc.UserID, traits)
+			if !traits.found {
+				return false
+			}
+			if len(cond.SubscriptionStatuses) > 0 && !containsFold(cond.SubscriptionStatuses, traits.subscriptionStatus) {
+				return false
+			}
+			if cond.SignedUpAfter != nil && !traits.signedUpAt.After(*cond.SignedUpAfter) {
+				return false
+			}
+			if cond.SignedUpBefore != nil && !traits.signedUpAt.Before(*cond.SignedUpBefore) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func loadUserTraits(db *gorm.DB, appID string, userID uuid.UUID, traits *userTraits) {
+	if traits.loaded {
+		return
+	}
+	traits.loaded = true
+
+	var user models.User
+	if err := db.Scopes(tenant.ForTenant(appID)).Select("id", "created_at").First(&user, "id = ?", userID).Error; err != nil {
+		return
+	}
+	traits.found = true
+	traits.signedUpAt = user.CreatedAt
+	traits.subscriptionStatus = "none"
+
+	var sub models.Subscription
+	if err := db.Scopes(tenant.ForTenant(appID)).
+		Where("user_id = ?", userID).
+		Order("updated_at DESC").
+		First(&sub).Error; err == nil {
+		traits.subscriptionStatus = sub.Status
+	}
+}
+
+// ListRules returns every rule for a key, in evaluation order.
+func (s *RemoteConfigService) ListRules(appID, key string) ([]models.RemoteConfigRule, error) {
+	var rules []models.RemoteConfigRule
+	if err := s.db.Scopes(tenant.ForTenant(appID)).
+		Where("key = ?", key).
+		Order("priority DESC, created_at ASC").
+		Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("list config rules: %w", err)
+	}
+	return rules, nil
+}
+
+// CreateRule adds a rule to an existing key and records it as a revision of
+// the key.
+func (s *RemoteConfigService) CreateRule(appID, key string, req *dto.RemoteConfigRuleRequest, actor tenant.AdminActor) (*models.RemoteConfigRule, error) {
+	rule := models.RemoteConfigRule{
+		ID:    uuid.New(),
+		AppID: appID,
+		Key:   key,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		base, err := lockConfigRow(tx, appID, key)
+		if err != nil {
+			return err
+		}
+		if base == nil {
+			return ErrConfigKeyNotFound
+		}
+		if err := applyRuleRequest(&rule, req, base); err != nil {
+			return err
+		}
+		if err := tx.Create(&rule).Error; err != nil {
+			return fmt.Errorf("create config rule: %w", err)
+		}
+		return recordRuleChange(tx, base, "rule_create", nil, &rule, actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.changed(appID)
+	return &rule, nil
+}
+
+// UpdateRule replaces a rule and records the change as a revision of its key.
+func (s *RemoteConfigService) UpdateRule(appID string, ruleID uuid.UUID, req *dto.RemoteConfigRuleRequest, actor tenant.AdminActor) (*models.RemoteConfigRule, error) {
+	var rule models.RemoteConfigRule
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := loadRule(tx, appID, ruleID, &rule); err != nil {
+			return err
+		}
+		base, err := lockConfigRow(tx, appID, rule.Key)
+		if err != nil {
+			return err
+		}
+		if base == nil {
+			return ErrConfigKeyNotFound
+		}
+		prev := rule
+		if err := applyRuleRequest(&rule, req, base); err != nil {
+			return err
+		}
+		if err := tx.Save(&rule).Error; err != nil {
+			return fmt.Errorf("update config rule: %w", err)
+		}
+		return recordRuleChange(tx, base, "rule_update", &prev, &rule, actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.changed(appID)
+	return &rule, nil
+}
+
+// DeleteRule removes a rule and records the deletion as a revision of its key.
+func (s *RemoteConfigService) DeleteRule(appID string, ruleID uuid.UUID, actor tenant.AdminActor) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var rule models.RemoteConfigRule
+		if err := loadRule(tx, appID, ruleID, &rule); err != nil {
+			return err
+		}
+		base, err := lockConfigRow(tx, appID, rule.Key)
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&rule).Error; err != nil {
+			return fmt.Errorf("delete config rule: %w", err)
+		}
+		if base == nil {
+			// The key was deleted before its rules; there is no history to add to.
+			return nil
+		}
+		// A deleted rule leaves no row behind to carry its timestamp, so bump the
+		// base key instead; otherwise config_version and delta sync would miss it.
+		if err := tx.Model(base).Update("updated_at", time.Now()).Error; err != nil {
+			return fmt.Errorf("touch config key: %w", err)
+		}
+		return recordRuleChange(tx, base, "rule_delete", &rule, nil, actor)
+	})
+	if err != nil {
+		return err
+	}
+	s.changed(appID)
+	return nil
+}
+
+func loadRule(tx *gorm.DB, appID string, ruleID uuid.UUID, rule *models.RemoteConfigRule) error {
+	if err := tx.Scopes(tenant.ForTenant(appID)).First(rule, "id = ?", ruleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRuleNotFound
+		}
+		return fmt.Errorf("load config rule: %w", err)
+	}
+	return nil
+}
+
+// applyRuleRequest fills rule from req. A rule serves its value in place of
+// the key's, so the value must satisfy the key's type and schema.
+func applyRuleRequest(rule *models.RemoteConfigRule, req *dto.RemoteConfigRuleRequest, base *models.RemoteConfig) error {
+	if req.Type != "" && req.Type != base.Type {
+		return fmt.Errorf("%w: rule type %s does not match the key's type %s", ErrInvalidConfigValue, req.Type, base.Type)
+	}
+	if err := ValidateConfigValue(base.Type, req.Value, base.Schema); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfigValue, err)
+	}
+	rollout := 100
+	if req.RolloutPercent != nil {
+		rollout = *req.RolloutPercent
+	}
+	if err := validateRuleConditions(&req.Conditions); err != nil {
+		return err
+	}
+
+	conditions, err := json.Marshal(req.Conditions)
+	if err != nil {
+		return fmt.Errorf("encode conditions: %w", err)
+	}
+
+	rule.Name = strings.TrimSpace(req.Name)
+	rule.Priority = req.Priority
+	rule.Conditions = conditions
+	rule.RolloutPercent = rollout
+	rule.Value = req.Value
+	rule.Type = base.Type
+	rule.Enabled = true
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+func validateRuleConditions(cond *models.RuleConditions) error {
+	for _, v := range []string{cond.MinAppVersion, cond.MaxAppVersion} {
+		if v == "" {
+			continue
+		}
+		if _, err := parseVersion(v); err != nil {
+			return fmt.Errorf("invalid app version %q", v)
+		}
+	}
+	for i, p := range cond.Platforms {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if !validPlatforms[p] {
+			return fmt.Errorf("invalid platform %q: must be ios, android, or web", p)
+		}
+		cond.Platforms[i] = p
+	}
+	for i, c := range cond.Countries {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if len(c) != 2 {
+			return fmt.Errorf("invalid country %q: must be an ISO 3166-1 alpha-2 code", c)
+		}
+		cond.Countries[i] = c
+	}
+	for _, b := range []*int{cond.UserBucketMin, cond.UserBucketMax} {
+		if b != nil && (*b < 0 || *b > 99) {
+			return errors.New("user buckets must be between 0 and 99")
+		}
+	}
+	if cond.UserBucketMin != nil && cond.UserBucketMax != nil && *cond.UserBucketMin > *cond.UserBucketMax {
+		return errors.New("user_bucket_min must not exceed user_bucket_max")
+	}
+	return nil
+}
+
+// ParseConfigValue converts a stored string value to its typed JSON representation.
+// Unparseable int/json values fall back to the raw string.
+func ParseConfigValue(key, typ, raw string) interface{} {
+	switch typ {
+	case "bool":
+		return raw == "true" || raw == "1"
+	case "int":
+		if v, err := strconv.Atoi(raw); err == nil {
+			return v
+		}
+		return raw
+	case "json":
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+			slog.Warn("remote config value is not valid JSON, using raw string", "key", key, "error", err)
+			return raw
+		}
+		return parsed
+	default:
+		return raw
+	}
+}
+
+// UserBucket maps a user ID to a stable bucket in [0, 100).
+func UserBucket(userID uuid.UUID) int {
+	return hashBucket(userID.String())
+}
+
+func hashBucket(s string) int {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return int(h.Sum32() % 100)
+}
+
+// CompareVersions compares dotted numeric versions ("1.2.10" vs "1.2.9").
+// Pre-release/build suffixes ("-beta", "+42") are ignored and missing
+// components count as zero. Returns -1, 0 or 1.
+func CompareVersions(a, b string) (int, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < len(va) || i < len(vb); i++ {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		if x < y {
+			return -1, nil
+		}
+		if x > y {
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func parseVersion(v string) ([]int, error) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if idx := strings.IndexAny(v, "-+ "); idx >= 0 {
+		v = v[:idx]
+	}
+	if v == "" {
+		return nil, errors.New("empty version")
+	}
+	parts := strings.Split(v, ".")
+	out := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version component %q", p)
+		}
+		out[i] = n
+	}
+	return out, nil
+}
+
+func containsFold(list []string, val string) bool {
+	if val == "" {
+		return false
+	}
+	for _, item := range list {
+		if strings.EqualFold(item, val) {
+			return true
+		}
+	}
+	return false
+}
+
+// localeMatches accepts exact tags ("pt-BR") and bare languages ("pt" matches "pt-BR").
+func localeMatches(locales []string, locale string) bool {
+	if locale == "" {
+		return false
+	}
+	locale = strings.ReplaceAll(locale, "_", "-")
+	lang := locale
+	if idx := strings.Index(locale, "-"); idx > 0 {
+		lang = locale[:idx]
+	}
+	for _, l := range locales {
+		l = strings.ReplaceAll(l, "_", "-")
+		if strings.EqualFold(l, locale) || strings.EqualFold(l, lang) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/localtime"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrNoStreaks = errors.New("app has no streaks to recompute")
+
+// StreakRecomputer is implemented by plugins that keep per-user streak rows.
+// RecomputeStreaks rebuilds a user's rows from their raw activity, bucketing
+// it by civil day in loc.
+type StreakRecomputer interface {
+	RecomputeStreaks(db *gorm.DB, appID string, userID uuid.UUID, loc *time.Location) error
+}
+
+const streakRecomputeBatch = 200
+
+// StreakRecomputeService rebuilds streak rows after a user's timezone changes,
+// and for whole apps on demand (e.g. rows written before timezones existed).
+type StreakRecomputeService struct {
+	db          *gorm.DB
+	recomputers map[string]StreakRecomputer
+}
+
+func NewStreakRecomputeService(db *gorm.DB, recomputers map[string]StreakRecomputer) *StreakRecomputeService {
+	return &StreakRecomputeService{db: db, recomputers: recomputers}
+}
+
+// RecomputeUser rebuilds one user's streaks in their current timezone. A
+// no-op for apps without streaks.
+func (s *StreakRecomputeService) RecomputeUser(appID string, userID uuid.UUID) error {
+	r, ok := s.recomputers[appID]
+	if !ok {
+		return nil
+	}
+	return r.RecomputeStreaks(s.db, appID, userID, localtime.ForUser(s.db, appID, userID))
+}
+
+// RecomputeApp rebuilds streaks for every user of the app, in batches.
+// Failures for individual users are logged and counted, not fatal.
+func (s *StreakRecomputeService) RecomputeApp(appID string) (done, failed int, err error) {
+	r, ok := s.recomputers[appID]
+	if !ok {
+		return 0, 0, ErrNoStreaks
+	}
+
+	var lastID uuid.UUID
+	for {
+		var users []models.User
+		if err := s.db.Scopes(tenant.ForTenant(appID)).
+			Select("id", "timezone").
+			Where("id > ?", lastID).
+			Order("id ASC").
+			Limit(streakRecomputeBatch).
+			Find(&users).Error; err != nil {
+			return done, failed, fmt.Errorf("load users: %w", err)
+		}
+		for _, u := range users {
+			if err := r.RecomputeStreaks(s.db, appID, u.ID, localtime.Location(u.Timezone)); err != nil {
+				slog.Error("streak recompute failed", "app_id", appID, "user_id", u.ID, "error", err)
+				failed++
+				continue
+			}
+			done++
+		}
+		if len(users) < streakRecomputeBatch {
+			return done, failed, nil
+		}
+		lastID = users[len(users)-1].ID
+	}
+}
+
+// Supports reports whether the app has streaks to recompute.
+func (s *StreakRecomputeService) Supports(appID string) bool {
+	_, ok := s.recomputers[appID]
+	return ok
+}
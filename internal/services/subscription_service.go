@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/events"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
 	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
 	"github.com/google/uuid"
@@ -13,11 +14,13 @@ import (
 )
 
 type SubscriptionService struct {
-	db *gorm.DB
+	db          *gorm.DB
+	experiments *ExperimentService
+	events      *events.Bus
 }
 
-func NewSubscriptionService(db *gorm.DB) *SubscriptionService {
-	return &SubscriptionService{db: db}
+func NewSubscriptionService(db *gorm.DB, experiments *ExperimentService, bus *events.Bus) *SubscriptionService {
+	return &SubscriptionService{db: db, experiments: experiments, events: bus}
 }
 
 func (s *SubscriptionService) HandleWebhookEvent(appID string, event *dto.RevenueCatEvent) error {
@@ -34,43 +37,79 @@ func (s *SubscriptionService) HandleWebhookEvent(appID string, event *dto.Revenu
 		slog.Warn("webhook event missing required ID field — rejected", "type", event.Type, "app", appID)
 		return fmt.Errorf("webhook event missing required ID field")
 	}
-	result := s.db.Exec(
-		`INSERT INTO processed_webhook_events (event_id, processed_at) VALUES (?, ?) ON CONFLICT (event_id) DO NOTHING`,
-		event.ID, time.Now().UTC(),
-	)
-	if result.Error != nil {
-		return fmt.Errorf("webhook idempotency check failed: %w", result.Error)
+	// The idempotency row, the subscription write and the subscription.changed
+	// outbox rows commit together. If any of them fails the webhook errors
+	// and RevenueCat retries the whole event, so plugins never miss a change
+	// that was stored or see one that wasn't.
+	var status string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(
+			`INSERT INTO processed_webhook_events (event_id, processed_at) VALUES (?, ?) ON CONFLICT (event_id) DO NOTHING`,
+			event.ID, time.Now().UTC(),
+		)
+		if result.Error != nil {
+			return fmt.Errorf("webhook idempotency check failed: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			slog.Info("duplicate webhook event skipped", "event_id", event.ID, "type", event.Type)
+			return nil
+		}
+
+		var err error
+		switch event.Type {
+		case "INITIAL_PURCHASE":
+			status, err = "active", s.handleInitialPurchase(tx, appID, event)
+		case "RENEWAL":
+			status, err = "active", s.handleRenewal(tx, appID, event)
+		case "CANCELLATION":
+			status, err = "cancelled", s.handleCancellation(tx, appID, event)
+		case "EXPIRATION", "EXPIRED_FROM_BILLING_ISSUE":
+			status, err = "expired", s.handleExpiration(tx, appID, event)
+		case "ENTERED_GRACE_PERIOD", "BILLING_ISSUE":
+			// Grace period: subscriber's payment failed but RevenueCat gives them time to fix it.
+			// Keep subscription active but mark it as grace_period so the app can surface a notice.
+			status, err = "grace_period", s.handleGracePeriod(tx, appID, event)
+		default:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return s.publishChange(tx, appID, event, status)
+	})
+	if err != nil {
+		return err
 	}
-	if result.RowsAffected == 0 {
-		slog.Info("duplicate webhook event skipped", "event_id", event.ID, "type", event.Type)
-		return nil
+	if event.Type == "INITIAL_PURCHASE" && status != "" {
+		s.trackPurchaseConversion(appID, event)
 	}
+	return nil
+}
 
-	switch event.Type {
-	case "INITIAL_PURCHASE":
-		return s.handleInitialPurchase(appID, event)
-	case "RENEWAL":
-		return s.handleRenewal(appID, event)
-	case "CANCELLATION":
-		return s.handleCancellation(appID, event)
-	case "EXPIRATION", "EXPIRED_FROM_BILLING_ISSUE":
-		return s.handleExpiration(appID, event)
-	case "ENTERED_GRACE_PERIOD", "BILLING_ISSUE":
-		// Grace period: subscriber's payment failed but RevenueCat gives them time to fix it.
-		// Keep subscription active but mark it as grace_period so the app can surface a notice.
-		return s.handleGracePeriod(appID, event)
-	default:
-		return nil
+// publishChange announces the new subscription state to plugins in tx. The
+// RevenueCat app_user_id is our user ID when the app configured it that way;
+// otherwise the event carries no user.
+func (s *SubscriptionService) publishChange(tx *gorm.DB, appID string, event *dto.RevenueCatEvent, status string) error {
+	userID, _ := uuid.Parse(event.AppUserID)
+	payload := events.SubscriptionChangedPayload{
+		Event:     event.Type,
+		Status:    status,
+		ProductID: event.ProductID,
+	}
+	if event.ExpirationAtMs > 0 {
+		expires := msToTime(event.ExpirationAtMs)
+		payload.ExpiresAt = &expires
 	}
+	return s.events.Publish(tx, appID, userID, events.SubscriptionChanged, payload)
 }
 
-func (s *SubscriptionService) handleInitialPurchase(appID string, event *dto.RevenueCatEvent) error {
+func (s *SubscriptionService) handleInitialPurchase(tx *gorm.DB, appID string, event *dto.RevenueCatEvent) error {
 	// Upsert: if a subscription already exists for this RevenueCat user (e.g. from a trial
 	// that converted), update it rather than inserting a duplicate row.
 	var existing models.Subscription
-	err := s.db.Scopes(tenant.ForTenant(appID)).Where("revenuecat_id = ?", event.AppUserID).First(&existing).Error
+	err := tx.Scopes(tenant.ForTenant(appID)).Where("revenuecat_id = ?", event.AppUserID).First(&existing).Error
 	if err == nil {
-		return s.db.Model(&existing).Updates(map[string]interface{}{
+		return tx.Model(&existing).Updates(map[string]interface{}{
 			"status":               "active",
 			"product_id":           event.ProductID,
 			"current_period_start": msToTime(event.PurchasedAtMs),
@@ -89,18 +128,30 @@ func (s *SubscriptionService) handleInitialPurchase(appID string, event *dto.Rev
 	}
 
 	var user models.User
-	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("id = ?", event.AppUserID).First(&user).Error; err == nil {
+	if err := tx.Scopes(tenant.ForTenant(appID)).Where("id = ?", event.AppUserID).First(&user).Error; err == nil {
 		sub.UserID = user.ID
 	}
 
-	return s.db.Create(&sub).Error
+	return tx.Create(&sub).Error
+}
+
+// trackPurchaseConversion credits the purchase to any running experiment whose
+// goal is "purchase". Failures are logged only — the subscription itself is
+// already stored and RevenueCat must not retry because of analytics.
+func (s *SubscriptionService) trackPurchaseConversion(appID string, event *dto.RevenueCatEvent) {
+	if s.experiments == nil || event.AppUserID == "" {
+		return
+	}
+	if _, err := s.experiments.TrackEvent(appID, event.AppUserID, ExperimentGoalPurchase, "", "revenuecat"); err != nil {
+		slog.Error("experiment purchase conversion failed", "app_id", appID, "event_id", event.ID, "error", err)
+	}
 }
 
-func (s *SubscriptionService) handleGracePeriod(appID string, event *dto.RevenueCatEvent) error {
+func (s *SubscriptionService) handleGracePeriod(tx *gorm.DB, appID string, event *dto.RevenueCatEvent) error {
 	// Keep the subscription accessible during grace period but mark status so the
 	// app can display a "payment issue" banner. If no subscription row exists yet,
 	// this is a no-op (RevenueCat may send BILLING_ISSUE before INITIAL_PURCHASE in edge cases).
-	result := s.db.Model(&models.Subscription{}).
+	result := tx.Model(&models.Subscription{}).
 		Scopes(tenant.ForTenant(appID)).
 		Where("revenuecat_id = ?", event.AppUserID).
 		Update("status", "grace_period")
@@ -113,28 +164,28 @@ func (s *SubscriptionService) handleGracePeriod(appID string, event *dto.Revenue
 	return nil
 }
 
-func (s *SubscriptionService) handleRenewal(appID string, event *dto.RevenueCatEvent) error {
+func (s *SubscriptionService) handleRenewal(tx *gorm.DB, appID string, event *dto.RevenueCatEvent) error {
 	var sub models.Subscription
-	if err := s.db.Scopes(tenant.ForTenant(appID)).Where("revenuecat_id = ?", event.AppUserID).First(&sub).Error; err != nil {
+	if err := tx.Scopes(tenant.ForTenant(appID)).Where("revenuecat_id = ?", event.AppUserID).First(&sub).Error; err != nil {
 		return fmt.Errorf("subscription not found for renewal: %w", err)
 	}
 
-	return s.db.Model(&sub).Updates(map[string]interface{}{
+	return tx.Model(&sub).Updates(map[string]interface{}{
 		"status":               "active",
 		"current_period_end":   msToTime(event.ExpirationAtMs),
 		"current_period_start": msToTime(event.PurchasedAtMs),
 	}).Error
 }
 
-func (s *SubscriptionService) handleCancellation(appID string, event *dto.RevenueCatEvent) error {
-	return s.db.Model(&models.Subscription{}).
+func (s *SubscriptionService) handleCancellation(tx *gorm.DB, appID string, event *dto.RevenueCatEvent) error {
+	return tx.Model(&models.Subscription{}).
 		Scopes(tenant.ForTenant(appID)).
 		Where("revenuecat_id = ?", event.AppUserID).
 		Update("status", "cancelled").Error
 }
 
-func (s *SubscriptionService) handleExpiration(appID string, event *dto.RevenueCatEvent) error {
-	return s.db.Model(&models.Subscription{}).
+func (s *SubscriptionService) handleExpiration(tx *gorm.DB, appID string, event *dto.RevenueCatEvent) error {
+	return tx.Model(&models.Subscription{}).
 		Scopes(tenant.ForTenant(appID)).
 		Where("revenuecat_id = ?", event.AppUserID).
 		Update("status", "expired").Error
@@ -0,0 +1,493 @@
+// Package streaks is the shared streak engine used by app plugins.
+//
+// A streak is a run of active days for one kind of activity ("journal",
+// "sleep", ...). Days are the user's local calendar days (see localtime), and
+// a Policy per kind decides how far apart active days may be, whether a missed
+// day is forgiven by a grace window or a freeze, and which milestones unlock
+// badges. All kinds share one table (models.Streak) keyed by app, user and
+// kind.
+package streaks
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/localtime"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNoStreak        = errors.New("no streak record found for user")
+	ErrNoFreezes       = errors.New("no streak freezes available")
+	ErrFreezeLimit     = errors.New("streak freeze limit reached")
+	ErrFreezeNotNeeded = errors.New("streak freeze can only be used after a missed day")
+)
+
+// Milestone is a badge unlocked once the streak (or, with Total, the number
+// of recorded activities) reaches Required.
+type Milestone struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Emoji       string `json:"emoji,omitempty"`
+	Required    int    `json:"required"`
+	Total       bool   `json:"-"`
+}
+
+// Badge is a milestone with the user's progress towards it.
+type Badge struct {
+	Milestone
+	Unlocked bool `json:"unlocked"`
+}
+
+// Policy configures one kind of streak.
+type Policy struct {
+	// MaxGap is the largest number of calendar days between two active days
+	// that still continues the streak. 1 (the default) means consecutive days.
+	MaxGap int
+
+	// A grace window forgives a single missed day once the streak is at
+	// least GraceMinStreak long, at most once per GraceCooldown. Zero
+	// GraceMinStreak disables it.
+	GraceMinStreak int
+	GraceCooldown  time.Duration
+
+	// AutoFreeze spends available freezes (one per missed day) to bridge a
+	// gap on the next activity. Without it freezes are spent with UseFreeze.
+	AutoFreeze bool
+	// InitialFreezes is the balance a new streak starts with; MaxFreezes caps
+	// AddFreeze (0 means no cap).
+	InitialFreezes int
+	MaxFreezes     int
+
+	Milestones []Milestone
+}
+
+// Result describes what a recorded activity did to the streak.
+type Result struct {
+	Streak *models.Streak
+	// NewDay is false when the user was already active on this local day.
+	NewDay bool
+	// Previous is the streak length before the activity; Broken reports
+	// that it was reset.
+	Previous int
+	Broken   bool
+	// UsedGrace and FrozenDays report how a gap was bridged. A bridged gap
+	// keeps the streak alive without extending it.
+	UsedGrace  bool
+	FrozenDays int
+	// Unlocked lists milestones reached by this activity.
+	Unlocked []Milestone
+}
+
+// Engine records activity for one kind of streak.
+type Engine struct {
+	db     *gorm.DB
+	kind   string
+	policy Policy
+}
+
+func New(db *gorm.DB, kind string, policy Policy) *Engine {
+	if policy.MaxGap < 1 {
+		policy.MaxGap = 1
+	}
+	milestones := make([]Milestone, len(policy.Milestones))
+	copy(milestones, policy.Milestones)
+	sort.SliceStable(milestones, func(i, j int) bool { return milestones[i].Required < milestones[j].Required })
+	policy.Milestones = milestones
+	return &Engine{db: db, kind: kind, policy: policy}
+}
+
+// Kind returns the streak kind this engine maintains.
+func (e *Engine) Kind() string { return e.kind }
+
+func (e *Engine) scope(appID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(tenant.ForTenant(appID)).Where("kind = ?", e.kind)
+	}
+}
+
+// Get returns the user's streak, or an unsaved empty one if they have no
+// activity yet.
+func (e *Engine) Get(appID string, userID uuid.UUID) (*models.Streak, error) {
+	var st models.Streak
+	err := e.db.Scopes(e.scope(appID)).Where("user_id = ?", userID).First(&st).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return e.empty(appID, userID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// ForUsers returns the streaks of the given users, keyed by user ID. Users
+// without a streak are absent from the map.
+func (e *Engine) ForUsers(appID string, userIDs []uuid.UUID) (map[uuid.UUID]models.Streak, error) {
+	var rows []models.Streak
+	if err := e.db.Scopes(e.scope(appID)).Where("user_id IN ?", userIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	byUser := make(map[uuid.UUID]models.Streak, len(rows))
+	for _, st := range rows {
+		byUser[st.UserID] = st
+	}
+	return byUser, nil
+}
+
+func (e *Engine) empty(appID string, userID uuid.UUID) *models.Streak {
+	return &models.Streak{
+		AppID:            appID,
+		UserID:           userID,
+		Kind:             e.kind,
+		FreezesAvailable: e.policy.InitialFreezes,
+		UnlockedBadges:   []string{},
+	}
+}
+
+// locked loads the user's streak row for update inside tx, creating it first
+// if needed.
+func (e *Engine) locked(tx *gorm.DB, appID string, userID uuid.UUID) (*models.Streak, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(e.empty(appID, userID)).Error; err != nil {
+		return nil, fmt.Errorf("create streak: %w", err)
+	}
+	var st models.Streak
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Scopes(e.scope(appID)).
+		Where("user_id = ?", userID).
+		First(&st).Error; err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Record registers an activity at the given instant in the user's timezone.
+func (e *Engine) Record(appID string, userID uuid.UUID, at time.Time) (*Result, error) {
+	return e.RecordIn(appID, userID, at, localtime.ForUser(e.db, appID, userID))
+}
+
+// RecordIn registers an activity at the given instant, bucketed into a day
+// in loc.
+func (e *Engine) RecordIn(appID string, userID uuid.UUID, at time.Time, loc *time.Location) (*Result, error) {
+	var res *Result
+	err := e.db.Transaction(func(tx *gorm.DB) error {
+		st, err := e.locked(tx, appID, userID)
+		if err != nil {
+			return err
+		}
+		res = e.apply(st, at, localtime.Day(at, loc))
+		return tx.Save(st).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (e *Engine) apply(st *models.Streak, at, day time.Time) *Result {
+	res := &Result{Streak: st, Previous: st.CurrentStreak}
+
+	st.TotalCount++
+	if st.LastActivityAt == nil || at.After(*st.LastActivityAt) {
+		st.LastActivityAt = &at
+	}
+
+	gap := 1
+	if st.LastActiveDay != nil {
+		gap = localtime.DaysBetween(*st.LastActiveDay, day)
+	}
+
+	switch {
+	case gap <= 0:
+		// Same day, or an activity backdated before the last active day:
+		// it only counts towards the total.
+	case gap <= e.policy.MaxGap || st.CurrentStreak == 0:
+		st.CurrentStreak++
+		st.GraceActive = false
+	case e.graceAllows(st, gap-e.policy.MaxGap, at):
+		st.GraceActive = true
+		st.GraceUsedAt = &at
+		res.UsedGrace = true
+	case e.policy.AutoFreeze && st.FreezesAvailable >= gap-e.policy.MaxGap:
+		missed := gap - e.policy.MaxGap
+		st.FreezesAvailable -= missed
+		st.FreezesUsed += missed
+		st.LastFreezeAt = &at
+		res.FrozenDays = missed
+	default:
+		st.CurrentStreak = 1
+		st.GraceActive = false
+		res.Broken = true
+	}
+
+	if gap > 0 {
+		res.NewDay = true
+		st.LastActiveDay = &day
+	}
+	if st.CurrentStreak > st.LongestStreak {
+		st.LongestStreak = st.CurrentStreak
+	}
+	res.Unlocked = e.unlock(st)
+	return res
+}
+
+func (e *Engine) graceAllows(st *models.Streak, missed int, at time.Time) bool {
+	if e.policy.GraceMinStreak <= 0 || missed != 1 || st.CurrentStreak < e.policy.GraceMinStreak {
+		return false
+	}
+	return st.GraceUsedAt == nil || at.Sub(*st.GraceUsedAt) >= e.policy.GraceCooldown
+}
+
+// unlock adds newly reached milestones to the streak and returns them.
+// Streak milestones count against the longest streak so a badge earned
+// before a reset is never lost.
+func (e *Engine) unlock(st *models.Streak) []Milestone {
+	var unlocked []Milestone
+	for _, m := range e.policy.Milestones {
+		value := st.LongestStreak
+		if m.Total {
+			value = st.TotalCount
+		}
+		if value >= m.Required && !hasBadge(st, m.Key) {
+			st.UnlockedBadges = append(st.UnlockedBadges, m.Key)
+			unlocked = append(unlocked, m)
+		}
+	}
+	return unlocked
+}
+
+func hasBadge(st *models.Streak, key string) bool {
+	for _, k := range st.UnlockedBadges {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Badges returns every milestone of the policy with whether the streak has
+// unlocked it.
+func (e *Engine) Badges(st *models.Streak) []Badge {
+	badges := make([]Badge, len(e.policy.Milestones))
+	for i, m := range e.policy.Milestones {
+		badges[i] = Badge{Milestone: m, Unlocked: hasBadge(st, m.Key)}
+	}
+	return badges
+}
+
+// NextMilestone returns the first streak milestone beyond the current
+// streak, or nil when all have been passed.
+func (e *Engine) NextMilestone(st *models.Streak) *Milestone {
+	for _, m := range e.policy.Milestones {
+		if !m.Total && m.Required > st.CurrentStreak {
+			return &m
+		}
+	}
+	return nil
+}
+
+// AddFreeze grants the user one more freeze, up to the policy's cap.
+func (e *Engine) AddFreeze(appID string, userID uuid.UUID) (*models.Streak, error) {
+	var st models.Streak
+	err := e.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(e.scope(appID)).
+			Where("user_id = ?", userID).
+			First(&st).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNoStreak
+			}
+			return err
+		}
+		if e.policy.MaxFreezes > 0 && st.FreezesAvailable >= e.policy.MaxFreezes {
+			return ErrFreezeLimit
+		}
+		st.FreezesAvailable++
+		return tx.Save(&st).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// UseFreeze spends freezes (one per missed day) so the user's next activity
+// today continues their streak instead of resetting it.
+func (e *Engine) UseFreeze(appID string, userID uuid.UUID) (*models.Streak, error) {
+	loc := localtime.ForUser(e.db, appID, userID)
+	var st models.Streak
+	err := e.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(e.scope(appID)).
+			Where("user_id = ?", userID).
+			First(&st).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNoStreak
+			}
+			return err
+		}
+		if st.LastActiveDay == nil || st.CurrentStreak == 0 {
+			return ErrNoStreak
+		}
+
+		today := localtime.Today(loc)
+		missed := localtime.DaysBetween(*st.LastActiveDay, today) - e.policy.MaxGap
+		if missed <= 0 {
+			return ErrFreezeNotNeeded
+		}
+		if st.FreezesAvailable < missed {
+			return ErrNoFreezes
+		}
+
+		now := time.Now()
+		bridged := today.AddDate(0, 0, -e.policy.MaxGap)
+		st.FreezesAvailable -= missed
+		st.FreezesUsed += missed
+		st.LastFreezeAt = &now
+		st.LastActiveDay = &bridged
+		return tx.Save(&st).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Recompute rebuilds the user's streak from the instants of all their
+// activity, bucketed into days in loc. Gaps that were bridged by grace or
+// freezes can't be told apart from breaks and count as breaks; the longest
+// streak and unlocked badges never shrink.
+func (e *Engine) Recompute(appID string, userID uuid.UUID, loc *time.Location, instants []time.Time) error {
+	if len(instants) == 0 {
+		return nil
+	}
+
+	days := localtime.UniqueDays(instants, loc)
+	current, longest := localtime.Runs(days, e.policy.MaxGap)
+	last := instants[0]
+	for _, t := range instants[1:] {
+		if t.After(last) {
+			last = t
+		}
+	}
+	lastDay := days[len(days)-1]
+
+	return e.db.Transaction(func(tx *gorm.DB) error {
+		st, err := e.locked(tx, appID, userID)
+		if err != nil {
+			return err
+		}
+		st.CurrentStreak = current
+		if longest > st.LongestStreak {
+			st.LongestStreak = longest
+		}
+		st.TotalCount = len(instants)
+		st.LastActiveDay = &lastDay
+		st.LastActivityAt = &last
+		st.GraceActive = false
+		e.unlock(st)
+		return tx.Save(st).Error
+	})
+}
+
+// Legacy describes a per-plugin streak table to copy into the shared table.
+// Column fields are SQL expressions over the legacy row; empty optional ones
+// default to zero.
+type Legacy struct {
+	// Table is the legacy table; the import is skipped when it doesn't exist.
+	Table string
+	// Live marks Table as still in use by the plugin, e.g. a history table
+	// that From aggregates over. It is kept after the import; any other
+	// legacy table is dropped, so account purges have no stale copy to miss.
+	Live bool
+	// From overrides the FROM source, e.g. an aggregating subquery over
+	// Table. It must yield app_id, user_id, current_streak and
+	// longest_streak columns.
+	From string
+	// Where filters legacy rows, e.g. "deleted_at IS NULL".
+	Where string
+
+	Total            string
+	LastActive       string
+	FreezesAvailable string
+	FreezesUsed      string
+	GraceActive      string
+	GraceUsedAt      string
+	// Badges must evaluate to a jsonb array of unlocked milestone keys.
+	Badges string
+}
+
+// ImportLegacy copies rows from a legacy streak table for existing users who
+// don't have a shared streak of this kind yet, then drops the table unless it
+// is Live. It runs once: a models.DataMigration row records the import, so
+// restarts never resurrect streaks of users deleted since.
+func (e *Engine) ImportLegacy(legacy Legacy) (int64, error) {
+	marker := "streaks.import:" + e.kind + ":" + legacy.Table
+	from := legacy.From
+	if from == "" {
+		from = legacy.Table
+	}
+	// Rows of purged users may linger in legacy tables; never copy them.
+	where := "user_id IS NOT NULL AND user_id IN (SELECT id FROM users)"
+	if legacy.Where != "" {
+		where += " AND (" + legacy.Where + ")"
+	}
+	or := func(expr, fallback string) string {
+		if expr == "" {
+			return fallback
+		}
+		return expr
+	}
+
+	sql := fmt.Sprintf(`INSERT INTO streaks
+		(app_id, user_id, kind, current_streak, longest_streak, total_count,
+		 last_active_day, last_activity_at, freezes_available, freezes_used,
+		 grace_active, grace_used_at, unlocked_badges, created_at, updated_at)
+		SELECT app_id, user_id, ?, current_streak, longest_streak, %s,
+		 (%s)::date, %s, %s, %s, %s, %s, COALESCE(%s, '[]'::jsonb), NOW(), NOW()
+		FROM %s
+		WHERE %s
+		ON CONFLICT (app_id, user_id, kind) DO NOTHING`,
+		or(legacy.Total, "0"),
+		or(legacy.LastActive, "NULL"), or(legacy.LastActive, "NULL"),
+		or(legacy.FreezesAvailable, "0"), or(legacy.FreezesUsed, "0"),
+		or(legacy.GraceActive, "false"), or(legacy.GraceUsedAt, "NULL"),
+		or(legacy.Badges, "NULL"), from, where)
+
+	var imported int64
+	err := e.db.Transaction(func(tx *gorm.DB) error {
+		// Instances starting together take turns; the loser sees the marker.
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", marker).Error; err != nil {
+			return err
+		}
+		if !tx.Migrator().HasTable(legacy.Table) {
+			return nil
+		}
+		var done int64
+		if err := tx.Model(&models.DataMigration{}).Where("name = ?", marker).Count(&done).Error; err != nil {
+			return err
+		}
+		if done > 0 {
+			return nil
+		}
+
+		result := tx.Exec(sql, e.kind)
+		if result.Error != nil {
+			return result.Error
+		}
+		imported = result.RowsAffected
+		if !legacy.Live {
+			if err := tx.Migrator().DropTable(legacy.Table); err != nil {
+				return fmt.Errorf("drop %s: %w", legacy.Table, err)
+			}
+		}
+		return tx.Create(&models.DataMigration{Name: marker, AppliedAt: time.Now()}).Error
+	})
+	return imported, err
+}
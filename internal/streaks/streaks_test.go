@@ -0,0 +1,225 @@
+package streaks
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/models"
+)
+
+// day returns the civil date March n, 2026; noon is an instant on it.
+func day(n int) time.Time  { return time.Date(2026, 3, n, 0, 0, 0, 0, time.UTC) }
+func noon(n int) time.Time { return day(n).Add(12 * time.Hour) }
+
+func ptr(t time.Time) *time.Time { return &t }
+
+func TestApply(t *testing.T) {
+	grace := Policy{GraceMinStreak: 3, GraceCooldown: 7 * 24 * time.Hour}
+	freeze := Policy{AutoFreeze: true}
+	milestones := Policy{Milestones: []Milestone{
+		{Key: "total_5", Required: 5, Total: true},
+		{Key: "streak_3", Required: 3},
+		{Key: "streak_7", Required: 7},
+	}}
+
+	type want struct {
+		current, longest, total int
+		lastDay                 time.Time
+		newDay, broken          bool
+		usedGrace               bool
+		frozen, freezesLeft     int
+		unlocked                []string
+	}
+	tests := []struct {
+		name   string
+		policy Policy
+		before models.Streak
+		at     time.Time
+		want   want
+	}{
+		{
+			name: "first activity",
+			at:   noon(1),
+			want: want{current: 1, longest: 1, total: 1, lastDay: day(1), newDay: true},
+		},
+		{
+			name:   "same-day repeat",
+			before: models.Streak{CurrentStreak: 3, LongestStreak: 3, TotalCount: 3, LastActiveDay: ptr(day(3))},
+			at:     noon(3),
+			want:   want{current: 3, longest: 3, total: 4, lastDay: day(3)},
+		},
+		{
+			name:   "next day",
+			before: models.Streak{CurrentStreak: 3, LongestStreak: 3, TotalCount: 3, LastActiveDay: ptr(day(3))},
+			at:     noon(4),
+			want:   want{current: 4, longest: 4, total: 4, lastDay: day(4), newDay: true},
+		},
+		{
+			name:   "missed day without grace breaks",
+			before: models.Streak{CurrentStreak: 5, LongestStreak: 5, TotalCount: 5, LastActiveDay: ptr(day(5))},
+			at:     noon(7),
+			want:   want{current: 1, longest: 5, total: 6, lastDay: day(7), newDay: true, broken: true},
+		},
+		{
+			name:   "gap within MaxGap",
+			policy: Policy{MaxGap: 2},
+			before: models.Streak{CurrentStreak: 2, LongestStreak: 2, TotalCount: 2, LastActiveDay: ptr(day(5))},
+			at:     noon(7),
+			want:   want{current: 3, longest: 3, total: 3, lastDay: day(7), newDay: true},
+		},
+		{
+			name:   "grace bridges one missed day",
+			policy: grace,
+			before: models.Streak{CurrentStreak: 5, LongestStreak: 5, TotalCount: 5, LastActiveDay: ptr(day(5))},
+			at:     noon(7),
+			want:   want{current: 5, longest: 5, total: 6, lastDay: day(7), newDay: true, usedGrace: true},
+		},
+		{
+			name:   "grace needs the minimum streak",
+			policy: grace,
+			before: models.Streak{CurrentStreak: 2, LongestStreak: 2, TotalCount: 2, LastActiveDay: ptr(day(5))},
+			at:     noon(7),
+			want:   want{current: 1, longest: 2, total: 3, lastDay: day(7), newDay: true, broken: true},
+		},
+		{
+			name:   "grace never bridges two missed days",
+			policy: grace,
+			before: models.Streak{CurrentStreak: 5, LongestStreak: 5, TotalCount: 5, LastActiveDay: ptr(day(5))},
+			at:     noon(8),
+			want:   want{current: 1, longest: 5, total: 6, lastDay: day(8), newDay: true, broken: true},
+		},
+		{
+			name:   "grace cooldown",
+			policy: grace,
+			before: models.Streak{CurrentStreak: 5, LongestStreak: 5, TotalCount: 5, LastActiveDay: ptr(day(5)), GraceUsedAt: ptr(noon(2))},
+			at:     noon(7),
+			want:   want{current: 1, longest: 5, total: 6, lastDay: day(7), newDay: true, broken: true},
+		},
+		{
+			name:   "grace after cooldown",
+			policy: grace,
+			before: models.Streak{CurrentStreak: 9, LongestStreak: 9, TotalCount: 9, LastActiveDay: ptr(day(12)), GraceUsedAt: ptr(noon(2))},
+			at:     noon(14),
+			want:   want{current: 9, longest: 9, total: 10, lastDay: day(14), newDay: true, usedGrace: true},
+		},
+		{
+			name:   "freezes bridge missed days",
+			policy: freeze,
+			before: models.Streak{CurrentStreak: 4, LongestStreak: 4, TotalCount: 4, LastActiveDay: ptr(day(5)), FreezesAvailable: 3},
+			at:     noon(8),
+			want:   want{current: 4, longest: 4, total: 5, lastDay: day(8), newDay: true, frozen: 2, freezesLeft: 1},
+		},
+		{
+			name:   "too few freezes breaks and keeps them",
+			policy: freeze,
+			before: models.Streak{CurrentStreak: 4, LongestStreak: 4, TotalCount: 4, LastActiveDay: ptr(day(5)), FreezesAvailable: 1},
+			at:     noon(8),
+			want:   want{current: 1, longest: 4, total: 5, lastDay: day(8), newDay: true, broken: true, freezesLeft: 1},
+		},
+		{
+			name:   "freezes are manual without AutoFreeze",
+			before: models.Streak{CurrentStreak: 4, LongestStreak: 4, TotalCount: 4, LastActiveDay: ptr(day(5)), FreezesAvailable: 3},
+			at:     noon(7),
+			want:   want{current: 1, longest: 4, total: 5, lastDay: day(7), newDay: true, broken: true, freezesLeft: 3},
+		},
+		{
+			name:   "backdated activity only counts towards the total",
+			before: models.Streak{CurrentStreak: 3, LongestStreak: 3, TotalCount: 3, LastActiveDay: ptr(day(5)), LastActivityAt: ptr(noon(5))},
+			at:     noon(2),
+			want:   want{current: 3, longest: 3, total: 4, lastDay: day(5)},
+		},
+		{
+			name:   "milestones unlock in order of requirement",
+			policy: milestones,
+			before: models.Streak{CurrentStreak: 2, LongestStreak: 2, TotalCount: 4, LastActiveDay: ptr(day(5)), UnlockedBadges: []string{}},
+			at:     noon(6),
+			want:   want{current: 3, longest: 3, total: 5, lastDay: day(6), newDay: true, unlocked: []string{"streak_3", "total_5"}},
+		},
+		{
+			name:   "unlocked milestones are not awarded again",
+			policy: milestones,
+			before: models.Streak{CurrentStreak: 1, LongestStreak: 3, TotalCount: 9, LastActiveDay: ptr(day(5)), UnlockedBadges: []string{"streak_3", "total_5"}},
+			at:     noon(6),
+			want:   want{current: 2, longest: 3, total: 10, lastDay: day(6), newDay: true},
+		},
+		{
+			name:   "streak milestones count the longest streak",
+			policy: milestones,
+			before: models.Streak{CurrentStreak: 1, LongestStreak: 7, TotalCount: 1, LastActiveDay: ptr(day(5)), UnlockedBadges: []string{}},
+			at:     noon(6),
+			want:   want{current: 2, longest: 7, total: 2, lastDay: day(6), newDay: true, unlocked: []string{"streak_3", "streak_7"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(nil, "test", tt.policy)
+			st := tt.before
+			badges := append([]string(nil), st.UnlockedBadges...)
+			res := e.apply(&st, tt.at, day(tt.at.Day()))
+
+			got := want{
+				current:     st.CurrentStreak,
+				longest:     st.LongestStreak,
+				total:       st.TotalCount,
+				newDay:      res.NewDay,
+				broken:      res.Broken,
+				usedGrace:   res.UsedGrace,
+				frozen:      res.FrozenDays,
+				freezesLeft: st.FreezesAvailable,
+			}
+			if st.LastActiveDay != nil {
+				got.lastDay = *st.LastActiveDay
+			}
+			for _, m := range res.Unlocked {
+				got.unlocked = append(got.unlocked, m.Key)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("apply:\n got %+v\nwant %+v", got, tt.want)
+			}
+
+			if res.Previous != tt.before.CurrentStreak {
+				t.Errorf("Previous = %d, want %d", res.Previous, tt.before.CurrentStreak)
+			}
+			if wantBadges := append(badges, tt.want.unlocked...); len(st.UnlockedBadges) != len(wantBadges) {
+				t.Errorf("UnlockedBadges = %v, want %v", st.UnlockedBadges, wantBadges)
+			}
+			if res.UsedGrace != st.GraceActive {
+				t.Errorf("GraceActive = %v after UsedGrace = %v", st.GraceActive, res.UsedGrace)
+			}
+			if tt.want.frozen > 0 && st.FreezesUsed != tt.want.frozen {
+				t.Errorf("FreezesUsed = %d, want %d", st.FreezesUsed, tt.want.frozen)
+			}
+		})
+	}
+}
+
+func TestApplyKeepsLatestActivity(t *testing.T) {
+	e := New(nil, "test", Policy{})
+	st := models.Streak{CurrentStreak: 1, LongestStreak: 1, TotalCount: 1, LastActiveDay: ptr(day(5)), LastActivityAt: ptr(noon(5))}
+	e.apply(&st, noon(3), day(3))
+	if !st.LastActivityAt.Equal(noon(5)) {
+		t.Errorf("LastActivityAt = %v after a backdated activity, want %v", st.LastActivityAt, noon(5))
+	}
+	e.apply(&st, noon(6), day(6))
+	if !st.LastActivityAt.Equal(noon(6)) {
+		t.Errorf("LastActivityAt = %v, want %v", st.LastActivityAt, noon(6))
+	}
+}
+
+func TestNextMilestone(t *testing.T) {
+	e := New(nil, "test", Policy{Milestones: []Milestone{
+		{Key: "streak_7", Required: 7},
+		{Key: "total_3", Required: 3, Total: true},
+		{Key: "streak_3", Required: 3},
+	}})
+	for current, want := range map[int]string{0: "streak_3", 3: "streak_7", 6: "streak_7", 7: ""} {
+		got := ""
+		if m := e.NextMilestone(&models.Streak{CurrentStreak: current}); m != nil {
+			got = m.Key
+		}
+		if got != want {
+			t.Errorf("NextMilestone(%d) = %q, want %q", current, got, want)
+		}
+	}
+}
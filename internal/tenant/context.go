@@ -35,3 +35,39 @@ func GetUserID(c *fiber.Ctx) (uuid.UUID, error) {
 
 	return uuid.Parse(sub)
 }
+
+// IsGuest reports whether the request's token belongs to a guest account.
+func IsGuest(c *fiber.Ctx) bool {
+	token, ok := c.Locals("user").(*jwt.Token)
+	if !ok {
+		return false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return false
+	}
+	guest, _ := claims["guest"].(bool)
+	return guest
+}
+
+// AdminActor identifies who performed an admin request, for audit records.
+type AdminActor struct {
+	// Type is "admin" for signed-in admins, "api-key" for admin API keys and
+	// "admin-token" for the ADMIN_TOKEN bootstrap credential.
+	Type  string `json:"type"`
+	ID    string `json:"id,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// SetAdminActor stores the authenticated admin on the request context.
+func SetAdminActor(c *fiber.Ctx, actor AdminActor) {
+	c.Locals("admin_actor", actor)
+}
+
+// GetAdminActor returns the admin set by AdminRequired, or a zero value.
+func GetAdminActor(c *fiber.Ctx) AdminActor {
+	if actor, ok := c.Locals("admin_actor").(AdminActor); ok {
+		return actor
+	}
+	return AdminActor{}
+}
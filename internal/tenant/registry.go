@@ -8,14 +8,24 @@ import (
 )
 
 type AppConfig struct {
-	AppID              string            `json:"app_id"`
-	AppName            string            `json:"app_name"`
-	BundleID           string            `json:"bundle_id"`
-	AIProvider         string            `json:"ai_provider"`
-	AIConfig           map[string]string `json:"ai_config"`
-	Features           map[string]bool   `json:"features"`
-	RevenueCatAuth     string            `json:"revenuecat_webhook_auth"`
-	AppleClientIDs     []string          `json:"apple_client_ids"`
+	AppID           string              `json:"app_id"`
+	AppName         string              `json:"app_name"`
+	BundleID        string              `json:"bundle_id"`
+	AIProvider      string              `json:"ai_provider"`
+	AIConfig        map[string]string   `json:"ai_config"`
+	Features        map[string]bool     `json:"features"`
+	RevenueCatAuth  string              `json:"revenuecat_webhook_auth"`
+	AppleClientIDs  []string            `json:"apple_client_ids"` // extra Sign in with Apple audiences, accepted alongside BundleID
+	GoogleClientIDs []string            `json:"google_client_ids"`
+	OIDCClientIDs   map[string][]string `json:"oidc_client_ids"` // keyed by OIDC_ISSUERS provider name
+	// Passkeys: the relying party ID is a domain the app is associated with;
+	// origins default to https://<rp id>.
+	WebAuthnRPID    string   `json:"webauthn_rp_id"`
+	WebAuthnOrigins []string `json:"webauthn_origins"`
+	// EmailVerifyURL is the page email change confirmations link to, with
+	// the token appended as ?token=. Without it the email shows the token
+	// for the user to paste into the app.
+	EmailVerifyURL string `json:"email_verify_url"`
 }
 
 type AppsFile struct {
@@ -110,6 +120,29 @@ func (r *Registry) GetBundleID(appID string) string {
 	return cfg.BundleID
 }
 
+// ClientIDs returns the audiences the app accepts in ID tokens from provider.
+// Apple always accepts the bundle ID.
+func (r *Registry) ClientIDs(appID, provider string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cfg, ok := r.apps[appID]
+	if !ok {
+		return nil
+	}
+	switch provider {
+	case "apple":
+		ids := make([]string, 0, len(cfg.AppleClientIDs)+1)
+		if cfg.BundleID != "" {
+			ids = append(ids, cfg.BundleID)
+		}
+		return append(ids, cfg.AppleClientIDs...)
+	case "google":
+		return cfg.GoogleClientIDs
+	default:
+		return cfg.OIDCClientIDs[provider]
+	}
+}
+
 // ToMap returns a map of app_id -> app_name for easy iteration
 func (r *Registry) ToMap() map[string]string {
 	r.mu.RLock()
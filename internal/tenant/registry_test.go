@@ -0,0 +1,37 @@
+package tenant
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClientIDs(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&AppConfig{
+		AppID:           "daiyly",
+		BundleID:        "com.example.daiyly",
+		AppleClientIDs:  []string{"com.example.daiyly.web"},
+		GoogleClientIDs: []string{"google-ios", "google-android"},
+		OIDCClientIDs:   map[string][]string{"okta": {"okta-daiyly"}},
+	})
+	r.Register(&AppConfig{AppID: "driftoff"})
+
+	tests := []struct {
+		appID, provider string
+		want            []string
+	}{
+		{"daiyly", "apple", []string{"com.example.daiyly", "com.example.daiyly.web"}},
+		{"daiyly", "google", []string{"google-ios", "google-android"}},
+		{"daiyly", "okta", []string{"okta-daiyly"}},
+		{"daiyly", "auth0", nil},
+		{"driftoff", "apple", []string{}},
+		{"driftoff", "google", nil},
+		{"unknown", "apple", nil},
+	}
+	for _, tt := range tests {
+		if got := r.ClientIDs(tt.appID, tt.provider); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ClientIDs(%s, %s) = %#v, want %#v", tt.appID, tt.provider, got, tt.want)
+		}
+	}
+
+}
@@ -0,0 +1,165 @@
+//go:build integration
+
+package testharness
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Client makes requests to the harness as one app and device. Each Client
+// gets its own X-Forwarded-For address, which the server trusts from
+// localhost, so per-IP rate limits apply per client rather than per test
+// run.
+type Client struct {
+	AppID string
+	// Token is sent as a Bearer token when set.
+	Token string
+	// Header is added to every request.
+	Header http.Header
+
+	h  *Harness
+	ip string
+}
+
+// Client returns an anonymous client for appID.
+func (h *Harness) Client(appID string) *Client {
+	n := h.ipSeq.Add(1)
+	return &Client{
+		AppID:  appID,
+		Header: http.Header{},
+		h:      h,
+		ip:     fmt.Sprintf("10.%d.%d.%d", byte(n>>16), byte(n>>8), byte(n)),
+	}
+}
+
+// WithToken returns a copy of c that sends token. The copy keeps c's
+// address.
+func (c *Client) WithToken(token string) *Client {
+	cp := *c
+	cp.Header = c.Header.Clone()
+	cp.Token = token
+	return &cp
+}
+
+// Do sends a request. body is sent as is when it is a []byte and as JSON
+// otherwise; nil sends none. Transport errors fail the test.
+func (c *Client) Do(t testing.TB, method, path string, body interface{}) *Response {
+	t.Helper()
+	var r io.Reader
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		r = bytes.NewReader(b)
+	default:
+		raw, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("encode %s %s body: %v", method, path, err)
+		}
+		r = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequest(method, c.h.URL+path, r)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	for k, v := range c.Header {
+		req.Header[k] = v
+	}
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.AppID != "" && req.Header.Get("X-App-ID") == "" {
+		req.Header.Set("X-App-ID", c.AppID)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	req.Header.Set("X-Forwarded-For", c.ip)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("%s %s: read body: %v", method, path, err)
+	}
+	return &Response{Status: resp.StatusCode, Header: resp.Header, Body: raw, method: method, path: path}
+}
+
+func (c *Client) Get(t testing.TB, path string) *Response {
+	t.Helper()
+	return c.Do(t, http.MethodGet, path, nil)
+}
+
+func (c *Client) Post(t testing.TB, path string, body interface{}) *Response {
+	t.Helper()
+	return c.Do(t, http.MethodPost, path, body)
+}
+
+func (c *Client) Put(t testing.TB, path string, body interface{}) *Response {
+	t.Helper()
+	return c.Do(t, http.MethodPut, path, body)
+}
+
+func (c *Client) Patch(t testing.TB, path string, body interface{}) *Response {
+	t.Helper()
+	return c.Do(t, http.MethodPatch, path, body)
+}
+
+func (c *Client) Delete(t testing.TB, path string) *Response {
+	t.Helper()
+	return c.Do(t, http.MethodDelete, path, nil)
+}
+
+// Response is a completed request.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+
+	method string
+	path   string
+}
+
+// Expect fails the test unless the response has status.
+func (r *Response) Expect(t testing.TB, status int) *Response {
+	t.Helper()
+	if r.Status != status {
+		t.Fatalf("%s: want status %d", r, status)
+	}
+	return r
+}
+
+// Decode unmarshals the JSON body into v.
+func (r *Response) Decode(t testing.TB, v interface{}) *Response {
+	t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("%s: decode body: %v", r, err)
+	}
+	return r
+}
+
+// Map decodes the body as a JSON object.
+func (r *Response) Map(t testing.TB) map[string]interface{} {
+	t.Helper()
+	var m map[string]interface{}
+	r.Decode(t, &m)
+	return m
+}
+
+func (r *Response) String() string {
+	body := r.Body
+	if len(body) > 2000 {
+		body = append(body[:2000:2000], "..."...)
+	}
+	return fmt.Sprintf("%s %s -> %d %s", r.method, r.path, r.Status, body)
+}
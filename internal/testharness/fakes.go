@@ -0,0 +1,319 @@
+//go:build integration
+
+package testharness
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// DefaultAIReply is the chat completion FakeAI returns when no Reply matches.
+const DefaultAIReply = "This is a test response."
+
+// embeddingDims matches text-embedding-3-small.
+const embeddingDims = 1536
+
+// AIRequest is a call FakeAI received.
+type AIRequest struct {
+	Path string
+	Body []byte
+	// Prompt is the text of every chat message, joined by newlines.
+	Prompt string
+}
+
+// FakeAI stands in for the OpenAI-compatible APIs the apps call: chat
+// completions (OpenAI and GLM), embeddings and Whisper transcriptions. Paths
+// are matched by suffix, so it serves both OPENAI_BASE_URL and GLM_API_URL.
+type FakeAI struct {
+	URL string
+
+	server     *httptest.Server
+	mu         sync.Mutex
+	replies    []aiReply
+	transcript string
+	requests   []AIRequest
+}
+
+type aiReply struct {
+	match   string
+	content string
+}
+
+func newFakeAI() *FakeAI {
+	f := &FakeAI{transcript: "Test transcription."}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	f.URL = f.server.URL
+	return f
+}
+
+// Reply makes chat completions whose prompt contains match answer content.
+// Later replies take precedence, so a test can override a shared one.
+func (f *FakeAI) Reply(match, content string) {
+	f.mu.Lock()
+	f.replies = append(f.replies, aiReply{match: match, content: content})
+	f.mu.Unlock()
+}
+
+// SetTranscript sets the text transcriptions return.
+func (f *FakeAI) SetTranscript(text string) {
+	f.mu.Lock()
+	f.transcript = text
+	f.mu.Unlock()
+}
+
+// Requests returns the calls received so far whose path ends in suffix, or
+// all calls for "".
+func (f *FakeAI) Requests(suffix string) []AIRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []AIRequest
+	for _, r := range f.requests {
+		if strings.HasSuffix(r.Path, suffix) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (f *FakeAI) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := AIRequest{Path: r.URL.Path, Body: body}
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/chat/completions"):
+		req.Prompt = chatPrompt(body)
+		f.record(req)
+		writeJSON(w, map[string]interface{}{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"created": time.Now().Unix(),
+			"model":   "test",
+			"choices": []map[string]interface{}{{
+				"index":         0,
+				"message":       map[string]string{"role": "assistant", "content": f.reply(req.Prompt)},
+				"finish_reason": "stop",
+			}},
+			"usage": map[string]int{"prompt_tokens": 1, "completion_tokens": 1, "total_tokens": 2},
+		})
+	case strings.HasSuffix(r.URL.Path, "/embeddings"):
+		f.record(req)
+		var in struct {
+			Input json.RawMessage `json:"input"`
+		}
+		json.Unmarshal(body, &in)
+		var inputs []string
+		if err := json.Unmarshal(in.Input, &inputs); err != nil {
+			var one string
+			json.Unmarshal(in.Input, &one)
+			inputs = []string{one}
+		}
+		data := make([]map[string]interface{}, len(inputs))
+		for i, s := range inputs {
+			data[i] = map[string]interface{}{"object": "embedding", "index": i, "embedding": embedding(s)}
+		}
+		writeJSON(w, map[string]interface{}{"object": "list", "data": data, "model": "test"})
+	case strings.HasSuffix(r.URL.Path, "/audio/transcriptions"):
+		// Multipart; the audio itself is irrelevant.
+		f.record(req)
+		f.mu.Lock()
+		text := f.transcript
+		f.mu.Unlock()
+		writeJSON(w, map[string]string{"text": text})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *FakeAI) record(req AIRequest) {
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.mu.Unlock()
+}
+
+func (f *FakeAI) reply(prompt string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.replies) - 1; i >= 0; i-- {
+		if strings.Contains(prompt, f.replies[i].match) {
+			return f.replies[i].content
+		}
+	}
+	return DefaultAIReply
+}
+
+// chatPrompt joins the text of a chat request's messages. Vision requests
+// send content as a list of parts; their text parts are kept.
+func chatPrompt(body []byte) string {
+	var req struct {
+		Messages []struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	json.Unmarshal(body, &req)
+	var parts []string
+	for _, m := range req.Messages {
+		var text string
+		if err := json.Unmarshal(m.Content, &text); err == nil {
+			parts = append(parts, text)
+			continue
+		}
+		var items []struct {
+			Text string `json:"text"`
+		}
+		json.Unmarshal(m.Content, &items)
+		for _, it := range items {
+			if it.Text != "" {
+				parts = append(parts, it.Text)
+			}
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// embedding derives a stable unit-ish vector from s, so equal texts embed
+// equally and similarity search is deterministic.
+func embedding(s string) []float64 {
+	v := make([]float64, embeddingDims)
+	seed := sha256.Sum256([]byte(s))
+	for i := range v {
+		b := seed[i%len(seed)] ^ byte(i/len(seed))
+		v[i] = float64(b)/255 - 0.5
+	}
+	return v
+}
+
+// FakeEmotionSense stands in for the EmotionSenseML text analysis service.
+type FakeEmotionSense struct {
+	URL string
+
+	server   *httptest.Server
+	mu       sync.Mutex
+	dominant string
+	calls    atomic.Int32
+}
+
+func newFakeEmotionSense() *FakeEmotionSense {
+	f := &FakeEmotionSense{dominant: "joy"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/analyze/text", func(w http.ResponseWriter, r *http.Request) {
+		f.calls.Add(1)
+		f.mu.Lock()
+		dominant := f.dominant
+		f.mu.Unlock()
+		writeJSON(w, map[string]interface{}{
+			"dominantEmotion": dominant,
+			"emotions": []map[string]interface{}{
+				{"type": dominant, "score": 0.9},
+				{"type": "neutral", "score": 0.1},
+			},
+		})
+	})
+	f.server = httptest.NewServer(mux)
+	f.URL = f.server.URL
+	return f
+}
+
+// SetDominant sets the emotion analyses report.
+func (f *FakeEmotionSense) SetDominant(emotion string) {
+	f.mu.Lock()
+	f.dominant = emotion
+	f.mu.Unlock()
+}
+
+// Calls returns how many texts have been analysed.
+func (f *FakeEmotionSense) Calls() int {
+	return int(f.calls.Load())
+}
+
+// Delivery is a request received by a WebhookReceiver inbox.
+type Delivery struct {
+	Header http.Header
+	Body   []byte
+}
+
+// WebhookReceiver is an HTTPS server for outbound webhook endpoints, which
+// must be https. The harness makes the webhook service trust its
+// certificate. Each Inbox gets its own path, so tests don't see each
+// other's deliveries.
+type WebhookReceiver struct {
+	server  *httptest.Server
+	mu      sync.Mutex
+	inboxes map[string]*Inbox
+	seq     atomic.Int64
+}
+
+// Inbox collects the deliveries sent to URL.
+type Inbox struct {
+	URL string
+
+	ch     chan Delivery
+	status atomic.Int32
+}
+
+func newWebhookReceiver() *WebhookReceiver {
+	rcv := &WebhookReceiver{inboxes: map[string]*Inbox{}}
+	rcv.server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rcv.mu.Lock()
+		inbox := rcv.inboxes[r.URL.Path]
+		rcv.mu.Unlock()
+		if inbox == nil {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		select {
+		case inbox.ch <- Delivery{Header: r.Header.Clone(), Body: body}:
+		default:
+		}
+		w.WriteHeader(int(inbox.status.Load()))
+	}))
+	return rcv
+}
+
+// Client returns an HTTP client that trusts the receiver.
+func (rcv *WebhookReceiver) Client() *http.Client {
+	return rcv.server.Client()
+}
+
+// Inbox returns a new inbox that answers 200.
+func (rcv *WebhookReceiver) Inbox() *Inbox {
+	path := "/hooks/" + strconv.FormatInt(rcv.seq.Add(1), 10)
+	inbox := &Inbox{URL: rcv.server.URL + path, ch: make(chan Delivery, 64)}
+	inbox.status.Store(http.StatusOK)
+	rcv.mu.Lock()
+	rcv.inboxes[path] = inbox
+	rcv.mu.Unlock()
+	return inbox
+}
+
+// Respond sets the status later deliveries are answered with, e.g. 500 to
+// exercise retries.
+func (i *Inbox) Respond(status int) {
+	i.status.Store(int32(status))
+}
+
+// Next waits up to timeout for a delivery and fails the test without one.
+func (i *Inbox) Next(t testing.TB, timeout time.Duration) Delivery {
+	t.Helper()
+	select {
+	case d := <-i.ch:
+		return d
+	case <-time.After(timeout):
+		t.Fatalf("no webhook delivery to %s within %s", i.URL, timeout)
+		return Delivery{}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
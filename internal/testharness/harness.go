@@ -0,0 +1,357 @@
+//go:build integration
+
+// Package testharness boots the whole API for end-to-end tests: every route
+// and plugin, served on a local port against a scratch PostgreSQL database,
+// with fake AI, Whisper, EmotionSenseML and OIDC providers and an HTTPS
+// receiver for outbound webhooks. Files are built with the integration tag:
+//
+//	go test -tags integration ./internal/e2e/...
+//
+// See StartPostgres for how the database is provided.
+package testharness
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/config"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/oidctest"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/server"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/tenant"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Apps are the tenants the harness registers, one per active plugin.
+var Apps = []string{"daiyly", "driftoff", "lucky_draw", "moodpulse"}
+
+// OIDCProvider is the name the fake OIDC issuer is registered under, as in
+// POST /api/auth/oidc/:provider.
+const OIDCProvider = "harness"
+
+// RevenueCatAuth returns the Authorization header RevenueCat webhooks for
+// appID must carry.
+func RevenueCatAuth(appID string) string {
+	return "Bearer rc-" + appID
+}
+
+// OIDCClientID returns the audience the fake issuer's tokens need for appID.
+func OIDCClientID(appID string) string {
+	return appID + ".harness"
+}
+
+// Options customises Start.
+type Options struct {
+	// Configure adjusts the config before the server is built.
+	Configure func(*config.Config)
+}
+
+// Harness is a running API. Fields are safe to use from tests; the fakes
+// can be reprogrammed between requests.
+type Harness struct {
+	// URL is the API's origin, e.g. http://127.0.0.1:41234.
+	URL      string
+	Config   *config.Config
+	Registry *tenant.Registry
+	DB       *gorm.DB
+	Server   *server.Server
+
+	AI           *FakeAI
+	EmotionSense *FakeEmotionSense
+	OIDC         *oidctest.Issuer
+	Webhooks     *WebhookReceiver
+
+	pg     *Postgres
+	dir    string // uploads
+	ln     net.Listener
+	ipSeq  atomic.Uint32
+	userID atomic.Uint64
+
+	adminOnce sync.Once
+	adminKey  string
+	adminErr  error
+}
+
+// Start boots the API. Set TEST_LOG=1 to see server logs. Callers must Close
+// the harness; with no PostgreSQL available it returns ErrNoPostgres.
+func Start(opts Options) (*Harness, error) {
+	if os.Getenv("TEST_LOG") == "" {
+		slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	}
+
+	h := &Harness{}
+	var err error
+	if h.pg, err = StartPostgres(); err != nil {
+		return nil, err
+	}
+	if h.dir, err = os.MkdirTemp("", "unified-uploads-"); err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.AI = newFakeAI()
+	h.EmotionSense = newFakeEmotionSense()
+	h.Webhooks = newWebhookReceiver()
+	if h.OIDC, err = oidctest.NewIssuer(); err != nil {
+		h.Close()
+		return nil, err
+	}
+
+	h.Config = h.config()
+	if opts.Configure != nil {
+		opts.Configure(h.Config)
+	}
+	h.Registry = tenant.NewRegistry()
+	for _, appID := range Apps {
+		h.Registry.Register(&tenant.AppConfig{
+			AppID:          appID,
+			AppName:        appID,
+			BundleID:       "com.harness." + appID,
+			RevenueCatAuth: RevenueCatAuth(appID),
+			OIDCClientIDs:  map[string][]string{OIDCProvider: {OIDCClientID(appID)}},
+		})
+	}
+
+	if h.DB, err = open(h.Config.DSN()); err != nil {
+		h.Close()
+		return nil, fmt.Errorf("testharness: connect: %w", err)
+	}
+	if h.Server, err = server.New(h.Config, h.Registry, h.DB, server.Options{DisableStartupMessage: true}); err != nil {
+		h.Close()
+		return nil, fmt.Errorf("testharness: build server: %w", err)
+	}
+	h.Server.Webhooks.SetHTTPClient(h.Webhooks.Client())
+
+	if h.ln, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.URL = "http://" + h.ln.Addr().String()
+	go h.Server.App.Listener(h.ln)
+	return h, nil
+}
+
+// config is a complete config for the harness: secrets are random, every
+// external service is a fake, and nothing is read from the environment.
+func (h *Harness) config() *config.Config {
+	cfg := &config.Config{
+		JWTSecret:        randomHex(32),
+		JWTAccessExpiry:  15 * time.Minute,
+		JWTRefreshExpiry: 168 * time.Hour,
+
+		GLMAPIKey:      "test-glm-key",
+		GLMAPIURL:      h.AI.URL + "/chat/completions",
+		GLMModel:       "glm-test",
+		GLMVisionModel: "glm-test-vision",
+
+		DeepSeekAPIKey: "test-deepseek-key",
+		DeepSeekAPIURL: h.AI.URL + "/chat/completions",
+		DeepSeekModel:  "deepseek-test",
+
+		OpenAIAPIKey:  "test-openai-key",
+		OpenAIModel:   "gpt-test",
+		OpenAIBaseURL: h.AI.URL,
+
+		AITimeout: 5 * time.Second,
+
+		AdminToken:         randomHex(32),
+		AdminSessionExpiry: time.Hour,
+		MFASecretKey:       randomHex(32),
+
+		AccountDeletionGrace: 336 * time.Hour,
+
+		Port: "0",
+
+		OIDCIssuers: map[string]string{OIDCProvider: h.OIDC.URL},
+
+		PushProvider: "mock",
+		MailProvider: "mock",
+		MailFrom:     "noreply@harness.test",
+
+		UploadsRoot:  h.dir,
+		MediaBaseURL: "https://media.harness.test",
+
+		EmotionSenseMLURL: h.EmotionSense.URL,
+
+		ModerationAutoHideThreshold: 3,
+	}
+	h.pg.Configure(cfg)
+	return cfg
+}
+
+// Close stops the server and every fake, and drops the database.
+func (h *Harness) Close() {
+	if h.Server != nil {
+		h.Server.App.ShutdownWithTimeout(5 * time.Second)
+		h.Server.Close()
+	} else if h.ln != nil {
+		h.ln.Close()
+	}
+	if h.DB != nil {
+		closeDB(h.DB)
+	}
+	if h.OIDC != nil {
+		h.OIDC.Close()
+	}
+	if h.Webhooks != nil {
+		h.Webhooks.server.Close()
+	}
+	if h.EmotionSense != nil {
+		h.EmotionSense.server.Close()
+	}
+	if h.AI != nil {
+		h.AI.server.Close()
+	}
+	if h.pg != nil {
+		h.pg.Close()
+	}
+	if h.dir != "" {
+		os.RemoveAll(h.dir)
+	}
+}
+
+// SignToken signs claims with the server's JWT secret.
+func (h *Harness) SignToken(claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(h.Config.JWTSecret))
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+// Token returns an access token for userID on appID with the claims
+// AuthService issues. Routes that load the user need it to exist; see
+// NewUser.
+func (h *Harness) Token(appID string, userID uuid.UUID) string {
+	now := time.Now()
+	return h.SignToken(jwt.MapClaims{
+		"sub":           userID.String(),
+		"email":         userID.String() + "@harness.test",
+		"app_id":        appID,
+		"is_apple_user": false,
+		"iat":           now.Unix(),
+		"exp":           now.Add(h.Config.JWTAccessExpiry).Unix(),
+	})
+}
+
+// GuestToken is Token for a guest account.
+func (h *Harness) GuestToken(appID string, userID uuid.UUID) string {
+	now := time.Now()
+	return h.SignToken(jwt.MapClaims{
+		"sub":           userID.String(),
+		"email":         "",
+		"app_id":        appID,
+		"is_apple_user": false,
+		"guest":         true,
+		"iat":           now.Unix(),
+		"exp":           now.Add(h.Config.JWTAccessExpiry).Unix(),
+	})
+}
+
+// User is an account registered through the API.
+type User struct {
+	ID           uuid.UUID
+	AppID        string
+	Email        string
+	Password     string
+	AccessToken  string
+	RefreshToken string
+}
+
+// NewUser registers a fresh account on appID.
+func (h *Harness) NewUser(t testing.TB, appID string) *User {
+	t.Helper()
+	u := &User{
+		AppID:    appID,
+		Email:    fmt.Sprintf("user%d-%s@harness.test", h.userID.Add(1), randomHex(3)),
+		Password: "Harness-Passw0rd",
+	}
+	var resp struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		User         struct {
+			ID uuid.UUID `json:"id"`
+		} `json:"user"`
+	}
+	h.Client(appID).Post(t, "/api/auth/register", map[string]string{
+		"email":    u.Email,
+		"password": u.Password,
+	}).Expect(t, 201).Decode(t, &resp)
+	u.ID = resp.User.ID
+	u.AccessToken = resp.AccessToken
+	u.RefreshToken = resp.RefreshToken
+	return u
+}
+
+// As returns a client signed in as u.
+func (h *Harness) As(u *User) *Client {
+	return h.Client(u.AppID).WithToken(u.AccessToken)
+}
+
+// Admin returns a client authenticated with an API key of an owner on every
+// app, sending X-App-ID appID.
+func (h *Harness) Admin(t testing.TB, appID string) *Client {
+	t.Helper()
+	h.adminOnce.Do(func() { h.adminKey, h.adminErr = h.createAdminKey(t) })
+	if h.adminErr != nil {
+		t.Fatalf("create admin API key: %v", h.adminErr)
+	}
+	c := h.Client(appID)
+	c.Header.Set("X-Admin-Key", h.adminKey)
+	return c
+}
+
+// createAdminKey uses the bootstrap token to create an owner and a key
+// acting for them.
+func (h *Harness) createAdminKey(t testing.TB) (string, error) {
+	bootstrap := h.Client(Apps[0])
+	bootstrap.Header.Set("X-Admin-Token", h.Config.AdminToken)
+
+	var admin struct {
+		ID uuid.UUID `json:"id"`
+	}
+	resp := bootstrap.Post(t, "/api/admin/admins", map[string]interface{}{
+		"email":    "owner@harness.test",
+		"name":     "Harness Owner",
+		"password": "Harness-Passw0rd",
+		"roles":    []map[string]string{{"app_id": "*", "role": "owner"}},
+	})
+	if resp.Status != 201 {
+		return "", errors.New(resp.String())
+	}
+	resp.Decode(t, &admin)
+
+	var key struct {
+		Key string `json:"key"`
+	}
+	resp = bootstrap.Post(t, "/api/admin/api-keys", map[string]string{
+		"name":     "harness",
+		"admin_id": admin.ID.String(),
+	})
+	if resp.Status != 201 {
+		return "", errors.New(resp.String())
+	}
+	resp.Decode(t, &key)
+	return key.Key, nil
+}
+
+// Eventually polls cond every 50ms until it is true, failing the test after
+// timeout. Use it for work the server finishes asynchronously.
+func Eventually(t testing.TB, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out after %s waiting for %s", timeout, what)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,232 @@
+package validation
+
+import (
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/locale"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/localtime"
+	"github.com/google/uuid"
+)
+
+// Check tests a non-nil value against a rule's param. It returns ok, or the
+// field error code to report.
+type Check func(v reflect.Value, param string) (code string, ok bool)
+
+var (
+	rulesMu sync.RWMutex
+	enums   = map[string][]string{}
+	rules   = map[string]Check{
+		"min":       bound(false, false),
+		"max":       bound(true, false),
+		"gte":       bound(false, true),
+		"lte":       bound(true, true),
+		"gt":        strict(false),
+		"lt":        strict(true),
+		"len":       exactLen,
+		"oneof":     oneOf,
+		"email":     str(apierr.FieldInvalidEmail, isEmail),
+		"url":       str(apierr.FieldInvalidURL, isURL),
+		"https_url": httpsURL,
+		"uuid":      str(apierr.FieldInvalidUUID, isUUID),
+		"latitude":  coordinate(90, apierr.FieldInvalidLatitude),
+		"longitude": coordinate(180, apierr.FieldInvalidLongitude),
+		"timezone":  str(apierr.FieldInvalidTimezone, localtime.Valid),
+		"locale":    str(apierr.FieldInvalidLocale, isLocale),
+		"password":  str(apierr.FieldWeakPassword, StrongPassword),
+	}
+)
+
+// Register adds a rule, e.g. an app-specific enum. Call it from init, before
+// any struct using the rule is validated.
+func Register(name string, check Check) {
+	rulesMu.Lock()
+	rules[name] = check
+	rulesMu.Unlock()
+}
+
+// RegisterString adds a rule for string fields that reports code when valid
+// returns false.
+func RegisterString(name, code string, valid func(string) bool) {
+	Register(name, str(code, valid))
+}
+
+// RegisterEnum adds a rule accepting only values. Failures are reported as
+// not_allowed with the values listed, like oneof.
+func RegisterEnum(name string, values ...string) {
+	allowed := make(map[string]bool, len(values))
+	for _, v := range values {
+		allowed[v] = true
+	}
+	rulesMu.Lock()
+	enums[name] = values
+	rulesMu.Unlock()
+	RegisterString(name, apierr.FieldNotAllowed, func(s string) bool { return allowed[s] })
+}
+
+func enumValues(name string) []string {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	return enums[name]
+}
+
+func lookup(name string) (Check, bool) {
+	rulesMu.RLock()
+	check, ok := rules[name]
+	rulesMu.RUnlock()
+	return check, ok
+}
+
+func str(code string, valid func(string) bool) Check {
+	return func(v reflect.Value, _ string) (string, bool) {
+		if v.Kind() != reflect.String {
+			return apierr.FieldInvalid, false
+		}
+		return code, valid(v.String())
+	}
+}
+
+// size is what min/max measure: characters for strings, items for slices
+// and maps, the value for numbers. The codes are the ones to report when
+// size is below or above the bound.
+func size(v reflect.Value) (n float64, under, over string, ok bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), apierr.FieldTooShort, apierr.FieldTooLong, true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), apierr.FieldTooFew, apierr.FieldTooMany, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), apierr.FieldTooSmall, apierr.FieldTooLarge, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), apierr.FieldTooSmall, apierr.FieldTooLarge, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), apierr.FieldTooSmall, apierr.FieldTooLarge, true
+	}
+	return 0, "", "", false
+}
+
+// bound is min/max, and gte/lte when numeric is set; the two differ only
+// in that gte/lte reject non-numbers.
+func bound(upper, numeric bool) Check {
+	return func(v reflect.Value, param string) (string, bool) {
+		limit, err := strconv.ParseFloat(param, 64)
+		n, under, over, ok := size(v)
+		if err != nil || !ok || (numeric && under != apierr.FieldTooSmall) {
+			return apierr.FieldInvalid, false
+		}
+		if upper {
+			return over, n <= limit
+		}
+		return under, n >= limit
+	}
+}
+
+func strict(upper bool) Check {
+	return func(v reflect.Value, param string) (string, bool) {
+		limit, err := strconv.ParseFloat(param, 64)
+		n, under, over, ok := size(v)
+		if err != nil || !ok {
+			return apierr.FieldInvalid, false
+		}
+		if upper {
+			return over, n < limit
+		}
+		return under, n > limit
+	}
+}
+
+func exactLen(v reflect.Value, param string) (string, bool) {
+	want, err := strconv.Atoi(param)
+	n, _, _, ok := size(v)
+	if err != nil || !ok {
+		return apierr.FieldInvalid, false
+	}
+	return apierr.FieldWrongLength, int(n) == want
+}
+
+// oneOf accepts one of the space-separated options in param.
+func oneOf(v reflect.Value, param string) (string, bool) {
+	var s string
+	switch v.Kind() {
+	case reflect.String:
+		s = v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = strconv.FormatInt(v.Int(), 10)
+	default:
+		return apierr.FieldInvalid, false
+	}
+	for _, opt := range strings.Fields(param) {
+		if s == opt {
+			return "", true
+		}
+	}
+	return apierr.FieldNotAllowed, false
+}
+
+func coordinate(limit float64, code string) Check {
+	return func(v reflect.Value, _ string) (string, bool) {
+		if v.Kind() != reflect.Float64 && v.Kind() != reflect.Float32 {
+			return code, false
+		}
+		f := v.Float()
+		return code, !math.IsNaN(f) && f >= -limit && f <= limit
+	}
+}
+
+// isEmail accepts a bare address whose domain has a dot. Display names and
+// comments are rejected; surrounding spaces are not, since callers trim.
+func isEmail(s string) bool {
+	s = strings.TrimSpace(s)
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return false
+	}
+	at := strings.LastIndex(s, "@")
+	return at >= 1 && strings.Contains(s[at+1:], ".")
+}
+
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// httpsURL rejects non-https URLs with their own code, so clients can tell
+// a malformed URL from an insecure one.
+func httpsURL(v reflect.Value, _ string) (string, bool) {
+	if v.Kind() != reflect.String || !isURL(v.String()) {
+		return apierr.FieldInvalidURL, false
+	}
+	return apierr.FieldInsecureURL, strings.HasPrefix(v.String(), "https://")
+}
+
+func isUUID(s string) bool {
+	_, err := uuid.Parse(s)
+	return err == nil
+}
+
+func isLocale(s string) bool {
+	_, ok := locale.Normalize(s)
+	return ok
+}
+
+// StrongPassword reports whether password is 8 to 72 bytes with an
+// uppercase letter and a digit. bcrypt silently truncates at 72 bytes, so
+// longer passwords would collide.
+func StrongPassword(password string) bool {
+	if len(password) < 8 || len(password) > 72 {
+		return false
+	}
+	var upper, digit bool
+	for _, ch := range password {
+		upper = upper || ch >= 'A' && ch <= 'Z'
+		digit = digit || ch >= '0' && ch <= '9'
+	}
+	return upper && digit
+}
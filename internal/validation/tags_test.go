@@ -0,0 +1,72 @@
+package validation_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	// The server imports every plugin, whose init functions register the
+	// app enums their tags use.
+	_ "github.com/ahmetcoskunkizilkaya/unified-backend/internal/server"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/validation"
+)
+
+// TestValidateTags checks every `validate` tag in the tree. Tags are parsed
+// when a struct is first validated, so a typo would otherwise panic on the
+// first request rather than fail here.
+func TestValidateTags(t *testing.T) {
+	root := filepath.Join("..", "..")
+	fset := token.NewFileSet()
+	checked := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			f, ok := n.(*ast.Field)
+			if !ok || f.Tag == nil {
+				return true
+			}
+			raw, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return true
+			}
+			tag, ok := reflect.StructTag(raw).Lookup("validate")
+			if !ok {
+				return true
+			}
+			checked++
+			if err := validation.CheckTag(tag); err != nil {
+				t.Errorf("%s: %v", fset.Position(f.Pos()), err)
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checked == 0 {
+		t.Fatal("found no validate tags; is the walk rooted at the module?")
+	}
+}
@@ -0,0 +1,257 @@
+// Package validation enforces `validate` struct tags on request bodies.
+// Rules are comma-separated and run in order; the first failing rule of a
+// field is reported:
+//
+//	Email string   `json:"email" validate:"required,email,max=255"`
+//	Note  *string  `json:"note" validate:"omitempty,max=5000"`
+//	Tags  []string `json:"tags" validate:"max=10,dive,max=30"`
+//
+// Nested structs, and slices of them, are validated too. Failures come back
+// as an *apierr.Error with one dto.FieldError per field, named by its JSON
+// path.
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Bind parses the request body into v and validates it. Both failures are
+// returned as an *apierr.Error, so handlers can return the error as is.
+func Bind(c *fiber.Ctx, v interface{}) error {
+	if err := c.BodyParser(v); err != nil {
+		return apierr.New(fiber.StatusBadRequest, "").WithCode(apierr.CodeInvalidBody)
+	}
+	return Struct(v)
+}
+
+// Struct validates v, a struct or pointer to one. It returns nil or an
+// *apierr.Error listing every invalid field.
+func Struct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	var errs []dto.FieldError
+	validateStruct(rv, "", &errs)
+	if len(errs) > 0 {
+		return apierr.Validation(errs)
+	}
+	return nil
+}
+
+// Var validates a single value against tag, reporting it as field.
+func Var(field string, v interface{}, tag string) error {
+	var errs []dto.FieldError
+	validateValue(reflect.ValueOf(v), field, parseTag(tag), &errs)
+	if len(errs) > 0 {
+		return apierr.Validation(errs)
+	}
+	return nil
+}
+
+type rule struct {
+	name  string
+	param string
+}
+
+type field struct {
+	index int
+	name  string
+	rules []rule
+}
+
+var fieldCache sync.Map // reflect.Type -> []field
+
+func fieldsOf(t reflect.Type) []field {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" && !f.Anonymous {
+			name = f.Name
+		}
+		fields = append(fields, field{index: i, name: name, rules: parseTag(f.Tag.Get("validate"))})
+	}
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// CheckTag reports whether tag would be accepted: every rule is known and
+// numeric rules have a number argument. Struct tags are parsed on first use
+// and a bad one panics then, so tests run CheckTag over every tag in the
+// tree.
+func CheckTag(tag string) error {
+	_, err := compileTag(tag)
+	return err
+}
+
+func parseTag(tag string) []rule {
+	rules, err := compileTag(tag)
+	if err != nil {
+		panic("validation: " + err.Error())
+	}
+	return rules
+}
+
+func compileTag(tag string) ([]rule, error) {
+	if tag == "" || tag == "-" {
+		return nil, nil
+	}
+	parts := strings.Split(tag, ",")
+	rules := make([]rule, 0, len(parts))
+	for _, p := range parts {
+		name, param, _ := strings.Cut(strings.TrimSpace(p), "=")
+		if name == "" {
+			continue
+		}
+		switch name {
+		case "omitempty", "dive", "required":
+		case "min", "max", "gte", "lte", "gt", "lt", "len":
+			if _, err := strconv.ParseFloat(param, 64); err != nil {
+				return nil, fmt.Errorf("rule %q in tag %q needs a number", name, tag)
+			}
+		case "oneof":
+			if strings.TrimSpace(param) == "" {
+				return nil, fmt.Errorf("rule oneof in tag %q has no options", tag)
+			}
+		default:
+			if _, ok := lookup(name); !ok {
+				return nil, fmt.Errorf("unknown rule %q in tag %q", name, tag)
+			}
+		}
+		rules = append(rules, rule{name: name, param: param})
+	}
+	return rules, nil
+}
+
+func validateStruct(v reflect.Value, prefix string, errs *[]dto.FieldError) {
+	for _, f := range fieldsOf(v.Type()) {
+		path := f.name
+		if prefix != "" && path != "" {
+			path = prefix + "." + path
+		} else if path == "" {
+			// Embedded struct without a JSON name: its fields are promoted.
+			path = prefix
+		}
+		validateValue(v.Field(f.index), path, f.rules, errs)
+	}
+}
+
+// validateValue applies rules to v, then descends into structs and slices
+// of structs.
+func validateValue(v reflect.Value, path string, rules []rule, errs *[]dto.FieldError) {
+	for i, r := range rules {
+		switch r.name {
+		case "omitempty":
+			if isZero(indirect(v)) {
+				return
+			}
+			continue
+		case "required":
+			if isZero(indirect(v)) {
+				*errs = append(*errs, dto.FieldError{Field: path, Code: apierr.FieldRequired})
+				return
+			}
+			continue
+		case "dive":
+			v = indirect(v)
+			if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+				for j := 0; j < v.Len(); j++ {
+					validateValue(v.Index(j), fmt.Sprintf("%s[%d]", path, j), rules[i+1:], errs)
+				}
+			}
+			return
+		}
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			// An absent optional field: only required applies. A present
+			// one is checked even when empty, unless omitempty is set.
+			return
+		}
+		check, _ := lookup(r.name)
+		if code, ok := check(indirect(v), r.param); !ok {
+			*errs = append(*errs, dto.FieldError{Field: path, Code: code, Param: displayParam(r)})
+			return
+		}
+	}
+
+	v = indirect(v)
+	switch v.Kind() {
+	case reflect.Struct:
+		validateStruct(v, path, errs)
+	case reflect.Slice, reflect.Array:
+		if indirectType(v.Type().Elem()).Kind() == reflect.Struct {
+			for j := 0; j < v.Len(); j++ {
+				validateValue(v.Index(j), fmt.Sprintf("%s[%d]", path, j), nil, errs)
+			}
+		}
+	}
+}
+
+// isZero reports whether v is absent: a nil pointer, slice or map, an empty
+// or whitespace-only string, or a zero value. omitempty and required look
+// through pointers, so a pointer to "" is empty.
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Invalid:
+		return true
+	}
+	return v.IsZero()
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// displayParam is the rule's argument as shown to users: the options of
+// oneof and registered enums become a comma-separated list.
+func displayParam(r rule) string {
+	if r.name == "oneof" {
+		return strings.Join(strings.Fields(r.param), ", ")
+	}
+	if values := enumValues(r.name); values != nil {
+		return strings.Join(values, ", ")
+	}
+	return r.param
+}
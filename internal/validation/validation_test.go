@@ -0,0 +1,231 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/apierr"
+	"github.com/ahmetcoskunkizilkaya/unified-backend/internal/dto"
+)
+
+// fieldErrors returns the field errors of a validation failure, or nil.
+func fieldErrors(t *testing.T, err error) []dto.FieldError {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var e *apierr.Error
+	if !errors.As(err, &e) || e.Code != apierr.CodeValidation {
+		t.Fatalf("err = %v, want a validation *apierr.Error", err)
+	}
+	return e.Fields
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		tag   string
+		value interface{}
+		code  string // "" when valid
+	}{
+		{"min=3", "abc", ""},
+		{"min=3", "ab", apierr.FieldTooShort},
+		{"min=3", "äöü", ""},
+		{"min=2", []int{1}, apierr.FieldTooFew},
+		{"min=2", 1, apierr.FieldTooSmall},
+		{"max=3", "abcd", apierr.FieldTooLong},
+		{"max=3", "日本語", ""},
+		{"max=2", []string{"a", "b", "c"}, apierr.FieldTooMany},
+		{"max=2", map[string]int{"a": 1}, ""},
+		{"max=10", 10.5, apierr.FieldTooLarge},
+		{"max=10", uint(10), ""},
+		{"gte=1", 1, ""},
+		{"gte=1", 0, apierr.FieldTooSmall},
+		{"gte=1", "a", apierr.FieldInvalid},
+		{"lte=5", 6, apierr.FieldTooLarge},
+		{"lte=5", 5.0, ""},
+		{"gt=0", 0, apierr.FieldTooSmall},
+		{"gt=0", 0.1, ""},
+		{"lt=10", 10, apierr.FieldTooLarge},
+		{"lt=10", 9, ""},
+		{"len=2", "ab", ""},
+		{"len=2", "abc", apierr.FieldWrongLength},
+		{"len=2", []int{1}, apierr.FieldWrongLength},
+		{"oneof=gratitude bullet word", "bullet", ""},
+		{"oneof=gratitude bullet word", "essay", apierr.FieldNotAllowed},
+		{"oneof=1 2 3", 2, ""},
+		{"oneof=1 2 3", 4, apierr.FieldNotAllowed},
+		{"oneof=a b", 1.5, apierr.FieldInvalid},
+		{"email", "a@example.com", ""},
+		{"email", "a@localhost", apierr.FieldInvalidEmail},
+		{"email", "Jane <a@example.com>", apierr.FieldInvalidEmail},
+		{"email", "not-an-email", apierr.FieldInvalidEmail},
+		{"email", 42, apierr.FieldInvalid},
+		{"url", "http://example.com/x", ""},
+		{"url", "example.com", apierr.FieldInvalidURL},
+		{"https_url", "https://example.com", ""},
+		{"https_url", "http://example.com", apierr.FieldInsecureURL},
+		{"https_url", "::", apierr.FieldInvalidURL},
+		{"uuid", "6ba7b810-9dad-11d1-80b4-00c04fd430c8", ""},
+		{"uuid", "6ba7b810", apierr.FieldInvalidUUID},
+		{"latitude", 41.0, ""},
+		{"latitude", 90.5, apierr.FieldInvalidLatitude},
+		{"latitude", "41", apierr.FieldInvalidLatitude},
+		{"longitude", -180.0, ""},
+		{"longitude", 181.0, apierr.FieldInvalidLongitude},
+		{"timezone", "Europe/Istanbul", ""},
+		{"timezone", "Mars/Olympus", apierr.FieldInvalidTimezone},
+		{"locale", "tr", ""},
+		{"locale", "xx-nope", apierr.FieldInvalidLocale},
+		{"password", "Sup3rsecret", ""},
+		{"password", "short1A", apierr.FieldWeakPassword},
+		{"password", "nouppercase1", apierr.FieldWeakPassword},
+		{"password", "NoDigitsHere", apierr.FieldWeakPassword},
+		{"password", strings.Repeat("A1", 37), apierr.FieldWeakPassword},
+		{"required,max=3", "abcd", apierr.FieldTooLong},
+		{"max=3,min=1", "", apierr.FieldTooShort},
+	}
+	for _, tt := range tests {
+		fields := fieldErrors(t, Var("f", tt.value, tt.tag))
+		var got string
+		if len(fields) > 0 {
+			got = fields[0].Code
+		}
+		if got != tt.code {
+			t.Errorf("%s on %#v: code = %q, want %q", tt.tag, tt.value, got, tt.code)
+		}
+	}
+}
+
+func TestRequiredAndOmitempty(t *testing.T) {
+	empty := ""
+	blank := "   "
+	tests := []struct {
+		name  string
+		tag   string
+		value interface{}
+		code  string
+	}{
+		{"required string", "required", "", apierr.FieldRequired},
+		{"required whitespace", "required", "  ", apierr.FieldRequired},
+		{"required zero int", "required", 0, apierr.FieldRequired},
+		{"required nil pointer", "required", (*string)(nil), apierr.FieldRequired},
+		{"required pointer to empty", "required", &empty, apierr.FieldRequired},
+		{"required pointer to blank", "required", &blank, apierr.FieldRequired},
+		{"required pointer to value", "required", strPtr("x"), ""},
+		{"required empty slice", "required", []string{}, apierr.FieldRequired},
+		{"nil pointer skips rules", "min=3", (*string)(nil), ""},
+		{"present empty pointer is checked", "min=3", &empty, apierr.FieldTooShort},
+		{"omitempty empty string", "omitempty,email", "", ""},
+		{"omitempty value", "omitempty,email", "nope", apierr.FieldInvalidEmail},
+		{"omitempty pointer to empty", "omitempty,min=3", &empty, ""},
+		{"omitempty pointer to value", "omitempty,min=3", strPtr("ab"), apierr.FieldTooShort},
+	}
+	for _, tt := range tests {
+		fields := fieldErrors(t, Var("f", tt.value, tt.tag))
+		var got string
+		if len(fields) > 0 {
+			got = fields[0].Code
+		}
+		if got != tt.code {
+			t.Errorf("%s: code = %q, want %q", tt.name, got, tt.code)
+		}
+	}
+}
+
+func TestStructPaths(t *testing.T) {
+	type item struct {
+		Name string `json:"name" validate:"required,max=5"`
+	}
+	type Embedded struct {
+		Note string `json:"note" validate:"max=3"`
+	}
+	type request struct {
+		Embedded
+		Title   string   `json:"title" validate:"required"`
+		Tags    []string `json:"tags" validate:"max=2,dive,max=3"`
+		Items   []item   `json:"items" validate:"max=3"`
+		Main    *item    `json:"main"`
+		Skipped string   `json:"-" validate:"required"`
+		NoJSON  string   `validate:"required"`
+		hidden  string
+	}
+
+	req := request{
+		Embedded: Embedded{Note: "long"},
+		Tags:     []string{"ok", "toolong"},
+		Items:    []item{{Name: "fine"}, {Name: ""}, {Name: "toolong"}},
+		Main:     &item{},
+		NoJSON:   "set",
+	}
+	got := map[string]string{}
+	for _, f := range fieldErrors(t, Struct(&req)) {
+		got[f.Field] = f.Code
+	}
+	want := map[string]string{
+		"note":          apierr.FieldTooLong,
+		"title":         apierr.FieldRequired,
+		"tags[1]":       apierr.FieldTooLong,
+		"items[1].name": apierr.FieldRequired,
+		"items[2].name": apierr.FieldTooLong,
+		"main.name":     apierr.FieldRequired,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("field errors = %v, want %v", got, want)
+	}
+
+	req = request{Title: "t", Tags: []string{"a", "b", "c"}, NoJSON: "set"}
+	fields := fieldErrors(t, Struct(req))
+	if len(fields) != 1 || fields[0].Field != "tags" || fields[0].Code != apierr.FieldTooMany || fields[0].Param != "2" {
+		t.Errorf("over-long slice: %+v, want tags too_many with param 2", fields)
+	}
+
+	if err := Struct((*request)(nil)); err != nil {
+		t.Errorf("nil struct pointer: %v", err)
+	}
+}
+
+func TestEnums(t *testing.T) {
+	RegisterEnum("test_fruit", "apple", "pear")
+
+	if err := Var("fruit", "pear", "test_fruit"); err != nil {
+		t.Errorf("registered value rejected: %v", err)
+	}
+	fields := fieldErrors(t, Var("fruit", "plum", "test_fruit"))
+	if len(fields) != 1 || fields[0].Code != apierr.FieldNotAllowed || fields[0].Param != "apple, pear" {
+		t.Errorf("unregistered value: %+v, want not_allowed listing the values", fields)
+	}
+
+	fields = fieldErrors(t, Var("kind", "c", "oneof=a  b"))
+	if len(fields) != 1 || fields[0].Param != "a, b" {
+		t.Errorf("oneof param = %+v, want \"a, b\"", fields)
+	}
+}
+
+func TestCheckTag(t *testing.T) {
+	for _, tag := range []string{"", "-", "required,email,max=255", "omitempty,max=5000", "max=10,dive,max=30", "oneof=a b"} {
+		if err := CheckTag(tag); err != nil {
+			t.Errorf("CheckTag(%q) = %v, want nil", tag, err)
+		}
+	}
+	for _, tag := range []string{"requird", "max=ten", "min", "oneof=", "required,emial"} {
+		if err := CheckTag(tag); err == nil {
+			t.Errorf("CheckTag(%q) = nil, want an error", tag)
+		}
+	}
+}
+
+func TestBadTagPanicsOnFirstUse(t *testing.T) {
+	type bad struct {
+		Name string `json:"name" validate:"requird"`
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("validating a struct with an unknown rule did not panic")
+		}
+	}()
+	_ = Struct(bad{})
+}
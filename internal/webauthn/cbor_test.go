@@ -0,0 +1,123 @@
+package webauthn
+
+import (
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestDecodeCBOR uses the examples from RFC 8949 Appendix A that fall in the
+// supported subset.
+func TestDecodeCBOR(t *testing.T) {
+	tests := []struct {
+		in   string
+		want interface{}
+	}{
+		{"00", int64(0)},
+		{"17", int64(23)},
+		{"1818", int64(24)},
+		{"190100", int64(256)},
+		{"1a000f4240", int64(1000000)},
+		{"1b000000e8d4a51000", int64(1000000000000)},
+		{"20", int64(-1)},
+		{"3863", int64(-100)},
+		{"3903e7", int64(-1000)},
+		{"40", []byte(nil)},
+		{"4401020304", []byte{1, 2, 3, 4}},
+		{"60", ""},
+		{"6161", "a"},
+		{"6449455446", "IETF"},
+		{"62c3bc", "ü"},
+		{"80", []interface{}{}},
+		{"83010203", []interface{}{int64(1), int64(2), int64(3)}},
+		{"8301820203820405", []interface{}{int64(1), []interface{}{int64(2), int64(3)}, []interface{}{int64(4), int64(5)}}},
+		{"a0", map[interface{}]interface{}{}},
+		{"a201020304", map[interface{}]interface{}{int64(1): int64(2), int64(3): int64(4)}},
+		{"a26161016162820203", map[interface{}]interface{}{"a": int64(1), "b": []interface{}{int64(2), int64(3)}}},
+		{"f4", false},
+		{"f5", true},
+		{"f6", nil},
+	}
+	for _, tt := range tests {
+		data, _ := hex.DecodeString(tt.in)
+		got, n, err := decodeCBOR(data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.in, err)
+			continue
+		}
+		if n != len(data) {
+			t.Errorf("%s: used %d bytes, want %d", tt.in, n, len(data))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeCBORStopsAtFirstItem(t *testing.T) {
+	got, n, err := decodeCBOR([]byte{0x01, 0x02, 0x03})
+	if err != nil || got != int64(1) || n != 1 {
+		t.Fatalf("decode = %v, %d, %v; want 1 using 1 byte", got, n, err)
+	}
+}
+
+func TestDecodeCBORRejects(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        string
+		truncated bool
+	}{
+		{"empty", "", true},
+		{"missing 1-byte argument", "18", true},
+		{"short 2-byte argument", "1901", true},
+		{"short 4-byte argument", "1a000001", true},
+		{"short 8-byte argument", "1b00000000000001", true},
+		{"short byte string", "440102", true},
+		{"short text string", "64494554", true},
+		{"huge byte string length", "5bffffffffffffffff", true},
+		{"short array", "830102", true},
+		{"huge array length", "9bffffffffffffffff00", true},
+		{"map missing value", "a101", true},
+		{"huge map length", "bb000000010000000000", true},
+		{"integer overflow", "1bffffffffffffffff", false},
+		{"negative overflow", "3bffffffffffffffff", false},
+		{"indefinite byte string", "5f4101ff", false},
+		{"indefinite array", "9f01ff", false},
+		{"tag", "c074323031332d30332d32315432303a30343a30305a", false},
+		{"half float", "f93c00", false},
+		{"undefined", "f7", false},
+		{"byte string map key", "a1400000", false},
+		{"array map key", "a18000", false},
+	}
+	for _, tt := range tests {
+		data, _ := hex.DecodeString(tt.in)
+		_, _, err := decodeCBOR(data)
+		if err == nil {
+			t.Errorf("%s: decoded without error", tt.name)
+			continue
+		}
+		if truncated := errors.Is(err, errCBORTruncated); truncated != tt.truncated {
+			t.Errorf("%s: err = %v, truncated = %v, want %v", tt.name, err, truncated, tt.truncated)
+		}
+	}
+}
+
+func TestDecodeCBORDepth(t *testing.T) {
+	nested := func(depth int) []byte {
+		// depth one-element arrays around a 0.
+		return append([]byte(strings.Repeat("\x81", depth)), 0x00)
+	}
+	if _, _, err := decodeCBOR(nested(maxCBORDepth)); err != nil {
+		t.Errorf("depth %d: %v", maxCBORDepth, err)
+	}
+	if _, _, err := decodeCBOR(nested(maxCBORDepth + 1)); err == nil || !strings.Contains(err.Error(), "too deep") {
+		t.Errorf("depth %d: err = %v, want nesting too deep", maxCBORDepth+1, err)
+	}
+	// Maps count towards the same limit.
+	deepMap := append([]byte(strings.Repeat("\xa1\x01", maxCBORDepth+1)), 0x00)
+	if _, _, err := decodeCBOR(deepMap); err == nil {
+		t.Error("deeply nested maps decoded without error")
+	}
+}
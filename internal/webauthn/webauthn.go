@@ -0,0 +1,324 @@
+// Package webauthn verifies passkey (WebAuthn Level 2) registrations and
+// assertions for a relying party. It checks client data, authenticator data
+// and signatures; attestation statements are not verified, as the server
+// requests "none" attestation and does not restrict authenticator models.
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers for the keys this package accepts.
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// SupportedAlgorithms lists the accepted algorithms in order of preference,
+// for pubKeyCredParams.
+var SupportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+// Authenticator data flags.
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+var ErrVerification = errors.New("webauthn verification failed")
+
+// RelyingParty identifies the server to authenticators. Origins are the
+// exact client origins allowed, e.g. "https://example.com" or an Android
+// "android:apk-key-hash:..." origin.
+type RelyingParty struct {
+	ID      string
+	Name    string
+	Origins []string
+}
+
+// Encode is the unpadded base64url encoding WebAuthn uses for binary values.
+func Encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode accepts base64url with or without padding.
+func Decode(s string) ([]byte, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.URLEncoding.DecodeString(s)
+}
+
+// NewChallenge returns a random 32-byte challenge, encoded.
+func NewChallenge() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return Encode(buf), nil
+}
+
+func fail(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrVerification, fmt.Sprintf(format, args...))
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// checkClientData verifies clientDataJSON against the ceremony type and the
+// expected challenge.
+func (rp *RelyingParty) checkClientData(raw []byte, typ, challenge string) error {
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return fail("malformed client data")
+	}
+	if cd.Type != typ {
+		return fail("unexpected client data type %q", cd.Type)
+	}
+	if cd.Challenge != challenge {
+		return fail("challenge mismatch")
+	}
+	for _, o := range rp.Origins {
+		if cd.Origin == o {
+			return nil
+		}
+	}
+	return fail("origin %q not allowed", cd.Origin)
+}
+
+type authenticatorData struct {
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    []byte // COSE_Key, only present on registration
+}
+
+func (rp *RelyingParty) parseAuthenticatorData(data []byte, requireUV bool) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, fail("authenticator data too short")
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(data[:32], rpIDHash[:]) {
+		return nil, fail("rp ID hash mismatch")
+	}
+	ad := &authenticatorData{
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if ad.flags&flagUserPresent == 0 {
+		return nil, fail("user not present")
+	}
+	if requireUV && ad.flags&flagUserVerified == 0 {
+		return nil, fail("user not verified")
+	}
+	if ad.flags&flagAttestedData == 0 {
+		return ad, nil
+	}
+
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, fail("attested credential data too short")
+	}
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLen == 0 || len(rest) < idLen {
+		return nil, fail("bad credential ID length")
+	}
+	ad.credentialID = append([]byte(nil), rest[:idLen]...)
+	rest = rest[idLen:]
+	_, n, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, fail("bad credential public key: %v", err)
+	}
+	ad.publicKey = append([]byte(nil), rest[:n]...)
+	return ad, nil
+}
+
+// Credential is a newly registered passkey.
+type Credential struct {
+	ID        []byte
+	PublicKey []byte // COSE_Key
+	Algorithm int64
+	SignCount uint32
+}
+
+// VerifyRegistration checks a navigator.credentials.create() response:
+// clientDataJSON and attestationObject as sent by the client, and the
+// challenge issued for the ceremony.
+func (rp *RelyingParty) VerifyRegistration(clientDataJSON, attestationObject []byte, challenge string, requireUV bool) (*Credential, error) {
+	if err := rp.checkClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+	obj, _, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return nil, fail("malformed attestation object: %v", err)
+	}
+	m, ok := obj.(map[interface{}]interface{})
+	if !ok {
+		return nil, fail("malformed attestation object")
+	}
+	authData, ok := m["authData"].([]byte)
+	if !ok {
+		return nil, fail("attestation object has no authData")
+	}
+	ad, err := rp.parseAuthenticatorData(authData, requireUV)
+	if err != nil {
+		return nil, err
+	}
+	if ad.credentialID == nil {
+		return nil, fail("no attested credential data")
+	}
+	alg, err := coseAlgorithm(ad.publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Credential{
+		ID:        ad.credentialID,
+		PublicKey: ad.publicKey,
+		Algorithm: alg,
+		SignCount: ad.signCount,
+	}, nil
+}
+
+// VerifyAssertion checks a navigator.credentials.get() response against the
+// stored credential and returns the new signature counter. storedCount is
+// the last counter seen; a counter that does not advance suggests a cloned
+// authenticator. Authenticators that do not count always report zero.
+func (rp *RelyingParty) VerifyAssertion(publicKey []byte, storedCount uint32, clientDataJSON, authenticatorData, signature []byte, challenge string, requireUV bool) (uint32, error) {
+	if err := rp.checkClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+	ad, err := rp.parseAuthenticatorData(authenticatorData, requireUV)
+	if err != nil {
+		return 0, err
+	}
+	clientHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte(nil), authenticatorData...), clientHash[:]...)
+	if err := verifySignature(publicKey, signed, signature); err != nil {
+		return 0, err
+	}
+	if (ad.signCount != 0 || storedCount != 0) && ad.signCount <= storedCount {
+		return 0, fail("signature counter did not advance")
+	}
+	return ad.signCount, nil
+}
+
+// COSE_Key labels.
+const (
+	coseKty = 1
+	coseAlg = 3
+	coseCrv = -1
+	coseX   = -2
+	coseY   = -3
+	coseN   = -1
+	coseE   = -2
+)
+
+func parseCOSE(raw []byte) (map[interface{}]interface{}, error) {
+	v, _, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, fail("malformed public key: %v", err)
+	}
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, fail("malformed public key")
+	}
+	return m, nil
+}
+
+func coseAlgorithm(raw []byte) (int64, error) {
+	m, err := parseCOSE(raw)
+	if err != nil {
+		return 0, err
+	}
+	alg, _ := m[int64(coseAlg)].(int64)
+	for _, a := range SupportedAlgorithms {
+		if a == alg {
+			// Parse once now so unusable keys are rejected at registration.
+			if _, err := publicKeyFromCOSE(m); err != nil {
+				return 0, err
+			}
+			return alg, nil
+		}
+	}
+	return 0, fail("unsupported algorithm %d", alg)
+}
+
+func publicKeyFromCOSE(m map[interface{}]interface{}) (crypto.PublicKey, error) {
+	alg, _ := m[int64(coseAlg)].(int64)
+	switch alg {
+	case AlgES256:
+		x, _ := m[int64(coseX)].([]byte)
+		y, _ := m[int64(coseY)].([]byte)
+		if crv, _ := m[int64(coseCrv)].(int64); crv != 1 || len(x) != 32 || len(y) != 32 {
+			return nil, fail("bad EC2 key")
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, fail("EC2 point not on curve")
+		}
+		return key, nil
+	case AlgEdDSA:
+		x, _ := m[int64(coseX)].([]byte)
+		if crv, _ := m[int64(coseCrv)].(int64); crv != 6 || len(x) != ed25519.PublicKeySize {
+			return nil, fail("bad OKP key")
+		}
+		return ed25519.PublicKey(x), nil
+	case AlgRS256:
+		n, _ := m[int64(coseN)].([]byte)
+		e, _ := m[int64(coseE)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, fail("bad RSA key")
+		}
+		exp := 0
+		for _, b := range e {
+			exp = exp<<8 | int(b)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exp}, nil
+	}
+	return nil, fail("unsupported algorithm %d", alg)
+}
+
+func verifySignature(rawKey, signed, sig []byte) error {
+	m, err := parseCOSE(rawKey)
+	if err != nil {
+		return err
+	}
+	pub, err := publicKeyFromCOSE(m)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(signed)
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], sig) {
+			return fail("bad signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, signed, sig) {
+			return fail("bad signature")
+		}
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) != nil {
+			return fail("bad signature")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+var testRP = &RelyingParty{ID: "example.com", Name: "Example", Origins: []string{"https://example.com"}}
+
+const testChallenge = "AAECAwQFBgcICQoLDA0ODw"
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// TestVerifyAssertionKnownAnswer checks a fixed Ed25519 assertion for
+// example.com. The authenticator data starts with SHA-256("example.com"),
+// as in the WebAuthn spec's examples.
+func TestVerifyAssertionKnownAnswer(t *testing.T) {
+	pub := mustHex("320abf32f760fcef81d2a2071acb41975d197244c164ff2ab5406688b1a59d2c")
+	authData := mustHex("a379a6f6eeafb9a55e378c118034e2751e682fab9f2d30ab13d2125586ce1947" + "05" + "00000007")
+	clientData := []byte(`{"type":"webauthn.get","challenge":"AAECAwQFBgcICQoLDA0ODw","origin":"https://example.com"}`)
+	sig := mustHex("f2dbf3d85d87e87b37b391c2d196c8425fd4b919dd932bb269cbabbd2c8738302a9549a22e8e748f92c4b746bcab9da4e0d20e6d7e35a0acb8daaa740a8fef04")
+	key := coseOKP(pub)
+
+	count, err := testRP.VerifyAssertion(key, 6, clientData, authData, sig, testChallenge, true)
+	if err != nil || count != 7 {
+		t.Fatalf("VerifyAssertion = %d, %v; want counter 7", count, err)
+	}
+
+	tampered := append([]byte(nil), sig...)
+	tampered[10] ^= 0x01
+	if _, err := testRP.VerifyAssertion(key, 6, clientData, authData, tampered, testChallenge, true); !errors.Is(err, ErrVerification) {
+		t.Errorf("tampered signature: err = %v", err)
+	}
+	// Flipping a flag changes the signed bytes.
+	flipped := append([]byte(nil), authData...)
+	flipped[32] |= flagAttestedData >> 1
+	if _, err := testRP.VerifyAssertion(key, 6, clientData, flipped, sig, testChallenge, true); !errors.Is(err, ErrVerification) {
+		t.Errorf("altered authenticator data: err = %v", err)
+	}
+}
+
+func TestVerifyAssertionCounter(t *testing.T) {
+	a := newTestAuthenticator(t)
+	tests := []struct {
+		stored, reported uint32
+		ok               bool
+	}{
+		{0, 0, true}, // authenticator without a counter
+		{0, 1, true},
+		{6, 7, true},
+		{7, 7, false},
+		{7, 3, false},
+		{7, 0, false},
+	}
+	for _, tt := range tests {
+		clientData, authData, sig := a.assert(t, testRP.ID, testRP.Origins[0], flagUserPresent|flagUserVerified, tt.reported)
+		count, err := testRP.VerifyAssertion(a.cose, tt.stored, clientData, authData, sig, testChallenge, true)
+		if tt.ok && (err != nil || count != tt.reported) {
+			t.Errorf("stored %d, reported %d: %d, %v; want %d", tt.stored, tt.reported, count, err, tt.reported)
+		}
+		if !tt.ok && !errors.Is(err, ErrVerification) {
+			t.Errorf("stored %d, reported %d: err = %v, want a counter regression", tt.stored, tt.reported, err)
+		}
+	}
+}
+
+func TestVerifyAssertionRejects(t *testing.T) {
+	a := newTestAuthenticator(t)
+	up := byte(flagUserPresent)
+	uv := byte(flagUserPresent | flagUserVerified)
+	tests := []struct {
+		name      string
+		rpID      string
+		origin    string
+		flags     byte
+		challenge string
+		requireUV bool
+	}{
+		{"rp ID mismatch", "evil.example", "https://example.com", uv, testChallenge, true},
+		{"rp ID suffix", "sub.example.com", "https://example.com", uv, testChallenge, true},
+		{"origin mismatch", "example.com", "https://evil.example", uv, testChallenge, true},
+		{"origin scheme", "example.com", "http://example.com", uv, testChallenge, true},
+		{"challenge mismatch", "example.com", "https://example.com", uv, "b3RoZXI", true},
+		{"user not present", "example.com", "https://example.com", flagUserVerified, testChallenge, false},
+		{"user not verified", "example.com", "https://example.com", up, testChallenge, true},
+	}
+	for _, tt := range tests {
+		clientData, authData, sig := a.assert(t, tt.rpID, tt.origin, tt.flags, 1)
+		if _, err := testRP.VerifyAssertion(a.cose, 0, clientData, authData, sig, tt.challenge, tt.requireUV); !errors.Is(err, ErrVerification) {
+			t.Errorf("%s: err = %v, want ErrVerification", tt.name, err)
+		}
+	}
+
+	// The same assertion passes without UV when it isn't required.
+	clientData, authData, sig := a.assert(t, testRP.ID, testRP.Origins[0], up, 1)
+	if _, err := testRP.VerifyAssertion(a.cose, 0, clientData, authData, sig, testChallenge, false); err != nil {
+		t.Errorf("presence-only assertion: %v", err)
+	}
+	// Registration client data is not an assertion.
+	createData := clientDataJSON("webauthn.create", testChallenge, testRP.Origins[0])
+	if _, err := testRP.VerifyAssertion(a.cose, 0, createData, authData, sig, testChallenge, false); !errors.Is(err, ErrVerification) {
+		t.Errorf("webauthn.create client data: err = %v", err)
+	}
+	for _, short := range [][]byte{nil, authData[:32], authData[:36]} {
+		if _, err := testRP.VerifyAssertion(a.cose, 0, clientData, short, sig, testChallenge, false); !errors.Is(err, ErrVerification) {
+			t.Errorf("%d bytes of authenticator data: err = %v", len(short), err)
+		}
+	}
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	a := newTestAuthenticator(t)
+	credID := []byte("credential-1")
+	clientData := clientDataJSON("webauthn.create", testChallenge, testRP.Origins[0])
+	uv := byte(flagUserPresent | flagUserVerified | flagAttestedData)
+
+	cred, err := testRP.VerifyRegistration(clientData, attestationObject(authenticatorDataFor(testRP.ID, uv, 0, credID, a.cose)), testChallenge, true)
+	if err != nil {
+		t.Fatalf("VerifyRegistration: %v", err)
+	}
+	if string(cred.ID) != string(credID) || string(cred.PublicKey) != string(a.cose) || cred.Algorithm != AlgES256 {
+		t.Errorf("credential = %+v", cred)
+	}
+
+	offCurve := coseEC2(make([]byte, 32), append(make([]byte, 31), 1))
+	badCrv := append([]byte(nil), a.cose...)
+	// crv P-384 with 32-byte coordinates
+	badCrv[6] = 0x02
+	// {alg: RS256} without a modulus, and {alg: ES384}
+	noModulus := []byte{0xa1, 0x03, 0x39, 0x01, 0x00}
+	unsupported := []byte{0xa1, 0x03, 0x38, 0x22}
+	tests := []struct {
+		name       string
+		clientData []byte
+		authData   []byte
+	}{
+		{"get client data", clientDataJSON("webauthn.get", testChallenge, testRP.Origins[0]), authenticatorDataFor(testRP.ID, uv, 0, credID, a.cose)},
+		{"origin mismatch", clientDataJSON("webauthn.create", testChallenge, "https://evil.example"), authenticatorDataFor(testRP.ID, uv, 0, credID, a.cose)},
+		{"rp ID mismatch", clientData, authenticatorDataFor("evil.example", uv, 0, credID, a.cose)},
+		{"no attested data", clientData, authenticatorDataFor(testRP.ID, uv&^flagAttestedData, 0, nil, nil)},
+		{"user not verified", clientData, authenticatorDataFor(testRP.ID, flagUserPresent|flagAttestedData, 0, credID, a.cose)},
+		{"empty credential ID", clientData, authenticatorDataFor(testRP.ID, uv, 0, []byte{}, a.cose)},
+		{"truncated public key", clientData, authenticatorDataFor(testRP.ID, uv, 0, credID, a.cose[:len(a.cose)-5])},
+		{"point off the curve", clientData, authenticatorDataFor(testRP.ID, uv, 0, credID, offCurve)},
+		{"wrong curve", clientData, authenticatorDataFor(testRP.ID, uv, 0, credID, badCrv)},
+		{"RSA key without modulus", clientData, authenticatorDataFor(testRP.ID, uv, 0, credID, noModulus)},
+		{"unsupported algorithm", clientData, authenticatorDataFor(testRP.ID, uv, 0, credID, unsupported)},
+	}
+	for _, tt := range tests {
+		if _, err := testRP.VerifyRegistration(tt.clientData, attestationObject(tt.authData), testChallenge, true); !errors.Is(err, ErrVerification) {
+			t.Errorf("%s: err = %v, want ErrVerification", tt.name, err)
+		}
+	}
+
+	// Credential ID length larger than the data that follows.
+	ad := authenticatorDataFor(testRP.ID, uv, 0, credID, a.cose)
+	binary.BigEndian.PutUint16(ad[37+16:], 0xffff)
+	if _, err := testRP.VerifyRegistration(clientData, attestationObject(ad), testChallenge, true); !errors.Is(err, ErrVerification) {
+		t.Errorf("overlong credential ID: err = %v", err)
+	}
+	obj := attestationObject(authenticatorDataFor(testRP.ID, uv, 0, credID, a.cose))
+	if _, err := testRP.VerifyRegistration(clientData, obj[:len(obj)-10], testChallenge, true); !errors.Is(err, ErrVerification) {
+		t.Errorf("truncated attestation object: err = %v", err)
+	}
+}
+
+// testAuthenticator is a software ES256 passkey.
+type testAuthenticator struct {
+	key  *ecdsa.PrivateKey
+	cose []byte
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, y := make([]byte, 32), make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+	return &testAuthenticator{key: key, cose: coseEC2(x, y)}
+}
+
+// assert signs an assertion for testChallenge.
+func (a *testAuthenticator) assert(t *testing.T, rpID, origin string, flags byte, count uint32) (clientData, authData, sig []byte) {
+	t.Helper()
+	clientData = clientDataJSON("webauthn.get", testChallenge, origin)
+	authData = authenticatorDataFor(rpID, flags, count, nil, nil)
+	hash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), hash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clientData, authData, sig
+}
+
+func clientDataJSON(typ, challenge, origin string) []byte {
+	return []byte(`{"type":"` + typ + `","challenge":"` + challenge + `","origin":"` + origin + `","crossOrigin":false}`)
+}
+
+// authenticatorDataFor builds authenticator data; credID non-nil adds
+// attested credential data with a zero AAGUID.
+func authenticatorDataFor(rpID string, flags byte, count uint32, credID, coseKey []byte) []byte {
+	hash := sha256.Sum256([]byte(rpID))
+	ad := append(hash[:], flags)
+	ad = binary.BigEndian.AppendUint32(ad, count)
+	if credID != nil {
+		ad = append(ad, make([]byte, 16)...)
+		ad = binary.BigEndian.AppendUint16(ad, uint16(len(credID)))
+		ad = append(ad, credID...)
+		ad = append(ad, coseKey...)
+	}
+	return ad
+}
+
+// attestationObject wraps authData in a "none" attestation:
+// {"fmt": "none", "attStmt": {}, "authData": authData}.
+func attestationObject(authData []byte) []byte {
+	obj := mustHex("a3" + "63666d74" + "646e6f6e65" + "6761747453746d74" + "a0" + "6861757468446174" + "61" + "59")
+	obj = binary.BigEndian.AppendUint16(obj, uint16(len(authData)))
+	return append(obj, authData...)
+}
+
+// coseEC2 encodes a P-256 ES256 COSE_Key.
+func coseEC2(x, y []byte) []byte {
+	key := mustHex("a5" + "0102" + "0326" + "2001" + "215820")
+	key = append(key, x...)
+	key = append(key, 0x22, 0x58, 0x20)
+	return append(key, y...)
+}
+
+// coseOKP encodes an Ed25519 EdDSA COSE_Key.
+func coseOKP(x []byte) []byte {
+	key := mustHex("a4" + "0101" + "0327" + "2006" + "215820")
+	return append(key, x[:ed25519.PublicKeySize]...)
+}